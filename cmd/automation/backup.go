@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"powerhive/internal/app"
+	"powerhive/internal/config"
+)
+
+// runBackup writes a SQLite snapshot, either to --out or as a timestamped
+// file in the configured backup directory, pruned like scheduled snapshots.
+func runBackup(args []string) error {
+	fs, configPath := newFlagSet("backup", "[--out FILE] [flags]")
+	out := fs.String("out", "", "write the snapshot to this file (default: a timestamped file in backup.dir)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	logger := commandLogger()
+
+	ctx := context.Background()
+	store, closeStore, err := openStore(ctx, cfg, logger, false)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	path := *out
+	if path != "" {
+		if err := store.BackupTo(ctx, path); err != nil {
+			return err
+		}
+	} else {
+		path, err = app.NewBackupScheduler(store, cfg, logger).Snapshot(ctx)
+		if err != nil {
+			return fmt.Errorf("%w; set backup.dir or pass --out", err)
+		}
+	}
+
+	fmt.Println(path)
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"powerhive/internal/config"
+	"powerhive/internal/server"
+)
+
+// runExport writes one history table to CSV, on stdout or to --out, in the
+// layout of the HTTP exports.
+func runExport(args []string) error {
+	fs, configPath := newFlagSet("export", "--table TABLE [--from TIME] [--to TIME] [--out FILE] [flags]")
+	table := fs.String("table", "", "table to export: "+strings.Join(server.ExportTables, ", "))
+	from := fs.String("from", "", "start of the period, RFC 3339 or YYYY-MM-DD (default: 30 days before --to)")
+	to := fs.String("to", "", "end of the period, RFC 3339 or YYYY-MM-DD, a date covering that whole day (default: now)")
+	out := fs.String("out", "", "write to this file instead of stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *table == "" {
+		fs.Usage()
+		return fmt.Errorf("--table is required")
+	}
+
+	fromTime, toTime, err := server.ParseExportRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	store, closeStore, err := openStore(ctx, cfg, commandLogger(), false)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	var (
+		w    io.Writer = os.Stdout
+		file *os.File
+	)
+	if *out != "" {
+		file, err = os.Create(*out)
+		if err != nil {
+			return err
+		}
+		w = file
+	}
+
+	buf := bufio.NewWriter(w)
+	err = server.ExportCSV(ctx, buf, store, *table, fromTime, toTime)
+	if err == nil {
+		err = buf.Flush()
+	}
+	// A failed close can mean the last write never reached the disk, so it
+	// must fail the export rather than leave a truncated file behind.
+	if file != nil {
+		err = errors.Join(err, file.Close())
+	}
+	return err
+}
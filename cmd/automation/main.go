@@ -1,91 +1,87 @@
+// Command powerhive runs the PowerHive daemon and its operational tasks:
+//
+//	powerhive serve                        run the daemon (the default)
+//	powerhive migrate                      install or upgrade the schema
+//	powerhive scan --subnet 10.0.0.0/24    run one discovery scan
+//	powerhive export --table plant_readings --from 2025-01-01
+//	powerhive backup                       snapshot the SQLite database
+//	powerhive simulate --from 2025-01-01   replay generation against the fleet
+//	powerhive testplant --curve sine       serve a stand-in plant API
+//	powerhive seed --miners 200            fill an empty database with demo data
+//	powerhive user --username admin        create a dashboard user (password on stdin)
+//
+// Every command reads config.json (or the file given with --config).
 package main
 
 import (
-	"context"
-	"database/sql"
 	"errors"
 	"flag"
-	"log/slog"
+	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
-
-	"powerhive/internal/app"
-	"powerhive/internal/config"
-	"powerhive/internal/database"
-
-	_ "modernc.org/sqlite"
+	"strings"
 )
 
-func main() {
-	// Parse command-line flags
-	testMode := flag.Bool("test", false, "Enable test mode (POST expected consumption to test server)")
-	testServerURL := flag.String("test-server-url", "", "Test server URL (overrides config, e.g., http://localhost:8090)")
-	flag.Parse()
-
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-
-	cfg, err := config.Load("config.json")
-	if err != nil {
-		logger.Error("load config failed", "err", err)
-		os.Exit(1)
-	}
-
-	// Override config with command-line flags
-	if *testMode {
-		cfg.Plant.TestMode = true
-		logger.Info("test mode enabled")
-	}
-	if *testServerURL != "" {
-		cfg.Plant.TestServerURL = *testServerURL
-		logger.Info("test server URL overridden", "url", *testServerURL)
-	}
-
-	db, err := sql.Open("sqlite", cfg.Database.Path)
-	if err != nil {
-		logger.Error("open database failed", "err", err)
-		os.Exit(1)
-	}
-	defer db.Close()
+// command is one powerhive subcommand. run receives the arguments after the
+// command name.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
 
-	// Configure connection pool for SQLite single-writer constraint
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
-	db.SetConnMaxLifetime(0)
+var commands = []command{
+	{"serve", "run the daemon: discovery, polling, balancing and the HTTP server", runServe},
+	{"migrate", "install or upgrade the database schema and exit", runMigrate},
+	{"scan", "run one discovery scan and print its summary", runScan},
+	{"export", "write a history table to CSV", runExport},
+	{"backup", "write a snapshot of the SQLite database", runBackup},
+	{"simulate", "replay plant generation against the fleet with the balancer's logic", runSimulate},
+	{"testplant", "serve a stand-in plant API with scripted generation for test mode", runTestPlant},
+	{"seed", "fill an empty database with a made-up fleet and history for demos", runSeed},
+	{"user", "create a dashboard user or reset its password, read from stdin", runUser},
+}
 
-	if err := db.Ping(); err != nil {
-		logger.Error("ping database failed", "err", err)
-		os.Exit(1)
-	}
+func main() {
+	args := os.Args[1:]
 
-	store, err := database.New(db)
-	if err != nil {
-		logger.Error("configure database failed", "err", err)
-		os.Exit(1)
+	// Without a command name, or with flags first, keep the daemon's
+	// original invocation working.
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	if err := store.Init(context.Background()); err != nil {
-		logger.Error("initialise schema failed", "err", err)
-		os.Exit(1)
+	if name == "help" {
+		usage()
+		return
 	}
 
-	appInstance, err := app.New(cfg, store, logger)
-	if err != nil {
-		logger.Error("initialise app failed", "err", err)
-		os.Exit(1)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "powerhive %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	logger.Info("powerhive starting", "database", cfg.Database.Path, "http_addr", cfg.HTTP.Addr)
+	fmt.Fprintf(os.Stderr, "powerhive: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
 
-	if err := appInstance.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
-		logger.Error("app terminated with error", "err", err)
-		os.Exit(1)
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: powerhive <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", cmd.name, cmd.summary)
 	}
-
-	logger.Info("powerhive stopped")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Run "powerhive <command> -h" for a command's flags.`)
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+
+	"powerhive/internal/config"
+)
+
+// runMigrate installs or upgrades the schema without starting any service,
+// so an upgrade can be applied and checked before the daemon is restarted.
+func runMigrate(args []string) error {
+	fs, configPath := newFlagSet("migrate", "[flags]")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	logger := commandLogger()
+
+	store, closeStore, err := openStore(context.Background(), cfg, logger, true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	logger.Info("schema up to date", "driver", store.Dialect(), "database", cfg.Database.Path)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"powerhive/internal/app"
+	"powerhive/internal/config"
+)
+
+// runScan runs one discovery scan, recording what it finds exactly as the
+// daemon's scheduled scans do, and prints the summary.
+func runScan(args []string) error {
+	fs, configPath := newFlagSet("scan", "[--subnet CIDR] [flags]")
+	subnet := fs.String("subnet", "", "scan only this subnet (default: every configured subnet)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	logger := commandLogger()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store, closeStore, err := openStore(ctx, cfg, logger, true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	report, err := app.NewDiscoverer(store, cfg, logger).Scan(ctx, *subnet)
+	if report.StartedAt.IsZero() {
+		return err
+	}
+	if report.Subnet == "" {
+		report.Subnet = "all configured"
+	}
+	fmt.Printf("subnet:        %s\n", report.Subnet)
+	fmt.Printf("duration:      %s\n", report.Duration.Round(time.Millisecond))
+	fmt.Printf("hosts probed:  %d\n", report.HostsProbed)
+	fmt.Printf("hosts alive:   %d\n", report.HostsAlive)
+	fmt.Printf("miners found:  %d\n", report.MinersFound)
+	fmt.Printf("miners lost:   %d\n", report.MinersLost)
+	return err
+}
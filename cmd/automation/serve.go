@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/tracing"
+	"powerhive/pkg/powerhive"
+)
+
+// runServe runs the daemon until SIGINT or SIGTERM.
+func runServe(args []string) error {
+	fs, configPath := newFlagSet("serve", "[flags]")
+	testMode := fs.Bool("test", false, "Enable test mode (POST expected consumption to test server)")
+	testServerURL := fs.String("test-server-url", "", "Test server URL (overrides config, e.g., http://localhost:8090)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// loadConfig reads the configuration file and re-applies the
+	// command-line overrides so they survive a reload.
+	loadConfig := func() (config.AppConfig, error) {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			return config.AppConfig{}, err
+		}
+		if *testMode {
+			cfg.Plant.TestMode = true
+		}
+		if *testServerURL != "" {
+			cfg.Plant.TestServerURL = *testServerURL
+		}
+		return cfg, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	logger, logLevels, logCloser, err := powerhive.NewLogger(cfg)
+	if err != nil {
+		return err
+	}
+	defer logCloser.Close()
+	slog.SetDefault(logger)
+
+	if *testMode {
+		logger.Info("test mode enabled")
+	}
+	if *testServerURL != "" {
+		logger.Info("test server URL overridden", "url", *testServerURL)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("flush traces failed", "err", err)
+		}
+	}()
+	if cfg.Tracing.Endpoint != "" {
+		logger.Info("tracing enabled", "endpoint", cfg.Tracing.Endpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
+	store, closeStore, err := openStore(context.Background(), cfg, logger, true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	engine, err := powerhive.New(
+		powerhive.WithConfig(cfg),
+		powerhive.WithConfigLoader(loadConfig),
+		powerhive.WithStore(store),
+		powerhive.WithLogger(logger),
+		powerhive.WithLogLevels(logLevels),
+	)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// SIGHUP reloads the configuration without restarting the services.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if err := engine.ReloadConfig(); err != nil {
+					logger.Error("config reload failed", "err", err)
+				}
+			}
+		}
+	}()
+
+	logger.Info("powerhive starting", "driver", store.Dialect(), "database", cfg.Database.Path, "http_addr", cfg.HTTP.Addr)
+
+	if err := engine.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	logger.Info("powerhive stopped")
+	return nil
+}
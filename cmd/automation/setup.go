@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/pkg/powerhive"
+)
+
+const defaultConfigPath = "config.json"
+
+// newFlagSet returns a flag set for the named command with the shared
+// --config flag registered.
+func newFlagSet(name, usage string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("powerhive "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: powerhive %s %s\n\n", name, usage)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", defaultConfigPath, "path to the configuration file")
+	return fs, configPath
+}
+
+// parseFlags parses args and rejects positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return nil
+}
+
+// commandLogger is the logger for one-off commands: text on stderr so it
+// does not mix with output written to stdout.
+func commandLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+}
+
+// openStore connects to the configured database. With migrate set it also
+// installs the schema and, when a key is configured, enables credential
+// encryption, which commands that write miners need. The returned function
+// closes the store and the connection pool.
+func openStore(ctx context.Context, cfg config.AppConfig, logger *slog.Logger, migrate bool) (*database.Store, func(), error) {
+	store, closeStore, err := powerhive.OpenStore(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !migrate {
+		return store, closeStore, nil
+	}
+	if err := powerhive.Migrate(ctx, store, cfg, logger); err != nil {
+		closeStore()
+		return nil, nil, err
+	}
+	return store, closeStore, nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/app"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/server"
+)
+
+// defaultSimulationRange is the history replayed when --from is not given.
+const defaultSimulationRange = 24 * time.Hour
+
+// runSimulate replays plant generation against a copy of the fleet with the
+// balancer's logic and prints the resulting consumption, preset changes and
+// curtailed energy.
+func runSimulate(args []string) error {
+	fs, configPath := newFlagSet("simulate", "[--from TIME] [--to TIME] [--profile FILE] [flags]")
+	from := fs.String("from", "", "start of the replayed history, RFC 3339 or YYYY-MM-DD (default: 24 hours before --to)")
+	to := fs.String("to", "", "end of the replayed history, RFC 3339 or YYYY-MM-DD (default: now)")
+	profile := fs.String("profile", "", "replay this CSV instead of the stored history; needs recorded_at and total_generation (kW) columns, as in the plant export")
+	steps := fs.String("steps", "", "also write each simulated cycle to this CSV file")
+	var (
+		safetyMargin optionalFloat
+		toleranceW   optionalFloat
+		kp           optionalFloat
+		ki           optionalFloat
+	)
+	fs.Var(&safetyMargin, "safety-margin", "safety margin percent (default: the stored setting)")
+	fs.Var(&toleranceW, "tolerance-w", "watts from the target within which no change is made (default: balancer.tolerance_w)")
+	fs.Var(&kp, "kp", "proportional gain for the pi strategy (default: balancer.kp)")
+	fs.Var(&ki, "ki", "integral gain for the pi strategy (default: balancer.ki)")
+	strategy := fs.String("strategy", "", "balancer strategy, greedy or pi (default: balancer.strategy)")
+	interval := fs.Duration("interval", 0, "balance cycle interval (default: intervals.balancer_seconds)")
+	cooldown := fs.Duration("cooldown", 0, "minimum time between changes to one miner (default: the balancer's cooldown)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if *strategy != "" {
+		switch s := strings.ToLower(*strategy); s {
+		case config.BalancerGreedy, config.BalancerPI:
+			cfg.Balancer.Strategy = s
+		default:
+			return fmt.Errorf("unknown strategy %q; use %s or %s", *strategy, config.BalancerGreedy, config.BalancerPI)
+		}
+	}
+	if toleranceW.set {
+		cfg.Balancer.ToleranceW = toleranceW.value
+	}
+	if kp.set {
+		cfg.Balancer.Kp = kp.value
+	}
+	if ki.set {
+		cfg.Balancer.Ki = ki.value
+	}
+	if *interval > 0 {
+		cfg.Intervals.BalancerSeconds = int(interval.Seconds())
+	}
+	if cfg.Intervals.BalancerSeconds <= 0 {
+		return fmt.Errorf("balance cycle interval must be at least one second")
+	}
+
+	logger := commandLogger()
+	ctx := context.Background()
+	store, closeStore, err := openStore(ctx, cfg, logger, false)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	req := app.SimulationRequest{Cooldown: *cooldown}
+	if safetyMargin.set {
+		req.SafetyMarginPercent = &safetyMargin.value
+	}
+
+	if *profile != "" {
+		req.Generation, err = readGenerationProfile(*profile)
+	} else {
+		req.Generation, err = loadGenerationHistory(ctx, store, *from, *to)
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := app.NewPowerBalancer(store, cfg, logger).Simulate(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	printSimulationReport(os.Stdout, report)
+	if *steps != "" {
+		return writeSimulationSteps(*steps, report.Steps)
+	}
+	return nil
+}
+
+// loadGenerationHistory reads the stored plant readings in the range.
+func loadGenerationHistory(ctx context.Context, store *database.Store, rawFrom, rawTo string) ([]app.GenerationSample, error) {
+	from, to, err := server.ParseExportRange(rawFrom, rawTo)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rawFrom) == "" {
+		from = to.Add(-defaultSimulationRange)
+	}
+
+	var samples []app.GenerationSample
+	err = store.ForEachPlantReading(ctx, from, to, func(reading database.PlantReading) error {
+		samples = append(samples, app.GenerationSample{At: reading.RecordedAt, GenerationKW: reading.TotalGeneration})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load plant readings: %w", err)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no plant readings between %s and %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	return samples, nil
+}
+
+// readGenerationProfile reads a generation profile from CSV. The header
+// names the columns; recorded_at is RFC 3339 and total_generation is in kW.
+// Other columns, such as the rest of a plant export, are ignored.
+func readGenerationProfile(path string) ([]app.GenerationSample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read profile header: %w", err)
+	}
+	atCol, kwCol := -1, -1
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case "recorded_at":
+			atCol = i
+		case "total_generation":
+			kwCol = i
+		}
+	}
+	if atCol < 0 || kwCol < 0 {
+		return nil, fmt.Errorf("profile needs recorded_at and total_generation columns")
+	}
+
+	var samples []app.GenerationSample
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read profile: %w", err)
+		}
+		if len(record) <= max(atCol, kwCol) {
+			return nil, fmt.Errorf("profile line %d: missing columns", line)
+		}
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(record[atCol]))
+		if err != nil {
+			return nil, fmt.Errorf("profile line %d: invalid recorded_at: %w", line, err)
+		}
+		kw, err := strconv.ParseFloat(strings.TrimSpace(record[kwCol]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("profile line %d: invalid total_generation: %w", line, err)
+		}
+		samples = append(samples, app.GenerationSample{At: at, GenerationKW: kw})
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("profile %s has no rows", path)
+	}
+	return samples, nil
+}
+
+func printSimulationReport(w io.Writer, report app.SimulationReport) {
+	fmt.Fprintf(w, "period:           %s to %s (%d cycles every %s)\n",
+		report.Start.Format(time.RFC3339), report.End.Format(time.RFC3339), report.Cycles, report.Interval)
+	fmt.Fprintf(w, "strategy:         %s, safety margin %.1f%%, tolerance %.0f W, cooldown %s\n",
+		report.Strategy, report.SafetyMarginPercent, report.ToleranceW, report.Cooldown)
+	fmt.Fprintf(w, "fleet:            %d online miners, %d eligible\n", report.Miners, report.MinersEligible)
+	fmt.Fprintf(w, "preset changes:   %d across %d miners\n", report.PresetChanges, report.MinersChanged)
+	fmt.Fprintf(w, "generation:       %.1f kWh\n", report.GenerationKWh)
+	fmt.Fprintf(w, "target:           %.1f kWh\n", report.TargetKWh)
+	fmt.Fprintf(w, "consumption:      %.1f kWh\n", report.ConsumptionKWh)
+	fmt.Fprintf(w, "curtailed:        %.1f kWh\n", report.CurtailedKWh)
+	fmt.Fprintf(w, "over generation:  %.1f kWh in %d cycles, peak %.0f W\n",
+		report.OverdrawKWh, report.OverdrawCycles, report.PeakOverdrawW)
+}
+
+func writeSimulationSteps(path string, steps []app.SimulationStep) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"at", "generation_kw", "target_w", "consumption_w", "preset_changes"})
+	for _, step := range steps {
+		_ = w.Write([]string{
+			step.At.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(step.GenerationKW, 'f', -1, 64),
+			strconv.FormatFloat(step.TargetW, 'f', 0, 64),
+			strconv.FormatFloat(step.ConsumptionW, 'f', 0, 64),
+			strconv.Itoa(step.PresetChanges),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// optionalFloat is a float flag that records whether it was given, so zero
+// can be told apart from the default.
+type optionalFloat struct {
+	value float64
+	set   bool
+}
+
+var _ flag.Value = (*optionalFloat)(nil)
+
+func (f *optionalFloat) String() string {
+	if !f.set {
+		return ""
+	}
+	return strconv.FormatFloat(f.value, 'f', -1, 64)
+}
+
+func (f *optionalFloat) Set(raw string) error {
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return err
+	}
+	f.value, f.set = v, true
+	return nil
+}
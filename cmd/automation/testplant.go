@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/testplant"
+)
+
+// Generation curves accepted by --curve.
+const (
+	curveWalk   = "walk"
+	curveSine   = "sine"
+	curveScript = "script"
+)
+
+// runTestPlant serves a stand-in plant API for test mode until SIGINT or
+// SIGTERM. The plant ID and token default to the configuration's plant
+// section so a daemon pointed at it needs no other changes.
+func runTestPlant(args []string) error {
+	flags, configPath := newFlagSet("testplant", "[--curve walk|sine|script] [flags]")
+	addr := flags.String("addr", ":8090", "listen address")
+	curve := flags.String("curve", curveWalk, "generation curve: walk (random walk), sine or script")
+	genMin := flags.Float64("gen-min", 0.05, "minimum generation in MW (walk, sine)")
+	genMax := flags.Float64("gen-max", 0.15, "maximum generation in MW (walk, sine)")
+	step := flags.Float64("step", 0.002, "largest change per --step-interval in MW (walk)")
+	stepInterval := flags.Duration("step-interval", 30*time.Second, "how often the walk moves (walk)")
+	period := flags.Duration("period", time.Hour, "length of one swing from midpoint back to midpoint (sine)")
+	script := flags.String("script", "", `time=MW points, interpolated and repeated, e.g. "0s=0.1,10m=0.3,20m=0.1" (script)`)
+	token := flags.String("token", "", "bearer token required on /data/latest (default: plant.api_key)")
+	plantID := flags.String("plant-id", "", "plant_id required on /data/latest (default: plant.plant_id)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	// The configuration only supplies defaults; the test plant runs
+	// without one.
+	cfg, err := config.Load(*configPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if *token == "" {
+		*token = cfg.Plant.APIKey
+	}
+	if *plantID == "" {
+		*plantID = cfg.Plant.PlantID
+	}
+
+	var generation testplant.Curve
+	switch *curve {
+	case curveWalk, curveSine:
+		if *genMin < 0 || *genMin >= *genMax {
+			return fmt.Errorf("--gen-min must be at least 0 and below --gen-max")
+		}
+		if *curve == curveWalk {
+			if *step <= 0 || *stepInterval <= 0 {
+				return fmt.Errorf("--step and --step-interval must be positive")
+			}
+			generation = &testplant.Walk{Min: *genMin, Max: *genMax, Step: *step, Interval: *stepInterval}
+		} else {
+			if *period <= 0 {
+				return fmt.Errorf("--period must be positive")
+			}
+			generation = testplant.Sine{Min: *genMin, Max: *genMax, Period: *period}
+		}
+	case curveScript:
+		s, err := testplant.ParseScript(*script)
+		if err != nil {
+			return fmt.Errorf("--script: %w", err)
+		}
+		generation = s
+	default:
+		return fmt.Errorf("unknown curve %q; use %s, %s or %s", *curve, curveWalk, curveSine, curveScript)
+	}
+
+	logger := commandLogger()
+	plant := testplant.New(testplant.Options{
+		PlantID: *plantID,
+		Token:   *token,
+		Curve:   generation,
+		Logger:  logger,
+	})
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           plant.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	base := "http://localhost"
+	if _, port, err := net.SplitHostPort(*addr); err == nil {
+		base += ":" + port
+	}
+	logger.Info("test plant listening", "addr", *addr, "curve", *curve, "plant_id", *plantID,
+		"auth", *token != "", "api_endpoint", base+"/data/latest", "test_server_url", base)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// runUser creates a dashboard user, or sets the password of an existing
+// one, reading the password from the first line of stdin. It is how the
+// first admin is made when http.require_login is on.
+func runUser(args []string) error {
+	fs, configPath := newFlagSet("user", "--username NAME [flags] < password")
+	username := fs.String("username", "", "user to create or update")
+	role := fs.String("role", "", "role of the user: admin or operator (default admin for new users)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*username) == "" {
+		return fmt.Errorf("--username is required")
+	}
+	if *role != "" && !database.ValidUserRole(*role) {
+		return fmt.Errorf("--role must be %q or %q", database.RoleAdmin, database.RoleOperator)
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("read password from stdin: %w", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	logger := commandLogger()
+
+	ctx := context.Background()
+	store, closeStore, err := openStore(ctx, cfg, logger, true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Username != strings.TrimSpace(*username) {
+			continue
+		}
+		update := database.UserUpdate{Password: &password}
+		if *role != "" {
+			update.Role = role
+		}
+		user, err := store.UpdateUser(ctx, user.ID, update)
+		if err != nil {
+			return err
+		}
+		logger.Info("user updated", "user", user.Username, "role", user.Role)
+		return nil
+	}
+
+	if *role == "" {
+		*role = database.RoleAdmin
+	}
+	user, err := store.CreateUser(ctx, *username, password, *role)
+	if err != nil {
+		return err
+	}
+	logger.Info("user created", "user", user.Username, "role", user.Role)
+	return nil
+}
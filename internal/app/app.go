@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"sync"
@@ -10,6 +11,7 @@ import (
 
 	"powerhive/internal/config"
 	"powerhive/internal/database"
+	"powerhive/internal/logging"
 	"powerhive/internal/server"
 )
 
@@ -20,6 +22,80 @@ const (
 	shutdownTimeout  = 5 * time.Second
 )
 
+// Background services New can start, by the name used in logs, in the
+// services configuration section and in WithServices and WithoutServices.
+// The write queue behind the pollers always runs.
+const (
+	ServiceDiscovery       = config.ServiceDiscovery
+	ServiceStatus          = config.ServiceStatus
+	ServiceTelemetry       = config.ServiceTelemetry
+	ServicePlantPoller     = config.ServicePlantPoller
+	ServicePowerBalancer   = config.ServicePowerBalancer
+	ServiceFirmwareUpdater = config.ServiceFirmwareUpdater
+	ServiceProfileRollout  = config.ServiceProfileRollout
+	ServiceBackup          = config.ServiceBackup
+	ServiceEconomics       = config.ServiceEconomics
+	ServiceWatchdog        = config.ServiceWatchdog
+	ServiceDrift           = config.ServiceDrift
+	ServiceMaintenance     = config.ServiceMaintenance
+	ServiceWebhooks        = config.ServiceWebhooks
+	ServiceTelegram        = config.ServiceTelegram
+	ServiceKeyRotation     = config.ServiceKeyRotation
+	ServicePlantMetrics    = config.ServicePlantMetrics
+	ServicePDU             = config.ServicePDU
+	ServiceReconciliation  = config.ServiceReconciliation
+	ServiceSwitchEvents    = config.ServiceSwitchEvents
+)
+
+// Services lists every background service name in start order.
+var Services = []string{
+	ServiceDiscovery,
+	ServiceStatus,
+	ServiceTelemetry,
+	ServicePlantPoller,
+	ServicePowerBalancer,
+	ServiceFirmwareUpdater,
+	ServiceProfileRollout,
+	ServiceBackup,
+	ServiceEconomics,
+	ServiceWatchdog,
+	ServiceDrift,
+	ServiceMaintenance,
+	ServiceWebhooks,
+	ServiceTelegram,
+	ServiceKeyRotation,
+	ServicePlantMetrics,
+	ServicePDU,
+	ServiceReconciliation,
+	ServiceSwitchEvents,
+}
+
+// Option customises an App built by New.
+type Option func(*App)
+
+// WithServices starts only the named background services. Services that
+// also need configuration, such as backup, still start only when it is set.
+func WithServices(names ...string) Option {
+	return func(a *App) {
+		a.only = append(a.only, names...)
+	}
+}
+
+// WithoutServices keeps the named background services from starting.
+func WithoutServices(names ...string) Option {
+	return func(a *App) {
+		a.without = append(a.without, names...)
+	}
+}
+
+// WithoutHTTP leaves the dashboard and API unserved, for callers that mount
+// Handler on their own server.
+func WithoutHTTP() Option {
+	return func(a *App) {
+		a.noHTTP = true
+	}
+}
+
 // App orchestrates background services and the dashboard server.
 type App struct {
 	cfg          config.AppConfig
@@ -29,46 +105,232 @@ type App struct {
 	telemetry    *TelemetryPoller
 	plantPoller  *PlantPoller
 	powerBalancer *PowerBalancer
+	backup       *BackupScheduler
+	firmware     *FirmwareUpdater
+	economics    *EconomicsFeed
+	watchdog     *HashboardWatchdog
+	drift        *DriftDetector
+	control      *MinerControl
+	rollout      *ProfileRollout
+	maintenance  *DatabaseMaintenance
+	webhooks     *WebhookDispatcher
+	telegram     *TelegramBot
+	keys         *KeyRotator
+	plantMetrics *PlantMetrics
+	pdu          *PDUPoller
+	reconciler   *ContainerReconciler
+	switchEvents *SwitchEventListener
+	writes       *database.WriteQueue
 	server       *server.Server
 	httpServer   *http.Server
+	pprofServer  *http.Server
+
+	// only and without hold the WithServices and WithoutServices names;
+	// enabled is the resulting set.
+	only    []string
+	without []string
+	enabled map[string]bool
+	noHTTP  bool
+
+	// services are the background services in start order and http the
+	// listener, tracked for GET /api/admin/services.
+	services []*service
+	http     *service
+
+	// elector is set in high-availability mode; the services then run only
+	// while this node holds the leader lease.
+	elector *LeaderElector
+
+	mu         sync.Mutex
+	loadConfig ConfigLoader
+	logLevels  *logging.Levels
 }
 
 // New builds an App with all dependencies wired.
-func New(cfg config.AppConfig, store *database.Store, logger *slog.Logger) (*App, error) {
+func New(cfg config.AppConfig, store *database.Store, logger *slog.Logger, options ...Option) (*App, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
+	a := &App{cfg: cfg, log: logger.With("component", "app")}
+	for _, name := range Services {
+		if !cfg.Services.Enabled(name) {
+			a.without = append(a.without, name)
+		}
+	}
+	if !cfg.Services.Enabled(config.ServiceHTTP) {
+		a.noHTTP = true
+	}
+	for _, option := range options {
+		option(a)
+	}
+	enabled, err := selectServices(a.only, a.without)
+	if err != nil {
+		return nil, err
+	}
+	a.enabled = enabled
+
+	firmwareRequests.SetLimit(cfg.Concurrency.MaxFirmwareRequests)
+	firmwareLog.SetCapacity(cfg.Firmware.DebugLog.Entries)
+	firmwareLog.SetEnabled(cfg.Firmware.DebugLog.Enabled)
+	if err := configureFirmwareTLS(cfg.Firmware.TLS); err != nil {
+		return nil, err
+	}
+
 	discovery := NewDiscoverer(store, cfg, logger)
 	status := NewStatusPoller(store, cfg, logger)
 	telemetry := NewTelemetryPoller(store, cfg, logger)
 	plantPoller := NewPlantPoller(store, cfg, logger)
 	powerBalancer := NewPowerBalancer(store, cfg, logger)
+	backup := NewBackupScheduler(store, cfg, logger)
+	firmwareUpdater := NewFirmwareUpdater(store, cfg, logger)
+	economicsFeed := NewEconomicsFeed(store, cfg, logger)
+	watchdog := NewHashboardWatchdog(store, cfg, logger)
+	drift := NewDriftDetector(store, cfg, logger)
+	control := NewMinerControl(store, cfg, logger)
+	rollout := NewProfileRollout(store, cfg, logger)
+	maintenance := NewDatabaseMaintenance(store, cfg, logger)
+	webhooks := NewWebhookDispatcher(store, cfg, logger)
+	telegramBot := NewTelegramBot(store, cfg, logger)
+	keyRotator := NewKeyRotator(store, cfg, logger)
+	plantMetrics := NewPlantMetrics(store, cfg, logger)
+	pduPoller := NewPDUPoller(store, cfg, logger)
+	reconciler := NewContainerReconciler(store, cfg, logger)
+	reconciler.estimator = powerBalancer
+	switchEvents := NewSwitchEventListener(store, cfg, logger)
+
+	writes := store.NewWriteQueue(cfg.Database.WriteBatchSize, time.Duration(cfg.Database.WriteBatchDelayMS)*time.Millisecond)
+	status.writes = writes
+	telemetry.writes = writes
+
+	a.discovery = discovery
+	a.status = status
+	a.telemetry = telemetry
+	a.plantPoller = plantPoller
+	a.powerBalancer = powerBalancer
+	a.backup = backup
+	a.firmware = firmwareUpdater
+	a.economics = economicsFeed
+	a.watchdog = watchdog
+	a.drift = drift
+	a.control = control
+	a.rollout = rollout
+	a.maintenance = maintenance
+	a.webhooks = webhooks
+	a.telegram = telegramBot
+	a.keys = keyRotator
+	a.plantMetrics = plantMetrics
+	a.pdu = pduPoller
+	a.reconciler = reconciler
+	a.switchEvents = switchEvents
+	var notifiers eventNotifiers
+	if a.enabled[ServiceWebhooks] && webhooks.Enabled() {
+		notifiers = append(notifiers, webhooks)
+	}
+	if a.enabled[ServiceTelegram] && telegramBot.Enabled() {
+		notifiers = append(notifiers, telegramBot)
+	}
+	if len(notifiers) > 0 {
+		discovery.events = notifiers
+		status.events = notifiers
+		plantPoller.events = notifiers
+		switchEvents.events = notifiers
+		powerBalancer.setEventNotifier(notifiers)
+	}
+	a.writes = writes
+	if cfg.HA.Enabled {
+		a.elector = NewLeaderElector(store, cfg, logger)
+	}
+	a.registerServices()
+	a.http = a.newHTTPService()
+
+	opts := []server.Option{
+		server.WithMinerController(control),
+		server.WithBalancePlanner(powerBalancer),
+		server.WithBalanceQueue(powerBalancer),
+		server.WithGenerationSmoothing(powerBalancer),
+		server.WithChangeLimits(powerBalancer),
+		server.WithReconciliation(reconciler),
+		server.WithReliabilityChecker(status),
+		server.WithConfigReloader(a),
+		server.WithLogLevels(a),
+		server.WithServiceReporter(a),
+		server.WithFirmwareLog(firmwareLog),
+		server.WithKeyRotator(keyRotator),
+		server.WithPlantMetrics(plantMetrics),
+		server.WithLimits(server.Limits{
+			RatePerSecond:  cfg.HTTP.RateLimitPerSecond,
+			Burst:          cfg.HTTP.RateLimitBurst,
+			MaxBodyBytes:   int64(cfg.HTTP.MaxBodyKB) << 10,
+			RequestTimeout: time.Duration(cfg.HTTP.RequestTimeoutSeconds) * time.Second,
+		}),
+	}
+	if maintenance.Enabled() {
+		opts = append(opts, server.WithMaintenance(maintenance))
+	}
+	if a.elector != nil {
+		opts = append(opts, server.WithLeaderReporter(a.elector))
+	}
+	if cfg.HTTP.GraphQL {
+		opts = append(opts, server.WithGraphQL())
+	}
+	if len(cfg.HTTP.AdminTokens) > 0 {
+		opts = append(opts, server.WithAdminTokens(cfg.HTTP.AdminTokens))
+	}
+	if cfg.HTTP.BasePath != "" {
+		opts = append(opts, server.WithBasePath(cfg.HTTP.BasePath))
+	}
+	if cfg.HTTP.WritesRestricted() {
+		opts = append(opts, server.WithWriteAccess(server.WriteAccess{
+			Allow:       cfg.HTTP.WriteAllowlistPrefixes(),
+			ClientCerts: cfg.HTTP.TLS.ClientCAFile != "",
+		}))
+	}
+	opts = append(opts, server.WithSessions(server.SessionOptions{
+		TTL:      time.Duration(cfg.HTTP.SessionHours) * time.Hour,
+		Required: cfg.HTTP.RequireLogin,
+	}))
+	if sunset := cfg.HTTP.LegacyAPISunsetTime(); !sunset.IsZero() {
+		opts = append(opts, server.WithLegacyAPISunset(sunset))
+	}
+	// Requests queued for a service that is switched off would never run,
+	// so its endpoints answer 503 instead.
+	if a.enabled[ServiceDiscovery] {
+		opts = append(opts, server.WithDiscovery(discovery))
+	}
+	if a.enabled[ServiceFirmwareUpdater] {
+		opts = append(opts, server.WithFirmwareUpdater(firmwareUpdater))
+	}
+	if a.enabled[ServiceProfileRollout] {
+		opts = append(opts, server.WithProfileRollout(rollout))
+	}
 
-	srv, err := server.New(store, logger)
+	srv, err := server.New(store, logger, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	httpServer := &http.Server{
+	a.server = srv
+	a.httpServer = &http.Server{
 		Addr:              cfg.HTTP.Addr,
 		Handler:           srv.Handler(),
 		ReadHeaderTimeout: httpReadTimeout,
 		WriteTimeout:      httpWriteTimeout,
 		IdleTimeout:       httpIdleTimeout,
 	}
+	if cfg.HTTP.TLS.ClientCAFile != "" {
+		tlsConfig, err := clientCertTLSConfig(cfg.HTTP.TLS.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		a.httpServer.TLSConfig = tlsConfig
+	}
+
+	if cfg.HTTP.PprofAddr != "" {
+		a.pprofServer = newProfilingServer(cfg.HTTP.PprofAddr)
+	}
 
-	return &App{
-		cfg:          cfg,
-		log:          logger.With("component", "app"),
-		discovery:    discovery,
-		status:       status,
-		telemetry:    telemetry,
-		plantPoller:  plantPoller,
-		powerBalancer: powerBalancer,
-		server:       srv,
-		httpServer:   httpServer,
-	}, nil
+	return a, nil
 }
 
 // Run starts the services and blocks until the context is cancelled or an error occurs.
@@ -93,20 +355,50 @@ func (a *App) Run(ctx context.Context) error {
 		}()
 	}
 
-	startService("discovery", a.discovery.Run)
-	startService("status", a.status.Run)
-	startService("telemetry", a.telemetry.Run)
-	startService("plant_poller", a.plantPoller.Run)
-	startService("power_balancer", a.powerBalancer.Run)
+	startService("write_queue", a.writes.Run)
+	if a.elector != nil {
+		startService("leader_election", func(ctx context.Context) {
+			a.elector.Run(ctx, func(leadCtx context.Context) {
+				a.runServices(leadCtx, ctx)
+			})
+		})
+	} else {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.runServices(ctx, ctx)
+		}()
+	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		a.log.Info("http listening", "addr", a.cfg.HTTP.Addr)
-		if err := a.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			errCh <- err
-		}
-	}()
+	if !a.noHTTP {
+		wg.Add(1)
+		a.http.setState(server.ServiceRunning, time.Now())
+		go func() {
+			defer wg.Done()
+			defer a.http.setState(server.ServiceStopped, time.Now())
+			a.log.Info("http listening", "addr", a.cfg.HTTP.Addr, "tls", a.cfg.HTTP.TLS.Enabled())
+			var err error
+			if tlsCfg := a.cfg.HTTP.TLS; tlsCfg.Enabled() {
+				err = a.httpServer.ListenAndServeTLS(tlsCfg.CertFile, tlsCfg.KeyFile)
+			} else {
+				err = a.httpServer.ListenAndServe()
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- err
+			}
+		}()
+	}
+
+	if a.pprofServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.log.Info("pprof listening", "addr", a.pprofServer.Addr)
+			if err := a.pprofServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				a.log.Error("pprof listener failed", "err", err)
+			}
+		}()
+	}
 
 	var runErr error
 	select {
@@ -117,10 +409,17 @@ func (a *App) Run(ctx context.Context) error {
 		cancel()
 	}
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
-	defer shutdownCancel()
-	if err := a.httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.Canceled) {
-		a.log.Error("http shutdown failed", "err", err)
+	if !a.noHTTP {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer shutdownCancel()
+		if err := a.httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.Canceled) {
+			a.log.Error("http shutdown failed", "err", err)
+		}
+	}
+	if a.pprofServer != nil {
+		// Close rather than Shutdown so a running CPU profile does not
+		// hold up exit.
+		_ = a.pprofServer.Close()
 	}
 
 	cancel()
@@ -131,3 +430,37 @@ func (a *App) Run(ctx context.Context) error {
 	}
 	return nil
 }
+
+// Handler returns the dashboard and API handler, with its middleware, for
+// mounting on another server.
+func (a *App) Handler() http.Handler {
+	return a.httpServer.Handler
+}
+
+// selectServices resolves WithServices and WithoutServices into the set of
+// services to start.
+func selectServices(only, without []string) (map[string]bool, error) {
+	known := make(map[string]bool, len(Services))
+	for _, name := range Services {
+		known[name] = true
+	}
+	enabled := make(map[string]bool, len(Services))
+	if len(only) == 0 {
+		for _, name := range Services {
+			enabled[name] = true
+		}
+	}
+	for _, name := range only {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+		enabled[name] = true
+	}
+	for _, name := range without {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+		delete(enabled, name)
+	}
+	return enabled, nil
+}
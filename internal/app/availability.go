@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// offlineStore is what taking a miner offline needs from the database.
+type offlineStore interface {
+	database.MinerWriter
+	database.AvailabilityRecorder
+}
+
+// takeOffline clears the IP of a miner that can no longer be reached, so
+// polling and balancing skip it until discovery finds it again, records
+// the change and sends a miner.offline event.
+func takeOffline(ctx context.Context, store offlineStore, log *slog.Logger, events EventNotifier, miner database.Miner, source, reason string) error {
+	minerID := strings.ToLower(miner.ID)
+	empty := ""
+	if _, err := store.UpsertMiner(ctx, database.UpsertMinerParams{
+		ID: minerID,
+		IP: &empty,
+	}); err != nil {
+		return err
+	}
+	log.Info("miner offline", "miner", miner.ID, "source", source)
+	recordAvailability(ctx, store, log, minerID, false, source, &reason)
+	if events != nil {
+		var lastIP string
+		if miner.IP != nil {
+			lastIP = strings.TrimSpace(*miner.IP)
+		}
+		events.Notify(ctx, config.WebhookMinerOffline, time.Now(), minerOfflinePayload{
+			MinerID:  minerID,
+			Name:     miner.Name,
+			Location: miner.Location,
+			LastIP:   lastIP,
+			Tags:     miner.Tags,
+		})
+	}
+	return nil
+}
+
+// recordAvailability notes whether a miner is reachable for
+// GET /api/miners/{id}/availability, logging actual transitions.
+func recordAvailability(ctx context.Context, store database.AvailabilityRecorder, log *slog.Logger, minerID string, online bool, source string, reason *string) {
+	changed, err := store.RecordMinerAvailability(ctx, database.AvailabilityChange{
+		MinerID:   minerID,
+		Online:    online,
+		Source:    source,
+		Reason:    reason,
+		ChangedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Warn("record miner availability failed", "miner", minerID, "err", err)
+		return
+	}
+	if changed {
+		log.Debug("miner availability changed", "miner", minerID, "online", online, "source", source)
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+const (
+	backupFilePrefix = "powerhive-"
+	backupFileSuffix = ".db"
+	backupTimeLayout = "20060102-150405"
+)
+
+// BackupScheduler writes timestamped database snapshots and prunes old ones.
+type BackupScheduler struct {
+	store    database.Maintainer
+	cfg      config.BackupConfig
+	log      *slog.Logger
+	interval time.Duration
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewBackupScheduler constructs the snapshot service.
+func NewBackupScheduler(store database.Maintainer, cfg config.AppConfig, logger *slog.Logger) *BackupScheduler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &BackupScheduler{
+		store:    store,
+		cfg:      cfg.Backup,
+		log:      logger.With("component", "backup"),
+		interval: time.Duration(cfg.Backup.IntervalMinutes) * time.Minute,
+	}
+}
+
+// Enabled reports whether a backup directory has been configured.
+func (b *BackupScheduler) Enabled() bool {
+	return b.cfg.Dir != "" && b.interval > 0
+}
+
+// Run takes snapshots on the configured interval until the context is cancelled.
+func (b *BackupScheduler) Run(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	b.log.Info("starting backup loop", "interval", b.interval, "dir", b.cfg.Dir, "keep", b.cfg.Keep)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.log.Info("stopping backup loop", "reason", ctx.Err())
+			return
+		case <-ticker.C:
+			_, err := b.Snapshot(ctx)
+			b.cycles.finish(time.Now(), err)
+			if err != nil {
+				b.log.Error("scheduled backup failed", "err", err)
+			}
+		}
+	}
+}
+
+// Snapshot writes a timestamped snapshot to the backup directory, prunes
+// the oldest beyond the retention count and returns the snapshot's path.
+func (b *BackupScheduler) Snapshot(ctx context.Context) (string, error) {
+	if b.cfg.Dir == "" {
+		return "", fmt.Errorf("backup directory is not configured")
+	}
+	if err := os.MkdirAll(b.cfg.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("create backup dir: %w", err)
+	}
+
+	name := backupFilePrefix + time.Now().UTC().Format(backupTimeLayout) + backupFileSuffix
+	path := filepath.Join(b.cfg.Dir, name)
+
+	if err := b.store.BackupTo(ctx, path); err != nil {
+		return "", err
+	}
+
+	b.log.Info("database snapshot written", "path", path)
+
+	if err := b.prune(); err != nil {
+		b.log.Warn("prune backups failed", "err", err)
+	}
+	return path, nil
+}
+
+// prune removes the oldest snapshots beyond the configured retention count.
+func (b *BackupScheduler) prune() error {
+	entries, err := os.ReadDir(b.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("read backup dir: %w", err)
+	}
+
+	var snapshots []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupFilePrefix) || !strings.HasSuffix(name, backupFileSuffix) {
+			continue
+		}
+		snapshots = append(snapshots, name)
+	}
+
+	if len(snapshots) <= b.cfg.Keep {
+		return nil
+	}
+
+	// Timestamped names sort chronologically.
+	sort.Strings(snapshots)
+	for _, name := range snapshots[:len(snapshots)-b.cfg.Keep] {
+		path := filepath.Join(b.cfg.Dir, name)
+		if err := os.Remove(path); err != nil {
+			b.log.Warn("remove old backup failed", "path", path, "err", err)
+			continue
+		}
+		b.log.Debug("old backup removed", "path", path)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"math"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// piController sizes each balance cycle's adjustment from the measured
+// consumption error. It lives on the balancer loop and is reset on reload.
+type piController struct {
+	integral float64 // accumulated error, W·s
+	last     time.Time
+	// saturated is set when the previous cycle wanted a change but no miner
+	// could move, so the integral stops growing in that direction.
+	saturated bool
+}
+
+// measuredConsumption returns the plant's metered container consumption in
+// W, falling back to the estimate when the meter reports nothing.
+func measuredConsumption(reading *database.PlantReading, estimatedW float64) float64 {
+	if reading != nil && reading.TotalContainerConsumption > 0 {
+		return reading.TotalContainerConsumption * 1000
+	}
+	return estimatedW
+}
+
+// delta returns the watts to add (positive) or shed (negative) for the cycle
+// running at now, followed by its proportional and integral terms.
+func (c *piController) delta(now time.Time, cfg config.BalancerConfig, targetW, measuredW float64, interval time.Duration) (float64, float64, float64) {
+	dt := interval.Seconds()
+	if !c.last.IsZero() {
+		// A long gap (startup, stalled cycles) must not dump a burst into
+		// the integral.
+		dt = math.Min(now.Sub(c.last).Seconds(), 2*interval.Seconds())
+	}
+	c.last = now
+
+	errW := targetW - measuredW
+
+	// Conditional integration: while the fleet cannot move any further,
+	// only accumulate error that would unwind the integral.
+	if !c.saturated || math.Signbit(errW) != math.Signbit(c.integral) {
+		c.integral += errW * dt
+	}
+	if cfg.Ki > 0 {
+		limit := cfg.MaxIntegralKW * 1000 / cfg.Ki
+		c.integral = math.Max(-limit, math.Min(limit, c.integral))
+	}
+
+	proportional := cfg.Kp * errW
+	integral := cfg.Ki * c.integral
+	return proportional + integral, proportional, integral
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"powerhive/internal/database"
+	"powerhive/internal/server"
+)
+
+// maxPlanCycles bounds the what-if simulation when the plan oscillates
+// around the target instead of settling.
+const maxPlanCycles = 50
+
+// PlanBalance simulates balance cycles against a hypothetical target until
+// consumption settles within tolerance, ignoring preset cooldowns. Nothing is
+// sent to miners and nothing is stored.
+func (b *PowerBalancer) PlanBalance(ctx context.Context, req server.BalancePlanRequest) (server.BalancePlan, error) {
+	var plan server.BalancePlan
+
+	switch {
+	case req.TargetPowerW != nil:
+		plan.TargetPowerW = *req.TargetPowerW
+	case req.GenerationKW != nil:
+		safetyMargin, err := b.loadSafetyMargin(ctx)
+		if err != nil {
+			return plan, err
+		}
+		if req.SafetyMarginPercent != nil {
+			safetyMargin = *req.SafetyMarginPercent
+		}
+		plan.SafetyMarginPercent = &safetyMargin
+		plan.TargetPowerW = *req.GenerationKW * (1.0 - safetyMargin/100.0) * 1000.0
+	default:
+		return plan, fmt.Errorf("target power or generation required")
+	}
+
+	miners, err := b.store.ListMiners(ctx)
+	if err != nil {
+		return plan, fmt.Errorf("list miners: %w", err)
+	}
+
+	allOnline := b.filterOnlineMiners(miners)
+	presetPowerMap, err := b.loadPresetPowerMap(allOnline)
+	if err != nil {
+		return plan, fmt.Errorf("load preset power data: %w", err)
+	}
+	expectedHashrate, err := b.loadPresetHashrateMap(ctx)
+	if err != nil {
+		return plan, err
+	}
+	unprofitable := b.loadUnprofitablePresets(ctx)
+	limits, err := b.store.GetThermalLimits(ctx)
+	if err != nil {
+		b.log.Warn("failed to load thermal limits, using defaults", "err", err)
+		limits = database.DefaultThermalLimits
+	}
+
+	measured := b.measuredPower(ctx, allOnline)
+	plan.CurrentConsumptionW = b.calculateCurrentConsumption(allOnline, presetPowerMap, measured) + b.reconciliationCorrectionW(ctx)
+	for _, miner := range allOnline {
+		if miner.LatestStatus != nil && miner.LatestStatus.Hashrate != nil {
+			plan.CurrentHashrateTH += *miner.LatestStatus.Hashrate / 1000
+		}
+	}
+
+	// Work on copies of the eligible miners' statuses so the simulation can
+	// move them between presets.
+	eligible := b.filterEligibleMiners(miners, nil)
+	byID := make(map[string]*database.Miner, len(eligible))
+	for i := range eligible {
+		if eligible[i].LatestStatus != nil {
+			status := *eligible[i].LatestStatus
+			eligible[i].LatestStatus = &status
+		}
+		byID[eligible[i].ID] = &eligible[i]
+	}
+
+	changes := make(map[string]*server.BalancePlanChange)
+	consumption := plan.CurrentConsumptionW
+	hashrateGH := plan.CurrentHashrateTH * 1000
+
+	apply := func(me minerEfficiency, targetPreset string, targetPower *float64, reason string) {
+		miner := byID[me.miner.ID]
+		status := miner.LatestStatus
+
+		change, exists := changes[miner.ID]
+		if !exists {
+			change = &server.BalancePlanChange{
+				MinerID:       miner.ID,
+				Model:         miner.Model.Alias,
+				Reason:        reason,
+				CurrentPreset: status.Preset,
+				CurrentPowerW: me.currentPower,
+			}
+			if status.Hashrate != nil {
+				th := *status.Hashrate / 1000
+				change.CurrentHashrateTH = &th
+			}
+			changes[miner.ID] = change
+		}
+
+		newHashrate := projectHashrate(miner.Model.Alias, status.Hashrate, me.currentPower, targetPreset, targetPower, expectedHashrate)
+		if status.Hashrate != nil {
+			hashrateGH -= *status.Hashrate
+		}
+		hashrateGH += newHashrate
+		if me.currentPower != nil && targetPower != nil {
+			consumption += *targetPower - *me.currentPower
+		}
+
+		// The measured power predates the simulated preset.
+		delete(measured, miner.ID)
+		preset := targetPreset
+		status.Preset = &preset
+		status.Hashrate = &newHashrate
+		status.PowerConsumption = targetPower
+
+		th := newHashrate / 1000
+		change.PlannedPreset = targetPreset
+		change.PlannedPowerW = targetPower
+		change.PlannedHashrateTH = &th
+	}
+
+	for plan.Cycles < maxPlanCycles {
+		efficiencies := b.calculateEfficiencies(eligible, presetPowerMap, expectedHashrate, measured, nil)
+		plan.Cycles++
+
+		// Hot miners are stepped down once, the way the first real cycle would.
+		derated := make(map[string]bool)
+		if plan.Cycles == 1 && limits.ChipTempCeilingC > 0 {
+			for _, me := range efficiencies {
+				temp, ok := minerChipTemp(me.miner)
+				if !ok || temp < limits.ChipTempCeilingC {
+					continue
+				}
+				targetPreset, targetPower, err := b.determineTargetPreset(me.miner, -1, presetPowerMap, unprofitable)
+				if err != nil || targetPreset == nil || (me.currentPreset != nil && *targetPreset == *me.currentPreset) {
+					continue
+				}
+				apply(me, *targetPreset, targetPower, "thermal_derate")
+				derated[me.miner.ID] = true
+			}
+		}
+
+		delta := plan.TargetPowerW - consumption
+		if math.Abs(delta) < b.toleranceW() {
+			plan.Converged = true
+			break
+		}
+
+		sortForDelta(efficiencies, delta)
+		plannedChanges, _ := b.planChanges(efficiencies, delta, presetPowerMap, unprofitable, limits,
+			func(me minerEfficiency) string {
+				if derated[me.miner.ID] {
+					return skipDerated
+				}
+				return ""
+			}, nil)
+		if len(plannedChanges) == 0 && len(derated) == 0 {
+			break
+		}
+		for _, me := range efficiencies {
+			if planned, exists := plannedChanges[me.miner.ID]; exists {
+				apply(me, *planned.targetPreset, planned.targetPower, "automatic_balance")
+			}
+		}
+	}
+
+	plan.ProjectedConsumptionW = consumption
+	plan.ProjectedHashrateTH = hashrateGH / 1000
+
+	plan.Changes = make([]server.BalancePlanChange, 0, len(changes))
+	for _, change := range changes {
+		// Miners that ended where they started are not changes.
+		if change.CurrentPreset != nil && *change.CurrentPreset == change.PlannedPreset {
+			continue
+		}
+		plan.Changes = append(plan.Changes, *change)
+	}
+	sort.Slice(plan.Changes, func(i, j int) bool {
+		return plan.Changes[i].MinerID < plan.Changes[j].MinerID
+	})
+
+	return plan, nil
+}
+
+// loadPresetHashrateMap returns the expected hashrate in TH/s per model alias
+// and preset, for presets that have one recorded.
+func (b *PowerBalancer) loadPresetHashrateMap(ctx context.Context) (map[string]map[string]float64, error) {
+	allPresets, err := b.store.GetAllModelPresets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load model presets: %w", err)
+	}
+
+	hashrateMap := make(map[string]map[string]float64)
+	for modelAlias, presets := range allPresets {
+		for _, preset := range presets {
+			if preset.ExpectedHashrateTH == nil {
+				continue
+			}
+			if hashrateMap[modelAlias] == nil {
+				hashrateMap[modelAlias] = make(map[string]float64)
+			}
+			hashrateMap[modelAlias][preset.Value] = *preset.ExpectedHashrateTH
+		}
+	}
+	return hashrateMap, nil
+}
+
+// projectHashrate estimates a miner's hashrate in GH/s after moving to
+// targetPreset. Sleep yields zero; otherwise the preset's expected hashrate
+// is used, falling back to scaling the current hashrate by the power ratio.
+func projectHashrate(modelAlias string, currentGH, currentPower *float64, targetPreset string, targetPower *float64, expected map[string]map[string]float64) float64 {
+	if strings.EqualFold(targetPreset, sleepPreset) {
+		return 0
+	}
+	if th, exists := expected[modelAlias][targetPreset]; exists {
+		return th * 1000
+	}
+	if currentGH == nil {
+		return 0
+	}
+	if currentPower != nil && *currentPower > 0 && targetPower != nil {
+		return *currentGH * *targetPower / *currentPower
+	}
+	return *currentGH
+}
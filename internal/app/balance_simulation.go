@@ -0,0 +1,222 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// GenerationSample is the plant generation from At until the next sample.
+type GenerationSample struct {
+	At           time.Time
+	GenerationKW float64
+}
+
+// SimulationRequest describes an offline replay of the balancer.
+// SafetyMarginPercent falls back to the stored setting and Cooldown to the
+// balancer's preset change cooldown. The cycle interval, strategy and
+// tolerance come from the balancer's configuration.
+type SimulationRequest struct {
+	Generation          []GenerationSample
+	SafetyMarginPercent *float64
+	Cooldown            time.Duration
+}
+
+// SimulationStep is the state after one simulated balance cycle.
+type SimulationStep struct {
+	At            time.Time
+	GenerationKW  float64
+	TargetW       float64
+	ConsumptionW  float64
+	PresetChanges int
+}
+
+// SimulationReport summarises a replay. Energy is integrated over each
+// cycle interval with the consumption the cycle left behind.
+// CurtailedKWh is generation the fleet did not consume; OverdrawKWh is
+// consumption beyond generation.
+type SimulationReport struct {
+	Start               time.Time
+	End                 time.Time
+	Interval            time.Duration
+	Strategy            string
+	SafetyMarginPercent float64
+	ToleranceW          float64
+	Cooldown            time.Duration
+	Miners              int
+	MinersEligible      int
+
+	Cycles         int
+	PresetChanges  int
+	MinersChanged  int
+	OverdrawCycles int
+
+	GenerationKWh  float64
+	TargetKWh      float64
+	ConsumptionKWh float64
+	CurtailedKWh   float64
+	OverdrawKWh    float64
+	PeakOverdrawW  float64
+
+	Steps []SimulationStep
+}
+
+// Simulate replays a generation profile against a copy of the current
+// fleet, running the balancer's eligibility, ordering and preset selection
+// each cycle. Each miner starts at its latest preset and moves only in the
+// simulation; nothing is sent to miners and nothing is stored. Thermal
+// derating, the forecast, battery policy and demand response are not
+// simulated, and the estimated consumption stands in for the meter.
+func (b *PowerBalancer) Simulate(ctx context.Context, req SimulationRequest) (SimulationReport, error) {
+	report := SimulationReport{
+		Interval:   b.interval,
+		Strategy:   b.cfg.Balancer.Strategy,
+		ToleranceW: b.toleranceW(),
+		Cooldown:   req.Cooldown,
+	}
+	if report.Interval <= 0 {
+		return report, fmt.Errorf("balancer interval must be positive")
+	}
+	if report.Cooldown <= 0 {
+		report.Cooldown = presetChangeCooldown
+	}
+	if len(req.Generation) == 0 {
+		return report, fmt.Errorf("no generation samples to replay")
+	}
+
+	samples := append([]GenerationSample(nil), req.Generation...)
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].At.Before(samples[j].At) })
+	report.Start = samples[0].At
+	report.End = samples[len(samples)-1].At
+
+	if req.SafetyMarginPercent != nil {
+		report.SafetyMarginPercent = *req.SafetyMarginPercent
+	} else {
+		safetyMargin, err := b.loadSafetyMargin(ctx)
+		if err != nil {
+			return report, err
+		}
+		report.SafetyMarginPercent = safetyMargin
+	}
+
+	miners, err := b.store.ListMiners(ctx)
+	if err != nil {
+		return report, fmt.Errorf("list miners: %w", err)
+	}
+
+	// Work on copies of the online miners' statuses so the simulation can
+	// move them between presets.
+	online := b.filterOnlineMiners(miners)
+	for i := range online {
+		if online[i].LatestStatus != nil {
+			status := *online[i].LatestStatus
+			online[i].LatestStatus = &status
+		}
+	}
+	report.Miners = len(online)
+
+	presetPowerMap, err := b.loadPresetPowerMap(online)
+	if err != nil {
+		return report, fmt.Errorf("load preset power data: %w", err)
+	}
+	expectedHashrate, err := b.loadPresetHashrateMap(ctx)
+	if err != nil {
+		return report, err
+	}
+	unprofitable := b.loadUnprofitablePresets(ctx)
+
+	var pi piController
+	usePI := b.cfg.Balancer.Strategy == config.BalancerPI
+	lastChange := make(map[string]time.Time)
+	changed := make(map[string]bool)
+	intervalHours := report.Interval.Hours()
+
+	next := 0
+	var generationKW float64
+	for now := report.Start; !now.After(report.End); now = now.Add(report.Interval) {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		for next < len(samples) && !samples[next].At.After(now) {
+			generationKW = samples[next].GenerationKW
+			next++
+		}
+
+		targetW := generationKW * (1.0 - report.SafetyMarginPercent/100.0) * 1000.0
+		consumptionW := b.calculateCurrentConsumption(online, presetPowerMap, nil)
+
+		var eligible []database.Miner
+		for _, miner := range online {
+			if b.ineligibleReason(miner, now) == "" {
+				eligible = append(eligible, miner)
+			}
+		}
+		report.MinersEligible = max(report.MinersEligible, len(eligible))
+		efficiencies := b.calculateEfficiencies(eligible, presetPowerMap, expectedHashrate, nil, nil)
+
+		delta := targetW - consumptionW
+		if usePI {
+			delta, _, _ = pi.delta(now, b.cfg.Balancer, targetW, consumptionW, report.Interval)
+		}
+
+		step := SimulationStep{At: now, GenerationKW: generationKW, TargetW: targetW}
+		if math.Abs(delta) < report.ToleranceW {
+			pi.saturated = false
+		} else {
+			sortForDelta(efficiencies, delta)
+			// Temperatures are not simulated, so the thermal limits are off.
+			planned, _ := b.planChanges(efficiencies, delta, presetPowerMap, unprofitable, database.ThermalLimits{},
+				func(me minerEfficiency) string {
+					if at, exists := lastChange[me.miner.ID]; exists && now.Sub(at) < report.Cooldown {
+						return skipCooldown
+					}
+					return ""
+				}, nil)
+			if usePI {
+				pi.saturated = len(planned) == 0
+			}
+
+			for _, me := range efficiencies {
+				change, exists := planned[me.miner.ID]
+				if !exists {
+					continue
+				}
+				// The efficiencies share the copied statuses with online.
+				status := me.miner.LatestStatus
+				hashrate := projectHashrate(me.miner.Model.Alias, status.Hashrate, me.currentPower, *change.targetPreset, change.targetPower, expectedHashrate)
+				status.Preset = change.targetPreset
+				status.Hashrate = &hashrate
+				status.PowerConsumption = change.targetPower
+
+				lastChange[me.miner.ID] = now
+				changed[me.miner.ID] = true
+				step.PresetChanges++
+			}
+		}
+
+		step.ConsumptionW = b.calculateCurrentConsumption(online, presetPowerMap, nil)
+		report.Steps = append(report.Steps, step)
+		report.Cycles++
+		report.PresetChanges += step.PresetChanges
+
+		generationW := generationKW * 1000.0
+		report.GenerationKWh += generationW / 1000.0 * intervalHours
+		report.TargetKWh += step.TargetW / 1000.0 * intervalHours
+		report.ConsumptionKWh += step.ConsumptionW / 1000.0 * intervalHours
+		if surplus := generationW - step.ConsumptionW; surplus > 0 {
+			report.CurtailedKWh += surplus / 1000.0 * intervalHours
+		} else if surplus < 0 {
+			report.OverdrawCycles++
+			report.OverdrawKWh += -surplus / 1000.0 * intervalHours
+			report.PeakOverdrawW = math.Max(report.PeakOverdrawW, -surplus)
+		}
+	}
+
+	report.MinersChanged = len(changed)
+	return report, nil
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/server"
+)
+
+// changeLimiter enforces the balancer's preset change limits. The counts
+// come from the recorded balance events plus the changes still in the
+// queue, so they hold across restarts.
+type changeLimiter struct {
+	store database.BalanceRecorder
+	queue *presetChangeQueue
+	log   *slog.Logger
+
+	mu        sync.Mutex
+	cfg       config.ChangeLimitsConfig
+	lastCycle int
+}
+
+func newChangeLimiter(store database.BalanceRecorder, queue *presetChangeQueue, cfg config.AppConfig, logger *slog.Logger) *changeLimiter {
+	l := &changeLimiter{store: store, queue: queue, log: logger}
+	l.configure(cfg)
+	return l
+}
+
+// configure applies new limits.
+func (l *changeLimiter) configure(cfg config.AppConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg.Balancer.ChangeLimits
+}
+
+// changeBudget is what the limits leave a cycle.
+type changeBudget struct {
+	// remaining is how many more changes the cycle may queue, -1 when
+	// unlimited.
+	remaining int
+	perMiner  int
+	// daily counts each miner's changes over the last 24 hours.
+	daily map[string]int
+}
+
+// allows reports whether n more changes fit the budget.
+func (c changeBudget) allows(n int) bool {
+	return c.remaining < 0 || c.remaining >= n
+}
+
+// spend takes n changes off the budget.
+func (c *changeBudget) spend(n int) {
+	if c.remaining >= 0 {
+		c.remaining = max(0, c.remaining-n)
+	}
+}
+
+// minerExhausted reports whether the miner reached its daily limit.
+func (c changeBudget) minerExhausted(minerID string) bool {
+	return c.perMiner > 0 && c.daily[minerID] >= c.perMiner
+}
+
+// budget works out the changes the limits leave for a cycle at now. A
+// count that cannot be loaded leaves its limit off for the cycle.
+func (l *changeLimiter) budget(ctx context.Context, now time.Time) changeBudget {
+	l.mu.Lock()
+	cfg := l.cfg
+	l.mu.Unlock()
+
+	budget := changeBudget{remaining: -1, perMiner: cfg.PerMinerPerDay}
+	if cfg.PerCycle > 0 {
+		budget.remaining = cfg.PerCycle
+	}
+	if cfg.PerHour > 0 {
+		counts, err := l.store.CountPresetChanges(ctx, now.Add(-time.Hour))
+		if err != nil {
+			l.log.Warn("failed to count preset changes, not limiting per hour", "err", err)
+		} else {
+			left := max(0, cfg.PerHour-sumCounts(counts)-l.queue.size())
+			if budget.remaining < 0 || left < budget.remaining {
+				budget.remaining = left
+			}
+		}
+	}
+	if cfg.PerMinerPerDay > 0 {
+		counts, err := l.store.CountPresetChanges(ctx, now.Add(-24*time.Hour))
+		if err != nil {
+			l.log.Warn("failed to count preset changes, not limiting per miner", "err", err)
+			budget.perMiner = 0
+		} else {
+			budget.daily = counts
+		}
+	}
+	return budget
+}
+
+// recordCycle notes how many changes the latest cycle made.
+func (l *changeLimiter) recordCycle(changes int) {
+	l.mu.Lock()
+	l.lastCycle = changes
+	l.mu.Unlock()
+}
+
+// report counts the changes against the limits for /api/balance/status.
+func (l *changeLimiter) report(ctx context.Context, now time.Time) (server.ChangeLimits, error) {
+	l.mu.Lock()
+	out := server.ChangeLimits{
+		PerCycle:       l.cfg.PerCycle,
+		PerHour:        l.cfg.PerHour,
+		PerMinerPerDay: l.cfg.PerMinerPerDay,
+		LastCycle:      l.lastCycle,
+	}
+	l.mu.Unlock()
+
+	hour, err := l.store.CountPresetChanges(ctx, now.Add(-time.Hour))
+	if err != nil {
+		return server.ChangeLimits{}, err
+	}
+	day, err := l.store.CountPresetChanges(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		return server.ChangeLimits{}, err
+	}
+	out.LastHour = sumCounts(hour)
+	out.LastDay = sumCounts(day)
+	out.Queued = l.queue.size()
+	for _, count := range day {
+		if out.PerMinerPerDay > 0 && count >= out.PerMinerPerDay {
+			out.MinersAtDailyLimit++
+		}
+	}
+	return out, nil
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
+
+// ChangeLimits reports the preset change counters for
+// /api/balance/status.
+func (b *PowerBalancer) ChangeLimits(ctx context.Context) (server.ChangeLimits, bool) {
+	out, err := b.limiter.report(ctx, b.clock.Now())
+	if err != nil {
+		b.log.Warn("failed to count preset changes", "err", err)
+		return server.ChangeLimits{}, false
+	}
+	return out, true
+}
+
+// trimPlanned keeps the first n planned changes in the order of miners and
+// returns them with their expected change in consumption. The others are
+// added to skipped with reason.
+func trimPlanned(miners []minerEfficiency, planned map[string]plannedChange, n int, reason string, skipped map[string]string) (map[string]plannedChange, float64) {
+	kept := make(map[string]plannedChange)
+	var powerChange float64
+	for _, me := range miners {
+		change, ok := planned[me.miner.ID]
+		if !ok {
+			continue
+		}
+		if len(kept) >= n {
+			skipped[me.miner.ID] = reason
+			continue
+		}
+		kept[me.miner.ID] = change
+		if me.currentPower != nil && change.targetPower != nil {
+			powerChange += *change.targetPower - *me.currentPower
+		}
+	}
+	return kept, powerChange
+}
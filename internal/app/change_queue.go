@@ -0,0 +1,321 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/server"
+)
+
+// presetChange is one preset assignment the balancer wants applied, with the
+// cycle figures recorded in its balance event.
+type presetChange struct {
+	cfg                config.AppConfig
+	miner              database.Miner
+	oldPreset          *string
+	newPreset          string
+	oldPower           *float64
+	newPower           *float64
+	consumptionBeforeW float64
+	targetPowerW       float64
+	availablePowerW    float64
+	reason             string
+}
+
+// powerDeltaW is the change in consumption expected from the change, zero
+// when either preset's power is unknown.
+func (c presetChange) powerDeltaW() float64 {
+	if c.oldPower == nil || c.newPower == nil {
+		return 0
+	}
+	return *c.newPower - *c.oldPower
+}
+
+// queuedChange is a change waiting in, or being applied by, the queue.
+type queuedChange struct {
+	change    presetChange
+	container string
+	queuedAt  time.Time
+	startedAt *time.Time
+}
+
+// presetChangeQueue applies the balancer's preset changes in the background
+// so a cycle never waits on the firmware. At most workers changes run at a
+// time, and a container (the miners sharing a location) starts a change at
+// most once every pacing interval, so a whole container never restarts at
+// once. Changes start in the order they were queued; one held back by
+// pacing does not block the changes behind it.
+type presetChangeQueue struct {
+	apply func(context.Context, presetChange) error
+	log   *slog.Logger
+	clock clock.Clock
+	wake  chan struct{}
+
+	mu        sync.Mutex
+	workers   int
+	pacing    time.Duration
+	items     []*queuedChange
+	lastStart map[string]time.Time
+	running   int
+	wg        sync.WaitGroup
+}
+
+func newPresetChangeQueue(cfg config.AppConfig, apply func(context.Context, presetChange) error, clk clock.Clock, logger *slog.Logger) *presetChangeQueue {
+	q := &presetChangeQueue{
+		apply:     apply,
+		log:       logger,
+		clock:     clk,
+		wake:      make(chan struct{}, 1),
+		lastStart: make(map[string]time.Time),
+	}
+	q.configure(cfg)
+	return q
+}
+
+// configure applies the worker and pacing limits; changes already running
+// are not affected.
+func (q *presetChangeQueue) configure(cfg config.AppConfig) {
+	q.mu.Lock()
+	q.workers = cfg.Balancer.ChangeWorkers
+	q.pacing = time.Duration(cfg.Balancer.ContainerPacingSeconds) * time.Second
+	q.mu.Unlock()
+	q.signal()
+}
+
+// enqueue adds change to the queue. A change still waiting for the same
+// miner is replaced in place; it reports false when the miner's previous
+// change is already being applied.
+func (q *presetChangeQueue) enqueue(change presetChange) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item := &queuedChange{
+		change:    change,
+		container: strings.ToLower(strings.TrimSpace(safeString(change.miner.Location))),
+		queuedAt:  q.clock.Now(),
+	}
+	for i, existing := range q.items {
+		if existing.change.miner.ID != change.miner.ID {
+			continue
+		}
+		if existing.startedAt != nil {
+			return false
+		}
+		q.items[i] = item
+		q.signal()
+		return true
+	}
+	q.items = append(q.items, item)
+	q.signal()
+	return true
+}
+
+// contains reports whether the miner has a change waiting or running.
+func (q *presetChangeQueue) contains(minerID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, item := range q.items {
+		if item.change.miner.ID == minerID {
+			return true
+		}
+	}
+	return false
+}
+
+// dropWaiting removes the changes not yet started and returns the
+// consumption change they were expected to make.
+func (q *presetChangeQueue) dropWaiting() (int, float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var (
+		kept    []*queuedChange
+		dropped int
+		deltaW  float64
+	)
+	for _, item := range q.items {
+		if item.startedAt != nil {
+			kept = append(kept, item)
+			continue
+		}
+		dropped++
+		deltaW += item.change.powerDeltaW()
+	}
+	q.items = kept
+	return dropped, deltaW
+}
+
+// size is the number of changes waiting or running.
+func (q *presetChangeQueue) size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// pendingDeltaW is the consumption change expected from every change not
+// yet finished.
+func (q *presetChangeQueue) pendingDeltaW() float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var total float64
+	for _, item := range q.items {
+		total += item.change.powerDeltaW()
+	}
+	return total
+}
+
+// run starts queued changes as workers and pacing allow until ctx is
+// cancelled. Changes still waiting then are dropped; the next cycle plans
+// them again.
+func (q *presetChangeQueue) run(ctx context.Context) {
+	for {
+		wait := q.dispatch(ctx)
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = q.clock.After(wait)
+		}
+		select {
+		case <-ctx.Done():
+			q.mu.Lock()
+			dropped := 0
+			for _, item := range q.items {
+				if item.startedAt == nil {
+					dropped++
+				}
+			}
+			q.mu.Unlock()
+			if dropped > 0 {
+				q.log.Info("dropping queued preset changes", "count", dropped)
+			}
+			return
+		case <-q.wake:
+		case <-timer:
+		}
+	}
+}
+
+// wait blocks until every running change has returned.
+func (q *presetChangeQueue) wait() {
+	q.wg.Wait()
+}
+
+// dispatch starts every change it may and returns how long until a change
+// held back by pacing may start, or zero when none is.
+func (q *presetChangeQueue) dispatch(ctx context.Context) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	workers := max(q.workers, 1)
+	now := q.clock.Now()
+	var wait time.Duration
+	for _, item := range q.items {
+		if q.running >= workers {
+			break
+		}
+		if item.startedAt != nil {
+			continue
+		}
+		if item.container != "" && q.pacing > 0 {
+			if last, ok := q.lastStart[item.container]; ok {
+				if remaining := q.pacing - now.Sub(last); remaining > 0 {
+					if wait == 0 || remaining < wait {
+						wait = remaining
+					}
+					continue
+				}
+			}
+		}
+
+		started := now
+		item.startedAt = &started
+		q.running++
+		if item.container != "" {
+			q.lastStart[item.container] = now
+		}
+		q.wg.Add(1)
+		go func(item *queuedChange) {
+			defer q.wg.Done()
+			err := q.apply(ctx, item.change)
+			q.finish(item, err)
+		}(item)
+	}
+
+	// Forget containers whose pacing has run out.
+	for container, last := range q.lastStart {
+		if now.Sub(last) >= q.pacing {
+			delete(q.lastStart, container)
+		}
+	}
+	return wait
+}
+
+func (q *presetChangeQueue) finish(item *queuedChange, err error) {
+	q.mu.Lock()
+	for i, existing := range q.items {
+		if existing == item {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			break
+		}
+	}
+	q.running--
+	q.mu.Unlock()
+	q.signal()
+
+	change := item.change
+	if err != nil {
+		q.log.Error("failed to apply preset change", "miner", change.miner.ID, "reason", change.reason, "err", err)
+		return
+	}
+	q.log.Info("preset changed",
+		"miner", change.miner.ID,
+		"old_preset", stringOrNil(change.oldPreset),
+		"new_preset", change.newPreset,
+		"waited", item.startedAt.Sub(item.queuedAt),
+	)
+}
+
+// signal wakes the dispatch loop without blocking.
+func (q *presetChangeQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// snapshot lists the queue in order, running changes first.
+func (q *presetChangeQueue) snapshot() server.PresetChangeQueue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := server.PresetChangeQueue{
+		Workers:         max(q.workers, 1),
+		ContainerPacing: max(q.pacing, 0),
+		Changes:         make([]server.QueuedPresetChange, 0, len(q.items)),
+	}
+	for _, item := range q.items {
+		change := item.change
+		queued := server.QueuedPresetChange{
+			MinerID:   change.miner.ID,
+			Location:  change.miner.Location,
+			OldPreset: change.oldPreset,
+			NewPreset: change.newPreset,
+			OldPowerW: change.oldPower,
+			NewPowerW: change.newPower,
+			Reason:    change.reason,
+			QueuedAt:  item.queuedAt,
+			StartedAt: item.startedAt,
+		}
+		out.PendingDeltaW += change.powerDeltaW()
+		out.Changes = append(out.Changes, queued)
+	}
+	sort.SliceStable(out.Changes, func(i, j int) bool {
+		return out.Changes[i].StartedAt != nil && out.Changes[j].StartedAt == nil
+	})
+	return out
+}
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// maintenanceTick is how often the maintenance loop looks for due tasks.
+const maintenanceTick = time.Minute
+
+// Maintenance task names, as reported in MaintenanceReport.Errors.
+const (
+	maintenanceCheckpoint = "checkpoint"
+	maintenanceOptimize   = "optimize"
+	maintenanceIntegrity  = "integrity_check"
+)
+
+// DatabaseMaintenance checkpoints the SQLite WAL, refreshes planner
+// statistics and checks the database file's integrity, each on its own
+// schedule, and keeps the latest results for the health endpoint.
+type DatabaseMaintenance struct {
+	store    database.Maintainer
+	cfg      config.MaintenanceConfig
+	log      *slog.Logger
+	reloadCh chan config.AppConfig
+
+	// next holds when each task is due; tasks turned off are absent.
+	next map[string]time.Time
+
+	mu     sync.Mutex
+	report database.MaintenanceReport
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewDatabaseMaintenance constructs the database maintenance service.
+func NewDatabaseMaintenance(store database.Maintainer, cfg config.AppConfig, logger *slog.Logger) *DatabaseMaintenance {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &DatabaseMaintenance{
+		store:    store,
+		cfg:      cfg.Maintenance,
+		log:      logger.With("component", "maintenance"),
+		reloadCh: make(chan config.AppConfig, 1),
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Enabled reports whether the database needs this housekeeping; it is
+// SQLite-specific.
+func (m *DatabaseMaintenance) Enabled() bool {
+	return m.store.Dialect() == database.DialectSQLite
+}
+
+// Reload hands a new configuration to the maintenance loop.
+func (m *DatabaseMaintenance) Reload(cfg config.AppConfig) {
+	queueReload(m.reloadCh, cfg)
+}
+
+// MaintenanceReport returns the latest maintenance results.
+func (m *DatabaseMaintenance) MaintenanceReport() database.MaintenanceReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := m.report
+	report.IntegrityProblems = slices.Clone(m.report.IntegrityProblems)
+	report.Errors = maps.Clone(m.report.Errors)
+	return report
+}
+
+// Run performs maintenance tasks as they fall due until the context is
+// cancelled. The WAL is checkpointed right away since it may have grown
+// while the service was down.
+func (m *DatabaseMaintenance) Run(ctx context.Context) {
+	m.log.Info("starting maintenance loop",
+		"checkpoint_minutes", m.cfg.CheckpointMinutes,
+		"optimize_hours", m.cfg.OptimizeHours,
+		"integrity_check_hours", m.cfg.IntegrityCheckHours)
+
+	m.schedule(time.Now(), true)
+	m.runDue(ctx)
+
+	ticker := time.NewTicker(maintenanceTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.log.Info("stopping maintenance loop", "reason", ctx.Err())
+			return
+		case <-ticker.C:
+			m.runDue(ctx)
+		case cfg := <-m.reloadCh:
+			m.cfg = cfg.Maintenance
+			m.schedule(time.Now(), false)
+			m.log.Info("configuration reloaded",
+				"checkpoint_minutes", m.cfg.CheckpointMinutes,
+				"optimize_hours", m.cfg.OptimizeHours,
+				"integrity_check_hours", m.cfg.IntegrityCheckHours)
+		}
+	}
+}
+
+// schedule sets each enabled task's next run one interval from now, or
+// keeps an earlier due time it already has. With checkpointNow the WAL
+// checkpoint is due immediately.
+func (m *DatabaseMaintenance) schedule(now time.Time, checkpointNow bool) {
+	intervals := map[string]time.Duration{
+		maintenanceCheckpoint: time.Duration(m.cfg.CheckpointMinutes) * time.Minute,
+		maintenanceOptimize:   time.Duration(m.cfg.OptimizeHours) * time.Hour,
+		maintenanceIntegrity:  time.Duration(m.cfg.IntegrityCheckHours) * time.Hour,
+	}
+	for task, interval := range intervals {
+		if interval <= 0 {
+			delete(m.next, task)
+			continue
+		}
+		due := now.Add(interval)
+		if task == maintenanceCheckpoint && checkpointNow {
+			due = now
+		}
+		if next, ok := m.next[task]; !ok || due.Before(next) {
+			m.next[task] = due
+		}
+	}
+}
+
+// runDue runs every task whose time has come and schedules its next run.
+func (m *DatabaseMaintenance) runDue(ctx context.Context) {
+	for _, task := range []string{maintenanceCheckpoint, maintenanceOptimize, maintenanceIntegrity} {
+		next, ok := m.next[task]
+		if !ok || time.Now().Before(next) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		var err error
+		switch task {
+		case maintenanceCheckpoint:
+			err = m.checkpoint(ctx)
+			m.next[task] = time.Now().Add(time.Duration(m.cfg.CheckpointMinutes) * time.Minute)
+		case maintenanceOptimize:
+			err = m.optimize(ctx)
+			m.next[task] = time.Now().Add(time.Duration(m.cfg.OptimizeHours) * time.Hour)
+		case maintenanceIntegrity:
+			err = m.integrityCheck(ctx)
+			m.next[task] = time.Now().Add(time.Duration(m.cfg.IntegrityCheckHours) * time.Hour)
+		}
+		m.recordResult(task, err)
+		m.cycles.finish(time.Now(), err)
+	}
+}
+
+func (m *DatabaseMaintenance) checkpoint(ctx context.Context) error {
+	checkpoint, err := m.store.CheckpointWAL(ctx)
+	if err != nil {
+		return err
+	}
+	size, err := m.store.WALSize(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	m.mu.Lock()
+	m.report.CheckpointAt = &now
+	m.report.Checkpoint = &checkpoint
+	m.report.WALBytes = &size
+	m.mu.Unlock()
+
+	if checkpoint.Busy {
+		m.log.Warn("wal checkpoint blocked by an open transaction",
+			"log_frames", checkpoint.LogFrames,
+			"checkpointed_frames", checkpoint.CheckpointedFrames,
+			"wal_bytes", size)
+		return nil
+	}
+	m.log.Debug("wal checkpointed", "frames", checkpoint.CheckpointedFrames, "wal_bytes", size)
+	return nil
+}
+
+func (m *DatabaseMaintenance) optimize(ctx context.Context) error {
+	if err := m.store.Optimize(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	m.mu.Lock()
+	m.report.OptimizeAt = &now
+	m.mu.Unlock()
+
+	m.log.Debug("planner statistics refreshed")
+	return nil
+}
+
+func (m *DatabaseMaintenance) integrityCheck(ctx context.Context) error {
+	started := time.Now()
+	problems, err := m.store.IntegrityCheck(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	m.mu.Lock()
+	m.report.IntegrityCheckAt = &now
+	m.report.IntegrityProblems = problems
+	m.mu.Unlock()
+
+	if len(problems) > 0 {
+		m.log.Error("database integrity check found problems",
+			"problems", len(problems), "first", problems[0], "duration", time.Since(started))
+		return nil
+	}
+	m.log.Info("database integrity check passed", "duration", time.Since(started))
+	return nil
+}
+
+// recordResult keeps a failed task's error in the report until the task
+// next succeeds.
+func (m *DatabaseMaintenance) recordResult(task string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		delete(m.report.Errors, task)
+		return
+	}
+	m.log.Error("database maintenance failed", "task", task, "err", err)
+	if m.report.Errors == nil {
+		m.report.Errors = make(map[string]string)
+	}
+	m.report.Errors[task] = err.Error()
+}
@@ -15,50 +15,168 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"powerhive/internal/config"
 	"powerhive/internal/database"
 	"powerhive/internal/firmware"
+	"powerhive/internal/server"
+	"powerhive/internal/tracing"
 )
 
 const (
 	apiKeyDescription   = "PowerHive"
-	defaultHTTPPort     = "80"
-	lightScanWorkers    = 32
-	probeWorkers        = 8
 	apiKeyLengthBytes   = 16
 	maxScanResultsQueue = 128
+	// Smallest prefix accepted for on-demand single-subnet scans
+	minTriggerPrefix = 16
+
+	scanTriggerScheduled = "scheduled"
+	scanTriggerManual    = "manual"
 )
 
+// DiscoveryStore is what discovery needs from the database.
+type DiscoveryStore interface {
+	database.MinerStore
+	database.MinerIdentityReader
+	database.UnlockCandidateReader
+	database.ModelStore
+	database.AvailabilityRecorder
+}
+
 // Discoverer performs network discovery to inventory miners.
 type Discoverer struct {
-	store        *database.Store
+	store        DiscoveryStore
 	cfg          config.AppConfig
 	log          *slog.Logger
 	httpClient   *http.Client
 	lightTimeout time.Duration
 	probeTimeout time.Duration
 	interval     time.Duration
+	triggerCh    chan scanRequest
+	reloadCh     chan config.AppConfig
+
+	mu       sync.Mutex
+	running  bool
+	pending  bool
+	lastScan *server.DiscoveryScan
+
+	// events is told about miners marked offline, for the webhooks.
+	events EventNotifier
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// scanRequest describes a single scan run. A nil subnet scans every
+// configured subnet.
+type scanRequest struct {
+	trigger string
+	subnet  *net.IPNet
 }
 
 // NewDiscoverer constructs a discovery service.
-func NewDiscoverer(store *database.Store, cfg config.AppConfig, logger *slog.Logger) *Discoverer {
+func NewDiscoverer(store DiscoveryStore, cfg config.AppConfig, logger *slog.Logger) *Discoverer {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
+	d := &Discoverer{
+		store:     store,
+		log:       logger.With("component", "discovery"),
+		triggerCh: make(chan scanRequest, 1),
+		reloadCh:  make(chan config.AppConfig, 1),
+	}
+	d.applyConfig(cfg)
+	return d
+}
+
+// Reload hands a new configuration to the discovery loop. It takes effect
+// before the next scan.
+func (d *Discoverer) Reload(cfg config.AppConfig) {
+	queueReload(d.reloadCh, cfg)
+}
+
+func (d *Discoverer) applyConfig(cfg config.AppConfig) {
 	probeTimeout := time.Duration(cfg.Network.MinerProbeTimeoutMs) * time.Millisecond
 
-	return &Discoverer{
-		store:        store,
-		cfg:          cfg,
-		log:          logger.With("component", "discovery"),
-		httpClient:   &http.Client{Timeout: probeTimeout},
-		lightTimeout: time.Duration(cfg.Network.LightScanTimeoutMs) * time.Millisecond,
-		probeTimeout: probeTimeout,
-		interval:     time.Duration(cfg.Intervals.DiscoverySeconds) * time.Second,
+	d.cfg = cfg
+	d.httpClient = firmwareHTTPClient(probeTimeout)
+	d.lightTimeout = time.Duration(cfg.Network.LightScanTimeoutMs) * time.Millisecond
+	d.probeTimeout = probeTimeout
+	d.interval = time.Duration(cfg.Intervals.DiscoverySeconds) * time.Second
+}
+
+// TriggerScan queues an immediate scan, optionally limited to one subnet.
+func (d *Discoverer) TriggerScan(subnet string) error {
+	req, err := manualScanRequest(subnet)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running || d.pending {
+		return server.ErrScanInProgress
+	}
+
+	select {
+	case d.triggerCh <- req:
+		d.pending = true
+		return nil
+	default:
+		return server.ErrScanInProgress
 	}
 }
 
+// Scan runs one scan, optionally limited to one subnet, and returns its
+// summary once it finishes. It is meant for one-off scans outside Run.
+func (d *Discoverer) Scan(ctx context.Context, subnet string) (server.DiscoveryScan, error) {
+	req, err := manualScanRequest(subnet)
+	if err != nil {
+		return server.DiscoveryScan{}, err
+	}
+
+	err = d.runScan(ctx, req)
+	d.mu.Lock()
+	report := *d.lastScan
+	d.mu.Unlock()
+	return report, err
+}
+
+// manualScanRequest validates an on-demand scan's subnet. An empty subnet
+// scans every configured subnet.
+func manualScanRequest(subnet string) (scanRequest, error) {
+	req := scanRequest{trigger: scanTriggerManual}
+	if subnet = strings.TrimSpace(subnet); subnet != "" {
+		ipNet, err := parseCIDR(subnet)
+		if err != nil {
+			return scanRequest{}, fmt.Errorf("invalid subnet %q: %w", subnet, err)
+		}
+		if ones, _ := ipNet.Mask.Size(); ones < minTriggerPrefix {
+			return scanRequest{}, fmt.Errorf("subnet %s is too large; use /%d or smaller", subnet, minTriggerPrefix)
+		}
+		req.subnet = ipNet
+	}
+	return req, nil
+}
+
+// ScanStatus reports whether a scan is running and the last scan's summary.
+func (d *Discoverer) ScanStatus() server.DiscoveryStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := server.DiscoveryStatus{
+		Running: d.running,
+		Pending: d.pending,
+	}
+	if d.lastScan != nil {
+		last := *d.lastScan
+		status.LastScan = &last
+	}
+	return status
+}
+
 // Run executes the discovery loop until the context is cancelled.
 func (d *Discoverer) Run(ctx context.Context) {
 	if ctx == nil {
@@ -67,7 +185,9 @@ func (d *Discoverer) Run(ctx context.Context) {
 
 	d.log.Info("starting discovery loop", "interval", d.interval)
 
-	if err := d.scan(ctx); err != nil {
+	err := d.runScan(ctx, scanRequest{trigger: scanTriggerScheduled})
+	d.cycles.finish(time.Now(), err)
+	if err != nil {
 		d.log.Error("initial discovery failed", "err", err)
 	}
 
@@ -80,39 +200,226 @@ func (d *Discoverer) Run(ctx context.Context) {
 			d.log.Info("stopping discovery loop", "reason", ctx.Err())
 			return
 		case <-ticker.C:
-			if err := d.scan(ctx); err != nil {
+			err := d.runScan(ctx, scanRequest{trigger: scanTriggerScheduled})
+			d.cycles.finish(time.Now(), err)
+			if err != nil {
 				d.log.Error("discovery run failed", "err", err)
 			}
+		case req := <-d.triggerCh:
+			d.mu.Lock()
+			d.pending = false
+			d.mu.Unlock()
+
+			err := d.runScan(ctx, req)
+			d.cycles.finish(time.Now(), err)
+			if err != nil {
+				d.log.Error("manual discovery run failed", "err", err)
+			}
+		case cfg := <-d.reloadCh:
+			d.applyConfig(cfg)
+			ticker.Reset(d.interval)
+			d.log.Info("configuration reloaded", "interval", d.interval, "subnets", len(cfg.Network.Subnets))
+		}
+	}
+}
+
+type discoveryResult struct {
+	IP     string
+	Driver string
+	// Port is the firmware API endpoint that answered; it is zero for
+	// stock miners, which are read over the cgminer API.
+	Port   config.FirmwarePort
+	Client *firmware.Client
+	Info   firmware.InfoResponse
+	Model  firmware.ModelResponse
+}
+
+// probeHost identifies the firmware answering at ip under its own span,
+// trying each configured firmware port in turn. It reports false when the
+// host is not a supported miner.
+func (d *Discoverer) probeHost(ctx context.Context, ip string) (discoveryResult, bool) {
+	ctx, span := tracing.Start(ctx, "discovery.probe", attribute.String("miner.ip", ip))
+	// Hosts that are not miners are the common case and not span errors;
+	// only a miner that fails mid-probe is.
+	var probeErr error
+	defer func() { tracing.End(span, probeErr) }()
+
+	var errs []error
+	for _, port := range d.cfg.Network.FirmwarePorts {
+		result, err := d.probeEndpoint(ctx, ip, port)
+		if err == nil {
+			span.SetAttributes(
+				attribute.String("miner.driver", result.Driver),
+				attribute.String("miner.endpoint", endpointAddress(ip, port)),
+			)
+			return result, true
 		}
+		if errors.Is(err, errProbeFailed) {
+			probeErr = err
+			return discoveryResult{}, false
+		}
+		errs = append(errs, err)
 	}
+
+	info, err := d.probeStock(ctx, ip)
+	if err != nil {
+		d.log.Debug("probe host skipped", "ip", ip, "err", errors.Join(append(errs, err)...))
+		return discoveryResult{}, false
+	}
+	span.SetAttributes(attribute.String("miner.driver", firmware.DriverAntminer))
+	return discoveryResult{
+		IP:     ip,
+		Driver: firmware.DriverAntminer,
+		Info:   info,
+		Model:  firmware.ModelResponse{FullName: info.Miner, Model: info.Model},
+	}, true
 }
 
-func (d *Discoverer) scan(ctx context.Context) error {
-	hosts, err := d.enumerateHosts()
+// errProbeFailed marks a host that answered as a miner but could not be
+// read completely; other ports are not tried.
+var errProbeFailed = errors.New("miner probe failed")
+
+// probeEndpoint identifies Vnish, then Braiins OS, at one firmware port.
+func (d *Discoverer) probeEndpoint(ctx context.Context, ip string, port config.FirmwarePort) (discoveryResult, error) {
+	addr := endpointAddress(ip, port)
+	client, err := firmware.NewClient(addr, firmware.WithHTTPClient(d.httpClient))
+	if err != nil {
+		return discoveryResult{}, fmt.Errorf("%s: %w", addr, err)
+	}
+
+	infoCtx, cancelInfo := context.WithTimeout(ctx, d.probeTimeout)
+	info, err := client.Info(infoCtx)
+	cancelInfo()
+	if err != nil {
+		alt, altErr := d.probeBraiins(ctx, addr)
+		if altErr != nil {
+			return discoveryResult{}, fmt.Errorf("%s: vnish: %v; braiins: %w", addr, err, altErr)
+		}
+		return discoveryResult{
+			IP:     ip,
+			Driver: firmware.DriverBraiins,
+			Port:   port,
+			Info:   alt,
+			Model:  firmware.ModelResponse{FullName: alt.Miner, Model: alt.Model},
+		}, nil
+	}
+
+	modelCtx, cancelModel := context.WithTimeout(ctx, d.probeTimeout)
+	model, err := client.Model(modelCtx)
+	cancelModel()
+	if err != nil {
+		d.log.Warn("fetch model data", "ip", ip, "endpoint", addr, "err", err)
+		return discoveryResult{}, fmt.Errorf("%w: fetch model data: %v", errProbeFailed, err)
+	}
+
+	return discoveryResult{
+		IP:     ip,
+		Driver: firmware.DriverVnish,
+		Port:   port,
+		Client: client,
+		Info:   info,
+		Model:  model,
+	}, nil
+}
+
+// Observed hashrates back-fill presets whose firmware reports none, from
+// the last presetHashrateWindow of statuses with at least
+// presetHashrateMinSamples samples per preset.
+const (
+	presetHashrateWindow     = 24 * time.Hour
+	presetHashrateMinSamples = 30
+)
+
+// backfillPresetHashrates fills in expected hashrates from polled statuses.
+// Failures are only logged; the scan result does not depend on them.
+func (d *Discoverer) backfillPresetHashrates(ctx context.Context) {
+	updated, err := d.store.BackfillPresetHashrates(ctx, time.Now().Add(-presetHashrateWindow), presetHashrateMinSamples)
+	if err != nil {
+		d.log.Warn("back-fill preset hashrates", "err", err)
+		return
+	}
+	if updated > 0 {
+		d.log.Info("preset hashrates back-filled from observed data", "presets", updated)
+	}
+}
+
+// runScan executes a scan and records its summary for ScanStatus.
+func (d *Discoverer) runScan(ctx context.Context, req scanRequest) error {
+	d.mu.Lock()
+	d.running = true
+	d.mu.Unlock()
+
+	report := server.DiscoveryScan{
+		Trigger:   req.trigger,
+		StartedAt: time.Now().UTC(),
+	}
+	if req.subnet != nil {
+		report.Subnet = req.subnet.String()
+	}
+
+	ctx, span := tracing.Start(ctx, "discovery.scan",
+		attribute.String("scan.trigger", report.Trigger),
+		attribute.String("scan.subnet", report.Subnet),
+	)
+	err := d.scan(ctx, req, &report)
+	d.backfillPresetHashrates(ctx)
+	span.SetAttributes(
+		attribute.Int("scan.hosts_probed", report.HostsProbed),
+		attribute.Int("scan.hosts_alive", report.HostsAlive),
+		attribute.Int("scan.miners_found", report.MinersFound),
+		attribute.Int("scan.miners_lost", report.MinersLost),
+	)
+	tracing.End(span, err)
+
+	report.FinishedAt = time.Now().UTC()
+	report.Duration = report.FinishedAt.Sub(report.StartedAt)
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	d.mu.Lock()
+	d.running = false
+	d.lastScan = &report
+	d.mu.Unlock()
+
+	d.log.Debug("discovery scan finished",
+		"trigger", report.Trigger,
+		"duration", report.Duration,
+		"hosts_probed", report.HostsProbed,
+		"hosts_alive", report.HostsAlive,
+		"miners_found", report.MinersFound,
+		"miners_lost", report.MinersLost,
+	)
+
+	return err
+}
+
+func (d *Discoverer) scan(ctx context.Context, req scanRequest, report *server.DiscoveryScan) error {
+	targets, err := d.enumerateTargets(req.subnet)
 	if err != nil {
 		return fmt.Errorf("enumerate hosts: %w", err)
 	}
-	if len(hosts) == 0 {
+	if len(targets) == 0 {
 		return nil
 	}
 
-	candidates := d.lightScan(ctx, hosts)
-	if len(candidates) == 0 {
-		return d.markOffline(ctx, map[string]struct{}{})
+	for _, target := range targets {
+		report.HostsProbed += len(target.hosts)
 	}
 
-	type discoveryResult struct {
-		IP     string
-		Client *firmware.Client
-		Info   firmware.InfoResponse
-		Model  firmware.ModelResponse
+	candidates := d.lightScan(ctx, targets)
+	report.HostsAlive = len(candidates)
+	if len(candidates) == 0 {
+		lost, err := d.markOffline(ctx, map[string]struct{}{}, req.subnet)
+		report.MinersLost = lost
+		return err
 	}
 
 	resultCh := make(chan discoveryResult, maxScanResultsQueue)
 	ipCh := make(chan string)
 
 	var wg sync.WaitGroup
-	workerCount := probeWorkers
+	workerCount := d.cfg.Concurrency.ProbeWorkers
 	if len(candidates) < workerCount {
 		workerCount = len(candidates)
 	}
@@ -131,37 +438,14 @@ func (d *Discoverer) scan(ctx context.Context) error {
 				default:
 				}
 
-				client, err := firmware.NewClient(ip, firmware.WithHTTPClient(d.httpClient))
-				if err != nil {
-					d.log.Warn("create firmware client", "ip", ip, "err", err)
-					continue
-				}
-
-				infoCtx, cancelInfo := context.WithTimeout(ctx, d.probeTimeout)
-				info, err := client.Info(infoCtx)
-				cancelInfo()
-				if err != nil {
-					d.log.Debug("probe host skipped", "ip", ip, "err", err)
-					continue
-				}
-
-				modelCtx, cancelModel := context.WithTimeout(ctx, d.probeTimeout)
-				model, err := client.Model(modelCtx)
-				cancelModel()
-				if err != nil {
-					d.log.Warn("fetch model data", "ip", ip, "err", err)
+				result, ok := d.probeHost(ctx, ip)
+				if !ok {
 					continue
 				}
-
 				select {
 				case <-ctx.Done():
 					return
-				case resultCh <- discoveryResult{
-					IP:     ip,
-					Client: client,
-					Info:   info,
-					Model:  model,
-				}:
+				case resultCh <- result:
 				}
 			}
 		}()
@@ -190,35 +474,124 @@ func (d *Discoverer) scan(ctx context.Context) error {
 			d.log.Error("apply discovery", "ip", res.IP, "err", err)
 		}
 	}
+	report.MinersFound = len(discovered)
 
-	return d.markOffline(ctx, discovered)
+	lost, err := d.markOffline(ctx, discovered, req.subnet)
+	report.MinersLost = lost
+	return err
 }
 
-func (d *Discoverer) enumerateHosts() ([]string, error) {
+// scanTarget groups the hosts of one configured subnet with the light-scan
+// strategies enabled for it.
+type scanTarget struct {
+	network *net.IPNet
+	hosts   []string
+	methods []string
+}
+
+// enumerateTargets expands the configured subnets into hosts. When only is
+// set, just that network is scanned, using the strategies of the configured
+// subnet that contains it (TCP otherwise).
+func (d *Discoverer) enumerateTargets(only *net.IPNet) ([]scanTarget, error) {
+	if only != nil {
+		methods := []string{config.ScanTCP}
+		for _, subnet := range d.cfg.Network.Subnets {
+			ipNet, err := parseCIDR(strings.TrimSpace(subnet.CIDR))
+			if err != nil {
+				continue
+			}
+			if ipNet.Contains(only.IP) && len(subnet.Scan) > 0 {
+				methods = subnet.Scan
+				break
+			}
+		}
+		hosts := expandCIDR(only)
+		if len(hosts) == 0 {
+			return nil, nil
+		}
+		return []scanTarget{{network: only, hosts: hosts, methods: methods}}, nil
+	}
+
 	seen := make(map[string]struct{})
+	var targets []scanTarget
 	for _, subnet := range d.cfg.Network.Subnets {
-		subnet = strings.TrimSpace(subnet)
-		if subnet == "" {
+		cidr := strings.TrimSpace(subnet.CIDR)
+		if cidr == "" {
 			continue
 		}
-		ipNet, err := parseCIDR(subnet)
+		ipNet, err := parseCIDR(cidr)
 		if err != nil {
-			d.log.Warn("parse subnet failed", "subnet", subnet, "err", err)
+			d.log.Warn("parse subnet failed", "subnet", cidr, "err", err)
 			continue
 		}
+
+		target := scanTarget{network: ipNet, methods: subnet.Scan}
 		for _, ip := range expandCIDR(ipNet) {
+			if _, dup := seen[ip]; dup {
+				continue
+			}
 			seen[ip] = struct{}{}
+			target.hosts = append(target.hosts, ip)
+		}
+		if len(target.hosts) > 0 {
+			targets = append(targets, target)
 		}
 	}
+	return targets, nil
+}
 
-	hosts := make([]string, 0, len(seen))
-	for ip := range seen {
-		hosts = append(hosts, ip)
+// lightScan runs every configured strategy per subnet and returns the union
+// of hosts that looked alive.
+func (d *Discoverer) lightScan(ctx context.Context, targets []scanTarget) []string {
+	found := make(map[string]struct{})
+	var results []string
+	add := func(hosts []string) {
+		for _, ip := range hosts {
+			if _, ok := found[ip]; ok {
+				continue
+			}
+			found[ip] = struct{}{}
+			results = append(results, ip)
+		}
 	}
-	return hosts, nil
+
+	for _, target := range targets {
+		methods := target.methods
+		if len(methods) == 0 {
+			methods = []string{config.ScanTCP}
+		}
+
+		for _, method := range methods {
+			if ctx.Err() != nil {
+				return results
+			}
+
+			switch method {
+			case config.ScanICMP:
+				alive, err := icmpSweep(ctx, target.hosts, d.lightTimeout)
+				if err != nil {
+					d.log.Warn("icmp sweep failed, falling back to tcp", "subnet", target.network.String(), "err", err)
+					add(d.tcpScan(ctx, target.hosts))
+					continue
+				}
+				add(alive)
+			case config.ScanARP:
+				alive, err := arpNeighbors(target.network)
+				if err != nil {
+					d.log.Warn("arp scan failed", "subnet", target.network.String(), "err", err)
+					continue
+				}
+				add(alive)
+			default:
+				add(d.tcpScan(ctx, target.hosts))
+			}
+		}
+	}
+
+	return results
 }
 
-func (d *Discoverer) lightScan(ctx context.Context, hosts []string) []string {
+func (d *Discoverer) tcpScan(ctx context.Context, hosts []string) []string {
 	var (
 		results []string
 		wg      sync.WaitGroup
@@ -226,7 +599,7 @@ func (d *Discoverer) lightScan(ctx context.Context, hosts []string) []string {
 		outCh   = make(chan string, len(hosts))
 	)
 
-	workers := lightScanWorkers
+	workers := d.cfg.Concurrency.LightScanWorkers
 	if len(hosts) < workers {
 		workers = len(hosts)
 	}
@@ -277,27 +650,31 @@ func (d *Discoverer) lightScan(ctx context.Context, hosts []string) []string {
 	return results
 }
 
+// pingHost reports whether any firmware port on ip accepts a connection.
 func (d *Discoverer) pingHost(ctx context.Context, ip string) bool {
 	dialer := &net.Dialer{
 		Timeout: d.lightTimeout,
 	}
 
-	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, defaultHTTPPort))
-	if err != nil {
-		return false
+	for _, port := range d.cfg.Network.FirmwarePorts {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(port.Port)))
+		if err != nil {
+			continue
+		}
+		_ = conn.Close()
+		return true
 	}
-	_ = conn.Close()
-	return true
+	return false
 }
 
-func (d *Discoverer) applyDiscovery(ctx context.Context, res struct {
-	IP     string
-	Client *firmware.Client
-	Info   firmware.InfoResponse
-	Model  firmware.ModelResponse
-}, discovered map[string]struct{}) error {
+func (d *Discoverer) applyDiscovery(ctx context.Context, res discoveryResult, discovered map[string]struct{}) error {
 	mac := strings.TrimSpace(strings.ToLower(res.Info.System.NetworkStatus.MAC))
-	if mac == "" {
+	serial := strings.TrimSpace(res.Info.Serial)
+	minerID, err := d.identify(ctx, mac, serial)
+	if err != nil {
+		return err
+	}
+	if minerID == "" {
 		return fmt.Errorf("missing mac address for ip %s", res.IP)
 	}
 
@@ -310,33 +687,53 @@ func (d *Discoverer) applyDiscovery(ctx context.Context, res struct {
 		modelAlias = strings.TrimSpace(res.Info.Model)
 	}
 	if modelAlias == "" {
-		return fmt.Errorf("model alias unavailable for mac %s", mac)
+		return fmt.Errorf("model alias unavailable for miner %s", minerID)
 	}
 
-	var currentMaxPreset *string
+	var currentMaxPreset, currentMinPreset *string
 	if existing, err := d.store.GetModelByAlias(ctx, modelAlias); err == nil {
 		currentMaxPreset = existing.MaxPreset
+		currentMinPreset = existing.MinPreset
 	}
 
 	if _, err := d.store.UpsertModel(ctx, database.ModelInput{
 		Name:      modelName,
 		Alias:     modelAlias,
 		MaxPreset: currentMaxPreset,
+		MinPreset: currentMinPreset,
 	}); err != nil {
 		return fmt.Errorf("upsert model %s: %w", modelAlias, err)
 	}
 
 	ipCopy := res.IP
-	miner, err := d.store.UpsertMiner(ctx, database.UpsertMinerParams{
-		ID:         strings.ToLower(mac),
+	fwName := strings.TrimSpace(res.Info.FWName)
+	fwVersion := strings.TrimSpace(res.Info.FWVersion)
+	params := database.UpsertMinerParams{
+		ID:         minerID,
+		Serial:     &serial,
 		IP:         &ipCopy,
 		ModelAlias: &modelAlias,
-	})
+		FWName:     &fwName,
+		FWVersion:  &fwVersion,
+		Driver:     &res.Driver,
+	}
+	if res.Port.Port != 0 {
+		params.APIScheme = &res.Port.Scheme
+		params.APIPort = &res.Port.Port
+	}
+	miner, err := d.store.UpsertMiner(ctx, params)
 	if err != nil {
-		return fmt.Errorf("upsert miner %s: %w", mac, err)
+		return fmt.Errorf("upsert miner %s: %w", minerID, err)
 	}
 
 	discovered[strings.ToLower(miner.ID)] = struct{}{}
+	recordAvailability(ctx, d.store, d.log, miner.ID, true, database.AvailabilityDiscovery, nil)
+
+	// API keys and autotune presets are Vnish concepts; other drivers are
+	// configured through the model's presets.
+	if res.Client == nil {
+		return nil
+	}
 
 	apiKey, err := d.ensureAPIKey(ctx, miner, res.Client)
 	if err != nil {
@@ -346,7 +743,7 @@ func (d *Discoverer) applyDiscovery(ctx context.Context, res struct {
 	}
 
 	if miner.Model != nil && len(miner.Model.Presets) == 0 && miner.APIKey != nil && strings.TrimSpace(*miner.APIKey) != "" {
-		presets, err := d.fetchPresets(ctx, res.Client, modelName, modelAlias, miner.Model.MaxPreset)
+		presets, err := d.fetchPresets(ctx, res.Client, modelName, modelAlias, miner.Model.MaxPreset, miner.Model.MinPreset)
 		if err != nil {
 			d.log.Warn("fetch presets", "miner", miner.ID, "ip", res.IP, "err", err)
 		}
@@ -358,6 +755,87 @@ func (d *Discoverer) applyDiscovery(ctx context.Context, res struct {
 	return nil
 }
 
+// identify returns the ID of the miner with the given MAC and serial
+// number. With the serial identity a miner already recorded under the
+// serial keeps its ID, so a new control board or a virtual MAC does not
+// create a duplicate, and a miner without a MAC is keyed by its serial.
+// Otherwise the MAC is the ID. It returns "" when neither identifies the
+// miner.
+func (d *Discoverer) identify(ctx context.Context, mac, serial string) (string, error) {
+	if d.cfg.Network.Identity != config.IdentitySerial || serial == "" {
+		return mac, nil
+	}
+
+	existing, err := d.store.FindMinerBySerial(ctx, serial)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		if mac != "" && existing.ID != mac {
+			d.log.Debug("miner identified by serial", "miner", existing.ID, "serial", serial, "mac", mac)
+		}
+		return existing.ID, nil
+	}
+	if mac != "" {
+		return mac, nil
+	}
+	return serialMinerID(serial), nil
+}
+
+// serialMinerID builds the ID of a miner known only by its serial number.
+func serialMinerID(serial string) string {
+	var b strings.Builder
+	b.WriteString("sn-")
+	for _, r := range strings.ToLower(serial) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == len("sn-") {
+		return ""
+	}
+	return b.String()
+}
+
+// probeBraiins identifies Braiins OS at addr using the fleet credentials
+// from the config.
+func (d *Discoverer) probeBraiins(ctx context.Context, addr string) (firmware.InfoResponse, error) {
+	braiins, err := firmware.NewBraiinsClient(addr,
+		d.cfg.Firmware.Braiins.Username,
+		d.cfg.Firmware.Braiins.Password,
+		firmware.WithHTTPClient(d.httpClient))
+	if err != nil {
+		return firmware.InfoResponse{}, err
+	}
+
+	infoCtx, cancel := context.WithTimeout(ctx, d.probeTimeout)
+	defer cancel()
+	return braiins.Info(infoCtx)
+}
+
+// probeStock identifies stock firmware through the cgminer API. Stock
+// miners are keyed by the MAC from the ARP cache.
+func (d *Discoverer) probeStock(ctx context.Context, ip string) (firmware.InfoResponse, error) {
+	stock, err := firmware.NewAntminerDriver(ip, firmware.WithHTTPClient(d.httpClient))
+	if err != nil {
+		return firmware.InfoResponse{}, err
+	}
+
+	infoCtx, cancel := context.WithTimeout(ctx, d.probeTimeout)
+	info, err := stock.Info(infoCtx)
+	cancel()
+	if err != nil {
+		return firmware.InfoResponse{}, fmt.Errorf("cgminer: %w", err)
+	}
+
+	mac, err := arpMAC(ip)
+	if err != nil {
+		return firmware.InfoResponse{}, fmt.Errorf("identify stock miner: %w", err)
+	}
+	info.System.NetworkStatus.MAC = mac
+	return info, nil
+}
+
 func (d *Discoverer) ensureAPIKey(ctx context.Context, miner database.Miner, client *firmware.Client) (string, error) {
 	if client == nil {
 		return "", fmt.Errorf("firmware client is nil")
@@ -368,12 +846,9 @@ func (d *Discoverer) ensureAPIKey(ctx context.Context, miner database.Miner, cli
 		return strings.TrimSpace(*miner.APIKey), nil
 	}
 
-	ctxUnlock, cancelUnlock := context.WithTimeout(ctx, d.probeTimeout)
-	defer cancelUnlock()
-
-	token, err := client.Unlock(ctxUnlock, miner.UnlockPass)
+	token, err := unlockMiner(ctx, d.store, client, miner, d.probeTimeout, d.log)
 	if err != nil {
-		return "", fmt.Errorf("unlock miner: %w", err)
+		return "", err
 	}
 
 	ctxKeys, cancelKeys := context.WithTimeout(ctx, d.probeTimeout)
@@ -428,7 +903,7 @@ func (d *Discoverer) storeAPIKey(ctx context.Context, minerID, key string) error
 	return nil
 }
 
-func (d *Discoverer) fetchPresets(ctx context.Context, client *firmware.Client, modelName, modelAlias string, maxPreset *string) ([]string, error) {
+func (d *Discoverer) fetchPresets(ctx context.Context, client *firmware.Client, modelName, modelAlias string, maxPreset, minPreset *string) ([]string, error) {
 	if client == nil {
 		return nil, fmt.Errorf("firmware client is nil")
 	}
@@ -461,6 +936,7 @@ func (d *Discoverer) fetchPresets(ctx context.Context, client *firmware.Client,
 		Alias:     modelAlias,
 		Presets:   values,
 		MaxPreset: maxPreset,
+		MinPreset: minPreset,
 	}); err != nil {
 		return nil, fmt.Errorf("update model presets %s: %w", modelAlias, err)
 	}
@@ -495,8 +971,11 @@ func (d *Discoverer) fetchPresets(ctx context.Context, client *firmware.Client,
 			}
 		}
 
-		// Try to extract hashrate from pretty field (e.g., "2150 watt ~ 83 TH")
-		if preset.Pretty != "" {
+		// Prefer a hashrate in tune_settings, then the pretty field
+		// (e.g., "2150 watt ~ 83 TH")
+		if hashrate, ok := tuneSettingHashrate(preset.TuneSettings); ok {
+			hashratePtr = &hashrate
+		} else if preset.Pretty != "" {
 			hashrate := extractHashrateFromPretty(preset.Pretty)
 			if hashrate > 0 {
 				hashratePtr = &hashrate
@@ -517,12 +996,16 @@ func (d *Discoverer) fetchPresets(ctx context.Context, client *firmware.Client,
 	return values, nil
 }
 
-func (d *Discoverer) markOffline(ctx context.Context, discovered map[string]struct{}) error {
+// markOffline clears the IP of miners that were not seen in this scan and
+// returns how many were marked offline. When scope is set only miners inside
+// that network are considered.
+func (d *Discoverer) markOffline(ctx context.Context, discovered map[string]struct{}, scope *net.IPNet) (int, error) {
 	miners, err := d.store.ListMiners(ctx)
 	if err != nil {
-		return fmt.Errorf("list miners: %w", err)
+		return 0, fmt.Errorf("list miners: %w", err)
 	}
 
+	lost := 0
 	for _, miner := range miners {
 		if _, ok := discovered[strings.ToLower(miner.ID)]; ok {
 			continue
@@ -530,20 +1013,25 @@ func (d *Discoverer) markOffline(ctx context.Context, discovered map[string]stru
 		if miner.IP == nil || strings.TrimSpace(*miner.IP) == "" {
 			continue
 		}
-		empty := ""
-		if _, err := d.store.UpsertMiner(ctx, database.UpsertMinerParams{
-			ID: strings.ToLower(miner.ID),
-			IP: &empty,
-		}); err != nil {
+		// A miner PowerHive is rebooting is expected to be missing.
+		if miner.Rebooting(time.Now()) {
+			continue
+		}
+		if scope != nil {
+			if ip := net.ParseIP(strings.TrimSpace(*miner.IP)); ip == nil || !scope.Contains(ip) {
+				continue
+			}
+		}
+		if err := takeOffline(ctx, d.store, d.log, d.events, miner, database.AvailabilityDiscovery, "missing from discovery scan"); err != nil {
 			if errors.Is(err, context.Canceled) {
-				return err
+				return lost, err
 			}
 			d.log.Warn("mark miner offline failed", "miner", miner.ID, "err", err)
 			continue
 		}
-		d.log.Info("miner offline", "miner", miner.ID)
+		lost++
 	}
-	return nil
+	return lost, nil
 }
 
 func parseCIDR(cidr string) (*net.IPNet, error) {
@@ -590,6 +1078,34 @@ func generateAPIKey() (string, error) {
 	return hex.EncodeToString(buf), nil
 }
 
+// tuneSettingHashrate reads a preset's expected hashrate in TH/s from its
+// tune settings. Values above 10,000 are taken to be GH/s.
+func tuneSettingHashrate(settings map[string]interface{}) (float64, bool) {
+	for _, key := range []string{"hashrate", "target_hashrate", "hashrate_ths", "ths"} {
+		var value float64
+		switch v := settings[key].(type) {
+		case float64:
+			value = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				continue
+			}
+			value = parsed
+		default:
+			continue
+		}
+		if value <= 0 {
+			continue
+		}
+		if value > 10000 {
+			value /= 1000
+		}
+		return value, true
+	}
+	return 0, false
+}
+
 // extractHashrateFromPretty extracts the terahash value from preset pretty strings.
 // Example: "2150 watt ~ 83 TH" -> 83.0
 func extractHashrateFromPretty(pretty string) float64 {
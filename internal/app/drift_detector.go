@@ -0,0 +1,442 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+const (
+	driftRequestTimeout = 10 * time.Second
+	// driftSettleTime leaves presets the balancer changed recently to the
+	// preset verifier.
+	driftSettleTime = 2 * time.Minute
+)
+
+// Settings checked for drift.
+const (
+	driftPreset      = "preset"
+	driftCoolingMode = "cooling.mode"
+	driftFanMinCount = "cooling.fan_min_count"
+	driftFanMinDuty  = "cooling.fan_min_duty"
+	driftFanMaxDuty  = "cooling.fan_max_duty"
+	driftFanDuty     = "cooling.fan_duty"
+	driftPools       = "pools"
+)
+
+// DriftStore is what drift detection needs from the database.
+type DriftStore interface {
+	database.MinerReader
+	database.EventRecorder
+	database.BalanceRecorder
+}
+
+// DriftDetector compares each managed miner's live settings with the
+// settings PowerHive wants it to run, records drift and optionally applies
+// the desired settings again.
+type DriftDetector struct {
+	store    DriftStore
+	cfg      config.AppConfig
+	log      *slog.Logger
+	interval time.Duration
+	reloadCh chan config.AppConfig
+	drivers  *driverCache
+
+	// drifting holds the live value of each drifting field, keyed by miner
+	// ID and field, so a drift is reported once rather than every cycle.
+	drifting map[string]map[string]string
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// desiredSettings is what a miner should be running. Nil or empty fields
+// are not checked.
+type desiredSettings struct {
+	preset  *string
+	cooling *database.CoolingSettingsInput
+	pools   []database.PoolInput
+}
+
+// checks reports whether field is compared against a desired value.
+func (s desiredSettings) checks(field string) bool {
+	section, _, _ := strings.Cut(field, ".")
+	switch section {
+	case driftPreset:
+		return s.preset != nil
+	case "cooling":
+		return s.cooling != nil
+	case driftPools:
+		return len(s.pools) > 0
+	}
+	return false
+}
+
+// settingDrift is one field whose live value differs from the desired one.
+type settingDrift struct {
+	field   string
+	desired string
+	actual  string
+}
+
+// NewDriftDetector constructs the settings drift detection service.
+func NewDriftDetector(store DriftStore, cfg config.AppConfig, logger *slog.Logger) *DriftDetector {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &DriftDetector{
+		store:    store,
+		cfg:      cfg,
+		log:      logger.With("component", "drift"),
+		interval: time.Duration(cfg.Drift.IntervalSeconds) * time.Second,
+		reloadCh: make(chan config.AppConfig, 1),
+		drivers:  newDriverCache(firmwareHTTPClient(firmwareClientTimeout)),
+		drifting: make(map[string]map[string]string),
+	}
+}
+
+// Enabled reports whether drift detection should run.
+func (d *DriftDetector) Enabled() bool {
+	return !d.cfg.Drift.Disabled
+}
+
+// Reload hands a new configuration to the detection loop.
+func (d *DriftDetector) Reload(cfg config.AppConfig) {
+	queueReload(d.reloadCh, cfg)
+}
+
+// Run checks the fleet once per interval until the context is cancelled.
+func (d *DriftDetector) Run(ctx context.Context) {
+	d.log.Info("starting drift detection loop", "interval", d.interval, "remediate", d.cfg.Drift.Remediate)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.log.Info("stopping drift detection loop", "reason", ctx.Err())
+			return
+		case <-ticker.C:
+			err := d.check(ctx)
+			d.cycles.finish(time.Now(), err)
+			if err != nil {
+				d.log.Error("drift check failed", "err", err)
+			}
+		case cfg := <-d.reloadCh:
+			d.cfg = cfg
+			d.interval = time.Duration(cfg.Drift.IntervalSeconds) * time.Second
+			ticker.Reset(d.interval)
+			d.log.Info("configuration reloaded", "interval", d.interval, "remediate", cfg.Drift.Remediate)
+		}
+	}
+}
+
+func (d *DriftDetector) check(ctx context.Context) error {
+	miners, err := d.store.ListMiners(ctx)
+	if err != nil {
+		return fmt.Errorf("list miners: %w", err)
+	}
+	d.drivers.retain(miners)
+
+	seen := make(map[string]bool, len(miners))
+	for _, miner := range miners {
+		if !miner.Managed || !driverReady(miner) {
+			continue
+		}
+		seen[miner.ID] = true
+		if err := d.checkMiner(ctx, miner); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			d.log.Warn("miner drift check failed", "miner", miner.ID, "err", err)
+		}
+	}
+
+	for id := range d.drifting {
+		if !seen[id] {
+			delete(d.drifting, id)
+		}
+	}
+	return nil
+}
+
+func (d *DriftDetector) checkMiner(ctx context.Context, miner database.Miner) error {
+	desired, err := d.desired(ctx, miner)
+	if err != nil {
+		return err
+	}
+	if desired.preset == nil && desired.cooling == nil && len(desired.pools) == 0 {
+		return nil
+	}
+
+	driver, err := d.drivers.get(d.cfg, miner)
+	if err != nil {
+		return fmt.Errorf("create firmware driver: %w", err)
+	}
+	reader, ok := driver.(firmware.SettingsReader)
+	if !ok {
+		return nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, driftRequestTimeout)
+	live, err := reader.ReadSettings(reqCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("read settings: %w", err)
+	}
+
+	drifts := diffSettings(desired, live)
+	d.report(ctx, miner, desired, drifts)
+	if len(drifts) > 0 && d.cfg.Drift.Remediate {
+		d.remediate(ctx, miner, driver, desired, drifts)
+	}
+	return nil
+}
+
+// desired returns the settings the miner should be running: the cooling and
+// pools of its settings snapshot, and the preset the balancer last applied
+// unless the snapshot is newer. Held miners and presets changed within
+// driftSettleTime are not checked for preset drift; protected miners are
+// checked for cooling only.
+func (d *DriftDetector) desired(ctx context.Context, miner database.Miner) (desiredSettings, error) {
+	var desired desiredSettings
+	var settingsAt time.Time
+	if miner.Settings != nil {
+		input := miner.Settings.Input()
+		desired.preset = input.Preset
+		desired.cooling = &input.Cooling
+		desired.pools = input.Pools
+		settingsAt = miner.Settings.CreatedAt
+	}
+
+	if miner.Protected {
+		desired.preset, desired.pools = nil, nil
+		return desired, nil
+	}
+	now := time.Now()
+	if miner.Held(now) || (miner.LastPresetChangeAt != nil && now.Sub(*miner.LastPresetChangeAt) < driftSettleTime) {
+		desired.preset = nil
+		return desired, nil
+	}
+
+	events, err := d.store.ListPowerBalanceEvents(ctx, &miner.ID, 20)
+	if err != nil {
+		return desiredSettings{}, fmt.Errorf("list balance events: %w", err)
+	}
+	for _, event := range events {
+		if !event.Success || event.NewPreset == nil {
+			continue
+		}
+		if event.RecordedAt.After(settingsAt) {
+			desired.preset = event.NewPreset
+		}
+		break
+	}
+	return desired, nil
+}
+
+// diffSettings lists the desired fields the live settings do not match.
+func diffSettings(desired desiredSettings, live firmware.MinerConfig) []settingDrift {
+	var drifts []settingDrift
+
+	if desired.preset != nil && live.Overclock != nil && !samePreset(&live.Overclock.Preset, *desired.preset) {
+		drifts = append(drifts, settingDrift{field: driftPreset, desired: *desired.preset, actual: live.Overclock.Preset})
+	}
+
+	if want, have := desired.cooling, live.Cooling; want != nil && have != nil {
+		var mode string
+		var duty *int
+		if have.Mode != nil {
+			mode, duty = have.Mode.Name, have.Mode.Param
+		}
+		if want.Mode != "" && !strings.EqualFold(want.Mode, mode) {
+			drifts = append(drifts, settingDrift{field: driftCoolingMode, desired: want.Mode, actual: mode})
+		}
+		for _, check := range []struct {
+			field      string
+			want, have *int
+		}{
+			{driftFanMinCount, want.FanMinCount, have.FanMinCount},
+			{driftFanMinDuty, want.FanMinDuty, have.FanMinDuty},
+			{driftFanMaxDuty, want.FanMaxDuty, have.FanMaxDuty},
+		} {
+			if check.want != nil && (check.have == nil || *check.want != *check.have) {
+				drifts = append(drifts, settingDrift{field: check.field, desired: formatIntPtr(check.want), actual: formatIntPtr(check.have)})
+			}
+		}
+		if strings.EqualFold(want.Mode, firmware.CoolingManual) && want.FanDuty != nil && (duty == nil || *duty != *want.FanDuty) {
+			drifts = append(drifts, settingDrift{field: driftFanDuty, desired: formatIntPtr(want.FanDuty), actual: formatIntPtr(duty)})
+		}
+	}
+
+	// Pool passwords are compared only by the firmware, which may mask them.
+	if len(desired.pools) > 0 && live.Pools != nil {
+		wantPools := make([]string, 0, len(desired.pools))
+		for _, pool := range desired.pools {
+			wantPools = append(wantPools, formatPool(pool.URL, safeString(pool.Username)))
+		}
+		havePools := make([]string, 0, len(live.Pools))
+		for _, pool := range live.Pools {
+			havePools = append(havePools, formatPool(pool.URL, pool.User))
+		}
+		if want, have := strings.Join(wantPools, ", "), strings.Join(havePools, ", "); want != have {
+			drifts = append(drifts, settingDrift{field: driftPools, desired: want, actual: have})
+		}
+	}
+
+	return drifts
+}
+
+// report records newly detected drift, and resolved drift for the fields
+// that were checked.
+func (d *DriftDetector) report(ctx context.Context, miner database.Miner, desired desiredSettings, drifts []settingDrift) {
+	state := d.drifting[miner.ID]
+	if state == nil {
+		state = make(map[string]string)
+	}
+
+	current := make(map[string]bool, len(drifts))
+	for _, drift := range drifts {
+		current[drift.field] = true
+		if actual, known := state[drift.field]; known && actual == drift.actual {
+			continue
+		}
+		state[drift.field] = drift.actual
+		d.log.Warn("settings drift detected", "miner", miner.ID, "ip", safeString(miner.IP),
+			"field", drift.field, "desired", drift.desired, "actual", drift.actual)
+		d.record(ctx, database.DriftEvent{
+			MinerID: miner.ID,
+			Field:   drift.field,
+			Desired: ptrString(drift.desired),
+			Actual:  ptrString(drift.actual),
+			Action:  database.DriftActionDetected,
+			Success: true,
+		})
+	}
+
+	for field := range state {
+		if current[field] || !desired.checks(field) {
+			continue
+		}
+		delete(state, field)
+		d.log.Info("settings drift resolved", "miner", miner.ID, "field", field)
+		d.record(ctx, database.DriftEvent{
+			MinerID: miner.ID,
+			Field:   field,
+			Action:  database.DriftActionResolved,
+			Success: true,
+		})
+	}
+
+	if len(state) == 0 {
+		delete(d.drifting, miner.ID)
+		return
+	}
+	d.drifting[miner.ID] = state
+}
+
+// remediate applies the desired value of each drifting section once and
+// records the outcome against every drifting field.
+func (d *DriftDetector) remediate(ctx context.Context, miner database.Miner, driver firmware.MinerDriver, desired desiredSettings, drifts []settingDrift) {
+	results := make(map[string]error)
+	for _, drift := range drifts {
+		section, _, _ := strings.Cut(drift.field, ".")
+		err, done := results[section]
+		if !done {
+			err = d.apply(ctx, miner, driver, desired, section)
+			results[section] = err
+		}
+
+		event := database.DriftEvent{
+			MinerID: miner.ID,
+			Field:   drift.field,
+			Desired: ptrString(drift.desired),
+			Actual:  ptrString(drift.actual),
+			Action:  database.DriftActionRemediated,
+			Success: err == nil,
+		}
+		if err != nil {
+			event.ErrorMessage = ptrString(err.Error())
+		}
+		d.record(ctx, event)
+		if !done {
+			if err != nil {
+				d.log.Error("settings drift remediation failed", "miner", miner.ID, "settings", section, "err", err)
+			} else {
+				d.log.Info("settings drift remediated", "miner", miner.ID, "settings", section)
+			}
+		}
+	}
+}
+
+func (d *DriftDetector) apply(ctx context.Context, miner database.Miner, driver firmware.MinerDriver, desired desiredSettings, section string) error {
+	reqCtx, cancel := context.WithTimeout(ctx, driftRequestTimeout)
+	defer cancel()
+
+	switch section {
+	case driftPreset:
+		result, err := driver.SetPowerTarget(reqCtx, *desired.preset)
+		if err != nil {
+			return err
+		}
+		restartIfRequired(reqCtx, d.log, driver, miner, *desired.preset, result)
+		if err := d.store.RecordPresetChange(ctx, miner.ID, time.Now()); err != nil {
+			d.log.Warn("failed to record preset change time", "miner", miner.ID, "err", err)
+		}
+		return nil
+	case "cooling":
+		controller, ok := driver.(firmware.CoolingController)
+		if !ok {
+			return firmware.ErrUnsupported
+		}
+		_, err := controller.SetCooling(reqCtx, firmwareCooling(*desired.cooling))
+		return err
+	case driftPools:
+		controller, ok := driver.(firmware.PoolController)
+		if !ok {
+			return firmware.ErrUnsupported
+		}
+		pools := make([]firmware.PoolSettings, 0, len(desired.pools))
+		for _, pool := range desired.pools {
+			pools = append(pools, firmware.PoolSettings{
+				URL:  strings.TrimSpace(pool.URL),
+				User: safeString(pool.Username),
+				Pass: safeString(pool.Password),
+			})
+		}
+		_, err := controller.SetPools(reqCtx, pools)
+		return err
+	default:
+		return fmt.Errorf("unknown settings section %q", section)
+	}
+}
+
+func (d *DriftDetector) record(ctx context.Context, event database.DriftEvent) {
+	if _, err := d.store.RecordDriftEvent(ctx, event); err != nil {
+		d.log.Warn("failed to record drift event", "miner", event.MinerID, "err", err)
+	}
+}
+
+func formatIntPtr(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+func formatPool(url, user string) string {
+	url, user = strings.TrimSpace(url), strings.TrimSpace(user)
+	if user == "" {
+		return url
+	}
+	return url + " (" + user + ")"
+}
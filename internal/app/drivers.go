@@ -0,0 +1,214 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+// firmwareClientTimeout bounds requests from callers that do not size their
+// own client.
+const firmwareClientTimeout = 5 * time.Second
+
+// firmwareRequests bounds the firmware HTTP requests in flight across all
+// services. App sizes it from the configuration.
+var firmwareRequests = firmware.NewLimiter(0)
+
+// firmwareTransport carries every firmware request. App installs the TLS
+// settings from the configuration; reloads swap them in place.
+var firmwareTransport = &swappableTransport{}
+
+// firmwareLog records firmware HTTP exchanges while debug logging is on.
+var firmwareLog = firmware.NewRequestLog(0)
+
+// firmwareHTTPClient returns a client whose requests count against
+// firmwareRequests and are recorded in firmwareLog.
+func firmwareHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: firmwareRequests.Transport(firmwareLog.Transport(firmwareTransport)),
+	}
+}
+
+// configureFirmwareTLS applies the configured certificate checks to
+// firmwareTransport.
+func configureFirmwareTLS(cfg config.TLSConfig) error {
+	transport, err := firmware.NewTransport(firmware.TLSOptions{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		PinnedSHA256:       cfg.PinnedSHA256,
+	})
+	if err != nil {
+		return fmt.Errorf("configure firmware tls: %w", err)
+	}
+	firmwareTransport.swap(transport)
+	return nil
+}
+
+// swappableTransport forwards to a transport that can be replaced while
+// clients hold on to it.
+type swappableTransport struct {
+	current atomic.Pointer[http.Transport]
+}
+
+func (t *swappableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport := t.current.Load(); transport != nil {
+		return transport.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (t *swappableTransport) swap(transport *http.Transport) {
+	if old := t.current.Swap(transport); old != nil {
+		old.CloseIdleConnections()
+	}
+}
+
+// minerAddress returns the firmware API address recorded for the miner:
+// its IP, prefixed with the scheme and port when discovery stored them.
+func minerAddress(miner database.Miner) string {
+	ip := strings.TrimSpace(safeString(miner.IP))
+	if miner.APIPort == nil {
+		return ip
+	}
+	scheme := config.SchemeHTTP
+	if miner.APIScheme != nil && *miner.APIScheme != "" {
+		scheme = *miner.APIScheme
+	}
+	return endpointAddress(ip, config.FirmwarePort{Scheme: scheme, Port: *miner.APIPort})
+}
+
+// endpointAddress returns the URL of the firmware API at ip on port.
+func endpointAddress(ip string, port config.FirmwarePort) string {
+	return port.Scheme + "://" + net.JoinHostPort(ip, strconv.Itoa(port.Port))
+}
+
+// newMinerDriver builds the firmware driver recorded for the miner.
+func newMinerDriver(cfg config.AppConfig, miner database.Miner, httpClient *http.Client) (firmware.MinerDriver, error) {
+	if httpClient == nil {
+		httpClient = firmwareHTTPClient(firmwareClientTimeout)
+	}
+	return firmware.NewDriver(miner.Driver, minerAddress(miner), minerCredentials(cfg, miner), firmware.WithHTTPClient(httpClient))
+}
+
+func minerCredentials(cfg config.AppConfig, miner database.Miner) firmware.Credentials {
+	creds := firmware.Credentials{
+		Username: cfg.Firmware.Braiins.Username,
+		Password: cfg.Firmware.Braiins.Password,
+	}
+	if miner.APIKey != nil {
+		creds.APIKey = strings.TrimSpace(*miner.APIKey)
+	}
+	return creds
+}
+
+// driverCache reuses each miner's firmware driver across cycles, keeping
+// its keep-alive connections and, for Braiins OS, its session token. An
+// entry is rebuilt when the miner's driver, address or credentials change.
+type driverCache struct {
+	mu      sync.Mutex
+	client  *http.Client
+	entries map[string]cachedDriver
+}
+
+type cachedDriver struct {
+	key    driverKey
+	driver firmware.MinerDriver
+}
+
+// driverKey holds everything a driver is built from.
+type driverKey struct {
+	kind  string
+	addr  string
+	creds firmware.Credentials
+}
+
+// newDriverCache returns a cache whose drivers send requests through client.
+func newDriverCache(client *http.Client) *driverCache {
+	return &driverCache{client: client, entries: make(map[string]cachedDriver)}
+}
+
+// setClient switches the cache to client and drops the drivers built on the
+// previous one.
+func (c *driverCache) setClient(client *http.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client = client
+	c.entries = make(map[string]cachedDriver)
+}
+
+// get returns the miner's driver, building it on first use or after the
+// miner changed.
+func (c *driverCache) get(cfg config.AppConfig, miner database.Miner) (firmware.MinerDriver, error) {
+	key := driverKey{kind: miner.Driver, addr: minerAddress(miner), creds: minerCredentials(cfg, miner)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[miner.ID]; ok && entry.key == key {
+		return entry.driver, nil
+	}
+	driver, err := newMinerDriver(cfg, miner, c.client)
+	if err != nil {
+		delete(c.entries, miner.ID)
+		return nil, err
+	}
+	c.entries[miner.ID] = cachedDriver{key: key, driver: driver}
+	return driver, nil
+}
+
+// retain drops the drivers of miners that are no longer listed.
+func (c *driverCache) retain(miners []database.Miner) {
+	keep := make(map[string]bool, len(miners))
+	for _, miner := range miners {
+		keep[miner.ID] = true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id := range c.entries {
+		if !keep[id] {
+			delete(c.entries, id)
+		}
+	}
+}
+
+// supportsPowerControl reports whether the miner's driver can change presets.
+// Stock Antminer firmware is monitored only.
+func supportsPowerControl(miner database.Miner) bool {
+	kind, err := firmware.ParseDriver(miner.Driver)
+	return err == nil && kind != firmware.DriverAntminer
+}
+
+// driverReady reports whether the miner is online and has the credentials
+// its driver needs. Only Vnish requires a provisioned API key.
+func driverReady(miner database.Miner) bool {
+	if miner.IP == nil || strings.TrimSpace(*miner.IP) == "" {
+		return false
+	}
+	kind, err := firmware.ParseDriver(miner.Driver)
+	if err != nil {
+		return false
+	}
+	if kind == firmware.DriverVnish {
+		return miner.APIKey != nil && strings.TrimSpace(*miner.APIKey) != ""
+	}
+	return true
+}
+
+// minerSpanAttrs identifies the miner on a firmware call's trace span.
+func minerSpanAttrs(miner database.Miner) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("miner.id", miner.ID),
+		attribute.String("miner.ip", safeString(miner.IP)),
+		attribute.String("miner.driver", miner.Driver),
+	}
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/economics"
+)
+
+const economicsFeedTimeout = 10 * time.Second
+
+// EconomicsFeed refreshes the stored hashprice and BTC price from an
+// external JSON feed. Energy cost and the balancer constraint stay under
+// manual control.
+type EconomicsFeed struct {
+	store      database.SettingsStore
+	cfg        config.AppConfig
+	log        *slog.Logger
+	httpClient *http.Client
+	interval   time.Duration
+	reloadCh   chan config.AppConfig
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewEconomicsFeed constructs the market data feed service.
+func NewEconomicsFeed(store database.SettingsStore, cfg config.AppConfig, logger *slog.Logger) *EconomicsFeed {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &EconomicsFeed{
+		store:      store,
+		cfg:        cfg,
+		log:        logger.With("component", "economics"),
+		httpClient: &http.Client{Timeout: economicsFeedTimeout},
+		interval:   time.Duration(cfg.Economics.FeedSeconds) * time.Second,
+		reloadCh:   make(chan config.AppConfig, 1),
+	}
+}
+
+// Enabled reports whether a feed URL is configured.
+func (f *EconomicsFeed) Enabled() bool {
+	return f.cfg.Economics.FeedURL != ""
+}
+
+// Reload hands a new configuration to the feed loop. Enabling a feed that
+// was disabled at startup requires a restart.
+func (f *EconomicsFeed) Reload(cfg config.AppConfig) {
+	queueReload(f.reloadCh, cfg)
+}
+
+// Run polls the feed until the context is cancelled.
+func (f *EconomicsFeed) Run(ctx context.Context) {
+	f.log.Info("starting economics feed loop", "interval", f.interval)
+
+	err := f.refresh(ctx)
+	f.cycles.finish(time.Now(), err)
+	if err != nil {
+		f.log.Error("initial economics refresh failed", "err", err)
+	}
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			f.log.Info("stopping economics feed loop", "reason", ctx.Err())
+			return
+		case <-ticker.C:
+			err := f.refresh(ctx)
+			f.cycles.finish(time.Now(), err)
+			if err != nil {
+				f.log.Error("economics refresh failed", "err", err)
+			}
+		case cfg := <-f.reloadCh:
+			if cfg.Economics.FeedURL == "" {
+				f.log.Warn("economics feed removed from configuration; keeping last values until restart")
+				continue
+			}
+			f.cfg = cfg
+			f.interval = time.Duration(cfg.Economics.FeedSeconds) * time.Second
+			ticker.Reset(f.interval)
+			f.log.Info("configuration reloaded", "interval", f.interval)
+		}
+	}
+}
+
+func (f *EconomicsFeed) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.cfg.Economics.FeedURL, nil)
+	if err != nil {
+		return fmt.Errorf("create feed request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+
+	var payload any
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return fmt.Errorf("decode feed: %w", err)
+	}
+
+	hashprice, err := jsonNumberAt(payload, f.cfg.Economics.HashpriceField)
+	if err != nil {
+		return fmt.Errorf("read hashprice: %w", err)
+	}
+	// The BTC price is informational; a feed without it is still usable.
+	btcPrice, btcErr := jsonNumberAt(payload, f.cfg.Economics.BTCPriceField)
+
+	settings, err := economics.Load(ctx, f.store)
+	if err != nil {
+		return fmt.Errorf("load economics settings: %w", err)
+	}
+	settings.HashpriceUSDPerTHDay = &hashprice
+	if btcErr == nil {
+		settings.BTCPriceUSD = &btcPrice
+	}
+	settings.Source = economics.SourceFeed
+
+	if err := economics.Save(ctx, f.store, settings); err != nil {
+		return fmt.Errorf("save economics settings: %w", err)
+	}
+
+	f.log.Debug("economics refreshed", "hashprice_usd_per_th_day", hashprice)
+	return nil
+}
+
+// jsonNumberAt follows a dot-separated path of object keys and returns the
+// number found there. Numeric strings are accepted.
+func jsonNumberAt(payload any, path string) (float64, error) {
+	current := payload
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return 0, fmt.Errorf("%s: not an object at %q", path, key)
+		}
+		if current, ok = obj[key]; !ok {
+			return 0, fmt.Errorf("%s: missing %q", path, key)
+		}
+	}
+
+	switch v := current.(type) {
+	case float64:
+		return v, nil
+	case string:
+		value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+		return value, nil
+	default:
+		return 0, fmt.Errorf("%s: not a number", path)
+	}
+}
@@ -0,0 +1,434 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+	"powerhive/internal/server"
+)
+
+const (
+	defaultFirmwareBatchSize = 5
+	firmwareUploadTimeout    = 10 * time.Minute
+	firmwareRebootTimeout    = 10 * time.Minute
+	firmwareRebootGrace      = 30 * time.Second
+	firmwareRebootPoll       = 15 * time.Second
+	maxFirmwareJobs          = 20
+)
+
+// FirmwareUpdateStore is what firmware updates need from the database.
+type FirmwareUpdateStore interface {
+	database.MinerStore
+	database.UnlockCandidateReader
+}
+
+// FirmwareUpdater pushes firmware images to miners in batches. A batch only
+// starts once every miner in the previous batch came back on the expected
+// version; the first failing batch stops the job and, when a rollback image
+// was supplied, every miner flashed by the job is restored with it.
+type FirmwareUpdater struct {
+	store        FirmwareUpdateStore
+	log          *slog.Logger
+	httpClient   *http.Client
+	probeTimeout time.Duration
+	queue        chan *firmwareJob
+
+	mu     sync.Mutex
+	jobs   []*firmwareJob
+	nextID int64
+	active bool
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+type firmwareJob struct {
+	server.FirmwareUpdateJob
+	req     server.FirmwareUpdateRequest
+	flashed map[string]bool
+}
+
+// NewFirmwareUpdater constructs the firmware update orchestrator.
+func NewFirmwareUpdater(store FirmwareUpdateStore, cfg config.AppConfig, logger *slog.Logger) *FirmwareUpdater {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	probeTimeout := time.Duration(cfg.Network.MinerProbeTimeoutMs) * time.Millisecond
+
+	return &FirmwareUpdater{
+		store:        store,
+		log:          logger.With("component", "firmware_updater"),
+		httpClient:   firmwareHTTPClient(probeTimeout),
+		probeTimeout: probeTimeout,
+		queue:        make(chan *firmwareJob, 1),
+	}
+}
+
+// StartUpdate validates the request and queues a new job.
+func (u *FirmwareUpdater) StartUpdate(ctx context.Context, req server.FirmwareUpdateRequest) (server.FirmwareUpdateJob, error) {
+	if strings.TrimSpace(req.ImagePath) == "" {
+		return server.FirmwareUpdateJob{}, fmt.Errorf("firmware image is required")
+	}
+	if len(req.MinerIDs) == 0 {
+		return server.FirmwareUpdateJob{}, fmt.Errorf("at least one miner is required")
+	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = defaultFirmwareBatchSize
+	}
+
+	seen := make(map[string]struct{}, len(req.MinerIDs))
+	var targets []server.FirmwareUpdateTarget
+	for _, id := range req.MinerIDs {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if _, dup := seen[id]; dup || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		miner, err := u.store.GetMiner(ctx, id)
+		if err != nil {
+			return server.FirmwareUpdateJob{}, err
+		}
+		if miner.IP == nil || strings.TrimSpace(*miner.IP) == "" {
+			return server.FirmwareUpdateJob{}, fmt.Errorf("miner %s is offline", id)
+		}
+		if kind, _ := firmware.ParseDriver(miner.Driver); kind != firmware.DriverVnish {
+			return server.FirmwareUpdateJob{}, fmt.Errorf("miner %s: firmware updates require the %s driver", id, firmware.DriverVnish)
+		}
+
+		target := server.FirmwareUpdateTarget{
+			MinerID: id,
+			Batch:   len(targets)/req.BatchSize + 1,
+			State:   server.TargetPending,
+		}
+		if miner.FWVersion != nil {
+			target.FromVersion = *miner.FWVersion
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return server.FirmwareUpdateJob{}, fmt.Errorf("at least one miner is required")
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.active {
+		return server.FirmwareUpdateJob{}, server.ErrUpdateInProgress
+	}
+
+	u.nextID++
+	job := &firmwareJob{
+		FirmwareUpdateJob: server.FirmwareUpdateJob{
+			ID:              strconv.FormatInt(u.nextID, 10),
+			State:           server.UpdateQueued,
+			ImageName:       req.ImageName,
+			HasRollback:     req.RollbackImagePath != "",
+			ExpectedVersion: req.ExpectedVersion,
+			BatchSize:       req.BatchSize,
+			Targets:         targets,
+			CreatedAt:       time.Now().UTC(),
+		},
+		req:     req,
+		flashed: make(map[string]bool),
+	}
+
+	select {
+	case u.queue <- job:
+	default:
+		return server.FirmwareUpdateJob{}, server.ErrUpdateInProgress
+	}
+
+	u.active = true
+	u.jobs = append(u.jobs, job)
+	if len(u.jobs) > maxFirmwareJobs {
+		u.jobs = u.jobs[len(u.jobs)-maxFirmwareJobs:]
+	}
+
+	return job.snapshot(), nil
+}
+
+// UpdateJobs returns recent jobs, newest first.
+func (u *FirmwareUpdater) UpdateJobs() []server.FirmwareUpdateJob {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	jobs := make([]server.FirmwareUpdateJob, 0, len(u.jobs))
+	for i := len(u.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, u.jobs[i].snapshot())
+	}
+	return jobs
+}
+
+// UpdateJob returns a single job by id.
+func (u *FirmwareUpdater) UpdateJob(id string) (server.FirmwareUpdateJob, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for _, job := range u.jobs {
+		if job.ID == id {
+			return job.snapshot(), true
+		}
+	}
+	return server.FirmwareUpdateJob{}, false
+}
+
+// Run executes queued jobs one at a time until the context is cancelled.
+func (u *FirmwareUpdater) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			u.drain()
+			return
+		case job := <-u.queue:
+			u.execute(ctx, job)
+			u.cycles.finish(time.Now(), nil)
+		}
+	}
+}
+
+// drain drops a job that was queued but never started.
+func (u *FirmwareUpdater) drain() {
+	select {
+	case job := <-u.queue:
+		u.finish(job, server.UpdateFailed, "service stopped before the job started")
+	default:
+	}
+}
+
+func (u *FirmwareUpdater) execute(ctx context.Context, job *firmwareJob) {
+	u.mu.Lock()
+	job.State = server.UpdateRunning
+	job.StartedAt = time.Now().UTC()
+	batches := job.Targets[len(job.Targets)-1].Batch
+	u.mu.Unlock()
+
+	u.log.Info("firmware update started", "job", job.ID, "image", job.ImageName, "miners", len(job.Targets), "batches", batches)
+
+	for batch := 1; batch <= batches; batch++ {
+		failed := u.runBatch(ctx, job, batch, job.req.ImagePath, job.req.ExpectedVersion)
+		if failed == 0 {
+			continue
+		}
+
+		reason := fmt.Sprintf("batch %d: %d miner(s) failed to update", batch, failed)
+		if ctx.Err() != nil {
+			reason = "service stopped during update"
+		}
+		u.log.Warn("firmware update halted", "job", job.ID, "reason", reason)
+
+		u.mu.Lock()
+		for i := range job.Targets {
+			if job.Targets[i].State == server.TargetPending {
+				job.Targets[i].State = server.TargetSkipped
+			}
+		}
+		u.mu.Unlock()
+
+		if job.req.RollbackImagePath != "" && ctx.Err() == nil {
+			u.rollback(ctx, job)
+			u.finish(job, server.UpdateRolledBack, reason)
+			return
+		}
+		u.finish(job, server.UpdateFailed, reason)
+		return
+	}
+
+	u.finish(job, server.UpdateCompleted, "")
+}
+
+// runBatch flashes every miner in the batch concurrently and returns how
+// many failed.
+func (u *FirmwareUpdater) runBatch(ctx context.Context, job *firmwareJob, batch int, image, expected string) int {
+	var (
+		wg     sync.WaitGroup
+		failed int
+	)
+
+	for i := range job.Targets {
+		if job.Targets[i].Batch != batch {
+			continue
+		}
+
+		u.mu.Lock()
+		job.Targets[i].State = server.TargetUpdating
+		minerID := job.Targets[i].MinerID
+		u.mu.Unlock()
+
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+
+			version, flashed, err := u.flash(ctx, minerID, image, job.req.ImageName, expected, job.req.KeepSettings)
+
+			u.mu.Lock()
+			defer u.mu.Unlock()
+			if flashed {
+				job.flashed[minerID] = true
+			}
+			job.Targets[idx].ToVersion = version
+			if err != nil {
+				failed++
+				job.Targets[idx].State = server.TargetFailed
+				job.Targets[idx].Error = err.Error()
+				u.log.Warn("firmware update failed", "job", job.ID, "miner", minerID, "err", err)
+				return
+			}
+			job.Targets[idx].State = server.TargetUpdated
+			u.log.Info("firmware updated", "job", job.ID, "miner", minerID, "version", version)
+		}(i)
+	}
+
+	wg.Wait()
+	return failed
+}
+
+// rollback restores every miner the job flashed using the rollback image.
+func (u *FirmwareUpdater) rollback(ctx context.Context, job *firmwareJob) {
+	var wg sync.WaitGroup
+
+	for i := range job.Targets {
+		u.mu.Lock()
+		minerID := job.Targets[i].MinerID
+		flashed := job.flashed[minerID]
+		u.mu.Unlock()
+		if !flashed {
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+
+			version, _, err := u.flash(ctx, minerID, job.req.RollbackImagePath, job.req.RollbackImageName, "", job.req.KeepSettings)
+
+			u.mu.Lock()
+			defer u.mu.Unlock()
+			if err != nil {
+				job.Targets[idx].State = server.TargetRollbackFailed
+				job.Targets[idx].Error = fmt.Sprintf("rollback: %v", err)
+				u.log.Error("firmware rollback failed", "job", job.ID, "miner", minerID, "err", err)
+				return
+			}
+			job.Targets[idx].State = server.TargetRolledBack
+			job.Targets[idx].ToVersion = version
+			u.log.Info("firmware rolled back", "job", job.ID, "miner", minerID, "version", version)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// flash uploads an image to one miner and waits for it to come back. It
+// reports whether the upload was accepted so rollbacks know which miners
+// were touched.
+func (u *FirmwareUpdater) flash(ctx context.Context, minerID, image, imageName, expected string, keepSettings bool) (string, bool, error) {
+	miner, err := u.store.GetMiner(ctx, minerID)
+	if err != nil {
+		return "", false, err
+	}
+	if miner.IP == nil || strings.TrimSpace(*miner.IP) == "" {
+		return "", false, fmt.Errorf("miner is offline")
+	}
+
+	client, err := firmware.NewClient(minerAddress(miner), firmware.WithHTTPClient(u.httpClient))
+	if err != nil {
+		return "", false, fmt.Errorf("create firmware client: %w", err)
+	}
+
+	token, err := unlockMiner(ctx, u.store, client, miner, u.probeTimeout, u.log)
+	if err != nil {
+		return "", false, err
+	}
+
+	file, err := os.Open(image)
+	if err != nil {
+		return "", false, fmt.Errorf("open firmware image: %w", err)
+	}
+	defer file.Close()
+
+	ctxUpload, cancelUpload := context.WithTimeout(ctx, firmwareUploadTimeout)
+	err = client.UpdateFirmware(ctxUpload, token, imageName, file, keepSettings)
+	cancelUpload()
+	if err != nil {
+		return "", false, err
+	}
+
+	info, err := u.waitForReboot(ctx, client)
+	if err != nil {
+		return "", true, err
+	}
+
+	name := strings.TrimSpace(info.FWName)
+	version := strings.TrimSpace(info.FWVersion)
+	if _, err := u.store.UpsertMiner(ctx, database.UpsertMinerParams{
+		ID:        minerID,
+		FWName:    &name,
+		FWVersion: &version,
+	}); err != nil {
+		u.log.Warn("store firmware version", "miner", minerID, "err", err)
+	}
+
+	if expected != "" && version != expected {
+		return version, true, fmt.Errorf("miner reports version %q, expected %q", version, expected)
+	}
+	return version, true, nil
+}
+
+// waitForReboot polls /info until the miner answers again after flashing.
+func (u *FirmwareUpdater) waitForReboot(ctx context.Context, client *firmware.Client) (firmware.InfoResponse, error) {
+	deadline := time.Now().Add(firmwareRebootTimeout)
+	wait := firmwareRebootGrace
+
+	for {
+		select {
+		case <-ctx.Done():
+			return firmware.InfoResponse{}, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = firmwareRebootPoll
+
+		ctxInfo, cancel := context.WithTimeout(ctx, u.probeTimeout)
+		info, err := client.Info(ctxInfo)
+		cancel()
+		if err == nil {
+			return info, nil
+		}
+		if time.Now().After(deadline) {
+			return firmware.InfoResponse{}, fmt.Errorf("miner did not come back within %s: %w", firmwareRebootTimeout, err)
+		}
+	}
+}
+
+func (u *FirmwareUpdater) finish(job *firmwareJob, state, reason string) {
+	_ = os.Remove(job.req.ImagePath)
+	if job.req.RollbackImagePath != "" {
+		_ = os.Remove(job.req.RollbackImagePath)
+	}
+
+	u.mu.Lock()
+	job.State = state
+	job.Error = reason
+	job.FinishedAt = time.Now().UTC()
+	u.active = false
+	u.mu.Unlock()
+
+	u.log.Info("firmware update finished", "job", job.ID, "state", state)
+}
+
+// snapshot copies the job for readers; callers must hold the updater lock.
+func (j *firmwareJob) snapshot() server.FirmwareUpdateJob {
+	out := j.FirmwareUpdateJob
+	out.Targets = append([]server.FirmwareUpdateTarget(nil), j.Targets...)
+	return out
+}
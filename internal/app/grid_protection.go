@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// gridProtectionPayload is the data of the grid.protection and
+// grid.restored events.
+type gridProtectionPayload struct {
+	ID                 int64      `json:"id"`
+	Cause              string     `json:"cause"`
+	FrequencyHz        *float64   `json:"frequency_hz"`
+	VoltageV           *float64   `json:"voltage_v"`
+	ShedKW             float64    `json:"shed_kw"`
+	ConsumptionBeforeW float64    `json:"consumption_before_w"`
+	ConsumptionAfterW  float64    `json:"consumption_after_w"`
+	MinersAdjusted     int        `json:"miners_adjusted"`
+	MinersFailed       int        `json:"miners_failed"`
+	StartedAt          time.Time  `json:"started_at"`
+	ClearedAt          *time.Time `json:"cleared_at"`
+}
+
+// gridFault checks the grid values of reading against the limits. Once
+// tripped, frequency must recover to the restore threshold. known is false
+// when the reading carries no grid values or is too old to act on.
+func gridFault(grid config.GridProtectionConfig, reading *database.PlantReading, tripped bool, now time.Time) (cause string, known bool) {
+	if reading == nil || (reading.GridFrequency == nil && reading.GridVoltage == nil) {
+		return "", false
+	}
+	if grid.MaxAgeSeconds > 0 && now.Sub(reading.RecordedAt) > time.Duration(grid.MaxAgeSeconds)*time.Second {
+		return "", false
+	}
+
+	if f := reading.GridFrequency; f != nil && grid.MinFrequencyHz > 0 {
+		limit := grid.MinFrequencyHz
+		if tripped {
+			limit = max(grid.RestoreFrequencyHz, grid.MinFrequencyHz)
+		}
+		if *f < limit {
+			return database.GridProtectionFrequency, true
+		}
+	}
+	if v := reading.GridVoltage; v != nil {
+		if grid.MinVoltageV > 0 && *v < grid.MinVoltageV {
+			return database.GridProtectionUndervoltage, true
+		}
+		if grid.MaxVoltageV > 0 && *v > grid.MaxVoltageV {
+			return database.GridProtectionOvervoltage, true
+		}
+	}
+	return "", true
+}
+
+// protectGrid sheds the configured block of load the first cycle the grid
+// leaves its limits and clears the protection once it is back. It returns
+// the miners it stepped down and whether the protection holds, in which
+// case the cycle must not raise any miner. Stale or missing grid values
+// keep the current state.
+func (b *PowerBalancer) protectGrid(ctx context.Context, reading *database.PlantReading, miners []minerEfficiency, presetPowerMap map[string]map[string]float64, unprofitable map[string]map[string]bool, derated map[string]bool, cooldownMap map[string]time.Time, currentConsumptionW *float64, targetPowerW float64, cycle *database.BalanceCycleInput) (map[string]bool, bool) {
+	shed := make(map[string]bool)
+	grid := b.cfg.Balancer.GridProtection
+	if !grid.Enabled() {
+		return shed, false
+	}
+
+	open, err := b.store.CurrentGridProtectionEvent(ctx)
+	if err != nil {
+		b.log.Warn("failed to load grid protection state", "err", err)
+	}
+	now := b.clock.Now()
+	cause, known := gridFault(grid, reading, open != nil, now)
+	if !known {
+		return shed, open != nil
+	}
+	if cause == "" {
+		if open != nil {
+			b.clearGridProtection(ctx, reading, now)
+		}
+		return shed, false
+	}
+	if open != nil {
+		b.log.Debug("grid still outside limits, holding load", "cause", cause, "since", open.StartedAt)
+		return shed, true
+	}
+
+	// Changes still waiting would undo the shed once they start.
+	if dropped, deltaW := b.queue.dropWaiting(); dropped > 0 {
+		*currentConsumptionW -= deltaW
+		b.log.Info("dropped queued preset changes for grid protection", "count", dropped)
+	}
+
+	before := *currentConsumptionW
+	sortForDelta(miners, -1)
+	planned, _ := b.planChanges(miners, -grid.ShedKW*1000, presetPowerMap, unprofitable, database.ThermalLimits{},
+		func(me minerEfficiency) string {
+			if derated[me.miner.ID] {
+				return skipDerated
+			}
+			if b.queue.contains(me.miner.ID) {
+				return skipQueued
+			}
+			return ""
+		}, nil)
+
+	// Step every planned miner down at once; the shed must land within
+	// this cycle.
+	var changes []presetChange
+	for _, me := range miners {
+		change, ok := planned[me.miner.ID]
+		if !ok {
+			continue
+		}
+		changes = append(changes, presetChange{
+			cfg:                b.cfg,
+			miner:              me.miner,
+			oldPreset:          me.currentPreset,
+			newPreset:          *change.targetPreset,
+			oldPower:           me.currentPower,
+			newPower:           change.targetPower,
+			consumptionBeforeW: before,
+			targetPowerW:       targetPowerW,
+			availablePowerW:    reading.AvailablePower * 1000,
+			reason:             "grid_protection",
+		})
+	}
+	applied, failed := b.applyAtOnce(ctx, changes)
+	for _, change := range applied {
+		shed[change.miner.ID] = true
+		cooldownMap[change.miner.ID] = now
+		*currentConsumptionW += change.powerDeltaW()
+	}
+
+	cycle.MinersAdjusted += len(shed)
+	cycle.MinersFailed += failed
+	b.pi = piController{}
+
+	event := database.GridProtectionEvent{
+		Cause:              cause,
+		FrequencyHz:        reading.GridFrequency,
+		VoltageV:           reading.GridVoltage,
+		ShedKW:             (before - *currentConsumptionW) / 1000,
+		ConsumptionBeforeW: before,
+		ConsumptionAfterW:  *currentConsumptionW,
+		MinersAdjusted:     len(shed),
+		MinersFailed:       failed,
+		StartedAt:          now,
+	}
+	b.log.Error("grid outside limits, shed load",
+		"cause", cause,
+		"frequency_hz", floatOrNil(reading.GridFrequency),
+		"voltage_v", floatOrNil(reading.GridVoltage),
+		"shed_kw", event.ShedKW,
+		"requested_kw", grid.ShedKW,
+		"miners_adjusted", len(shed),
+		"miners_failed", failed,
+	)
+	recorded, err := b.store.RecordGridProtectionEvent(ctx, event)
+	if err != nil {
+		b.log.Warn("failed to record grid protection event", "err", err)
+		recorded = event
+	}
+	b.notifyGrid(ctx, config.WebhookGridProtection, recorded, now)
+	return shed, true
+}
+
+// clearGridProtection ends the open protection events now the grid is back
+// within its limits.
+func (b *PowerBalancer) clearGridProtection(ctx context.Context, reading *database.PlantReading, now time.Time) {
+	events, err := b.store.ClearGridProtectionEvents(ctx, now)
+	if err != nil {
+		b.log.Warn("failed to clear grid protection", "err", err)
+		return
+	}
+	for _, event := range events {
+		b.log.Info("grid back within limits, releasing load",
+			"cause", event.Cause,
+			"frequency_hz", floatOrNil(reading.GridFrequency),
+			"voltage_v", floatOrNil(reading.GridVoltage),
+			"duration", now.Sub(event.StartedAt).Round(time.Second),
+		)
+		b.notifyGrid(ctx, config.WebhookGridRestored, event, now)
+	}
+}
+
+func (b *PowerBalancer) notifyGrid(ctx context.Context, name string, event database.GridProtectionEvent, now time.Time) {
+	if b.events == nil {
+		return
+	}
+	b.events.Notify(ctx, name, now, gridProtectionPayload{
+		ID:                 event.ID,
+		Cause:              event.Cause,
+		FrequencyHz:        event.FrequencyHz,
+		VoltageV:           event.VoltageV,
+		ShedKW:             event.ShedKW,
+		ConsumptionBeforeW: event.ConsumptionBeforeW,
+		ConsumptionAfterW:  event.ConsumptionAfterW,
+		MinersAdjusted:     event.MinersAdjusted,
+		MinersFailed:       event.MinersFailed,
+		StartedAt:          event.StartedAt,
+		ClearedAt:          event.ClearedAt,
+	})
+}
+
+// floatOrNil dereferences v for logging.
+func floatOrNil(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
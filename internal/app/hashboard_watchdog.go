@@ -0,0 +1,269 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+const watchdogRequestTimeout = 10 * time.Second
+
+// WatchdogStore is what the hashboard watchdog needs from the database.
+type WatchdogStore interface {
+	database.MinerReader
+	database.EventRecorder
+}
+
+// HashboardWatchdog restarts mining on miners whose hashboards keep failing
+// and raises an alert when restarts do not bring a board back.
+type HashboardWatchdog struct {
+	store    WatchdogStore
+	cfg      config.AppConfig
+	log      *slog.Logger
+	interval time.Duration
+	reloadCh chan config.AppConfig
+	drivers  *driverCache
+
+	// miners tracks failing boards across cycles, keyed by miner ID.
+	miners map[string]*watchdogMiner
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+type watchdogMiner struct {
+	lastStatusID int64
+	// lastConfirmed tells a poll that confirmed the same snapshot apart
+	// from no poll at all.
+	lastConfirmed time.Time
+	// failures counts consecutive failing polls per chain identifier.
+	failures    map[string]int
+	restarts    int
+	lastRestart time.Time
+	alerted     bool
+}
+
+// NewHashboardWatchdog constructs the hashboard watchdog service.
+func NewHashboardWatchdog(store WatchdogStore, cfg config.AppConfig, logger *slog.Logger) *HashboardWatchdog {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &HashboardWatchdog{
+		store:    store,
+		cfg:      cfg,
+		log:      logger.With("component", "watchdog"),
+		interval: time.Duration(cfg.Intervals.StatusSeconds) * time.Second,
+		reloadCh: make(chan config.AppConfig, 1),
+		drivers:  newDriverCache(firmwareHTTPClient(firmwareClientTimeout)),
+		miners:   make(map[string]*watchdogMiner),
+	}
+}
+
+// Enabled reports whether the watchdog should run.
+func (w *HashboardWatchdog) Enabled() bool {
+	return !w.cfg.Watchdog.Disabled
+}
+
+// Reload hands a new configuration to the watchdog loop.
+func (w *HashboardWatchdog) Reload(cfg config.AppConfig) {
+	queueReload(w.reloadCh, cfg)
+}
+
+// Run checks the latest statuses once per status poll until the context is
+// cancelled.
+func (w *HashboardWatchdog) Run(ctx context.Context) {
+	w.log.Info("starting hashboard watchdog loop", "interval", w.interval, "failure_polls", w.cfg.Watchdog.FailurePolls)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info("stopping hashboard watchdog loop", "reason", ctx.Err())
+			return
+		case <-ticker.C:
+			err := w.check(ctx)
+			w.cycles.finish(time.Now(), err)
+			if err != nil {
+				w.log.Error("watchdog cycle failed", "err", err)
+			}
+		case cfg := <-w.reloadCh:
+			w.cfg = cfg
+			w.interval = time.Duration(cfg.Intervals.StatusSeconds) * time.Second
+			ticker.Reset(w.interval)
+			w.log.Info("configuration reloaded", "interval", w.interval)
+		}
+	}
+}
+
+func (w *HashboardWatchdog) check(ctx context.Context) error {
+	miners, err := w.store.ListMiners(ctx)
+	if err != nil {
+		return fmt.Errorf("list miners: %w", err)
+	}
+	w.drivers.retain(miners)
+
+	seen := make(map[string]bool, len(miners))
+	for _, miner := range miners {
+		seen[miner.ID] = true
+		if miner.LatestStatus == nil || miner.LatestStatusID == nil {
+			continue
+		}
+
+		state := w.miners[miner.ID]
+		if state == nil {
+			state = &watchdogMiner{failures: make(map[string]int)}
+			w.miners[miner.ID] = state
+		}
+		// Only a fresh or freshly confirmed status counts as another poll.
+		if *miner.LatestStatusID == state.lastStatusID && miner.LatestStatus.LastConfirmedAt.Equal(state.lastConfirmed) {
+			continue
+		}
+		state.lastStatusID = *miner.LatestStatusID
+		state.lastConfirmed = miner.LatestStatus.LastConfirmedAt
+
+		w.evaluate(ctx, miner, state)
+	}
+
+	for id := range w.miners {
+		if !seen[id] {
+			delete(w.miners, id)
+		}
+	}
+	return nil
+}
+
+func (w *HashboardWatchdog) evaluate(ctx context.Context, miner database.Miner, state *watchdogMiner) {
+	status := miner.LatestStatus
+	mining := status.State != nil && strings.EqualFold(*status.State, "mining")
+
+	var failing []string
+	for i, chain := range status.Chains {
+		id := strconv.Itoa(i)
+		if chain.ChainIdentifier != nil && *chain.ChainIdentifier != "" {
+			id = *chain.ChainIdentifier
+		}
+		if chainFailed(chain, mining) {
+			state.failures[id]++
+			if state.failures[id] >= w.cfg.Watchdog.FailurePolls {
+				failing = append(failing, id)
+			}
+		} else {
+			delete(state.failures, id)
+		}
+	}
+
+	if len(state.failures) == 0 {
+		if state.restarts > 0 || state.alerted {
+			w.log.Info("hashboards recovered", "miner", miner.ID, "restarts", state.restarts)
+			w.record(ctx, database.HashboardEvent{
+				MinerID: miner.ID,
+				Action:  database.HashboardActionRecovered,
+				Attempt: state.restarts,
+				Success: true,
+			})
+		}
+		state.restarts = 0
+		state.alerted = false
+		return
+	}
+	if len(failing) == 0 {
+		return
+	}
+
+	chains := strings.Join(failing, ",")
+	if state.restarts >= w.cfg.Watchdog.MaxRestarts {
+		if !state.alerted {
+			state.alerted = true
+			w.log.Error("hashboard did not recover after restarts",
+				"miner", miner.ID, "ip", safeString(miner.IP), "chains", chains, "restarts", state.restarts)
+			w.record(ctx, database.HashboardEvent{
+				MinerID:         miner.ID,
+				ChainIdentifier: &chains,
+				Action:          database.HashboardActionAlert,
+				Attempt:         state.restarts,
+				Success:         false,
+				ErrorMessage:    ptrString(fmt.Sprintf("chains %s still failing after %d restarts", chains, state.restarts)),
+			})
+		}
+		return
+	}
+
+	backoff := time.Duration(w.cfg.Watchdog.RestartBackoffSeconds) * time.Second
+	if !state.lastRestart.IsZero() && time.Since(state.lastRestart) < backoff {
+		return
+	}
+
+	state.restarts++
+	state.lastRestart = time.Now()
+	// Boards get FailurePolls fresh polls to come back after the restart.
+	state.failures = make(map[string]int)
+
+	err := w.restart(ctx, miner)
+	event := database.HashboardEvent{
+		MinerID:         miner.ID,
+		ChainIdentifier: &chains,
+		Action:          database.HashboardActionRestart,
+		Attempt:         state.restarts,
+		Success:         err == nil,
+	}
+	if err != nil {
+		event.ErrorMessage = ptrString(err.Error())
+		w.log.Warn("hashboard restart failed", "miner", miner.ID, "chains", chains, "attempt", state.restarts, "err", err)
+	} else {
+		w.log.Warn("restarted mining for failing hashboards", "miner", miner.ID, "chains", chains, "attempt", state.restarts)
+	}
+	w.record(ctx, event)
+}
+
+func (w *HashboardWatchdog) restart(ctx context.Context, miner database.Miner) error {
+	if !driverReady(miner) {
+		return fmt.Errorf("miner missing IP or credentials")
+	}
+
+	driver, err := w.drivers.get(w.cfg, miner)
+	if err != nil {
+		return fmt.Errorf("create firmware driver: %w", err)
+	}
+	restarter, ok := driver.(firmware.Restarter)
+	if !ok {
+		return firmware.ErrUnsupported
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, watchdogRequestTimeout)
+	defer cancel()
+
+	if err := restarter.Restart(reqCtx); err != nil {
+		return fmt.Errorf("restart mining: %w", err)
+	}
+	return nil
+}
+
+func (w *HashboardWatchdog) record(ctx context.Context, event database.HashboardEvent) {
+	if _, err := w.store.RecordHashboardEvent(ctx, event); err != nil {
+		w.log.Warn("failed to record hashboard event", "miner", event.MinerID, "err", err)
+	}
+}
+
+// chainFailed reports whether a chain is in a failure state, or produces no
+// hashrate while the miner is mining. Disabled boards are ignored.
+func chainFailed(chain database.ChainSnapshot, mining bool) bool {
+	if chain.State != nil {
+		switch strings.ToLower(*chain.State) {
+		case "failure", "error":
+			return true
+		case "disabled":
+			return false
+		}
+	}
+	return mining && chain.Hashrate != nil && *chain.Hashrate <= 0
+}
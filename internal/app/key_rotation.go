@@ -0,0 +1,296 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+	"powerhive/internal/server"
+)
+
+// keyRotationCheckInterval is how often the scheduled job looks for keys
+// older than the configured interval.
+const keyRotationCheckInterval = time.Hour
+
+// Key rotation triggers.
+const (
+	keyRotationScheduled = "scheduled"
+	keyRotationManual    = "manual"
+)
+
+// KeyRotationStore is what API key rotation needs from the database.
+type KeyRotationStore interface {
+	database.MinerStore
+	database.KeyRotationRecorder
+	database.UnlockCandidateReader
+}
+
+// KeyRotator replaces the firmware API keys PowerHive provisioned on Vnish
+// miners. A new key is registered and verified before it is stored, and
+// only then are the old PowerHive keys removed, so a failed rotation leaves
+// the miner reachable with its previous key.
+type KeyRotator struct {
+	store        KeyRotationStore
+	log          *slog.Logger
+	httpClient   *http.Client
+	probeTimeout time.Duration
+	reloadCh     chan config.AppConfig
+
+	mu  sync.Mutex
+	cfg config.AppConfig
+
+	// rotating serialises rotations so a manual rotation never races the
+	// scheduled one on the same miner.
+	rotating sync.Mutex
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewKeyRotator constructs the API key rotation service.
+func NewKeyRotator(store KeyRotationStore, cfg config.AppConfig, logger *slog.Logger) *KeyRotator {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	probeTimeout := time.Duration(cfg.Network.MinerProbeTimeoutMs) * time.Millisecond
+
+	return &KeyRotator{
+		store:        store,
+		cfg:          cfg,
+		log:          logger.With("component", "key_rotation"),
+		httpClient:   firmwareHTTPClient(probeTimeout),
+		probeTimeout: probeTimeout,
+		reloadCh:     make(chan config.AppConfig, 1),
+	}
+}
+
+// Enabled reports whether scheduled rotation should run.
+func (k *KeyRotator) Enabled() bool {
+	return k.config().KeyRotation.IntervalDays > 0
+}
+
+// Reload hands a new configuration to the rotation loop.
+func (k *KeyRotator) Reload(cfg config.AppConfig) {
+	queueReload(k.reloadCh, cfg)
+}
+
+func (k *KeyRotator) config() config.AppConfig {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.cfg
+}
+
+// Run rotates keys older than the configured interval, checking hourly,
+// until the context is cancelled.
+func (k *KeyRotator) Run(ctx context.Context) {
+	k.log.Info("starting key rotation loop", "interval_days", k.config().KeyRotation.IntervalDays)
+
+	ticker := time.NewTicker(keyRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			k.log.Info("stopping key rotation loop", "reason", ctx.Err())
+			return
+		case <-ticker.C:
+			err := k.rotateDue(ctx)
+			k.cycles.finish(time.Now(), err)
+			if err != nil {
+				k.log.Error("key rotation failed", "err", err)
+			}
+		case cfg := <-k.reloadCh:
+			k.mu.Lock()
+			k.cfg = cfg
+			k.mu.Unlock()
+			k.log.Info("configuration reloaded", "interval_days", cfg.KeyRotation.IntervalDays)
+		}
+	}
+}
+
+// rotateDue rotates the key of every reachable Vnish miner whose key has
+// not been replaced within the interval. Miners never rotated count from
+// their creation.
+func (k *KeyRotator) rotateDue(ctx context.Context) error {
+	days := k.config().KeyRotation.IntervalDays
+	if days <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+
+	miners, err := k.store.ListMiners(ctx)
+	if err != nil {
+		return fmt.Errorf("list miners: %w", err)
+	}
+	last, err := k.store.LastKeyRotations(ctx)
+	if err != nil {
+		return err
+	}
+
+	rotated, failed := 0, 0
+	for _, miner := range miners {
+		if kind, _ := firmware.ParseDriver(miner.Driver); kind != firmware.DriverVnish || !driverReady(miner) {
+			continue
+		}
+		since, ok := last[miner.ID]
+		if !ok {
+			since = miner.CreatedAt
+		}
+		if since.After(cutoff) {
+			continue
+		}
+		if _, err := k.rotate(ctx, miner, keyRotationScheduled); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			k.log.Warn("miner key rotation failed", "miner", miner.ID, "err", err)
+			continue
+		}
+		rotated++
+	}
+
+	if rotated > 0 || failed > 0 {
+		k.log.Info("key rotation finished", "rotated", rotated, "failed", failed)
+	}
+	return nil
+}
+
+// RotateKey replaces the miner's API key now, for example after the key
+// may have leaked.
+func (k *KeyRotator) RotateKey(ctx context.Context, minerID string) (database.KeyRotation, error) {
+	miner, err := k.store.GetMiner(ctx, minerID)
+	if err != nil {
+		return database.KeyRotation{}, err
+	}
+	if kind, _ := firmware.ParseDriver(miner.Driver); kind != firmware.DriverVnish {
+		return database.KeyRotation{}, fmt.Errorf("api keys: %w", firmware.ErrUnsupported)
+	}
+	if !driverReady(miner) {
+		return database.KeyRotation{}, server.ErrMinerUnreachable
+	}
+	return k.rotate(ctx, miner, keyRotationManual)
+}
+
+// rotate replaces the miner's key and records the attempt.
+func (k *KeyRotator) rotate(ctx context.Context, miner database.Miner, trigger string) (database.KeyRotation, error) {
+	k.rotating.Lock()
+	defer k.rotating.Unlock()
+
+	rotateErr := k.replaceKey(ctx, miner)
+	rotation := database.KeyRotation{
+		MinerID:   miner.ID,
+		Trigger:   trigger,
+		Success:   rotateErr == nil,
+		RotatedAt: time.Now().UTC(),
+	}
+	if rotateErr != nil {
+		message := rotateErr.Error()
+		rotation.ErrorMessage = &message
+	}
+
+	recorded, err := k.store.RecordKeyRotation(ctx, rotation)
+	if err != nil {
+		k.log.Warn("record key rotation", "miner", miner.ID, "err", err)
+		recorded = rotation
+	}
+	if rotateErr != nil {
+		return recorded, rotateErr
+	}
+	k.log.Info("api key rotated", "miner", miner.ID, "trigger", trigger)
+	return recorded, nil
+}
+
+// replaceKey registers a new key, checks the miner accepts it, stores it
+// and then removes every other key PowerHive created on the miner.
+func (k *KeyRotator) replaceKey(ctx context.Context, miner database.Miner) error {
+	client, err := firmware.NewClient(minerAddress(miner), firmware.WithHTTPClient(k.httpClient))
+	if err != nil {
+		return fmt.Errorf("create firmware client: %w", err)
+	}
+
+	token, err := unlockMiner(ctx, k.store, client, miner, k.probeTimeout, k.log)
+	if err != nil {
+		return err
+	}
+
+	newKey, err := generateAPIKey()
+	if err != nil {
+		return fmt.Errorf("generate api key: %w", err)
+	}
+
+	ctxCreate, cancelCreate := context.WithTimeout(ctx, k.probeTimeout)
+	err = client.CreateAPIKey(ctxCreate, token, newKey, apiKeyDescription)
+	cancelCreate()
+	if err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+
+	ctxVerify, cancelVerify := context.WithTimeout(ctx, k.probeTimeout)
+	_, err = client.GetSettings(ctxVerify, newKey)
+	cancelVerify()
+	if err != nil {
+		k.deleteKey(ctx, client, token, miner.ID, newKey)
+		return fmt.Errorf("verify new api key: %w", err)
+	}
+
+	stored := newKey
+	if _, err := k.store.UpsertMiner(ctx, database.UpsertMinerParams{
+		ID:     miner.ID,
+		APIKey: &stored,
+	}); err != nil {
+		k.deleteKey(ctx, client, token, miner.ID, newKey)
+		return fmt.Errorf("store api key for miner %s: %w", miner.ID, err)
+	}
+
+	// The old key is removed even when listing fails; other stale
+	// PowerHive keys, left by earlier failed rotations, only when it works.
+	stale := map[string]bool{}
+	if miner.APIKey != nil && strings.TrimSpace(*miner.APIKey) != "" {
+		stale[strings.TrimSpace(*miner.APIKey)] = true
+	}
+	ctxKeys, cancelKeys := context.WithTimeout(ctx, k.probeTimeout)
+	keys, err := client.ListAPIKeys(ctxKeys, token)
+	cancelKeys()
+	if err != nil {
+		k.log.Warn("list api keys", "miner", miner.ID, "err", err)
+	}
+	for _, key := range keys {
+		if strings.EqualFold(key.Description, apiKeyDescription) && strings.TrimSpace(key.Key) != "" {
+			stale[strings.TrimSpace(key.Key)] = true
+		}
+	}
+	delete(stale, newKey)
+
+	var failed []string
+	for key := range stale {
+		ctxDelete, cancelDelete := context.WithTimeout(ctx, k.probeTimeout)
+		err := client.DeleteAPIKey(ctxDelete, token, key)
+		cancelDelete()
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("new key stored but %d old key(s) were not removed: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// deleteKey removes a key that could not be put into use. Failures are
+// only logged; the caller already has the error that matters.
+func (k *KeyRotator) deleteKey(ctx context.Context, client *firmware.Client, token, minerID, key string) {
+	ctxDelete, cancel := context.WithTimeout(ctx, k.probeTimeout)
+	defer cancel()
+	if err := client.DeleteAPIKey(ctxDelete, token, key); err != nil {
+		k.log.Warn("remove unused api key", "miner", minerID, "err", err)
+	}
+}
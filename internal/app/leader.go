@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/server"
+)
+
+// leaderLeaseName is the lease every instance on a database competes for.
+const leaderLeaseName = "powerhive"
+
+const leaseReleaseTimeout = 5 * time.Second
+
+// LeaseStore is what leader election needs from the database.
+type LeaseStore interface {
+	AcquireLease(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	GetLease(ctx context.Context, name string) (database.LeaderLease, error)
+}
+
+// LeaderElector holds the leader lease in high-availability mode. While it
+// holds the lease the background services run; when it cannot renew the
+// lease it stops them and stands by until the lease is free again.
+type LeaderElector struct {
+	store  LeaseStore
+	nodeID string
+	ttl    time.Duration
+	log    *slog.Logger
+	clock  clock.Clock
+
+	mu        sync.Mutex
+	leader    bool
+	since     time.Time
+	holder    string
+	expiresAt time.Time
+}
+
+// NewLeaderElector constructs the elector for this node.
+func NewLeaderElector(store LeaseStore, cfg config.AppConfig, logger *slog.Logger) *LeaderElector {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &LeaderElector{
+		store:  store,
+		nodeID: cfg.HA.NodeID,
+		ttl:    time.Duration(cfg.HA.LeaseSeconds) * time.Second,
+		log:    logger.With("component", "leader", "node", cfg.HA.NodeID),
+		clock:  clock.Real,
+	}
+}
+
+// Run competes for the lease until the context is cancelled. Each time the
+// lease is won it calls lead with a context that is cancelled when the
+// lease is lost, and waits for lead to return before competing again.
+func (e *LeaderElector) Run(ctx context.Context, lead func(context.Context)) {
+	interval := e.ttl / 3
+	e.log.Info("starting leader election", "lease", e.ttl, "renew", interval)
+
+	var (
+		leadCancel context.CancelFunc
+		leadDone   chan struct{}
+	)
+	stepDown := func(reason string) {
+		if leadCancel == nil {
+			return
+		}
+		e.log.Warn("stepping down", "reason", reason)
+		leadCancel()
+		<-leadDone
+		leadCancel, leadDone = nil, nil
+		e.mu.Lock()
+		e.leader = false
+		e.since = time.Time{}
+		e.mu.Unlock()
+	}
+
+	ticker := e.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := e.clock.Now()
+		acquired, err := e.store.AcquireLease(ctx, leaderLeaseName, e.nodeID, now, e.ttl)
+		switch {
+		case ctx.Err() != nil:
+		case err != nil:
+			e.log.Error("lease renewal failed", "err", err)
+			// Give the lease up a renewal early so the services have
+			// stopped before another node can take it over.
+			e.mu.Lock()
+			expiring := e.leader && !now.Before(e.expiresAt.Add(-interval))
+			e.mu.Unlock()
+			if expiring {
+				stepDown("lease could not be renewed")
+			}
+		case acquired:
+			e.mu.Lock()
+			e.holder = e.nodeID
+			e.expiresAt = now.Add(e.ttl)
+			e.mu.Unlock()
+			if leadCancel == nil {
+				e.log.Info("became leader")
+				e.mu.Lock()
+				e.leader = true
+				e.since = now
+				e.mu.Unlock()
+				var leadCtx context.Context
+				leadCtx, leadCancel = context.WithCancel(ctx)
+				leadDone = make(chan struct{})
+				go func(done chan struct{}) {
+					defer close(done)
+					lead(leadCtx)
+				}(leadDone)
+			}
+		default:
+			stepDown("lease is held by another node")
+			e.observe(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			wasLeader := leadCancel != nil
+			if wasLeader {
+				leadCancel()
+				<-leadDone
+			}
+			e.mu.Lock()
+			e.leader = false
+			e.mu.Unlock()
+			if wasLeader {
+				e.release()
+			}
+			e.log.Info("stopping leader election", "reason", ctx.Err())
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// observe records which node holds the lease while this one stands by.
+func (e *LeaderElector) observe(ctx context.Context) {
+	lease, err := e.store.GetLease(ctx, leaderLeaseName)
+	if err != nil {
+		e.log.Debug("read lease failed", "err", err)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if lease.Holder != e.holder {
+		e.log.Info("standing by", "leader", lease.Holder)
+	}
+	e.holder = lease.Holder
+	e.expiresAt = lease.ExpiresAt
+}
+
+// release hands the lease back at shutdown so a standby takes over at its
+// next renewal instead of waiting for the lease to expire.
+func (e *LeaderElector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), leaseReleaseTimeout)
+	defer cancel()
+	if err := e.store.ReleaseLease(ctx, leaderLeaseName, e.nodeID); err != nil {
+		e.log.Warn("release lease failed", "err", err)
+		return
+	}
+	e.log.Info("lease released")
+}
+
+// LeaderStatus reports this node's role for GET /api/admin/leader and the
+// standby's read-only API.
+func (e *LeaderElector) LeaderStatus() server.LeaderStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return server.LeaderStatus{
+		NodeID:    e.nodeID,
+		Leader:    e.leader,
+		Holder:    e.holder,
+		Since:     optionalTime(e.since),
+		ExpiresAt: optionalTime(e.expiresAt),
+	}
+}
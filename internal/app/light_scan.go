@@ -0,0 +1,234 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	arpTablePath      = "/proc/net/arp"
+	arpFlagIncomplete = "0x0"
+	icmpProtocol      = 1
+	icmpPayload       = "powerhive"
+)
+
+// icmpSweep sends a single echo request to every host and returns the ones
+// that answer within timeout. A raw ICMP socket is used when the process has
+// the privilege; otherwise it falls back to the unprivileged datagram socket
+// Linux offers to groups listed in net.ipv4.ping_group_range.
+func icmpSweep(ctx context.Context, hosts []string, timeout time.Duration) ([]string, error) {
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	conn, privileged, err := listenICMP()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	wanted := make(map[string]struct{}, len(hosts))
+	for _, host := range hosts {
+		wanted[host] = struct{}{}
+	}
+
+	id := os.Getpid() & 0xffff
+
+	var (
+		mu    sync.Mutex
+		alive = make(map[string]struct{})
+		done  = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		buf := make([]byte, 1500)
+		for {
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			msg, err := icmp.ParseMessage(icmpProtocol, buf[:n])
+			if err != nil || msg.Type != ipv4.ICMPTypeEchoReply {
+				continue
+			}
+			// The kernel rewrites the identifier on unprivileged sockets.
+			if echo, ok := msg.Body.(*icmp.Echo); !ok || (privileged && echo.ID != id) {
+				continue
+			}
+			ip := addrIP(peer)
+			if _, ok := wanted[ip]; !ok {
+				continue
+			}
+			mu.Lock()
+			alive[ip] = struct{}{}
+			mu.Unlock()
+		}
+	}()
+
+	for seq, host := range hosts {
+		if ctx.Err() != nil {
+			break
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			continue
+		}
+
+		msg := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Code: 0,
+			Body: &icmp.Echo{ID: id, Seq: seq & 0xffff, Data: []byte(icmpPayload)},
+		}
+		payload, err := msg.Marshal(nil)
+		if err != nil {
+			continue
+		}
+
+		var dst net.Addr = &net.IPAddr{IP: ip}
+		if !privileged {
+			dst = &net.UDPAddr{IP: ip}
+		}
+		// Individual send failures (e.g. ENOBUFS on large sweeps) only cost that host.
+		_, _ = conn.WriteTo(payload, dst)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		_ = conn.Close()
+		<-done
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	result := make([]string, 0, len(alive))
+	for ip := range alive {
+		result = append(result, ip)
+	}
+	return result, nil
+}
+
+func listenICMP() (*icmp.PacketConn, bool, error) {
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err == nil {
+		return conn, true, nil
+	}
+
+	conn, udpErr := icmp.ListenPacket("udp4", "0.0.0.0")
+	if udpErr == nil {
+		return conn, false, nil
+	}
+
+	return nil, false, fmt.Errorf("open icmp socket: raw: %v; unprivileged: %w", err, udpErr)
+}
+
+func addrIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.String()
+	case *net.UDPAddr:
+		return a.IP.String()
+	default:
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return addr.String()
+		}
+		return host
+	}
+}
+
+// arpEntry is a complete row of the kernel ARP table.
+type arpEntry struct {
+	ip  net.IP
+	mac string
+}
+
+// readARPTable parses /proc/net/arp, skipping incomplete entries.
+func readARPTable() ([]arpEntry, error) {
+	file, err := os.Open(arpTablePath)
+	if err != nil {
+		return nil, fmt.Errorf("open arp table: %w", err)
+	}
+	defer file.Close()
+
+	var entries []arpEntry
+	scanner := bufio.NewScanner(file)
+	header := true
+	for scanner.Scan() {
+		if header {
+			header = false
+			continue
+		}
+
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		if fields[2] == arpFlagIncomplete || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+		entries = append(entries, arpEntry{ip: ip, mac: strings.ToLower(fields[3])})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read arp table: %w", err)
+	}
+
+	return entries, nil
+}
+
+// arpNeighbors returns hosts inside network that have a complete entry in the
+// kernel ARP table. It only sees hosts this machine has talked to recently,
+// so it works best alongside the ICMP or TCP strategies.
+func arpNeighbors(network *net.IPNet) ([]string, error) {
+	entries, err := readARPTable()
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+	for _, entry := range entries {
+		if network.Contains(entry.ip) {
+			hosts = append(hosts, entry.ip.String())
+		}
+	}
+	return hosts, nil
+}
+
+// arpMAC looks up the MAC address of a host on the local segment. Firmware
+// that does not report its own MAC, such as the cgminer API, is identified
+// this way after the probe has populated the ARP cache.
+func arpMAC(ip string) (string, error) {
+	target := net.ParseIP(ip)
+	if target == nil {
+		return "", fmt.Errorf("invalid ip %q", ip)
+	}
+
+	entries, err := readARPTable()
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.ip.Equal(target) {
+			return entry.mac, nil
+		}
+	}
+	return "", fmt.Errorf("no arp entry for %s", ip)
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+	"powerhive/internal/server"
+)
+
+const minerControlTimeout = 10 * time.Second
+
+// MinerControl runs one-off commands against a single miner on behalf of the
+// API.
+type MinerControl struct {
+	store   database.MinerStore
+	log     *slog.Logger
+	drivers *driverCache
+
+	mu  sync.Mutex
+	cfg config.AppConfig
+}
+
+// NewMinerControl constructs the per-miner command service.
+func NewMinerControl(store database.MinerStore, cfg config.AppConfig, logger *slog.Logger) *MinerControl {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &MinerControl{
+		store:   store,
+		cfg:     cfg,
+		log:     logger.With("component", "control"),
+		drivers: newDriverCache(firmwareHTTPClient(firmwareClientTimeout)),
+	}
+}
+
+// Reload swaps in a new configuration for subsequent commands.
+func (c *MinerControl) Reload(cfg config.AppConfig) {
+	c.mu.Lock()
+	c.cfg = cfg
+	c.mu.Unlock()
+}
+
+// LocateMiner turns the miner's locate LED on or off.
+func (c *MinerControl) LocateMiner(ctx context.Context, minerID string, on bool) error {
+	driver, err := c.driver(ctx, minerID)
+	if err != nil {
+		return err
+	}
+	locator, ok := driver.(firmware.Locator)
+	if !ok {
+		return firmware.ErrUnsupported
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, minerControlTimeout)
+	defer cancel()
+
+	if err := locator.Locate(reqCtx, on); err != nil {
+		return fmt.Errorf("locate miner %s: %w", minerID, err)
+	}
+	return nil
+}
+
+// MinerLogs fetches the end of one of the miner's logs.
+func (c *MinerControl) MinerLogs(ctx context.Context, minerID, kind string, maxBytes int64) (firmware.MinerLog, error) {
+	driver, err := c.driver(ctx, minerID)
+	if err != nil {
+		return firmware.MinerLog{}, err
+	}
+	reader, ok := driver.(firmware.LogReader)
+	if !ok {
+		return firmware.MinerLog{}, firmware.ErrUnsupported
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, minerControlTimeout)
+	defer cancel()
+
+	logs, err := reader.Logs(reqCtx, kind, maxBytes)
+	if err != nil {
+		return firmware.MinerLog{}, fmt.Errorf("fetch %s log of miner %s: %w", kind, minerID, err)
+	}
+	return logs, nil
+}
+
+// SetCooling applies cooling settings to the miner and records them as its
+// settings snapshot. Nil fields keep the recorded values.
+func (c *MinerControl) SetCooling(ctx context.Context, minerID string, cooling database.CoolingSettingsInput) (database.Settings, *firmware.SaveConfigResult, error) {
+	miner, driver, err := c.minerDriver(ctx, minerID)
+	if err != nil {
+		return database.Settings{}, nil, err
+	}
+	controller, ok := driver.(firmware.CoolingController)
+	if !ok {
+		return database.Settings{}, nil, firmware.ErrUnsupported
+	}
+
+	var input database.SettingsInput
+	if miner.Settings != nil {
+		input = miner.Settings.Input()
+	}
+	input.Cooling = mergeCooling(input.Cooling, cooling)
+	if err := input.Validate(); err != nil {
+		return database.Settings{}, nil, fmt.Errorf("%w: %v", server.ErrInvalidSettings, err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, minerControlTimeout)
+	defer cancel()
+
+	result, err := controller.SetCooling(reqCtx, firmwareCooling(input.Cooling))
+	if err != nil {
+		return database.Settings{}, nil, fmt.Errorf("set cooling on miner %s: %w", minerID, err)
+	}
+
+	saved, err := c.store.SaveMinerSettings(ctx, minerID, input)
+	if err != nil {
+		return database.Settings{}, nil, fmt.Errorf("record cooling settings: %w", err)
+	}
+	return saved, result, nil
+}
+
+// firmwareCooling converts recorded cooling settings into the firmware
+// payload. The fan duty is only sent in manual mode.
+func firmwareCooling(cooling database.CoolingSettingsInput) firmware.CoolingSettings {
+	settings := firmware.CoolingSettings{
+		Mode:        &firmware.CoolingModeSetting{Name: cooling.Mode},
+		FanMinCount: cooling.FanMinCount,
+		FanMinDuty:  cooling.FanMinDuty,
+		FanMaxDuty:  cooling.FanMaxDuty,
+	}
+	if cooling.Mode == firmware.CoolingManual {
+		settings.Mode.Param = cooling.FanDuty
+	}
+	return settings
+}
+
+// mergeCooling overlays the fields set in update on current.
+func mergeCooling(current, update database.CoolingSettingsInput) database.CoolingSettingsInput {
+	if mode := strings.ToLower(strings.TrimSpace(update.Mode)); mode != "" {
+		current.Mode = mode
+	}
+	if current.Mode == "" {
+		current.Mode = firmware.CoolingAuto
+	}
+	if update.FanMinCount != nil {
+		current.FanMinCount = update.FanMinCount
+	}
+	if update.FanMinDuty != nil {
+		current.FanMinDuty = update.FanMinDuty
+	}
+	if update.FanMaxDuty != nil {
+		current.FanMaxDuty = update.FanMaxDuty
+	}
+	if update.FanDuty != nil {
+		current.FanDuty = update.FanDuty
+	}
+	return current
+}
+
+// driver loads the miner and builds its firmware driver.
+func (c *MinerControl) driver(ctx context.Context, minerID string) (firmware.MinerDriver, error) {
+	_, driver, err := c.minerDriver(ctx, minerID)
+	return driver, err
+}
+
+// minerDriver loads the miner and builds its firmware driver, returning
+// both.
+func (c *MinerControl) minerDriver(ctx context.Context, minerID string) (database.Miner, firmware.MinerDriver, error) {
+	miner, err := c.store.GetMiner(ctx, minerID)
+	if err != nil {
+		return database.Miner{}, nil, err
+	}
+	if !driverReady(miner) {
+		return database.Miner{}, nil, server.ErrMinerUnreachable
+	}
+
+	c.mu.Lock()
+	cfg := c.cfg
+	c.mu.Unlock()
+
+	driver, err := c.drivers.get(cfg, miner)
+	if err != nil {
+		return database.Miner{}, nil, fmt.Errorf("create firmware driver: %w", err)
+	}
+	return miner, driver, nil
+}
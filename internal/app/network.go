@@ -0,0 +1,355 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+	"powerhive/internal/server"
+)
+
+// MinerNetwork reads the miner's network configuration from its firmware.
+func (c *MinerControl) MinerNetwork(ctx context.Context, minerID string) (firmware.NetworkSettings, error) {
+	driver, err := c.driver(ctx, minerID)
+	if err != nil {
+		return firmware.NetworkSettings{}, err
+	}
+	controller, ok := driver.(firmware.NetworkController)
+	if !ok {
+		return firmware.NetworkSettings{}, firmware.ErrUnsupported
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, minerControlTimeout)
+	defer cancel()
+
+	network, err := controller.ReadNetwork(reqCtx)
+	if err != nil {
+		return firmware.NetworkSettings{}, fmt.Errorf("read network of miner %s: %w", minerID, err)
+	}
+	return network, nil
+}
+
+// SetMinerNetwork overlays update on the miner's current network
+// configuration, validates the result and applies it.
+func (c *MinerControl) SetMinerNetwork(ctx context.Context, minerID string, update firmware.NetworkSettings) (firmware.NetworkSettings, *firmware.SaveConfigResult, error) {
+	miner, driver, err := c.minerDriver(ctx, minerID)
+	if err != nil {
+		return firmware.NetworkSettings{}, nil, err
+	}
+	controller, ok := driver.(firmware.NetworkController)
+	if !ok {
+		return firmware.NetworkSettings{}, nil, firmware.ErrUnsupported
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, minerControlTimeout)
+	defer cancel()
+
+	current, err := controller.ReadNetwork(reqCtx)
+	if err != nil {
+		return firmware.NetworkSettings{}, nil, fmt.Errorf("read network of miner %s: %w", minerID, err)
+	}
+	network := mergeNetwork(current, update)
+	if err := validateNetwork(network); err != nil {
+		return firmware.NetworkSettings{}, nil, fmt.Errorf("%w: %v", server.ErrInvalidSettings, err)
+	}
+
+	result, err := c.applyNetwork(reqCtx, miner, controller, network)
+	if err != nil {
+		return firmware.NetworkSettings{}, nil, err
+	}
+	return network, result, nil
+}
+
+// applyNetwork sends network to the miner. A new static address is
+// recorded as the miner's IP right away, so polling follows the miner
+// instead of waiting for discovery to find it again.
+func (c *MinerControl) applyNetwork(ctx context.Context, miner database.Miner, controller firmware.NetworkController, network firmware.NetworkSettings) (*firmware.SaveConfigResult, error) {
+	result, err := controller.SetNetwork(ctx, network)
+	if err != nil {
+		return nil, fmt.Errorf("set network on miner %s: %w", miner.ID, err)
+	}
+
+	if networkStatic(network) && safeString(network.IPAddress) != safeString(miner.IP) {
+		if _, err := c.store.UpsertMiner(ctx, database.UpsertMinerParams{ID: miner.ID, IP: network.IPAddress}); err != nil {
+			return result, fmt.Errorf("record new address of miner %s: %w", miner.ID, err)
+		}
+		c.log.Info("miner moved to static address", "miner", miner.ID, "old_ip", safeString(miner.IP), "ip", *network.IPAddress)
+	}
+	return result, nil
+}
+
+// ReIPMiners assigns sequential static addresses to the miners at
+// plan.Location and applies them concurrently, unless plan.DryRun is set.
+// Errors in the plan itself fail the whole request before any miner is
+// changed.
+func (c *MinerControl) ReIPMiners(ctx context.Context, plan server.ReIPPlan) ([]server.ReIPResult, error) {
+	miners, err := c.store.ListMiners(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list miners: %w", err)
+	}
+
+	location := strings.TrimSpace(plan.Location)
+	var targets []database.Miner
+	taken := make(map[string]bool)
+	for _, miner := range miners {
+		if strings.EqualFold(strings.TrimSpace(safeString(miner.Location)), location) {
+			targets = append(targets, miner)
+			continue
+		}
+		if ip := strings.TrimSpace(safeString(miner.IP)); ip != "" {
+			taken[ip] = true
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: no miners at location %q", server.ErrInvalidSettings, location)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		a, b := safeString(targets[i].Name), safeString(targets[j].Name)
+		if a != b {
+			return a < b
+		}
+		return targets[i].ID < targets[j].ID
+	})
+
+	networks, err := planAddresses(plan, len(targets), taken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", server.ErrInvalidSettings, err)
+	}
+
+	results := make([]server.ReIPResult, len(targets))
+	for i, miner := range targets {
+		results[i] = server.ReIPResult{
+			MinerID:  miner.ID,
+			Name:     miner.Name,
+			OldIP:    miner.IP,
+			NewIP:    *networks[i].IPAddress,
+			Hostname: networks[i].Hostname,
+			State:    server.ReIPPlanned,
+		}
+	}
+	if plan.DryRun {
+		return results, nil
+	}
+
+	var wg sync.WaitGroup
+	for i, miner := range targets {
+		wg.Add(1)
+		go func(idx int, miner database.Miner) {
+			defer wg.Done()
+
+			result, err := c.reIPMiner(ctx, miner, networks[idx])
+			if err != nil {
+				results[idx].State = server.ReIPFailed
+				results[idx].Error = err.Error()
+				c.log.Warn("re-ip failed on miner", "miner", miner.ID, "ip", *networks[idx].IPAddress, "err", err)
+				return
+			}
+			results[idx].State = server.ReIPApplied
+			if result != nil {
+				results[idx].RebootRequired = result.RebootRequired
+			}
+		}(i, miner)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func (c *MinerControl) reIPMiner(ctx context.Context, miner database.Miner, network firmware.NetworkSettings) (*firmware.SaveConfigResult, error) {
+	if !driverReady(miner) {
+		return nil, server.ErrMinerUnreachable
+	}
+
+	c.mu.Lock()
+	cfg := c.cfg
+	c.mu.Unlock()
+
+	driver, err := c.drivers.get(cfg, miner)
+	if err != nil {
+		return nil, fmt.Errorf("create firmware driver: %w", err)
+	}
+	controller, ok := driver.(firmware.NetworkController)
+	if !ok {
+		return nil, firmware.ErrUnsupported
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, minerControlTimeout)
+	defer cancel()
+	return c.applyNetwork(reqCtx, miner, controller, network)
+}
+
+// planAddresses builds the static configuration of count miners, handing
+// out addresses from plan.StartIP upwards and skipping taken ones.
+func planAddresses(plan server.ReIPPlan, count int, taken map[string]bool) ([]firmware.NetworkSettings, error) {
+	dhcp := false
+	base := firmware.NetworkSettings{
+		DHCP:       &dhcp,
+		IPAddress:  &plan.StartIP,
+		Netmask:    &plan.Netmask,
+		Gateway:    &plan.Gateway,
+		DNSServers: plan.DNSServers,
+	}
+	if err := validateNetwork(base); err != nil {
+		return nil, err
+	}
+
+	start := netip.MustParseAddr(strings.TrimSpace(plan.StartIP))
+	subnet, _ := subnetOf(start, strings.TrimSpace(plan.Netmask))
+	gateway := netip.MustParseAddr(strings.TrimSpace(plan.Gateway))
+	taken[gateway.String()] = true
+
+	prefix := strings.TrimSpace(plan.HostnamePrefix)
+	networks := make([]firmware.NetworkSettings, 0, count)
+	addr := start
+	for i := 0; i < count; i++ {
+		for taken[addr.String()] || !hostAddress(subnet, addr) {
+			addr = addr.Next()
+			if !subnet.Contains(addr) {
+				return nil, fmt.Errorf("%s has no room for %d miners from %s", subnet, count, start)
+			}
+		}
+
+		network := base
+		ip := addr.String()
+		network.IPAddress = &ip
+		if prefix != "" {
+			hostname := fmt.Sprintf("%s%03d", prefix, i+1)
+			if !validHostname(hostname) {
+				return nil, fmt.Errorf("invalid hostname %q", hostname)
+			}
+			network.Hostname = &hostname
+		}
+		networks = append(networks, network)
+		addr = addr.Next()
+	}
+	return networks, nil
+}
+
+// mergeNetwork overlays the fields set in update on current. Switching to
+// DHCP keeps the recorded static address for a later switch back.
+func mergeNetwork(current, update firmware.NetworkSettings) firmware.NetworkSettings {
+	if update.DHCP != nil {
+		current.DHCP = update.DHCP
+	}
+	if update.Hostname != nil {
+		current.Hostname = stringPtr(*update.Hostname)
+	}
+	if update.IPAddress != nil {
+		current.IPAddress = stringPtr(*update.IPAddress)
+	}
+	if update.Netmask != nil {
+		current.Netmask = stringPtr(*update.Netmask)
+	}
+	if update.Gateway != nil {
+		current.Gateway = stringPtr(*update.Gateway)
+	}
+	if update.DNSServers != nil {
+		current.DNSServers = update.DNSServers
+	}
+	return current
+}
+
+// validateNetwork checks the hostname and DNS servers and, for a static
+// configuration, that the address, netmask and gateway form a usable
+// IPv4 subnet.
+func validateNetwork(network firmware.NetworkSettings) error {
+	if network.Hostname != nil && !validHostname(*network.Hostname) {
+		return fmt.Errorf("invalid hostname %q", *network.Hostname)
+	}
+	for _, dns := range network.DNSServers {
+		if _, err := netip.ParseAddr(strings.TrimSpace(dns)); err != nil {
+			return fmt.Errorf("invalid DNS server %q", dns)
+		}
+	}
+	if !networkStatic(network) {
+		return nil
+	}
+
+	ip, err := parseIPv4("ip", network.IPAddress)
+	if err != nil {
+		return err
+	}
+	gateway, err := parseIPv4("gateway", network.Gateway)
+	if err != nil {
+		return err
+	}
+	if network.Netmask == nil || strings.TrimSpace(*network.Netmask) == "" {
+		return fmt.Errorf("netmask is required for a static address")
+	}
+	subnet, err := subnetOf(ip, strings.TrimSpace(*network.Netmask))
+	if err != nil {
+		return err
+	}
+	if !hostAddress(subnet, ip) {
+		return fmt.Errorf("%s is not a host address in %s", ip, subnet)
+	}
+	if !subnet.Contains(gateway) {
+		return fmt.Errorf("gateway %s is outside %s", gateway, subnet)
+	}
+	if ip == gateway {
+		return fmt.Errorf("ip and gateway are both %s", ip)
+	}
+	return nil
+}
+
+// networkStatic reports whether network turns DHCP off.
+func networkStatic(network firmware.NetworkSettings) bool {
+	return network.DHCP != nil && !*network.DHCP
+}
+
+func parseIPv4(field string, value *string) (netip.Addr, error) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return netip.Addr{}, fmt.Errorf("%s is required for a static address", field)
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(*value))
+	if err != nil || !addr.Is4() {
+		return netip.Addr{}, fmt.Errorf("%s must be an IPv4 address, got %q", field, *value)
+	}
+	return addr, nil
+}
+
+// subnetOf returns the subnet of ip under a dotted netmask.
+func subnetOf(ip netip.Addr, netmask string) (netip.Prefix, error) {
+	mask := net.ParseIP(netmask).To4()
+	if mask == nil {
+		return netip.Prefix{}, fmt.Errorf("invalid netmask %q", netmask)
+	}
+	bits, size := net.IPMask(mask).Size()
+	if size == 0 || bits == 0 {
+		return netip.Prefix{}, fmt.Errorf("invalid netmask %q", netmask)
+	}
+	return netip.PrefixFrom(ip, bits).Masked(), nil
+}
+
+// hostAddress reports whether addr is in subnet and is neither its network
+// nor its broadcast address. /31 and /32 subnets have no such addresses.
+func hostAddress(subnet netip.Prefix, addr netip.Addr) bool {
+	if !subnet.Contains(addr) {
+		return false
+	}
+	if subnet.Bits() >= 31 {
+		return true
+	}
+	if addr == subnet.Addr() {
+		return false
+	}
+	return subnet.Contains(addr.Next())
+}
+
+// validHostname accepts a single RFC 1123 label.
+func validHostname(name string) bool {
+	if name == "" || len(name) > 63 || name[0] == '-' || name[len(name)-1] == '-' {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
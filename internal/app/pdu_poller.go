@@ -0,0 +1,248 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/pdu"
+	"powerhive/internal/tracing"
+)
+
+// PDUStore is what the PDU poller needs from the database.
+type PDUStore interface {
+	database.MinerReader
+	database.PowerMeasurementStore
+}
+
+// PDUPoller measures the power each miner draws at its smart PDU outlets
+// and stores it with the power the miner's firmware reports.
+type PDUPoller struct {
+	store    PDUStore
+	cfg      config.AppConfig
+	log      *slog.Logger
+	interval time.Duration
+	readers  map[string]pdu.Reader
+	reloadCh chan config.AppConfig
+	clock    clock.Clock
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewPDUPoller constructs the PDU polling service.
+func NewPDUPoller(store PDUStore, cfg config.AppConfig, logger *slog.Logger) *PDUPoller {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	p := &PDUPoller{
+		store:    store,
+		log:      logger.With("component", "pdu"),
+		reloadCh: make(chan config.AppConfig, 1),
+		clock:    clock.Real,
+	}
+	p.applyConfig(cfg)
+	return p
+}
+
+// Enabled reports whether any PDU is configured.
+func (p *PDUPoller) Enabled() bool {
+	return p.cfg.PDU.Enabled()
+}
+
+// Reload hands a new configuration to the polling loop. It takes effect
+// before the next poll; configuring the first PDU requires a restart.
+func (p *PDUPoller) Reload(cfg config.AppConfig) {
+	queueReload(p.reloadCh, cfg)
+}
+
+func (p *PDUPoller) applyConfig(cfg config.AppConfig) {
+	timeout := time.Duration(cfg.Network.MinerProbeTimeoutMs) * time.Millisecond
+
+	readers := make(map[string]pdu.Reader, len(cfg.PDU.Devices))
+	for _, device := range cfg.PDU.Devices {
+		reader, err := pdu.New(device, timeout)
+		if err != nil {
+			p.log.Error("skipping pdu", "pdu", device.Name, "err", err)
+			continue
+		}
+		readers[device.Name] = reader
+	}
+
+	p.cfg = cfg
+	p.readers = readers
+	p.interval = time.Duration(cfg.PDU.IntervalSeconds) * time.Second
+}
+
+// Run polls the PDUs until the context is cancelled.
+func (p *PDUPoller) Run(ctx context.Context) {
+	p.log.Info("starting pdu loop", "interval", p.interval, "pdus", len(p.readers))
+
+	err := p.poll(ctx)
+	p.cycles.finish(p.clock.Now(), err)
+	if err != nil {
+		p.log.Error("initial pdu poll failed", "err", err)
+	}
+
+	ticker := p.clock.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.log.Info("stopping pdu loop", "reason", ctx.Err())
+			return
+		case <-ticker.C():
+			err := p.poll(ctx)
+			p.cycles.finish(p.clock.Now(), err)
+			if err != nil {
+				p.log.Error("pdu poll failed", "err", err)
+			}
+		case cfg := <-p.reloadCh:
+			if !cfg.PDU.Enabled() {
+				p.log.Warn("pdus removed from configuration; keeping the last configuration until restart")
+				continue
+			}
+			p.applyConfig(cfg)
+			ticker.Reset(p.interval)
+			p.log.Info("configuration reloaded", "interval", p.interval, "pdus", len(p.readers))
+		}
+	}
+}
+
+// minerOutlet is an outlet feeding a miner.
+type minerOutlet struct {
+	pdu    string
+	outlet string
+}
+
+func (o minerOutlet) String() string {
+	return o.pdu + "/" + o.outlet
+}
+
+func (p *PDUPoller) poll(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "pdu.poll")
+	defer func() { tracing.End(span, err) }()
+
+	miners, err := p.store.ListMiners(ctx)
+	if err != nil {
+		return fmt.Errorf("list miners: %w", err)
+	}
+	byKey := make(map[string]database.Miner, 2*len(miners))
+	for _, miner := range miners {
+		byKey[miner.ID] = miner
+		if miner.IP != nil {
+			byKey[*miner.IP] = miner
+		}
+	}
+
+	// Resolve every outlet to its miner; a miner draws the sum of its
+	// outlets.
+	feeds := make(map[string][]minerOutlet)
+	for _, device := range p.cfg.PDU.Devices {
+		for outlet, key := range device.Outlets {
+			miner, ok := byKey[strings.TrimSpace(key)]
+			if !ok {
+				p.log.Debug("pdu outlet maps to an unknown miner", "pdu", device.Name, "outlet", outlet, "miner", key)
+				continue
+			}
+			feeds[miner.ID] = append(feeds[miner.ID], minerOutlet{pdu: device.Name, outlet: outlet})
+		}
+	}
+
+	readings, errs := p.readAll(ctx)
+	now := p.clock.Now()
+
+	var measurements []database.PowerMeasurement
+	for minerID, outlets := range feeds {
+		sort.Slice(outlets, func(i, j int) bool { return outlets[i].String() < outlets[j].String() })
+		var (
+			powerW   float64
+			names    []string
+			complete = true
+		)
+		for _, o := range outlets {
+			value, ok := readings[o]
+			if !ok {
+				complete = false
+				break
+			}
+			powerW += value
+			names = append(names, o.String())
+		}
+		// Part of a miner's draw is no measurement of it.
+		if !complete {
+			continue
+		}
+
+		measurement := database.PowerMeasurement{
+			MinerID:    minerID,
+			Outlets:    strings.Join(names, ","),
+			PowerW:     powerW,
+			RecordedAt: now,
+		}
+		if status := byKey[minerID].LatestStatus; status != nil {
+			measurement.FirmwarePowerW = status.PowerConsumption
+		}
+		measurements = append(measurements, measurement)
+	}
+
+	if err := p.store.RecordPowerMeasurements(ctx, measurements); err != nil {
+		return fmt.Errorf("record power measurements: %w", err)
+	}
+	p.log.Debug("pdu poll complete", "miners_measured", len(measurements), "miners_mapped", len(feeds), "pdus_failed", len(errs))
+	return errors.Join(errs...)
+}
+
+// readAll reads every PDU at once and returns the outlet readings. A PDU
+// that cannot be read adds to the errors and leaves its outlets out.
+func (p *PDUPoller) readAll(ctx context.Context) (map[minerOutlet]float64, []error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		readings = make(map[minerOutlet]float64)
+		errs     []error
+	)
+	for _, device := range p.cfg.PDU.Devices {
+		reader, ok := p.readers[device.Name]
+		if !ok {
+			continue
+		}
+		outlets := make([]string, 0, len(device.Outlets))
+		for outlet := range device.Outlets {
+			outlets = append(outlets, outlet)
+		}
+		sort.Strings(outlets)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			powers, err := reader.Read(ctx, outlets)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				p.log.Warn("failed to read pdu", "pdu", device.Name, "err", err)
+				errs = append(errs, fmt.Errorf("read pdu %s: %w", device.Name, err))
+				return
+			}
+			for outlet, power := range powers {
+				readings[minerOutlet{pdu: device.Name, outlet: outlet}] = power
+			}
+			if missing := len(outlets) - len(powers); missing > 0 {
+				p.log.Debug("pdu returned no reading for some outlets", "pdu", device.Name, "missing", missing)
+			}
+		}()
+	}
+	wg.Wait()
+	return readings, errs
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// Actions recorded with quarantined readings, one per low-confidence policy.
+const (
+	quarantineDropped      = "dropped"
+	quarantineDamped       = "damped"
+	quarantineHeld         = "held"
+	quarantineConservative = "conservative"
+)
+
+// lowConfidence quarantines a reading at or below the confidence threshold
+// and stores the policy's replacement, if any. It always returns an error
+// wrapping errLowConfidence, so gap tracking still counts the reading as
+// missing.
+func (p *PlantPoller) lowConfidence(ctx context.Context, trust TrustInfo, input database.PlantReadingInput) (*database.PlantReading, error) {
+	policy := p.cfg.Plant.LowConfidence
+	lowErr := fmt.Errorf("%w: confidence %.2f (%s)", errLowConfidence, trust.ConfidenceScore, trust.Status)
+
+	replacement, action := p.fallbackReading(policy, input)
+	if err := p.store.QuarantinePlantReading(ctx, database.QuarantinedReadingInput{
+		PlantID:                   input.PlantID,
+		Confidence:                trust.ConfidenceScore,
+		TrustStatus:               &trust.Status,
+		TrustSummary:              &trust.Summary,
+		TotalGeneration:           input.TotalGeneration,
+		TotalContainerConsumption: input.TotalContainerConsumption,
+		Action:                    action,
+		RawData:                   input.RawData,
+		RecordedAt:                input.RecordedAt,
+	}); err != nil {
+		p.log.Warn("failed to quarantine plant reading", "err", err)
+	}
+
+	if replacement == nil {
+		p.log.Warn("skipping low confidence reading",
+			"confidence", trust.ConfidenceScore,
+			"status", trust.Status,
+			"plant_id", input.PlantID,
+		)
+		return nil, lowErr
+	}
+
+	recorded, err := p.store.RecordPlantReading(ctx, *replacement)
+	if err != nil {
+		return nil, fmt.Errorf("store %s plant reading: %w", action, err)
+	}
+	p.log.Warn("replaced low confidence reading",
+		"policy", policy.Policy,
+		"confidence", trust.ConfidenceScore,
+		"status", trust.Status,
+		"reported_generation_kw", input.TotalGeneration,
+		"generation_kw", recorded.TotalGeneration,
+		"container_kw", recorded.TotalContainerConsumption,
+	)
+	return &recorded, lowErr
+}
+
+// fallbackReading builds the reading the policy stores in place of input,
+// or nil when it stores nothing, and the action to quarantine input with.
+// Every policy but drop starts from the last trusted reading and drops
+// without one. Exported power is left out of held and conservative
+// readings, so zero-export mode falls back to generation.
+func (p *PlantPoller) fallbackReading(policy config.LowConfidenceConfig, input database.PlantReadingInput) (*database.PlantReadingInput, string) {
+	last := p.lastTrusted
+	if policy.Policy == config.LowConfidenceDrop || last == nil {
+		return nil, quarantineDropped
+	}
+
+	out := input
+	fallback := policy.Policy
+	out.Fallback = &fallback
+
+	var action string
+	switch policy.Policy {
+	case config.LowConfidenceDamp:
+		damp := func(previous, reported float64) float64 {
+			return previous + policy.Damping*(reported-previous)
+		}
+		out.TotalGeneration = damp(last.TotalGeneration, input.TotalGeneration)
+		out.TotalContainerConsumption = damp(last.TotalContainerConsumption, input.TotalContainerConsumption)
+		if last.ExportedPower != nil && input.ExportedPower != nil {
+			exported := damp(*last.ExportedPower, *input.ExportedPower)
+			out.ExportedPower = &exported
+		}
+		action = quarantineDamped
+	case config.LowConfidenceHold:
+		minutes := math.Max(0, input.RecordedAt.Sub(last.RecordedAt).Minutes())
+		out.TotalGeneration = last.TotalGeneration * math.Pow(1-policy.HoldDecayPercentPerMinute/100, minutes)
+		out.TotalContainerConsumption = last.TotalContainerConsumption
+		out.GenerationSources = last.GenerationSources
+		out.ConsumptionSources = last.ConsumptionSources
+		out.ExportedPower = nil
+		action = quarantineHeld
+	case config.LowConfidenceConservative:
+		out.TotalGeneration = math.Min(input.TotalGeneration, last.TotalGeneration) * (1 - policy.ConservativeMarginPercent/100)
+		out.ExportedPower = nil
+		action = quarantineConservative
+	default:
+		return nil, quarantineDropped
+	}
+	out.AvailablePower = out.TotalGeneration - out.TotalContainerConsumption
+	return &out, action
+}
+
+// plantReadingInput turns a stored reading back into an input.
+func plantReadingInput(reading database.PlantReading) *database.PlantReadingInput {
+	return &database.PlantReadingInput{
+		PlantID:                   reading.PlantID,
+		TotalGeneration:           reading.TotalGeneration,
+		TotalContainerConsumption: reading.TotalContainerConsumption,
+		AvailablePower:            reading.AvailablePower,
+		GenerationSources:         reading.GenerationSources,
+		ConsumptionSources:        reading.ConsumptionSources,
+		ExportedPower:             reading.ExportedPower,
+		BatterySOC:                reading.BatterySOC,
+		BatteryPower:              reading.BatteryPower,
+		GridFrequency:             reading.GridFrequency,
+		GridVoltage:               reading.GridVoltage,
+		RecordedAt:                reading.RecordedAt,
+	}
+}
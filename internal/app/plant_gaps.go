@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// plantGapTracker follows the plant poller's readings to detect gaps.
+type plantGapTracker struct {
+	// lastGoodAt is when the last usable reading arrived, by our clock.
+	lastGoodAt time.Time
+	// lastReadingAt is the collection time of that reading, by the
+	// aggregator's, used to spot the aggregator repeating itself.
+	lastReadingAt *time.Time
+	// open is set while a gap is recorded as open.
+	open bool
+	// settled is set once gaps were closed after startup, so the first
+	// usable reading also closes a gap left open by a restart.
+	settled bool
+}
+
+// plantGapPayload is the data of plant.gap and plant.restored events.
+type plantGapPayload struct {
+	ID              int64      `json:"id"`
+	Reason          string     `json:"reason"`
+	LastReadingAt   *time.Time `json:"last_reading_at"`
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	LastError       *string    `json:"last_error"`
+}
+
+// startGapTracking counts the gap from the latest stored reading, or from
+// now when there is none. A trusted latest reading also seeds the
+// low-confidence fallbacks.
+func (p *PlantPoller) startGapTracking(ctx context.Context) {
+	p.gaps = plantGapTracker{lastGoodAt: p.clock.Now()}
+	latest, err := p.store.GetLatestPlantReading(ctx)
+	if err != nil {
+		p.log.Warn("failed to load latest plant reading", "err", err)
+		return
+	}
+	if latest != nil {
+		at := latest.RecordedAt
+		p.gaps.lastReadingAt = &at
+		if at.Before(p.gaps.lastGoodAt) {
+			p.gaps.lastGoodAt = at
+		}
+		if latest.Fallback == nil {
+			p.lastTrusted = plantReadingInput(*latest)
+		}
+	}
+}
+
+// trackGap records a gap once no usable reading has arrived for two plant
+// intervals, either because the aggregator failed, its reading had low
+// confidence or it repeated an earlier reading. Once the gap lasts the
+// plant gap_alert_seconds it is alerted on, and when readings resume it is
+// closed.
+func (p *PlantPoller) trackGap(ctx context.Context, reading *database.PlantReading, pollErr error) {
+	now := p.clock.Now()
+	reason := ""
+	var detail *string
+	switch {
+	case errors.Is(pollErr, errLowConfidence):
+		reason = database.PlantGapLowConfidence
+	case pollErr != nil:
+		reason = database.PlantGapUnreachable
+	case reading == nil:
+		return
+	case p.gaps.lastReadingAt != nil && !reading.RecordedAt.After(*p.gaps.lastReadingAt):
+		reason = database.PlantGapStale
+	}
+	if pollErr != nil {
+		message := pollErr.Error()
+		detail = &message
+	}
+
+	if reason == "" {
+		at := reading.RecordedAt
+		p.gaps.lastReadingAt = &at
+		p.gaps.lastGoodAt = now
+		if p.gaps.open || !p.gaps.settled {
+			p.closeGaps(ctx, now)
+		}
+		return
+	}
+
+	if now.Sub(p.gaps.lastGoodAt) <= 2*p.interval {
+		return
+	}
+	gap, err := p.store.OpenPlantGap(ctx, reason, p.gaps.lastReadingAt, p.gaps.lastGoodAt.Add(p.interval), detail)
+	if err != nil {
+		p.log.Warn("failed to record plant gap", "err", err)
+		return
+	}
+	if !p.gaps.open {
+		p.log.Warn("plant readings missing",
+			"reason", reason,
+			"since", gap.StartedAt,
+			"last_reading_at", p.gaps.lastReadingAt,
+		)
+	}
+	p.gaps.open = true
+
+	alertAfter := time.Duration(p.cfg.Plant.GapAlertSeconds) * time.Second
+	if gap.Alerted || gap.Duration(now) < alertAfter {
+		return
+	}
+	p.log.Error("plant readings missing too long",
+		"reason", gap.Reason,
+		"since", gap.StartedAt,
+		"duration", gap.Duration(now).Round(time.Second),
+		"last_error", stringOrNil(gap.LastError),
+	)
+	if err := p.store.MarkPlantGapAlerted(ctx, gap.ID); err != nil {
+		p.log.Warn("failed to mark plant gap alerted", "gap", gap.ID, "err", err)
+	}
+	p.notifyGap(ctx, config.WebhookPlantGap, gap, now)
+}
+
+// closeGaps ends the open gaps now that readings are back, telling the
+// event notifier about those that were alerted on.
+func (p *PlantPoller) closeGaps(ctx context.Context, now time.Time) {
+	gaps, err := p.store.ClosePlantGaps(ctx, now)
+	if err != nil {
+		p.log.Warn("failed to close plant gap", "err", err)
+		return
+	}
+	p.gaps.open = false
+	p.gaps.settled = true
+	for _, gap := range gaps {
+		p.log.Info("plant readings resumed", "reason", gap.Reason, "duration", gap.Duration(now).Round(time.Second))
+		if gap.Alerted {
+			p.notifyGap(ctx, config.WebhookPlantRestored, gap, now)
+		}
+	}
+}
+
+func (p *PlantPoller) notifyGap(ctx context.Context, event string, gap database.PlantGap, now time.Time) {
+	if p.events == nil {
+		return
+	}
+	p.events.Notify(ctx, event, now, plantGapPayload{
+		ID:              gap.ID,
+		Reason:          gap.Reason,
+		LastReadingAt:   gap.LastReadingAt,
+		StartedAt:       gap.StartedAt,
+		EndedAt:         gap.EndedAt,
+		DurationSeconds: gap.Duration(now).Seconds(),
+		LastError:       gap.LastError,
+	})
+}
@@ -0,0 +1,261 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+const (
+	// plantMetricsInterval is how often today's metrics are refreshed and
+	// missing days filled in.
+	plantMetricsInterval = 15 * time.Minute
+	// plantMetricsBackfillDays is how far back missing days are computed.
+	plantMetricsBackfillDays = 30
+	// plantMetricsMaxGap is the longest interval between two readings that
+	// is integrated, as in the energy report.
+	plantMetricsMaxGap = 5 * time.Minute
+	// plantMetricsMaxCycles bounds the balance cycles read for one day.
+	plantMetricsMaxCycles = 100000
+)
+
+// PlantMetricsStore is what the daily plant metrics need from the database.
+type PlantMetricsStore interface {
+	database.PlantReader
+	database.PlantMetricsRecorder
+}
+
+// PlantMetrics derives daily plant metrics from the plant readings and
+// balance cycles: energy generated and consumed, the capacity factor, the
+// surplus curtailed while the fleet was at its maximum presets and the
+// consumption generation fell short of. Finished days are computed once;
+// today is refreshed every interval.
+type PlantMetrics struct {
+	store    PlantMetricsStore
+	log      *slog.Logger
+	reloadCh chan config.AppConfig
+
+	mu  sync.Mutex
+	cfg config.PlantConfig
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewPlantMetrics constructs the daily plant metrics service.
+func NewPlantMetrics(store PlantMetricsStore, cfg config.AppConfig, logger *slog.Logger) *PlantMetrics {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &PlantMetrics{
+		store:    store,
+		cfg:      cfg.Plant,
+		log:      logger.With("component", "plant_metrics"),
+		reloadCh: make(chan config.AppConfig, 1),
+	}
+}
+
+// Reload hands a new configuration to the metrics loop.
+func (m *PlantMetrics) Reload(cfg config.AppConfig) {
+	queueReload(m.reloadCh, cfg)
+}
+
+// MetricsTimezone is the zone whose calendar days the metrics cover.
+func (m *PlantMetrics) MetricsTimezone() *time.Location {
+	m.mu.Lock()
+	name := m.cfg.MetricsTimezone
+	m.mu.Unlock()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Run computes the metrics right away and then once per interval until
+// the context is cancelled.
+func (m *PlantMetrics) Run(ctx context.Context) {
+	cfg := m.config()
+	m.log.Info("starting plant metrics loop", "interval", plantMetricsInterval, "timezone", cfg.MetricsTimezone, "capacity_kw", cfg.CapacityKW)
+
+	m.update(ctx)
+
+	ticker := time.NewTicker(plantMetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.log.Info("stopping plant metrics loop", "reason", ctx.Err())
+			return
+		case <-ticker.C:
+			m.update(ctx)
+		case cfg := <-m.reloadCh:
+			m.mu.Lock()
+			m.cfg = cfg.Plant
+			m.mu.Unlock()
+			m.log.Info("configuration reloaded", "timezone", cfg.Plant.MetricsTimezone, "capacity_kw", cfg.Plant.CapacityKW)
+		}
+	}
+}
+
+func (m *PlantMetrics) config() config.PlantConfig {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cfg
+}
+
+func (m *PlantMetrics) update(ctx context.Context) {
+	err := m.computeDue(ctx, time.Now())
+	m.cycles.finish(time.Now(), err)
+	if err != nil {
+		m.log.Error("plant metrics update failed", "err", err)
+	}
+}
+
+// computeDue computes today and every day of the backfill window that was
+// not yet computed after it ended. A day counts as ended once readings
+// straddling midnight can no longer arrive.
+func (m *PlantMetrics) computeDue(ctx context.Context, now time.Time) error {
+	loc := m.MetricsTimezone()
+
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	first := today.AddDate(0, 0, -plantMetricsBackfillDays)
+	capacityKW := m.config().CapacityKW
+
+	stored, err := m.store.ListPlantDailyMetrics(ctx, loc.String(), first.Format(time.DateOnly), today.Format(time.DateOnly))
+	if err != nil {
+		return err
+	}
+	final := make(map[string]bool, len(stored))
+	for _, metrics := range stored {
+		end, err := time.ParseInLocation(time.DateOnly, metrics.Day, loc)
+		if err == nil && !metrics.ComputedAt.Before(end.AddDate(0, 0, 1).Add(plantMetricsMaxGap)) {
+			final[metrics.Day] = true
+		}
+	}
+
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if final[day.Format(time.DateOnly)] {
+			continue
+		}
+		metrics, ok, err := m.computeDay(ctx, day, now, capacityKW)
+		if err != nil {
+			return fmt.Errorf("compute %s: %w", day.Format(time.DateOnly), err)
+		}
+		if !ok {
+			continue
+		}
+		if err := m.store.SavePlantDailyMetrics(ctx, metrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// computeDay integrates the readings of the day starting at start, up to
+// now for today. It reports false when the day has no readings.
+func (m *PlantMetrics) computeDay(ctx context.Context, start, now time.Time, capacityKW float64) (database.PlantDailyMetrics, bool, error) {
+	end := start.AddDate(0, 0, 1)
+	windowEnd := end
+	if now.Before(windowEnd) {
+		windowEnd = now
+	}
+
+	cycles, err := m.store.ListBalanceCycles(ctx, start.Add(-plantMetricsMaxGap), windowEnd, plantMetricsMaxCycles)
+	if err != nil {
+		return database.PlantDailyMetrics{}, false, err
+	}
+	// Listed newest first; walk them oldest first alongside the readings.
+	slices.Reverse(cycles)
+
+	metrics := database.PlantDailyMetrics{
+		Day:      start.Format(time.DateOnly),
+		Timezone: start.Location().String(),
+	}
+	var (
+		covered time.Duration
+		prev    *database.PlantReading
+		next    int
+		atMax   bool
+		atMaxAt time.Time
+	)
+	err = m.store.ForEachPlantReading(ctx, start.Add(-plantMetricsMaxGap), windowEnd.Add(plantMetricsMaxGap), func(reading database.PlantReading) error {
+		defer func() { prev = &reading }()
+		if prev == nil {
+			return nil
+		}
+		seg, ok := database.NewPowerSegment(prev.RecordedAt, prev.TotalGeneration, reading.RecordedAt, reading.TotalGeneration, plantMetricsMaxGap)
+		if !ok {
+			return nil
+		}
+		seg, ok = seg.Clip(start, windowEnd)
+		if !ok {
+			return nil
+		}
+
+		// The fleet's state over the segment is that of the last balance
+		// cycle before it, if recent enough.
+		for next < len(cycles) && !cycles[next].RecordedAt.After(seg.Start) {
+			atMax = balanceCycleAtMax(cycles[next])
+			atMaxAt = cycles[next].RecordedAt
+			next++
+		}
+		fleetAtMax := atMax && seg.Start.Sub(atMaxAt) <= plantMetricsMaxGap
+
+		metrics.Generated += seg.KWh()
+		covered += seg.Duration()
+		if fleetAtMax {
+			metrics.AtMax += seg.Duration()
+		}
+
+		clip := func(startKW, endKW float64) database.PowerSegment {
+			part, _ := database.NewPowerSegment(prev.RecordedAt, startKW, reading.RecordedAt, endKW, 0)
+			part, _ = part.Clip(start, windowEnd)
+			return part
+		}
+		metrics.Container += clip(prev.TotalContainerConsumption, reading.TotalContainerConsumption).KWh()
+		surplus := clip(max(0, prev.AvailablePower), max(0, reading.AvailablePower)).KWh()
+		metrics.Surplus += surplus
+		if fleetAtMax {
+			metrics.CurtailedAtMax += surplus
+		}
+		metrics.Short += clip(max(0, -prev.AvailablePower), max(0, -reading.AvailablePower)).KWh()
+		return nil
+	})
+	if err != nil {
+		return database.PlantDailyMetrics{}, false, err
+	}
+	if covered <= 0 {
+		return database.PlantDailyMetrics{}, false, nil
+	}
+
+	metrics.Covered = covered
+	metrics.Coverage = min(1, covered.Seconds()/windowEnd.Sub(start).Seconds())
+	if capacityKW > 0 {
+		factor := metrics.Generated / (capacityKW * covered.Hours())
+		metrics.CapacityKW = &capacityKW
+		metrics.CapacityFactor = &factor
+	}
+	metrics.ComputedAt = now
+	return metrics, true, nil
+}
+
+// balanceCycleAtMax reports whether a cycle wanted more load than the
+// fleet drew but every eligible miner was already at its top preset.
+func balanceCycleAtMax(cycle database.BalanceCycle) bool {
+	if cycle.MinersEligible == 0 || cycle.MinersAdjusted > 0 || cycle.TargetPowerW <= cycle.ConsumptionAfterW {
+		return false
+	}
+	atMax := cycle.Skipped[skipMaxPreset]
+	return atMax > 0 && atMax+cycle.Skipped[skipNoEligiblePreset] >= cycle.MinersEligible
+}
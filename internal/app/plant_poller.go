@@ -3,47 +3,81 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"powerhive/internal/clock"
 	"powerhive/internal/config"
 	"powerhive/internal/database"
+	"powerhive/internal/tracing"
 )
 
 const plantRequestTimeout = 10 * time.Second
 
+// errLowConfidence marks a reading skipped for its confidence score.
+var errLowConfidence = errors.New("low confidence reading")
+
+// PlantStore is what plant polling needs from the database.
+type PlantStore interface {
+	database.PlantReader
+	database.PlantWriter
+	database.PlantGapRecorder
+}
+
 // PlantPoller periodically fetches energy generation and consumption data from the hydro plant API.
 type PlantPoller struct {
-	store      *database.Store
+	store      PlantStore
 	cfg        config.AppConfig
 	log        *slog.Logger
 	httpClient *http.Client
 	interval   time.Duration
+	reloadCh   chan config.AppConfig
+	clock      clock.Clock
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+
+	// events is told when readings stop and resume.
+	events EventNotifier
+	gaps   plantGapTracker
+	// lastTrusted is the last reading above the confidence threshold,
+	// which low-confidence fallbacks start from.
+	lastTrusted *database.PlantReadingInput
 }
 
 // NewPlantPoller creates a new plant data polling service.
-func NewPlantPoller(store *database.Store, cfg config.AppConfig, logger *slog.Logger) *PlantPoller {
+func NewPlantPoller(store PlantStore, cfg config.AppConfig, logger *slog.Logger) *PlantPoller {
 	return &PlantPoller{
 		store:      store,
 		cfg:        cfg,
 		log:        logger.With("component", "plant"),
 		httpClient: &http.Client{Timeout: plantRequestTimeout},
 		interval:   time.Duration(cfg.Intervals.PlantSeconds) * time.Second,
+		reloadCh:   make(chan config.AppConfig, 1),
+		clock:      clock.Real,
 	}
 }
 
+// Reload hands new plant credentials and interval to the polling loop.
+func (p *PlantPoller) Reload(cfg config.AppConfig) {
+	queueReload(p.reloadCh, cfg)
+}
+
 // Run starts the plant data polling loop.
 func (p *PlantPoller) Run(ctx context.Context) {
 	p.log.Info("starting plant polling loop", "interval", p.interval)
 
+	p.startGapTracking(ctx)
+
 	// Initial poll
-	if err := p.poll(ctx); err != nil {
-		p.log.Error("initial plant poll failed", "err", err)
-	}
+	p.cycle(ctx)
 
-	ticker := time.NewTicker(p.interval)
+	ticker := p.clock.NewTicker(p.interval)
 	defer ticker.Stop()
 
 	for {
@@ -51,21 +85,42 @@ func (p *PlantPoller) Run(ctx context.Context) {
 		case <-ctx.Done():
 			p.log.Info("stopping plant polling loop", "reason", ctx.Err())
 			return
-		case <-ticker.C:
-			if err := p.poll(ctx); err != nil {
-				p.log.Error("plant poll failed", "err", err)
-			}
+		case <-ticker.C():
+			p.cycle(ctx)
+		case cfg := <-p.reloadCh:
+			p.cfg = cfg
+			p.interval = time.Duration(cfg.Intervals.PlantSeconds) * time.Second
+			ticker.Reset(p.interval)
+			p.log.Info("configuration reloaded", "interval", p.interval)
 		}
 	}
 }
 
-func (p *PlantPoller) poll(ctx context.Context) error {
+// cycle polls the plant once and tracks gaps in its readings. A reading
+// skipped for low confidence is not a failed cycle.
+func (p *PlantPoller) cycle(ctx context.Context) {
+	reading, err := p.poll(ctx)
+	if errors.Is(err, errLowConfidence) {
+		p.cycles.finish(p.clock.Now(), nil)
+	} else {
+		p.cycles.finish(p.clock.Now(), err)
+		if err != nil {
+			p.log.Error("plant poll failed", "err", err)
+		}
+	}
+	p.trackGap(ctx, reading, err)
+}
+
+func (p *PlantPoller) poll(ctx context.Context) (stored *database.PlantReading, err error) {
+	ctx, span := tracing.Start(ctx, "plant.poll", attribute.String("plant.id", p.cfg.Plant.PlantID))
+	defer func() { tracing.End(span, err) }()
+
 	// Build URL with plant_id query parameter
 	url := fmt.Sprintf("%s?plant_id=%s", p.cfg.Plant.APIEndpoint, p.cfg.Plant.PlantID)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("create plant request: %w", err)
+		return nil, fmt.Errorf("create plant request: %w", err)
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.cfg.Plant.APIKey))
@@ -73,31 +128,21 @@ func (p *PlantPoller) poll(ctx context.Context) error {
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("fetch plant data: %w", err)
+		return nil, fmt.Errorf("fetch plant data: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("plant API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("plant API returned status %d", resp.StatusCode)
 	}
 
 	var apiResp PlantAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return fmt.Errorf("decode plant response: %w", err)
+		return nil, fmt.Errorf("decode plant response: %w", err)
 	}
 
 	reading := apiResp.Reading
 
-	// Check confidence score - skip readings with confidence ≤ 0.8
-	if reading.Trust.ConfidenceScore <= 0.8 {
-		p.log.Warn("skipping low confidence reading",
-			"confidence", reading.Trust.ConfidenceScore,
-			"status", reading.Trust.Status,
-			"plant_id", reading.PlantID,
-		)
-		return nil
-	}
-
 	// Extract individual source data (already in MW from API)
 	generationSources := make(map[string]float64)
 	for sourceName, source := range reading.Generation {
@@ -120,6 +165,24 @@ func (p *PlantPoller) poll(ctx context.Context) error {
 	// Calculate available power (generation - consumption) in kW
 	availablePowerKW := totalGenerationKW - totalConsumptionKW
 
+	var exportedKW *float64
+	if reading.Totals.ExportedMW != nil {
+		kw := *reading.Totals.ExportedMW * 1000
+		exportedKW = &kw
+	}
+
+	var batterySOC, batteryKW *float64
+	if battery := reading.Battery; battery != nil {
+		soc := battery.SOCPercent
+		kw := battery.PowerMW * 1000
+		batterySOC, batteryKW = &soc, &kw
+	}
+
+	var gridFrequency, gridVoltage *float64
+	if grid := reading.Grid; grid != nil {
+		gridFrequency, gridVoltage = grid.FrequencyHz, grid.VoltageV
+	}
+
 	// Store raw JSON for debugging
 	rawJSON, _ := json.Marshal(apiResp)
 	rawStr := string(rawJSON)
@@ -131,24 +194,36 @@ func (p *PlantPoller) poll(ctx context.Context) error {
 		AvailablePower:            availablePowerKW,
 		GenerationSources:         generationSources,
 		ConsumptionSources:        consumptionSources,
+		ExportedPower:             exportedKW,
+		BatterySOC:                batterySOC,
+		BatteryPower:              batteryKW,
+		GridFrequency:             gridFrequency,
+		GridVoltage:               gridVoltage,
 		RawData:                   &rawStr,
 		RecordedAt:                reading.CollectionTimestamp,
 	}
 
-	stored, err := p.store.RecordPlantReading(ctx, input)
+	// Readings at or below the confidence threshold are quarantined and
+	// replaced according to the low-confidence policy.
+	if reading.Trust.ConfidenceScore <= p.cfg.Plant.LowConfidence.MinConfidence {
+		return p.lowConfidence(ctx, reading.Trust, input)
+	}
+
+	recorded, err := p.store.RecordPlantReading(ctx, input)
 	if err != nil {
-		return fmt.Errorf("store plant reading: %w", err)
+		return nil, fmt.Errorf("store plant reading: %w", err)
 	}
 
 	p.log.Info("plant data recorded",
-		"plant_id", stored.PlantID,
-		"generation_kw", stored.TotalGeneration,
-		"container_kw", stored.TotalContainerConsumption,
-		"available_kw", stored.AvailablePower,
+		"plant_id", recorded.PlantID,
+		"generation_kw", recorded.TotalGeneration,
+		"container_kw", recorded.TotalContainerConsumption,
+		"available_kw", recorded.AvailablePower,
 		"confidence", reading.Trust.ConfidenceScore,
 	)
 
-	return nil
+	p.lastTrusted = &input
+	return &recorded, nil
 }
 
 // PlantAPIResponse models the response from the energy aggregator API.
@@ -164,6 +239,8 @@ type PlantDataReading struct {
 	Generation          map[string]SourceReading  `json:"generation"`
 	Consumption         map[string]SourceReading  `json:"consumption"`
 	Totals              PlantTotals               `json:"totals"`
+	Battery             *BatteryReading           `json:"battery"`
+	Grid                *GridReading              `json:"grid"`
 	Trust               TrustInfo                 `json:"trust"`
 }
 
@@ -176,9 +253,23 @@ type SourceReading struct {
 
 // PlantTotals contains pre-calculated aggregate values.
 type PlantTotals struct {
-	GenerationMW  float64 `json:"generation_mw"`
-	ConsumptionMW float64 `json:"consumption_mw"`
-	ExportedMW    float64 `json:"exported_mw"`
+	GenerationMW  float64  `json:"generation_mw"`
+	ConsumptionMW float64  `json:"consumption_mw"`
+	ExportedMW    *float64 `json:"exported_mw"`
+}
+
+// BatteryReading is the plant battery's state, present only at plants with
+// storage. PowerMW is positive while discharging and negative while charging.
+type BatteryReading struct {
+	SOCPercent float64 `json:"soc_percent"`
+	PowerMW    float64 `json:"power_mw"`
+}
+
+// GridReading is the grid quality at the plant's connection point, present
+// only when the aggregator measures it.
+type GridReading struct {
+	FrequencyHz *float64 `json:"frequency_hz"`
+	VoltageV    *float64 `json:"voltage_v"`
 }
 
 // TrustInfo contains confidence scoring for the reading.
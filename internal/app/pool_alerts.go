@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+// poolWindow holds a pool's share counters at the start of the current
+// reject rate window.
+type poolWindow struct {
+	good, bad int64
+	// high records whether the last full window crossed the limit, so each
+	// spell of rejects alerts once.
+	high bool
+}
+
+// poolAlertPayload is the data of pool.dead and pool.reject_rate events.
+type poolAlertPayload struct {
+	MinerID  string  `json:"miner_id"`
+	Name     *string `json:"name"`
+	Location *string `json:"location"`
+	IP       string  `json:"ip"`
+	URL      string  `json:"url"`
+	Worker   string  `json:"worker"`
+	Status   string  `json:"status"`
+	// RejectPercent and WindowShares describe the window that crossed the
+	// limit, for pool.reject_rate events.
+	RejectPercent *float64 `json:"reject_percent,omitempty"`
+	WindowShares  int64    `json:"window_shares,omitempty"`
+}
+
+// checkPools alerts when a pool turns dead, compared with the miner's
+// previous status, and when a pool's share of rejected and stale shares
+// over the last window crosses the configured limit.
+func (p *StatusPoller) checkPools(ctx context.Context, miner database.Miner, summary firmware.SummaryResponse) {
+	deadBefore := make(map[string]bool)
+	if prev := miner.LatestStatus; prev != nil {
+		for _, pool := range prev.Pools {
+			if poolDead(safeString(pool.Status)) {
+				deadBefore[safeString(pool.URL)] = true
+			}
+		}
+	}
+
+	windows := make(map[string]*poolWindow, len(summary.Miner.Pools))
+	for _, pool := range summary.Miner.Pools {
+		url := strings.TrimSpace(pool.URL)
+		if poolDead(pool.Status) && !deadBefore[url] {
+			p.log.Warn("miner pool dead", "miner", miner.ID, "ip", safeString(miner.IP), "pool", url, "worker", pool.User)
+			p.notifyPool(ctx, config.WebhookPoolDead, miner, pool, nil, 0)
+		}
+		windows[url] = p.advancePoolWindow(ctx, miner, pool, p.poolWindows[miner.ID][url])
+	}
+	p.poolWindows[miner.ID] = windows
+}
+
+// advancePoolWindow folds the pool's counters into its window and returns
+// the window to keep. Once the window holds WindowShares shares its reject
+// rate is checked and a new window starts.
+func (p *StatusPoller) advancePoolWindow(ctx context.Context, miner database.Miner, pool firmware.SummaryPool, window *poolWindow) *poolWindow {
+	if pool.Accepted == nil {
+		return window
+	}
+	good := int64(*pool.Accepted)
+	bad := int64(valueOrZeroInt(pool.Rejected) + valueOrZeroInt(pool.Stale))
+
+	// Counters start over when the miner restarts mining.
+	if window == nil || good < window.good || bad < window.bad {
+		high := window != nil && window.high
+		return &poolWindow{good: good, bad: bad, high: high}
+	}
+
+	limits := p.cfg.Pools
+	shares := (good - window.good) + (bad - window.bad)
+	if shares < int64(limits.WindowShares) {
+		return window
+	}
+
+	rate := float64(bad-window.bad) / float64(shares) * 100
+	high := rate >= limits.RejectPercent
+	url := strings.TrimSpace(pool.URL)
+	switch {
+	case high && !window.high:
+		p.log.Warn("miner pool reject rate high",
+			"miner", miner.ID,
+			"pool", url,
+			"worker", pool.User,
+			"reject_percent", rate,
+			"shares", shares,
+		)
+		p.notifyPool(ctx, config.WebhookPoolRejects, miner, pool, &rate, shares)
+	case !high && window.high:
+		p.log.Info("miner pool reject rate normal again", "miner", miner.ID, "pool", url, "reject_percent", rate)
+	}
+	return &poolWindow{good: good, bad: bad, high: high}
+}
+
+func (p *StatusPoller) notifyPool(ctx context.Context, event string, miner database.Miner, pool firmware.SummaryPool, rate *float64, shares int64) {
+	if p.events == nil {
+		return
+	}
+	p.events.Notify(ctx, event, p.clock.Now(), poolAlertPayload{
+		MinerID:       miner.ID,
+		Name:          miner.Name,
+		Location:      miner.Location,
+		IP:            safeString(miner.IP),
+		URL:           strings.TrimSpace(pool.URL),
+		Worker:        strings.TrimSpace(pool.User),
+		Status:        strings.ToLower(strings.TrimSpace(pool.Status)),
+		RejectPercent: rate,
+		WindowShares:  shares,
+	})
+}
+
+// retainPoolWindows forgets the windows of miners no longer listed.
+func (p *StatusPoller) retainPoolWindows(miners []database.Miner) {
+	listed := make(map[string]bool, len(miners))
+	for _, miner := range miners {
+		listed[miner.ID] = true
+	}
+	for id := range p.poolWindows {
+		if !listed[id] {
+			delete(p.poolWindows, id)
+		}
+	}
+}
+
+func poolDead(status string) bool {
+	return strings.EqualFold(strings.TrimSpace(status), "dead")
+}
+
+func valueOrZeroInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func int64FromInt(value *int) *int64 {
+	if value == nil {
+		return nil
+	}
+	converted := int64(*value)
+	return &converted
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
@@ -11,64 +12,205 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"powerhive/internal/clock"
 	"powerhive/internal/config"
 	"powerhive/internal/database"
+	"powerhive/internal/economics"
 	"powerhive/internal/firmware"
+	"powerhive/internal/forecast"
+	"powerhive/internal/server"
+	"powerhive/internal/tracing"
 )
 
 const (
 	// Minimum time between preset changes for a single miner to avoid thrashing
-	presetChangeCooldown   = 30 * time.Second
+	presetChangeCooldown   = database.PresetChangeCooldown
 	balancerRequestTimeout = 5 * time.Second
+	// Delay before the first cycle so the pollers can populate data
+	balancerStartDelay = 5 * time.Second
+	// Consumption within this distance of the target needs no changes
+	// (roughly one miner's consumption) unless the configuration sets its own
+	defaultBalanceToleranceW = 2000
+	// Preset that puts a miner to sleep; allowed below the model's min_preset floor
+	sleepPreset = firmware.SleepPreset
+)
+
+// Reasons a balance cycle leaves a miner alone.
+const (
+	skipUnmanaged        = "unmanaged"
+	skipHeld             = "held"
+	skipProtected        = "protected"
+	skipRebooting        = "rebooting"
+	skipQueued           = "queued"
+	skipNoPowerControl   = "no_power_control"
+	skipNoModel          = "no_model"
+	skipUnreliable       = "unreliable"
+	skipNoStatus         = "no_status"
+	skipNoPowerData      = "no_power_data"
+	skipCooldown         = "cooldown"
+	skipDerated          = "thermal_derate"
+	skipNearTempCeiling  = "near_temp_ceiling"
+	skipMaxPreset        = "max_preset"
+	skipNoEligiblePreset = "no_eligible_preset"
+	skipWithinTolerance  = "within_tolerance"
+	skipPaused           = "paused"
+	skipGridProtection   = "grid_protection"
+	skipPowerRestore     = "power_restore"
+	skipChangeLimit      = "change_limit"
 )
 
+// BalancerStore is what the power balancer needs from the database.
+type BalancerStore interface {
+	database.MinerReader
+	database.ModelStore
+	database.PlantReader
+	database.BalanceRecorder
+	database.SettingsStore
+	database.DemandResponseStore
+	database.RebootRecorder
+	database.GridProtectionRecorder
+	database.PowerMeasurementStore
+	database.ReconciliationStore
+}
+
 // PowerBalancer orchestrates power consumption across miners to match available generation.
 type PowerBalancer struct {
-	store    *database.Store
+	store    BalancerStore
 	cfg      config.AppConfig
 	log      *slog.Logger
 	interval time.Duration
+	reloadCh chan config.AppConfig
+	forecast forecast.Provider
+	pi       piController
+	drivers  *driverCache
+	verifier *presetVerifier
+	reboots  *presetRebooter
+	queue    *presetChangeQueue
+	smoother *generationSmoother
+	limiter  *changeLimiter
+	clock    clock.Clock
+	// events receives every recorded balance event for the webhooks.
+	events EventNotifier
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+	// rotatedAt is when curtailment was last rotated.
+	rotatedAt time.Time
+	// restore tracks the staggered ramp-up after a blackout.
+	restore powerRestore
 }
 
 // NewPowerBalancer creates a new power balancing orchestrator.
-func NewPowerBalancer(store *database.Store, cfg config.AppConfig, logger *slog.Logger) *PowerBalancer {
-	return &PowerBalancer{
+func NewPowerBalancer(store BalancerStore, cfg config.AppConfig, logger *slog.Logger) *PowerBalancer {
+	log := logger.With("component", "balancer")
+	drivers := newDriverCache(firmwareHTTPClient(firmwareClientTimeout))
+	b := &PowerBalancer{
 		store:    store,
 		cfg:      cfg,
-		log:      logger.With("component", "balancer"),
+		log:      log,
 		interval: time.Duration(cfg.Intervals.BalancerSeconds) * time.Second,
+		reloadCh: make(chan config.AppConfig, 1),
+		forecast: newForecastProvider(store, cfg),
+		drivers:  drivers,
+		verifier: newPresetVerifier(store, drivers, clock.Real, log),
+		reboots:  newPresetRebooter(store, drivers, clock.Real, log),
+		smoother: newGenerationSmoother(store, cfg),
+		clock:    clock.Real,
 	}
+	b.queue = newPresetChangeQueue(cfg, b.applyPresetChange, clock.Real, log)
+	b.limiter = newChangeLimiter(store, b.queue, cfg, log)
+	return b
+}
+
+func newForecastProvider(store database.PlantReader, cfg config.AppConfig) forecast.Provider {
+	return forecast.NewMovingAverage(store, time.Duration(cfg.Forecast.WindowMinutes)*time.Minute)
+}
+
+// setEventNotifier sends the balancer's, the preset verifier's and the
+// reboot workflow's events to n.
+func (b *PowerBalancer) setEventNotifier(n EventNotifier) {
+	b.events = n
+	b.verifier.events = n
+	b.reboots.events = n
+}
+
+// BalanceQueue reports the preset changes waiting to be applied or being
+// applied.
+func (b *PowerBalancer) BalanceQueue() server.PresetChangeQueue {
+	return b.queue.snapshot()
+}
+
+// Reload hands a new configuration to the balancing loop. It takes effect
+// before the next cycle.
+func (b *PowerBalancer) Reload(cfg config.AppConfig) {
+	queueReload(b.reloadCh, cfg)
 }
 
 // Run starts the power balancing loop.
 func (b *PowerBalancer) Run(ctx context.Context) {
 	b.log.Info("starting power balancing loop", "interval", b.interval)
-
-	// Initial run after a short delay to let other services populate data
-	time.Sleep(5 * time.Second)
-	if err := b.balance(ctx); err != nil {
-		b.log.Error("initial balance failed", "err", err)
-	}
-
-	ticker := time.NewTicker(b.interval)
-	defer ticker.Stop()
+	go b.queue.run(ctx)
+
+	// The first cycle waits a short delay to let the pollers populate data.
+	// It is scheduled rather than slept so shutdown and reloads are not held
+	// up; the ticker starts once it has run.
+	start := b.clock.NewTimer(balancerStartDelay)
+	var ticker clock.Ticker
+	var tick <-chan time.Time
+	defer func() {
+		start.Stop()
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
 
 	for {
 		select {
 		case <-ctx.Done():
 			b.log.Info("stopping power balancing loop", "reason", ctx.Err())
+			b.queue.wait()
+			b.verifier.wait()
+			b.reboots.wait()
 			return
-		case <-ticker.C:
-			if err := b.balance(ctx); err != nil {
+		case <-start.C():
+			err := b.balance(ctx)
+			b.cycles.finish(b.clock.Now(), err)
+			if err != nil {
+				b.log.Error("initial balance failed", "err", err)
+			}
+			ticker = b.clock.NewTicker(b.interval)
+			tick = ticker.C()
+		case <-tick:
+			err := b.balance(ctx)
+			b.cycles.finish(b.clock.Now(), err)
+			if err != nil {
 				b.log.Error("balance cycle failed", "err", err)
 			}
+		case cfg := <-b.reloadCh:
+			b.cfg = cfg
+			b.interval = time.Duration(cfg.Intervals.BalancerSeconds) * time.Second
+			b.forecast = newForecastProvider(b.store, cfg)
+			b.pi = piController{}
+			b.queue.configure(cfg)
+			b.smoother.configure(cfg)
+			b.limiter.configure(cfg)
+			if ticker != nil {
+				ticker.Reset(b.interval)
+			}
+			b.log.Info("configuration reloaded", "interval", b.interval)
 		}
 	}
 }
 
-func (b *PowerBalancer) balance(ctx context.Context) error {
+func (b *PowerBalancer) balance(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "balancer.cycle")
+	defer func() { tracing.End(span, err) }()
+
 	// Get latest plant reading
 	plantReading, err := b.store.GetLatestPlantReading(ctx)
 	if err != nil {
@@ -80,18 +222,25 @@ func (b *PowerBalancer) balance(ctx context.Context) error {
 	}
 
 	// Get safety margin from settings
-	safetyMarginStr, err := b.store.GetAppSetting(ctx, "safety_margin_percent")
+	safetyMargin, err := b.loadSafetyMargin(ctx)
 	if err != nil {
-		return fmt.Errorf("get safety margin: %w", err)
+		return err
 	}
 
-	var safetyMargin float64
-	if err := json.Unmarshal([]byte(safetyMarginStr), &safetyMargin); err != nil {
-		safetyMargin = 10.0 // Default fallback
+	// Calculate target power (plant generation minus safety margin),
+	// smoothing out the jitter of the readings first
+	generation := plantReading.TotalGeneration
+	if b.cfg.Balancer.Smoothing.Method != "" {
+		smoothed, samples, err := b.smoother.smooth(ctx, plantReading)
+		if err != nil {
+			b.log.Warn("failed to smooth generation, using latest reading", "err", err)
+		} else {
+			b.log.Debug("smoothed generation", "raw_kw", generation, "smoothed_kw", smoothed, "samples", samples)
+			generation = smoothed
+		}
 	}
-
-	// Calculate target power (plant generation minus safety margin)
-	targetPower := plantReading.TotalGeneration * (1.0 - safetyMargin/100.0)
+	generation = b.plannedGeneration(ctx, generation)
+	targetPower := generation * (1.0 - safetyMargin/100.0)
 
 	b.log.Debug("balance cycle starting",
 		"available_kw", plantReading.AvailablePower,
@@ -104,9 +253,11 @@ func (b *PowerBalancer) balance(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("list miners: %w", err)
 	}
+	b.drivers.retain(miners)
 
 	// Filter to managed miners for balancing decisions
-	eligible := b.filterEligibleMiners(miners)
+	skipped := make(map[string]string)
+	eligible := b.filterEligibleMiners(miners, skipped)
 	if len(eligible) == 0 {
 		b.log.Debug("no eligible miners for balancing")
 	}
@@ -120,93 +271,215 @@ func (b *PowerBalancer) balance(ctx context.Context) error {
 		return fmt.Errorf("load preset power data: %w", err)
 	}
 
-	// Calculate current total consumption from ALL online miners (managed + unmanaged)
-	currentConsumption := b.calculateCurrentConsumption(allOnline, presetPowerMap)
+	// Rank miners by the expected hashrate of their preset; the live one is
+	// zero right after a change
+	expectedHashrate, err := b.loadPresetHashrateMap(ctx)
+	if err != nil {
+		b.log.Warn("failed to load preset hashrates, using live hashrate", "err", err)
+	}
+
+	// Calculate current total consumption from ALL online miners (managed + unmanaged),
+	// preferring the power measured at their PDU outlets
+	measured := b.measuredPower(ctx, allOnline)
+	currentConsumption := b.calculateCurrentConsumption(allOnline, presetPowerMap, measured)
+	// Add the load the container meters see beyond the estimates
+	if correctionW := b.reconciliationCorrectionW(ctx); correctionW != 0 {
+		b.log.Info("correcting consumption from container meters", "estimated_w", currentConsumption, "correction_w", correctionW)
+		currentConsumption += correctionW
+	}
 
 	// Convert target from kW to W for comparison with miner presets
 	targetPowerW := targetPower * 1000.0
-	currentConsumptionW := currentConsumption
+	// Queued changes have not reached the miners yet; count them as made so
+	// they are not planned again.
+	pendingW := b.queue.pendingDeltaW()
+	currentConsumptionW := currentConsumption + pendingW
+
+	mode, err := b.store.GetBalanceMode(ctx)
+	if err != nil {
+		b.log.Warn("failed to load balance mode, using fixed headroom", "err", err)
+		mode = database.BalanceModeFixedHeadroom
+	}
+	if mode == database.BalanceModeZeroExport {
+		// Whatever the plant exports is surplus the fleet can absorb.
+		if plantReading.ExportedPower != nil {
+			targetPowerW = currentConsumptionW + *plantReading.ExportedPower*1000.0
+		} else {
+			b.log.Warn("zero export mode needs exported power, using fixed headroom")
+		}
+	}
+	targetPowerW = b.applyBatteryPolicy(ctx, plantReading, targetPowerW)
+	targetPowerW = b.applyDemandResponse(ctx, measuredConsumption(plantReading, currentConsumptionW), targetPowerW)
 
 	b.log.Info("power status",
 		"current_w", currentConsumptionW,
 		"target_w", targetPowerW,
+		"mode", mode,
 		"delta_w", targetPowerW-currentConsumptionW,
 		"eligible_miners", len(eligible),
 	)
 
+	// Summarise the cycle once every change has been attempted
+	cycle := database.BalanceCycleInput{
+		Mode:               string(mode),
+		GenerationKW:       plantReading.TotalGeneration,
+		TargetPowerW:       targetPowerW,
+		ConsumptionBeforeW: currentConsumptionW,
+		MinersEligible:     len(eligible),
+	}
+	defer func() {
+		cycle.ConsumptionAfterW = currentConsumptionW
+		cycle.SkippedMiners = skipped
+		b.recordCycle(ctx, cycle)
+		b.limiter.recordCycle(cycle.MinersAdjusted)
+	}()
+
+	unprofitable := b.loadUnprofitablePresets(ctx)
+
+	cooldownMap := presetChangeTimes(miners)
+	budget := b.limiter.budget(ctx, b.clock.Now())
+
+	limits, err := b.store.GetThermalLimits(ctx)
+	if err != nil {
+		b.log.Warn("failed to load thermal limits, using defaults", "err", err)
+		limits = database.DefaultThermalLimits
+	}
+
+	// Step down overheating miners before looking at power headroom
+	estimatedW := currentConsumptionW
+	minerEfficiencies := b.calculateEfficiencies(eligible, presetPowerMap, expectedHashrate, measured, skipped)
+	derated, derateFailed := b.derateHotMiners(ctx, minerEfficiencies, limits, presetPowerMap, unprofitable, cooldownMap,
+		&currentConsumptionW, targetPowerW, plantReading.AvailablePower*1000)
+	cycle.MinersAdjusted = len(derated)
+	cycle.MinersFailed = derateFailed
+
+	// Shed load as soon as the grid sags, even while paused
+	shed, gridHold := b.protectGrid(ctx, plantReading, minerEfficiencies, presetPowerMap, unprofitable, derated, cooldownMap,
+		&currentConsumptionW, targetPowerW, &cycle)
+	// Park miners that all booted at once so they ramp up in batches
+	parked := b.checkPowerRestore(ctx, minerEfficiencies, presetPowerMap, unprofitable,
+		func(id string) bool { return derated[id] || shed[id] }, cooldownMap,
+		&currentConsumptionW, targetPowerW, plantReading.AvailablePower*1000, &cycle)
+	changed := func(id string) bool { return derated[id] || shed[id] || parked[id] }
+
+	// A paused balancer still derates hot miners, but goes no further.
+	pause, err := b.store.GetBalancerPause(ctx)
+	if err != nil {
+		b.log.Warn("failed to load balancer pause, balancing anyway", "err", err)
+	}
+	if pause.Paused {
+		b.pi = piController{}
+		b.log.Info("balancing paused, leaving presets alone", "paused_by", pause.By)
+		for _, me := range minerEfficiencies {
+			if !changed(me.miner.ID) {
+				skipped[me.miner.ID] = skipPaused
+			}
+		}
+		return nil
+	}
+
 	// Decide if we need to adjust
 	delta := targetPowerW - currentConsumptionW
-	if math.Abs(delta) < 2000 { // Within 2000W tolerance (roughly one miner's consumption)
+	usePI := b.cfg.Balancer.Strategy == config.BalancerPI
+	if usePI {
+		// Feed back the metered consumption, adjusted for any derating
+		// this cycle has already done and the changes still queued.
+		measuredW := measuredConsumption(plantReading, estimatedW-pendingW) + pendingW + (currentConsumptionW - estimatedW)
+		var proportional, integral float64
+		delta, proportional, integral = b.pi.delta(b.clock.Now(), b.cfg.Balancer, targetPowerW, measuredW, b.interval)
+		b.log.Info("pi control",
+			"measured_w", measuredW,
+			"error_w", targetPowerW-measuredW,
+			"p_w", proportional,
+			"i_w", integral,
+			"delta_w", delta,
+		)
+	}
+	if math.Abs(delta) < b.toleranceW() {
+		b.pi.saturated = false
 		b.log.Debug("consumption within tolerance, no changes needed")
+		for _, me := range minerEfficiencies {
+			if !changed(me.miner.ID) {
+				skipped[me.miner.ID] = skipWithinTolerance
+			}
+		}
+		b.endPowerRestore("consumption reached target")
+		// A settled fleet is the time to spread curtailment around.
+		if !gridHold {
+			rotated := b.rotateCurtailment(ctx, minerEfficiencies, presetPowerMap, expectedHashrate, unprofitable, limits,
+				cooldownMap, changed, &budget, &currentConsumptionW, targetPowerW, plantReading.AvailablePower*1000)
+			for _, id := range rotated {
+				delete(skipped, id)
+			}
+			cycle.MinersAdjusted += len(rotated)
+		}
+		return nil
+	}
+	// No miner is raised until the grid is back within its limits.
+	if gridHold && delta > 0 {
+		b.pi = piController{}
+		b.log.Info("grid protection active, not raising load", "delta_w", delta)
+		for _, me := range minerEfficiencies {
+			if !changed(me.miner.ID) {
+				skipped[me.miner.ID] = skipGridProtection
+			}
+		}
+		return nil
+	}
+	// After a blackout the fleet ramps up one batch at a time.
+	if b.restore.active && delta > 0 && !b.restoreBatchDue() {
+		b.log.Debug("power restore, waiting for next batch", "delta_w", delta)
+		for _, me := range minerEfficiencies {
+			if !changed(me.miner.ID) {
+				skipped[me.miner.ID] = skipPowerRestore
+			}
+		}
 		return nil
 	}
 
 	// Sort miners by efficiency (W/TH) - worst first for reduction, best first for increase
-	minerEfficiencies := b.calculateEfficiencies(eligible, presetPowerMap)
+	sortForDelta(minerEfficiencies, delta)
 	if delta < 0 {
-		// Need to reduce consumption - adjust least efficient miners first
-		sort.Slice(minerEfficiencies, func(i, j int) bool {
-			return minerEfficiencies[i].efficiency > minerEfficiencies[j].efficiency
-		})
 		b.log.Info("reducing consumption", "miners_to_adjust", len(minerEfficiencies))
 	} else {
-		// Can increase consumption - increase most efficient miners first
-		sort.Slice(minerEfficiencies, func(i, j int) bool {
-			return minerEfficiencies[i].efficiency < minerEfficiencies[j].efficiency
-		})
 		b.log.Info("increasing consumption", "miners_to_adjust", len(minerEfficiencies))
 	}
 
-	// Load cooldown map
-	cooldownMap, err := b.loadCooldownMap(ctx)
-	if err != nil {
-		b.log.Warn("failed to load cooldown map, continuing", "err", err)
-		cooldownMap = make(map[string]time.Time)
-	}
-
 	// Calculate planned changes and expected consumption
-	plannedChanges := make(map[string]struct {
-		targetPreset *string
-		targetPower  *float64
-	})
-
-	expectedConsumption := currentConsumptionW
-	for _, me := range minerEfficiencies {
-		// Check cooldown
-		if lastChange, exists := cooldownMap[me.miner.ID]; exists {
-			if time.Since(lastChange) < presetChangeCooldown {
-				continue
+	plannedChanges, powerChange := b.planChanges(minerEfficiencies, delta, presetPowerMap, unprofitable, limits,
+		func(me minerEfficiency) string {
+			if derated[me.miner.ID] {
+				return skipDerated
 			}
-		}
-
-		// Determine target preset
-		targetPreset, targetPower, err := b.determineTargetPreset(me.miner, delta, presetPowerMap)
-		if err != nil {
-			continue
-		}
-
-		if targetPreset == nil || (me.currentPreset != nil && *targetPreset == *me.currentPreset) {
-			continue // No change needed
-		}
-
-		// Store planned change
-		plannedChanges[me.miner.ID] = struct {
-			targetPreset *string
-			targetPower  *float64
-		}{targetPreset: targetPreset, targetPower: targetPower}
-
-		// Calculate expected consumption (current of unchanged + new of changed)
-		if me.currentPower != nil && targetPower != nil {
-			powerChange := *targetPower - *me.currentPower
-			expectedConsumption += powerChange
-			delta -= powerChange
-		}
-
-		// Stop planning if we're close enough to target
-		if math.Abs(delta) < 2000 {
-			break
+			if shed[me.miner.ID] {
+				return skipGridProtection
+			}
+			if parked[me.miner.ID] {
+				return skipPowerRestore
+			}
+			if budget.minerExhausted(me.miner.ID) {
+				return skipChangeLimit
+			}
+			if lastChange, exists := cooldownMap[me.miner.ID]; exists && b.clock.Since(lastChange) < presetChangeCooldown {
+				return skipCooldown
+			}
+			return ""
+		}, skipped)
+	if b.restore.active && delta > 0 {
+		if len(plannedChanges) == 0 {
+			b.endPowerRestore("no miner left to raise")
+		} else {
+			plannedChanges, powerChange = b.restoreBatch(minerEfficiencies, plannedChanges, skipped)
 		}
 	}
+	if !budget.allows(len(plannedChanges)) {
+		b.log.Info("preset change limit reached", "planned_changes", len(plannedChanges), "allowed", budget.remaining)
+		plannedChanges, powerChange = trimPlanned(minerEfficiencies, plannedChanges, budget.remaining, skipChangeLimit, skipped)
+	}
+	expectedConsumption := currentConsumptionW + powerChange
+	if usePI {
+		b.pi.saturated = len(plannedChanges) == 0
+	}
 
 	// Store and POST expected consumption
 	if err := b.storeExpectedConsumption(ctx, expectedConsumption); err != nil {
@@ -223,9 +496,13 @@ func (b *PowerBalancer) balance(ctx context.Context) error {
 		"delta_w", expectedConsumption-currentConsumptionW,
 		"planned_changes", len(plannedChanges))
 
-	// Now apply the planned changes
+	// Queue the planned changes; the queue applies them in the background
+	reason := "automatic_balance"
+	if b.restore.active {
+		reason = "power_restore"
+	}
 	adjustedCount := 0
-	delta = targetPowerW - currentConsumptionW // Reset delta for actual application
+	applied := make(map[string]bool)
 
 	for _, me := range minerEfficiencies {
 		// Check if we have a planned change for this miner
@@ -234,16 +511,22 @@ func (b *PowerBalancer) balance(ctx context.Context) error {
 			continue
 		}
 
-		// Apply preset change
-		if err := b.applyPresetChange(ctx, me.miner, me.currentPreset, *planned.targetPreset,
-			me.currentPower, planned.targetPower, currentConsumptionW, targetPowerW,
-			plantReading.AvailablePower*1000, "automatic_balance"); err != nil {
-			b.log.Error("failed to apply preset change", "miner", me.miner.ID, "err", err)
+		applied[me.miner.ID] = true
+		if !b.queue.enqueue(presetChange{
+			cfg:                b.cfg,
+			miner:              me.miner,
+			oldPreset:          me.currentPreset,
+			newPreset:          *planned.targetPreset,
+			oldPower:           me.currentPower,
+			newPower:           planned.targetPower,
+			consumptionBeforeW: currentConsumptionW,
+			targetPowerW:       targetPowerW,
+			availablePowerW:    plantReading.AvailablePower * 1000,
+			reason:             reason,
+		}) {
+			skipped[me.miner.ID] = skipQueued
 			continue
 		}
-
-		// Update cooldown map
-		cooldownMap[me.miner.ID] = time.Now()
 		adjustedCount++
 
 		// Recalculate delta
@@ -253,7 +536,7 @@ func (b *PowerBalancer) balance(ctx context.Context) error {
 			currentConsumptionW += powerChange
 		}
 
-		b.log.Info("preset changed",
+		b.log.Info("preset change queued",
 			"miner", me.miner.ID,
 			"old_preset", stringOrNil(me.currentPreset),
 			"new_preset", *planned.targetPreset,
@@ -261,16 +544,18 @@ func (b *PowerBalancer) balance(ctx context.Context) error {
 		)
 
 		// Stop if we're close enough to target
-		if math.Abs(delta) < 2000 {
+		if math.Abs(delta) < b.toleranceW() {
 			break
 		}
 	}
-
-	// Save cooldown map
-	if err := b.saveCooldownMap(ctx, cooldownMap); err != nil {
-		b.log.Warn("failed to save cooldown map", "err", err)
+	for _, me := range minerEfficiencies {
+		if _, exists := plannedChanges[me.miner.ID]; exists && !applied[me.miner.ID] {
+			skipped[me.miner.ID] = skipWithinTolerance
+		}
 	}
 
+	cycle.MinersAdjusted += adjustedCount
+	span.SetAttributes(attribute.Int("balancer.miners_adjusted", adjustedCount))
 	if adjustedCount > 0 {
 		b.log.Info("balance cycle complete", "miners_adjusted", adjustedCount)
 	}
@@ -278,6 +563,208 @@ func (b *PowerBalancer) balance(ctx context.Context) error {
 	return nil
 }
 
+// plannedGeneration returns the generation to balance against. With the
+// forecast enabled, a predicted drop replaces the current reading so the
+// fleet ramps down ahead of it; predicted rises are ignored until they
+// show up in the readings.
+func (b *PowerBalancer) plannedGeneration(ctx context.Context, currentKW float64) float64 {
+	if !b.cfg.Forecast.Enabled || b.forecast == nil {
+		return currentKW
+	}
+
+	horizon := time.Duration(b.cfg.Forecast.HorizonMinutes) * time.Minute
+	predicted, err := b.forecast.Forecast(ctx, horizon)
+	if err != nil {
+		if errors.Is(err, forecast.ErrInsufficientData) {
+			b.log.Debug("no generation forecast yet, using current reading")
+		} else {
+			b.log.Warn("generation forecast failed, using current reading", "err", err)
+		}
+		return currentKW
+	}
+
+	if predicted.GenerationKW >= currentKW {
+		return currentKW
+	}
+	b.log.Info("planning against forecast generation drop",
+		"current_kw", currentKW,
+		"forecast_kw", predicted.GenerationKW,
+		"horizon", horizon,
+		"samples", predicted.Samples,
+	)
+	return predicted.GenerationKW
+}
+
+// applyBatteryPolicy lets the fleet draw on a charged battery and sheds
+// harder once it is depleted. Plants without a battery are unaffected.
+func (b *PowerBalancer) applyBatteryPolicy(ctx context.Context, reading *database.PlantReading, targetW float64) float64 {
+	if reading.BatterySOC == nil {
+		return targetW
+	}
+
+	policy, err := b.store.GetBatteryPolicy(ctx)
+	if err != nil {
+		b.log.Warn("failed to load battery policy, using defaults", "err", err)
+		policy = database.DefaultBatteryPolicy
+	}
+
+	adjusted := policy.Adjust(*reading.BatterySOC, targetW/1000.0) * 1000.0
+	if adjusted != targetW {
+		b.log.Info("battery adjusted target",
+			"soc_percent", *reading.BatterySOC,
+			"target_w", targetW,
+			"adjusted_w", adjusted,
+		)
+	}
+	return adjusted
+}
+
+// applyDemandResponse caps the target at the lowest limit among the active
+// demand-response events, whatever the generation, and records loadW against
+// each event's compliance.
+func (b *PowerBalancer) applyDemandResponse(ctx context.Context, loadW, targetW float64) float64 {
+	events, err := b.store.ActiveDREvents(ctx, b.clock.Now())
+	if err != nil {
+		b.log.Warn("failed to load demand response events", "err", err)
+		return targetW
+	}
+
+	for _, event := range events {
+		limitW := event.MaxLoadKW * 1000.0
+		if err := b.store.RecordDRSample(ctx, event.ID, loadW/1000.0, loadW <= limitW); err != nil {
+			b.log.Warn("failed to record demand response sample", "event", event.ID, "err", err)
+		}
+		if limitW < targetW {
+			b.log.Info("demand response event caps target",
+				"event", event.ID,
+				"max_load_w", limitW,
+				"load_w", loadW,
+				"target_w", targetW,
+			)
+			targetW = limitW
+		}
+	}
+	return targetW
+}
+
+// toleranceW returns how far consumption may sit from the target before the
+// balancer acts.
+func (b *PowerBalancer) toleranceW() float64 {
+	if b.cfg.Balancer.ToleranceW > 0 {
+		return b.cfg.Balancer.ToleranceW
+	}
+	return defaultBalanceToleranceW
+}
+
+// loadSafetyMargin reads the safety margin percentage, defaulting to 10.
+func (b *PowerBalancer) loadSafetyMargin(ctx context.Context) (float64, error) {
+	safetyMarginStr, err := b.store.GetAppSetting(ctx, "safety_margin_percent")
+	if err != nil {
+		return 0, fmt.Errorf("get safety margin: %w", err)
+	}
+
+	var safetyMargin float64
+	if err := json.Unmarshal([]byte(safetyMarginStr), &safetyMargin); err != nil {
+		safetyMargin = 10.0 // Default fallback
+	}
+	return safetyMargin, nil
+}
+
+// sortForDelta orders miners for adjustment: least efficient first when
+// consumption must drop, most efficient first when it can rise.
+func sortForDelta(miners []minerEfficiency, delta float64) {
+	if delta < 0 {
+		sort.Slice(miners, func(i, j int) bool {
+			return miners[i].efficiency > miners[j].efficiency
+		})
+		return
+	}
+	sort.Slice(miners, func(i, j int) bool {
+		return miners[i].efficiency < miners[j].efficiency
+	})
+}
+
+type plannedChange struct {
+	targetPreset *string
+	targetPower  *float64
+}
+
+// planChanges walks the sorted miners and picks one preset step for each
+// until the remaining delta is within tolerance. Miners for which skip
+// returns a reason are left alone. When skipped is not nil, every miner left
+// alone is added to it with its reason, including those not reached because
+// the delta was already covered. It returns the planned changes and
+// the expected change in total consumption.
+func (b *PowerBalancer) planChanges(miners []minerEfficiency, delta float64, presetPowerMap map[string]map[string]float64, unprofitable map[string]map[string]bool, limits database.ThermalLimits, skip func(minerEfficiency) string, skipped map[string]string) (map[string]plannedChange, float64) {
+	plannedChanges := make(map[string]plannedChange)
+	var totalChange float64
+	leave := func(me minerEfficiency, reason string) {
+		if skipped != nil {
+			skipped[me.miner.ID] = reason
+		}
+	}
+
+	for _, me := range miners {
+		if skip != nil {
+			if reason := skip(me); reason != "" {
+				leave(me, reason)
+				continue
+			}
+		}
+
+		// Never raise a miner running close to the temperature ceiling
+		if delta > 0 && limits.ChipTempCeilingC > 0 {
+			if temp, ok := minerChipTemp(me.miner); ok && temp >= limits.ChipTempCeilingC-limits.MarginC {
+				b.log.Debug("skipping increase for warm miner", "miner", me.miner.ID, "chip_temp_c", temp)
+				leave(me, skipNearTempCeiling)
+				continue
+			}
+		}
+
+		// Determine target preset
+		targetPreset, targetPower, err := b.determineTargetPreset(me.miner, delta, presetPowerMap, unprofitable)
+		if err != nil {
+			leave(me, skipNoPowerData)
+			continue
+		}
+
+		if targetPreset == nil || (me.currentPreset != nil && *targetPreset == *me.currentPreset) {
+			if delta > 0 && me.miner.Model.MaxPreset != nil {
+				leave(me, skipMaxPreset)
+			} else {
+				leave(me, skipNoEligiblePreset)
+			}
+			continue // No change needed
+		}
+
+		plannedChanges[me.miner.ID] = plannedChange{targetPreset: targetPreset, targetPower: targetPower}
+
+		// Calculate expected consumption (current of unchanged + new of changed)
+		if me.currentPower != nil && targetPower != nil {
+			powerChange := *targetPower - *me.currentPower
+			totalChange += powerChange
+			delta -= powerChange
+		}
+
+		// Stop planning if we're close enough to target
+		if math.Abs(delta) < b.toleranceW() {
+			break
+		}
+	}
+
+	if skipped != nil {
+		for _, me := range miners {
+			if _, planned := plannedChanges[me.miner.ID]; !planned {
+				if _, left := skipped[me.miner.ID]; !left {
+					skipped[me.miner.ID] = skipWithinTolerance
+				}
+			}
+		}
+	}
+
+	return plannedChanges, totalChange
+}
+
 type minerEfficiency struct {
 	miner         database.Miner
 	efficiency    float64 // W/TH
@@ -285,19 +772,16 @@ type minerEfficiency struct {
 	currentPower  *float64
 }
 
-func (b *PowerBalancer) filterEligibleMiners(miners []database.Miner) []database.Miner {
+// filterEligibleMiners returns the miners the balancer may change. When
+// skipped is not nil, the others are added to it with the reason.
+func (b *PowerBalancer) filterEligibleMiners(miners []database.Miner, skipped map[string]string) []database.Miner {
 	var eligible []database.Miner
+	now := b.clock.Now()
 	for _, miner := range miners {
-		if !miner.Managed {
-			continue
-		}
-		if miner.IP == nil || *miner.IP == "" {
-			continue
-		}
-		if miner.APIKey == nil || *miner.APIKey == "" {
-			continue
-		}
-		if miner.Model == nil {
+		if reason := b.ineligibleReason(miner, now); reason != "" {
+			if skipped != nil {
+				skipped[miner.ID] = reason
+			}
 			continue
 		}
 		eligible = append(eligible, miner)
@@ -305,6 +789,40 @@ func (b *PowerBalancer) filterEligibleMiners(miners []database.Miner) []database
 	return eligible
 }
 
+func (b *PowerBalancer) ineligibleReason(miner database.Miner, now time.Time) string {
+	if !miner.Managed {
+		return skipUnmanaged
+	}
+	// Held and protected miners still count towards consumption but are
+	// never changed
+	if miner.Protected {
+		return skipProtected
+	}
+	if miner.Held(now) {
+		return skipHeld
+	}
+	if miner.Rebooting(now) {
+		return skipRebooting
+	}
+	if b.queue.contains(miner.ID) {
+		return skipQueued
+	}
+	if !driverReady(miner) || !supportsPowerControl(miner) {
+		return skipNoPowerControl
+	}
+	if miner.Model == nil {
+		return skipNoModel
+	}
+	// Preset changes on miners that keep failing polls are likely to
+	// fail too; leave them until they recover.
+	if miner.Reliability.Flaky(b.cfg.Reliability.MaxConsecutiveFailures, b.cfg.Reliability.MinSuccessRatio) {
+		b.log.Debug("skipping unreliable miner", "miner", miner.ID,
+			"consecutive_failures", miner.Reliability.ConsecutiveFailures)
+		return skipUnreliable
+	}
+	return ""
+}
+
 // filterOnlineMiners returns all miners (managed and unmanaged) that are online
 // and have a model. Used for calculating total consumption baseline.
 func (b *PowerBalancer) filterOnlineMiners(miners []database.Miner) []database.Miner {
@@ -402,10 +920,25 @@ func (b *PowerBalancer) loadPresetPowerMap(miners []database.Miner) (map[string]
 	return presetPowerMap, nil
 }
 
-func (b *PowerBalancer) calculateCurrentConsumption(miners []database.Miner, presetPowerMap map[string]map[string]float64) float64 {
+func (b *PowerBalancer) calculateCurrentConsumption(miners []database.Miner, presetPowerMap map[string]map[string]float64, measured map[string]float64) float64 {
 	total := 0.0
+	for _, power := range b.minerConsumption(miners, presetPowerMap, measured) {
+		total += power
+	}
+	return total
+}
+
+// minerConsumption estimates the power of each miner with power data: the
+// measured power, else the power of its preset, else the power its status
+// reports.
+func (b *PowerBalancer) minerConsumption(miners []database.Miner, presetPowerMap map[string]map[string]float64, measured map[string]float64) map[string]float64 {
+	consumption := make(map[string]float64, len(miners))
 
 	for _, miner := range miners {
+		if power, ok := measured[miner.ID]; ok {
+			consumption[miner.ID] = power
+			continue
+		}
 		if miner.Model == nil {
 			continue
 		}
@@ -443,26 +976,78 @@ func (b *PowerBalancer) calculateCurrentConsumption(miners []database.Miner, pre
 					"miner", miner.ID)
 			}
 		} else {
-			total += minerPower
+			consumption[miner.ID] = minerPower
 		}
 	}
 
-	return total
+	return consumption
+}
+
+// measuredPower returns the power measured at the PDU outlets of the
+// miners that the balancer may use in place of the preset estimate: taken
+// within the pdu max_age_seconds, and at least settle_seconds after the
+// miner's latest preset change.
+func (b *PowerBalancer) measuredPower(ctx context.Context, miners []database.Miner) map[string]float64 {
+	cfg := b.cfg.PDU
+	if !cfg.Enabled() {
+		return nil
+	}
+	now := b.clock.Now()
+	latest, err := b.store.LatestPowerMeasurements(ctx, now.Add(-time.Duration(cfg.MaxAgeSeconds)*time.Second))
+	if err != nil {
+		b.log.Warn("failed to load power measurements, using preset estimates", "err", err)
+		return nil
+	}
+
+	settle := time.Duration(cfg.SettleSeconds) * time.Second
+	measured := make(map[string]float64, len(latest))
+	for _, miner := range miners {
+		m, ok := latest[miner.ID]
+		if !ok {
+			continue
+		}
+		if changedAt := miner.LastPresetChangeAt; changedAt != nil && m.RecordedAt.Before(changedAt.Add(settle)) {
+			continue
+		}
+		measured[miner.ID] = m.PowerW
+	}
+	b.log.Debug("using measured power", "miners", len(measured), "recent_measurements", len(latest))
+	return measured
+}
+
+// presetHashrate looks up the expected hashrate in TH/s of a model's preset.
+func presetHashrate(expected map[string]map[string]float64, modelAlias string, preset *string) (float64, bool) {
+	if preset == nil {
+		return 0, false
+	}
+	hashrate, ok := expected[modelAlias][*preset]
+	return hashrate, ok && hashrate > 0
 }
 
-func (b *PowerBalancer) calculateEfficiencies(miners []database.Miner, presetPowerMap map[string]map[string]float64) []minerEfficiency {
+// calculateEfficiencies returns the W/TH of every miner with enough data to
+// balance, taking the power from measured where it has the miner. When
+// skipped is not nil, the others are added to it with the reason.
+func (b *PowerBalancer) calculateEfficiencies(miners []database.Miner, presetPowerMap, expectedHashrate map[string]map[string]float64, measured map[string]float64, skipped map[string]string) []minerEfficiency {
 	var efficiencies []minerEfficiency
 
 	for _, miner := range miners {
 		if miner.LatestStatus == nil {
+			if skipped != nil {
+				skipped[miner.ID] = skipNoStatus
+			}
 			continue
 		}
 		if miner.Model == nil {
+			if skipped != nil {
+				skipped[miner.ID] = skipNoModel
+			}
 			continue
 		}
 
 		var currentPower *float64
-		if miner.LatestStatus.Preset != nil {
+		if power, ok := measured[miner.ID]; ok {
+			currentPower = &power
+		} else if miner.LatestStatus.Preset != nil {
 			if powerMap, exists := presetPowerMap[miner.Model.Alias]; exists {
 				if power, exists := powerMap[*miner.LatestStatus.Preset]; exists {
 					currentPower = &power
@@ -477,13 +1062,19 @@ func (b *PowerBalancer) calculateEfficiencies(miners []database.Miner, presetPow
 
 		// Skip if no power data available
 		if currentPower == nil {
+			if skipped != nil {
+				skipped[miner.ID] = skipNoPowerData
+			}
 			continue
 		}
 
-		// Calculate hashrate in TH/s
+		// Calculate hashrate in TH/s from the model's efficiency curve,
+		// falling back to the live hashrate for presets without one
 		var hashrateTH float64
-		if miner.LatestStatus.Hashrate != nil {
-			hashrateTH = *miner.LatestStatus.Hashrate / 1e12
+		if expected, ok := presetHashrate(expectedHashrate, miner.Model.Alias, miner.LatestStatus.Preset); ok {
+			hashrateTH = expected
+		} else if miner.LatestStatus.Hashrate != nil {
+			hashrateTH = *miner.LatestStatus.Hashrate / 1000
 		}
 
 		// For miners with 0 hashrate (like disabled preset), set very high efficiency
@@ -508,7 +1099,145 @@ func (b *PowerBalancer) calculateEfficiencies(miners []database.Miner, presetPow
 	return efficiencies
 }
 
-func (b *PowerBalancer) determineTargetPreset(miner database.Miner, delta float64, presetPowerMap map[string]map[string]float64) (*string, *float64, error) {
+// derateHotMiners steps every miner at or above the chip temperature ceiling
+// down one preset, regardless of power headroom. It returns the miners it
+// changed and how many changes failed, and adjusts currentConsumptionW by the
+// expected power change.
+func (b *PowerBalancer) derateHotMiners(ctx context.Context, miners []minerEfficiency, limits database.ThermalLimits, presetPowerMap map[string]map[string]float64, unprofitable map[string]map[string]bool, cooldownMap map[string]time.Time, currentConsumptionW *float64, targetPowerW, availablePowerW float64) (map[string]bool, int) {
+	derated := make(map[string]bool)
+	failed := 0
+	if limits.ChipTempCeilingC <= 0 {
+		return derated, failed
+	}
+
+	for _, me := range miners {
+		temp, ok := minerChipTemp(me.miner)
+		if !ok || temp < limits.ChipTempCeilingC {
+			continue
+		}
+
+		if lastChange, exists := cooldownMap[me.miner.ID]; exists && b.clock.Since(lastChange) < presetChangeCooldown {
+			b.log.Debug("hot miner in cooldown, waiting", "miner", me.miner.ID, "chip_temp_c", temp)
+			continue
+		}
+
+		targetPreset, targetPower, err := b.determineTargetPreset(me.miner, -1, presetPowerMap, unprofitable)
+		if err != nil || targetPreset == nil || (me.currentPreset != nil && *targetPreset == *me.currentPreset) {
+			b.log.Warn("miner over temperature ceiling but no lower preset available",
+				"miner", me.miner.ID, "chip_temp_c", temp, "ceiling_c", limits.ChipTempCeilingC)
+			continue
+		}
+
+		if err := b.applyPresetChange(ctx, presetChange{
+			cfg:                b.cfg,
+			miner:              me.miner,
+			oldPreset:          me.currentPreset,
+			newPreset:          *targetPreset,
+			oldPower:           me.currentPower,
+			newPower:           targetPower,
+			consumptionBeforeW: *currentConsumptionW,
+			targetPowerW:       targetPowerW,
+			availablePowerW:    availablePowerW,
+			reason:             "thermal_derate",
+		}); err != nil {
+			b.log.Error("failed to derate hot miner", "miner", me.miner.ID, "err", err)
+			failed++
+			continue
+		}
+
+		cooldownMap[me.miner.ID] = b.clock.Now()
+		derated[me.miner.ID] = true
+		if me.currentPower != nil && targetPower != nil {
+			*currentConsumptionW += *targetPower - *me.currentPower
+		}
+
+		b.log.Warn("derated hot miner",
+			"miner", me.miner.ID,
+			"chip_temp_c", temp,
+			"ceiling_c", limits.ChipTempCeilingC,
+			"old_preset", stringOrNil(me.currentPreset),
+			"new_preset", *targetPreset,
+		)
+	}
+
+	return derated, failed
+}
+
+// applyAtOnce applies the changes concurrently, bypassing the change queue,
+// for emergencies that cannot wait for its pacing. It returns the changes
+// that succeeded and how many failed.
+func (b *PowerBalancer) applyAtOnce(ctx context.Context, changes []presetChange) ([]presetChange, int) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied []presetChange
+		failed  int
+	)
+	for _, change := range changes {
+		wg.Add(1)
+		go func(change presetChange) {
+			defer wg.Done()
+			err := b.applyPresetChange(ctx, change)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				b.log.Error("failed to apply preset change", "miner", change.miner.ID, "reason", change.reason, "err", err)
+				failed++
+				return
+			}
+			applied = append(applied, change)
+		}(change)
+	}
+	wg.Wait()
+	return applied, failed
+}
+
+// minerChipTemp returns the hottest chip temperature in the miner's latest
+// status, falling back to PCB temperatures when the firmware reports no chip
+// readings.
+func minerChipTemp(miner database.Miner) (float64, bool) {
+	if miner.LatestStatus == nil {
+		return 0, false
+	}
+
+	var chip, pcb float64
+	var haveChip, havePCB bool
+	for _, chain := range miner.LatestStatus.Chains {
+		if chain.ChipTempMax != nil && (!haveChip || *chain.ChipTempMax > chip) {
+			chip, haveChip = *chain.ChipTempMax, true
+		}
+		if chain.PCBTempMax != nil && (!havePCB || *chain.PCBTempMax > pcb) {
+			pcb, havePCB = *chain.PCBTempMax, true
+		}
+	}
+	if haveChip {
+		return chip, true
+	}
+	return pcb, havePCB
+}
+
+// loadUnprofitablePresets returns the presets the economics settings rule
+// out, or nil when the minimum profitable preset constraint is off.
+func (b *PowerBalancer) loadUnprofitablePresets(ctx context.Context) map[string]map[string]bool {
+	settings, err := economics.Load(ctx, b.store)
+	if err != nil {
+		b.log.Warn("failed to load economics settings, ignoring profitability", "err", err)
+		return nil
+	}
+	if !settings.MinProfitablePreset || !settings.Complete() {
+		return nil
+	}
+
+	presets, err := b.store.GetAllModelPresets(ctx)
+	if err != nil {
+		b.log.Warn("failed to load model presets, ignoring profitability", "err", err)
+		return nil
+	}
+	return settings.UnprofitablePresets(presets)
+}
+
+func (b *PowerBalancer) determineTargetPreset(miner database.Miner, delta float64, presetPowerMap map[string]map[string]float64, unprofitable map[string]map[string]bool) (*string, *float64, error) {
 	if miner.Model == nil {
 		return nil, nil, fmt.Errorf("miner has no model")
 	}
@@ -539,6 +1268,28 @@ func (b *PowerBalancer) determineTargetPreset(miner database.Miner, delta float6
 		}
 	}
 
+	// Resolve the min_preset floor. Presets below it are never targeted; the
+	// only way to go lower is the sleep preset.
+	var floorPower *float64
+	if minPreset := miner.Model.MinPreset; minPreset != nil {
+		if power, exists := powerMap[*minPreset]; exists {
+			floorPower = &power
+		}
+	}
+	belowFloor := func(pp presetPower) bool {
+		if strings.EqualFold(pp.preset, sleepPreset) {
+			return false
+		}
+		return floorPower != nil && pp.power < *floorPower
+	}
+
+	// Presets that would run at a loss are never targeted either, wherever
+	// they sit relative to the floor. Sleeping costs nothing, so it stays.
+	losing := unprofitable[miner.Model.Alias]
+	isUnprofitable := func(pp presetPower) bool {
+		return losing[pp.preset] && !strings.EqualFold(pp.preset, sleepPreset)
+	}
+
 	// Determine direction
 	needsReduction := delta < 0
 
@@ -549,6 +1300,12 @@ func (b *PowerBalancer) determineTargetPreset(miner database.Miner, delta float6
 	if needsReduction {
 		// Need to reduce - find a lower preset
 		for i := len(presets) - 1; i >= 0; i-- {
+			if belowFloor(presets[i]) {
+				continue // Respect min_preset; further cuts fall through to sleep
+			}
+			if isUnprofitable(presets[i]) {
+				continue // Skip loss-making presets on the way down
+			}
 			if currentPower == nil || presets[i].power < *currentPower {
 				preset := presets[i].preset
 				power := presets[i].power
@@ -561,6 +1318,12 @@ func (b *PowerBalancer) determineTargetPreset(miner database.Miner, delta float6
 		// Can increase - find a higher preset (but respect max_preset)
 		maxPreset := miner.Model.MaxPreset
 		for i := 0; i < len(presets); i++ {
+			if belowFloor(presets[i]) {
+				continue // Waking from sleep goes straight to the floor
+			}
+			if isUnprofitable(presets[i]) {
+				continue // Climb past loss-making presets
+			}
 			if currentPower == nil || presets[i].power > *currentPower {
 				// Check if this exceeds max_preset
 				if maxPreset != nil && presets[i].preset != *maxPreset {
@@ -613,24 +1376,37 @@ func (b *PowerBalancer) determineTargetPreset(miner database.Miner, delta float6
 	return targetPreset, targetPower, nil
 }
 
-func (b *PowerBalancer) applyPresetChange(ctx context.Context, miner database.Miner, oldPreset *string, newPreset string, oldPower, newPower *float64, totalConsumBefore, targetPower, availablePower float64, reason string) error {
-	if miner.IP == nil || miner.APIKey == nil {
-		return fmt.Errorf("miner missing IP or API key")
+// applyPresetChange sets the miner's preset, records the balance event and
+// schedules the verification or reboot that follows.
+func (b *PowerBalancer) applyPresetChange(ctx context.Context, change presetChange) (err error) {
+	miner, oldPreset, newPreset := change.miner, change.oldPreset, change.newPreset
+	oldPower, newPower := change.oldPower, change.newPower
+	totalConsumBefore, targetPower, availablePower := change.consumptionBeforeW, change.targetPowerW, change.availablePowerW
+	reason := change.reason
+
+	ctx, span := tracing.Start(ctx, "balancer.set_preset", append(minerSpanAttrs(miner),
+		attribute.String("preset.old", safeString(oldPreset)),
+		attribute.String("preset.new", newPreset),
+	)...)
+	defer func() { tracing.End(span, err) }()
+
+	if !driverReady(miner) {
+		return fmt.Errorf("miner missing IP or credentials")
 	}
 
-	client, err := firmware.NewClient(*miner.IP)
+	driver, err := b.drivers.get(change.cfg, miner)
 	if err != nil {
-		return fmt.Errorf("create firmware client: %w", err)
+		return fmt.Errorf("create firmware driver: %w", err)
 	}
 
 	reqCtx, cancel := context.WithTimeout(ctx, balancerRequestTimeout)
 	defer cancel()
 
 	// Apply preset change via firmware API
-	result, err := client.SetPreset(reqCtx, *miner.APIKey, newPreset)
+	result, err := driver.SetPowerTarget(reqCtx, newPreset)
 	if err != nil {
 		// Log failure event
-		_, _ = b.store.RecordPowerBalanceEvent(ctx, database.PowerBalanceEventInput{
+		event, recordErr := b.store.RecordPowerBalanceEvent(ctx, database.PowerBalanceEventInput{
 			MinerID:                miner.ID,
 			OldPreset:              oldPreset,
 			NewPreset:              &newPreset,
@@ -642,20 +1418,17 @@ func (b *PowerBalancer) applyPresetChange(ctx context.Context, miner database.Mi
 			TargetPower:            &targetPower,
 			Success:                false,
 			ErrorMessage:           ptrString(err.Error()),
-			RecordedAt:             time.Now().UTC(),
+			RecordedAt:             b.clock.Now().UTC(),
 		})
+		if recordErr == nil {
+			notifyBalanceEvent(ctx, b.events, event)
+		}
 		return fmt.Errorf("set preset via firmware: %w", err)
 	}
 
-	// Log if restart/reboot is required
-	if result != nil {
-		if result.RestartRequired {
-			b.log.Info("miner restart required after preset change", "miner", miner.ID, "preset", newPreset)
-			client.RestartMining(reqCtx, *miner.APIKey)
-		}
-		if result.RebootRequired {
-			b.log.Info("miner reboot required after preset change", "miner", miner.ID, "preset", newPreset)
-		}
+	restartIfRequired(reqCtx, b.log, driver, miner, newPreset, result)
+	if err := b.store.RecordPresetChange(ctx, miner.ID, b.clock.Now()); err != nil {
+		b.log.Warn("failed to record preset change time", "miner", miner.ID, "err", err)
 	}
 
 	// Calculate new total consumption
@@ -666,7 +1439,7 @@ func (b *PowerBalancer) applyPresetChange(ctx context.Context, miner database.Mi
 	totalConsumAfter := totalConsumBefore + powerChange
 
 	// Log success event
-	if _, err := b.store.RecordPowerBalanceEvent(ctx, database.PowerBalanceEventInput{
+	if event, err := b.store.RecordPowerBalanceEvent(ctx, database.PowerBalanceEventInput{
 		MinerID:                miner.ID,
 		OldPreset:              oldPreset,
 		NewPreset:              &newPreset,
@@ -678,35 +1451,65 @@ func (b *PowerBalancer) applyPresetChange(ctx context.Context, miner database.Mi
 		AvailablePower:         &availablePower,
 		TargetPower:            &targetPower,
 		Success:                true,
-		RecordedAt:             time.Now().UTC(),
+		RecordedAt:             b.clock.Now().UTC(),
 	}); err != nil {
 		b.log.Warn("failed to log balance event", "err", err)
+	} else {
+		notifyBalanceEvent(ctx, b.events, event)
+	}
+
+	check := presetCheck{
+		cfg:       change.cfg,
+		miner:     miner,
+		oldPreset: oldPreset,
+		newPreset: newPreset,
+		oldPower:  oldPower,
+		newPower:  newPower,
+	}
+	// A change waiting for a reboot is verified once the miner is back.
+	if result == nil || !result.RebootRequired || !b.reboots.schedule(ctx, check) {
+		b.verifier.schedule(ctx, check)
 	}
 
 	return nil
 }
 
-func (b *PowerBalancer) loadCooldownMap(ctx context.Context) (map[string]time.Time, error) {
-	data, err := b.store.GetAppSetting(ctx, "last_preset_change")
-	if err != nil {
-		return make(map[string]time.Time), nil
+// restartIfRequired logs the restart or reboot the firmware asks for after
+// a preset change and restarts Vnish miners. The balancer reboots miners
+// through presetRebooter.
+func restartIfRequired(ctx context.Context, log *slog.Logger, driver firmware.MinerDriver, miner database.Miner, preset string, result *firmware.SaveConfigResult) {
+	if result == nil {
+		return
 	}
-
-	var cooldownMap map[string]time.Time
-	if err := json.Unmarshal([]byte(data), &cooldownMap); err != nil {
-		return make(map[string]time.Time), nil
+	if result.RestartRequired {
+		log.Info("miner restart required after preset change", "miner", miner.ID, "preset", preset)
+		if client, ok := driver.(*firmware.Client); ok {
+			client.RestartMining(ctx, *miner.APIKey)
+		}
+	}
+	if result.RebootRequired {
+		log.Info("miner reboot required after preset change", "miner", miner.ID, "preset", preset)
 	}
-
-	return cooldownMap, nil
 }
 
-func (b *PowerBalancer) saveCooldownMap(ctx context.Context, cooldownMap map[string]time.Time) error {
-	data, err := json.Marshal(cooldownMap)
-	if err != nil {
-		return err
+// recordCycle stores the cycle summary. Failures are logged and otherwise
+// ignored so they never interrupt balancing.
+func (b *PowerBalancer) recordCycle(ctx context.Context, cycle database.BalanceCycleInput) {
+	cycle.RecordedAt = b.clock.Now().UTC()
+	if _, err := b.store.RecordBalanceCycle(ctx, cycle); err != nil {
+		b.log.Warn("failed to record balance cycle", "err", err)
 	}
+}
 
-	return b.store.SetAppSetting(ctx, "last_preset_change", string(data))
+// presetChangeTimes maps each miner to its last balancer preset change.
+func presetChangeTimes(miners []database.Miner) map[string]time.Time {
+	times := make(map[string]time.Time, len(miners))
+	for _, miner := range miners {
+		if miner.LastPresetChangeAt != nil {
+			times[miner.ID] = *miner.LastPresetChangeAt
+		}
+	}
+	return times
 }
 
 func stringOrNil(s *string) string {
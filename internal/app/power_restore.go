@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// powerRestore is the balancer's state after a mass reconnection.
+type powerRestore struct {
+	active    bool
+	startedAt time.Time
+	// lastBatch is when the latest batch of miners was raised.
+	lastBatch time.Time
+	// triggeredAt keeps miners booted before the latest restore from
+	// triggering another.
+	triggeredAt time.Time
+}
+
+// minerBootedAt derives when the miner last booted from the uptime in its
+// latest status.
+func minerBootedAt(miner database.Miner) (time.Time, bool) {
+	status := miner.LatestStatus
+	if status == nil || status.Uptime == nil {
+		return time.Time{}, false
+	}
+	return status.RecordedAt.Add(-time.Duration(*status.Uptime) * time.Second), true
+}
+
+// checkPowerRestore enters power-restore mode when enough managed miners
+// booted within the window, as they do when power returns after a
+// blackout, and parks them at their lowest preset at once so they do not
+// all ramp up together. It returns the miners it parked. The mode ends
+// after its maximum duration; endPowerRestore ends it earlier.
+func (b *PowerBalancer) checkPowerRestore(ctx context.Context, miners []minerEfficiency, presetPowerMap map[string]map[string]float64, unprofitable map[string]map[string]bool, changed func(string) bool, cooldownMap map[string]time.Time, currentConsumptionW *float64, targetPowerW, availablePowerW float64, cycle *database.BalanceCycleInput) map[string]bool {
+	parked := make(map[string]bool)
+	cfg := b.cfg.Balancer.PowerRestore
+	if !cfg.Enabled() {
+		b.restore.active = false
+		return parked
+	}
+
+	now := b.clock.Now()
+	if b.restore.active && now.Sub(b.restore.startedAt) >= time.Duration(cfg.MaxMinutes)*time.Minute {
+		b.endPowerRestore("max duration reached")
+	}
+	if b.restore.active {
+		return parked
+	}
+
+	window := time.Duration(cfg.WindowSeconds) * time.Second
+	var booted []minerEfficiency
+	for _, me := range miners {
+		bootedAt, ok := minerBootedAt(me.miner)
+		if ok && now.Sub(bootedAt) <= window && bootedAt.After(b.restore.triggeredAt) {
+			booted = append(booted, me)
+		}
+	}
+	if len(booted) < cfg.MinMiners {
+		return parked
+	}
+
+	b.restore = powerRestore{active: true, startedAt: now, lastBatch: now, triggeredAt: now}
+	var changes []presetChange
+	for _, me := range booted {
+		if changed(me.miner.ID) || me.currentPower == nil {
+			continue
+		}
+		presets := rotationPresets(me.miner, presetPowerMap, unprofitable)
+		if len(presets) == 0 || presets[0].power >= *me.currentPower {
+			continue
+		}
+		power := presets[0].power
+		changes = append(changes, presetChange{
+			cfg:                b.cfg,
+			miner:              me.miner,
+			oldPreset:          me.currentPreset,
+			newPreset:          presets[0].name,
+			oldPower:           me.currentPower,
+			newPower:           &power,
+			consumptionBeforeW: *currentConsumptionW,
+			targetPowerW:       targetPowerW,
+			availablePowerW:    availablePowerW,
+			reason:             "power_restore",
+		})
+	}
+	// Changes still waiting were planned before the blackout.
+	if dropped, deltaW := b.queue.dropWaiting(); dropped > 0 {
+		*currentConsumptionW -= deltaW
+	}
+	applied, failed := b.applyAtOnce(ctx, changes)
+	for _, change := range applied {
+		parked[change.miner.ID] = true
+		cooldownMap[change.miner.ID] = now
+		*currentConsumptionW += change.powerDeltaW()
+	}
+	cycle.MinersAdjusted += len(parked)
+	cycle.MinersFailed += failed
+	b.pi = piController{}
+
+	b.log.Warn("mass reconnection detected, staggering ramp-up",
+		"booted_miners", len(booted),
+		"parked", len(parked),
+		"failed", failed,
+		"batch_size", cfg.BatchSize,
+		"batch_delay", time.Duration(cfg.BatchDelaySeconds)*time.Second,
+	)
+	return parked
+}
+
+// restoreBatchDue reports whether the ramp-up may raise its next batch.
+func (b *PowerBalancer) restoreBatchDue() bool {
+	delay := time.Duration(b.cfg.Balancer.PowerRestore.BatchDelaySeconds) * time.Second
+	return b.clock.Since(b.restore.lastBatch) >= delay
+}
+
+// restoreBatch trims planned increases to the next batch of the ramp-up,
+// keeping the first miners in order, and returns the batch with its
+// expected change in consumption. The others are skipped with reason
+// power_restore.
+func (b *PowerBalancer) restoreBatch(miners []minerEfficiency, planned map[string]plannedChange, skipped map[string]string) (map[string]plannedChange, float64) {
+	batch, powerChange := trimPlanned(miners, planned, b.cfg.Balancer.PowerRestore.BatchSize, skipPowerRestore, skipped)
+	if len(batch) > 0 {
+		b.restore.lastBatch = b.clock.Now()
+	}
+	return batch, powerChange
+}
+
+// endPowerRestore leaves power-restore mode.
+func (b *PowerBalancer) endPowerRestore(reason string) {
+	if !b.restore.active {
+		return
+	}
+	b.restore.active = false
+	b.log.Info("power restore complete", "reason", reason, "duration", b.clock.Since(b.restore.startedAt).Round(time.Second))
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+// reasonReboot is the balance event recorded once a reboot the balancer
+// started has finished or failed.
+const reasonReboot = "reboot"
+
+// rebootStore is what the reboot workflow needs from the database.
+type rebootStore interface {
+	database.BalanceRecorder
+	database.RebootRecorder
+}
+
+// presetRebooter reboots miners whose firmware needs a reboot before a
+// preset change takes effect. It opens a reboot window on the miner, so
+// discovery and the status poller do not report the expected downtime and
+// the balancer leaves the miner alone, reboots it after a short delay,
+// waits for it to answer again and reads the preset back. Each reboot runs
+// in its own goroutine so the balance cycle does not wait for it.
+type presetRebooter struct {
+	store   rebootStore
+	log     *slog.Logger
+	drivers *driverCache
+	clock   clock.Clock
+	events  EventNotifier
+
+	mu     sync.Mutex
+	active map[string]bool
+	wg     sync.WaitGroup
+}
+
+func newPresetRebooter(store rebootStore, drivers *driverCache, clk clock.Clock, logger *slog.Logger) *presetRebooter {
+	return &presetRebooter{
+		store:   store,
+		log:     logger,
+		drivers: drivers,
+		clock:   clk,
+		active:  make(map[string]bool),
+	}
+}
+
+// schedule starts a managed reboot for check. It reports false, leaving
+// the change to the preset verifier, when managed reboots are off or the
+// miner is already rebooting.
+func (r *presetRebooter) schedule(ctx context.Context, check presetCheck) bool {
+	delay := time.Duration(check.cfg.Balancer.RebootDelaySeconds) * time.Second
+	timeout := time.Duration(check.cfg.Balancer.RebootTimeoutSeconds) * time.Second
+	if delay <= 0 {
+		return false
+	}
+
+	miner := check.miner
+	r.mu.Lock()
+	if r.active[miner.ID] {
+		r.mu.Unlock()
+		return false
+	}
+	r.active[miner.ID] = true
+	r.mu.Unlock()
+
+	until := r.clock.Now().Add(delay + timeout)
+	if err := r.store.SetRebootWindow(ctx, miner.ID, &until); err != nil {
+		r.log.Warn("failed to open reboot window", "miner", miner.ID, "err", err)
+	}
+	r.log.Info("miner reboot scheduled", "miner", miner.ID, "preset", check.newPreset, "in", delay, "window_until", until)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.finish(miner.ID)
+		r.reboot(ctx, check, delay, timeout)
+	}()
+	return true
+}
+
+// wait blocks until every running reboot has returned.
+func (r *presetRebooter) wait() {
+	r.wg.Wait()
+}
+
+func (r *presetRebooter) finish(minerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.active, minerID)
+}
+
+func (r *presetRebooter) reboot(ctx context.Context, check presetCheck, delay, timeout time.Duration) {
+	miner := check.miner
+	select {
+	case <-ctx.Done():
+		return
+	case <-r.clock.After(delay):
+	}
+
+	err := r.rebootAndVerify(ctx, check, timeout)
+	if ctx.Err() != nil {
+		// Shutting down: the window closes by itself when it expires.
+		return
+	}
+	if closeErr := r.store.SetRebootWindow(ctx, miner.ID, nil); closeErr != nil {
+		r.log.Warn("failed to close reboot window", "miner", miner.ID, "err", closeErr)
+	}
+	r.record(ctx, check, err)
+	if err != nil {
+		r.log.Error("managed reboot failed", "miner", miner.ID, "preset", check.newPreset, "err", err)
+		return
+	}
+	r.log.Info("miner back after reboot", "miner", miner.ID, "preset", check.newPreset)
+}
+
+// rebootAndVerify reboots the miner, waits up to timeout for it to answer
+// and checks it runs the new preset.
+func (r *presetRebooter) rebootAndVerify(ctx context.Context, check presetCheck, timeout time.Duration) error {
+	miner := check.miner
+	driver, err := r.drivers.get(check.cfg, miner)
+	if err != nil {
+		return fmt.Errorf("create firmware driver: %w", err)
+	}
+	rebooter, ok := driver.(firmware.Rebooter)
+	if !ok {
+		return fmt.Errorf("reboot: %w", firmware.ErrUnsupported)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, balancerRequestTimeout)
+	err = rebooter.Reboot(reqCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("reboot: %w", err)
+	}
+
+	deadline := r.clock.Now().Add(timeout)
+	wait := min(firmwareRebootGrace, timeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.clock.After(wait):
+		}
+		wait = firmwareRebootPoll
+
+		reqCtx, cancel := context.WithTimeout(ctx, balancerRequestTimeout)
+		_, err := driver.Info(reqCtx)
+		cancel()
+		if err == nil {
+			break
+		}
+		if !r.clock.Now().Before(deadline) {
+			return fmt.Errorf("miner did not come back within %s: %w", timeout, err)
+		}
+	}
+
+	reader, ok := driver.(firmware.PresetReader)
+	if !ok {
+		return nil
+	}
+	reqCtx, cancel = context.WithTimeout(ctx, balancerRequestTimeout)
+	actual, err := reader.CurrentPreset(reqCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("read preset after reboot: %w", err)
+	}
+	if !samePreset(actual, check.newPreset) {
+		return fmt.Errorf("miner reports preset %q after reboot, expected %q", safeString(actual), check.newPreset)
+	}
+	return nil
+}
+
+// record logs the reboot's outcome as a balance event. A nil err records
+// a miner that came back on the new preset.
+func (r *presetRebooter) record(ctx context.Context, check presetCheck, err error) {
+	input := database.PowerBalanceEventInput{
+		MinerID:    check.miner.ID,
+		OldPreset:  check.oldPreset,
+		NewPreset:  &check.newPreset,
+		OldPower:   check.oldPower,
+		NewPower:   check.newPower,
+		Reason:     reasonReboot,
+		Success:    err == nil,
+		RecordedAt: r.clock.Now().UTC(),
+	}
+	if err != nil {
+		input.ErrorMessage = ptrString(err.Error())
+	}
+	event, recordErr := r.store.RecordPowerBalanceEvent(ctx, input)
+	if recordErr != nil {
+		r.log.Warn("failed to log balance event", "err", recordErr)
+		return
+	}
+	notifyBalanceEvent(ctx, r.events, event)
+}
@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+// Balance event reasons recorded by the preset verifier.
+const (
+	reasonVerifyFailed = "verify_failed"
+	reasonVerifyRetry  = "verify_retry"
+	reasonVerifyRevert = "verify_revert"
+)
+
+// presetVerifier reads a miner's preset back some time after the balancer
+// changes it. Firmware can accept a change and never apply it; the verifier
+// records that as a failed event, reapplies the change and finally reverts
+// it. Each check runs in its own goroutine so the balance cycle does not
+// wait out the delay.
+type presetVerifier struct {
+	store   database.BalanceRecorder
+	log     *slog.Logger
+	drivers *driverCache
+	clock   clock.Clock
+	events  EventNotifier
+
+	mu      sync.Mutex
+	seq     uint64
+	pending map[string]uint64
+	wg      sync.WaitGroup
+}
+
+// presetCheck describes one change to verify.
+type presetCheck struct {
+	cfg       config.AppConfig
+	miner     database.Miner
+	oldPreset *string
+	newPreset string
+	oldPower  *float64
+	newPower  *float64
+}
+
+func newPresetVerifier(store database.BalanceRecorder, drivers *driverCache, clk clock.Clock, logger *slog.Logger) *presetVerifier {
+	return &presetVerifier{
+		store:   store,
+		log:     logger,
+		drivers: drivers,
+		clock:   clk,
+		pending: make(map[string]uint64),
+	}
+}
+
+// schedule starts verifying check. A later change to the same miner
+// supersedes the pending check.
+func (v *presetVerifier) schedule(ctx context.Context, check presetCheck) {
+	delay := time.Duration(check.cfg.Balancer.VerifyDelaySeconds) * time.Second
+	if delay <= 0 {
+		return
+	}
+
+	v.mu.Lock()
+	v.seq++
+	id := v.seq
+	v.pending[check.miner.ID] = id
+	v.mu.Unlock()
+
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		defer v.finish(check.miner.ID, id)
+		v.verify(ctx, check, id, delay)
+	}()
+}
+
+// wait blocks until every running check has returned.
+func (v *presetVerifier) wait() {
+	v.wg.Wait()
+}
+
+func (v *presetVerifier) current(minerID string, id uint64) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.pending[minerID] == id
+}
+
+func (v *presetVerifier) finish(minerID string, id uint64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.pending[minerID] == id {
+		delete(v.pending, minerID)
+	}
+}
+
+func (v *presetVerifier) verify(ctx context.Context, check presetCheck, id uint64, delay time.Duration) {
+	miner := check.miner
+	driver, err := v.drivers.get(check.cfg, miner)
+	if err != nil {
+		v.log.Warn("preset verification skipped", "miner", miner.ID, "err", err)
+		return
+	}
+	reader, ok := driver.(firmware.PresetReader)
+	if !ok {
+		return
+	}
+
+	retries := max(check.cfg.Balancer.VerifyRetries, 0)
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-v.clock.After(delay):
+		}
+		if !v.current(miner.ID, id) {
+			return
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, balancerRequestTimeout)
+		actual, err := reader.CurrentPreset(reqCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				v.log.Warn("preset verification failed to read preset", "miner", miner.ID, "err", err)
+			}
+			return
+		}
+		if samePreset(actual, check.newPreset) {
+			if attempt > 0 {
+				v.log.Info("preset applied after retry", "miner", miner.ID, "preset", check.newPreset, "attempts", attempt+1)
+			}
+			return
+		}
+
+		v.log.Warn("preset change did not take effect", "miner", miner.ID, "expected", check.newPreset, "actual", safeString(actual))
+		v.record(ctx, check, check.oldPreset, check.newPreset, reasonVerifyFailed,
+			fmt.Errorf("miner reports preset %q, expected %q", safeString(actual), check.newPreset))
+
+		if attempt < retries {
+			err := v.apply(ctx, driver, miner, check.newPreset)
+			v.record(ctx, check, actual, check.newPreset, reasonVerifyRetry, err)
+			if err != nil {
+				v.log.Error("preset retry failed", "miner", miner.ID, "preset", check.newPreset, "err", err)
+				return
+			}
+			continue
+		}
+
+		if !check.cfg.Balancer.RevertOnVerifyFailure || check.oldPreset == nil {
+			v.log.Error("preset change abandoned after retries", "miner", miner.ID, "preset", check.newPreset)
+			return
+		}
+		err = v.apply(ctx, driver, miner, *check.oldPreset)
+		v.record(ctx, check, &check.newPreset, *check.oldPreset, reasonVerifyRevert, err)
+		if err != nil {
+			v.log.Error("preset revert failed", "miner", miner.ID, "preset", *check.oldPreset, "err", err)
+			return
+		}
+		v.log.Warn("preset change reverted", "miner", miner.ID, "preset", *check.oldPreset)
+		return
+	}
+}
+
+func (v *presetVerifier) apply(ctx context.Context, driver firmware.MinerDriver, miner database.Miner, preset string) error {
+	reqCtx, cancel := context.WithTimeout(ctx, balancerRequestTimeout)
+	defer cancel()
+	result, err := driver.SetPowerTarget(reqCtx, preset)
+	if err != nil {
+		return err
+	}
+	restartIfRequired(reqCtx, v.log, driver, miner, preset, result)
+	if err := v.store.RecordPresetChange(ctx, miner.ID, v.clock.Now()); err != nil {
+		v.log.Warn("failed to record preset change time", "miner", miner.ID, "err", err)
+	}
+	return nil
+}
+
+// record logs a verifier event. A nil err records a successful change.
+func (v *presetVerifier) record(ctx context.Context, check presetCheck, from *string, to, reason string, err error) {
+	input := database.PowerBalanceEventInput{
+		MinerID:    check.miner.ID,
+		OldPreset:  from,
+		NewPreset:  &to,
+		Reason:     reason,
+		Success:    err == nil,
+		RecordedAt: v.clock.Now().UTC(),
+	}
+	if to == check.newPreset {
+		input.OldPower, input.NewPower = check.oldPower, check.newPower
+	} else {
+		input.OldPower, input.NewPower = check.newPower, check.oldPower
+	}
+	if err != nil {
+		input.ErrorMessage = ptrString(err.Error())
+	}
+	event, recordErr := v.store.RecordPowerBalanceEvent(ctx, input)
+	if recordErr != nil {
+		v.log.Warn("failed to log balance event", "err", recordErr)
+		return
+	}
+	notifyBalanceEvent(ctx, v.events, event)
+}
+
+// samePreset reports whether the preset read from the miner matches want.
+func samePreset(actual *string, want string) bool {
+	return actual != nil && strings.EqualFold(strings.TrimSpace(*actual), strings.TrimSpace(want))
+}
@@ -0,0 +1,463 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+	"powerhive/internal/server"
+)
+
+const (
+	defaultRolloutBatchSize = 10
+	rolloutRequestTimeout   = 10 * time.Second
+	maxRolloutJobs          = 20
+)
+
+// RolloutStore is what profile rollouts need from the database.
+type RolloutStore interface {
+	database.MinerStore
+	database.ProfileReader
+}
+
+// ProfileRollout applies configuration profiles to miners in batches. Each
+// miner that accepts the profile has it merged into its settings snapshot,
+// which makes it the desired state for drift detection. A job stops after
+// the batch in which more miners failed than the request allows.
+type ProfileRollout struct {
+	store   RolloutStore
+	log     *slog.Logger
+	drivers *driverCache
+	queue   chan *rolloutJob
+
+	mu     sync.Mutex
+	cfg    config.AppConfig
+	jobs   []*rolloutJob
+	nextID int64
+	active bool
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+type rolloutJob struct {
+	server.ProfileRolloutJob
+	profile database.ConfigProfile
+}
+
+// NewProfileRollout constructs the configuration profile rollout engine.
+func NewProfileRollout(store RolloutStore, cfg config.AppConfig, logger *slog.Logger) *ProfileRollout {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &ProfileRollout{
+		store:   store,
+		cfg:     cfg,
+		log:     logger.With("component", "rollout"),
+		drivers: newDriverCache(firmwareHTTPClient(firmwareClientTimeout)),
+		queue:   make(chan *rolloutJob, 1),
+	}
+}
+
+// Reload swaps in a new configuration for subsequent batches.
+func (p *ProfileRollout) Reload(cfg config.AppConfig) {
+	p.mu.Lock()
+	p.cfg = cfg
+	p.mu.Unlock()
+}
+
+// StartRollout resolves the target miners and queues a new job. Members
+// that cannot be configured are listed as skipped rather than rejecting the
+// whole rollout.
+func (p *ProfileRollout) StartRollout(ctx context.Context, req server.ProfileRolloutRequest) (server.ProfileRolloutJob, error) {
+	profile, err := p.store.GetConfigProfile(ctx, req.ProfileID)
+	if err != nil {
+		return server.ProfileRolloutJob{}, err
+	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = defaultRolloutBatchSize
+	}
+
+	members, err := p.members(ctx, profile, req.MinerIDs)
+	if err != nil {
+		return server.ProfileRolloutJob{}, err
+	}
+	if len(members) == 0 {
+		if profile.Tag == nil {
+			return server.ProfileRolloutJob{}, fmt.Errorf("profile %q is not assigned to a tag; list the miners to configure", profile.Name)
+		}
+		return server.ProfileRolloutJob{}, fmt.Errorf("no miners carry tag %q", *profile.Tag)
+	}
+
+	var targets []server.ProfileRolloutTarget
+	var eligible int
+	for _, miner := range members {
+		target := server.ProfileRolloutTarget{MinerID: miner.ID, State: server.TargetPending}
+		switch {
+		case !miner.Managed:
+			target.State, target.Error = server.TargetSkipped, "miner is not managed"
+		case !driverReady(miner):
+			target.State, target.Error = server.TargetSkipped, server.ErrMinerUnreachable.Error()
+		case miner.Protected && !req.Force:
+			target.State, target.Error = server.TargetSkipped, server.ErrMinerProtected.Error()
+		default:
+			target.Batch = eligible/req.BatchSize + 1
+			eligible++
+		}
+		targets = append(targets, target)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active {
+		return server.ProfileRolloutJob{}, server.ErrRolloutInProgress
+	}
+
+	p.nextID++
+	job := &rolloutJob{
+		ProfileRolloutJob: server.ProfileRolloutJob{
+			ID:          strconv.FormatInt(p.nextID, 10),
+			ProfileID:   profile.ID,
+			ProfileName: profile.Name,
+			State:       server.RolloutQueued,
+			BatchSize:   req.BatchSize,
+			MaxFailures: req.MaxFailures,
+			Targets:     targets,
+			CreatedAt:   time.Now().UTC(),
+		},
+		profile: profile,
+	}
+
+	select {
+	case p.queue <- job:
+	default:
+		return server.ProfileRolloutJob{}, server.ErrRolloutInProgress
+	}
+
+	p.active = true
+	p.jobs = append(p.jobs, job)
+	if len(p.jobs) > maxRolloutJobs {
+		p.jobs = p.jobs[len(p.jobs)-maxRolloutJobs:]
+	}
+
+	return job.snapshot(), nil
+}
+
+// members returns the listed miners, or every miner carrying the profile's
+// tag when none are listed.
+func (p *ProfileRollout) members(ctx context.Context, profile database.ConfigProfile, ids []string) ([]database.Miner, error) {
+	if len(ids) > 0 {
+		seen := make(map[string]bool, len(ids))
+		var miners []database.Miner
+		for _, id := range ids {
+			id = strings.ToLower(strings.TrimSpace(id))
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			miner, err := p.store.GetMiner(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			miners = append(miners, miner)
+		}
+		return miners, nil
+	}
+
+	if profile.Tag == nil {
+		return nil, nil
+	}
+	all, err := p.store.ListMiners(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list miners: %w", err)
+	}
+	var miners []database.Miner
+	for _, miner := range all {
+		if slices.Contains(miner.Tags, *profile.Tag) {
+			miners = append(miners, miner)
+		}
+	}
+	return miners, nil
+}
+
+// RolloutJobs returns recent jobs, newest first.
+func (p *ProfileRollout) RolloutJobs() []server.ProfileRolloutJob {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	jobs := make([]server.ProfileRolloutJob, 0, len(p.jobs))
+	for i := len(p.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, p.jobs[i].snapshot())
+	}
+	return jobs
+}
+
+// RolloutJob returns a single job by id.
+func (p *ProfileRollout) RolloutJob(id string) (server.ProfileRolloutJob, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, job := range p.jobs {
+		if job.ID == id {
+			return job.snapshot(), true
+		}
+	}
+	return server.ProfileRolloutJob{}, false
+}
+
+// Run executes queued jobs one at a time until the context is cancelled.
+func (p *ProfileRollout) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			p.drain()
+			return
+		case job := <-p.queue:
+			p.execute(ctx, job)
+			p.cycles.finish(time.Now(), nil)
+		}
+	}
+}
+
+// drain drops a job that was queued but never started.
+func (p *ProfileRollout) drain() {
+	select {
+	case job := <-p.queue:
+		p.skipPending(job)
+		p.finish(job, server.RolloutFailed, "service stopped before the rollout started")
+	default:
+	}
+}
+
+func (p *ProfileRollout) execute(ctx context.Context, job *rolloutJob) {
+	p.mu.Lock()
+	job.State = server.RolloutRunning
+	job.StartedAt = time.Now().UTC()
+	batches := 0
+	for _, target := range job.Targets {
+		batches = max(batches, target.Batch)
+	}
+	p.mu.Unlock()
+
+	p.log.Info("profile rollout started", "job", job.ID, "profile", job.ProfileName, "miners", len(job.Targets), "batches", batches)
+
+	failed := 0
+	for batch := 1; batch <= batches; batch++ {
+		failed += p.runBatch(ctx, job, batch)
+		if ctx.Err() == nil && failed <= job.MaxFailures {
+			continue
+		}
+
+		reason := fmt.Sprintf("batch %d: %d miner(s) failed, limit is %d", batch, failed, job.MaxFailures)
+		if ctx.Err() != nil {
+			reason = "service stopped during rollout"
+		}
+		p.log.Warn("profile rollout halted", "job", job.ID, "reason", reason)
+		p.skipPending(job)
+		p.finish(job, server.RolloutFailed, reason)
+		return
+	}
+
+	p.finish(job, server.RolloutCompleted, "")
+}
+
+// runBatch configures every miner in the batch concurrently and returns
+// how many failed.
+func (p *ProfileRollout) runBatch(ctx context.Context, job *rolloutJob, batch int) int {
+	p.mu.Lock()
+	cfg := p.cfg
+	p.mu.Unlock()
+
+	var (
+		wg     sync.WaitGroup
+		failed int
+	)
+
+	for i := range job.Targets {
+		p.mu.Lock()
+		if job.Targets[i].Batch != batch || job.Targets[i].State != server.TargetPending {
+			p.mu.Unlock()
+			continue
+		}
+		job.Targets[i].State = server.TargetApplying
+		minerID := job.Targets[i].MinerID
+		p.mu.Unlock()
+
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+
+			result, err := p.apply(ctx, cfg, minerID, job.profile)
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			job.Targets[idx].RestartRequired = result.RestartRequired
+			job.Targets[idx].RebootRequired = result.RebootRequired
+			if err != nil {
+				failed++
+				job.Targets[idx].State = server.TargetFailed
+				job.Targets[idx].Error = err.Error()
+				p.log.Warn("profile rollout failed on miner", "job", job.ID, "miner", minerID, "err", err)
+				return
+			}
+			job.Targets[idx].State = server.TargetApplied
+			p.log.Info("profile applied", "job", job.ID, "miner", minerID, "profile", job.ProfileName)
+		}(i)
+	}
+
+	wg.Wait()
+	return failed
+}
+
+// apply sends each section of the profile to the miner and records the
+// merged settings snapshot once every section was accepted. The returned
+// result combines the restart and reboot flags of all sections.
+func (p *ProfileRollout) apply(ctx context.Context, cfg config.AppConfig, minerID string, profile database.ConfigProfile) (firmware.SaveConfigResult, error) {
+	var combined firmware.SaveConfigResult
+
+	miner, err := p.store.GetMiner(ctx, minerID)
+	if err != nil {
+		return combined, err
+	}
+	if !driverReady(miner) {
+		return combined, server.ErrMinerUnreachable
+	}
+	driver, err := p.drivers.get(cfg, miner)
+	if err != nil {
+		return combined, fmt.Errorf("create firmware driver: %w", err)
+	}
+
+	var input database.SettingsInput
+	if miner.Settings != nil {
+		input = miner.Settings.Input()
+	}
+	if profile.Cooling != nil {
+		input.Cooling = mergeCooling(input.Cooling, *profile.Cooling)
+	}
+	if profile.Misc != nil {
+		input.Misc = *profile.Misc
+	}
+	if len(profile.Pools) > 0 {
+		input.Pools = profile.Pools
+	}
+	if err := input.Validate(); err != nil {
+		return combined, fmt.Errorf("%w: %v", server.ErrInvalidSettings, err)
+	}
+
+	type step struct {
+		section string
+		send    func(context.Context) (*firmware.SaveConfigResult, error)
+	}
+	var steps []step
+	if len(profile.Pools) > 0 {
+		steps = append(steps, step{"pools", func(ctx context.Context) (*firmware.SaveConfigResult, error) {
+			controller, ok := driver.(firmware.PoolController)
+			if !ok {
+				return nil, firmware.ErrUnsupported
+			}
+			pools := make([]firmware.PoolSettings, 0, len(input.Pools))
+			for _, pool := range input.Pools {
+				pools = append(pools, firmware.PoolSettings{
+					URL:  strings.TrimSpace(pool.URL),
+					User: safeString(pool.Username),
+					Pass: safeString(pool.Password),
+				})
+			}
+			return controller.SetPools(ctx, pools)
+		}})
+	}
+	if profile.Cooling != nil {
+		steps = append(steps, step{"cooling", func(ctx context.Context) (*firmware.SaveConfigResult, error) {
+			controller, ok := driver.(firmware.CoolingController)
+			if !ok {
+				return nil, firmware.ErrUnsupported
+			}
+			return controller.SetCooling(ctx, firmwareCooling(input.Cooling))
+		}})
+	}
+	if profile.Misc != nil {
+		steps = append(steps, step{"misc", func(ctx context.Context) (*firmware.SaveConfigResult, error) {
+			controller, ok := driver.(firmware.MiscController)
+			if !ok {
+				return nil, firmware.ErrUnsupported
+			}
+			return controller.SetMisc(ctx, firmware.MiscSettings{
+				IgnoreBrokenSensors:  &input.Misc.IgnoreBrokenSensors,
+				MinOperationalChains: input.Misc.MinOperationalChains,
+			})
+		}})
+	}
+
+	for _, step := range steps {
+		reqCtx, cancel := context.WithTimeout(ctx, rolloutRequestTimeout)
+		result, err := step.send(reqCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, firmware.ErrUnsupported) {
+				return combined, fmt.Errorf("%s settings: firmware driver %s does not support them", step.section, driver.Kind())
+			}
+			return combined, fmt.Errorf("set %s settings: %w", step.section, err)
+		}
+		if result != nil {
+			combined.RestartRequired = combined.RestartRequired || result.RestartRequired
+			combined.RebootRequired = combined.RebootRequired || result.RebootRequired
+		}
+	}
+
+	if combined.RestartRequired {
+		if restarter, ok := driver.(firmware.Restarter); ok {
+			reqCtx, cancel := context.WithTimeout(ctx, rolloutRequestTimeout)
+			err := restarter.Restart(reqCtx)
+			cancel()
+			if err != nil {
+				p.log.Warn("restart after profile rollout failed", "miner", minerID, "err", err)
+			} else {
+				combined.RestartRequired = false
+			}
+		}
+	}
+
+	if _, err := p.store.SaveMinerSettings(ctx, minerID, input); err != nil {
+		return combined, fmt.Errorf("record settings: %w", err)
+	}
+	return combined, nil
+}
+
+// skipPending marks every target that has not started as skipped.
+func (p *ProfileRollout) skipPending(job *rolloutJob) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range job.Targets {
+		if job.Targets[i].State == server.TargetPending {
+			job.Targets[i].State = server.TargetSkipped
+		}
+	}
+}
+
+func (p *ProfileRollout) finish(job *rolloutJob, state, reason string) {
+	p.mu.Lock()
+	job.State = state
+	job.Error = reason
+	job.FinishedAt = time.Now().UTC()
+	p.active = false
+	p.mu.Unlock()
+
+	p.log.Info("profile rollout finished", "job", job.ID, "state", state)
+}
+
+// snapshot copies the job for readers; callers must hold the rollout lock.
+func (j *rolloutJob) snapshot() server.ProfileRolloutJob {
+	out := j.ProfileRolloutJob
+	out.Targets = append([]server.ProfileRolloutTarget(nil), j.Targets...)
+	return out
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// newProfilingServer serves the net/http/pprof handlers on addr, which the
+// configuration restricts to loopback.
+func newProfilingServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	// CPU profiles and traces stream for the requested duration, so no
+	// write timeout is applied.
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: httpReadTimeout,
+		IdleTimeout:       httpIdleTimeout,
+	}
+}
@@ -0,0 +1,297 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/server"
+)
+
+// consumptionEstimator estimates the power each online miner draws, the
+// way the balancer counts it.
+type consumptionEstimator interface {
+	estimateConsumption(ctx context.Context, miners []database.Miner) (map[string]float64, error)
+}
+
+// ReconcilerStore is what the reconciliation job needs from the
+// database.
+type ReconcilerStore interface {
+	database.MinerReader
+	database.PlantReader
+	database.ReconciliationStore
+}
+
+// ContainerReconciler compares each container's meter in the plant
+// readings with the estimated consumption of the miners located in it.
+type ContainerReconciler struct {
+	store     ReconcilerStore
+	estimator consumptionEstimator
+	log       *slog.Logger
+	reloadCh  chan config.AppConfig
+	clock     clock.Clock
+
+	mu  sync.Mutex
+	cfg config.AppConfig
+	// flagged holds the containers whose discrepancy is over the alert
+	// threshold, so each is logged once when it crosses.
+	flagged map[string]bool
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewContainerReconciler constructs the container reconciliation job.
+// The App sets its estimator to the balancer.
+func NewContainerReconciler(store ReconcilerStore, cfg config.AppConfig, logger *slog.Logger) *ContainerReconciler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &ContainerReconciler{
+		store:    store,
+		cfg:      cfg,
+		log:      logger.With("component", "reconciliation"),
+		reloadCh: make(chan config.AppConfig, 1),
+		clock:    clock.Real,
+		flagged:  make(map[string]bool),
+	}
+}
+
+// Reload hands a new configuration to the job. It takes effect before the
+// next run.
+func (r *ContainerReconciler) Reload(cfg config.AppConfig) {
+	queueReload(r.reloadCh, cfg)
+}
+
+func (r *ContainerReconciler) config() config.AppConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cfg
+}
+
+func (r *ContainerReconciler) interval() time.Duration {
+	return time.Duration(r.config().Reconciliation.IntervalSeconds) * time.Second
+}
+
+// Run reconciles the containers until the context is cancelled.
+func (r *ContainerReconciler) Run(ctx context.Context) {
+	r.log.Info("starting reconciliation loop", "interval", r.interval())
+
+	ticker := r.clock.NewTicker(r.interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.log.Info("stopping reconciliation loop", "reason", ctx.Err())
+			return
+		case <-ticker.C():
+			err := r.reconcile(ctx)
+			r.cycles.finish(r.clock.Now(), err)
+			if err != nil {
+				r.log.Error("reconciliation failed", "err", err)
+			}
+		case cfg := <-r.reloadCh:
+			r.mu.Lock()
+			r.cfg = cfg
+			r.mu.Unlock()
+			ticker.Reset(r.interval())
+			r.log.Info("configuration reloaded", "interval", r.interval())
+		}
+	}
+}
+
+// reconcile stores a reconciliation of every container the latest plant
+// reading meters. A stale reading is skipped; the plant gap alerts cover
+// it.
+func (r *ContainerReconciler) reconcile(ctx context.Context) error {
+	cfg := r.config()
+	reading, err := r.store.GetLatestPlantReading(ctx)
+	if err != nil {
+		return fmt.Errorf("load latest plant reading: %w", err)
+	}
+	now := r.clock.Now()
+	if reading == nil || len(reading.ConsumptionSources) == 0 {
+		r.log.Debug("no container meters to reconcile")
+		return nil
+	}
+	maxAge := 3 * time.Duration(max(cfg.Intervals.PlantSeconds, cfg.Reconciliation.IntervalSeconds)) * time.Second
+	if age := now.Sub(reading.RecordedAt); age > maxAge {
+		r.log.Debug("latest plant reading too old to reconcile", "age", age.Round(time.Second))
+		return nil
+	}
+
+	miners, err := r.store.ListMiners(ctx)
+	if err != nil {
+		return fmt.Errorf("list miners: %w", err)
+	}
+	estimates, err := r.estimator.estimateConsumption(ctx, miners)
+	if err != nil {
+		return fmt.Errorf("estimate consumption: %w", err)
+	}
+
+	estimatedW := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, miner := range miners {
+		power, ok := estimates[miner.ID]
+		if !ok || miner.Location == nil {
+			continue
+		}
+		location := strings.TrimSpace(*miner.Location)
+		estimatedW[location] += power
+		counts[location]++
+	}
+
+	containers := make([]string, 0, len(reading.ConsumptionSources))
+	for container := range reading.ConsumptionSources {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	reconciliations := make([]database.ContainerReconciliation, 0, len(containers))
+	for _, container := range containers {
+		location := container
+		if mapped, ok := cfg.Reconciliation.Containers[container]; ok {
+			location = strings.TrimSpace(mapped)
+		}
+		meterKW := reading.ConsumptionSources[container] * 1000
+		rec := database.ContainerReconciliation{
+			Container:     container,
+			Location:      location,
+			MeterKW:       meterKW,
+			EstimatedKW:   estimatedW[location] / 1000,
+			DiscrepancyKW: meterKW - estimatedW[location]/1000,
+			Miners:        counts[location],
+			ReadingAt:     reading.RecordedAt,
+			RecordedAt:    now,
+		}
+		r.flag(rec, cfg.Reconciliation.AlertPercent)
+		reconciliations = append(reconciliations, rec)
+	}
+
+	if err := r.store.RecordContainerReconciliations(ctx, reconciliations); err != nil {
+		return fmt.Errorf("record reconciliations: %w", err)
+	}
+	r.log.Debug("containers reconciled", "containers", len(reconciliations))
+	return nil
+}
+
+// flag logs a container crossing the alert threshold in either direction.
+func (r *ContainerReconciler) flag(rec database.ContainerReconciliation, alertPercent float64) {
+	over := overAlert(rec, alertPercent)
+	r.mu.Lock()
+	was := r.flagged[rec.Container]
+	r.flagged[rec.Container] = over
+	r.mu.Unlock()
+
+	switch {
+	case over && !was:
+		r.log.Warn("container meter disagrees with miner estimates",
+			"container", rec.Container,
+			"meter_kw", rec.MeterKW,
+			"estimated_kw", rec.EstimatedKW,
+			"discrepancy_kw", rec.DiscrepancyKW,
+			"miners", rec.Miners,
+		)
+	case !over && was:
+		r.log.Info("container meter agrees with miner estimates again", "container", rec.Container, "discrepancy_kw", rec.DiscrepancyKW)
+	}
+}
+
+// overAlert reports whether a container's discrepancy exceeds alertPercent
+// of its meter reading.
+func overAlert(rec database.ContainerReconciliation, alertPercent float64) bool {
+	if rec.MeterKW <= 0 {
+		return rec.EstimatedKW > 0
+	}
+	return math.Abs(rec.DiscrepancyKW)/rec.MeterKW*100 > alertPercent
+}
+
+// averageDiscrepancyKW averages each container's discrepancy over
+// reconciliations and sums the averages.
+func averageDiscrepancyKW(reconciliations []database.ContainerReconciliation) float64 {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, rec := range reconciliations {
+		sums[rec.Container] += rec.DiscrepancyKW
+		counts[rec.Container]++
+	}
+	var total float64
+	for container, sum := range sums {
+		total += sum / float64(counts[container])
+	}
+	return total
+}
+
+// reconciliationWindow returns the reconciliations the balancer's
+// correction averages at now.
+func reconciliationWindow(ctx context.Context, store database.ReconciliationStore, cfg config.ReconciliationConfig, now time.Time) ([]database.ContainerReconciliation, error) {
+	return store.ListContainerReconciliations(ctx, now.Add(-time.Duration(cfg.WindowMinutes)*time.Minute), now.Add(time.Second))
+}
+
+// Reconciliation reports the latest reconciliation for
+// /api/balance/status.
+func (r *ContainerReconciler) Reconciliation(ctx context.Context) (server.Reconciliation, bool) {
+	cfg := r.config().Reconciliation
+	latest, err := r.store.LatestContainerReconciliations(ctx)
+	if err != nil {
+		r.log.Warn("failed to load reconciliation", "err", err)
+		return server.Reconciliation{}, false
+	}
+	if len(latest) == 0 {
+		return server.Reconciliation{}, false
+	}
+
+	out := server.Reconciliation{AlertPercent: cfg.AlertPercent}
+	for _, rec := range latest {
+		out.Containers = append(out.Containers, server.ContainerReconciliation{
+			ContainerReconciliation: rec,
+			Flagged:                 overAlert(rec, cfg.AlertPercent),
+		})
+	}
+	if cfg.CorrectBalancer {
+		window, err := reconciliationWindow(ctx, r.store, cfg, r.clock.Now())
+		if err != nil {
+			r.log.Warn("failed to load reconciliation window", "err", err)
+		} else {
+			correction := averageDiscrepancyKW(window)
+			out.CorrectionKW = &correction
+		}
+	}
+	return out, true
+}
+
+// estimateConsumption implements consumptionEstimator.
+func (b *PowerBalancer) estimateConsumption(ctx context.Context, miners []database.Miner) (map[string]float64, error) {
+	online := b.filterOnlineMiners(miners)
+	presetPowerMap, err := b.loadPresetPowerMap(online)
+	if err != nil {
+		return nil, fmt.Errorf("load preset power data: %w", err)
+	}
+	return b.minerConsumption(online, presetPowerMap, b.measuredPower(ctx, online)), nil
+}
+
+// reconciliationCorrectionW returns the watts the balancer adds to its
+// consumption estimate for what the container meters see beyond it, zero
+// unless reconciliation correct_balancer is set.
+func (b *PowerBalancer) reconciliationCorrectionW(ctx context.Context) float64 {
+	cfg := b.cfg.Reconciliation
+	if !cfg.CorrectBalancer {
+		return 0
+	}
+	window, err := reconciliationWindow(ctx, b.store, cfg, b.clock.Now())
+	if err != nil {
+		b.log.Warn("failed to load reconciliation, not correcting consumption", "err", err)
+		return 0
+	}
+	return averageDiscrepancyKW(window) * 1000
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+
+	"powerhive/internal/config"
+	"powerhive/internal/logging"
+)
+
+// ConfigLoader reads the configuration from its source, including any
+// command-line overrides.
+type ConfigLoader func() (config.AppConfig, error)
+
+// SetConfigLoader installs the loader ReloadConfig uses.
+func (a *App) SetConfigLoader(load ConfigLoader) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.loadConfig = load
+}
+
+// ReloadConfig re-reads the configuration and hands it to the running
+// services. Subnets, intervals, timeouts, worker pools, plant credentials,
+// firmware credentials and webhook endpoints take effect on each service's
+// next cycle and log levels and the firmware request cap immediately;
+// database, HTTP, backup, log output, tracing, services and ha settings
+// still require a restart.
+func (a *App) ReloadConfig() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.loadConfig == nil {
+		return fmt.Errorf("configuration reload is not available")
+	}
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	if cfg.Database != a.cfg.Database {
+		a.log.Warn("database settings changed; restart to apply")
+	}
+	if !reflect.DeepEqual(cfg.HTTP, a.cfg.HTTP) {
+		a.log.Warn("http settings changed; restart to apply")
+	}
+	if cfg.Backup != a.cfg.Backup {
+		a.log.Warn("backup settings changed; restart to apply")
+	}
+	if cfg.Tracing != a.cfg.Tracing {
+		a.log.Warn("tracing settings changed; restart to apply")
+	}
+	if !maps.Equal(cfg.Services, a.cfg.Services) {
+		a.log.Warn("services settings changed; restart to apply")
+	}
+	if cfg.HA != a.cfg.HA {
+		a.log.Warn("ha settings changed; restart to apply")
+	}
+
+	if cfg.Logging.Format != a.cfg.Logging.Format || cfg.Logging.File != a.cfg.Logging.File {
+		a.log.Warn("log output settings changed; restart to apply")
+	}
+	if a.logLevels != nil {
+		if err := a.logLevels.Apply(cfg.Logging); err != nil {
+			return fmt.Errorf("apply log levels: %w", err)
+		}
+	}
+
+	if err := configureFirmwareTLS(cfg.Firmware.TLS); err != nil {
+		return err
+	}
+	firmwareRequests.SetLimit(cfg.Concurrency.MaxFirmwareRequests)
+	firmwareLog.SetCapacity(cfg.Firmware.DebugLog.Entries)
+	// Leave a debug log toggled through the API alone unless the file
+	// changes the setting.
+	if cfg.Firmware.DebugLog.Enabled != a.cfg.Firmware.DebugLog.Enabled {
+		firmwareLog.SetEnabled(cfg.Firmware.DebugLog.Enabled)
+	}
+	a.discovery.Reload(cfg)
+	a.status.Reload(cfg)
+	a.telemetry.Reload(cfg)
+	a.plantPoller.Reload(cfg)
+	a.powerBalancer.Reload(cfg)
+	a.economics.Reload(cfg)
+	a.watchdog.Reload(cfg)
+	a.drift.Reload(cfg)
+	a.control.Reload(cfg)
+	a.rollout.Reload(cfg)
+	a.maintenance.Reload(cfg)
+	a.webhooks.Reload(cfg)
+	a.telegram.Reload(cfg)
+	a.keys.Reload(cfg)
+	a.plantMetrics.Reload(cfg)
+	a.pdu.Reload(cfg)
+	a.reconciler.Reload(cfg)
+	a.switchEvents.Reload(cfg)
+
+	// Keep the settings that are still in effect until the next restart.
+	cfg.Database = a.cfg.Database
+	cfg.HTTP = a.cfg.HTTP
+	cfg.Backup = a.cfg.Backup
+	cfg.Tracing = a.cfg.Tracing
+	cfg.Services = a.cfg.Services
+	cfg.HA = a.cfg.HA
+	cfg.Logging.Format = a.cfg.Logging.Format
+	cfg.Logging.File = a.cfg.Logging.File
+	a.cfg = cfg
+	a.log.Info("configuration reloaded")
+	return nil
+}
+
+// SetLogLevels installs the level registry behind the log level API and
+// configuration reloads.
+func (a *App) SetLogLevels(levels *logging.Levels) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.logLevels = levels
+}
+
+// LogLevels reports the default level and component overrides.
+func (a *App) LogLevels() map[string]string {
+	a.mu.Lock()
+	levels := a.logLevels
+	a.mu.Unlock()
+	if levels == nil {
+		return map[string]string{}
+	}
+	return levels.Snapshot()
+}
+
+// SetLogLevel changes a component's level until the next reload or restart.
+func (a *App) SetLogLevel(component, level string) error {
+	a.mu.Lock()
+	levels := a.logLevels
+	a.mu.Unlock()
+	if levels == nil {
+		return fmt.Errorf("log level control is not available")
+	}
+	return levels.Set(component, level)
+}
+
+// queueReload replaces any configuration still waiting in ch with cfg so a
+// service picks up only the latest one without blocking the caller.
+func queueReload(ch chan config.AppConfig, cfg config.AppConfig) {
+	for {
+		select {
+		case ch <- cfg:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// rotationPreset is a preset a rotated miner may be moved to.
+type rotationPreset struct {
+	name  string
+	power float64
+}
+
+// rotationCandidate is a miner the rotation may raise or lower.
+type rotationCandidate struct {
+	minerEfficiency
+	presets []rotationPreset
+	// efficiency is the W/TH at the top preset, zero when unknown.
+	efficiency float64
+	// since is the miner's last preset change, zero when never changed.
+	since time.Time
+}
+
+// rotateCurtailment swaps load between miners of similar efficiency so
+// the same miners do not stay curtailed for weeks: the miner curtailed
+// longest is raised to about the power of the miner running longest at a
+// higher preset, which is lowered to about the power of the first. It runs
+// once per rotation interval, only in cycles already within tolerance,
+// queues both changes and returns the miners it moved.
+func (b *PowerBalancer) rotateCurtailment(ctx context.Context, miners []minerEfficiency, presetPowerMap, expectedHashrate map[string]map[string]float64, unprofitable map[string]map[string]bool, limits database.ThermalLimits, cooldownMap map[string]time.Time, changed func(string) bool, budget *changeBudget, currentConsumptionW *float64, targetPowerW, availablePowerW float64) []string {
+	cfg := b.cfg.Balancer.Rotation
+	if cfg.IntervalMinutes <= 0 {
+		return nil
+	}
+	now := b.clock.Now()
+	if b.rotatedAt.IsZero() {
+		// Give a fresh start one interval before the first swap.
+		b.rotatedAt = now
+		return nil
+	}
+	if now.Sub(b.rotatedAt) < time.Duration(cfg.IntervalMinutes)*time.Minute {
+		return nil
+	}
+	b.rotatedAt = now
+
+	var lows, highs []rotationCandidate
+	for _, me := range miners {
+		id := me.miner.ID
+		if changed(id) || me.currentPower == nil || me.currentPreset == nil || b.queue.contains(id) || budget.minerExhausted(id) {
+			continue
+		}
+		if lastChange, ok := cooldownMap[id]; ok && b.clock.Since(lastChange) < presetChangeCooldown {
+			continue
+		}
+		presets := rotationPresets(me.miner, presetPowerMap, unprofitable)
+		if len(presets) < 2 {
+			continue
+		}
+		candidate := rotationCandidate{minerEfficiency: me, presets: presets, since: cooldownMap[id]}
+		top := presets[len(presets)-1]
+		if hashrate, ok := presetHashrate(expectedHashrate, me.miner.Model.Alias, &top.name); ok {
+			candidate.efficiency = top.power / hashrate
+		}
+
+		if *me.currentPower < top.power {
+			warm := false
+			if limits.ChipTempCeilingC > 0 {
+				temp, ok := minerChipTemp(me.miner)
+				warm = ok && temp >= limits.ChipTempCeilingC-limits.MarginC
+			}
+			if !warm {
+				lows = append(lows, candidate)
+			}
+		}
+		if *me.currentPower > presets[0].power {
+			highs = append(highs, candidate)
+		}
+	}
+	// Curtailed longest and running longest first
+	sort.SliceStable(lows, func(i, j int) bool { return lows[i].since.Before(lows[j].since) })
+	sort.SliceStable(highs, func(i, j int) bool { return highs[i].since.Before(highs[j].since) })
+
+	var rotated []string
+	used := make(map[string]bool)
+	for _, low := range lows {
+		if len(rotated) >= 2*cfg.MaxSwaps || !budget.allows(2) {
+			break
+		}
+		if used[low.miner.ID] {
+			continue
+		}
+		for _, high := range highs {
+			if used[high.miner.ID] || high.miner.ID == low.miner.ID || *high.currentPower <= *low.currentPower {
+				continue
+			}
+			if !inRotationBand(low, high, cfg.BandPercent) {
+				continue
+			}
+			raiseTo := nearestRotationPreset(low.presets, *high.currentPower)
+			lowerTo := nearestRotationPreset(high.presets, *low.currentPower)
+			if raiseTo.power <= *low.currentPower || lowerTo.power >= *high.currentPower {
+				continue
+			}
+			netW := (raiseTo.power - *low.currentPower) + (lowerTo.power - *high.currentPower)
+			if math.Abs(netW) >= b.toleranceW() {
+				continue
+			}
+
+			// Lower first so the swap never overshoots the target.
+			for _, step := range []struct {
+				candidate rotationCandidate
+				to        rotationPreset
+			}{{high, lowerTo}, {low, raiseTo}} {
+				newPower := step.to.power
+				b.queue.enqueue(presetChange{
+					cfg:                b.cfg,
+					miner:              step.candidate.miner,
+					oldPreset:          step.candidate.currentPreset,
+					newPreset:          step.to.name,
+					oldPower:           step.candidate.currentPower,
+					newPower:           &newPower,
+					consumptionBeforeW: *currentConsumptionW,
+					targetPowerW:       targetPowerW,
+					availablePowerW:    availablePowerW,
+					reason:             "rotation",
+				})
+			}
+			*currentConsumptionW += netW
+			budget.spend(2)
+			used[low.miner.ID] = true
+			used[high.miner.ID] = true
+			rotated = append(rotated, high.miner.ID, low.miner.ID)
+
+			b.log.Info("rotating curtailment",
+				"raise", low.miner.ID,
+				"raise_preset", raiseTo.name,
+				"curtailed_since", low.since,
+				"lower", high.miner.ID,
+				"lower_preset", lowerTo.name,
+				"running_since", high.since,
+				"net_w", netW,
+			)
+			break
+		}
+	}
+	if len(rotated) == 0 {
+		b.log.Debug("no miners to rotate", "curtailed", len(lows), "running", len(highs))
+	}
+	return rotated
+}
+
+// rotationPresets lists the presets the balancer may put the miner on,
+// lowest power first: none above max_preset, none losing money and none
+// below min_preset except sleep.
+func rotationPresets(miner database.Miner, presetPowerMap map[string]map[string]float64, unprofitable map[string]map[string]bool) []rotationPreset {
+	if miner.Model == nil {
+		return nil
+	}
+	powerMap := presetPowerMap[miner.Model.Alias]
+	ceiling, floor := math.Inf(1), math.Inf(-1)
+	if maxPreset := miner.Model.MaxPreset; maxPreset != nil {
+		if power, ok := powerMap[*maxPreset]; ok {
+			ceiling = power
+		}
+	}
+	if minPreset := miner.Model.MinPreset; minPreset != nil {
+		if power, ok := powerMap[*minPreset]; ok {
+			floor = power
+		}
+	}
+	losing := unprofitable[miner.Model.Alias]
+
+	var presets []rotationPreset
+	for name, power := range powerMap {
+		if power > ceiling {
+			continue
+		}
+		if !strings.EqualFold(name, sleepPreset) && (losing[name] || power < floor) {
+			continue
+		}
+		presets = append(presets, rotationPreset{name: name, power: power})
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].power < presets[j].power })
+	return presets
+}
+
+// nearestRotationPreset returns the preset whose power is closest to
+// powerW, the lower one on a tie.
+func nearestRotationPreset(presets []rotationPreset, powerW float64) rotationPreset {
+	best := presets[0]
+	for _, preset := range presets[1:] {
+		if math.Abs(preset.power-powerW) < math.Abs(best.power-powerW) {
+			best = preset
+		}
+	}
+	return best
+}
+
+// inRotationBand reports whether two miners may trade load: the same
+// model, or top-preset efficiencies within bandPercent of each other.
+func inRotationBand(a, b rotationCandidate, bandPercent float64) bool {
+	if a.miner.Model.Alias == b.miner.Model.Alias {
+		return true
+	}
+	if a.efficiency <= 0 || b.efficiency <= 0 {
+		return false
+	}
+	return math.Abs(a.efficiency-b.efficiency)/math.Max(a.efficiency, b.efficiency)*100 <= bandPercent
+}
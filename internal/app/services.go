@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/server"
+)
+
+// cycleRecord remembers a service's latest cycle: a poll, scan, balance
+// run or job.
+type cycleRecord struct {
+	mu    sync.Mutex
+	at    time.Time
+	err   error
+	count int64
+}
+
+func (r *cycleRecord) finish(at time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.at = at
+	r.err = err
+	r.count++
+}
+
+// service is one background service and its lifecycle.
+type service struct {
+	name string
+	run  func(context.Context)
+	// configured is false for optional services whose configuration is
+	// missing; they do not start even when enabled.
+	configured bool
+	// runnable is set for enabled, configured services.
+	runnable bool
+	cycles   *cycleRecord
+
+	mu        sync.Mutex
+	state     string
+	startedAt time.Time
+	stoppedAt time.Time
+}
+
+// registerServices lists the background services in start order.
+func (a *App) registerServices() {
+	add := func(name string, run func(context.Context), configured bool, cycles *cycleRecord) {
+		state := server.ServiceNotStarted
+		switch {
+		case !a.enabled[name]:
+			state = server.ServiceDisabled
+		case !configured:
+			state = server.ServiceUnconfigured
+		case a.elector != nil:
+			state = server.ServiceStandby
+		}
+		a.services = append(a.services, &service{
+			name:       name,
+			run:        run,
+			configured: configured,
+			runnable:   a.enabled[name] && configured,
+			cycles:     cycles,
+			state:      state,
+		})
+	}
+	add(ServiceDiscovery, a.discovery.Run, true, &a.discovery.cycles)
+	add(ServiceStatus, a.status.Run, true, &a.status.cycles)
+	add(ServiceTelemetry, a.telemetry.Run, true, &a.telemetry.cycles)
+	add(ServicePlantPoller, a.plantPoller.Run, true, &a.plantPoller.cycles)
+	add(ServicePowerBalancer, a.powerBalancer.Run, true, &a.powerBalancer.cycles)
+	add(ServiceFirmwareUpdater, a.firmware.Run, true, &a.firmware.cycles)
+	add(ServiceProfileRollout, a.rollout.Run, true, &a.rollout.cycles)
+	add(ServiceBackup, a.backup.Run, a.backup.Enabled(), &a.backup.cycles)
+	add(ServiceEconomics, a.economics.Run, a.economics.Enabled(), &a.economics.cycles)
+	add(ServiceWatchdog, a.watchdog.Run, a.watchdog.Enabled(), &a.watchdog.cycles)
+	add(ServiceDrift, a.drift.Run, a.drift.Enabled(), &a.drift.cycles)
+	add(ServiceMaintenance, a.maintenance.Run, a.maintenance.Enabled(), &a.maintenance.cycles)
+	add(ServiceWebhooks, a.webhooks.Run, a.webhooks.Enabled(), &a.webhooks.cycles)
+	add(ServiceTelegram, a.telegram.Run, a.telegram.Enabled(), &a.telegram.cycles)
+	add(ServiceKeyRotation, a.keys.Run, a.keys.Enabled(), &a.keys.cycles)
+	add(ServicePlantMetrics, a.plantMetrics.Run, true, &a.plantMetrics.cycles)
+	add(ServicePDU, a.pdu.Run, a.pdu.Enabled(), &a.pdu.cycles)
+	add(ServiceReconciliation, a.reconciler.Run, true, &a.reconciler.cycles)
+	add(ServiceSwitchEvents, a.switchEvents.Run, a.switchEvents.Enabled(), &a.switchEvents.cycles)
+}
+
+// runServices starts the runnable background services and waits for them
+// to stop. In high-availability mode it runs each time this node becomes
+// the leader with a ctx that ends when it steps down; services stopped
+// while appCtx, the App's own context, is still live return to standby.
+func (a *App) runServices(ctx, appCtx context.Context) {
+	var wg sync.WaitGroup
+	for _, svc := range a.services {
+		if !svc.runnable {
+			continue
+		}
+		wg.Add(1)
+		svc.setState(server.ServiceRunning, time.Now())
+		go func() {
+			defer wg.Done()
+			a.log.Info("service started", "service", svc.name)
+			svc.run(ctx)
+			a.log.Info("service stopped", "service", svc.name)
+			if appCtx.Err() == nil {
+				svc.setState(server.ServiceStandby, time.Now())
+			} else {
+				svc.setState(server.ServiceStopped, time.Now())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *service) setState(state string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	switch state {
+	case server.ServiceRunning:
+		s.startedAt = at
+	case server.ServiceStopped, server.ServiceStandby:
+		s.stoppedAt = at
+	}
+}
+
+// newHTTPService tracks the HTTP listener alongside the background
+// services. It has no cycles and is started by Run itself.
+func (a *App) newHTTPService() *service {
+	state := server.ServiceNotStarted
+	if a.noHTTP {
+		state = server.ServiceDisabled
+	}
+	return &service{name: config.ServiceHTTP, configured: true, cycles: &cycleRecord{}, state: state}
+}
+
+// ServiceStates reports each background service and the HTTP server for
+// GET /api/admin/services.
+func (a *App) ServiceStates() []server.ServiceState {
+	states := make([]server.ServiceState, 0, len(a.services)+1)
+	for _, svc := range append(a.services[:len(a.services):len(a.services)], a.http) {
+		svc.mu.Lock()
+		state := server.ServiceState{
+			Name:      svc.name,
+			State:     svc.state,
+			StartedAt: optionalTime(svc.startedAt),
+			StoppedAt: optionalTime(svc.stoppedAt),
+		}
+		svc.mu.Unlock()
+
+		svc.cycles.mu.Lock()
+		state.LastCycleAt = optionalTime(svc.cycles.at)
+		state.Cycles = svc.cycles.count
+		if svc.cycles.err != nil {
+			state.LastError = svc.cycles.err.Error()
+		}
+		svc.cycles.mu.Unlock()
+		states = append(states, state)
+	}
+	return states
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/server"
+)
+
+// generationSmoother filters plant generation over the latest readings.
+// It keeps no state between calls, so the balancer and the status endpoint
+// see the same value and a restart does not reset the filter.
+type generationSmoother struct {
+	store database.PlantReader
+
+	mu       sync.Mutex
+	cfg      config.SmoothingConfig
+	interval time.Duration
+}
+
+func newGenerationSmoother(store database.PlantReader, cfg config.AppConfig) *generationSmoother {
+	s := &generationSmoother{store: store}
+	s.configure(cfg)
+	return s
+}
+
+// configure applies a new smoothing configuration.
+func (s *generationSmoother) configure(cfg config.AppConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg.Balancer.Smoothing
+	s.interval = time.Duration(cfg.Intervals.PlantSeconds) * time.Second
+}
+
+// smooth returns the filtered generation in kW up to the latest reading,
+// and the number of readings it was based on. It returns the latest
+// reading unchanged while smoothing is off.
+func (s *generationSmoother) smooth(ctx context.Context, latest *database.PlantReading) (float64, int, error) {
+	s.mu.Lock()
+	cfg, interval := s.cfg, s.interval
+	s.mu.Unlock()
+	if latest == nil {
+		return 0, 0, nil
+	}
+	if cfg.Method == "" {
+		return latest.TotalGeneration, 1, nil
+	}
+
+	// Allow for missed polls; only the last Samples readings are used.
+	span := time.Duration(cfg.Samples) * max(interval, time.Second) * 3
+	var values []float64
+	err := s.store.ForEachPlantReading(ctx, latest.RecordedAt.Add(-span), latest.RecordedAt.Add(time.Second), func(reading database.PlantReading) error {
+		values = append(values, reading.TotalGeneration)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("load plant readings: %w", err)
+	}
+	if len(values) == 0 {
+		values = []float64{latest.TotalGeneration}
+	}
+	if len(values) > cfg.Samples {
+		values = values[len(values)-cfg.Samples:]
+	}
+	return smoothValues(values, cfg), len(values), nil
+}
+
+// smoothValues filters values, oldest first, and applies the dead band:
+// the output holds until the filtered signal moves more than DeadBandKW
+// away from it.
+func smoothValues(values []float64, cfg config.SmoothingConfig) float64 {
+	var held, filtered float64
+	for i := range values {
+		switch {
+		case cfg.Method == config.SmoothingMedian:
+			filtered = median(values[:i+1])
+		case i == 0:
+			filtered = values[0]
+		default:
+			filtered = cfg.Alpha*values[i] + (1-cfg.Alpha)*filtered
+		}
+		if i == 0 || math.Abs(filtered-held) > cfg.DeadBandKW {
+			held = filtered
+		}
+	}
+	return held
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// SmoothedGeneration reports the latest generation before and after
+// smoothing for /api/balance/status.
+func (b *PowerBalancer) SmoothedGeneration(ctx context.Context) (server.SmoothedGeneration, bool) {
+	b.smoother.mu.Lock()
+	method := b.smoother.cfg.Method
+	b.smoother.mu.Unlock()
+	if method == "" {
+		return server.SmoothedGeneration{}, false
+	}
+
+	reading, err := b.store.GetLatestPlantReading(ctx)
+	if err != nil || reading == nil {
+		return server.SmoothedGeneration{}, false
+	}
+	smoothed, samples, err := b.smoother.smooth(ctx, reading)
+	if err != nil {
+		b.log.Warn("failed to smooth generation", "err", err)
+		return server.SmoothedGeneration{}, false
+	}
+	return server.SmoothedGeneration{
+		Method:     method,
+		RawKW:      reading.TotalGeneration,
+		SmoothedKW: smoothed,
+		Samples:    samples,
+	}, true
+}
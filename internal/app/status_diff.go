@@ -0,0 +1,124 @@
+package app
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+// confirmsStatus reports whether a poll at now that produced next can be
+// recorded as a confirmation of the miner's latest snapshot instead of a
+// new one. It cannot after a failed poll, or when the snapshot was last
+// confirmed more than two poll intervals ago, since the miner may have
+// changed unseen in between; the snapshot is also replaced once it is
+// FullSnapshotMinutes old.
+func confirmsStatus(cfg config.StatusHistoryConfig, miner database.Miner, next database.MinerStatusInput, interval time.Duration, now time.Time) bool {
+	prev := miner.LatestStatus
+	if !cfg.Dedupe || prev == nil || miner.Reliability.ConsecutiveFailures > 0 {
+		return false
+	}
+	if now.Sub(prev.RecordedAt) >= time.Duration(cfg.FullSnapshotMinutes)*time.Minute {
+		return false
+	}
+	if now.Sub(prev.LastConfirmedAt) > 2*interval {
+		return false
+	}
+	return statusUnchanged(cfg, *prev, next)
+}
+
+// statusUnchanged compares a poll with the previous snapshot. Uptime and
+// pool share counters always move, so they only count when uptime goes
+// backwards, which means the miner restarted.
+func statusUnchanged(cfg config.StatusHistoryConfig, prev database.Status, next database.MinerStatusInput) bool {
+	if prev.Uptime != nil && next.Uptime != nil && *next.Uptime < *prev.Uptime {
+		return false
+	}
+	if !sameText(prev.State, next.State) || !sameText(prev.Preset, next.Preset) {
+		return false
+	}
+	pct := cfg.TolerancePercent
+	if !withinPercent(prev.Hashrate, next.Hashrate, pct) ||
+		!withinPercent(prev.PowerUsage, next.PowerUsage, pct) ||
+		!withinPercent(prev.PowerConsumption, next.PowerConsumption, pct) {
+		return false
+	}
+
+	if len(prev.Fans) != len(next.Fans) {
+		return false
+	}
+	for i, fan := range next.Fans {
+		old := prev.Fans[i]
+		if !sameText(old.FanIdentifier, fan.FanIdentifier) || !sameText(old.Status, fan.Status) {
+			return false
+		}
+		if !withinPercent(floatFromInt(old.RPM), floatFromInt(fan.RPM), pct) {
+			return false
+		}
+	}
+
+	if len(prev.Pools) != len(next.Pools) {
+		return false
+	}
+	for i, pool := range next.Pools {
+		old := prev.Pools[i]
+		if old.PoolIndex != pool.PoolIndex || !sameText(old.URL, pool.URL) || !sameText(old.Worker, pool.Worker) || !sameText(old.Status, pool.Status) {
+			return false
+		}
+	}
+
+	if len(prev.Chains) != len(next.Chains) {
+		return false
+	}
+	celsius := cfg.ToleranceCelsius
+	for i, chain := range next.Chains {
+		old := prev.Chains[i]
+		if !sameText(old.ChainIdentifier, chain.ChainIdentifier) || !sameText(old.State, chain.State) {
+			return false
+		}
+		if !withinPercent(old.Hashrate, chain.Hashrate, pct) ||
+			!withinDelta(old.PCBTempMin, chain.PCBTempMin, celsius) ||
+			!withinDelta(old.PCBTempMax, chain.PCBTempMax, celsius) ||
+			!withinDelta(old.ChipTempMin, chain.ChipTempMin, celsius) ||
+			!withinDelta(old.ChipTempMax, chain.ChipTempMax, celsius) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameText compares two optional strings the way they are stored: trimmed,
+// with an empty string the same as none.
+func sameText(a, b *string) bool {
+	return strings.TrimSpace(safeString(a)) == strings.TrimSpace(safeString(b))
+}
+
+// withinPercent reports whether next is within pct percent of prev. A value
+// appearing or disappearing is a change.
+func withinPercent(prev, next *float64, pct float64) bool {
+	if prev == nil || next == nil {
+		return prev == nil && next == nil
+	}
+	if *prev == *next {
+		return true
+	}
+	return math.Abs(*next-*prev) <= math.Abs(*prev)*pct/100
+}
+
+// withinDelta reports whether next is within delta of prev.
+func withinDelta(prev, next *float64, delta float64) bool {
+	if prev == nil || next == nil {
+		return prev == nil && next == nil
+	}
+	return math.Abs(*next-*prev) <= delta
+}
+
+func floatFromInt(value *int) *float64 {
+	if value == nil {
+		return nil
+	}
+	f := float64(*value)
+	return &f
+}
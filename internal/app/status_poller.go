@@ -2,49 +2,118 @@ package app
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"powerhive/internal/clock"
 	"powerhive/internal/config"
 	"powerhive/internal/database"
 	"powerhive/internal/firmware"
+	"powerhive/internal/tracing"
 )
 
-const (
-	statusWorkerCount = 100
-)
+// minStatusGap is the shortest pause between two status polls.
+const minStatusGap = time.Second
+
+// StatusStore is what the status poller needs from the database.
+type StatusStore interface {
+	database.MinerReader
+	database.StatusWriter
+	database.AvailabilityRecorder
+}
 
 // StatusPoller captures periodic miner summaries.
 type StatusPoller struct {
-	store        *database.Store
-	cfg          config.AppConfig
-	log          *slog.Logger
-	httpClient   *http.Client
-	interval     time.Duration
-	requestLimit time.Duration
+	store             StatusStore
+	writes            *database.WriteQueue
+	cfg               config.AppConfig
+	log               *slog.Logger
+	httpClient        *http.Client
+	drivers           *driverCache
+	interval          time.Duration
+	requestLimit      time.Duration
+	jitter            float64
+	burst             bool
+	unmanagedInterval time.Duration
+	// unmanagedPolled records when each unmanaged miner was last polled so
+	// they can run at their slower cadence. It is owned by the poll loop.
+	unmanagedPolled map[string]time.Time
+	// poolWindows tracks each miner's pool share counters for the reject
+	// rate alert. It is owned by the poll loop.
+	poolWindows map[string]map[string]*poolWindow
+	reloadCh    chan config.AppConfig
+	clock       clock.Clock
+
+	// events is told about dead pools and high reject rates.
+	events EventNotifier
+
+	// reliability is also read by the HTTP server, so it is guarded
+	// separately from the loop-owned config.
+	mu          sync.RWMutex
+	reliability config.ReliabilityConfig
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
 }
 
 // NewStatusPoller creates a status polling service.
-func NewStatusPoller(store *database.Store, cfg config.AppConfig, logger *slog.Logger) *StatusPoller {
+func NewStatusPoller(store StatusStore, cfg config.AppConfig, logger *slog.Logger) *StatusPoller {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
+	p := &StatusPoller{
+		store:           store,
+		log:             logger.With("component", "status"),
+		unmanagedPolled: make(map[string]time.Time),
+		poolWindows:     make(map[string]map[string]*poolWindow),
+		reloadCh:        make(chan config.AppConfig, 1),
+		clock:           clock.Real,
+	}
+	p.applyConfig(cfg)
+	return p
+}
+
+// Reload hands a new configuration to the polling loop. It takes effect
+// before the next poll.
+func (p *StatusPoller) Reload(cfg config.AppConfig) {
+	queueReload(p.reloadCh, cfg)
+}
+
+func (p *StatusPoller) applyConfig(cfg config.AppConfig) {
 	timeout := time.Duration(cfg.Network.MinerProbeTimeoutMs) * time.Millisecond
 
-	return &StatusPoller{
-		store:        store,
-		cfg:          cfg,
-		log:          logger.With("component", "status"),
-		httpClient:   &http.Client{Timeout: timeout},
-		interval:     time.Duration(cfg.Intervals.StatusSeconds) * time.Second,
-		requestLimit: timeout,
+	p.cfg = cfg
+	p.httpClient = firmwareHTTPClient(timeout)
+	if p.drivers == nil {
+		p.drivers = newDriverCache(p.httpClient)
+	} else {
+		p.drivers.setClient(p.httpClient)
 	}
+	p.interval = time.Duration(cfg.Intervals.StatusSeconds) * time.Second
+	p.requestLimit = timeout
+	p.jitter = float64(cfg.Intervals.StatusJitterPercent) / 100
+	p.burst = cfg.Intervals.StatusBurst
+	p.unmanagedInterval = time.Duration(cfg.Intervals.UnmanagedStatusSeconds) * time.Second
+
+	p.mu.Lock()
+	p.reliability = cfg.Reliability
+	p.mu.Unlock()
+}
+
+// Flaky reports whether rel crosses the configured reliability limits.
+func (p *StatusPoller) Flaky(rel database.PollReliability) bool {
+	p.mu.RLock()
+	limits := p.reliability
+	p.mu.RUnlock()
+	return rel.Flaky(limits.MaxConsecutiveFailures, limits.MinSuccessRatio)
 }
 
 // Run starts the polling loop until the context is cancelled.
@@ -53,51 +122,113 @@ func (p *StatusPoller) Run(ctx context.Context) {
 		ctx = context.Background()
 	}
 
-	p.log.Info("starting status loop", "interval", p.interval)
+	p.log.Info("starting status loop", "interval", p.interval, "spread", !p.burst)
 
-	if err := p.poll(ctx); err != nil {
+	start := p.clock.Now()
+	err := p.poll(ctx)
+	p.cycles.finish(p.clock.Now(), err)
+	if err != nil {
 		p.log.Error("initial status poll failed", "err", err)
 	}
 
-	ticker := time.NewTicker(p.interval)
-	defer ticker.Stop()
+	timer := p.clock.NewTimer(p.nextDelay(p.clock.Since(start)))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			p.log.Info("stopping status loop", "reason", ctx.Err())
 			return
-		case <-ticker.C:
-			if err := p.poll(ctx); err != nil {
+		case <-timer.C():
+			start := p.clock.Now()
+			err := p.poll(ctx)
+			p.cycles.finish(p.clock.Now(), err)
+			if err != nil {
 				p.log.Error("status poll failed", "err", err)
 			}
+			timer.Reset(p.nextDelay(p.clock.Since(start)))
+		case cfg := <-p.reloadCh:
+			p.applyConfig(cfg)
+			timer.Reset(p.nextDelay(0))
+			p.log.Info("configuration reloaded", "interval", p.interval)
 		}
 	}
 }
 
-func (p *StatusPoller) poll(ctx context.Context) error {
+// nextDelay returns the wait before the next poll: the interval varied by
+// the configured jitter, less the time the last poll took.
+func (p *StatusPoller) nextDelay(elapsed time.Duration) time.Duration {
+	delay := p.interval
+	if !p.burst && p.jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.jitter * float64(p.interval))
+	}
+	delay -= elapsed
+	if delay < minStatusGap {
+		delay = minStatusGap
+	}
+	return delay
+}
+
+// dispatchGap returns the pause between handing out consecutive poll
+// targets so that n requests are spread over the interval. The window
+// leaves room for the jitter and the last request to finish before the
+// next poll.
+func (p *StatusPoller) dispatchGap(n int) time.Duration {
+	if p.burst || n < 2 {
+		return 0
+	}
+	window := time.Duration(float64(p.interval)*(1-p.jitter)) - p.requestLimit
+	if window <= 0 {
+		return 0
+	}
+	return window / time.Duration(n)
+}
+
+// dueUnmanaged reports whether an unmanaged miner is due for a poll at its
+// slower cadence. Polls happen on the status interval, so a miner is due
+// once less than half an interval remains of its own.
+func (p *StatusPoller) dueUnmanaged(minerID string, now time.Time) bool {
+	last, ok := p.unmanagedPolled[minerID]
+	return !ok || now.Sub(last) >= p.unmanagedInterval-p.interval/2
+}
+
+func (p *StatusPoller) poll(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "status.poll")
+	defer func() { tracing.End(span, err) }()
+
 	miners, err := p.store.ListMiners(ctx)
 	if err != nil {
 		return fmt.Errorf("list miners: %w", err)
 	}
+	p.drivers.retain(miners)
+	p.retainPoolWindows(miners)
 
 	type pollTarget struct {
 		miner database.Miner
 	}
 
+	now := p.clock.Now()
+	unmanagedPolled := make(map[string]time.Time, len(p.unmanagedPolled))
 	var targets []pollTarget
 	for _, miner := range miners {
-		if !miner.Managed {
+		// Stock firmware cannot be managed, so those miners are always
+		// polled to keep hashrate and temperatures in the inventory.
+		if !miner.Managed && supportsPowerControl(miner) {
 			continue
 		}
-		if miner.IP == nil || strings.TrimSpace(*miner.IP) == "" {
+		if !driverReady(miner) {
 			continue
 		}
-		if miner.APIKey == nil || strings.TrimSpace(*miner.APIKey) == "" {
-			continue
+		if !miner.Managed {
+			if !p.dueUnmanaged(miner.ID, now) {
+				unmanagedPolled[miner.ID] = p.unmanagedPolled[miner.ID]
+				continue
+			}
+			unmanagedPolled[miner.ID] = now
 		}
 		targets = append(targets, pollTarget{miner: miner})
 	}
+	p.unmanagedPolled = unmanagedPolled
 
 	if len(targets) == 0 {
 		return nil
@@ -114,7 +245,7 @@ func (p *StatusPoller) poll(ctx context.Context) error {
 	jobs := make(chan pollTarget)
 
 	var wg sync.WaitGroup
-	workers := statusWorkerCount
+	workers := p.cfg.Concurrency.StatusWorkers
 	if len(targets) < workers {
 		workers = len(targets)
 	}
@@ -133,48 +264,30 @@ func (p *StatusPoller) poll(ctx context.Context) error {
 				default:
 				}
 
-				miner := job.miner
-				client, err := firmware.NewClient(*miner.IP,
-					firmware.WithHTTPClient(p.httpClient),
-					firmware.WithAPIKey(strings.TrimSpace(*miner.APIKey)))
-				if err != nil {
-					resultCh <- pollResult{miner: miner, err: fmt.Errorf("create client: %w", err)}
-					continue
-				}
-
-				reqCtx, cancel := context.WithTimeout(ctx, p.requestLimit)
-				summary, err := client.Summary(reqCtx)
-				cancel()
-				if err != nil {
-					resultCh <- pollResult{miner: miner, err: fmt.Errorf("fetch summary: %w", err)}
-					continue
-				}
-
-				var preset *string
-				perfCtx, cancelPerf := context.WithTimeout(ctx, p.requestLimit)
-				perfSummary, perfErr := client.PerfSummary(perfCtx)
-				cancelPerf()
-				if perfErr != nil {
-					p.log.Debug("perf summary fetch failed", "miner", miner.ID, "err", perfErr)
-				} else {
-					preset = parseCurrentPreset(perfSummary.CurrentPreset)
-				}
-
-				resultCh <- pollResult{miner: miner, summary: summary, preset: preset}
+				res := p.pollMiner(ctx, job.miner)
+				resultCh <- pollResult{miner: job.miner, summary: res.summary, preset: res.preset, err: res.err}
 			}
 		}()
 	}
 
+	gap := p.dispatchGap(len(targets))
+	span.SetAttributes(attribute.Int("status.targets", len(targets)), attribute.Int64("status.gap_ms", gap.Milliseconds()))
 	go func() {
-		for _, target := range targets {
+		defer close(jobs)
+		for i, target := range targets {
+			if i > 0 && gap > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-p.clock.After(gap):
+				}
+			}
 			select {
 			case <-ctx.Done():
-				close(jobs)
 				return
 			case jobs <- target:
 			}
 		}
-		close(jobs)
 	}()
 
 	go func() {
@@ -183,18 +296,149 @@ func (p *StatusPoller) poll(ctx context.Context) error {
 	}()
 
 	for res := range resultCh {
+		p.recordReliability(ctx, res.miner, res.err)
 		if res.err != nil {
+			if res.miner.Rebooting(p.clock.Now()) {
+				p.log.Debug("poll miner failed during reboot", "miner", res.miner.ID, "err", res.err)
+				continue
+			}
 			p.log.Warn("poll miner failed", "miner", res.miner.ID, "ip", safeString(res.miner.IP), "err", res.err)
 			continue
 		}
 		if err := p.persistStatus(ctx, res.miner, res.summary, res.preset); err != nil {
 			p.log.Warn("persist miner status failed", "miner", res.miner.ID, "err", err)
 		}
+		p.checkFans(res.miner, res.summary)
+		p.checkPools(ctx, res.miner, res.summary)
 	}
 
 	return nil
 }
 
+type minerPoll struct {
+	summary firmware.SummaryResponse
+	preset  *string
+	err     error
+}
+
+// pollMiner fetches one miner's summary and current preset under its own
+// span, so slow miners stand out in the poll trace.
+func (p *StatusPoller) pollMiner(ctx context.Context, miner database.Miner) (res minerPoll) {
+	ctx, span := tracing.Start(ctx, "status.miner", minerSpanAttrs(miner)...)
+	defer func() { tracing.End(span, res.err) }()
+
+	driver, err := p.drivers.get(p.cfg, miner)
+	if err != nil {
+		return minerPoll{err: fmt.Errorf("create driver: %w", err)}
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.requestLimit)
+	summary, err := driver.Summary(reqCtx)
+	cancel()
+	if err != nil {
+		return minerPoll{err: fmt.Errorf("fetch summary: %w", err)}
+	}
+
+	var preset *string
+	if reader, ok := driver.(firmware.PresetReader); ok {
+		perfCtx, cancelPerf := context.WithTimeout(ctx, p.requestLimit)
+		current, perfErr := reader.CurrentPreset(perfCtx)
+		cancelPerf()
+		if perfErr != nil {
+			p.log.Debug("current preset fetch failed", "miner", miner.ID, "err", perfErr)
+		} else {
+			preset = current
+		}
+	}
+
+	return minerPoll{summary: summary, preset: preset}
+}
+
+// recordReliability updates the miner's poll reliability and availability,
+// and alerts when it becomes flaky or recovers. Polls cut short by shutdown
+// are not counted, nor do failures inside a planned reboot window count
+// against reliability, though they still show as downtime.
+func (p *StatusPoller) recordReliability(ctx context.Context, miner database.Miner, pollErr error) {
+	if ctx.Err() != nil {
+		return
+	}
+	if pollErr != nil && miner.Rebooting(p.clock.Now()) {
+		reason := pollErr.Error()
+		recordAvailability(ctx, p.store, p.log, miner.ID, false, database.AvailabilityPoll, &reason)
+		return
+	}
+	if pollErr == nil {
+		recordAvailability(ctx, p.store, p.log, miner.ID, true, database.AvailabilityPoll, nil)
+	} else {
+		reason := pollErr.Error()
+		recordAvailability(ctx, p.store, p.log, miner.ID, false, database.AvailabilityPoll, &reason)
+	}
+	rel, err := p.store.RecordPollResult(ctx, miner.ID, pollErr)
+	if err != nil {
+		p.log.Warn("record poll reliability failed", "miner", miner.ID, "err", err)
+		return
+	}
+
+	wasFlaky := p.Flaky(miner.Reliability)
+	flaky := p.Flaky(rel)
+	switch {
+	case flaky && !wasFlaky:
+		p.log.Error("miner polls unreliable, balancer will skip it",
+			"miner", miner.ID,
+			"ip", safeString(miner.IP),
+			"consecutive_failures", rel.ConsecutiveFailures,
+			"success_ratio", valueOrZero(rel.SuccessRatio),
+			"last_error", safeString(rel.LastError),
+		)
+	case wasFlaky && !flaky:
+		p.log.Info("miner polls reliable again",
+			"miner", miner.ID,
+			"success_ratio", valueOrZero(rel.SuccessRatio),
+		)
+	}
+}
+
+// checkFans alerts when a fan fails, comparing against the miner's previous
+// status so each failure is reported once. Immersion miners have no fans
+// and are skipped.
+func (p *StatusPoller) checkFans(miner database.Miner, summary firmware.SummaryResponse) {
+	if miner.Immersion() {
+		return
+	}
+
+	failedBefore := make(map[string]bool)
+	if prev := miner.LatestStatus; prev != nil {
+		for _, fan := range prev.Fans {
+			if fanFailed(safeString(prev.State), fan.RPM, safeString(fan.Status)) {
+				failedBefore[safeString(fan.FanIdentifier)] = true
+			}
+		}
+	}
+
+	state := strings.TrimSpace(summary.Miner.MinerStatus.MinerState)
+	for _, fan := range summary.Miner.Cooling.Fans {
+		fanID := fmt.Sprintf("fan-%d", fan.ID)
+		if !fanFailed(state, fan.RPM, fan.Status) || failedBefore[fanID] {
+			continue
+		}
+		attrs := []any{"miner", miner.ID, "ip", safeString(miner.IP), "fan", fanID, "status", fan.Status}
+		if fan.RPM != nil {
+			attrs = append(attrs, "rpm", *fan.RPM)
+		}
+		p.log.Warn("miner fan failure", attrs...)
+	}
+}
+
+// fanFailed reports whether a fan reports a fault, or stands still while
+// the miner is mining.
+func fanFailed(minerState string, rpm *int, status string) bool {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status != "" && status != "ok" {
+		return true
+	}
+	return rpm != nil && *rpm == 0 && strings.EqualFold(minerState, "mining")
+}
+
 func (p *StatusPoller) persistStatus(ctx context.Context, miner database.Miner, summary firmware.SummaryResponse, preset *string) error {
 	state := strings.TrimSpace(summary.Miner.MinerStatus.MinerState)
 	var statePtr *string
@@ -209,7 +453,7 @@ func (p *StatusPoller) persistStatus(ctx context.Context, miner database.Miner,
 		Hashrate:         summary.Miner.HashrateRealtime,
 		PowerUsage:       summary.Miner.PowerUsage,
 		PowerConsumption: summary.Miner.PowerConsumption,
-		RecordedAt:       time.Now().UTC(),
+		RecordedAt:       p.clock.Now().UTC(),
 	}
 
 	for _, fan := range summary.Miner.Cooling.Fans {
@@ -221,6 +465,18 @@ func (p *StatusPoller) persistStatus(ctx context.Context, miner database.Miner,
 		})
 	}
 
+	for _, pool := range summary.Miner.Pools {
+		statusInput.Pools = append(statusInput.Pools, database.PoolStatInput{
+			PoolIndex: pool.ID,
+			URL:       stringPtr(pool.URL),
+			Worker:    stringPtr(pool.User),
+			Status:    stringPtr(strings.ToLower(pool.Status)),
+			Accepted:  int64FromInt(pool.Accepted),
+			Rejected:  int64FromInt(pool.Rejected),
+			Stale:     int64FromInt(pool.Stale),
+		})
+	}
+
 	for _, chain := range summary.Miner.Chains {
 		identifier := fmt.Sprintf("chain-%d", chain.ID)
 		snapshot := database.ChainSnapshotInput{
@@ -235,49 +491,37 @@ func (p *StatusPoller) persistStatus(ctx context.Context, miner database.Miner,
 		statusInput.Chains = append(statusInput.Chains, snapshot)
 	}
 
-	if _, err := p.store.RecordMinerStatus(ctx, miner.ID, statusInput); err != nil {
-		return fmt.Errorf("record status: %w", err)
-	}
-
-	p.log.Debug("miner status recorded", "miner", miner.ID, "hashrate", valueOrZero(summary.Miner.HashrateRealtime))
-	return nil
-}
-
-func parseCurrentPreset(raw json.RawMessage) *string {
-	if len(raw) == 0 {
-		return nil
-	}
-
-	var asString string
-	if err := json.Unmarshal(raw, &asString); err == nil {
-		return stringPtr(asString)
+	interval := p.interval
+	if !miner.Managed {
+		interval = p.unmanagedInterval
 	}
-
-	var preset struct {
-		Name   string `json:"name"`
-		Pretty string `json:"pretty"`
-		Preset string `json:"preset"`
-	}
-	if err := json.Unmarshal(raw, &preset); err == nil {
-		if ptr := stringPtr(preset.Name); ptr != nil {
-			return ptr
-		}
-		if ptr := stringPtr(preset.Preset); ptr != nil {
-			return ptr
+	if confirmsStatus(p.cfg.StatusHistory, miner, statusInput, interval, statusInput.RecordedAt) {
+		statusID := miner.LatestStatus.ID
+		var err error
+		if p.writes != nil {
+			err = p.writes.ConfirmMinerStatus(ctx, miner.ID, statusID, statusInput.RecordedAt)
+		} else {
+			err = p.store.ConfirmMinerStatus(ctx, miner.ID, statusID, statusInput.RecordedAt)
 		}
-		if ptr := stringPtr(preset.Pretty); ptr != nil {
-			return ptr
+		if err != nil {
+			return fmt.Errorf("confirm status: %w", err)
 		}
+		p.log.Debug("miner status unchanged", "miner", miner.ID, "status", statusID)
+		return nil
 	}
 
-	str := strings.TrimSpace(string(raw))
-	if str == "" || strings.EqualFold(str, "null") {
-		return nil
+	var err error
+	if p.writes != nil {
+		err = p.writes.RecordMinerStatus(ctx, miner.ID, statusInput)
+	} else {
+		_, err = p.store.RecordMinerStatus(ctx, miner.ID, statusInput)
 	}
-	if strings.HasPrefix(str, "\"") && strings.HasSuffix(str, "\"") {
-		str = strings.Trim(str, "\"")
+	if err != nil {
+		return fmt.Errorf("record status: %w", err)
 	}
-	return stringPtr(str)
+
+	p.log.Debug("miner status recorded", "miner", miner.ID, "hashrate", valueOrZero(summary.Miner.HashrateRealtime))
+	return nil
 }
 
 func stringPtr(value string) *string {
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/linkevents"
+	"powerhive/internal/snmp"
+)
+
+// SwitchEventStore is what the switch event listener needs from the
+// database.
+type SwitchEventStore interface {
+	database.MinerReader
+	database.MinerWriter
+	database.AvailabilityRecorder
+}
+
+// SwitchEventListener receives SNMP traps and syslog messages from the
+// container switches and takes a miner offline as soon as its port goes
+// down, instead of waiting for the next discovery scan.
+type SwitchEventListener struct {
+	store    SwitchEventStore
+	log      *slog.Logger
+	reloadCh chan config.AppConfig
+	clock    clock.Clock
+
+	mu     sync.Mutex
+	cfg    config.SwitchEventsConfig
+	parser *linkevents.SyslogParser
+
+	// events is told about miners taken offline, for the webhooks.
+	events EventNotifier
+
+	// cycles records the latest handled event for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewSwitchEventListener constructs the switch event listener.
+func NewSwitchEventListener(store SwitchEventStore, cfg config.AppConfig, logger *slog.Logger) *SwitchEventListener {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	l := &SwitchEventListener{
+		store:    store,
+		log:      logger.With("component", "switch_events"),
+		reloadCh: make(chan config.AppConfig, 1),
+		clock:    clock.Real,
+	}
+	l.applyConfig(cfg.SwitchEvents)
+	return l
+}
+
+// Enabled reports whether a listener and a switch are configured.
+func (l *SwitchEventListener) Enabled() bool {
+	return l.config().Enabled()
+}
+
+// Reload hands a new configuration to the listener. Switches, ports and
+// patterns take effect at once; changing an address requires a restart.
+func (l *SwitchEventListener) Reload(cfg config.AppConfig) {
+	queueReload(l.reloadCh, cfg)
+}
+
+func (l *SwitchEventListener) applyConfig(cfg config.SwitchEventsConfig) {
+	parser, err := linkevents.NewSyslogParser(cfg.SyslogPatterns)
+	if err != nil {
+		// The configuration checks the patterns, so only the built-in
+		// ones are left to fall back on.
+		l.log.Error("invalid syslog patterns, using the built-in ones", "err", err)
+		parser, _ = linkevents.NewSyslogParser(nil)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+	l.parser = parser
+}
+
+func (l *SwitchEventListener) config() config.SwitchEventsConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cfg
+}
+
+// Run listens for traps and syslog messages until the context is
+// cancelled.
+func (l *SwitchEventListener) Run(ctx context.Context) {
+	cfg := l.config()
+	listeners := []struct {
+		kind   string
+		addr   string
+		handle func(context.Context, net.IP, []byte) error
+	}{
+		{kind: "snmp_trap", addr: cfg.TrapAddr, handle: l.handleTrap},
+		{kind: "syslog", addr: cfg.SyslogAddr, handle: l.handleSyslog},
+	}
+
+	var wg sync.WaitGroup
+	for _, listener := range listeners {
+		if listener.addr == "" {
+			continue
+		}
+		conn, err := net.ListenPacket("udp", listener.addr)
+		if err != nil {
+			l.log.Error("failed to listen for switch events", "kind", listener.kind, "addr", listener.addr, "err", err)
+			continue
+		}
+		l.log.Info("listening for switch events", "kind", listener.kind, "addr", conn.LocalAddr().String(), "switches", len(cfg.Switches))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serve(ctx, conn, listener.kind, listener.handle)
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			l.log.Info("stopping switch event listener", "reason", ctx.Err())
+			return
+		case next := <-l.reloadCh:
+			if next.SwitchEvents.TrapAddr != cfg.TrapAddr || next.SwitchEvents.SyslogAddr != cfg.SyslogAddr {
+				l.log.Warn("switch event addresses changed; keeping the current listeners until restart")
+				next.SwitchEvents.TrapAddr, next.SwitchEvents.SyslogAddr = cfg.TrapAddr, cfg.SyslogAddr
+			}
+			l.applyConfig(next.SwitchEvents)
+			l.log.Info("configuration reloaded", "switches", len(next.SwitchEvents.Switches))
+		}
+	}
+}
+
+// serve reads datagrams from conn until the context is cancelled.
+func (l *SwitchEventListener) serve(ctx context.Context, conn net.PacketConn, kind string, handle func(context.Context, net.IP, []byte) error) {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			l.log.Warn("failed to read switch event", "kind", kind, "err", err)
+			continue
+		}
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		if err := handle(ctx, udpAddr.IP, buf[:n]); err != nil {
+			l.log.Warn("failed to handle switch event", "kind", kind, "from", udpAddr.IP.String(), "err", err)
+		}
+	}
+}
+
+func (l *SwitchEventListener) handleTrap(ctx context.Context, from net.IP, packet []byte) error {
+	trap, err := snmp.ParseTrap(packet)
+	if err != nil {
+		l.log.Debug("ignoring malformed snmp trap", "from", from.String(), "err", err)
+		return nil
+	}
+	cfg := l.config()
+	if cfg.Community != "" && trap.Community != cfg.Community {
+		l.log.Debug("ignoring snmp trap with another community", "from", from.String())
+		return nil
+	}
+	event, ok := linkevents.FromTrap(trap)
+	if !ok {
+		return nil
+	}
+	// A v1 trap names its agent, which differs from the sender when a
+	// collector relays it.
+	sw, ok := findSwitch(cfg, from.String())
+	if !ok && trap.AgentAddr != "" {
+		sw, ok = findSwitch(cfg, trap.AgentAddr)
+	}
+	if !ok {
+		l.log.Debug("ignoring link trap from an unknown switch", "from", from.String())
+		return nil
+	}
+	return l.handle(ctx, sw, event)
+}
+
+func (l *SwitchEventListener) handleSyslog(ctx context.Context, from net.IP, packet []byte) error {
+	l.mu.Lock()
+	cfg, parser := l.cfg, l.parser
+	l.mu.Unlock()
+
+	event, ok := parser.Parse(string(packet))
+	if !ok {
+		return nil
+	}
+	sw, ok := findSwitch(cfg, from.String())
+	if !ok {
+		l.log.Debug("ignoring link message from an unknown switch", "from", from.String())
+		return nil
+	}
+	return l.handle(ctx, sw, event)
+}
+
+// handle takes the miner on a port that went down offline. A port coming
+// up is only logged; discovery finds the miner again on its next scan.
+func (l *SwitchEventListener) handle(ctx context.Context, sw config.SwitchConfig, event linkevents.Event) (err error) {
+	port, key, ok := switchPort(sw, event.Ports)
+	if !ok {
+		l.log.Debug("link change on an unmapped port", "switch", sw.Name, "ports", event.Ports, "up", event.Up)
+		return nil
+	}
+	defer func() { l.cycles.finish(l.clock.Now(), err) }()
+
+	miners, err := l.store.ListMiners(ctx)
+	if err != nil {
+		return fmt.Errorf("list miners: %w", err)
+	}
+	miner, ok := findMiner(miners, key)
+	if !ok {
+		l.log.Debug("switch port maps to an unknown miner", "switch", sw.Name, "port", port, "miner", key)
+		return nil
+	}
+
+	if event.Up {
+		l.log.Info("switch port up", "switch", sw.Name, "port", port, "miner", miner.ID)
+		return nil
+	}
+	if miner.IP == nil || strings.TrimSpace(*miner.IP) == "" {
+		l.log.Debug("switch port down for a miner already offline", "switch", sw.Name, "port", port, "miner", miner.ID)
+		return nil
+	}
+	// A miner PowerHive is rebooting is expected to drop its link.
+	if miner.Rebooting(l.clock.Now()) {
+		l.log.Debug("switch port down for a rebooting miner", "switch", sw.Name, "port", port, "miner", miner.ID)
+		return nil
+	}
+
+	reason := fmt.Sprintf("link down on switch %s port %s", sw.Name, port)
+	if err := takeOffline(ctx, l.store, l.log, l.events, miner, database.AvailabilitySwitch, reason); err != nil {
+		return fmt.Errorf("take miner %s offline: %w", miner.ID, err)
+	}
+	return nil
+}
+
+// findSwitch returns the switch sending from address.
+func findSwitch(cfg config.SwitchEventsConfig, address string) (config.SwitchConfig, bool) {
+	for _, sw := range cfg.Switches {
+		if sw.Address == address {
+			return sw, true
+		}
+	}
+	return config.SwitchConfig{}, false
+}
+
+// switchPort returns the first of ports mapped on sw and the miner it
+// maps to. Interface names match regardless of case.
+func switchPort(sw config.SwitchConfig, ports []string) (string, string, bool) {
+	for _, port := range ports {
+		if key, ok := sw.Ports[port]; ok {
+			return port, strings.TrimSpace(key), true
+		}
+	}
+	for _, port := range ports {
+		for name, key := range sw.Ports {
+			if strings.EqualFold(name, port) {
+				return port, strings.TrimSpace(key), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// findMiner returns the miner whose ID or IP address is key.
+func findMiner(miners []database.Miner, key string) (database.Miner, bool) {
+	for _, miner := range miners {
+		if strings.EqualFold(miner.ID, key) || (miner.IP != nil && strings.TrimSpace(*miner.IP) == key) {
+			return miner, true
+		}
+	}
+	return database.Miner{}, false
+}
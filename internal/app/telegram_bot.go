@@ -0,0 +1,463 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/telegram"
+)
+
+const (
+	telegramPollTimeout = 30 * time.Second
+	telegramRetryDelay  = 5 * time.Second
+	// telegramAlertQueue bounds the alerts waiting to be sent; further
+	// alerts are dropped until the queue drains.
+	telegramAlertQueue = 100
+	// Curtailments from the bot last telegramCurtailDefault unless the
+	// command gives a duration, and at most telegramCurtailMax.
+	telegramCurtailDefault = time.Hour
+	telegramCurtailMax     = 7 * 24 * time.Hour
+	// telegramSource marks the demand-response events the bot creates.
+	telegramSource = "telegram"
+)
+
+const telegramHelp = `PowerHive commands:
+/status - fleet, plant and balancer summary
+/pause - stop automatic balancing (hot miners are still derated)
+/resume - resume automatic balancing
+/curtail 200kW [2h] - hold the fleet at or below 200 kW, for 1h by default
+/curtail off - end every active curtailment`
+
+// TelegramStore is what the Telegram bot needs from the database.
+type TelegramStore interface {
+	FleetSummary(ctx context.Context, now time.Time) (database.FleetSummary, error)
+	GetLatestPlantReading(ctx context.Context) (*database.PlantReading, error)
+	GetBalanceMode(ctx context.Context) (database.BalanceMode, error)
+	GetBalancerPause(ctx context.Context) (database.BalancerPause, error)
+	SetBalancerPause(ctx context.Context, pause database.BalancerPause) error
+	ActiveDREvents(ctx context.Context, at time.Time) ([]database.DREvent, error)
+	CreateDREvent(ctx context.Context, input database.DREventInput) (database.DREvent, error)
+	CancelDREvent(ctx context.Context, id int64) (database.DREvent, error)
+}
+
+// TelegramBot answers operator commands in allowlisted Telegram chats and
+// sends them alerts. Its commands make the same changes as the REST API:
+// /pause and /resume set the balancer pause and /curtail creates and
+// cancels demand-response events.
+type TelegramBot struct {
+	store  TelegramStore
+	log    *slog.Logger
+	clock  clock.Clock
+	alerts chan string
+
+	mu     sync.Mutex
+	cfg    config.TelegramConfig
+	client *telegram.Client
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// NewTelegramBot constructs the Telegram bot.
+func NewTelegramBot(store TelegramStore, cfg config.AppConfig, logger *slog.Logger) *TelegramBot {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &TelegramBot{
+		store:  store,
+		log:    logger.With("component", "telegram"),
+		clock:  clock.Real,
+		alerts: make(chan string, telegramAlertQueue),
+		cfg:    cfg.Telegram,
+		client: telegram.New(cfg.Telegram.BotToken, cfg.Telegram.APIURL),
+	}
+}
+
+// Enabled reports whether a bot token is configured.
+func (t *TelegramBot) Enabled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cfg.BotToken != ""
+}
+
+// Reload applies a new token, chat allowlist and alert list.
+func (t *TelegramBot) Reload(cfg config.AppConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if cfg.Telegram.BotToken != t.cfg.BotToken || cfg.Telegram.APIURL != t.cfg.APIURL {
+		t.client = telegram.New(cfg.Telegram.BotToken, cfg.Telegram.APIURL)
+	}
+	t.cfg = cfg.Telegram
+}
+
+func (t *TelegramBot) current() (config.TelegramConfig, *telegram.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cfg, t.client
+}
+
+// Notify queues an alert for the allowlisted chats if the event is one of
+// the configured alerts. It never blocks the caller.
+func (t *TelegramBot) Notify(_ context.Context, event string, at time.Time, data any) {
+	cfg, _ := t.current()
+	if !slices.Contains(cfg.Alerts, event) {
+		return
+	}
+	text := formatTelegramAlert(event, at, data)
+	select {
+	case t.alerts <- text:
+	default:
+		t.log.Warn("telegram alert queue full, dropping alert", "event", event)
+	}
+}
+
+// Run answers commands and sends queued alerts until the context is
+// cancelled.
+func (t *TelegramBot) Run(ctx context.Context) {
+	cfg, _ := t.current()
+	t.log.Info("starting telegram bot", "chats", len(cfg.ChatIDs), "alerts", cfg.Alerts)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t.poll(ctx)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			t.log.Info("stopping telegram bot", "reason", ctx.Err())
+			return
+		case text := <-t.alerts:
+			t.broadcast(ctx, text)
+		}
+	}
+}
+
+// poll long-polls for messages and answers them.
+func (t *TelegramBot) poll(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		_, client := t.current()
+		updates, err := client.GetUpdates(ctx, offset, telegramPollTimeout)
+		t.cycles.finish(t.clock.Now(), err)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			t.log.Warn("telegram poll failed", "err", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.clock.After(telegramRetryDelay):
+			}
+			continue
+		}
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message == nil || update.Message.Text == "" {
+				continue
+			}
+			t.handle(ctx, client, update.Message)
+		}
+	}
+}
+
+// handle answers one message from an allowlisted chat.
+func (t *TelegramBot) handle(ctx context.Context, client *telegram.Client, msg *telegram.Message) {
+	cfg, _ := t.current()
+	if !slices.Contains(cfg.ChatIDs, msg.Chat.ID) {
+		t.log.Warn("ignoring telegram message from chat not in chat_ids", "chat", msg.Chat.ID, "from", msg.From.Name())
+		return
+	}
+
+	fields := strings.Fields(msg.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return
+	}
+	// Commands in groups may be addressed as /status@BotName.
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+	by := fmt.Sprintf("telegram:%d", msg.Chat.ID)
+	if name := msg.From.Name(); name != "" {
+		by = "telegram:" + name
+	}
+
+	var (
+		reply string
+		err   error
+	)
+	switch command {
+	case "/status":
+		reply, err = t.status(ctx)
+	case "/pause":
+		reply, err = t.setPaused(ctx, true, by)
+	case "/resume":
+		reply, err = t.setPaused(ctx, false, by)
+	case "/curtail":
+		reply, err = t.curtail(ctx, fields[1:], by)
+	case "/start", "/help":
+		reply = telegramHelp
+	default:
+		reply = "Unknown command.\n\n" + telegramHelp
+	}
+	if err != nil {
+		t.log.Error("telegram command failed", "command", command, "chat", msg.Chat.ID, "err", err)
+		reply = "Failed: " + err.Error()
+	} else if command != "/status" && command != "/start" && command != "/help" {
+		t.log.Info("telegram command", "command", msg.Text, "chat", msg.Chat.ID, "from", msg.From.Name())
+	}
+
+	if err := client.SendMessage(ctx, msg.Chat.ID, reply); err != nil && ctx.Err() == nil {
+		t.log.Warn("telegram reply failed", "chat", msg.Chat.ID, "err", err)
+	}
+}
+
+// broadcast sends text to every allowlisted chat.
+func (t *TelegramBot) broadcast(ctx context.Context, text string) {
+	cfg, client := t.current()
+	for _, chatID := range cfg.ChatIDs {
+		if err := client.SendMessage(ctx, chatID, text); err != nil && ctx.Err() == nil {
+			t.log.Warn("telegram alert failed", "chat", chatID, "err", err)
+		}
+	}
+}
+
+// status summarises the fleet, the latest plant reading and the balancer.
+func (t *TelegramBot) status(ctx context.Context) (string, error) {
+	now := t.clock.Now()
+	fleet, err := t.store.FleetSummary(ctx, now)
+	if err != nil {
+		return "", fmt.Errorf("fleet summary: %w", err)
+	}
+	reading, err := t.store.GetLatestPlantReading(ctx)
+	if err != nil {
+		return "", fmt.Errorf("plant reading: %w", err)
+	}
+	mode, err := t.store.GetBalanceMode(ctx)
+	if err != nil {
+		return "", fmt.Errorf("balance mode: %w", err)
+	}
+	pause, err := t.store.GetBalancerPause(ctx)
+	if err != nil {
+		return "", fmt.Errorf("balancer pause: %w", err)
+	}
+	events, err := t.store.ActiveDREvents(ctx, now)
+	if err != nil {
+		return "", fmt.Errorf("curtailments: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Miners: %d/%d online, %d managed, %d held\n", fleet.Online, fleet.Miners, fleet.Managed, fleet.Held)
+	fmt.Fprintf(&b, "Consumption: %.1f kW (managed %.1f, unmanaged %.1f)\n",
+		(fleet.ManagedPowerW+fleet.UnmanagedPowerW)/1000, fleet.ManagedPowerW/1000, fleet.UnmanagedPowerW/1000)
+	fmt.Fprintf(&b, "Hashrate: %.1f TH/s\n", fleet.HashrateGH/1000)
+	if reading != nil {
+		fmt.Fprintf(&b, "Plant: %.1f kW generated, %.1f kW available (%s ago)\n",
+			reading.TotalGeneration, reading.AvailablePower, now.Sub(reading.RecordedAt).Round(time.Second))
+	} else {
+		b.WriteString("Plant: no readings yet\n")
+	}
+	if pause.Paused {
+		fmt.Fprintf(&b, "Balancer: PAUSED%s\n", pauseDetail(pause, now))
+	} else {
+		fmt.Fprintf(&b, "Balancer: running, %s\n", mode)
+	}
+	for _, event := range events {
+		fmt.Fprintf(&b, "Curtailed: at most %.0f kW until %s UTC (#%d, %s)\n",
+			event.MaxLoadKW, event.EndsAt.UTC().Format("Jan 2 15:04"), event.ID, event.Source)
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+func pauseDetail(pause database.BalancerPause, now time.Time) string {
+	var parts []string
+	if pause.By != "" {
+		parts = append(parts, "by "+pause.By)
+	}
+	if pause.Since != nil {
+		parts = append(parts, now.Sub(*pause.Since).Round(time.Minute).String()+" ago")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// setPaused pauses or resumes automatic balancing.
+func (t *TelegramBot) setPaused(ctx context.Context, paused bool, by string) (string, error) {
+	current, err := t.store.GetBalancerPause(ctx)
+	if err != nil {
+		return "", fmt.Errorf("balancer pause: %w", err)
+	}
+	if current.Paused == paused {
+		if paused {
+			return "Balancing is already paused" + pauseDetail(current, t.clock.Now()) + ".", nil
+		}
+		return "Balancing is already running.", nil
+	}
+
+	pause := database.BalancerPause{Paused: paused}
+	if paused {
+		now := t.clock.Now().UTC()
+		pause.Since = &now
+		pause.By = by
+	}
+	if err := t.store.SetBalancerPause(ctx, pause); err != nil {
+		return "", err
+	}
+	if paused {
+		return "Balancing paused. Presets stay as they are, except that hot miners are still derated. /resume to continue.", nil
+	}
+	return "Balancing resumed.", nil
+}
+
+// curtail creates a demand-response event from now, or with "off" cancels
+// every active one.
+func (t *TelegramBot) curtail(ctx context.Context, args []string, by string) (string, error) {
+	if len(args) == 0 {
+		return "Usage: /curtail 200kW [2h] or /curtail off", nil
+	}
+
+	now := t.clock.Now().UTC()
+	if strings.EqualFold(args[0], "off") {
+		events, err := t.store.ActiveDREvents(ctx, now)
+		if err != nil {
+			return "", fmt.Errorf("curtailments: %w", err)
+		}
+		if len(events) == 0 {
+			return "No curtailment is active.", nil
+		}
+		for _, event := range events {
+			if _, err := t.store.CancelDREvent(ctx, event.ID); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("Ended %d curtailment(s).", len(events)), nil
+	}
+
+	limitKW, err := parsePowerKW(args[0])
+	if err != nil {
+		return "", err
+	}
+	duration := telegramCurtailDefault
+	if len(args) > 1 {
+		duration, err = time.ParseDuration(args[1])
+		if err != nil || duration <= 0 {
+			return "", fmt.Errorf("invalid duration %q; use e.g. 30m or 2h", args[1])
+		}
+		if duration > telegramCurtailMax {
+			return "", fmt.Errorf("curtailments may last at most %s", telegramCurtailMax)
+		}
+	}
+
+	reason := "curtailed from Telegram by " + strings.TrimPrefix(by, "telegram:")
+	event, err := t.store.CreateDREvent(ctx, database.DREventInput{
+		Source:    telegramSource,
+		Reason:    &reason,
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+		MaxLoadKW: limitKW,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Curtailing to at most %.0f kW until %s UTC (#%d). /curtail off to end it early.",
+		event.MaxLoadKW, event.EndsAt.UTC().Format("Jan 2 15:04"), event.ID), nil
+}
+
+// parsePowerKW reads a power such as "200", "200kW", "1.5MW" or "800000W"
+// in kilowatts; a bare number is in kilowatts.
+func parsePowerKW(raw string) (float64, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(value, "kw"):
+		value = strings.TrimSuffix(value, "kw")
+	case strings.HasSuffix(value, "mw"):
+		value, scale = strings.TrimSuffix(value, "mw"), 1000
+	case strings.HasSuffix(value, "w"):
+		value, scale = strings.TrimSuffix(value, "w"), 0.001
+	}
+	kw, err := strconv.ParseFloat(value, 64)
+	if err != nil || kw < 0 {
+		return 0, fmt.Errorf("invalid power %q; use e.g. 200kW", raw)
+	}
+	return kw * scale, nil
+}
+
+// formatTelegramAlert renders an event as a chat message.
+func formatTelegramAlert(event string, at time.Time, data any) string {
+	stamp := at.UTC().Format("15:04 UTC")
+	switch payload := data.(type) {
+	case balanceEventPayload:
+		change := fmt.Sprintf("%s → %s", displayPreset(payload.OldPreset), displayPreset(payload.NewPreset))
+		if event == config.WebhookThermalDerate {
+			if !payload.Success {
+				return fmt.Sprintf("⚠️ %s: derating hot miner %s (%s) FAILED: %s", stamp, payload.MinerID, change, displayPreset(payload.ErrorMessage))
+			}
+			return fmt.Sprintf("🔥 %s: derated hot miner %s, %s", stamp, payload.MinerID, change)
+		}
+		if !payload.Success {
+			return fmt.Sprintf("⚠️ %s: preset change on %s (%s, %s) failed: %s", stamp, payload.MinerID, change, payload.Reason, displayPreset(payload.ErrorMessage))
+		}
+		return fmt.Sprintf("%s: %s %s (%s)", stamp, payload.MinerID, change, payload.Reason)
+	case minerOfflinePayload:
+		name := payload.MinerID
+		if payload.Name != nil && *payload.Name != "" {
+			name = fmt.Sprintf("%s (%s)", *payload.Name, payload.MinerID)
+		}
+		where := ""
+		if payload.Location != nil && *payload.Location != "" {
+			where = " in " + *payload.Location
+		}
+		return fmt.Sprintf("📴 %s: miner %s%s went offline, last seen at %s", stamp, name, where, payload.LastIP)
+	case poolAlertPayload:
+		name := payload.MinerID
+		if payload.Name != nil && *payload.Name != "" {
+			name = fmt.Sprintf("%s (%s)", *payload.Name, payload.MinerID)
+		}
+		if payload.RejectPercent != nil {
+			return fmt.Sprintf("⚠️ %s: %.1f%% of the last %d shares from %s to %s were rejected", stamp, *payload.RejectPercent, payload.WindowShares, name, payload.URL)
+		}
+		return fmt.Sprintf("⚠️ %s: pool %s is dead on miner %s (worker %s)", stamp, payload.URL, name, payload.Worker)
+	case plantGapPayload:
+		duration := time.Duration(payload.DurationSeconds * float64(time.Second)).Round(time.Second)
+		if event == config.WebhookPlantRestored {
+			return fmt.Sprintf("✅ %s: plant readings are back after %s without them", stamp, duration)
+		}
+		return fmt.Sprintf("⚠️ %s: no usable plant reading for %s (%s)", stamp, duration, payload.Reason)
+	case gridProtectionPayload:
+		if event == config.WebhookGridRestored {
+			return fmt.Sprintf("✅ %s: grid back within limits, load may rise again", stamp)
+		}
+		reading := ""
+		if payload.FrequencyHz != nil {
+			reading += fmt.Sprintf(" %.2f Hz", *payload.FrequencyHz)
+		}
+		if payload.VoltageV != nil {
+			reading += fmt.Sprintf(" %.0f V", *payload.VoltageV)
+		}
+		return fmt.Sprintf("⚡ %s: grid %s fault (%s), shed %.0f kW on %d miners (%d failed)",
+			stamp, payload.Cause, strings.TrimSpace(reading), payload.ShedKW, payload.MinersAdjusted, payload.MinersFailed)
+	}
+	return fmt.Sprintf("%s: %s", stamp, event)
+}
+
+func displayPreset(value *string) string {
+	if value == nil || *value == "" {
+		return "?"
+	}
+	return *value
+}
@@ -9,41 +9,72 @@ import (
 	"sync"
 	"time"
 
+	"powerhive/internal/clock"
 	"powerhive/internal/config"
 	"powerhive/internal/database"
 	"powerhive/internal/firmware"
+	"powerhive/internal/tracing"
 )
 
-const (
-	telemetryWorkerCount = 30
-)
+// TelemetryStore is what the telemetry poller needs from the database.
+type TelemetryStore interface {
+	database.MinerReader
+	database.TelemetryWriter
+}
 
 // TelemetryPoller captures chip-level telemetry on a slower cadence.
 type TelemetryPoller struct {
-	store        *database.Store
+	store        TelemetryStore
+	writes       *database.WriteQueue
 	cfg          config.AppConfig
 	log          *slog.Logger
 	httpClient   *http.Client
+	drivers      *driverCache
 	interval     time.Duration
 	requestLimit time.Duration
+	reloadCh     chan config.AppConfig
+	clock        clock.Clock
+	// polls counts completed poll cycles for chip sampling.
+	polls int
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
 }
 
 // NewTelemetryPoller constructs a telemetry polling service.
-func NewTelemetryPoller(store *database.Store, cfg config.AppConfig, logger *slog.Logger) *TelemetryPoller {
+func NewTelemetryPoller(store TelemetryStore, cfg config.AppConfig, logger *slog.Logger) *TelemetryPoller {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
+	p := &TelemetryPoller{
+		store:    store,
+		log:      logger.With("component", "telemetry"),
+		reloadCh: make(chan config.AppConfig, 1),
+		clock:    clock.Real,
+	}
+	p.applyConfig(cfg)
+	return p
+}
+
+// Reload hands a new configuration to the polling loop. It takes effect
+// before the next poll.
+func (p *TelemetryPoller) Reload(cfg config.AppConfig) {
+	queueReload(p.reloadCh, cfg)
+}
+
+func (p *TelemetryPoller) applyConfig(cfg config.AppConfig) {
 	timeout := time.Duration(cfg.Network.MinerProbeTimeoutMs) * time.Millisecond
 
-	return &TelemetryPoller{
-		store:        store,
-		cfg:          cfg,
-		log:          logger.With("component", "telemetry"),
-		httpClient:   &http.Client{Timeout: timeout},
-		interval:     time.Duration(cfg.Intervals.TelemetrySeconds) * time.Second,
-		requestLimit: timeout,
+	p.cfg = cfg
+	p.httpClient = firmwareHTTPClient(timeout)
+	if p.drivers == nil {
+		p.drivers = newDriverCache(p.httpClient)
+	} else {
+		p.drivers.setClient(p.httpClient)
 	}
+	p.interval = time.Duration(cfg.Intervals.TelemetrySeconds) * time.Second
+	p.requestLimit = timeout
 }
 
 // Run starts the telemetry loop until cancellation.
@@ -54,11 +85,13 @@ func (p *TelemetryPoller) Run(ctx context.Context) {
 
 	p.log.Info("starting telemetry loop", "interval", p.interval)
 
-	if err := p.poll(ctx); err != nil {
+	err := p.poll(ctx)
+	p.cycles.finish(p.clock.Now(), err)
+	if err != nil {
 		p.log.Error("initial telemetry poll failed", "err", err)
 	}
 
-	ticker := time.NewTicker(p.interval)
+	ticker := p.clock.NewTicker(p.interval)
 	defer ticker.Stop()
 
 	for {
@@ -66,19 +99,29 @@ func (p *TelemetryPoller) Run(ctx context.Context) {
 		case <-ctx.Done():
 			p.log.Info("stopping telemetry loop", "reason", ctx.Err())
 			return
-		case <-ticker.C:
-			if err := p.poll(ctx); err != nil {
+		case <-ticker.C():
+			err := p.poll(ctx)
+			p.cycles.finish(p.clock.Now(), err)
+			if err != nil {
 				p.log.Error("telemetry poll failed", "err", err)
 			}
+		case cfg := <-p.reloadCh:
+			p.applyConfig(cfg)
+			ticker.Reset(p.interval)
+			p.log.Info("configuration reloaded", "interval", p.interval)
 		}
 	}
 }
 
-func (p *TelemetryPoller) poll(ctx context.Context) error {
+func (p *TelemetryPoller) poll(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "telemetry.poll")
+	defer func() { tracing.End(span, err) }()
+
 	miners, err := p.store.ListMiners(ctx)
 	if err != nil {
 		return fmt.Errorf("list miners: %w", err)
 	}
+	p.drivers.retain(miners)
 
 	type target struct {
 		miner database.Miner
@@ -92,10 +135,7 @@ func (p *TelemetryPoller) poll(ctx context.Context) error {
 		if miner.Model == nil || miner.Model.MaxPreset == nil {
 			continue
 		}
-		if miner.IP == nil || strings.TrimSpace(*miner.IP) == "" {
-			continue
-		}
-		if miner.APIKey == nil || strings.TrimSpace(*miner.APIKey) == "" {
+		if !driverReady(miner) {
 			continue
 		}
 		targets = append(targets, target{miner: miner})
@@ -105,6 +145,9 @@ func (p *TelemetryPoller) poll(ctx context.Context) error {
 		return nil
 	}
 
+	keepChips := p.keepChips()
+	p.polls++
+
 	type telemetryResult struct {
 		miner database.Miner
 		data  []firmware.ChainTelemetry
@@ -115,7 +158,7 @@ func (p *TelemetryPoller) poll(ctx context.Context) error {
 	jobs := make(chan target)
 
 	var wg sync.WaitGroup
-	workers := telemetryWorkerCount
+	workers := p.cfg.Concurrency.TelemetryWorkers
 	if len(targets) < workers {
 		workers = len(targets)
 	}
@@ -134,24 +177,8 @@ func (p *TelemetryPoller) poll(ctx context.Context) error {
 				default:
 				}
 
-				miner := job.miner
-				client, err := firmware.NewClient(*miner.IP,
-					firmware.WithHTTPClient(p.httpClient),
-					firmware.WithAPIKey(strings.TrimSpace(*miner.APIKey)))
-				if err != nil {
-					resultCh <- telemetryResult{miner: miner, err: fmt.Errorf("create client: %w", err)}
-					continue
-				}
-
-				reqCtx, cancel := context.WithTimeout(ctx, p.requestLimit)
-				chains, err := client.Chains(reqCtx)
-				cancel()
-				if err != nil {
-					resultCh <- telemetryResult{miner: miner, err: fmt.Errorf("fetch chains: %w", err)}
-					continue
-				}
-
-				resultCh <- telemetryResult{miner: miner, data: chains}
+				chains, err := p.fetchChains(ctx, job.miner)
+				resultCh <- telemetryResult{miner: job.miner, data: chains, err: err}
 			}
 		}()
 	}
@@ -178,7 +205,7 @@ func (p *TelemetryPoller) poll(ctx context.Context) error {
 			p.log.Warn("telemetry poll failed", "miner", res.miner.ID, "ip", safeString(res.miner.IP), "err", res.err)
 			continue
 		}
-		if err := p.persistTelemetry(ctx, res.miner, res.data); err != nil {
+		if err := p.persistTelemetry(ctx, res.miner, res.data, keepChips); err != nil {
 			p.log.Warn("persist telemetry failed", "miner", res.miner.ID, "err", err)
 		}
 	}
@@ -186,7 +213,37 @@ func (p *TelemetryPoller) poll(ctx context.Context) error {
 	return nil
 }
 
-func (p *TelemetryPoller) persistTelemetry(ctx context.Context, miner database.Miner, chains []firmware.ChainTelemetry) error {
+// fetchChains reads one miner's chain telemetry under its own span.
+func (p *TelemetryPoller) fetchChains(ctx context.Context, miner database.Miner) (chains []firmware.ChainTelemetry, err error) {
+	ctx, span := tracing.Start(ctx, "telemetry.miner", minerSpanAttrs(miner)...)
+	defer func() { tracing.End(span, err) }()
+
+	driver, err := p.drivers.get(p.cfg, miner)
+	if err != nil {
+		return nil, fmt.Errorf("create driver: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.requestLimit)
+	defer cancel()
+	chains, err = driver.Chains(reqCtx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch chains: %w", err)
+	}
+	return chains, nil
+}
+
+// keepChips reports whether this poll stores per-chip rows.
+func (p *TelemetryPoller) keepChips() bool {
+	switch p.cfg.Telemetry.Chips {
+	case config.ChipsSummary:
+		return false
+	case config.ChipsSampled:
+		return p.polls%p.cfg.Telemetry.ChipSampleEvery == 0
+	}
+	return true
+}
+
+func (p *TelemetryPoller) persistTelemetry(ctx context.Context, miner database.Miner, chains []firmware.ChainTelemetry, keepChips bool) error {
 	if len(chains) == 0 {
 		return nil
 	}
@@ -199,23 +256,66 @@ func (p *TelemetryPoller) persistTelemetry(ctx context.Context, miner database.M
 			State:           stringPtr(strings.TrimSpace(chain.Status.State)),
 			Hashrate:        chain.HashrateRealtime,
 		}
-
-		for _, chip := range chain.Chips {
-			chipID := fmt.Sprintf("chip-%d", chip.ID)
-			snapshot.Chips = append(snapshot.Chips, database.ChipSnapshotInput{
-				ChipIdentifier: stringPtr(chipID),
-				Hashrate:       chip.Hashrate,
-				Temperature:    chip.Temp,
-			})
+		summarizeChips(&snapshot, chain.Chips)
+
+		if keepChips {
+			for _, chip := range chain.Chips {
+				chipID := fmt.Sprintf("chip-%d", chip.ID)
+				snapshot.Chips = append(snapshot.Chips, database.ChipSnapshotInput{
+					ChipIdentifier: stringPtr(chipID),
+					Hashrate:       chip.Hashrate,
+					Temperature:    chip.Temp,
+				})
+			}
 		}
 
 		snapshots = append(snapshots, snapshot)
 	}
 
-	if err := p.store.RecordChainTelemetry(ctx, miner.ID, time.Now().UTC(), snapshots); err != nil {
+	record := p.store.RecordChainTelemetry
+	if p.writes != nil {
+		record = p.writes.RecordChainTelemetry
+	}
+	if err := record(ctx, miner.ID, p.clock.Now().UTC(), snapshots); err != nil {
 		return fmt.Errorf("record chain telemetry: %w", err)
 	}
 
 	p.log.Debug("telemetry recorded", "miner", miner.ID, "chains", len(snapshots))
 	return nil
 }
+
+// summarizeChips fills the chain-level chip count and temperature range, so
+// history keeps them even when per-chip rows are not stored.
+func summarizeChips(snapshot *database.ChainSnapshotInput, chips []firmware.ChipTelemetry) {
+	if len(chips) == 0 {
+		return
+	}
+	count := len(chips)
+	snapshot.ChipCount = &count
+
+	var (
+		minTemp, maxTemp, sum float64
+		n                     int
+	)
+	for _, chip := range chips {
+		if chip.Temp == nil {
+			continue
+		}
+		temp := *chip.Temp
+		if n == 0 || temp < minTemp {
+			minTemp = temp
+		}
+		if n == 0 || temp > maxTemp {
+			maxTemp = temp
+		}
+		sum += temp
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	avg := sum / float64(n)
+	snapshot.ChipTempMin = &minTemp
+	snapshot.ChipTempMax = &maxTemp
+	snapshot.ChipTempAvg = &avg
+}
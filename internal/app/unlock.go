@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+// UnlockStore is what unlocking a miner needs from the database.
+type UnlockStore interface {
+	database.MinerWriter
+	database.UnlockCandidateReader
+}
+
+// unlockMiner exchanges the first unlock password the miner accepts for a
+// bearer token. Candidates come from the miner and the credential vault;
+// only a rejected password moves on to the next, so an unreachable miner
+// fails on the first attempt. A vault password that works is stored as
+// the miner's own so it is tried first next time.
+func unlockMiner(ctx context.Context, store UnlockStore, client *firmware.Client, miner database.Miner, timeout time.Duration, log *slog.Logger) (string, error) {
+	candidates, err := store.UnlockCandidates(ctx, miner)
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		ctxUnlock, cancel := context.WithTimeout(ctx, timeout)
+		token, err := client.Unlock(ctxUnlock, candidate.Password)
+		cancel()
+		if err != nil {
+			if !errors.Is(err, firmware.ErrUnauthorized) {
+				return "", fmt.Errorf("unlock miner: %w", err)
+			}
+			lastErr = err
+			continue
+		}
+
+		if candidate.Password != miner.UnlockPass {
+			password := candidate.Password
+			if _, err := store.UpsertMiner(ctx, database.UpsertMinerParams{
+				ID:         miner.ID,
+				UnlockPass: &password,
+			}); err != nil {
+				log.Warn("store unlock password", "miner", miner.ID, "err", err)
+			} else {
+				log.Info("unlock password taken from vault", "miner", miner.ID, "source", candidate.Source)
+			}
+		}
+		return token, nil
+	}
+	return "", fmt.Errorf("unlock miner: none of %d passwords was accepted: %w", len(candidates), lastErr)
+}
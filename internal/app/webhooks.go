@@ -0,0 +1,369 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+)
+
+const (
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 50
+	// Failed deliveries are retried after webhookRetryBase, doubling per
+	// attempt up to webhookRetryMax.
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = time.Hour
+
+	webhookEventHeader     = "X-PowerHive-Event"
+	webhookDeliveryHeader  = "X-PowerHive-Delivery"
+	webhookSignatureHeader = "X-PowerHive-Signature-256"
+)
+
+// WebhookStore is what the webhook dispatcher needs from the database.
+type WebhookStore interface {
+	EnqueueWebhookDelivery(ctx context.Context, input database.WebhookDeliveryInput) (database.WebhookDelivery, error)
+	DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]database.WebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, id int64, attempt database.WebhookAttempt) error
+}
+
+// EventNotifier hands events to the webhook dispatcher or the Telegram
+// bot. See the config.Webhook* event names.
+type EventNotifier interface {
+	Notify(ctx context.Context, event string, at time.Time, data any)
+}
+
+// eventNotifiers hands each event to several notifiers.
+type eventNotifiers []EventNotifier
+
+func (ns eventNotifiers) Notify(ctx context.Context, event string, at time.Time, data any) {
+	for _, n := range ns {
+		n.Notify(ctx, event, at, data)
+	}
+}
+
+// WebhookDispatcher queues events for the configured webhook endpoints in
+// the database and posts them, retrying failures with backoff. Queued
+// deliveries survive restarts.
+type WebhookDispatcher struct {
+	store WebhookStore
+	log   *slog.Logger
+	clock clock.Clock
+	wake  chan struct{}
+
+	mu     sync.Mutex
+	cfg    config.WebhooksConfig
+	client *http.Client
+
+	// cycles records the latest cycle for GET /api/admin/services.
+	cycles cycleRecord
+}
+
+// webhookEnvelope is the JSON body of every delivery. ID is shared by the
+// deliveries of one event to several endpoints so receivers can drop
+// repeats.
+type webhookEnvelope struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// NewWebhookDispatcher constructs the webhook dispatcher.
+func NewWebhookDispatcher(store WebhookStore, cfg config.AppConfig, logger *slog.Logger) *WebhookDispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &WebhookDispatcher{
+		store:  store,
+		log:    logger.With("component", "webhooks"),
+		clock:  clock.Real,
+		wake:   make(chan struct{}, 1),
+		cfg:    cfg.Webhooks,
+		client: &http.Client{Timeout: time.Duration(cfg.Webhooks.TimeoutSeconds) * time.Second},
+	}
+}
+
+// Enabled reports whether any endpoint is configured.
+func (d *WebhookDispatcher) Enabled() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.cfg.Endpoints) > 0
+}
+
+// Reload applies new endpoints, attempts and timeout. Deliveries already
+// queued for a removed endpoint fail on their next attempt.
+func (d *WebhookDispatcher) Reload(cfg config.AppConfig) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cfg = cfg.Webhooks
+	d.client = &http.Client{Timeout: time.Duration(cfg.Webhooks.TimeoutSeconds) * time.Second}
+}
+
+// Notify queues event for every endpoint that subscribes to it.
+func (d *WebhookDispatcher) Notify(ctx context.Context, event string, at time.Time, data any) {
+	d.mu.Lock()
+	endpoints := d.cfg.Endpoints
+	d.mu.Unlock()
+
+	var payload []byte
+	for _, endpoint := range endpoints {
+		if !endpoint.Wants(event) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(webhookEnvelope{ID: newEventID(), Event: event, OccurredAt: at.UTC(), Data: data})
+			if err != nil {
+				d.log.Error("encode webhook event failed", "event", event, "err", err)
+				return
+			}
+		}
+		if _, err := d.store.EnqueueWebhookDelivery(ctx, database.WebhookDeliveryInput{
+			Endpoint:  endpoint.Name,
+			URL:       endpoint.URL,
+			Event:     event,
+			Payload:   string(payload),
+			CreatedAt: d.clock.Now(),
+		}); err != nil {
+			d.log.Warn("queue webhook delivery failed", "endpoint", endpoint.Name, "event", event, "err", err)
+		}
+	}
+	if payload != nil {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run delivers queued events as they arrive, and retries due ones every
+// few seconds, until the context is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	d.mu.Lock()
+	endpoints := len(d.cfg.Endpoints)
+	d.mu.Unlock()
+	d.log.Info("starting webhook dispatcher", "endpoints", endpoints)
+
+	ticker := d.clock.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := d.deliverDue(ctx)
+		d.cycles.finish(d.clock.Now(), err)
+		if err != nil && ctx.Err() == nil {
+			d.log.Warn("webhook deliveries failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			d.log.Info("stopping webhook dispatcher", "reason", ctx.Err())
+			return
+		case <-ticker.C():
+		case <-d.wake:
+		}
+	}
+}
+
+// deliverDue attempts every delivery that is due.
+func (d *WebhookDispatcher) deliverDue(ctx context.Context) error {
+	due, err := d.store.DueWebhookDeliveries(ctx, d.clock.Now(), webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("list due deliveries: %w", err)
+	}
+
+	failed := 0
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.attempt(ctx, delivery) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deliveries failed", failed, len(due))
+	}
+	return nil
+}
+
+// attempt posts one delivery and records the outcome. It reports whether
+// the endpoint accepted it.
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery database.WebhookDelivery) bool {
+	d.mu.Lock()
+	cfg, client := d.cfg, d.client
+	d.mu.Unlock()
+
+	var (
+		endpoint config.WebhookEndpoint
+		found    bool
+	)
+	for _, candidate := range cfg.Endpoints {
+		if candidate.Name == delivery.Endpoint {
+			endpoint, found = candidate, true
+			break
+		}
+	}
+
+	outcome := database.WebhookAttempt{URL: delivery.URL, State: database.WebhookFailed}
+	var statusCode int
+	var err error
+	if found {
+		outcome.URL = endpoint.URL
+		statusCode, err = postWebhook(ctx, client, endpoint, delivery)
+	} else {
+		err = fmt.Errorf("endpoint %q is no longer configured", delivery.Endpoint)
+	}
+
+	now := d.clock.Now()
+	outcome.At = now
+	outcome.NextAttemptAt = now
+	if statusCode != 0 {
+		outcome.StatusCode = &statusCode
+	}
+	attempts := delivery.Attempts + 1
+	switch {
+	case err == nil:
+		outcome.State = database.WebhookDelivered
+	case found && attempts < cfg.MaxAttempts:
+		outcome.State = database.WebhookPending
+		outcome.NextAttemptAt = now.Add(webhookBackoff(attempts))
+	}
+	if err != nil {
+		outcome.Error = ptrString(err.Error())
+		d.log.Warn("webhook delivery failed",
+			"delivery", delivery.ID, "endpoint", delivery.Endpoint, "event", delivery.Event,
+			"attempt", attempts, "state", outcome.State, "err", err)
+	} else {
+		d.log.Debug("webhook delivered", "delivery", delivery.ID, "endpoint", delivery.Endpoint, "event", delivery.Event)
+	}
+
+	if recordErr := d.store.RecordWebhookAttempt(ctx, delivery.ID, outcome); recordErr != nil {
+		d.log.Error("record webhook attempt failed", "delivery", delivery.ID, "err", recordErr)
+	}
+	return err == nil
+}
+
+// postWebhook sends the delivery's payload, signed with the endpoint's
+// secret if it has one, and returns the response status.
+func postWebhook(ctx context.Context, client *http.Client, endpoint config.WebhookEndpoint, delivery database.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PowerHive-Webhooks")
+	req.Header.Set(webhookEventHeader, delivery.Event)
+	req.Header.Set(webhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	if endpoint.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhook(endpoint.Secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook returns the signature header value: "sha256=" and the hex
+// HMAC-SHA256 of body keyed with secret.
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait before retrying after the given number of
+// failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	wait := webhookRetryBase
+	for i := 1; i < attempts && wait < webhookRetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, webhookRetryMax)
+}
+
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// balanceEventPayload is the data of balance.preset_change and
+// balance.thermal_derate events, with the field names of GET
+// /api/balance/events.
+type balanceEventPayload struct {
+	ID                     int64     `json:"id"`
+	MinerID                string    `json:"miner_id"`
+	OldPreset              *string   `json:"old_preset"`
+	NewPreset              *string   `json:"new_preset"`
+	OldPower               *float64  `json:"old_power"`
+	NewPower               *float64  `json:"new_power"`
+	Reason                 string    `json:"reason"`
+	TotalConsumptionBefore *float64  `json:"total_consumption_before"`
+	TotalConsumptionAfter  *float64  `json:"total_consumption_after"`
+	AvailablePower         *float64  `json:"available_power"`
+	TargetPower            *float64  `json:"target_power"`
+	Success                bool      `json:"success"`
+	ErrorMessage           *string   `json:"error_message"`
+	RecordedAt             time.Time `json:"recorded_at"`
+}
+
+// notifyBalanceEvent sends a recorded balance event to the notifiers.
+// Thermal derates go out as balance.thermal_derate.
+func notifyBalanceEvent(ctx context.Context, n EventNotifier, event database.PowerBalanceEvent) {
+	if n == nil {
+		return
+	}
+	name := config.WebhookBalanceChange
+	if event.Reason == "thermal_derate" {
+		name = config.WebhookThermalDerate
+	}
+	n.Notify(ctx, name, event.RecordedAt, balanceEventPayload{
+		ID:                     event.ID,
+		MinerID:                event.MinerID,
+		OldPreset:              event.OldPreset,
+		NewPreset:              event.NewPreset,
+		OldPower:               event.OldPower,
+		NewPower:               event.NewPower,
+		Reason:                 event.Reason,
+		TotalConsumptionBefore: event.TotalConsumptionBefore,
+		TotalConsumptionAfter:  event.TotalConsumptionAfter,
+		AvailablePower:         event.AvailablePower,
+		TargetPower:            event.TargetPower,
+		Success:                event.Success,
+		ErrorMessage:           event.ErrorMessage,
+		RecordedAt:             event.RecordedAt.UTC(),
+	})
+}
+
+// minerOfflinePayload is the data of miner.offline events.
+type minerOfflinePayload struct {
+	MinerID  string   `json:"miner_id"`
+	Name     *string  `json:"name"`
+	Location *string  `json:"location"`
+	LastIP   string   `json:"last_ip"`
+	Tags     []string `json:"tags"`
+}
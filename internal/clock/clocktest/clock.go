@@ -0,0 +1,221 @@
+// Package clocktest provides a manually advanced clock.Clock for driving
+// services through time without waiting.
+package clocktest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"powerhive/internal/clock"
+)
+
+// Clock is a fake clock whose time only moves when Advance or Set is
+// called. Timers and tickers fire, in time order, as the clock passes their
+// deadlines. Like the runtime's timers, a fire whose receiver has not yet
+// drained the previous value is dropped.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter is a pending timer or ticker.
+type waiter struct {
+	c      chan time.Time
+	at     time.Time
+	period time.Duration // zero for one-shot timers
+	active bool
+}
+
+// New returns a fake clock set to start.
+func New(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+var _ clock.Clock = (*Clock)(nil)
+
+// Now returns the fake time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since returns the fake time elapsed since t.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// After returns a channel that receives the fake time once d has passed.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer returns a timer that fires once d has passed.
+func (c *Clock) NewTimer(d time.Duration) clock.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &waiter{c: make(chan time.Time, 1), at: c.now.Add(d), active: true}
+	c.waiters = append(c.waiters, w)
+	c.fireLocked()
+	return &timer{clock: c, w: w}
+}
+
+// NewTicker returns a ticker that fires every d. It panics if d is not
+// positive, like time.NewTicker.
+func (c *Clock) NewTicker(d time.Duration) clock.Ticker {
+	if d <= 0 {
+		panic("clocktest: non-positive interval for NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &waiter{c: make(chan time.Time, 1), at: c.now.Add(d), period: d, active: true}
+	c.waiters = append(c.waiters, w)
+	return &ticker{clock: c, w: w}
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker due
+// on the way.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advanceLocked(c.now.Add(d))
+}
+
+// Set moves the clock to t, firing every timer and ticker due on the way.
+// Moving it backwards fires nothing.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.Before(c.now) {
+		c.now = t
+		return
+	}
+	c.advanceLocked(t)
+}
+
+// Waiters returns the number of active timers and tickers, so a test can
+// wait for a service to block on the clock before advancing it.
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, w := range c.waiters {
+		if w.active {
+			n++
+		}
+	}
+	return n
+}
+
+// advanceLocked steps the clock through each due deadline up to target so
+// waiters observe the time they were due at.
+func (c *Clock) advanceLocked(target time.Time) {
+	for {
+		next, ok := c.nextDeadlineLocked()
+		if !ok || next.After(target) {
+			break
+		}
+		if next.After(c.now) {
+			c.now = next
+		}
+		c.fireLocked()
+	}
+	c.now = target
+}
+
+func (c *Clock) nextDeadlineLocked() (time.Time, bool) {
+	var next time.Time
+	found := false
+	for _, w := range c.waiters {
+		if w.active && (!found || w.at.Before(next)) {
+			next = w.at
+			found = true
+		}
+	}
+	return next, found
+}
+
+// fireLocked delivers every waiter due at the current time, re-arms
+// tickers and forgets spent timers.
+func (c *Clock) fireLocked() {
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+
+	kept := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.active && !w.at.After(c.now) {
+			select {
+			case w.c <- c.now:
+			default:
+			}
+			if w.period > 0 {
+				for !w.at.After(c.now) {
+					w.at = w.at.Add(w.period)
+				}
+			} else {
+				w.active = false
+			}
+		}
+		if w.active {
+			kept = append(kept, w)
+		}
+	}
+	c.waiters = kept
+}
+
+// stop deactivates w and reports whether it was pending.
+func (c *Clock) stop(w *waiter) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	wasActive := w.active
+	w.active = false
+	return wasActive
+}
+
+// reset re-arms w to fire d from now and reports whether it was pending.
+func (c *Clock) reset(w *waiter, d time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	wasActive := w.active
+	w.at = c.now.Add(d)
+	if !wasActive {
+		w.active = true
+		c.waiters = append(c.waiters, w)
+	}
+	if w.period == 0 {
+		c.fireLocked()
+	}
+	return wasActive
+}
+
+type timer struct {
+	clock *Clock
+	w     *waiter
+}
+
+func (t *timer) C() <-chan time.Time        { return t.w.c }
+func (t *timer) Stop() bool                 { return t.clock.stop(t.w) }
+func (t *timer) Reset(d time.Duration) bool { return t.clock.reset(t.w, d) }
+
+type ticker struct {
+	clock *Clock
+	w     *waiter
+}
+
+func (t *ticker) C() <-chan time.Time { return t.w.c }
+func (t *ticker) Stop()               { t.clock.stop(t.w) }
+
+// Reset changes the ticker's period and restarts it. It panics if d is not
+// positive, like time.Ticker.Reset.
+func (t *ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clocktest: non-positive interval for Ticker.Reset")
+	}
+	t.clock.mu.Lock()
+	t.w.period = d
+	t.clock.mu.Unlock()
+	t.clock.reset(t.w, d)
+}
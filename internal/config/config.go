@@ -3,38 +3,877 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	encryptionKeyEnv = "POWERHIVE_ENCRYPTION_KEY"
+	telegramTokenEnv = "POWERHIVE_TELEGRAM_BOT_TOKEN"
 )
 
 type AppConfig struct {
-	Database  DatabaseConfig `json:"database"`
-	Network   NetworkConfig  `json:"network"`
-	Intervals IntervalConfig `json:"intervals"`
-	HTTP      HTTPConfig     `json:"http"`
-	Plant     PlantConfig    `json:"plant"`
+	Database    DatabaseConfig    `json:"database"`
+	Network     NetworkConfig     `json:"network"`
+	Intervals   IntervalConfig    `json:"intervals"`
+	Concurrency ConcurrencyConfig `json:"concurrency"`
+	HTTP        HTTPConfig        `json:"http"`
+	Plant       PlantConfig       `json:"plant"`
+	Backup      BackupConfig      `json:"backup"`
+	Firmware    FirmwareConfig    `json:"firmware"`
+	Logging     LoggingConfig     `json:"logging"`
+	Economics   EconomicsConfig   `json:"economics"`
+	Watchdog    WatchdogConfig    `json:"watchdog"`
+	Drift       DriftConfig       `json:"drift"`
+	Reliability ReliabilityConfig `json:"reliability"`
+	Pools       PoolAlertConfig   `json:"pools"`
+	Forecast    ForecastConfig    `json:"forecast"`
+	Balancer    BalancerConfig    `json:"balancer"`
+	Tracing     TracingConfig     `json:"tracing"`
+	Telemetry   TelemetryConfig   `json:"telemetry"`
+	Maintenance MaintenanceConfig `json:"maintenance"`
+	Services    ServicesConfig    `json:"services"`
+	HA          HAConfig          `json:"ha"`
+	Webhooks    WebhooksConfig    `json:"webhooks"`
+	Telegram    TelegramConfig    `json:"telegram"`
+	KeyRotation KeyRotationConfig `json:"key_rotation"`
+	PDU         PDUConfig         `json:"pdu"`
+	// Reconciliation compares container meters with the miners' estimated
+	// consumption.
+	Reconciliation ReconciliationConfig `json:"reconciliation"`
+	// SwitchEvents takes miners offline when their switch port goes down.
+	SwitchEvents SwitchEventsConfig `json:"switch_events"`
+	// StatusHistory skips storing status snapshots that repeat the last one.
+	StatusHistory StatusHistoryConfig `json:"status_history"`
 }
 
 type DatabaseConfig struct {
-	Path string `json:"path"`
+	Driver                 string `json:"driver"`
+	Path                   string `json:"path"`
+	DSN                    string `json:"dsn"`
+	MaxOpenConns           int    `json:"max_open_conns"`
+	MaxIdleConns           int    `json:"max_idle_conns"`
+	ConnMaxLifetimeSeconds int    `json:"conn_max_lifetime_seconds"`
+	// EncryptionKey enables at-rest encryption of miner credentials. The
+	// POWERHIVE_ENCRYPTION_KEY environment variable takes precedence.
+	EncryptionKey string `json:"encryption_key"`
+	// WriteBatchSize and WriteBatchDelayMS control how status and telemetry
+	// writes are grouped into shared transactions. A batch size of 1 commits
+	// every write on its own.
+	WriteBatchSize    int `json:"write_batch_size"`
+	WriteBatchDelayMS int `json:"write_batch_delay_ms"`
+}
+
+// IsPostgres reports whether the PostgreSQL backend is configured.
+func (d DatabaseConfig) IsPostgres() bool {
+	switch strings.ToLower(strings.TrimSpace(d.Driver)) {
+	case "postgres", "postgresql", "pgx":
+		return true
+	}
+	return false
+}
+
+// Source returns the connection string for the configured driver.
+func (d DatabaseConfig) Source() string {
+	if d.IsPostgres() {
+		return d.DSN
+	}
+	return d.Path
 }
 
 type NetworkConfig struct {
-	Subnets             []string `json:"subnets"`
-	LightScanTimeoutMs  int      `json:"light_scan_timeout_ms"`
-	MinerProbeTimeoutMs int      `json:"miner_probe_timeout_ms"`
+	Subnets             []SubnetConfig `json:"subnets"`
+	LightScanTimeoutMs  int            `json:"light_scan_timeout_ms"`
+	MinerProbeTimeoutMs int            `json:"miner_probe_timeout_ms"`
+	// FirmwarePorts lists the firmware API endpoints discovery tries on each
+	// host, in order. It defaults to plain HTTP on port 80.
+	FirmwarePorts []FirmwarePort `json:"firmware_ports"`
+	// Identity selects how discovery recognises a miner it has seen
+	// before: IdentityMAC (the default) or IdentitySerial.
+	Identity string `json:"identity"`
 }
 
+// Discovery identities. Miners are always keyed by MAC when first found;
+// with IdentitySerial a miner whose serial number is already recorded
+// keeps that record even if its MAC changed.
+const (
+	IdentityMAC    = "mac"
+	IdentitySerial = "serial"
+)
+
+// Firmware API schemes.
+const (
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+)
+
+// FirmwarePort is a scheme and port a miner's firmware API may listen on.
+// In JSON it may be a port number, a string such as "8080" or "https:443",
+// or an object such as {"scheme": "https", "port": 8443}. Without a scheme,
+// port 443 means HTTPS and any other port HTTP.
+type FirmwarePort struct {
+	Scheme string `json:"scheme"`
+	Port   int    `json:"port"`
+}
+
+func (p *FirmwarePort) UnmarshalJSON(data []byte) error {
+	var port int
+	if err := json.Unmarshal(data, &port); err == nil {
+		*p = FirmwarePort{Port: port}
+		return nil
+	}
+
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		scheme, portText, found := strings.Cut(strings.TrimSpace(raw), ":")
+		if !found {
+			scheme, portText = "", scheme
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(portText))
+		if err != nil {
+			return fmt.Errorf("firmware port %q: %w", raw, err)
+		}
+		*p = FirmwarePort{Scheme: scheme, Port: port}
+		return nil
+	}
+
+	type plain FirmwarePort
+	var obj plain
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("firmware port must be a number, string or object: %w", err)
+	}
+	*p = FirmwarePort(obj)
+	return nil
+}
+
+// Light-scan strategies used to find live hosts before probing the firmware API.
+const (
+	ScanTCP  = "tcp"
+	ScanICMP = "icmp"
+	ScanARP  = "arp"
+)
+
+// SubnetConfig describes a subnet to scan and which light-scan strategies to
+// use for it. In JSON it may be a plain CIDR string (TCP scan only) or an
+// object such as {"cidr": "10.0.0.0/16", "scan": ["arp", "icmp"]}.
+type SubnetConfig struct {
+	CIDR string   `json:"cidr"`
+	Scan []string `json:"scan"`
+}
+
+func (s *SubnetConfig) UnmarshalJSON(data []byte) error {
+	var cidr string
+	if err := json.Unmarshal(data, &cidr); err == nil {
+		s.CIDR = cidr
+		s.Scan = nil
+		return nil
+	}
+
+	type plain SubnetConfig
+	var obj plain
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("subnet must be a CIDR string or object: %w", err)
+	}
+	*s = SubnetConfig(obj)
+	return nil
+}
+
+// IntervalConfig sets how often each service runs. Status polls are spread
+// across the interval rather than sent in one burst, and each interval is
+// varied by up to StatusJitterPercent; StatusBurst restores the old
+// all-at-once behaviour. Unmanaged miners are polled every
+// UnmanagedStatusSeconds, which defaults to StatusSeconds.
 type IntervalConfig struct {
-	DiscoverySeconds int `json:"discovery_seconds"`
-	StatusSeconds    int `json:"status_seconds"`
-	TelemetrySeconds int `json:"telemetry_seconds"`
-	PlantSeconds     int `json:"plant_seconds"`
-	BalancerSeconds  int `json:"balancer_seconds"`
+	DiscoverySeconds       int  `json:"discovery_seconds"`
+	StatusSeconds          int  `json:"status_seconds"`
+	StatusJitterPercent    int  `json:"status_jitter_percent"`
+	StatusBurst            bool `json:"status_burst"`
+	UnmanagedStatusSeconds int  `json:"unmanaged_status_seconds"`
+	TelemetrySeconds       int  `json:"telemetry_seconds"`
+	PlantSeconds           int  `json:"plant_seconds"`
+	BalancerSeconds        int  `json:"balancer_seconds"`
+}
+
+// ConcurrencyConfig sizes the worker pools of discovery and the pollers.
+// MaxFirmwareRequests caps the firmware HTTP requests in flight across all
+// services, so a large discovery scan cannot starve the status poller; a
+// negative value removes the cap.
+type ConcurrencyConfig struct {
+	LightScanWorkers    int `json:"light_scan_workers"`
+	ProbeWorkers        int `json:"probe_workers"`
+	StatusWorkers       int `json:"status_workers"`
+	TelemetryWorkers    int `json:"telemetry_workers"`
+	MaxFirmwareRequests int `json:"max_firmware_requests"`
 }
 
 type HTTPConfig struct {
 	Addr string `json:"addr"`
+	// PprofAddr starts a separate net/http/pprof listener when set. It must
+	// bind a loopback address since the profiles are unauthenticated.
+	PprofAddr string `json:"pprof_addr"`
+	// RateLimitPerSecond and RateLimitBurst bound API requests per client
+	// IP. A negative rate disables the limit.
+	RateLimitPerSecond float64 `json:"rate_limit_per_second"`
+	RateLimitBurst     int     `json:"rate_limit_burst"`
+	// MaxBodyKB caps JSON request bodies. Firmware uploads are exempt.
+	MaxBodyKB int `json:"max_body_kb"`
+	// RequestTimeoutSeconds bounds how long an API request may run. Exports,
+	// reports, backups and firmware uploads are exempt.
+	RequestTimeoutSeconds int `json:"request_timeout_seconds"`
+	// GraphQL serves the read-only GraphQL API at /api/graphql.
+	GraphQL bool `json:"graphql"`
+	// LegacyAPISunset is the date, YYYY-MM-DD, from which the unversioned
+	// /api/ aliases of the /api/v1/ routes answer 410 Gone. Until then, or
+	// while it is empty, they work and are marked deprecated.
+	LegacyAPISunset string `json:"legacy_api_sunset"`
+	// AdminTokens are bearer tokens that carry the admin role, which may
+	// change protected miners and force changes onto them. With none, every
+	// caller is an admin.
+	AdminTokens []string `json:"admin_tokens"`
+	// RequireLogin turns away API callers that are neither signed in to the
+	// dashboard nor sending an admin token. SessionHours is how long a
+	// sign-in lasts.
+	RequireLogin bool `json:"require_login"`
+	SessionHours int  `json:"session_hours"`
+	// TLS serves the API over HTTPS, optionally checking client
+	// certificates.
+	TLS HTTPTLSConfig `json:"tls"`
+	// WriteAllowlist holds the addresses and CIDR ranges allowed to make API
+	// writes. When it is set, or TLS.ClientCAFile is, any other caller
+	// without a verified client certificate can only read.
+	WriteAllowlist []string `json:"write_allowlist"`
+	// BasePath is the sub-path, such as /powerhive, that a reverse proxy
+	// serves PowerHive under. Empty serves it at the root.
+	BasePath string `json:"base_path"`
+}
+
+// HTTPTLSConfig serves HTTPS with CertFile and KeyFile. ClientCAFile
+// verifies client certificates; they are asked for but not required, so
+// browsers without one can still read.
+type HTTPTLSConfig struct {
+	CertFile     string `json:"cert_file"`
+	KeyFile      string `json:"key_file"`
+	ClientCAFile string `json:"client_ca_file"`
+}
+
+// Enabled reports whether HTTPS is configured.
+func (t HTTPTLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// WritesRestricted reports whether API writes are limited to the allowlist
+// and client certificates.
+func (h HTTPConfig) WritesRestricted() bool {
+	return len(h.WriteAllowlist) > 0 || h.TLS.ClientCAFile != ""
+}
+
+// WriteAllowlistPrefixes returns WriteAllowlist with single addresses as
+// one-address prefixes. Invalid entries, which validation rejects, are
+// left out.
+func (h HTTPConfig) WriteAllowlistPrefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range h.WriteAllowlist {
+		if prefix, err := parseAllowlistEntry(entry); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+func parseAllowlistEntry(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// minAdminTokenLength keeps admin tokens long enough not to be guessed.
+const minAdminTokenLength = 16
+
+// LegacyAPISunsetTime returns the start of the LegacyAPISunset day in UTC,
+// or the zero time when none is set.
+func (h HTTPConfig) LegacyAPISunsetTime() time.Time {
+	sunset, err := time.Parse(time.DateOnly, strings.TrimSpace(h.LegacyAPISunset))
+	if err != nil {
+		return time.Time{}
+	}
+	return sunset
+}
+
+// BackupConfig controls scheduled database snapshots. Leaving Dir empty
+// disables the scheduler.
+type BackupConfig struct {
+	Dir             string `json:"dir"`
+	IntervalMinutes int    `json:"interval_minutes"`
+	Keep            int    `json:"keep"`
+}
+
+// FirmwareConfig holds fleet-wide credentials for firmware drivers other
+// than Vnish, which provisions its own API keys.
+type FirmwareConfig struct {
+	Braiins  BraiinsConfig  `json:"braiins"`
+	TLS      TLSConfig      `json:"tls"`
+	DebugLog DebugLogConfig `json:"debug_log"`
+}
+
+// DebugLogConfig records recent firmware HTTP exchanges, with credentials
+// redacted, for GET /api/debug/firmware-log. Entries bounds the ring buffer
+// (default 200).
+type DebugLogConfig struct {
+	Enabled bool `json:"enabled"`
+	Entries int  `json:"entries"`
+}
+
+// TLSConfig controls certificate checks for miners served over HTTPS. Miner
+// controllers usually present self-signed certificates: either skip
+// verification or pin the SHA-256 fingerprints of the accepted certificates.
+type TLSConfig struct {
+	InsecureSkipVerify bool     `json:"insecure_skip_verify"`
+	PinnedSHA256       []string `json:"pinned_sha256"`
+}
+
+// BraiinsConfig authenticates against the Braiins OS API. An empty username
+// selects the firmware default "root".
+type BraiinsConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Log output formats.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LoggingConfig controls log format, verbosity and optional file output.
+// Components maps a component name such as "discovery" or "http" to its
+// own level; other components use Level.
+type LoggingConfig struct {
+	Format     string            `json:"format"`
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+	// File additionally writes logs to this path, rotating it once it
+	// exceeds MaxSizeMB and keeping MaxBackups old files.
+	File       string `json:"file"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	MaxBackups int    `json:"max_backups"`
+}
+
+// EconomicsConfig configures the optional market data feed. Without a
+// FeedURL, prices are maintained through /api/settings/economics.
+type EconomicsConfig struct {
+	FeedURL     string `json:"feed_url"`
+	FeedSeconds int    `json:"feed_seconds"`
+	// HashpriceField and BTCPriceField are dot-separated paths into the
+	// feed's JSON response.
+	HashpriceField string `json:"hashprice_field"`
+	BTCPriceField  string `json:"btc_price_field"`
+}
+
+// WatchdogConfig controls the hashboard watchdog. A chain that reports a
+// failure state or zero hashrate for FailurePolls consecutive status polls
+// triggers a mining restart, at most MaxRestarts times and no more often
+// than every RestartBackoffSeconds. A board still failing after that raises
+// an alert.
+type WatchdogConfig struct {
+	Disabled              bool `json:"disabled"`
+	FailurePolls          int  `json:"failure_polls"`
+	MaxRestarts           int  `json:"max_restarts"`
+	RestartBackoffSeconds int  `json:"restart_backoff_seconds"`
+}
+
+// DriftConfig controls settings drift detection. Every IntervalSeconds
+// (default 600) each managed miner's live preset, cooling and pools are
+// compared with the desired settings PowerHive recorded for it. Drift is
+// logged and recorded as an event; with Remediate the desired settings are
+// applied again.
+type DriftConfig struct {
+	Disabled        bool `json:"disabled"`
+	IntervalSeconds int  `json:"interval_seconds"`
+	Remediate       bool `json:"remediate"`
+}
+
+// ReliabilityConfig flags miners whose status polls keep failing. A miner is
+// flaky after MaxConsecutiveFailures failed polls in a row, or while its
+// success ratio over recent polls is below MinSuccessRatio. The balancer
+// leaves flaky miners alone since preset changes on them are likely to fail.
+type ReliabilityConfig struct {
+	MaxConsecutiveFailures int     `json:"max_consecutive_failures"`
+	MinSuccessRatio        float64 `json:"min_success_ratio"`
+}
+
+// PoolAlertConfig sets when the pool stats in status polls raise alerts. A
+// pool whose status turns dead always does. Each pool's reject rate is
+// checked every WindowShares (default 200) new shares and alerts when
+// RejectPercent (default 5) or more of them were rejected or stale.
+type PoolAlertConfig struct {
+	RejectPercent float64 `json:"reject_percent"`
+	WindowShares  int     `json:"window_shares"`
+}
+
+// ForecastConfig lets the balancer plan against generation predicted
+// HorizonMinutes ahead from the trend of the last WindowMinutes of plant
+// readings. The forecast only ever lowers the target, never raises it.
+type ForecastConfig struct {
+	Enabled        bool `json:"enabled"`
+	HorizonMinutes int  `json:"horizon_minutes"`
+	WindowMinutes  int  `json:"window_minutes"`
+}
+
+// Balancer control strategies.
+const (
+	BalancerGreedy = "greedy"
+	BalancerPI     = "pi"
+)
+
+// BalancerConfig selects how the balancer sizes each cycle's adjustment.
+// The greedy strategy steps miners until the estimated consumption meets the
+// target. The pi strategy feeds the measured consumption error through a
+// proportional-integral controller; Ki is per second and the integral term
+// is clamped to MaxIntegralKW to prevent windup. Either way, no change is
+// made while the error is within ToleranceW (default 2000, roughly one
+// miner), which keeps the fleet from chasing small fluctuations.
+//
+// VerifyDelaySeconds after each preset change (default 20, negative turns
+// verification off) the balancer reads the miner's preset back. A change
+// that has not taken effect is reapplied up to VerifyRetries times (default
+// 1, negative for none) and then, with RevertOnVerifyFailure, rolled back
+// to the previous preset.
+//
+// When the firmware reports that a preset change needs a reboot, the miner
+// is rebooted RebootDelaySeconds later (default 10, negative only logs the
+// request) and given RebootTimeoutSeconds (default 600) to come back with
+// the new preset. Offline alerts are suppressed for the miner meanwhile.
+//
+// Changes are applied in the background by ChangeWorkers workers (default
+// 4), and the miners of one location start a change at most once every
+// ContainerPacingSeconds (default 5, negative for no pacing).
+type BalancerConfig struct {
+	Strategy               string               `json:"strategy"`
+	Kp                     float64              `json:"kp"`
+	Ki                     float64              `json:"ki"`
+	MaxIntegralKW          float64              `json:"max_integral_kw"`
+	ToleranceW             float64              `json:"tolerance_w"`
+	VerifyDelaySeconds     int                  `json:"verify_delay_seconds"`
+	VerifyRetries          int                  `json:"verify_retries"`
+	RevertOnVerifyFailure  bool                 `json:"revert_on_verify_failure"`
+	RebootDelaySeconds     int                  `json:"reboot_delay_seconds"`
+	RebootTimeoutSeconds   int                  `json:"reboot_timeout_seconds"`
+	ChangeWorkers          int                  `json:"change_workers"`
+	ContainerPacingSeconds int                  `json:"container_pacing_seconds"`
+	GridProtection         GridProtectionConfig `json:"grid_protection"`
+	Rotation               RotationConfig       `json:"rotation"`
+	PowerRestore           PowerRestoreConfig   `json:"power_restore"`
+	Smoothing              SmoothingConfig      `json:"smoothing"`
+	ChangeLimits           ChangeLimitsConfig   `json:"change_limits"`
+}
+
+// ChangeLimitsConfig caps the balancer's preset changes to bound firmware
+// flash wear and restart churn: at most PerCycle changes queued by one
+// cycle, PerHour changes fleet-wide in any rolling hour and PerMinerPerDay
+// changes to one miner in any rolling 24 hours. Thermal derating, grid
+// protection and power-restore parking are never held back, but count
+// towards the limits. Zero leaves a limit off.
+type ChangeLimitsConfig struct {
+	PerCycle       int `json:"per_cycle"`
+	PerHour        int `json:"per_hour"`
+	PerMinerPerDay int `json:"per_miner_per_day"`
+}
+
+// Generation smoothing methods.
+const (
+	SmoothingEMA    = "ema"
+	SmoothingMedian = "median"
+)
+
+// SmoothingConfig filters the jitter out of the plant generation before
+// the balancer computes its target. Method "ema" is an exponential moving
+// average with weight Alpha (default 0.3) on each new reading, "median"
+// the median; both run over the last Samples readings (default 10). The
+// filtered value then only moves once it is more than DeadBandKW away
+// from where it last settled. Smoothing is off while Method is empty.
+type SmoothingConfig struct {
+	Method     string  `json:"method"`
+	Alpha      float64 `json:"alpha"`
+	Samples    int     `json:"samples"`
+	DeadBandKW float64 `json:"dead_band_kw"`
+}
+
+// PowerRestoreConfig staggers the fleet's ramp-up after a blackout. When
+// at least MinMiners managed miners report having booted within the last
+// WindowSeconds (default 300), the balancer parks them at their lowest
+// preset and then raises at most BatchSize miners (default 10) every
+// BatchDelaySeconds (default 60) until consumption reaches the target, or
+// MaxMinutes (default 60) have passed. It is off while MinMiners is zero.
+type PowerRestoreConfig struct {
+	MinMiners         int `json:"min_miners"`
+	WindowSeconds     int `json:"window_seconds"`
+	BatchSize         int `json:"batch_size"`
+	BatchDelaySeconds int `json:"batch_delay_seconds"`
+	MaxMinutes        int `json:"max_minutes"`
+}
+
+// Enabled reports whether mass reconnections are watched for.
+func (p PowerRestoreConfig) Enabled() bool {
+	return p.MinMiners > 0
+}
+
+// RotationConfig spreads curtailment across the fleet. Every
+// IntervalMinutes, while consumption is within tolerance, the balancer
+// swaps the load of up to MaxSwaps (default 1) pairs: the miner curtailed
+// longest is raised and the miner running at a higher preset longest is
+// lowered by about the same power. Both must be within BandPercent
+// (default 5) of each other's efficiency at their top preset, or be the
+// same model. Rotation is off while IntervalMinutes is zero.
+type RotationConfig struct {
+	IntervalMinutes int     `json:"interval_minutes"`
+	BandPercent     float64 `json:"band_percent"`
+	MaxSwaps        int     `json:"max_swaps"`
+}
+
+// GridProtectionConfig sheds load when the plant reports grid frequency
+// below MinFrequencyHz or voltage outside MinVoltageV..MaxVoltageV. The
+// balancer then steps miners down, least efficient first, until ShedKW of
+// load is shed, without waiting for the change queue or cooldowns, and
+// raises no miner until the grid is back within limits: frequency at or
+// above RestoreFrequencyHz (default MinFrequencyHz) and voltage within its
+// bounds. Grid values older than MaxAgeSeconds (default two plant
+// intervals) are ignored. Zero limits are not checked, and protection is
+// off without ShedKW.
+type GridProtectionConfig struct {
+	MinFrequencyHz     float64 `json:"min_frequency_hz"`
+	RestoreFrequencyHz float64 `json:"restore_frequency_hz"`
+	MinVoltageV        float64 `json:"min_voltage_v"`
+	MaxVoltageV        float64 `json:"max_voltage_v"`
+	ShedKW             float64 `json:"shed_kw"`
+	MaxAgeSeconds      int     `json:"max_age_seconds"`
+}
+
+// Enabled reports whether any grid limit is set along with a block to shed.
+func (g GridProtectionConfig) Enabled() bool {
+	return g.ShedKW > 0 && (g.MinFrequencyHz > 0 || g.MinVoltageV > 0 || g.MaxVoltageV > 0)
+}
+
+// TracingConfig exports OpenTelemetry spans for discovery scans, polls and
+// balance cycles to an OTLP/HTTP collector at Endpoint (host:port). Tracing
+// is off while Endpoint is empty. SampleRatio is the fraction of root spans
+// kept, 1 by default.
+type TracingConfig struct {
+	Endpoint    string  `json:"endpoint"`
+	Insecure    bool    `json:"insecure"`
+	ServiceName string  `json:"service_name"`
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// Chip persistence modes for the telemetry poller.
+const (
+	ChipsAll     = "all"
+	ChipsSampled = "sampled"
+	ChipsSummary = "summary"
+)
+
+// TelemetryConfig controls how much chip-level detail the telemetry poller
+// stores. Chain snapshots always carry the chip count and the min, max and
+// average chip temperature. Chips "all" (the default) also stores a row per
+// chip on every poll, "sampled" only on every ChipSampleEvery-th poll
+// (default 10), and "summary" never.
+type TelemetryConfig struct {
+	Chips           string `json:"chips"`
+	ChipSampleEvery int    `json:"chip_sample_every"`
+}
+
+// StatusHistoryConfig lets the status poller skip snapshots of a miner
+// that has not changed. With Dedupe on, a poll whose state, preset, pools
+// and fan and chain states match the miner's latest snapshot, with
+// hashrate, power and fan speeds within TolerancePercent (default 2) and
+// temperatures within ToleranceCelsius (default 2) of it, only moves that
+// snapshot's last_confirmed_at forward. A new snapshot is still stored at
+// least every FullSnapshotMinutes (default 5), and after a failed or
+// missed poll.
+type StatusHistoryConfig struct {
+	Dedupe              bool    `json:"dedupe"`
+	TolerancePercent    float64 `json:"tolerance_percent"`
+	ToleranceCelsius    float64 `json:"tolerance_celsius"`
+	FullSnapshotMinutes int     `json:"full_snapshot_minutes"`
+}
+
+// MaintenanceConfig schedules SQLite housekeeping. Every CheckpointMinutes
+// (default 15) the WAL is checkpointed and truncated, every OptimizeHours
+// (default 24) PRAGMA optimize refreshes planner statistics, and every
+// IntegrityCheckHours (default 168) PRAGMA integrity_check verifies the
+// file. A negative value turns that task off. PostgreSQL deployments skip
+// the job.
+type MaintenanceConfig struct {
+	CheckpointMinutes   int `json:"checkpoint_minutes"`
+	OptimizeHours       int `json:"optimize_hours"`
+	IntegrityCheckHours int `json:"integrity_check_hours"`
+}
+
+// HAConfig runs several instances against one database with a single
+// leader. The leader holds a lease in the database, renewed every third of
+// LeaseSeconds (default 15), and runs the background services; the other
+// instances stand by and serve read-only API traffic until the lease
+// lapses. NodeID names this instance in the lease and defaults to the host
+// name and process ID.
+type HAConfig struct {
+	Enabled      bool   `json:"enabled"`
+	NodeID       string `json:"node_id"`
+	LeaseSeconds int    `json:"lease_seconds"`
+}
+
+// Events the webhooks section can subscribe to.
+const (
+	// WebhookBalanceChange is a preset change by the balancer or the preset
+	// verifier, successful or not.
+	WebhookBalanceChange = "balance.preset_change"
+	// WebhookThermalDerate is the balancer stepping down a miner over the
+	// chip temperature ceiling, its emergency action.
+	WebhookThermalDerate = "balance.thermal_derate"
+	// WebhookMinerOffline is a miner that dropped out of a discovery scan.
+	WebhookMinerOffline = "miner.offline"
+	// WebhookPoolDead is a miner reporting one of its pools as dead.
+	WebhookPoolDead = "pool.dead"
+	// WebhookPoolRejects is a pool's reject rate crossing the pools
+	// reject_percent.
+	WebhookPoolRejects = "pool.reject_rate"
+	// WebhookPlantGap is the plant going without a usable reading for
+	// the plant gap_alert_seconds.
+	WebhookPlantGap = "plant.gap"
+	// WebhookPlantRestored is usable plant readings resuming after a
+	// plant.gap alert.
+	WebhookPlantRestored = "plant.restored"
+	// WebhookGridProtection is the balancer shedding load because grid
+	// frequency or voltage left the balancer grid_protection limits.
+	WebhookGridProtection = "grid.protection"
+	// WebhookGridRestored is the grid returning within those limits.
+	WebhookGridRestored = "grid.restored"
+)
+
+// WebhookEvents lists every event the webhooks section accepts.
+var WebhookEvents = []string{
+	WebhookBalanceChange,
+	WebhookThermalDerate,
+	WebhookMinerOffline,
+	WebhookPoolDead,
+	WebhookPoolRejects,
+	WebhookPlantGap,
+	WebhookPlantRestored,
+	WebhookGridProtection,
+	WebhookGridRestored,
+}
+
+// WebhooksConfig posts events as JSON to external systems. Deliveries that
+// fail are retried with exponential backoff, starting at 30 seconds and
+// capped at an hour, up to MaxAttempts (default 8) tries of TimeoutSeconds
+// (default 10) each.
+type WebhooksConfig struct {
+	Endpoints      []WebhookEndpoint `json:"endpoints"`
+	MaxAttempts    int               `json:"max_attempts"`
+	TimeoutSeconds int               `json:"timeout_seconds"`
+}
+
+// WebhookEndpoint is one receiver. A Secret signs every body with
+// HMAC-SHA256; Events limits the endpoint to those events and defaults to
+// all of them.
+type WebhookEndpoint struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// Wants reports whether the endpoint subscribes to event.
+func (e WebhookEndpoint) Wants(event string) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, event)
+}
+
+// TelegramConfig connects an optional Telegram bot for operators. The bot
+// answers commands only from, and sends Alerts (default
+// balance.thermal_derate and miner.offline; the webhook event names) only
+// to, the chats in ChatIDs. The POWERHIVE_TELEGRAM_BOT_TOKEN environment
+// variable takes precedence over BotToken. APIURL defaults to the public
+// Bot API.
+type TelegramConfig struct {
+	BotToken string   `json:"bot_token"`
+	ChatIDs  []int64  `json:"chat_ids"`
+	Alerts   []string `json:"alerts"`
+	APIURL   string   `json:"api_url"`
+}
+
+// KeyRotationConfig schedules API key rotation for Vnish miners. Each
+// miner's key is replaced once it is IntervalDays old; zero, the default,
+// leaves rotation to POST /api/miners/{id}/rotate-key.
+type KeyRotationConfig struct {
+	IntervalDays int `json:"interval_days"`
+}
+
+// PDUConfig polls smart PDUs for the power each miner actually draws.
+// Every IntervalSeconds (default 30) each device is read and the wattage
+// of its mapped outlets stored alongside the power the miner's firmware
+// reports. The balancer prefers a measurement to the preset estimate while
+// it is at most MaxAgeSeconds old (default three intervals) and was taken
+// at least SettleSeconds (default 120) after the miner's latest preset
+// change.
+type PDUConfig struct {
+	IntervalSeconds int               `json:"interval_seconds"`
+	MaxAgeSeconds   int               `json:"max_age_seconds"`
+	SettleSeconds   int               `json:"settle_seconds"`
+	Devices         []PDUDeviceConfig `json:"devices"`
+}
+
+// Enabled reports whether any PDU is configured.
+func (p PDUConfig) Enabled() bool {
+	return len(p.Devices) > 0
+}
+
+// PDU protocols.
+const (
+	PDUProtocolHTTP = "http"
+	PDUProtocolSNMP = "snmp"
+)
+
+// PDUDeviceConfig is one smart PDU. Outlets maps outlet numbers to the ID
+// or IP address of the miner plugged in; a miner on several outlets, one
+// per power supply, draws their sum. Over http, URL returns JSON and
+// PowerPath is the dot-separated path to an outlet's reading. Over snmp,
+// Address is the agent (port 161 by default), Community the SNMPv2c
+// community (default public) and PowerOID the OID of an outlet's reading.
+// {outlet} in PowerPath and PowerOID stands for the outlet number.
+// Readings are multiplied by Scale (default 1) to give watts.
+type PDUDeviceConfig struct {
+	Name      string            `json:"name"`
+	Protocol  string            `json:"protocol"`
+	URL       string            `json:"url"`
+	PowerPath string            `json:"power_path"`
+	Address   string            `json:"address"`
+	Community string            `json:"community"`
+	PowerOID  string            `json:"power_oid"`
+	Scale     float64           `json:"scale"`
+	Outlets   map[string]string `json:"outlets"`
+}
+
+// ReconciliationConfig compares each container's meter with the estimated
+// consumption of its miners. Every IntervalSeconds (default 60) the job
+// sums the estimated power of the online miners whose location is the
+// container and stores it against the container's consumption source in
+// the latest plant reading. Containers maps consumption source names to
+// miner locations where the two differ; otherwise a source matches the
+// location of the same name. A container whose discrepancy exceeds
+// AlertPercent (default 10) of its meter is flagged. With CorrectBalancer,
+// the balancer adds every container's discrepancy, averaged over the last
+// WindowMinutes (default 15), to its consumption estimate, so unmanaged
+// load and estimation error count against the target.
+type ReconciliationConfig struct {
+	IntervalSeconds int               `json:"interval_seconds"`
+	WindowMinutes   int               `json:"window_minutes"`
+	AlertPercent    float64           `json:"alert_percent"`
+	CorrectBalancer bool              `json:"correct_balancer"`
+	Containers      map[string]string `json:"containers"`
+}
+
+// SwitchEventsConfig listens for link changes reported by the container
+// switches. TrapAddr receives SNMP v1/v2c traps and SyslogAddr syslog
+// messages over UDP; either may be empty. Traps whose community is not
+// Community are ignored unless it is empty. SyslogPatterns are regular
+// expressions tried before the built-in ones for common switches; each
+// must capture the port in a group named port and the new state in a
+// group named state. A link-down on a mapped port takes the miner offline
+// at once; the next discovery scan brings it back.
+type SwitchEventsConfig struct {
+	TrapAddr       string         `json:"trap_addr"`
+	SyslogAddr     string         `json:"syslog_addr"`
+	Community      string         `json:"community"`
+	SyslogPatterns []string       `json:"syslog_patterns"`
+	Switches       []SwitchConfig `json:"switches"`
+}
+
+// Enabled reports whether a listener and a switch are configured.
+func (s SwitchEventsConfig) Enabled() bool {
+	return (s.TrapAddr != "" || s.SyslogAddr != "") && len(s.Switches) > 0
+}
+
+// SwitchConfig is one switch. Address is the IP it sends traps and syslog
+// from. Ports maps a port, by ifIndex or interface name, to the ID or IP
+// address of the miner connected to it.
+type SwitchConfig struct {
+	Name    string            `json:"name"`
+	Address string            `json:"address"`
+	Ports   map[string]string `json:"ports"`
+}
+
+// Service names for the services section.
+const (
+	ServiceDiscovery       = "discovery"
+	ServiceStatus          = "status"
+	ServiceTelemetry       = "telemetry"
+	ServicePlantPoller     = "plant_poller"
+	ServicePowerBalancer   = "power_balancer"
+	ServiceFirmwareUpdater = "firmware_updater"
+	ServiceProfileRollout  = "profile_rollout"
+	ServiceBackup          = "backup"
+	ServiceEconomics       = "economics"
+	ServiceWatchdog        = "watchdog"
+	ServiceDrift           = "drift"
+	ServiceMaintenance     = "maintenance"
+	ServiceWebhooks        = "webhooks"
+	ServiceTelegram        = "telegram"
+	ServiceKeyRotation     = "key_rotation"
+	ServicePlantMetrics    = "plant_metrics"
+	ServicePDU             = "pdu"
+	ServiceReconciliation  = "reconciliation"
+	ServiceSwitchEvents    = "switch_events"
+	ServiceHTTP            = "http"
+)
+
+// ServiceNames lists every name the services section accepts.
+var ServiceNames = []string{
+	ServiceDiscovery,
+	ServiceStatus,
+	ServiceTelemetry,
+	ServicePlantPoller,
+	ServicePowerBalancer,
+	ServiceFirmwareUpdater,
+	ServiceProfileRollout,
+	ServiceBackup,
+	ServiceEconomics,
+	ServiceWatchdog,
+	ServiceDrift,
+	ServiceMaintenance,
+	ServiceWebhooks,
+	ServiceTelegram,
+	ServiceKeyRotation,
+	ServicePlantMetrics,
+	ServicePDU,
+	ServiceReconciliation,
+	ServiceSwitchEvents,
+	ServiceHTTP,
+}
+
+// ServicesConfig switches services off by name, for nodes that run only
+// part of PowerHive: discovery and the API on a monitoring node, say, or
+// only the balancer against a shared PostgreSQL database. Every service
+// runs unless it is set to false here. Changes need a restart.
+type ServicesConfig map[string]bool
+
+// Enabled reports whether the named service should run.
+func (s ServicesConfig) Enabled(name string) bool {
+	enabled, ok := s[name]
+	return !ok || enabled
 }
 
 type PlantConfig struct {
@@ -43,6 +882,42 @@ type PlantConfig struct {
 	PlantID       string `json:"plant_id"`
 	TestMode      bool   `json:"test_mode"`
 	TestServerURL string `json:"test_server_url"`
+	// GapAlertSeconds is how long the plant may go without a usable
+	// reading before a plant.gap alert is raised (default 300).
+	GapAlertSeconds int                 `json:"gap_alert_seconds"`
+	LowConfidence   LowConfidenceConfig `json:"low_confidence"`
+	// CapacityKW is the plant's nameplate capacity, the basis of the daily
+	// capacity factor; without it the capacity factor is not computed.
+	CapacityKW float64 `json:"capacity_kw"`
+	// MetricsTimezone is the IANA zone whose calendar days the daily plant
+	// metrics cover (default UTC).
+	MetricsTimezone string `json:"metrics_timezone"`
+}
+
+// Low-confidence reading policies.
+const (
+	LowConfidenceDrop         = "drop"
+	LowConfidenceDamp         = "damp"
+	LowConfidenceHold         = "hold"
+	LowConfidenceConservative = "conservative"
+)
+
+// LowConfidenceConfig decides what the plant poller stores in place of a
+// reading whose confidence score is at or below MinConfidence (default
+// 0.8). Every such reading is quarantined either way. The drop policy
+// (default) stores nothing. The damp policy stores the reading moved only
+// Damping (default 0.5) of the way from the last trusted reading. The hold
+// policy repeats the last trusted reading with its generation decayed by
+// HoldDecayPercentPerMinute (default 2) for every minute since. The
+// conservative policy stores the lower of the reported and last trusted
+// generation less ConservativeMarginPercent (default 20). Without a
+// trusted reading yet, every policy drops.
+type LowConfidenceConfig struct {
+	MinConfidence             float64 `json:"min_confidence"`
+	Policy                    string  `json:"policy"`
+	Damping                   float64 `json:"damping"`
+	HoldDecayPercentPerMinute float64 `json:"hold_decay_percent_per_minute"`
+	ConservativeMarginPercent float64 `json:"conservative_margin_percent"`
 }
 
 func Load(path string) (AppConfig, error) {
@@ -61,6 +936,13 @@ func Load(path string) (AppConfig, error) {
 		return AppConfig{}, fmt.Errorf("parse config %s: %w", filepath.Base(absPath), err)
 	}
 
+	if key := strings.TrimSpace(os.Getenv(encryptionKeyEnv)); key != "" {
+		cfg.Database.EncryptionKey = key
+	}
+	if token := strings.TrimSpace(os.Getenv(telegramTokenEnv)); token != "" {
+		cfg.Telegram.BotToken = token
+	}
+
 	if err := cfg.validate(filepath.Dir(absPath)); err != nil {
 		return AppConfig{}, err
 	}
@@ -69,18 +951,69 @@ func Load(path string) (AppConfig, error) {
 }
 
 func (c *AppConfig) validate(baseDir string) error {
-	if c.Database.Path == "" {
-		return fmt.Errorf("database path is required")
+	if c.Database.IsPostgres() {
+		if strings.TrimSpace(c.Database.DSN) == "" {
+			return fmt.Errorf("database dsn is required for the postgres driver")
+		}
+		if c.Database.MaxOpenConns <= 0 {
+			c.Database.MaxOpenConns = 10
+		}
+		if c.Database.MaxIdleConns <= 0 {
+			c.Database.MaxIdleConns = 5
+		}
+		if c.Database.ConnMaxLifetimeSeconds <= 0 {
+			c.Database.ConnMaxLifetimeSeconds = 300
+		}
+	} else {
+		if c.Database.Path == "" {
+			return fmt.Errorf("database path is required")
+		}
+
+		if !filepath.IsAbs(c.Database.Path) {
+			c.Database.Path = filepath.Clean(filepath.Join(baseDir, c.Database.Path))
+		}
+
+		// SQLite allows a single writer; keep one shared connection.
+		c.Database.MaxOpenConns = 1
+		c.Database.MaxIdleConns = 1
+		c.Database.ConnMaxLifetimeSeconds = 0
+	}
+
+	if c.Database.WriteBatchSize <= 0 {
+		c.Database.WriteBatchSize = 64
+	}
+	if c.Database.WriteBatchDelayMS <= 0 {
+		c.Database.WriteBatchDelayMS = 50
 	}
 
-	if !filepath.IsAbs(c.Database.Path) {
-		c.Database.Path = filepath.Clean(filepath.Join(baseDir, c.Database.Path))
+	for name := range c.Services {
+		if !slices.Contains(ServiceNames, name) {
+			return fmt.Errorf("unknown service %q in services; use one of %s", name, strings.Join(ServiceNames, ", "))
+		}
 	}
 
-	if len(c.Network.Subnets) == 0 {
+	// Only discovery scans the subnets.
+	if len(c.Network.Subnets) == 0 && c.Services.Enabled(ServiceDiscovery) {
 		return fmt.Errorf("at least one network subnet is required")
 	}
 
+	for i := range c.Network.Subnets {
+		subnet := &c.Network.Subnets[i]
+		subnet.CIDR = strings.TrimSpace(subnet.CIDR)
+		if len(subnet.Scan) == 0 {
+			subnet.Scan = []string{ScanTCP}
+		}
+		for j, method := range subnet.Scan {
+			method = strings.ToLower(strings.TrimSpace(method))
+			switch method {
+			case ScanTCP, ScanICMP, ScanARP:
+			default:
+				return fmt.Errorf("subnet %s: unknown scan method %q", subnet.CIDR, method)
+			}
+			subnet.Scan[j] = method
+		}
+	}
+
 	if c.Network.LightScanTimeoutMs <= 0 {
 		c.Network.LightScanTimeoutMs = 300
 	}
@@ -89,6 +1022,36 @@ func (c *AppConfig) validate(baseDir string) error {
 		c.Network.MinerProbeTimeoutMs = 1500
 	}
 
+	c.Network.Identity = strings.ToLower(strings.TrimSpace(c.Network.Identity))
+	switch c.Network.Identity {
+	case "":
+		c.Network.Identity = IdentityMAC
+	case IdentityMAC, IdentitySerial:
+	default:
+		return fmt.Errorf("network identity must be %s or %s", IdentityMAC, IdentitySerial)
+	}
+
+	if len(c.Network.FirmwarePorts) == 0 {
+		c.Network.FirmwarePorts = []FirmwarePort{{Scheme: SchemeHTTP, Port: 80}}
+	}
+	for i := range c.Network.FirmwarePorts {
+		port := &c.Network.FirmwarePorts[i]
+		if port.Port <= 0 || port.Port > 65535 {
+			return fmt.Errorf("firmware port %d out of range", port.Port)
+		}
+		port.Scheme = strings.ToLower(strings.TrimSpace(port.Scheme))
+		switch port.Scheme {
+		case "":
+			port.Scheme = SchemeHTTP
+			if port.Port == 443 {
+				port.Scheme = SchemeHTTPS
+			}
+		case SchemeHTTP, SchemeHTTPS:
+		default:
+			return fmt.Errorf("firmware port %d: unknown scheme %q", port.Port, port.Scheme)
+		}
+	}
+
 	if c.Intervals.DiscoverySeconds <= 0 {
 		c.Intervals.DiscoverySeconds = 30
 	}
@@ -96,6 +1059,34 @@ func (c *AppConfig) validate(baseDir string) error {
 	if c.Intervals.StatusSeconds <= 0 {
 		c.Intervals.StatusSeconds = 15
 	}
+	if c.Intervals.StatusJitterPercent < 0 || c.Intervals.StatusJitterPercent > 50 {
+		return fmt.Errorf("status_jitter_percent must be between 0 and 50")
+	}
+	if c.Intervals.StatusJitterPercent == 0 {
+		c.Intervals.StatusJitterPercent = 10
+	}
+	if c.Intervals.UnmanagedStatusSeconds < c.Intervals.StatusSeconds {
+		c.Intervals.UnmanagedStatusSeconds = c.Intervals.StatusSeconds
+	}
+
+	if c.Concurrency.LightScanWorkers <= 0 {
+		c.Concurrency.LightScanWorkers = 32
+	}
+	if c.Concurrency.ProbeWorkers <= 0 {
+		c.Concurrency.ProbeWorkers = 8
+	}
+	if c.Concurrency.StatusWorkers <= 0 {
+		c.Concurrency.StatusWorkers = 100
+	}
+	if c.Concurrency.TelemetryWorkers <= 0 {
+		c.Concurrency.TelemetryWorkers = 30
+	}
+	if c.Concurrency.MaxFirmwareRequests == 0 {
+		c.Concurrency.MaxFirmwareRequests = 64
+	}
+	if c.Firmware.DebugLog.Entries <= 0 {
+		c.Firmware.DebugLog.Entries = 200
+	}
 
 	if c.Intervals.TelemetrySeconds <= 0 {
 		c.Intervals.TelemetrySeconds = 60
@@ -113,17 +1104,584 @@ func (c *AppConfig) validate(baseDir string) error {
 		c.HTTP.Addr = ":8080"
 	}
 
+	if c.HTTP.RateLimitPerSecond == 0 {
+		c.HTTP.RateLimitPerSecond = 20
+	}
+	if c.HTTP.RateLimitBurst <= 0 {
+		c.HTTP.RateLimitBurst = 40
+	}
+	if c.HTTP.MaxBodyKB <= 0 {
+		c.HTTP.MaxBodyKB = 1024
+	}
+	if c.HTTP.RequestTimeoutSeconds <= 0 {
+		c.HTTP.RequestTimeoutSeconds = 20
+	}
+	if c.HTTP.SessionHours <= 0 {
+		c.HTTP.SessionHours = 12
+	}
+
+	if raw := strings.TrimSpace(c.HTTP.LegacyAPISunset); raw != "" {
+		if _, err := time.Parse(time.DateOnly, raw); err != nil {
+			return fmt.Errorf("http.legacy_api_sunset %q must be a date like 2027-01-31", c.HTTP.LegacyAPISunset)
+		}
+	}
+	for i, token := range c.HTTP.AdminTokens {
+		token = strings.TrimSpace(token)
+		if len(token) < minAdminTokenLength {
+			return fmt.Errorf("http.admin_tokens[%d] must be at least %d characters", i, minAdminTokenLength)
+		}
+		c.HTTP.AdminTokens[i] = token
+	}
+	if (c.HTTP.TLS.CertFile == "") != (c.HTTP.TLS.KeyFile == "") {
+		return fmt.Errorf("http.tls needs both cert_file and key_file")
+	}
+	if c.HTTP.TLS.ClientCAFile != "" && !c.HTTP.TLS.Enabled() {
+		return fmt.Errorf("http.tls.client_ca_file needs cert_file and key_file")
+	}
+	c.HTTP.BasePath = strings.TrimRight(strings.TrimSpace(c.HTTP.BasePath), "/")
+	if base := c.HTTP.BasePath; base != "" {
+		if !strings.HasPrefix(base, "/") || strings.ContainsAny(base, " ?#%\"\\<>") || strings.Contains(base, "//") {
+			return fmt.Errorf("http.base_path %q must be a path like /powerhive", base)
+		}
+	}
+	for _, entry := range c.HTTP.WriteAllowlist {
+		if _, err := parseAllowlistEntry(entry); err != nil {
+			return fmt.Errorf("http.write_allowlist entry %q must be an IP address or CIDR range", entry)
+		}
+	}
+
+	if c.HTTP.PprofAddr != "" {
+		host, _, err := net.SplitHostPort(c.HTTP.PprofAddr)
+		if err != nil {
+			return fmt.Errorf("invalid pprof address %q: %w", c.HTTP.PprofAddr, err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("pprof address %q must bind a loopback address", c.HTTP.PprofAddr)
+		}
+	}
+
 	if c.Plant.APIEndpoint == "" {
 		c.Plant.APIEndpoint = "https://energy-aggregator.fly.dev/data/latest"
 	}
+	if c.Plant.GapAlertSeconds <= 0 {
+		c.Plant.GapAlertSeconds = 300
+	}
+	if c.Plant.CapacityKW < 0 {
+		return fmt.Errorf("plant capacity_kw must not be negative")
+	}
+	c.Plant.MetricsTimezone = strings.TrimSpace(c.Plant.MetricsTimezone)
+	if c.Plant.MetricsTimezone == "" {
+		c.Plant.MetricsTimezone = "UTC"
+	}
+	if _, err := time.LoadLocation(c.Plant.MetricsTimezone); err != nil {
+		return fmt.Errorf("invalid plant metrics_timezone %q: %w", c.Plant.MetricsTimezone, err)
+	}
+	lowConfidence := &c.Plant.LowConfidence
+	lowConfidence.Policy = strings.ToLower(strings.TrimSpace(lowConfidence.Policy))
+	switch lowConfidence.Policy {
+	case "":
+		lowConfidence.Policy = LowConfidenceDrop
+	case LowConfidenceDrop, LowConfidenceDamp, LowConfidenceHold, LowConfidenceConservative:
+	default:
+		return fmt.Errorf("unknown plant low_confidence policy %q", lowConfidence.Policy)
+	}
+	if lowConfidence.MinConfidence < 0 || lowConfidence.MinConfidence > 1 {
+		return fmt.Errorf("plant low_confidence min_confidence must be between 0 and 1")
+	}
+	if lowConfidence.MinConfidence == 0 {
+		lowConfidence.MinConfidence = 0.8
+	}
+	if lowConfidence.Damping < 0 || lowConfidence.Damping > 1 {
+		return fmt.Errorf("plant low_confidence damping must be between 0 and 1")
+	}
+	if lowConfidence.Damping == 0 {
+		lowConfidence.Damping = 0.5
+	}
+	if lowConfidence.HoldDecayPercentPerMinute < 0 || lowConfidence.HoldDecayPercentPerMinute > 100 ||
+		lowConfidence.ConservativeMarginPercent < 0 || lowConfidence.ConservativeMarginPercent > 100 {
+		return fmt.Errorf("plant low_confidence hold_decay_percent_per_minute and conservative_margin_percent must be between 0 and 100")
+	}
+	if lowConfidence.HoldDecayPercentPerMinute == 0 {
+		lowConfidence.HoldDecayPercentPerMinute = 2
+	}
+	if lowConfidence.ConservativeMarginPercent == 0 {
+		lowConfidence.ConservativeMarginPercent = 20
+	}
+
+	if c.Backup.Dir != "" {
+		if !filepath.IsAbs(c.Backup.Dir) {
+			c.Backup.Dir = filepath.Clean(filepath.Join(baseDir, c.Backup.Dir))
+		}
+		if c.Backup.IntervalMinutes <= 0 {
+			c.Backup.IntervalMinutes = 360
+		}
+		if c.Backup.Keep <= 0 {
+			c.Backup.Keep = 7
+		}
+	}
+
+	c.Logging.Format = strings.ToLower(strings.TrimSpace(c.Logging.Format))
+	switch c.Logging.Format {
+	case "":
+		c.Logging.Format = LogFormatText
+	case LogFormatText, LogFormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q", c.Logging.Format)
+	}
 
-	if c.Plant.APIKey == "" {
-		return fmt.Errorf("plant API key is required")
+	if strings.TrimSpace(c.Logging.Level) == "" {
+		c.Logging.Level = "info"
+	}
+	if err := checkLogLevel(c.Logging.Level); err != nil {
+		return err
+	}
+	for component, level := range c.Logging.Components {
+		if err := checkLogLevel(level); err != nil {
+			return fmt.Errorf("logging component %s: %w", component, err)
+		}
 	}
 
-	if c.Plant.PlantID == "" {
-		return fmt.Errorf("plant ID is required")
+	if c.Logging.File != "" {
+		if !filepath.IsAbs(c.Logging.File) {
+			c.Logging.File = filepath.Clean(filepath.Join(baseDir, c.Logging.File))
+		}
+		if c.Logging.MaxSizeMB <= 0 {
+			c.Logging.MaxSizeMB = 50
+		}
+		if c.Logging.MaxBackups <= 0 {
+			c.Logging.MaxBackups = 5
+		}
+	}
+
+	if c.Economics.FeedURL != "" {
+		if c.Economics.FeedSeconds <= 0 {
+			c.Economics.FeedSeconds = 600
+		}
+		if c.Economics.HashpriceField == "" {
+			c.Economics.HashpriceField = "hashprice_usd_per_th_day"
+		}
+		if c.Economics.BTCPriceField == "" {
+			c.Economics.BTCPriceField = "btc_price_usd"
+		}
+	}
+
+	if c.Watchdog.FailurePolls <= 0 {
+		c.Watchdog.FailurePolls = 3
+	}
+	if c.Watchdog.MaxRestarts <= 0 {
+		c.Watchdog.MaxRestarts = 2
+	}
+	if c.Watchdog.RestartBackoffSeconds <= 0 {
+		c.Watchdog.RestartBackoffSeconds = 600
 	}
 
+	if c.Drift.IntervalSeconds <= 0 {
+		c.Drift.IntervalSeconds = 600
+	}
+
+	if c.KeyRotation.IntervalDays < 0 {
+		return fmt.Errorf("key_rotation interval_days must not be negative")
+	}
+
+	if err := c.PDU.validate(); err != nil {
+		return err
+	}
+
+	if c.Reconciliation.IntervalSeconds <= 0 {
+		c.Reconciliation.IntervalSeconds = 60
+	}
+	if c.Reconciliation.WindowMinutes <= 0 {
+		c.Reconciliation.WindowMinutes = 15
+	}
+	if c.Reconciliation.AlertPercent < 0 {
+		return fmt.Errorf("reconciliation alert_percent must not be negative")
+	}
+	if c.Reconciliation.AlertPercent == 0 {
+		c.Reconciliation.AlertPercent = 10
+	}
+
+	if err := c.SwitchEvents.validate(); err != nil {
+		return err
+	}
+
+	if c.Reliability.MaxConsecutiveFailures <= 0 {
+		c.Reliability.MaxConsecutiveFailures = 3
+	}
+	if c.Reliability.MinSuccessRatio < 0 || c.Reliability.MinSuccessRatio > 1 {
+		return fmt.Errorf("reliability min_success_ratio must be between 0 and 1")
+	}
+	if c.Reliability.MinSuccessRatio == 0 {
+		c.Reliability.MinSuccessRatio = 0.8
+	}
+
+	if c.Pools.RejectPercent < 0 || c.Pools.RejectPercent > 100 {
+		return fmt.Errorf("pools reject_percent must be between 0 and 100")
+	}
+	if c.Pools.RejectPercent == 0 {
+		c.Pools.RejectPercent = 5
+	}
+	if c.Pools.WindowShares <= 0 {
+		c.Pools.WindowShares = 200
+	}
+
+	if c.Forecast.HorizonMinutes <= 0 {
+		c.Forecast.HorizonMinutes = 10
+	}
+	if c.Forecast.HorizonMinutes > 60 {
+		return fmt.Errorf("forecast horizon_minutes must be at most 60")
+	}
+	if c.Forecast.WindowMinutes <= 0 {
+		c.Forecast.WindowMinutes = 15
+	}
+
+	c.Balancer.Strategy = strings.ToLower(strings.TrimSpace(c.Balancer.Strategy))
+	switch c.Balancer.Strategy {
+	case "":
+		c.Balancer.Strategy = BalancerGreedy
+	case BalancerGreedy, BalancerPI:
+	default:
+		return fmt.Errorf("unknown balancer strategy %q", c.Balancer.Strategy)
+	}
+	if c.Balancer.Kp < 0 || c.Balancer.Ki < 0 || c.Balancer.MaxIntegralKW < 0 {
+		return fmt.Errorf("balancer gains and max_integral_kw must not be negative")
+	}
+	if c.Balancer.ToleranceW < 0 {
+		return fmt.Errorf("balancer tolerance_w must not be negative")
+	}
+	if c.Balancer.ToleranceW == 0 {
+		c.Balancer.ToleranceW = 2000
+	}
+	if c.Balancer.Kp == 0 {
+		c.Balancer.Kp = 0.5
+	}
+	if c.Balancer.Ki == 0 {
+		c.Balancer.Ki = 0.01
+	}
+	if c.Balancer.MaxIntegralKW == 0 {
+		c.Balancer.MaxIntegralKW = 20
+	}
+	if c.Balancer.VerifyDelaySeconds == 0 {
+		c.Balancer.VerifyDelaySeconds = 20
+	}
+	if c.Balancer.VerifyRetries == 0 {
+		c.Balancer.VerifyRetries = 1
+	}
+	if c.Balancer.RebootDelaySeconds == 0 {
+		c.Balancer.RebootDelaySeconds = 10
+	}
+	if c.Balancer.RebootTimeoutSeconds <= 0 {
+		c.Balancer.RebootTimeoutSeconds = 600
+	}
+	if c.Balancer.ChangeWorkers <= 0 {
+		c.Balancer.ChangeWorkers = 4
+	}
+	if c.Balancer.ContainerPacingSeconds == 0 {
+		c.Balancer.ContainerPacingSeconds = 5
+	}
+	grid := &c.Balancer.GridProtection
+	if grid.MinFrequencyHz < 0 || grid.RestoreFrequencyHz < 0 || grid.MinVoltageV < 0 || grid.MaxVoltageV < 0 || grid.ShedKW < 0 {
+		return fmt.Errorf("balancer grid_protection limits must not be negative")
+	}
+	if grid.RestoreFrequencyHz == 0 {
+		grid.RestoreFrequencyHz = grid.MinFrequencyHz
+	}
+	if grid.RestoreFrequencyHz < grid.MinFrequencyHz {
+		return fmt.Errorf("balancer grid_protection restore_frequency_hz must be at least min_frequency_hz")
+	}
+	if grid.MaxVoltageV > 0 && grid.MinVoltageV >= grid.MaxVoltageV {
+		return fmt.Errorf("balancer grid_protection min_voltage_v must be below max_voltage_v")
+	}
+	if grid.MaxAgeSeconds <= 0 {
+		grid.MaxAgeSeconds = 2 * c.Intervals.PlantSeconds
+	}
+	rotation := &c.Balancer.Rotation
+	if rotation.IntervalMinutes < 0 || rotation.BandPercent < 0 || rotation.MaxSwaps < 0 {
+		return fmt.Errorf("balancer rotation values must not be negative")
+	}
+	if rotation.BandPercent == 0 {
+		rotation.BandPercent = 5
+	}
+	if rotation.MaxSwaps == 0 {
+		rotation.MaxSwaps = 1
+	}
+	smoothing := &c.Balancer.Smoothing
+	smoothing.Method = strings.ToLower(strings.TrimSpace(smoothing.Method))
+	switch smoothing.Method {
+	case "", SmoothingEMA, SmoothingMedian:
+	default:
+		return fmt.Errorf("balancer smoothing method must be %q or %q", SmoothingEMA, SmoothingMedian)
+	}
+	if smoothing.Alpha < 0 || smoothing.Alpha > 1 {
+		return fmt.Errorf("balancer smoothing alpha must be between 0 and 1")
+	}
+	if smoothing.Alpha == 0 {
+		smoothing.Alpha = 0.3
+	}
+	if smoothing.Samples < 0 || smoothing.DeadBandKW < 0 {
+		return fmt.Errorf("balancer smoothing samples and dead_band_kw must not be negative")
+	}
+	if smoothing.Samples == 0 {
+		smoothing.Samples = 10
+	}
+	changeLimits := c.Balancer.ChangeLimits
+	if changeLimits.PerCycle < 0 || changeLimits.PerHour < 0 || changeLimits.PerMinerPerDay < 0 {
+		return fmt.Errorf("balancer change_limits must not be negative")
+	}
+	restore := &c.Balancer.PowerRestore
+	if restore.MinMiners < 0 || restore.WindowSeconds < 0 || restore.BatchSize < 0 || restore.BatchDelaySeconds < 0 || restore.MaxMinutes < 0 {
+		return fmt.Errorf("balancer power_restore values must not be negative")
+	}
+	if restore.WindowSeconds == 0 {
+		restore.WindowSeconds = 300
+	}
+	if restore.BatchSize == 0 {
+		restore.BatchSize = 10
+	}
+	if restore.BatchDelaySeconds == 0 {
+		restore.BatchDelaySeconds = 60
+	}
+	if restore.MaxMinutes == 0 {
+		restore.MaxMinutes = 60
+	}
+
+	c.Telemetry.Chips = strings.ToLower(strings.TrimSpace(c.Telemetry.Chips))
+	switch c.Telemetry.Chips {
+	case "":
+		c.Telemetry.Chips = ChipsAll
+	case ChipsAll, ChipsSampled, ChipsSummary:
+	default:
+		return fmt.Errorf("unknown telemetry chips mode %q", c.Telemetry.Chips)
+	}
+	if c.Telemetry.ChipSampleEvery <= 0 {
+		c.Telemetry.ChipSampleEvery = 10
+	}
+
+	history := &c.StatusHistory
+	if history.TolerancePercent < 0 || history.ToleranceCelsius < 0 {
+		return fmt.Errorf("status_history tolerances must not be negative")
+	}
+	if history.TolerancePercent == 0 {
+		history.TolerancePercent = 2
+	}
+	if history.ToleranceCelsius == 0 {
+		history.ToleranceCelsius = 2
+	}
+	if history.FullSnapshotMinutes <= 0 {
+		history.FullSnapshotMinutes = 5
+	}
+
+	if c.Maintenance.CheckpointMinutes == 0 {
+		c.Maintenance.CheckpointMinutes = 15
+	}
+	if c.Maintenance.OptimizeHours == 0 {
+		c.Maintenance.OptimizeHours = 24
+	}
+	if c.Maintenance.IntegrityCheckHours == 0 {
+		c.Maintenance.IntegrityCheckHours = 168
+	}
+
+	c.HA.NodeID = strings.TrimSpace(c.HA.NodeID)
+	if c.HA.NodeID == "" {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			host = "powerhive"
+		}
+		c.HA.NodeID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if c.HA.LeaseSeconds < 0 {
+		return fmt.Errorf("ha lease_seconds must not be negative")
+	}
+	if c.HA.LeaseSeconds == 0 {
+		c.HA.LeaseSeconds = 15
+	}
+
+	if c.Webhooks.MaxAttempts <= 0 {
+		c.Webhooks.MaxAttempts = 8
+	}
+	if c.Webhooks.TimeoutSeconds <= 0 {
+		c.Webhooks.TimeoutSeconds = 10
+	}
+	names := make(map[string]bool, len(c.Webhooks.Endpoints))
+	for i := range c.Webhooks.Endpoints {
+		endpoint := &c.Webhooks.Endpoints[i]
+		endpoint.Name = strings.TrimSpace(endpoint.Name)
+		endpoint.URL = strings.TrimSpace(endpoint.URL)
+		if endpoint.Name == "" {
+			endpoint.Name = endpoint.URL
+		}
+		if names[endpoint.Name] {
+			return fmt.Errorf("duplicate webhook endpoint %q", endpoint.Name)
+		}
+		names[endpoint.Name] = true
+		u, err := url.Parse(endpoint.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook endpoint %q needs an http or https url", endpoint.Name)
+		}
+		for _, event := range endpoint.Events {
+			if !slices.Contains(WebhookEvents, event) {
+				return fmt.Errorf("webhook endpoint %q: unknown event %q", endpoint.Name, event)
+			}
+		}
+	}
+
+	c.Telegram.BotToken = strings.TrimSpace(c.Telegram.BotToken)
+	if c.Telegram.BotToken != "" && len(c.Telegram.ChatIDs) == 0 {
+		return fmt.Errorf("telegram chat_ids are required with a bot token")
+	}
+	if c.Telegram.Alerts == nil {
+		c.Telegram.Alerts = []string{WebhookThermalDerate, WebhookMinerOffline}
+	}
+	for _, event := range c.Telegram.Alerts {
+		if !slices.Contains(WebhookEvents, event) {
+			return fmt.Errorf("telegram: unknown alert %q", event)
+		}
+	}
+
+	c.Tracing.Endpoint = strings.TrimSpace(c.Tracing.Endpoint)
+	if strings.TrimSpace(c.Tracing.ServiceName) == "" {
+		c.Tracing.ServiceName = "powerhive"
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample_ratio must be between 0 and 1")
+	}
+	if c.Tracing.SampleRatio == 0 {
+		c.Tracing.SampleRatio = 1
+	}
+
+	// Only the plant poller calls the plant API.
+	if c.Services.Enabled(ServicePlantPoller) {
+		if c.Plant.APIKey == "" {
+			return fmt.Errorf("plant API key is required")
+		}
+
+		if c.Plant.PlantID == "" {
+			return fmt.Errorf("plant ID is required")
+		}
+	}
+
+	return nil
+}
+
+func checkLogLevel(level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	return nil
+}
+
+// validate checks the PDU devices and fills in defaults.
+func (p *PDUConfig) validate() error {
+	if !p.Enabled() {
+		return nil
+	}
+	if p.IntervalSeconds <= 0 {
+		p.IntervalSeconds = 30
+	}
+	if p.MaxAgeSeconds <= 0 {
+		p.MaxAgeSeconds = 3 * p.IntervalSeconds
+	}
+	if p.SettleSeconds < 0 {
+		return fmt.Errorf("pdu settle_seconds must not be negative")
+	}
+	if p.SettleSeconds == 0 {
+		p.SettleSeconds = 120
+	}
+
+	names := make(map[string]bool, len(p.Devices))
+	for i := range p.Devices {
+		device := &p.Devices[i]
+		device.Name = strings.TrimSpace(device.Name)
+		if device.Name == "" {
+			return fmt.Errorf("pdu devices[%d]: name is required", i)
+		}
+		if names[device.Name] {
+			return fmt.Errorf("pdu device %q is configured twice", device.Name)
+		}
+		names[device.Name] = true
+
+		switch device.Protocol {
+		case PDUProtocolHTTP:
+			if device.URL == "" {
+				return fmt.Errorf("pdu device %q: url is required", device.Name)
+			}
+			if !strings.Contains(device.PowerPath, "{outlet}") {
+				return fmt.Errorf("pdu device %q: power_path must contain {outlet}", device.Name)
+			}
+		case PDUProtocolSNMP:
+			if device.Address == "" {
+				return fmt.Errorf("pdu device %q: address is required", device.Name)
+			}
+			if !strings.Contains(device.PowerOID, "{outlet}") {
+				return fmt.Errorf("pdu device %q: power_oid must contain {outlet}", device.Name)
+			}
+			if device.Community == "" {
+				device.Community = "public"
+			}
+		default:
+			return fmt.Errorf("pdu device %q: protocol must be %q or %q", device.Name, PDUProtocolHTTP, PDUProtocolSNMP)
+		}
+		if device.Scale < 0 {
+			return fmt.Errorf("pdu device %q: scale must not be negative", device.Name)
+		}
+		if device.Scale == 0 {
+			device.Scale = 1
+		}
+		if len(device.Outlets) == 0 {
+			return fmt.Errorf("pdu device %q: outlets are required", device.Name)
+		}
+		for outlet, miner := range device.Outlets {
+			if strings.TrimSpace(outlet) == "" || strings.TrimSpace(miner) == "" {
+				return fmt.Errorf("pdu device %q: outlets need an outlet number and a miner", device.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SwitchEventsConfig) validate() error {
+	for _, addr := range []string{s.TrapAddr, s.SyslogAddr} {
+		if addr == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("switch_events address %q: %w", addr, err)
+		}
+	}
+	for _, pattern := range s.SyslogPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("switch_events syslog pattern %q: %w", pattern, err)
+		}
+		if re.SubexpIndex("port") < 0 || re.SubexpIndex("state") < 0 {
+			return fmt.Errorf("switch_events syslog pattern %q must have port and state groups", pattern)
+		}
+	}
+
+	addresses := make(map[string]string, len(s.Switches))
+	for i := range s.Switches {
+		sw := &s.Switches[i]
+		sw.Name = strings.TrimSpace(sw.Name)
+		if sw.Name == "" {
+			return fmt.Errorf("switch_events switches[%d]: name is required", i)
+		}
+		ip := net.ParseIP(strings.TrimSpace(sw.Address))
+		if ip == nil {
+			return fmt.Errorf("switch %q: address must be an IP address", sw.Name)
+		}
+		sw.Address = ip.String()
+		if other, ok := addresses[sw.Address]; ok {
+			return fmt.Errorf("switches %q and %q share address %s", other, sw.Name, sw.Address)
+		}
+		addresses[sw.Address] = sw.Name
+		if len(sw.Ports) == 0 {
+			return fmt.Errorf("switch %q: ports are required", sw.Name)
+		}
+		for port, miner := range sw.Ports {
+			if strings.TrimSpace(port) == "" || strings.TrimSpace(miner) == "" {
+				return fmt.Errorf("switch %q: ports need a port and a miner", sw.Name)
+			}
+		}
+	}
 	return nil
 }
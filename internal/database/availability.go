@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Sources of availability changes.
+const (
+	// AvailabilityDiscovery changes come from a scan finding a miner or
+	// missing it.
+	AvailabilityDiscovery = "discovery"
+	// AvailabilityPoll changes come from status polls succeeding or
+	// failing.
+	AvailabilityPoll = "status_poll"
+	// AvailabilitySwitch changes come from the miner's switch port going
+	// down.
+	AvailabilitySwitch = "switch"
+)
+
+// AvailabilityChange is a miner going online or offline.
+type AvailabilityChange struct {
+	ID        int64
+	MinerID   string
+	Online    bool
+	Source    string
+	Reason    *string
+	ChangedAt time.Time
+}
+
+// RecordMinerAvailability notes whether a miner is reachable. A
+// miner_availability row is stored only when the state differs from the
+// last one recorded; the result reports whether it did.
+func (s *Store) RecordMinerAvailability(ctx context.Context, change AvailabilityChange) (bool, error) {
+	change.MinerID = strings.TrimSpace(change.MinerID)
+	if change.MinerID == "" {
+		return false, fmt.Errorf("miner id is required")
+	}
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = time.Now().UTC()
+	}
+	if change.Reason != nil && len(*change.Reason) > maxPollErrorLength {
+		reason := (*change.Reason)[:maxPollErrorLength]
+		change.Reason = &reason
+	}
+	online := boolToInt(change.Online)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin availability tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE miners SET available = ?
+		WHERE id = ? AND (available IS NULL OR available <> ?)
+	`, online, change.MinerID, online)
+	if err != nil {
+		return false, fmt.Errorf("update availability for miner %s: %w", change.MinerID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("update availability for miner %s: %w", change.MinerID, err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO miner_availability (miner_id, online, source, reason, changed_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, change.MinerID, online, change.Source, nullableString(change.Reason), change.ChangedAt.UTC()); err != nil {
+		return false, fmt.Errorf("insert availability change for miner %s: %w", change.MinerID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit availability change for miner %s: %w", change.MinerID, err)
+	}
+	return true, nil
+}
+
+// ListAvailabilityChanges returns a miner's availability changes after from
+// up to and including to, oldest first, preceded by the last change at or
+// before from, which gives the state the range starts in.
+func (s *Store) ListAvailabilityChanges(ctx context.Context, minerID string, from, to time.Time) ([]AvailabilityChange, error) {
+	var changes []AvailabilityChange
+
+	prior, err := s.queryAvailabilityChanges(ctx, `
+		SELECT id, miner_id, online, source, reason, changed_at
+		FROM miner_availability
+		WHERE miner_id = ? AND changed_at <= ?
+		ORDER BY changed_at DESC, id DESC
+		LIMIT 1
+	`, minerID, from.UTC())
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, prior...)
+
+	within, err := s.queryAvailabilityChanges(ctx, `
+		SELECT id, miner_id, online, source, reason, changed_at
+		FROM miner_availability
+		WHERE miner_id = ? AND changed_at > ? AND changed_at <= ?
+		ORDER BY changed_at, id
+	`, minerID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return append(changes, within...), nil
+}
+
+func (s *Store) queryAvailabilityChanges(ctx context.Context, query string, args ...any) ([]AvailabilityChange, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query availability changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []AvailabilityChange
+	for rows.Next() {
+		var (
+			change    AvailabilityChange
+			onlineInt int
+			reason    sql.NullString
+		)
+		if err := rows.Scan(&change.ID, &change.MinerID, &onlineInt, &change.Source, &reason, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("scan availability change: %w", err)
+		}
+		change.Online = onlineInt == 1
+		change.Reason = stringPtrFromNull(reason)
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate availability changes: %w", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BackupTo writes a consistent snapshot of the database to path using
+// VACUUM INTO. The destination must not already exist. Only SQLite is
+// supported; PostgreSQL deployments should rely on pg_dump.
+func (s *Store) BackupTo(ctx context.Context, path string) error {
+	if s.dialect != DialectSQLite {
+		return fmt.Errorf("online backup is not supported for %s", s.dialect)
+	}
+
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return fmt.Errorf("backup path is required")
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup destination %s already exists", path)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("vacuum into %s: %w", path, err)
+	}
+
+	return nil
+}
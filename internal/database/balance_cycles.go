@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RecordBalanceCycle stores the summary of one balance cycle together with
+// the reason each skipped miner was left alone.
+func (s *Store) RecordBalanceCycle(ctx context.Context, input BalanceCycleInput) (BalanceCycle, error) {
+	recordedAt := input.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = time.Now().UTC()
+	}
+
+	var skipped map[string]int
+	var skippedJSON []byte
+	if len(input.SkippedMiners) > 0 {
+		skipped = make(map[string]int)
+		for _, reason := range input.SkippedMiners {
+			skipped[reason]++
+		}
+		var err error
+		skippedJSON, err = json.Marshal(skipped)
+		if err != nil {
+			return BalanceCycle{}, fmt.Errorf("marshal skipped miners: %w", err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return BalanceCycle{}, fmt.Errorf("begin balance cycle tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO balance_cycles (
+			mode, generation_kw, target_power, consumption_before, consumption_after,
+			miners_eligible, miners_adjusted, miners_failed, skipped, recorded_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, input.Mode, input.GenerationKW, input.TargetPowerW, input.ConsumptionBeforeW, input.ConsumptionAfterW,
+		input.MinersEligible, input.MinersAdjusted, input.MinersFailed,
+		nullableBytes(skippedJSON), recordedAt.UTC()).Scan(&id); err != nil {
+		return BalanceCycle{}, fmt.Errorf("insert balance cycle: %w", err)
+	}
+
+	for minerID, reason := range input.SkippedMiners {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO balance_cycle_skips (cycle_id, miner_id, reason) VALUES (?, ?, ?)
+		`, id, minerID, reason); err != nil {
+			return BalanceCycle{}, fmt.Errorf("insert skipped miner %s for cycle %d: %w", minerID, id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return BalanceCycle{}, fmt.Errorf("commit balance cycle tx: %w", err)
+	}
+
+	return BalanceCycle{
+		ID:                 id,
+		Mode:               input.Mode,
+		GenerationKW:       input.GenerationKW,
+		TargetPowerW:       input.TargetPowerW,
+		ConsumptionBeforeW: input.ConsumptionBeforeW,
+		ConsumptionAfterW:  input.ConsumptionAfterW,
+		MinersEligible:     input.MinersEligible,
+		MinersAdjusted:     input.MinersAdjusted,
+		MinersFailed:       input.MinersFailed,
+		Skipped:            skipped,
+		RecordedAt:         recordedAt.UTC(),
+	}, nil
+}
+
+// GetBalanceCycle returns one cycle with the miners it skipped, ordered by
+// reason and miner ID.
+func (s *Store) GetBalanceCycle(ctx context.Context, id int64) (BalanceCycle, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, mode, generation_kw, target_power, consumption_before, consumption_after,
+			miners_eligible, miners_adjusted, miners_failed, skipped, recorded_at
+		FROM balance_cycles
+		WHERE id = ?
+	`, id)
+	cycle, err := scanBalanceCycle(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return BalanceCycle{}, fmt.Errorf("balance cycle %d not found", id)
+		}
+		return BalanceCycle{}, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT miner_id, reason
+		FROM balance_cycle_skips
+		WHERE cycle_id = ?
+		ORDER BY reason, miner_id
+	`, id)
+	if err != nil {
+		return BalanceCycle{}, fmt.Errorf("query skipped miners for cycle %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var skip BalanceCycleSkip
+		if err := rows.Scan(&skip.MinerID, &skip.Reason); err != nil {
+			return BalanceCycle{}, fmt.Errorf("scan skipped miner: %w", err)
+		}
+		cycle.SkippedMiners = append(cycle.SkippedMiners, skip)
+	}
+	if err := rows.Err(); err != nil {
+		return BalanceCycle{}, fmt.Errorf("iterate skipped miners for cycle %d: %w", id, err)
+	}
+
+	return cycle, nil
+}
+
+// ListBalanceCycles returns up to limit cycles recorded in [from, to), newest
+// first. A zero from or to leaves that end of the range open.
+func (s *Store) ListBalanceCycles(ctx context.Context, from, to time.Time, limit int) ([]BalanceCycle, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, mode, generation_kw, target_power, consumption_before, consumption_after,
+			miners_eligible, miners_adjusted, miners_failed, skipped, recorded_at
+		FROM balance_cycles
+		WHERE 1 = 1
+	`
+	var args []any
+	if !from.IsZero() {
+		query += " AND recorded_at >= ?"
+		args = append(args, from.UTC())
+	}
+	if !to.IsZero() {
+		query += " AND recorded_at < ?"
+		args = append(args, to.UTC())
+	}
+	query += " ORDER BY recorded_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query balance cycles: %w", err)
+	}
+	defer rows.Close()
+
+	var cycles []BalanceCycle
+	for rows.Next() {
+		cycle, err := scanBalanceCycle(rows)
+		if err != nil {
+			return nil, err
+		}
+		cycles = append(cycles, cycle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate balance cycles: %w", err)
+	}
+
+	return cycles, nil
+}
+
+func scanBalanceCycle(row interface{ Scan(...any) error }) (BalanceCycle, error) {
+	var (
+		cycle   BalanceCycle
+		skipped sql.NullString
+	)
+	if err := row.Scan(&cycle.ID, &cycle.Mode, &cycle.GenerationKW, &cycle.TargetPowerW,
+		&cycle.ConsumptionBeforeW, &cycle.ConsumptionAfterW, &cycle.MinersEligible,
+		&cycle.MinersAdjusted, &cycle.MinersFailed, &skipped, &cycle.RecordedAt); err != nil {
+		return BalanceCycle{}, fmt.Errorf("scan balance cycle: %w", err)
+	}
+	if skipped.Valid && skipped.String != "" {
+		if err := json.Unmarshal([]byte(skipped.String), &cycle.Skipped); err != nil {
+			return BalanceCycle{}, fmt.Errorf("decode skipped miners for cycle %d: %w", cycle.ID, err)
+		}
+	}
+	return cycle, nil
+}
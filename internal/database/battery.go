@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const batteryPolicyKey = "battery_policy"
+
+// BatteryPolicy adjusts the balancer target from the plant battery's state
+// of charge. At or above BoostSOCPercent the fleet may draw up to
+// MaxDischargeKW beyond generation; at or below ReserveSOCPercent the target
+// is cut by a further DepletedMarginPercent. A zero MaxDischargeKW disables
+// the boost.
+type BatteryPolicy struct {
+	BoostSOCPercent       float64 `json:"boost_soc_percent"`
+	MaxDischargeKW        float64 `json:"max_discharge_kw"`
+	ReserveSOCPercent     float64 `json:"reserve_soc_percent"`
+	DepletedMarginPercent float64 `json:"depleted_margin_percent"`
+}
+
+// DefaultBatteryPolicy applies until a policy is saved.
+var DefaultBatteryPolicy = BatteryPolicy{
+	BoostSOCPercent:       60,
+	MaxDischargeKW:        0,
+	ReserveSOCPercent:     20,
+	DepletedMarginPercent: 10,
+}
+
+// GetBatteryPolicy returns the stored policy or DefaultBatteryPolicy.
+func (s *Store) GetBatteryPolicy(ctx context.Context) (BatteryPolicy, error) {
+	raw, err := s.GetAppSetting(ctx, batteryPolicyKey)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultBatteryPolicy, nil
+		}
+		return BatteryPolicy{}, err
+	}
+
+	var policy BatteryPolicy
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return BatteryPolicy{}, fmt.Errorf("decode battery policy: %w", err)
+	}
+	return policy, nil
+}
+
+// SetBatteryPolicy stores policy.
+func (s *Store) SetBatteryPolicy(ctx context.Context, policy BatteryPolicy) error {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("encode battery policy: %w", err)
+	}
+	return s.SetAppSetting(ctx, batteryPolicyKey, string(data))
+}
+
+// Adjust returns targetKW adjusted for a battery at socPercent.
+func (p BatteryPolicy) Adjust(socPercent, targetKW float64) float64 {
+	switch {
+	case p.MaxDischargeKW > 0 && socPercent >= p.BoostSOCPercent:
+		return targetKW + p.MaxDischargeKW
+	case socPercent <= p.ReserveSOCPercent:
+		return targetKW * (1 - p.DepletedMarginPercent/100)
+	}
+	return targetKW
+}
@@ -0,0 +1,331 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrCustomerExists is returned when a customer name is taken.
+var ErrCustomerExists = errors.New("customer name already in use")
+
+// ErrInvalidCustomerToken is returned by CustomerForToken for unknown
+// tokens.
+var ErrInvalidCustomerToken = errors.New("invalid customer token")
+
+// CustomerTokenPrefix starts every customer token so leaked ones are easy
+// to recognise.
+const CustomerTokenPrefix = "phc_"
+
+const customerColumns = `c.id, c.name, c.contact, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM miners m WHERE m.customer_id = c.id AND m.archived_at IS NULL)`
+
+// Customer is a hosting customer whose miners run in the plant.
+type Customer struct {
+	ID      int64
+	Name    string
+	Contact *string
+	// Miners counts the customer's miners that are not archived.
+	Miners    int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CustomerInput creates or replaces a customer.
+type CustomerInput struct {
+	Name    string
+	Contact *string
+}
+
+// CustomerToken is an API token that gives a customer read access to its
+// own miners. Only a hash of the token is stored; Prefix identifies it.
+type CustomerToken struct {
+	ID         int64
+	CustomerID int64
+	Name       string
+	Prefix     string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+// CreateCustomer stores a new customer.
+func (s *Store) CreateCustomer(ctx context.Context, input CustomerInput) (Customer, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return Customer{}, fmt.Errorf("customer name is required")
+	}
+	if err := s.checkCustomerName(ctx, name, 0); err != nil {
+		return Customer{}, err
+	}
+
+	now := time.Now().UTC()
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO customers (name, contact, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`, name, nullableTrimmedString(input.Contact), now, now).Scan(&id); err != nil {
+		return Customer{}, fmt.Errorf("insert customer %s: %w", name, err)
+	}
+	return s.GetCustomer(ctx, id)
+}
+
+// UpdateCustomer replaces a customer's name and contact.
+func (s *Store) UpdateCustomer(ctx context.Context, id int64, input CustomerInput) (Customer, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return Customer{}, fmt.Errorf("customer name is required")
+	}
+	if err := s.checkCustomerName(ctx, name, id); err != nil {
+		return Customer{}, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE customers SET name = ?, contact = ?, updated_at = ? WHERE id = ?
+	`, name, nullableTrimmedString(input.Contact), time.Now().UTC(), id)
+	if err != nil {
+		return Customer{}, fmt.Errorf("update customer %d: %w", id, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return Customer{}, fmt.Errorf("customer rows affected: %w", err)
+	}
+	if rows == 0 {
+		return Customer{}, fmt.Errorf("customer %d not found", id)
+	}
+	return s.GetCustomer(ctx, id)
+}
+
+// DeleteCustomer removes a customer and its tokens. Its miners stay in the
+// inventory without a customer.
+func (s *Store) DeleteCustomer(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete customer tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE miners SET customer_id = NULL WHERE customer_id = ?`, id); err != nil {
+		return fmt.Errorf("unassign miners of customer %d: %w", id, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM customer_tokens WHERE customer_id = ?`, id); err != nil {
+		return fmt.Errorf("delete tokens of customer %d: %w", id, err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM customers WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete customer %d: %w", id, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("customer rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("customer %d not found", id)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete customer tx: %w", err)
+	}
+	return nil
+}
+
+// GetCustomer returns a single customer.
+func (s *Store) GetCustomer(ctx context.Context, id int64) (Customer, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+customerColumns+` FROM customers c WHERE c.id = ?`, id)
+	customer, err := scanCustomer(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Customer{}, fmt.Errorf("customer %d not found", id)
+		}
+		return Customer{}, err
+	}
+	return customer, nil
+}
+
+// ListCustomers returns every customer ordered by name.
+func (s *Store) ListCustomers(ctx context.Context) ([]Customer, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+customerColumns+` FROM customers c ORDER BY c.name`)
+	if err != nil {
+		return nil, fmt.Errorf("query customers: %w", err)
+	}
+	defer rows.Close()
+
+	var customers []Customer
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate customers: %w", err)
+	}
+	return customers, nil
+}
+
+func scanCustomer(row interface{ Scan(...any) error }) (Customer, error) {
+	var (
+		customer Customer
+		contact  sql.NullString
+	)
+	if err := row.Scan(&customer.ID, &customer.Name, &contact, &customer.CreatedAt, &customer.UpdatedAt, &customer.Miners); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Customer{}, err
+		}
+		return Customer{}, fmt.Errorf("scan customer: %w", err)
+	}
+	customer.Contact = stringPtrFromNull(contact)
+	return customer, nil
+}
+
+// checkCustomerName reports ErrCustomerExists when another customer than id
+// already uses name.
+func (s *Store) checkCustomerName(ctx context.Context, name string, id int64) error {
+	var existing int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM customers WHERE name = ?`, name).Scan(&existing)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("look up customer %s: %w", name, err)
+	case existing != id:
+		return fmt.Errorf("%w: %s", ErrCustomerExists, name)
+	}
+	return nil
+}
+
+// ListCustomerMiners returns the customer's miners that are not archived.
+func (s *Store) ListCustomerMiners(ctx context.Context, customerID int64) ([]Miner, error) {
+	miners, err := s.ListMiners(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Miner, 0, len(miners))
+	for _, miner := range miners {
+		if miner.CustomerID != nil && *miner.CustomerID == customerID {
+			out = append(out, miner)
+		}
+	}
+	return out, nil
+}
+
+// CustomerFleetSummary is FleetSummary over the customer's miners.
+func (s *Store) CustomerFleetSummary(ctx context.Context, customerID int64, now time.Time) (FleetSummary, error) {
+	return s.fleetSummary(ctx, now, &customerID)
+}
+
+// CreateCustomerToken issues a new token for the customer. The token itself
+// is only returned here; the store keeps its hash.
+func (s *Store) CreateCustomerToken(ctx context.Context, customerID int64, name string) (CustomerToken, string, error) {
+	if _, err := s.GetCustomer(ctx, customerID); err != nil {
+		return CustomerToken{}, "", err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return CustomerToken{}, "", fmt.Errorf("generate customer token: %w", err)
+	}
+	token := CustomerTokenPrefix + hex.EncodeToString(secret)
+
+	record := CustomerToken{
+		CustomerID: customerID,
+		Name:       strings.TrimSpace(name),
+		Prefix:     token[:len(CustomerTokenPrefix)+8],
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO customer_tokens (customer_id, name, token_hash, prefix, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`, customerID, record.Name, hashToken(token), record.Prefix, record.CreatedAt).Scan(&record.ID); err != nil {
+		return CustomerToken{}, "", fmt.Errorf("insert token for customer %d: %w", customerID, err)
+	}
+	return record, token, nil
+}
+
+// ListCustomerTokens returns the customer's tokens, newest first.
+func (s *Store) ListCustomerTokens(ctx context.Context, customerID int64) ([]CustomerToken, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, customer_id, name, prefix, created_at, last_used_at
+		FROM customer_tokens
+		WHERE customer_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("query tokens of customer %d: %w", customerID, err)
+	}
+	defer rows.Close()
+
+	var tokens []CustomerToken
+	for rows.Next() {
+		var (
+			token    CustomerToken
+			lastUsed sql.NullTime
+		)
+		if err := rows.Scan(&token.ID, &token.CustomerID, &token.Name, &token.Prefix, &token.CreatedAt, &lastUsed); err != nil {
+			return nil, fmt.Errorf("scan customer token: %w", err)
+		}
+		token.LastUsedAt = timePtrFromNull(lastUsed)
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate customer tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// DeleteCustomerToken revokes one of the customer's tokens.
+func (s *Store) DeleteCustomerToken(ctx context.Context, customerID, tokenID int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM customer_tokens WHERE id = ? AND customer_id = ?`, tokenID, customerID)
+	if err != nil {
+		return fmt.Errorf("delete customer token %d: %w", tokenID, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("customer token rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("customer token %d not found", tokenID)
+	}
+	return nil
+}
+
+// CustomerForToken returns the customer a token belongs to and notes when
+// the token was used, or ErrInvalidCustomerToken.
+func (s *Store) CustomerForToken(ctx context.Context, token string, now time.Time) (Customer, error) {
+	token = strings.TrimSpace(token)
+	if !strings.HasPrefix(token, CustomerTokenPrefix) {
+		return Customer{}, ErrInvalidCustomerToken
+	}
+
+	var tokenID, customerID int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, customer_id FROM customer_tokens WHERE token_hash = ?
+	`, hashToken(token)).Scan(&tokenID, &customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Customer{}, ErrInvalidCustomerToken
+	}
+	if err != nil {
+		return Customer{}, fmt.Errorf("look up customer token: %w", err)
+	}
+
+	// Portal requests come in bursts; a minute's precision is plenty.
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE customer_tokens SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)
+	`, now.UTC(), tokenID, now.UTC().Add(-time.Minute)); err != nil {
+		return Customer{}, fmt.Errorf("record customer token use: %w", err)
+	}
+	return s.GetCustomer(ctx, customerID)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Alert sources reported by ListAlerts.
+const (
+	AlertSourceHashboard = "hashboard"
+	AlertSourceDrift     = "drift"
+	AlertSourceBalance   = "balance"
+)
+
+// FleetSummary aggregates the latest status of every miner that is not
+// archived. Online miners have an address; power and hashrate only count
+// online miners.
+type FleetSummary struct {
+	Miners          int
+	Online          int
+	Managed         int // Online and managed
+	Held            int
+	ManagedPowerW   float64
+	UnmanagedPowerW float64
+	HashrateGH      float64
+}
+
+// MinerRanking is one miner in a worst-first ranking. Value is the metric
+// ranked on, for example the hottest chip in °C.
+type MinerRanking struct {
+	MinerID string
+	Name    *string
+	Value   float64
+}
+
+// Alert is a recent event worth an operator's attention: a hashboard alert
+// or failed restart, detected or unremediated drift, or a failed preset
+// change. Kind is the event's action, drift field or balance reason.
+type Alert struct {
+	Source     string
+	MinerID    string
+	Kind       string
+	Message    *string
+	RecordedAt time.Time
+}
+
+// FleetSummary computes fleet totals in a single query. Holds are evaluated
+// at now.
+func (s *Store) FleetSummary(ctx context.Context, now time.Time) (FleetSummary, error) {
+	return s.fleetSummary(ctx, now, nil)
+}
+
+// fleetSummary computes FleetSummary, over one customer's miners when
+// customerID is set.
+func (s *Store) fleetSummary(ctx context.Context, now time.Time, customerID *int64) (FleetSummary, error) {
+	filter := ""
+	args := []any{now.UTC()}
+	if customerID != nil {
+		filter = " AND m.customer_id = ?"
+		args = append(args, *customerID)
+	}
+
+	var summary FleetSummary
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN m.ip IS NOT NULL AND m.ip <> '' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.ip IS NOT NULL AND m.ip <> '' AND m.managed = 1 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.hold = 1 AND (m.hold_until IS NULL OR m.hold_until > ?) THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.ip IS NOT NULL AND m.ip <> '' AND m.managed = 1 THEN st.power_consumption END), 0),
+			COALESCE(SUM(CASE WHEN m.ip IS NOT NULL AND m.ip <> '' AND m.managed = 0 THEN st.power_consumption END), 0),
+			COALESCE(SUM(CASE WHEN m.ip IS NOT NULL AND m.ip <> '' THEN st.hashrate END), 0)
+		FROM miners m
+		LEFT JOIN statuses st ON st.id = m.latest_status_id
+		WHERE m.archived_at IS NULL`+filter,
+		args...).Scan(&summary.Miners, &summary.Online, &summary.Managed, &summary.Held,
+		&summary.ManagedPowerW, &summary.UnmanagedPowerW, &summary.HashrateGH)
+	if err != nil {
+		return FleetSummary{}, fmt.Errorf("query fleet summary: %w", err)
+	}
+	return summary, nil
+}
+
+// HottestMiners ranks online miners by the hottest chip of their latest
+// status, hottest first.
+func (s *Store) HottestMiners(ctx context.Context, limit int) ([]MinerRanking, error) {
+	return s.rankMiners(ctx, "hottest miners", `
+		SELECT m.id, m.name, MAX(c.chip_temp_max) AS value
+		FROM miners m
+		JOIN chain_snapshots c ON c.status_id = m.latest_status_id
+		WHERE m.archived_at IS NULL AND m.ip IS NOT NULL AND m.ip <> '' AND c.chip_temp_max IS NOT NULL
+		GROUP BY m.id, m.name
+		ORDER BY value DESC, m.id
+		LIMIT ?
+	`, limit)
+}
+
+// LeastEfficientMiners ranks online miners by the J/TH of their latest
+// status, least efficient first. Miners without hashrate or power readings
+// are left out.
+func (s *Store) LeastEfficientMiners(ctx context.Context, limit int) ([]MinerRanking, error) {
+	return s.rankMiners(ctx, "least efficient miners", `
+		SELECT m.id, m.name, st.power_consumption * 1000.0 / st.hashrate AS value
+		FROM miners m
+		JOIN statuses st ON st.id = m.latest_status_id
+		WHERE m.archived_at IS NULL AND m.ip IS NOT NULL AND m.ip <> ''
+			AND st.hashrate > 0 AND st.power_consumption > 0
+		ORDER BY value DESC, m.id
+		LIMIT ?
+	`, limit)
+}
+
+func (s *Store) rankMiners(ctx context.Context, what, query string, limit int) ([]MinerRanking, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	var ranking []MinerRanking
+	for rows.Next() {
+		var (
+			entry MinerRanking
+			name  sql.NullString
+		)
+		if err := rows.Scan(&entry.MinerID, &name, &entry.Value); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", what, err)
+		}
+		entry.Name = stringPtrFromNull(name)
+		ranking = append(ranking, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate %s: %w", what, err)
+	}
+	return ranking, nil
+}
+
+// ListAlerts returns up to limit alerts recorded since since, newest first.
+func (s *Store) ListAlerts(ctx context.Context, since time.Time, limit int) ([]Alert, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	since = since.UTC()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT source, miner_id, kind, message, recorded_at FROM (
+			SELECT 'hashboard' AS source, miner_id, action AS kind, error_message AS message, recorded_at
+			FROM hashboard_events
+			WHERE recorded_at >= ? AND (action = ? OR success = 0)
+			UNION ALL
+			SELECT 'drift', miner_id, field, error_message, recorded_at
+			FROM drift_events
+			WHERE recorded_at >= ? AND (action = ? OR success = 0)
+			UNION ALL
+			SELECT 'balance', miner_id, reason, error_message, recorded_at
+			FROM power_balance_events
+			WHERE recorded_at >= ? AND success = 0
+		) alerts
+		ORDER BY recorded_at DESC
+		LIMIT ?
+	`, since, HashboardActionAlert, since, DriftActionDetected, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []Alert
+	for rows.Next() {
+		var (
+			alert   Alert
+			message sql.NullString
+		)
+		if err := rows.Scan(&alert.Source, &alert.MinerID, &alert.Kind, &message, &alert.RecordedAt); err != nil {
+			return nil, fmt.Errorf("scan alert: %w", err)
+		}
+		alert.Message = stringPtrFromNull(message)
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate alerts: %w", err)
+	}
+	return alerts, nil
+}
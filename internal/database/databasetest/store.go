@@ -0,0 +1,804 @@
+// Package databasetest provides in-memory fakes of the database role
+// interfaces for exercising services without a SQLite file.
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// pollRatioWeight matches the weight database.Store gives the newest poll
+// in a miner's success ratio.
+const pollRatioWeight = 0.05
+
+// Store is an in-memory implementation of MinerReader, StatusWriter,
+// AvailabilityRecorder, TelemetryWriter, PlantReader, PlantWriter,
+// PlantGapRecorder, GridProtectionRecorder, PowerMeasurementStore,
+// ReconciliationStore, BalanceRecorder and EventRecorder. Seed it with PutMiner; the recorded rows can be read back
+// through the accessor methods. It is safe for concurrent use.
+type Store struct {
+	mu sync.Mutex
+
+	miners          map[string]database.Miner
+	statuses        []database.Status
+	telemetry       []database.ChainSnapshot
+	plantReadings   []database.PlantReading
+	plantGaps       []database.PlantGap
+	gridProtection  []database.GridProtectionEvent
+	measurements    []database.PowerMeasurement
+	reconciliations []database.ContainerReconciliation
+	quarantined     []database.QuarantinedReadingInput
+	balanceEvents   []database.PowerBalanceEvent
+	balanceCycles   []database.BalanceCycle
+	hashboardEvents []database.HashboardEvent
+	driftEvents     []database.DriftEvent
+	availability    []database.AvailabilityChange
+	nextID          int64
+}
+
+var (
+	_ database.MinerReader            = (*Store)(nil)
+	_ database.StatusWriter           = (*Store)(nil)
+	_ database.AvailabilityRecorder   = (*Store)(nil)
+	_ database.TelemetryWriter        = (*Store)(nil)
+	_ database.PlantReader            = (*Store)(nil)
+	_ database.PlantWriter            = (*Store)(nil)
+	_ database.PlantGapRecorder       = (*Store)(nil)
+	_ database.GridProtectionRecorder = (*Store)(nil)
+	_ database.PowerMeasurementStore  = (*Store)(nil)
+	_ database.ReconciliationStore    = (*Store)(nil)
+	_ database.BalanceRecorder        = (*Store)(nil)
+	_ database.EventRecorder          = (*Store)(nil)
+)
+
+// New returns an empty Store.
+func New() *Store {
+	return &Store{miners: make(map[string]database.Miner)}
+}
+
+// PutMiner adds or replaces a miner.
+func (s *Store) PutMiner(miner database.Miner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.miners[miner.ID] = miner
+}
+
+func (s *Store) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func notFound(minerID string) error {
+	return fmt.Errorf("miner %s not found", minerID)
+}
+
+// ListMiners returns the miners that are not archived, ordered by ID.
+func (s *Store) ListMiners(ctx context.Context) ([]database.Miner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var miners []database.Miner
+	for _, miner := range s.miners {
+		if miner.ArchivedAt == nil {
+			miners = append(miners, miner)
+		}
+	}
+	sort.Slice(miners, func(i, j int) bool { return miners[i].ID < miners[j].ID })
+	return miners, nil
+}
+
+// GetMiner returns a miner by ID.
+func (s *Store) GetMiner(ctx context.Context, minerID string) (database.Miner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	miner, ok := s.miners[minerID]
+	if !ok {
+		return database.Miner{}, notFound(minerID)
+	}
+	return miner, nil
+}
+
+// RecordMinerStatus stores a status and makes it the miner's latest.
+func (s *Store) RecordMinerStatus(ctx context.Context, minerID string, input database.MinerStatusInput) (database.Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	miner, ok := s.miners[minerID]
+	if !ok {
+		return database.Status{}, notFound(minerID)
+	}
+
+	recordedAt := input.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = time.Now().UTC()
+	}
+	status := database.Status{
+		ID:               s.id(),
+		MinerID:          minerID,
+		Uptime:           input.Uptime,
+		State:            input.State,
+		Preset:           input.Preset,
+		Hashrate:         input.Hashrate,
+		PowerUsage:       input.PowerUsage,
+		PowerConsumption: input.PowerConsumption,
+		RecordedAt:       recordedAt,
+		LastConfirmedAt:  recordedAt,
+	}
+	for _, fan := range input.Fans {
+		status.Fans = append(status.Fans, database.FanStatus{
+			ID:            s.id(),
+			StatusID:      status.ID,
+			FanIdentifier: fan.FanIdentifier,
+			RPM:           fan.RPM,
+			Status:        fan.Status,
+		})
+	}
+	for _, pool := range input.Pools {
+		status.Pools = append(status.Pools, database.PoolStat{
+			ID:        s.id(),
+			StatusID:  status.ID,
+			PoolIndex: pool.PoolIndex,
+			URL:       pool.URL,
+			Worker:    pool.Worker,
+			Status:    pool.Status,
+			Accepted:  pool.Accepted,
+			Rejected:  pool.Rejected,
+			Stale:     pool.Stale,
+		})
+	}
+	status.Chains = s.chainSnapshots(minerID, &status.ID, recordedAt, input.Chains)
+	s.statuses = append(s.statuses, status)
+
+	miner.LatestStatus = &status
+	miner.LatestStatusID = &status.ID
+	s.miners[minerID] = miner
+	return status, nil
+}
+
+// ConfirmMinerStatus moves a status's LastConfirmedAt forward.
+func (s *Store) ConfirmMinerStatus(ctx context.Context, minerID string, statusID int64, confirmedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if confirmedAt.IsZero() {
+		confirmedAt = time.Now().UTC()
+	}
+	for i := range s.statuses {
+		if s.statuses[i].ID != statusID || s.statuses[i].MinerID != minerID {
+			continue
+		}
+		s.statuses[i].LastConfirmedAt = confirmedAt
+		if miner, ok := s.miners[minerID]; ok && miner.LatestStatusID != nil && *miner.LatestStatusID == statusID {
+			status := s.statuses[i]
+			miner.LatestStatus = &status
+			s.miners[minerID] = miner
+		}
+		return nil
+	}
+	return fmt.Errorf("status %d of miner %s not found", statusID, minerID)
+}
+
+// RecordPollResult folds a poll outcome into the miner's reliability the
+// way database.Store does.
+func (s *Store) RecordPollResult(ctx context.Context, minerID string, pollErr error) (database.PollReliability, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	miner, ok := s.miners[minerID]
+	if !ok {
+		return database.PollReliability{}, notFound(minerID)
+	}
+
+	rel := miner.Reliability
+	ratio := 1.0
+	if rel.SuccessRatio != nil {
+		ratio = *rel.SuccessRatio
+	}
+	ratio *= 1 - pollRatioWeight
+	if pollErr == nil {
+		ratio += pollRatioWeight
+		rel.ConsecutiveFailures = 0
+	} else {
+		message := pollErr.Error()
+		at := time.Now().UTC()
+		rel.ConsecutiveFailures++
+		rel.LastError = &message
+		rel.LastErrorAt = &at
+	}
+	rel.SuccessRatio = &ratio
+
+	miner.Reliability = rel
+	s.miners[minerID] = miner
+	return rel, nil
+}
+
+// RecordMinerAvailability stores the change if it differs from the
+// miner's last recorded state, as database.Store does.
+func (s *Store) RecordMinerAvailability(ctx context.Context, change database.AvailabilityChange) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.miners[change.MinerID]; !ok {
+		return false, nil
+	}
+	for i := len(s.availability) - 1; i >= 0; i-- {
+		if s.availability[i].MinerID == change.MinerID {
+			if s.availability[i].Online == change.Online {
+				return false, nil
+			}
+			break
+		}
+	}
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = time.Now().UTC()
+	}
+	change.ID = s.id()
+	s.availability = append(s.availability, change)
+	return true, nil
+}
+
+// RecordChainTelemetry stores chain snapshots that belong to no status.
+func (s *Store) RecordChainTelemetry(ctx context.Context, minerID string, recordedAt time.Time, chains []database.ChainSnapshotInput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.miners[minerID]; !ok {
+		return notFound(minerID)
+	}
+	s.telemetry = append(s.telemetry, s.chainSnapshots(minerID, nil, recordedAt, chains)...)
+	return nil
+}
+
+func (s *Store) chainSnapshots(minerID string, statusID *int64, recordedAt time.Time, chains []database.ChainSnapshotInput) []database.ChainSnapshot {
+	var snapshots []database.ChainSnapshot
+	for _, chain := range chains {
+		snapshot := database.ChainSnapshot{
+			ID:              s.id(),
+			MinerID:         minerID,
+			StatusID:        statusID,
+			ChainIdentifier: chain.ChainIdentifier,
+			State:           chain.State,
+			Hashrate:        chain.Hashrate,
+			PCBTempMin:      chain.PCBTempMin,
+			PCBTempMax:      chain.PCBTempMax,
+			ChipTempMin:     chain.ChipTempMin,
+			ChipTempMax:     chain.ChipTempMax,
+			ChipTempAvg:     chain.ChipTempAvg,
+			ChipCount:       chain.ChipCount,
+			RecordedAt:      recordedAt,
+		}
+		for _, chip := range chain.Chips {
+			snapshot.Chips = append(snapshot.Chips, database.ChipSnapshot{
+				ID:              s.id(),
+				ChainSnapshotID: snapshot.ID,
+				ChipIdentifier:  chip.ChipIdentifier,
+				Hashrate:        chip.Hashrate,
+				Temperature:     chip.Temperature,
+			})
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
+// RecordPlantReading stores a plant reading.
+func (s *Store) RecordPlantReading(ctx context.Context, input database.PlantReadingInput) (database.PlantReading, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordedAt := input.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = time.Now().UTC()
+	}
+	reading := database.PlantReading{
+		ID:                        s.id(),
+		PlantID:                   input.PlantID,
+		TotalGeneration:           input.TotalGeneration,
+		TotalContainerConsumption: input.TotalContainerConsumption,
+		AvailablePower:            input.AvailablePower,
+		GenerationSources:         input.GenerationSources,
+		ConsumptionSources:        input.ConsumptionSources,
+		ExportedPower:             input.ExportedPower,
+		BatterySOC:                input.BatterySOC,
+		BatteryPower:              input.BatteryPower,
+		GridFrequency:             input.GridFrequency,
+		GridVoltage:               input.GridVoltage,
+		Fallback:                  input.Fallback,
+		RawData:                   input.RawData,
+		RecordedAt:                recordedAt,
+	}
+	s.plantReadings = append(s.plantReadings, reading)
+	return reading, nil
+}
+
+// QuarantinePlantReading stores a rejected reading.
+func (s *Store) QuarantinePlantReading(ctx context.Context, input database.QuarantinedReadingInput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quarantined = append(s.quarantined, input)
+	return nil
+}
+
+// GetLatestPlantReading returns the newest reading, or nil when there is
+// none.
+func (s *Store) GetLatestPlantReading(ctx context.Context) (*database.PlantReading, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *database.PlantReading
+	for i := range s.plantReadings {
+		reading := s.plantReadings[i]
+		if latest == nil || !reading.RecordedAt.Before(latest.RecordedAt) {
+			latest = &reading
+		}
+	}
+	return latest, nil
+}
+
+// ForEachPlantReading calls fn for every reading recorded in [from, to) in
+// chronological order.
+func (s *Store) ForEachPlantReading(ctx context.Context, from, to time.Time, fn func(database.PlantReading) error) error {
+	s.mu.Lock()
+	var readings []database.PlantReading
+	for _, reading := range s.plantReadings {
+		if !reading.RecordedAt.Before(from) && reading.RecordedAt.Before(to) {
+			readings = append(readings, reading)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.SliceStable(readings, func(i, j int) bool { return readings[i].RecordedAt.Before(readings[j].RecordedAt) })
+	for _, reading := range readings {
+		if err := fn(reading); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenPlantGap returns the open gap, recording lastError on it, or starts
+// a new one.
+func (s *Store) OpenPlantGap(ctx context.Context, reason string, lastReadingAt *time.Time, startedAt time.Time, lastError *string) (database.PlantGap, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.plantGaps {
+		if s.plantGaps[i].EndedAt == nil {
+			s.plantGaps[i].LastError = lastError
+			return s.plantGaps[i], nil
+		}
+	}
+	gap := database.PlantGap{
+		ID:            s.id(),
+		Reason:        reason,
+		LastReadingAt: lastReadingAt,
+		StartedAt:     startedAt,
+		LastError:     lastError,
+	}
+	s.plantGaps = append(s.plantGaps, gap)
+	return gap, nil
+}
+
+// MarkPlantGapAlerted records that the gap was alerted on.
+func (s *Store) MarkPlantGapAlerted(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.plantGaps {
+		if s.plantGaps[i].ID == id {
+			s.plantGaps[i].Alerted = true
+			return nil
+		}
+	}
+	return fmt.Errorf("plant gap %d not found", id)
+}
+
+// ClosePlantGaps ends every open gap at endedAt and returns them.
+func (s *Store) ClosePlantGaps(ctx context.Context, endedAt time.Time) ([]database.PlantGap, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var closed []database.PlantGap
+	for i := range s.plantGaps {
+		if s.plantGaps[i].EndedAt == nil {
+			ended := endedAt
+			s.plantGaps[i].EndedAt = &ended
+			closed = append(closed, s.plantGaps[i])
+		}
+	}
+	return closed, nil
+}
+
+// RecordGridProtectionEvent stores a load shed.
+func (s *Store) RecordGridProtectionEvent(ctx context.Context, event database.GridProtectionEvent) (database.GridProtectionEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = s.id()
+	if event.StartedAt.IsZero() {
+		event.StartedAt = time.Now().UTC()
+	}
+	s.gridProtection = append(s.gridProtection, event)
+	return event, nil
+}
+
+// CurrentGridProtectionEvent returns the event not yet cleared, or nil.
+func (s *Store) CurrentGridProtectionEvent(ctx context.Context) (*database.GridProtectionEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.gridProtection) - 1; i >= 0; i-- {
+		if s.gridProtection[i].ClearedAt == nil {
+			event := s.gridProtection[i]
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+// ClearGridProtectionEvents marks every open event cleared and returns
+// them.
+func (s *Store) ClearGridProtectionEvents(ctx context.Context, clearedAt time.Time) ([]database.GridProtectionEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cleared []database.GridProtectionEvent
+	for i := range s.gridProtection {
+		if s.gridProtection[i].ClearedAt == nil {
+			at := clearedAt
+			s.gridProtection[i].ClearedAt = &at
+			cleared = append(cleared, s.gridProtection[i])
+		}
+	}
+	return cleared, nil
+}
+
+// RecordPowerMeasurements stores one PDU poll's measurements.
+func (s *Store) RecordPowerMeasurements(ctx context.Context, measurements []database.PowerMeasurement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range measurements {
+		if _, ok := s.miners[m.MinerID]; !ok {
+			return notFound(m.MinerID)
+		}
+		m.ID = s.id()
+		if m.RecordedAt.IsZero() {
+			m.RecordedAt = time.Now()
+		}
+		m.RecordedAt = m.RecordedAt.UTC()
+		s.measurements = append(s.measurements, m)
+	}
+	return nil
+}
+
+// LatestPowerMeasurements returns each miner's newest measurement taken
+// since the given time.
+func (s *Store) LatestPowerMeasurements(ctx context.Context, since time.Time) (map[string]database.PowerMeasurement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := make(map[string]database.PowerMeasurement)
+	for _, m := range s.measurements {
+		if m.RecordedAt.Before(since) {
+			continue
+		}
+		if prev, ok := latest[m.MinerID]; !ok || !m.RecordedAt.Before(prev.RecordedAt) {
+			latest[m.MinerID] = m
+		}
+	}
+	return latest, nil
+}
+
+// RecordContainerReconciliations stores one reconciliation run.
+func (s *Store) RecordContainerReconciliations(ctx context.Context, reconciliations []database.ContainerReconciliation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rec := range reconciliations {
+		rec.ID = s.id()
+		if rec.RecordedAt.IsZero() {
+			rec.RecordedAt = time.Now()
+		}
+		rec.RecordedAt = rec.RecordedAt.UTC()
+		rec.ReadingAt = rec.ReadingAt.UTC()
+		s.reconciliations = append(s.reconciliations, rec)
+	}
+	return nil
+}
+
+// ListContainerReconciliations returns the reconciliations recorded in
+// [from, to), oldest first.
+func (s *Store) ListContainerReconciliations(ctx context.Context, from, to time.Time) ([]database.ContainerReconciliation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []database.ContainerReconciliation
+	for _, rec := range s.reconciliations {
+		if !rec.RecordedAt.Before(from) && rec.RecordedAt.Before(to) {
+			out = append(out, rec)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].RecordedAt.Before(out[j].RecordedAt) })
+	return out, nil
+}
+
+// LatestContainerReconciliations returns the newest reconciliation run,
+// ordered by container.
+func (s *Store) LatestContainerReconciliations(ctx context.Context) ([]database.ContainerReconciliation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest time.Time
+	for _, rec := range s.reconciliations {
+		if rec.RecordedAt.After(latest) {
+			latest = rec.RecordedAt
+		}
+	}
+	var out []database.ContainerReconciliation
+	for _, rec := range s.reconciliations {
+		if rec.RecordedAt.Equal(latest) {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Container < out[j].Container })
+	return out, nil
+}
+
+// RecordPowerBalanceEvent stores a balance event.
+func (s *Store) RecordPowerBalanceEvent(ctx context.Context, input database.PowerBalanceEventInput) (database.PowerBalanceEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordedAt := input.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = time.Now().UTC()
+	}
+	event := database.PowerBalanceEvent{
+		ID:                     s.id(),
+		MinerID:                input.MinerID,
+		OldPreset:              input.OldPreset,
+		NewPreset:              input.NewPreset,
+		OldPower:               input.OldPower,
+		NewPower:               input.NewPower,
+		Reason:                 input.Reason,
+		TotalConsumptionBefore: input.TotalConsumptionBefore,
+		TotalConsumptionAfter:  input.TotalConsumptionAfter,
+		AvailablePower:         input.AvailablePower,
+		TargetPower:            input.TargetPower,
+		Success:                input.Success,
+		ErrorMessage:           input.ErrorMessage,
+		RecordedAt:             recordedAt,
+	}
+	s.balanceEvents = append(s.balanceEvents, event)
+	return event, nil
+}
+
+// ListPowerBalanceEvents returns the newest events first, optionally for
+// one miner.
+func (s *Store) ListPowerBalanceEvents(ctx context.Context, minerID *string, limit int) ([]database.PowerBalanceEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit <= 0 {
+		limit = 100
+	}
+	var events []database.PowerBalanceEvent
+	for i := len(s.balanceEvents) - 1; i >= 0 && len(events) < limit; i-- {
+		event := s.balanceEvents[i]
+		if minerID != nil && *minerID != "" && event.MinerID != *minerID {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// RecordBalanceCycle stores a balance cycle summary.
+func (s *Store) RecordBalanceCycle(ctx context.Context, input database.BalanceCycleInput) (database.BalanceCycle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordedAt := input.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = time.Now().UTC()
+	}
+	cycle := database.BalanceCycle{
+		ID:                 s.id(),
+		Mode:               input.Mode,
+		GenerationKW:       input.GenerationKW,
+		TargetPowerW:       input.TargetPowerW,
+		ConsumptionBeforeW: input.ConsumptionBeforeW,
+		ConsumptionAfterW:  input.ConsumptionAfterW,
+		MinersEligible:     input.MinersEligible,
+		MinersAdjusted:     input.MinersAdjusted,
+		MinersFailed:       input.MinersFailed,
+		Skipped:            make(map[string]int),
+		RecordedAt:         recordedAt,
+	}
+	for minerID, reason := range input.SkippedMiners {
+		cycle.Skipped[reason]++
+		cycle.SkippedMiners = append(cycle.SkippedMiners, database.BalanceCycleSkip{MinerID: minerID, Reason: reason})
+	}
+	sort.Slice(cycle.SkippedMiners, func(i, j int) bool { return cycle.SkippedMiners[i].MinerID < cycle.SkippedMiners[j].MinerID })
+	s.balanceCycles = append(s.balanceCycles, cycle)
+	return cycle, nil
+}
+
+// CountPresetChanges counts each miner's successful balance events since
+// the given time.
+func (s *Store) CountPresetChanges(ctx context.Context, since time.Time) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, event := range s.balanceEvents {
+		if event.Success && !event.RecordedAt.Before(since) {
+			counts[event.MinerID]++
+		}
+	}
+	return counts, nil
+}
+
+// RecordPresetChange sets the miner's last preset change time.
+func (s *Store) RecordPresetChange(ctx context.Context, minerID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	miner, ok := s.miners[minerID]
+	if !ok {
+		return notFound(minerID)
+	}
+	at = at.UTC()
+	miner.LastPresetChangeAt = &at
+	s.miners[minerID] = miner
+	return nil
+}
+
+// RecordHashboardEvent stores a hashboard watchdog event.
+func (s *Store) RecordHashboardEvent(ctx context.Context, event database.HashboardEvent) (database.HashboardEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = s.id()
+	if event.RecordedAt.IsZero() {
+		event.RecordedAt = time.Now().UTC()
+	}
+	s.hashboardEvents = append(s.hashboardEvents, event)
+	return event, nil
+}
+
+// RecordDriftEvent stores a settings drift event.
+func (s *Store) RecordDriftEvent(ctx context.Context, event database.DriftEvent) (database.DriftEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = s.id()
+	if event.RecordedAt.IsZero() {
+		event.RecordedAt = time.Now().UTC()
+	}
+	s.driftEvents = append(s.driftEvents, event)
+	return event, nil
+}
+
+// Statuses returns the statuses recorded for a miner, oldest first.
+func (s *Store) Statuses(minerID string) []database.Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var statuses []database.Status
+	for _, status := range s.statuses {
+		if status.MinerID == minerID {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// Telemetry returns the chain telemetry recorded for a miner, oldest first.
+func (s *Store) Telemetry(minerID string) []database.ChainSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var snapshots []database.ChainSnapshot
+	for _, snapshot := range s.telemetry {
+		if snapshot.MinerID == minerID {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots
+}
+
+// PlantReadings returns every recorded plant reading, oldest first.
+func (s *Store) PlantReadings() []database.PlantReading {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.plantReadings)
+}
+
+// QuarantinedReadings returns every quarantined reading, oldest first.
+func (s *Store) QuarantinedReadings() []database.QuarantinedReadingInput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.quarantined)
+}
+
+// PlantGaps returns every recorded plant gap, oldest first.
+func (s *Store) PlantGaps() []database.PlantGap {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.plantGaps)
+}
+
+// GridProtectionEvents returns every recorded load shed, oldest first.
+func (s *Store) GridProtectionEvents() []database.GridProtectionEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.gridProtection)
+}
+
+// PowerMeasurements returns every recorded power measurement, oldest
+// first.
+func (s *Store) PowerMeasurements() []database.PowerMeasurement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.measurements)
+}
+
+// ContainerReconciliations returns every recorded reconciliation, oldest
+// first.
+func (s *Store) ContainerReconciliations() []database.ContainerReconciliation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.reconciliations)
+}
+
+// BalanceEvents returns every recorded balance event, oldest first.
+func (s *Store) BalanceEvents() []database.PowerBalanceEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.balanceEvents)
+}
+
+// BalanceCycles returns every recorded balance cycle, oldest first.
+func (s *Store) BalanceCycles() []database.BalanceCycle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.balanceCycles)
+}
+
+// HashboardEvents returns every recorded hashboard event, oldest first.
+func (s *Store) HashboardEvents() []database.HashboardEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.hashboardEvents)
+}
+
+// AvailabilityChanges returns the availability changes recorded for a
+// miner, oldest first.
+func (s *Store) AvailabilityChanges(minerID string) []database.AvailabilityChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changes []database.AvailabilityChange
+	for _, change := range s.availability {
+		if change.MinerID == minerID {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// DriftEvents returns every recorded drift event, oldest first.
+func (s *Store) DriftEvents() []database.DriftEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.driftEvents)
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const drEventColumns = `id, source, reason, starts_at, ends_at, max_load_kw, cancelled_at, samples, violations, peak_load_kw, created_at`
+
+// CreateDREvent schedules a demand-response event and returns it.
+func (s *Store) CreateDREvent(ctx context.Context, input DREventInput) (DREvent, error) {
+	if !input.EndsAt.After(input.StartsAt) {
+		return DREvent{}, fmt.Errorf("event must end after it starts")
+	}
+	source := strings.TrimSpace(input.Source)
+	if source == "" {
+		source = "operator"
+	}
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO dr_events (source, reason, starts_at, ends_at, max_load_kw, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, source, nullableString(input.Reason), input.StartsAt.UTC(), input.EndsAt.UTC(),
+		input.MaxLoadKW, time.Now().UTC()).Scan(&id)
+	if err != nil {
+		return DREvent{}, fmt.Errorf("insert dr event: %w", err)
+	}
+	return s.GetDREvent(ctx, id)
+}
+
+// GetDREvent returns a single demand-response event.
+func (s *Store) GetDREvent(ctx context.Context, id int64) (DREvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+drEventColumns+` FROM dr_events WHERE id = ?`, id)
+	if err != nil {
+		return DREvent{}, fmt.Errorf("query dr event %d: %w", id, err)
+	}
+	events, err := scanDREvents(rows)
+	if err != nil {
+		return DREvent{}, err
+	}
+	if len(events) == 0 {
+		return DREvent{}, fmt.Errorf("dr event %d not found", id)
+	}
+	return events[0], nil
+}
+
+// ListDREvents returns events by start time, newest first.
+func (s *Store) ListDREvents(ctx context.Context, limit int) ([]DREvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+drEventColumns+`
+		FROM dr_events
+		ORDER BY starts_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query dr events: %w", err)
+	}
+	return scanDREvents(rows)
+}
+
+// ActiveDREvents returns the events that are not cancelled and whose window
+// contains at.
+func (s *Store) ActiveDREvents(ctx context.Context, at time.Time) ([]DREvent, error) {
+	at = at.UTC()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+drEventColumns+`
+		FROM dr_events
+		WHERE starts_at <= ? AND ends_at > ? AND cancelled_at IS NULL
+		ORDER BY max_load_kw, id
+	`, at, at)
+	if err != nil {
+		return nil, fmt.Errorf("query active dr events: %w", err)
+	}
+	return scanDREvents(rows)
+}
+
+// CancelDREvent marks an event cancelled. Cancelling an event twice keeps
+// the first cancellation time.
+func (s *Store) CancelDREvent(ctx context.Context, id int64) (DREvent, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE dr_events SET cancelled_at = COALESCE(cancelled_at, ?) WHERE id = ?
+	`, time.Now().UTC(), id)
+	if err != nil {
+		return DREvent{}, fmt.Errorf("cancel dr event %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return DREvent{}, fmt.Errorf("dr event %d not found", id)
+	}
+	return s.GetDREvent(ctx, id)
+}
+
+// RecordDRSample adds one balance cycle's measured load to an event's
+// compliance record.
+func (s *Store) RecordDRSample(ctx context.Context, id int64, loadKW float64, compliant bool) error {
+	violation := 0
+	if !compliant {
+		violation = 1
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE dr_events
+		SET samples = samples + 1,
+			violations = violations + ?,
+			peak_load_kw = CASE WHEN peak_load_kw IS NULL OR peak_load_kw < ? THEN ? ELSE peak_load_kw END
+		WHERE id = ?
+	`, violation, loadKW, loadKW, id)
+	if err != nil {
+		return fmt.Errorf("record dr sample for event %d: %w", id, err)
+	}
+	return nil
+}
+
+func scanDREvents(rows *sql.Rows) ([]DREvent, error) {
+	defer rows.Close()
+
+	var events []DREvent
+	for rows.Next() {
+		var (
+			event       DREvent
+			reason      sql.NullString
+			cancelledAt sql.NullTime
+			peakLoad    sql.NullFloat64
+		)
+		if err := rows.Scan(&event.ID, &event.Source, &reason, &event.StartsAt, &event.EndsAt,
+			&event.MaxLoadKW, &cancelledAt, &event.Samples, &event.Violations, &peakLoad,
+			&event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan dr event: %w", err)
+		}
+		event.Reason = stringPtrFromNull(reason)
+		event.CancelledAt = timePtrFromNull(cancelledAt)
+		event.PeakLoadKW = floatPtrFromNull(peakLoad)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate dr events: %w", err)
+	}
+	return events, nil
+}
+
+// Active reports whether the event caps consumption at t.
+func (e DREvent) Active(t time.Time) bool {
+	return e.CancelledAt == nil && !t.Before(e.StartsAt) && t.Before(e.EndsAt)
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// Dialect identifies the SQL backend a Store talks to.
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// ParseDialect normalises a configured driver name. An empty name selects SQLite.
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "sqlite", "sqlite3":
+		return DialectSQLite, nil
+	case "postgres", "postgresql", "pgx":
+		return DialectPostgres, nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q", name)
+	}
+}
+
+// PoolOptions configures the database/sql connection pool.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Open connects to the requested backend and applies the pool options. For
+// PostgreSQL the connection rewrites SQLite-style ? placeholders to $n so the
+// Store queries run unchanged on both backends.
+func Open(dialect Dialect, dsn string, pool PoolOptions) (*sql.DB, error) {
+	if strings.TrimSpace(dsn) == "" {
+		return nil, fmt.Errorf("database dsn is required")
+	}
+
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	switch dialect {
+	case DialectSQLite:
+		db, err = sql.Open("sqlite", dsn)
+		if err != nil {
+			return nil, fmt.Errorf("open sqlite: %w", err)
+		}
+	case DialectPostgres:
+		cfg, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("parse postgres dsn: %w", err)
+		}
+		db = sql.OpenDB(rebindConnector{base: stdlib.GetConnector(*cfg)})
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+
+	return db, nil
+}
+
+// rebindQuery converts ? placeholders to PostgreSQL's positional $n form,
+// leaving quoted literals untouched.
+func rebindQuery(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var (
+		b        strings.Builder
+		n        int
+		inSingle bool
+		inDouble bool
+	)
+	b.Grow(len(query) + 8)
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '?' && !inSingle && !inDouble:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+var (
+	pgAutoIncrementPK = regexp.MustCompile(`(?i)\bINTEGER\s+PRIMARY\s+KEY\s+AUTOINCREMENT\b`)
+	pgInteger         = regexp.MustCompile(`(?i)\bINTEGER\b`)
+	pgReal            = regexp.MustCompile(`(?i)\bREAL\b`)
+	pgDatetime        = regexp.MustCompile(`(?i)\bDATETIME\b`)
+	pgAddColumn       = regexp.MustCompile(`(?i)\bADD\s+COLUMN\s+`)
+	pgInsertOrIgnore  = regexp.MustCompile(`(?i)^\s*INSERT\s+OR\s+IGNORE\s+INTO\b`)
+)
+
+// translateSchema rewrites a SQLite DDL statement into its PostgreSQL
+// equivalent so both backends share a single list of schema statements.
+func translateSchema(dialect Dialect, stmt string) string {
+	if dialect != DialectPostgres {
+		return stmt
+	}
+
+	stmt = pgAutoIncrementPK.ReplaceAllString(stmt, "BIGSERIAL PRIMARY KEY")
+	stmt = pgInteger.ReplaceAllString(stmt, "BIGINT")
+	stmt = pgReal.ReplaceAllString(stmt, "DOUBLE PRECISION")
+	stmt = pgDatetime.ReplaceAllString(stmt, "TIMESTAMPTZ")
+	stmt = pgAddColumn.ReplaceAllString(stmt, "ADD COLUMN IF NOT EXISTS ")
+
+	if pgInsertOrIgnore.MatchString(stmt) {
+		stmt = pgInsertOrIgnore.ReplaceAllString(stmt, "INSERT INTO")
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";") + " ON CONFLICT DO NOTHING;"
+	}
+
+	return stmt
+}
+
+// rebindConnector wraps the pgx connector so every connection rebinds
+// placeholders before handing queries to the driver.
+type rebindConnector struct {
+	base driver.Connector
+}
+
+func (c rebindConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rebindConn{Conn: conn}, nil
+}
+
+func (c rebindConnector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+type rebindConn struct {
+	driver.Conn
+}
+
+func (c *rebindConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebindQuery(query))
+}
+
+func (c *rebindConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, rebindQuery(query))
+	}
+	return c.Conn.Prepare(rebindQuery(query))
+}
+
+func (c *rebindConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, rebindQuery(query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *rebindConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, rebindQuery(query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *rebindConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *rebindConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *rebindConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *rebindConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *rebindConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecordDriftEvent stores a settings drift event and returns it with its ID
+// set.
+func (s *Store) RecordDriftEvent(ctx context.Context, event DriftEvent) (DriftEvent, error) {
+	event.MinerID = strings.TrimSpace(event.MinerID)
+	if event.MinerID == "" {
+		return DriftEvent{}, fmt.Errorf("miner id is required")
+	}
+	if event.RecordedAt.IsZero() {
+		event.RecordedAt = time.Now().UTC()
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO drift_events (miner_id, field, desired, actual, action, success, error_message, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, event.MinerID,
+		event.Field,
+		nullableString(event.Desired),
+		nullableString(event.Actual),
+		event.Action,
+		boolToInt(event.Success),
+		nullableString(event.ErrorMessage),
+		event.RecordedAt.UTC()).Scan(&event.ID)
+	if err != nil {
+		return DriftEvent{}, fmt.Errorf("insert drift event for miner %s: %w", event.MinerID, err)
+	}
+	return event, nil
+}
+
+// ListDriftEvents returns recent settings drift events, optionally filtered
+// by miner.
+func (s *Store) ListDriftEvents(ctx context.Context, minerID *string, limit int) ([]DriftEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, miner_id, field, desired, actual, action, success, error_message, recorded_at
+		FROM drift_events
+	`
+	args := []any{}
+
+	if minerID != nil && *minerID != "" {
+		query += " WHERE miner_id = ?"
+		args = append(args, *minerID)
+	}
+
+	query += " ORDER BY recorded_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query drift events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []DriftEvent
+	for rows.Next() {
+		event, err := scanDriftEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate drift events: %w", err)
+	}
+
+	return events, nil
+}
+
+func scanDriftEvent(rows *sql.Rows) (DriftEvent, error) {
+	var (
+		event      DriftEvent
+		desired    sql.NullString
+		actual     sql.NullString
+		successInt int
+		errorMsg   sql.NullString
+	)
+	if err := rows.Scan(&event.ID, &event.MinerID, &event.Field, &desired, &actual, &event.Action,
+		&successInt, &errorMsg, &event.RecordedAt); err != nil {
+		return DriftEvent{}, fmt.Errorf("scan drift event: %w", err)
+	}
+	event.Desired = stringPtrFromNull(desired)
+	event.Actual = stringPtrFromNull(actual)
+	event.Success = successInt == 1
+	event.ErrorMessage = stringPtrFromNull(errorMsg)
+	return event, nil
+}
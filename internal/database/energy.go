@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// PowerSegment is the stretch between two consecutive power samples of one
+// source. Power is taken to change linearly across it, so its energy is
+// the trapezoid under the two samples.
+type PowerSegment struct {
+	Start   time.Time
+	End     time.Time
+	StartKW float64
+	EndKW   float64
+}
+
+// NewPowerSegment joins two consecutive samples. It reports false when the
+// samples are out of order or further apart than maxGap, which is treated
+// as missing data rather than bridged.
+func NewPowerSegment(start time.Time, startKW float64, end time.Time, endKW float64, maxGap time.Duration) (PowerSegment, bool) {
+	gap := end.Sub(start)
+	if gap <= 0 || (maxGap > 0 && gap > maxGap) {
+		return PowerSegment{}, false
+	}
+	return PowerSegment{Start: start, End: end, StartKW: startKW, EndKW: endKW}, true
+}
+
+// NewStatusSegment joins two consecutive status samples of a miner, whose
+// power is in watts, like NewPowerSegment. A snapshot and its confirmation
+// are one reading that every poll in between found unchanged, so they are
+// joined however far apart.
+func NewStatusSegment(prev, next StatusPower, maxGap time.Duration) (PowerSegment, bool) {
+	if prev.PowerConsumption == nil || next.PowerConsumption == nil {
+		return PowerSegment{}, false
+	}
+	if next.Confirmation && next.ID == prev.ID {
+		maxGap = 0
+	}
+	return NewPowerSegment(prev.RecordedAt, *prev.PowerConsumption/1000, next.RecordedAt, *next.PowerConsumption/1000, maxGap)
+}
+
+// at interpolates the segment's power at t, which must lie within it.
+func (p PowerSegment) at(t time.Time) float64 {
+	span := p.End.Sub(p.Start)
+	if span <= 0 {
+		return p.StartKW
+	}
+	frac := float64(t.Sub(p.Start)) / float64(span)
+	return p.StartKW + (p.EndKW-p.StartKW)*frac
+}
+
+// Clip returns the part of the segment within [from, to), interpolating
+// the power at the cut points. It reports false when they do not overlap.
+func (p PowerSegment) Clip(from, to time.Time) (PowerSegment, bool) {
+	start, end := p.Start, p.End
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !start.Before(end) {
+		return PowerSegment{}, false
+	}
+	return PowerSegment{Start: start, End: end, StartKW: p.at(start), EndKW: p.at(end)}, true
+}
+
+// Duration is the time the segment covers.
+func (p PowerSegment) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// KWh is the energy under the segment.
+func (p PowerSegment) KWh() float64 {
+	return (p.StartKW + p.EndKW) / 2 * p.Duration().Hours()
+}
+
+// EnergyTotal is power integrated over a window. Covered is the part of
+// the window backed by samples; gaps count neither energy nor coverage.
+type EnergyTotal struct {
+	From    time.Time
+	To      time.Time
+	KWh     float64
+	Covered time.Duration
+}
+
+func newEnergyTotal(from, to time.Time) EnergyTotal {
+	return EnergyTotal{From: from, To: to}
+}
+
+// add integrates the part of seg inside the window. Coverage is only
+// counted when countCoverage is set, so sources summed together (several
+// miners) cover the window once.
+func (e *EnergyTotal) add(seg PowerSegment, countCoverage bool) {
+	clipped, ok := seg.Clip(e.From, e.To)
+	if !ok {
+		return
+	}
+	e.KWh += clipped.KWh()
+	if countCoverage {
+		e.Covered += clipped.Duration()
+	}
+}
+
+// Coverage is the fraction of the window backed by samples.
+func (e EnergyTotal) Coverage() float64 {
+	length := e.To.Sub(e.From)
+	if length <= 0 {
+		return 0
+	}
+	return min(1, float64(e.Covered)/float64(length))
+}
+
+// AverageKW is the time-weighted mean power over the covered part of the
+// window, nil when nothing is covered.
+func (e EnergyTotal) AverageKW() *float64 {
+	if e.Covered <= 0 {
+		return nil
+	}
+	avg := e.KWh / e.Covered.Hours()
+	return &avg
+}
+
+// PlantEnergy is the plant's generation, container consumption and
+// unused available power integrated over a window.
+type PlantEnergy struct {
+	Generated EnergyTotal
+	Container EnergyTotal
+	Curtailed EnergyTotal
+}
+
+// IntegratePlantEnergy integrates plant readings over [from, to) with the
+// trapezoidal rule. Readings up to maxGap outside the window are read so
+// its edges are interpolated rather than dropped; consecutive readings
+// further apart than maxGap are treated as missing data.
+func (s *Store) IntegratePlantEnergy(ctx context.Context, from, to time.Time, maxGap time.Duration) (PlantEnergy, error) {
+	out := PlantEnergy{
+		Generated: newEnergyTotal(from, to),
+		Container: newEnergyTotal(from, to),
+		Curtailed: newEnergyTotal(from, to),
+	}
+
+	var prev *PlantReading
+	err := s.ForEachPlantReading(ctx, from.Add(-maxGap), to.Add(maxGap), func(reading PlantReading) error {
+		if prev != nil {
+			if seg, ok := NewPowerSegment(prev.RecordedAt, prev.TotalGeneration, reading.RecordedAt, reading.TotalGeneration, maxGap); ok {
+				out.Generated.add(seg, true)
+				seg.StartKW, seg.EndKW = prev.TotalContainerConsumption, reading.TotalContainerConsumption
+				out.Container.add(seg, true)
+				seg.StartKW, seg.EndKW = max(0, prev.AvailablePower), max(0, reading.AvailablePower)
+				out.Curtailed.add(seg, true)
+			}
+		}
+		prev = &reading
+		return nil
+	})
+	if err != nil {
+		return PlantEnergy{}, err
+	}
+	return out, nil
+}
+
+// IntegrateMinerEnergy integrates the power consumption miners reported in
+// their statuses over [from, to) with the trapezoidal rule, like
+// IntegratePlantEnergy. An empty minerID sums every miner; the window then
+// counts as covered where any miner has samples.
+func (s *Store) IntegrateMinerEnergy(ctx context.Context, minerID string, from, to time.Time, maxGap time.Duration) (EnergyTotal, error) {
+	out := newEnergyTotal(from, to)
+	coverage := newEnergyTotal(from, to)
+	var coveredUntil time.Time
+
+	prev := make(map[string]StatusPower)
+	err := s.forEachStatusPower(ctx, minerID, from.Add(-maxGap), to.Add(maxGap), func(sample StatusPower) error {
+		last, ok := prev[sample.MinerID]
+		prev[sample.MinerID] = sample
+		if !ok {
+			return nil
+		}
+		seg, ok := NewStatusSegment(last, sample, maxGap)
+		if !ok {
+			return nil
+		}
+		out.add(seg, false)
+
+		// Samples arrive in time order, so only the part of the segment
+		// past what is already covered adds coverage.
+		if seg.Start.Before(coveredUntil) {
+			seg.Start = coveredUntil
+		}
+		if seg.Start.Before(seg.End) {
+			coverage.add(seg, true)
+			coveredUntil = seg.End
+		}
+		return nil
+	})
+	if err != nil {
+		return EnergyTotal{}, err
+	}
+	out.Covered = coverage.Covered
+	return out, nil
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Grid protection causes: which grid-quality limit the plant crossed.
+const (
+	GridProtectionFrequency    = "frequency"
+	GridProtectionUndervoltage = "undervoltage"
+	GridProtectionOvervoltage  = "overvoltage"
+)
+
+// GridProtectionEvent records the balancer shedding load because grid
+// frequency or voltage left its limits. ClearedAt is nil while the grid is
+// still outside them.
+type GridProtectionEvent struct {
+	ID                 int64
+	Cause              string
+	FrequencyHz        *float64
+	VoltageV           *float64
+	ShedKW             float64
+	ConsumptionBeforeW float64
+	ConsumptionAfterW  float64
+	MinersAdjusted     int
+	MinersFailed       int
+	StartedAt          time.Time
+	ClearedAt          *time.Time
+}
+
+const gridProtectionColumns = `id, cause, frequency_hz, voltage_v, shed_kw, consumption_before_w, consumption_after_w, miners_adjusted, miners_failed, started_at, cleared_at`
+
+// RecordGridProtectionEvent stores a load shed.
+func (s *Store) RecordGridProtectionEvent(ctx context.Context, event GridProtectionEvent) (GridProtectionEvent, error) {
+	if event.StartedAt.IsZero() {
+		event.StartedAt = time.Now().UTC()
+	}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO grid_protection_events (cause, frequency_hz, voltage_v, shed_kw, consumption_before_w, consumption_after_w, miners_adjusted, miners_failed, started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, event.Cause, nullableFloat64(event.FrequencyHz), nullableFloat64(event.VoltageV), event.ShedKW,
+		event.ConsumptionBeforeW, event.ConsumptionAfterW, event.MinersAdjusted, event.MinersFailed,
+		event.StartedAt.UTC()).Scan(&event.ID)
+	if err != nil {
+		return GridProtectionEvent{}, fmt.Errorf("insert grid protection event: %w", err)
+	}
+	return event, nil
+}
+
+// CurrentGridProtectionEvent returns the event not yet cleared, or nil.
+// It survives restarts, so a restart during a grid disturbance does not
+// shed a second block.
+func (s *Store) CurrentGridProtectionEvent(ctx context.Context) (*GridProtectionEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+gridProtectionColumns+`
+		FROM grid_protection_events
+		WHERE cleared_at IS NULL
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query open grid protection event: %w", err)
+	}
+	events, err := scanGridProtectionEvents(rows)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return &events[0], nil
+}
+
+// ClearGridProtectionEvents marks every open event cleared at clearedAt
+// and returns them.
+func (s *Store) ClearGridProtectionEvents(ctx context.Context, clearedAt time.Time) ([]GridProtectionEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+gridProtectionColumns+` FROM grid_protection_events WHERE cleared_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("query open grid protection events: %w", err)
+	}
+	events, err := scanGridProtectionEvents(rows)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE grid_protection_events SET cleared_at = ? WHERE cleared_at IS NULL`, clearedAt.UTC()); err != nil {
+		return nil, fmt.Errorf("clear grid protection events: %w", err)
+	}
+	for i := range events {
+		cleared := clearedAt
+		events[i].ClearedAt = &cleared
+	}
+	return events, nil
+}
+
+// ListGridProtectionEvents returns up to limit events, newest first.
+func (s *Store) ListGridProtectionEvents(ctx context.Context, limit int) ([]GridProtectionEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+gridProtectionColumns+`
+		FROM grid_protection_events
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query grid protection events: %w", err)
+	}
+	return scanGridProtectionEvents(rows)
+}
+
+func scanGridProtectionEvents(rows *sql.Rows) ([]GridProtectionEvent, error) {
+	defer rows.Close()
+
+	var events []GridProtectionEvent
+	for rows.Next() {
+		var (
+			event              GridProtectionEvent
+			frequency, voltage sql.NullFloat64
+			clearedAt          sql.NullTime
+		)
+		if err := rows.Scan(&event.ID, &event.Cause, &frequency, &voltage, &event.ShedKW, &event.ConsumptionBeforeW,
+			&event.ConsumptionAfterW, &event.MinersAdjusted, &event.MinersFailed, &event.StartedAt, &clearedAt); err != nil {
+			return nil, fmt.Errorf("scan grid protection event: %w", err)
+		}
+		event.FrequencyHz = floatPtrFromNull(frequency)
+		event.VoltageV = floatPtrFromNull(voltage)
+		event.ClearedAt = timePtrFromNull(clearedAt)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate grid protection events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecordHashboardEvent stores a hashboard watchdog action and returns it
+// with its ID set.
+func (s *Store) RecordHashboardEvent(ctx context.Context, event HashboardEvent) (HashboardEvent, error) {
+	event.MinerID = strings.TrimSpace(event.MinerID)
+	if event.MinerID == "" {
+		return HashboardEvent{}, fmt.Errorf("miner id is required")
+	}
+	if event.RecordedAt.IsZero() {
+		event.RecordedAt = time.Now().UTC()
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO hashboard_events (miner_id, chain_identifier, action, attempt, success, error_message, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, event.MinerID,
+		nullableString(event.ChainIdentifier),
+		event.Action,
+		event.Attempt,
+		boolToInt(event.Success),
+		nullableString(event.ErrorMessage),
+		event.RecordedAt.UTC()).Scan(&event.ID)
+	if err != nil {
+		return HashboardEvent{}, fmt.Errorf("insert hashboard event for miner %s: %w", event.MinerID, err)
+	}
+	return event, nil
+}
+
+// ListHashboardEvents returns recent hashboard watchdog events, optionally
+// filtered by miner.
+func (s *Store) ListHashboardEvents(ctx context.Context, minerID *string, limit int) ([]HashboardEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, miner_id, chain_identifier, action, attempt, success, error_message, recorded_at
+		FROM hashboard_events
+	`
+	args := []any{}
+
+	if minerID != nil && *minerID != "" {
+		query += " WHERE miner_id = ?"
+		args = append(args, *minerID)
+	}
+
+	query += " ORDER BY recorded_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query hashboard events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []HashboardEvent
+	for rows.Next() {
+		event, err := scanHashboardEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate hashboard events: %w", err)
+	}
+
+	return events, nil
+}
+
+func scanHashboardEvent(rows *sql.Rows) (HashboardEvent, error) {
+	var (
+		event      HashboardEvent
+		chainID    sql.NullString
+		successInt int
+		errorMsg   sql.NullString
+	)
+	if err := rows.Scan(&event.ID, &event.MinerID, &chainID, &event.Action, &event.Attempt,
+		&successInt, &errorMsg, &event.RecordedAt); err != nil {
+		return HashboardEvent{}, fmt.Errorf("scan hashboard event: %w", err)
+	}
+	event.ChainIdentifier = stringPtrFromNull(chainID)
+	event.Success = successInt == 1
+	event.ErrorMessage = stringPtrFromNull(errorMsg)
+	return event, nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"strings"
+	"time"
 )
 
 func boolToInt(b bool) int {
@@ -89,3 +90,18 @@ func floatPtrFromNull(ns sql.NullFloat64) *float64 {
 	value := ns.Float64
 	return &value
 }
+
+func nullableTime(value *time.Time) any {
+	if value == nil {
+		return nil
+	}
+	return value.UTC()
+}
+
+func timePtrFromNull(nt sql.NullTime) *time.Time {
+	if !nt.Valid {
+		return nil
+	}
+	value := nt.Time
+	return &value
+}
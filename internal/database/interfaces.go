@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// The interfaces below split the Store by role so services can depend on
+// just the queries they use and be exercised against the in-memory fakes in
+// package databasetest. *Store implements all of them.
+
+// MinerReader reads the miner inventory.
+type MinerReader interface {
+	ListMiners(ctx context.Context) ([]Miner, error)
+	GetMiner(ctx context.Context, minerID string) (Miner, error)
+}
+
+// MinerWriter adds miners and updates their identity and desired settings.
+type MinerWriter interface {
+	UpsertMiner(ctx context.Context, params UpsertMinerParams) (Miner, error)
+	SaveMinerSettings(ctx context.Context, minerID string, input SettingsInput) (Settings, error)
+}
+
+// MinerIdentityReader finds miners by an identity other than their ID.
+type MinerIdentityReader interface {
+	FindMinerBySerial(ctx context.Context, serial string) (*Miner, error)
+}
+
+// MinerStore reads and writes miners.
+type MinerStore interface {
+	MinerReader
+	MinerWriter
+}
+
+// ModelStore maintains miner models and their preset metrics.
+type ModelStore interface {
+	UpsertModel(ctx context.Context, input ModelInput) (Model, error)
+	GetModelByAlias(ctx context.Context, alias string) (Model, error)
+	GetAllModelPresets(ctx context.Context) (map[string][]ModelPreset, error)
+	UpdatePresetMetrics(ctx context.Context, modelAlias, presetValue string, powerW, hashrateTH *float64) error
+	BackfillPresetHashrates(ctx context.Context, since time.Time, minSamples int) (int, error)
+}
+
+// StatusWriter records status polls and their outcome.
+type StatusWriter interface {
+	RecordMinerStatus(ctx context.Context, minerID string, input MinerStatusInput) (Status, error)
+	ConfirmMinerStatus(ctx context.Context, minerID string, statusID int64, confirmedAt time.Time) error
+	RecordPollResult(ctx context.Context, minerID string, pollErr error) (PollReliability, error)
+}
+
+// AvailabilityRecorder records miners going online and offline.
+type AvailabilityRecorder interface {
+	RecordMinerAvailability(ctx context.Context, change AvailabilityChange) (bool, error)
+}
+
+// TelemetryWriter records chain and chip telemetry.
+type TelemetryWriter interface {
+	RecordChainTelemetry(ctx context.Context, minerID string, recordedAt time.Time, chains []ChainSnapshotInput) error
+}
+
+// PlantReader reads plant generation and consumption history.
+type PlantReader interface {
+	GetLatestPlantReading(ctx context.Context) (*PlantReading, error)
+	ForEachPlantReading(ctx context.Context, from, to time.Time, fn func(PlantReading) error) error
+}
+
+// PlantWriter records plant readings and quarantines rejected ones.
+type PlantWriter interface {
+	RecordPlantReading(ctx context.Context, input PlantReadingInput) (PlantReading, error)
+	QuarantinePlantReading(ctx context.Context, input QuarantinedReadingInput) error
+}
+
+// PlantGapRecorder opens, alerts on and closes stretches without usable
+// plant readings.
+type PlantGapRecorder interface {
+	OpenPlantGap(ctx context.Context, reason string, lastReadingAt *time.Time, startedAt time.Time, lastError *string) (PlantGap, error)
+	MarkPlantGapAlerted(ctx context.Context, id int64) error
+	ClosePlantGaps(ctx context.Context, endedAt time.Time) ([]PlantGap, error)
+}
+
+// PlantMetricsRecorder stores the daily plant metrics and reads the
+// balance cycles they are derived from.
+type PlantMetricsRecorder interface {
+	ListBalanceCycles(ctx context.Context, from, to time.Time, limit int) ([]BalanceCycle, error)
+	SavePlantDailyMetrics(ctx context.Context, metrics PlantDailyMetrics) error
+	ListPlantDailyMetrics(ctx context.Context, timezone, fromDay, toDay string) ([]PlantDailyMetrics, error)
+}
+
+// GridProtectionRecorder records the load shed on grid disturbances.
+type GridProtectionRecorder interface {
+	RecordGridProtectionEvent(ctx context.Context, event GridProtectionEvent) (GridProtectionEvent, error)
+	CurrentGridProtectionEvent(ctx context.Context) (*GridProtectionEvent, error)
+	ClearGridProtectionEvents(ctx context.Context, clearedAt time.Time) ([]GridProtectionEvent, error)
+}
+
+// PowerMeasurementStore records and reads the power measured at PDU
+// outlets.
+type PowerMeasurementStore interface {
+	RecordPowerMeasurements(ctx context.Context, measurements []PowerMeasurement) error
+	LatestPowerMeasurements(ctx context.Context, since time.Time) (map[string]PowerMeasurement, error)
+}
+
+// ReconciliationStore records and reads container meter reconciliations.
+type ReconciliationStore interface {
+	RecordContainerReconciliations(ctx context.Context, reconciliations []ContainerReconciliation) error
+	ListContainerReconciliations(ctx context.Context, from, to time.Time) ([]ContainerReconciliation, error)
+	LatestContainerReconciliations(ctx context.Context) ([]ContainerReconciliation, error)
+}
+
+// BalanceRecorder records what the balancer did and reads it back.
+type BalanceRecorder interface {
+	RecordPowerBalanceEvent(ctx context.Context, input PowerBalanceEventInput) (PowerBalanceEvent, error)
+	ListPowerBalanceEvents(ctx context.Context, minerID *string, limit int) ([]PowerBalanceEvent, error)
+	RecordBalanceCycle(ctx context.Context, input BalanceCycleInput) (BalanceCycle, error)
+	RecordPresetChange(ctx context.Context, minerID string, at time.Time) error
+	CountPresetChanges(ctx context.Context, since time.Time) (map[string]int, error)
+}
+
+// EventRecorder records hashboard watchdog and settings drift events.
+type EventRecorder interface {
+	RecordHashboardEvent(ctx context.Context, event HashboardEvent) (HashboardEvent, error)
+	RecordDriftEvent(ctx context.Context, event DriftEvent) (DriftEvent, error)
+}
+
+// KeyRotationRecorder records API key rotations and when each miner's key
+// was last replaced.
+type KeyRotationRecorder interface {
+	RecordKeyRotation(ctx context.Context, rotation KeyRotation) (KeyRotation, error)
+	LastKeyRotations(ctx context.Context) (map[string]time.Time, error)
+}
+
+// RebootRecorder opens and closes a miner's planned reboot window.
+type RebootRecorder interface {
+	SetRebootWindow(ctx context.Context, minerID string, until *time.Time) error
+}
+
+// UnlockCandidateReader lists the passwords to try, in order, when
+// unlocking a miner.
+type UnlockCandidateReader interface {
+	UnlockCandidates(ctx context.Context, miner Miner) ([]UnlockCandidate, error)
+}
+
+// SettingsStore reads and writes fleet-wide settings.
+type SettingsStore interface {
+	GetAppSetting(ctx context.Context, key string) (string, error)
+	SetAppSetting(ctx context.Context, key, value string) error
+	GetThermalLimits(ctx context.Context) (ThermalLimits, error)
+	GetBatteryPolicy(ctx context.Context) (BatteryPolicy, error)
+	GetBalanceMode(ctx context.Context) (BalanceMode, error)
+	GetBalancerPause(ctx context.Context) (BalancerPause, error)
+}
+
+// DemandResponseStore reads active demand response events and records
+// compliance samples.
+type DemandResponseStore interface {
+	ActiveDREvents(ctx context.Context, at time.Time) ([]DREvent, error)
+	RecordDRSample(ctx context.Context, id int64, loadKW float64, compliant bool) error
+}
+
+// ProfileReader reads configuration profiles.
+type ProfileReader interface {
+	GetConfigProfile(ctx context.Context, id int64) (ConfigProfile, error)
+}
+
+// Maintainer runs database housekeeping and backups.
+type Maintainer interface {
+	Dialect() Dialect
+	BackupTo(ctx context.Context, path string) error
+	CheckpointWAL(ctx context.Context) (WALCheckpoint, error)
+	WALSize(ctx context.Context) (int64, error)
+	Optimize(ctx context.Context) error
+	IntegrityCheck(ctx context.Context) ([]string, error)
+}
+
+var (
+	_ MinerReader          = (*Store)(nil)
+	_ MinerWriter          = (*Store)(nil)
+	_ ModelStore           = (*Store)(nil)
+	_ StatusWriter         = (*Store)(nil)
+	_ AvailabilityRecorder = (*Store)(nil)
+	_ TelemetryWriter      = (*Store)(nil)
+	_ PlantReader          = (*Store)(nil)
+	_ PlantWriter          = (*Store)(nil)
+	_ BalanceRecorder      = (*Store)(nil)
+	_ EventRecorder        = (*Store)(nil)
+	_ SettingsStore        = (*Store)(nil)
+	_ DemandResponseStore  = (*Store)(nil)
+	_ ProfileReader        = (*Store)(nil)
+	_ Maintainer           = (*Store)(nil)
+)
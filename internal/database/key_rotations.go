@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecordKeyRotation stores the outcome of an API key rotation.
+func (s *Store) RecordKeyRotation(ctx context.Context, rotation KeyRotation) (KeyRotation, error) {
+	rotation.MinerID = strings.TrimSpace(rotation.MinerID)
+	if rotation.MinerID == "" {
+		return KeyRotation{}, fmt.Errorf("miner id is required")
+	}
+	if rotation.RotatedAt.IsZero() {
+		rotation.RotatedAt = time.Now().UTC()
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_key_rotations (miner_id, triggered_by, success, error_message, rotated_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`, rotation.MinerID,
+		rotation.Trigger,
+		boolToInt(rotation.Success),
+		nullableString(rotation.ErrorMessage),
+		rotation.RotatedAt.UTC()).Scan(&rotation.ID)
+	if err != nil {
+		return KeyRotation{}, fmt.Errorf("insert key rotation for miner %s: %w", rotation.MinerID, err)
+	}
+	return rotation, nil
+}
+
+// ListKeyRotations returns recent key rotations, optionally filtered by
+// miner.
+func (s *Store) ListKeyRotations(ctx context.Context, minerID *string, limit int) ([]KeyRotation, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, miner_id, triggered_by, success, error_message, rotated_at
+		FROM api_key_rotations
+	`
+	args := []any{}
+
+	if minerID != nil && *minerID != "" {
+		query += " WHERE miner_id = ?"
+		args = append(args, *minerID)
+	}
+
+	query += " ORDER BY rotated_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query key rotations: %w", err)
+	}
+	defer rows.Close()
+
+	var rotations []KeyRotation
+	for rows.Next() {
+		rotation, err := scanKeyRotation(rows)
+		if err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, rotation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate key rotations: %w", err)
+	}
+	return rotations, nil
+}
+
+func scanKeyRotation(rows *sql.Rows) (KeyRotation, error) {
+	var (
+		rotation   KeyRotation
+		success    int
+		errMessage sql.NullString
+	)
+	if err := rows.Scan(&rotation.ID, &rotation.MinerID, &rotation.Trigger, &success, &errMessage, &rotation.RotatedAt); err != nil {
+		return KeyRotation{}, fmt.Errorf("scan key rotation: %w", err)
+	}
+	rotation.Success = success != 0
+	rotation.ErrorMessage = stringPtrFromNull(errMessage)
+	return rotation, nil
+}
+
+// LastKeyRotations returns when each miner's API key was last replaced
+// successfully. Miners whose key was never rotated are absent.
+func (s *Store) LastKeyRotations(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.miner_id, r.rotated_at
+		FROM api_key_rotations r
+		WHERE r.id = (
+			SELECT id FROM api_key_rotations
+			WHERE miner_id = r.miner_id AND success = 1
+			ORDER BY rotated_at DESC, id DESC
+			LIMIT 1
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query last key rotations: %w", err)
+	}
+	defer rows.Close()
+
+	last := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			minerID string
+			at      time.Time
+		)
+		if err := rows.Scan(&minerID, &at); err != nil {
+			return nil, fmt.Errorf("scan last key rotation: %w", err)
+		}
+		last[minerID] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate last key rotations: %w", err)
+	}
+	return last, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LeaderLease is the current holder of a named lease.
+type LeaderLease struct {
+	Name       string
+	Holder     string
+	AcquiredAt time.Time
+	RenewedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// AcquireLease takes the named lease for holder until now+ttl, or renews it
+// if holder already has it. It reports false when another holder's lease
+// has not yet expired. Instances sharing a database use it to elect one
+// leader.
+func (s *Store) AcquireLease(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	name = strings.TrimSpace(name)
+	holder = strings.TrimSpace(holder)
+	if name == "" || holder == "" {
+		return false, fmt.Errorf("lease name and holder are required")
+	}
+	if ttl <= 0 {
+		return false, fmt.Errorf("lease ttl must be positive")
+	}
+
+	now = now.UTC()
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO leader_leases (name, holder, acquired_at, renewed_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			holder = excluded.holder,
+			acquired_at = CASE WHEN leader_leases.holder = excluded.holder THEN leader_leases.acquired_at ELSE excluded.acquired_at END,
+			renewed_at = excluded.renewed_at,
+			expires_at = excluded.expires_at
+		WHERE leader_leases.holder = excluded.holder OR leader_leases.expires_at <= ?
+	`, name, holder, now, now, now.Add(ttl), now)
+	if err != nil {
+		return false, fmt.Errorf("acquire lease %q: %w", name, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("acquire lease %q: %w", name, err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseLease gives up the named lease if holder has it, so a standby can
+// take over without waiting for it to expire.
+func (s *Store) ReleaseLease(ctx context.Context, name, holder string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM leader_leases WHERE name = ? AND holder = ?`, name, holder)
+	if err != nil {
+		return fmt.Errorf("release lease %q: %w", name, err)
+	}
+	return nil
+}
+
+// GetLease returns the named lease, expired or not.
+func (s *Store) GetLease(ctx context.Context, name string) (LeaderLease, error) {
+	lease := LeaderLease{Name: name}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT holder, acquired_at, renewed_at, expires_at
+		FROM leader_leases
+		WHERE name = ?
+	`, name).Scan(&lease.Holder, &lease.AcquiredAt, &lease.RenewedAt, &lease.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return LeaderLease{}, fmt.Errorf("lease %q not found", name)
+		}
+		return LeaderLease{}, fmt.Errorf("query lease %q: %w", name, err)
+	}
+	return lease, nil
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// integrityCheckMaxErrors caps how many problems PRAGMA integrity_check
+// reports before it stops.
+const integrityCheckMaxErrors = 100
+
+// WALCheckpoint is the outcome of a WAL checkpoint. Busy is set when a
+// reader or writer kept the checkpoint from completing; the remaining frames
+// are copied by a later run.
+type WALCheckpoint struct {
+	Busy               bool
+	LogFrames          int
+	CheckpointedFrames int
+}
+
+// MaintenanceReport summarizes the latest database maintenance runs for the
+// health endpoint. Nil times mean the task has not run since startup.
+type MaintenanceReport struct {
+	CheckpointAt      *time.Time
+	Checkpoint        *WALCheckpoint
+	WALBytes          *int64
+	OptimizeAt        *time.Time
+	IntegrityCheckAt  *time.Time
+	IntegrityProblems []string
+	// Errors holds the error of each task whose latest run failed, keyed
+	// by task name.
+	Errors map[string]string
+}
+
+// Healthy reports whether the last integrity check found no problems and no
+// task's latest run failed.
+func (r MaintenanceReport) Healthy() bool {
+	return len(r.IntegrityProblems) == 0 && len(r.Errors) == 0
+}
+
+// CheckpointWAL copies the write-ahead log into the database file and
+// truncates it. Only SQLite is supported.
+func (s *Store) CheckpointWAL(ctx context.Context) (WALCheckpoint, error) {
+	if s.dialect != DialectSQLite {
+		return WALCheckpoint{}, fmt.Errorf("wal checkpoint is not supported for %s", s.dialect)
+	}
+
+	var (
+		busy       int
+		checkpoint WALCheckpoint
+	)
+	err := s.db.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &checkpoint.LogFrames, &checkpoint.CheckpointedFrames)
+	if err != nil {
+		return WALCheckpoint{}, fmt.Errorf("wal checkpoint: %w", err)
+	}
+	checkpoint.Busy = busy != 0
+	return checkpoint, nil
+}
+
+// WALSize returns the size of the SQLite write-ahead log in bytes, zero when
+// there is none.
+func (s *Store) WALSize(ctx context.Context) (int64, error) {
+	if s.dialect != DialectSQLite {
+		return 0, fmt.Errorf("wal size is not supported for %s", s.dialect)
+	}
+
+	var file string
+	err := s.db.QueryRowContext(ctx, `SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&file)
+	if err != nil {
+		return 0, fmt.Errorf("locate database file: %w", err)
+	}
+	if file == "" {
+		// In-memory databases have no log on disk.
+		return 0, nil
+	}
+
+	info, err := os.Stat(file + "-wal")
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("stat wal file: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// Optimize refreshes the query planner statistics: PRAGMA optimize on
+// SQLite, ANALYZE on PostgreSQL.
+func (s *Store) Optimize(ctx context.Context) error {
+	query := `PRAGMA optimize`
+	if s.dialect == DialectPostgres {
+		query = `ANALYZE`
+	}
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("optimize: %w", err)
+	}
+	return nil
+}
+
+// IntegrityCheck runs PRAGMA integrity_check and returns the problems it
+// found, nil when the database is intact. Only SQLite is supported.
+func (s *Store) IntegrityCheck(ctx context.Context) ([]string, error) {
+	if s.dialect != DialectSQLite {
+		return nil, fmt.Errorf("integrity check is not supported for %s", s.dialect)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`PRAGMA integrity_check(%d)`, integrityCheckMaxErrors))
+	if err != nil {
+		return nil, fmt.Errorf("integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line sql.NullString
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("scan integrity check: %w", err)
+		}
+		if text := strings.TrimSpace(line.String); text != "" && text != "ok" {
+			problems = append(problems, text)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate integrity check: %w", err)
+	}
+	return problems, nil
+}
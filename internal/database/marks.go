@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// History tables LatestRecord can inspect.
+const (
+	HistoryStatuses      = "statuses"
+	HistoryTelemetry     = "chain_snapshots"
+	HistoryPlantReadings = "plant_readings"
+	HistoryBalanceEvents = "power_balance_events"
+)
+
+var historyTables = map[string]bool{
+	HistoryStatuses:      true,
+	HistoryTelemetry:     true,
+	HistoryPlantReadings: true,
+	HistoryBalanceEvents: true,
+}
+
+// updatedColumns names the column stamped when a row of a history table is
+// changed in place: a status confirmed by an unchanged poll, or a balance
+// event acknowledged or annotated.
+var updatedColumns = map[string]string{
+	HistoryStatuses:      "last_confirmed_at",
+	HistoryBalanceEvents: "annotated_at",
+}
+
+// RecordMark identifies the newest row of a history table. It changes
+// whenever a row is appended, so callers can tell whether a listing is
+// unchanged without loading it. The zero value means the table is empty.
+// Statuses and balance events can also change after they are recorded;
+// UpdatedAt is the last time any of them did.
+type RecordMark struct {
+	ID         int64
+	RecordedAt time.Time
+	UpdatedAt  time.Time
+}
+
+// LatestRecord returns the mark of the newest row in table, restricted to
+// one miner when minerID is set. Plant readings have no miner and ignore it.
+func (s *Store) LatestRecord(ctx context.Context, table string, minerID *string) (RecordMark, error) {
+	if !historyTables[table] {
+		return RecordMark{}, fmt.Errorf("unknown history table %q", table)
+	}
+
+	query := `SELECT id, recorded_at FROM ` + table
+	var args []any
+	if minerID != nil && table != HistoryPlantReadings {
+		query += ` WHERE miner_id = ?`
+		args = append(args, *minerID)
+	}
+	query += ` ORDER BY recorded_at DESC, id DESC LIMIT 1`
+
+	var mark RecordMark
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&mark.ID, &mark.RecordedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RecordMark{}, nil
+	}
+	if err != nil {
+		return RecordMark{}, fmt.Errorf("query latest %s: %w", table, err)
+	}
+
+	if column, ok := updatedColumns[table]; ok {
+		query := `SELECT ` + column + ` FROM ` + table + ` WHERE ` + column + ` IS NOT NULL`
+		if minerID != nil {
+			query += ` AND miner_id = ?`
+		}
+		query += ` ORDER BY ` + column + ` DESC LIMIT 1`
+		err := s.db.QueryRowContext(ctx, query, args...).Scan(&mark.UpdatedAt)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return RecordMark{}, fmt.Errorf("query latest update of %s: %w", table, err)
+		}
+	}
+	return mark, nil
+}
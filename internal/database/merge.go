@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FindMinerBySerial returns the miner with the given serial number, nil
+// when none has it. Unarchived miners are preferred, then the oldest.
+func (s *Store) FindMinerBySerial(ctx context.Context, serial string) (*Miner, error) {
+	serial = strings.TrimSpace(serial)
+	if serial == "" {
+		return nil, nil
+	}
+
+	var minerID string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id FROM miners
+		WHERE serial = ?
+		ORDER BY CASE WHEN archived_at IS NULL THEN 0 ELSE 1 END, created_at, id
+		LIMIT 1
+	`, serial).Scan(&minerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find miner by serial %s: %w", serial, err)
+	}
+
+	miner, err := s.GetMiner(ctx, minerID)
+	if err != nil {
+		return nil, err
+	}
+	return &miner, nil
+}
+
+// mergedMinerColumns are copied from the merged miner when the kept miner
+// has no value of its own.
+var mergedMinerColumns = []string{
+	"ip", "api_key", "api_scheme", "api_port", "fw_name", "fw_version",
+	"name", "location", "tags", "customer_id", "serial", "model_id", "settings_id",
+}
+
+// MergeMiners folds the duplicate record sourceID into targetID: its
+// statuses, telemetry, events, availability and key rotation history move
+// to the target, labels and identity the target lacks are copied over, and
+// the source is deleted. The target's latest status becomes the newest of
+// both.
+func (s *Store) MergeMiners(ctx context.Context, targetID, sourceID string) (Miner, error) {
+	targetID = strings.TrimSpace(targetID)
+	sourceID = strings.TrimSpace(sourceID)
+	if targetID == sourceID {
+		return Miner{}, fmt.Errorf("cannot merge miner %s into itself", targetID)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Miner{}, fmt.Errorf("begin merge miners tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var targetSettings, sourceSettings sql.NullInt64
+	for _, row := range []struct {
+		id       string
+		settings *sql.NullInt64
+	}{{targetID, &targetSettings}, {sourceID, &sourceSettings}} {
+		if err := tx.QueryRowContext(ctx, `SELECT settings_id FROM miners WHERE id = ?`, row.id).Scan(row.settings); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return Miner{}, fmt.Errorf("miner %s not found", row.id)
+			}
+			return Miner{}, fmt.Errorf("query miner %s: %w", row.id, err)
+		}
+	}
+
+	sets := make([]string, 0, len(mergedMinerColumns))
+	args := make([]any, 0, len(mergedMinerColumns)+1)
+	for _, column := range mergedMinerColumns {
+		sets = append(sets, fmt.Sprintf("%[1]s = COALESCE(%[1]s, (SELECT %[1]s FROM miners WHERE id = ?))", column))
+		args = append(args, sourceID)
+	}
+	sets = append(sets, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, targetID)
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE miners SET %s WHERE id = ?", strings.Join(sets, ", ")), args...); err != nil {
+		return Miner{}, fmt.Errorf("merge miner %s into %s: %w", sourceID, targetID, err)
+	}
+
+	statements := []string{
+		`UPDATE miners SET latest_status_id = NULL, settings_id = NULL WHERE id = ?`,
+		`UPDATE statuses SET miner_id = ? WHERE miner_id = ?`,
+		`UPDATE chain_snapshots SET miner_id = ? WHERE miner_id = ?`,
+		`UPDATE power_balance_events SET miner_id = ? WHERE miner_id = ?`,
+		`UPDATE hashboard_events SET miner_id = ? WHERE miner_id = ?`,
+		`UPDATE drift_events SET miner_id = ? WHERE miner_id = ?`,
+		`UPDATE miner_availability SET miner_id = ? WHERE miner_id = ?`,
+		`UPDATE api_key_rotations SET miner_id = ? WHERE miner_id = ?`,
+		`UPDATE power_measurements SET miner_id = ? WHERE miner_id = ?`,
+		// A cycle that skipped both records keeps the target's row.
+		`UPDATE balance_cycle_skips SET miner_id = ? WHERE miner_id = ? AND cycle_id NOT IN (SELECT cycle_id FROM balance_cycle_skips WHERE miner_id = ?)`,
+		`DELETE FROM balance_cycle_skips WHERE miner_id = ?`,
+		`UPDATE miners SET latest_status_id = (SELECT id FROM statuses WHERE miner_id = ? ORDER BY recorded_at DESC, id DESC LIMIT 1) WHERE id = ?`,
+		`DELETE FROM miners WHERE id = ?`,
+	}
+	statementArgs := [][]any{
+		{sourceID},
+		{targetID, sourceID},
+		{targetID, sourceID},
+		{targetID, sourceID},
+		{targetID, sourceID},
+		{targetID, sourceID},
+		{targetID, sourceID},
+		{targetID, sourceID},
+		{targetID, sourceID, targetID},
+		{sourceID},
+		{targetID, targetID},
+		{sourceID},
+	}
+	for i, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt, statementArgs[i]...); err != nil {
+			return Miner{}, fmt.Errorf("merge miner %s into %s: %w", sourceID, targetID, err)
+		}
+	}
+
+	// The source's settings snapshot is dropped unless the target took it.
+	if sourceSettings.Valid && targetSettings.Valid {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM settings_pools WHERE settings_id = ?`, sourceSettings.Int64); err != nil {
+			return Miner{}, fmt.Errorf("delete settings of miner %s: %w", sourceID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM settings WHERE id = ?`, sourceSettings.Int64); err != nil {
+			return Miner{}, fmt.Errorf("delete settings of miner %s: %w", sourceID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Miner{}, fmt.Errorf("commit merge miners tx: %w", err)
+	}
+	return s.GetMiner(ctx, targetID)
+}
@@ -3,9 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
 // UpsertMiner ensures a miner row exists and applies the provided updates.
@@ -21,7 +24,13 @@ func (s *Store) UpsertMiner(ctx context.Context, params UpsertMinerParams) (Mine
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	if _, err := tx.ExecContext(ctx, `INSERT INTO miners (id) VALUES (?) ON CONFLICT(id) DO NOTHING`, minerID); err != nil {
+	unlockDefault := defaultUnlockPass
+	defaultPass, err := s.sealSecret(&unlockDefault)
+	if err != nil {
+		return Miner{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO miners (id, unlock_pass) VALUES (?, ?) ON CONFLICT(id) DO NOTHING`, minerID, defaultPass); err != nil {
 		return Miner{}, fmt.Errorf("ensure miner %s: %w", minerID, err)
 	}
 
@@ -45,8 +54,12 @@ func (s *Store) UpsertMiner(ctx context.Context, params UpsertMinerParams) (Mine
 		if apiKey == "" {
 			sets = append(sets, "api_key = NULL")
 		} else {
+			sealed, err := s.sealSecret(&apiKey)
+			if err != nil {
+				return Miner{}, err
+			}
 			sets = append(sets, "api_key = ?")
-			args = append(args, apiKey)
+			args = append(args, sealed)
 		}
 	}
 
@@ -60,8 +73,93 @@ func (s *Store) UpsertMiner(ctx context.Context, params UpsertMinerParams) (Mine
 		if pass == "" {
 			return Miner{}, fmt.Errorf("unlock password cannot be empty")
 		}
+		sealed, err := s.sealSecret(&pass)
+		if err != nil {
+			return Miner{}, err
+		}
 		sets = append(sets, "unlock_pass = ?")
-		args = append(args, pass)
+		args = append(args, sealed)
+	}
+
+	if params.FWName != nil {
+		sets = append(sets, "fw_name = ?")
+		args = append(args, nullableTrimmedString(params.FWName))
+	}
+
+	if params.FWVersion != nil {
+		sets = append(sets, "fw_version = ?")
+		args = append(args, nullableTrimmedString(params.FWVersion))
+	}
+
+	if params.Driver != nil {
+		driver := strings.ToLower(strings.TrimSpace(*params.Driver))
+		if driver == "" {
+			return Miner{}, fmt.Errorf("driver cannot be empty")
+		}
+		sets = append(sets, "driver = ?")
+		args = append(args, driver)
+	}
+
+	if params.APIPort != nil {
+		sets = append(sets, "api_scheme = ?", "api_port = ?")
+		args = append(args, nullableTrimmedString(params.APIScheme), *params.APIPort)
+	}
+
+	if params.Name != nil {
+		sets = append(sets, "name = ?")
+		args = append(args, nullableTrimmedString(params.Name))
+	}
+
+	if params.Location != nil {
+		sets = append(sets, "location = ?")
+		args = append(args, nullableTrimmedString(params.Location))
+	}
+
+	if params.Tags != nil {
+		tags, err := encodeTags(*params.Tags)
+		if err != nil {
+			return Miner{}, err
+		}
+		sets = append(sets, "tags = ?")
+		args = append(args, tags)
+	}
+
+	if params.Hold != nil {
+		sets = append(sets, "hold = ?", "hold_until = ?")
+		var until *time.Time
+		if *params.Hold {
+			until = params.HoldUntil
+		}
+		args = append(args, boolToInt(*params.Hold), nullableTime(until))
+	}
+
+	if params.Protected != nil {
+		sets = append(sets, "protected = ?")
+		args = append(args, boolToInt(*params.Protected))
+	}
+
+	if params.CustomerID != nil {
+		if *params.CustomerID == 0 {
+			sets = append(sets, "customer_id = NULL")
+		} else {
+			var exists int
+			err := tx.QueryRowContext(ctx, `SELECT 1 FROM customers WHERE id = ?`, *params.CustomerID).Scan(&exists)
+			if errors.Is(err, sql.ErrNoRows) {
+				return Miner{}, fmt.Errorf("customer %d not found", *params.CustomerID)
+			}
+			if err != nil {
+				return Miner{}, fmt.Errorf("look up customer %d: %w", *params.CustomerID, err)
+			}
+			sets = append(sets, "customer_id = ?")
+			args = append(args, *params.CustomerID)
+		}
+	}
+
+	if params.Serial != nil {
+		if serial := strings.TrimSpace(*params.Serial); serial != "" {
+			sets = append(sets, "serial = ?")
+			args = append(args, serial)
+		}
 	}
 
 	if params.ModelAlias != nil {
@@ -112,13 +210,31 @@ func (s *Store) GetMiner(ctx context.Context, minerID string) (Miner, error) {
 		latestStatusID sql.NullInt64
 		managedInt     int
 		unlockPass     string
+		fwName         sql.NullString
+		fwVersion      sql.NullString
+		name           sql.NullString
+		location       sql.NullString
+		tags           sql.NullString
+		holdInt        int
+		holdUntil      sql.NullTime
+		protectedInt   int
+		pollRatio      sql.NullFloat64
+		pollError      sql.NullString
+		pollErrorAt    sql.NullTime
+		apiScheme      sql.NullString
+		apiPort        sql.NullInt64
+		archivedAt     sql.NullTime
+		presetChangeAt sql.NullTime
+		customerID     sql.NullInt64
+		serial         sql.NullString
+		rebootUntil    sql.NullTime
 	)
 
 	err = tx.QueryRowContext(ctx, `
-		SELECT id, ip, api_key, managed, unlock_pass, model_id, settings_id, latest_status_id, created_at, updated_at
+		SELECT id, ip, api_key, managed, unlock_pass, fw_name, fw_version, driver, name, location, tags, hold, hold_until, protected, poll_failures, poll_success_ratio, last_poll_error, last_poll_error_at, api_scheme, api_port, archived_at, last_preset_change_at, customer_id, serial, reboot_until, model_id, settings_id, latest_status_id, created_at, updated_at
 		FROM miners
 		WHERE id = ?
-	`, minerID).Scan(&miner.ID, &ip, &apiKey, &managedInt, &unlockPass, &modelID, &settingsID, &latestStatusID, &miner.CreatedAt, &miner.UpdatedAt)
+	`, minerID).Scan(&miner.ID, &ip, &apiKey, &managedInt, &unlockPass, &fwName, &fwVersion, &miner.Driver, &name, &location, &tags, &holdInt, &holdUntil, &protectedInt, &miner.Reliability.ConsecutiveFailures, &pollRatio, &pollError, &pollErrorAt, &apiScheme, &apiPort, &archivedAt, &presetChangeAt, &customerID, &serial, &rebootUntil, &modelID, &settingsID, &latestStatusID, &miner.CreatedAt, &miner.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Miner{}, fmt.Errorf("miner %s not found", minerID)
@@ -130,10 +246,35 @@ func (s *Store) GetMiner(ctx context.Context, minerID string) (Miner, error) {
 		miner.IP = &ip.String
 	}
 	if apiKey.Valid {
-		miner.APIKey = &apiKey.String
+		value, err := s.revealSecret(apiKey.String)
+		if err != nil {
+			return Miner{}, fmt.Errorf("api key for miner %s: %w", minerID, err)
+		}
+		miner.APIKey = &value
 	}
 	miner.Managed = managedInt != 0
-	miner.UnlockPass = unlockPass
+	miner.FWName = stringPtrFromNull(fwName)
+	miner.FWVersion = stringPtrFromNull(fwVersion)
+	miner.Name = stringPtrFromNull(name)
+	miner.Location = stringPtrFromNull(location)
+	miner.Tags = decodeTags(tags)
+	miner.Hold = holdInt != 0
+	miner.HoldUntil = timePtrFromNull(holdUntil)
+	miner.Protected = protectedInt != 0
+	miner.Reliability.SuccessRatio = floatPtrFromNull(pollRatio)
+	miner.Reliability.LastError = stringPtrFromNull(pollError)
+	miner.Reliability.LastErrorAt = timePtrFromNull(pollErrorAt)
+	miner.APIScheme = stringPtrFromNull(apiScheme)
+	miner.APIPort = intPtrFromNull(apiPort)
+	miner.ArchivedAt = timePtrFromNull(archivedAt)
+	miner.LastPresetChangeAt = timePtrFromNull(presetChangeAt)
+	miner.CustomerID = int64PtrFromNull(customerID)
+	miner.Serial = stringPtrFromNull(serial)
+	miner.RebootUntil = timePtrFromNull(rebootUntil)
+	miner.UnlockPass, err = s.revealSecret(unlockPass)
+	if err != nil {
+		return Miner{}, fmt.Errorf("unlock password for miner %s: %w", minerID, err)
+	}
 
 	if modelID.Valid {
 		model, err := s.getModelByIDTx(ctx, tx, modelID.Int64)
@@ -167,8 +308,23 @@ func (s *Store) GetMiner(ctx context.Context, minerID string) (Miner, error) {
 	return miner, nil
 }
 
-// ListMiners returns every miner without loading heavy historical fields.
+// ListMiners returns every miner that is not archived, without loading
+// heavy historical fields.
 func (s *Store) ListMiners(ctx context.Context) ([]Miner, error) {
+	return s.listMiners(ctx, false)
+}
+
+// ListArchivedMiners returns the archived miners.
+func (s *Store) ListArchivedMiners(ctx context.Context) ([]Miner, error) {
+	return s.listMiners(ctx, true)
+}
+
+func (s *Store) listMiners(ctx context.Context, archived bool) ([]Miner, error) {
+	filter := "archived_at IS NULL"
+	if archived {
+		filter = "archived_at IS NOT NULL"
+	}
+
 	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, fmt.Errorf("begin list miners tx: %w", err)
@@ -176,8 +332,9 @@ func (s *Store) ListMiners(ctx context.Context) ([]Miner, error) {
 	defer func() { _ = tx.Rollback() }()
 
 	rows, err := tx.QueryContext(ctx, `
-		SELECT id, ip, api_key, managed, unlock_pass, model_id, settings_id, latest_status_id, created_at, updated_at
+		SELECT id, ip, api_key, managed, unlock_pass, fw_name, fw_version, driver, name, location, tags, hold, hold_until, protected, poll_failures, poll_success_ratio, last_poll_error, last_poll_error_at, api_scheme, api_port, archived_at, last_preset_change_at, customer_id, serial, reboot_until, model_id, settings_id, latest_status_id, created_at, updated_at
 		FROM miners
+		WHERE `+filter+`
 		ORDER BY id
 	`)
 	if err != nil {
@@ -185,7 +342,13 @@ func (s *Store) ListMiners(ctx context.Context) ([]Miner, error) {
 	}
 	defer rows.Close()
 
-	var miners []Miner
+	// The related records are loaded once the rows are read: PostgreSQL
+	// cannot run another query on the transaction while a cursor is open.
+	type listedMiner struct {
+		miner                               Miner
+		modelID, settingsID, latestStatusID sql.NullInt64
+	}
+	var listed []listedMiner
 
 	for rows.Next() {
 		var (
@@ -196,9 +359,27 @@ func (s *Store) ListMiners(ctx context.Context) ([]Miner, error) {
 			settingsID     sql.NullInt64
 			latestStatusID sql.NullInt64
 			managedInt     int
+			fwName         sql.NullString
+			fwVersion      sql.NullString
+			name           sql.NullString
+			location       sql.NullString
+			tags           sql.NullString
+			holdInt        int
+			holdUntil      sql.NullTime
+			protectedInt   int
+			pollRatio      sql.NullFloat64
+			pollError      sql.NullString
+			pollErrorAt    sql.NullTime
+			apiScheme      sql.NullString
+			apiPort        sql.NullInt64
+			archivedAt     sql.NullTime
+			presetChangeAt sql.NullTime
+			customerID     sql.NullInt64
+			serial         sql.NullString
+			rebootUntil    sql.NullTime
 		)
 
-		if err := rows.Scan(&miner.ID, &ip, &apiKey, &managedInt, &miner.UnlockPass, &modelID, &settingsID, &latestStatusID, &miner.CreatedAt, &miner.UpdatedAt); err != nil {
+		if err := rows.Scan(&miner.ID, &ip, &apiKey, &managedInt, &miner.UnlockPass, &fwName, &fwVersion, &miner.Driver, &name, &location, &tags, &holdInt, &holdUntil, &protectedInt, &miner.Reliability.ConsecutiveFailures, &pollRatio, &pollError, &pollErrorAt, &apiScheme, &apiPort, &archivedAt, &presetChangeAt, &customerID, &serial, &rebootUntil, &modelID, &settingsID, &latestStatusID, &miner.CreatedAt, &miner.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("scan miner: %w", err)
 		}
 
@@ -207,29 +388,68 @@ func (s *Store) ListMiners(ctx context.Context) ([]Miner, error) {
 			miner.IP = &value
 		}
 		if apiKey.Valid {
-			value := apiKey.String
+			value, err := s.revealSecret(apiKey.String)
+			if err != nil {
+				return nil, fmt.Errorf("api key for miner %s: %w", miner.ID, err)
+			}
 			miner.APIKey = &value
 		}
 		miner.Managed = managedInt != 0
+		miner.FWName = stringPtrFromNull(fwName)
+		miner.FWVersion = stringPtrFromNull(fwVersion)
+		miner.Name = stringPtrFromNull(name)
+		miner.Location = stringPtrFromNull(location)
+		miner.Tags = decodeTags(tags)
+		miner.Hold = holdInt != 0
+		miner.HoldUntil = timePtrFromNull(holdUntil)
+		miner.Protected = protectedInt != 0
+		miner.Reliability.SuccessRatio = floatPtrFromNull(pollRatio)
+		miner.Reliability.LastError = stringPtrFromNull(pollError)
+		miner.Reliability.LastErrorAt = timePtrFromNull(pollErrorAt)
+		miner.APIScheme = stringPtrFromNull(apiScheme)
+		miner.APIPort = intPtrFromNull(apiPort)
+		miner.ArchivedAt = timePtrFromNull(archivedAt)
+		miner.LastPresetChangeAt = timePtrFromNull(presetChangeAt)
+		miner.CustomerID = int64PtrFromNull(customerID)
+		miner.Serial = stringPtrFromNull(serial)
+		miner.RebootUntil = timePtrFromNull(rebootUntil)
+
+		pass, err := s.revealSecret(miner.UnlockPass)
+		if err != nil {
+			return nil, fmt.Errorf("unlock password for miner %s: %w", miner.ID, err)
+		}
+		miner.UnlockPass = pass
 
-		if modelID.Valid {
-			model, err := s.getModelByIDTx(ctx, tx, modelID.Int64)
+		listed = append(listed, listedMiner{miner: miner, modelID: modelID, settingsID: settingsID, latestStatusID: latestStatusID})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate miners: %w", err)
+	}
+	rows.Close()
+
+	miners := make([]Miner, 0, len(listed))
+	for _, entry := range listed {
+		miner := entry.miner
+
+		if entry.modelID.Valid {
+			model, err := s.getModelByIDTx(ctx, tx, entry.modelID.Int64)
 			if err != nil {
 				return nil, err
 			}
 			miner.Model = &model
 		}
 
-		if settingsID.Valid {
-			settings, err := s.getSettingsByIDTx(ctx, tx, settingsID.Int64)
+		if entry.settingsID.Valid {
+			settings, err := s.getSettingsByIDTx(ctx, tx, entry.settingsID.Int64)
 			if err != nil {
 				return nil, err
 			}
 			miner.Settings = &settings
 		}
 
-		if latestStatusID.Valid {
-			id := latestStatusID.Int64
+		if entry.latestStatusID.Valid {
+			id := entry.latestStatusID.Int64
 			miner.LatestStatusID = &id
 			status, err := s.getStatusByIDTx(ctx, tx, id)
 			if err != nil {
@@ -241,9 +461,171 @@ func (s *Store) ListMiners(ctx context.Context) ([]Miner, error) {
 		miners = append(miners, miner)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate miners: %w", err)
+	return miners, nil
+}
+
+// NormalizeTags trims and lowercases tags, drops empty and duplicate ones
+// and sorts the result.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
 	}
+	sort.Strings(out)
+	return out
+}
 
-	return miners, nil
+// encodeTags stores tags as a JSON array, or NULL when there are none.
+func encodeTags(tags []string) (any, error) {
+	tags = NormalizeTags(tags)
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("encode miner tags: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeTags(raw sql.NullString) []string {
+	if !raw.Valid || raw.String == "" {
+		return nil
+	}
+	var tags []string
+	if err := json.Unmarshal([]byte(raw.String), &tags); err != nil {
+		return nil
+	}
+	return tags
+}
+
+// Held reports whether the miner's balancer hold is in effect at now.
+func (m Miner) Held(now time.Time) bool {
+	return m.Hold && (m.HoldUntil == nil || now.Before(*m.HoldUntil))
+}
+
+// Rebooting reports whether the miner is inside a planned reboot window at
+// now, during which it is expected not to answer.
+func (m Miner) Rebooting(now time.Time) bool {
+	return m.RebootUntil != nil && now.Before(*m.RebootUntil)
+}
+
+// ImmersionTag marks a miner as immersion cooled regardless of its cooling
+// settings.
+const ImmersionTag = "immersion"
+
+// Immersion reports whether the miner is immersion cooled: its cooling mode
+// is "immersion" or it carries ImmersionTag. Immersion miners run without
+// fans.
+func (m Miner) Immersion() bool {
+	if m.Settings != nil && strings.EqualFold(m.Settings.Cooling.Mode, "immersion") {
+		return true
+	}
+	for _, tag := range m.Tags {
+		if strings.EqualFold(tag, ImmersionTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveMiner marks the miner archived at the given time. Archiving an
+// archived miner keeps the original time.
+func (s *Store) ArchiveMiner(ctx context.Context, minerID string, at time.Time) (Miner, error) {
+	if err := s.setArchived(ctx, minerID, `COALESCE(archived_at, ?)`, at.UTC()); err != nil {
+		return Miner{}, err
+	}
+	return s.GetMiner(ctx, minerID)
+}
+
+// RestoreMiner clears the miner's archived mark.
+func (s *Store) RestoreMiner(ctx context.Context, minerID string) (Miner, error) {
+	if err := s.setArchived(ctx, minerID, `NULL`); err != nil {
+		return Miner{}, err
+	}
+	return s.GetMiner(ctx, minerID)
+}
+
+func (s *Store) setArchived(ctx context.Context, minerID, value string, args ...any) error {
+	minerID = strings.TrimSpace(minerID)
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE miners
+		SET archived_at = `+value+`, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, append(args, minerID)...)
+	if err != nil {
+		return fmt.Errorf("archive miner %s: %w", minerID, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("archive miner rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("miner %s not found", minerID)
+	}
+	return nil
+}
+
+// DeleteMiner removes the miner together with its statuses, telemetry,
+// events and settings. Child rows are deleted explicitly because SQLite
+// only enforces the cascades on connections with foreign keys enabled.
+func (s *Store) DeleteMiner(ctx context.Context, minerID string) error {
+	minerID = strings.TrimSpace(minerID)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete miner tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var settingsID sql.NullInt64
+	if err := tx.QueryRowContext(ctx, `SELECT settings_id FROM miners WHERE id = ?`, minerID).Scan(&settingsID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("miner %s not found", minerID)
+		}
+		return fmt.Errorf("query miner %s: %w", minerID, err)
+	}
+
+	statements := []string{
+		`UPDATE miners SET latest_status_id = NULL, settings_id = NULL WHERE id = ?`,
+		`DELETE FROM chain_chips WHERE chain_snapshot_id IN (SELECT id FROM chain_snapshots WHERE miner_id = ?)`,
+		`DELETE FROM chain_snapshots WHERE miner_id = ?`,
+		`DELETE FROM status_fans WHERE status_id IN (SELECT id FROM statuses WHERE miner_id = ?)`,
+		`DELETE FROM status_pools WHERE status_id IN (SELECT id FROM statuses WHERE miner_id = ?)`,
+		`DELETE FROM statuses WHERE miner_id = ?`,
+		`DELETE FROM power_balance_events WHERE miner_id = ?`,
+		`DELETE FROM balance_cycle_skips WHERE miner_id = ?`,
+		`DELETE FROM hashboard_events WHERE miner_id = ?`,
+		`DELETE FROM drift_events WHERE miner_id = ?`,
+		`DELETE FROM miner_availability WHERE miner_id = ?`,
+		`DELETE FROM api_key_rotations WHERE miner_id = ?`,
+		`DELETE FROM power_measurements WHERE miner_id = ?`,
+		`DELETE FROM miners WHERE id = ?`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt, minerID); err != nil {
+			return fmt.Errorf("delete miner %s: %w", minerID, err)
+		}
+	}
+
+	// Settings snapshots are not shared between miners.
+	if settingsID.Valid {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM settings_pools WHERE settings_id = ?`, settingsID.Int64); err != nil {
+			return fmt.Errorf("delete settings for miner %s: %w", minerID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM settings WHERE id = ?`, settingsID.Int64); err != nil {
+			return fmt.Errorf("delete settings for miner %s: %w", minerID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete miner tx: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postgresDSNEnv names a PostgreSQL database the tests may use. Each test
+// works in a schema of its own there and drops it afterwards.
+const postgresDSNEnv = "POWERHIVE_TEST_POSTGRES_DSN"
+
+// testStores returns an initialised store per backend: a temporary SQLite
+// file always, and PostgreSQL when postgresDSNEnv is set.
+func testStores(t *testing.T) map[Dialect]*Store {
+	t.Helper()
+	stores := map[Dialect]*Store{
+		DialectSQLite: openTestStore(t, DialectSQLite, filepath.Join(t.TempDir(), "test.db"), PoolOptions{MaxOpenConns: 1, MaxIdleConns: 1}),
+	}
+
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Logf("%s is not set; testing SQLite only", postgresDSNEnv)
+		return stores
+	}
+
+	schema := fmt.Sprintf("powerhive_test_%d", time.Now().UnixNano())
+	admin := openTestDB(t, DialectPostgres, dsn, PoolOptions{MaxOpenConns: 1})
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { _, _ = admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	sep := " "
+	if strings.Contains(dsn, "://") {
+		sep = "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+	}
+	stores[DialectPostgres] = openTestStore(t, DialectPostgres, dsn+sep+"search_path="+schema, PoolOptions{MaxOpenConns: 4, MaxIdleConns: 4})
+	return stores
+}
+
+func openTestDB(t *testing.T, dialect Dialect, dsn string, pool PoolOptions) *sql.DB {
+	t.Helper()
+	db, err := Open(dialect, dsn, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func openTestStore(t *testing.T, dialect Dialect, dsn string, pool PoolOptions) *Store {
+	t.Helper()
+	store, err := New(openTestDB(t, dialect, dsn, pool), dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// TestListMinersLoadsRelations lists a miner with a model, settings and a
+// latest status with hashboards and chips. The relations are loaded on the
+// listing's transaction, which PostgreSQL refuses while a cursor is open.
+func TestListMinersLoadsRelations(t *testing.T) {
+	for dialect, store := range testStores(t) {
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := store.UpsertModel(ctx, ModelInput{Name: "Antminer S21", Alias: "s21", Presets: []string{"3000", "3300", "3600"}}); err != nil {
+				t.Fatal(err)
+			}
+			alias, ip := "s21", "10.0.0.10"
+			for _, id := range []string{"02:de:00:00:00:01", "02:de:00:00:00:02"} {
+				if _, err := store.UpsertMiner(ctx, UpsertMinerParams{ID: id, IP: &ip, ModelAlias: &alias}); err != nil {
+					t.Fatal(err)
+				}
+				preset := "3300"
+				if _, err := store.SaveMinerSettings(ctx, id, SettingsInput{Preset: &preset}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := store.RecordMinerStatus(ctx, id, benchmarkSnapshot(time.Now().UTC())); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			miners, err := store.ListMiners(ctx)
+			if err != nil {
+				t.Fatalf("ListMiners: %v", err)
+			}
+			if len(miners) != 2 {
+				t.Fatalf("ListMiners returned %d miners, want 2", len(miners))
+			}
+			for _, miner := range miners {
+				if miner.Model == nil || len(miner.Model.Presets) != 3 {
+					t.Errorf("miner %s: model presets not loaded: %+v", miner.ID, miner.Model)
+				}
+				if miner.Settings == nil {
+					t.Errorf("miner %s: settings not loaded", miner.ID)
+				}
+				if miner.LatestStatus == nil {
+					t.Fatalf("miner %s: latest status not loaded", miner.ID)
+				}
+				if got := len(miner.LatestStatus.Chains); got != 3 {
+					t.Fatalf("miner %s: %d chains, want 3", miner.ID, got)
+				}
+				if got := len(miner.LatestStatus.Chains[0].Chips); got != 108 {
+					t.Errorf("miner %s: %d chips on the first chain, want 108", miner.ID, got)
+				}
+			}
+
+			models, err := store.ListModels(ctx)
+			if err != nil {
+				t.Fatalf("ListModels: %v", err)
+			}
+			if len(models) != 1 || len(models[0].Presets) != 3 {
+				t.Errorf("ListModels = %+v, want one model with 3 presets", models)
+			}
+		})
+	}
+}
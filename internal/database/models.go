@@ -8,9 +8,19 @@ import (
 	"strings"
 )
 
+var (
+	// ErrModelExists is returned when creating a model whose alias is taken.
+	ErrModelExists = errors.New("model already exists")
+	// ErrModelInUse is returned when deleting a model miners still use.
+	ErrModelInUse = errors.New("model in use")
+	// ErrInvalidModel is returned for a model or preset list that fails
+	// validation.
+	ErrInvalidModel = errors.New("invalid model")
+)
+
 // UpsertModel inserts a new model or updates an existing one matched by alias.
 // When Presets is nil the stored presets remain untouched; an empty slice clears
-// existing presets.
+// existing presets. Presets kept in the list keep their expected metrics.
 func (s *Store) UpsertModel(ctx context.Context, input ModelInput) (Model, error) {
 	if err := validateModelInput(input); err != nil {
 		return Model{}, err
@@ -23,12 +33,13 @@ func (s *Store) UpsertModel(ctx context.Context, input ModelInput) (Model, error
 	defer func() { _ = tx.Rollback() }()
 
 	if _, err := tx.ExecContext(ctx, `
-		INSERT INTO models (name, alias, max_preset)
-		VALUES (?, ?, ?)
+		INSERT INTO models (name, alias, max_preset, min_preset)
+		VALUES (?, ?, ?, ?)
 		ON CONFLICT(alias) DO UPDATE SET
 			name = excluded.name,
-			max_preset = excluded.max_preset
-	`, input.Name, input.Alias, nullableTrimmedString(input.MaxPreset)); err != nil {
+			max_preset = excluded.max_preset,
+			min_preset = excluded.min_preset
+	`, input.Name, input.Alias, nullableTrimmedString(input.MaxPreset), nullableTrimmedString(input.MinPreset)); err != nil {
 		return Model{}, fmt.Errorf("upsert model %s: %w", input.Alias, err)
 	}
 
@@ -38,22 +49,8 @@ func (s *Store) UpsertModel(ctx context.Context, input ModelInput) (Model, error
 	}
 
 	if input.Presets != nil {
-		if _, err := tx.ExecContext(ctx, `DELETE FROM model_presets WHERE model_id = ?`, modelID); err != nil {
-			return Model{}, fmt.Errorf("clear presets for model %s: %w", input.Alias, err)
-		}
-
-		for idx, preset := range input.Presets {
-			value := strings.TrimSpace(preset)
-			if value == "" {
-				return Model{}, fmt.Errorf("preset at position %d is empty", idx)
-			}
-
-			if _, err := tx.ExecContext(ctx, `
-				INSERT INTO model_presets (model_id, value, position)
-				VALUES (?, ?, ?)
-			`, modelID, value, idx); err != nil {
-				return Model{}, fmt.Errorf("insert preset %s for model %s: %w", value, input.Alias, err)
-			}
+		if err := replacePresetsTx(ctx, tx, modelID, input.Alias, input.Presets); err != nil {
+			return Model{}, err
 		}
 	}
 
@@ -98,7 +95,7 @@ func (s *Store) ListModels(ctx context.Context) ([]Model, error) {
 	defer func() { _ = tx.Rollback() }()
 
 	rows, err := tx.QueryContext(ctx, `
-		SELECT id, name, alias, max_preset, created_at
+		SELECT id, name, alias, max_preset, min_preset, created_at
 		FROM models
 		ORDER BY alias
 	`)
@@ -112,27 +109,35 @@ func (s *Store) ListModels(ctx context.Context) ([]Model, error) {
 		var (
 			m   Model
 			max sql.NullString
+			min sql.NullString
 		)
 
-		if err := rows.Scan(&m.ID, &m.Name, &m.Alias, &max, &m.CreatedAt); err != nil {
+		if err := rows.Scan(&m.ID, &m.Name, &m.Alias, &max, &min, &m.CreatedAt); err != nil {
 			return nil, fmt.Errorf("scan model: %w", err)
 		}
 
 		if max.Valid {
 			m.MaxPreset = &max.String
 		}
-
-		presets, err := s.loadPresetsTx(ctx, tx, m.ID)
-		if err != nil {
-			return nil, err
+		if min.Valid {
+			m.MinPreset = &min.String
 		}
-		m.Presets = presets
+
 		models = append(models, m)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate models: %w", err)
 	}
+	rows.Close()
+
+	for i := range models {
+		presets, err := s.loadPresetsTx(ctx, tx, models[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		models[i].Presets = presets
+	}
 
 	return models, nil
 }
@@ -160,13 +165,14 @@ func (s *Store) getModelByIDTx(ctx context.Context, tx *sql.Tx, id int64) (Model
 	var (
 		model Model
 		max   sql.NullString
+		min   sql.NullString
 	)
 
 	if err := tx.QueryRowContext(ctx, `
-		SELECT id, name, alias, max_preset, created_at
+		SELECT id, name, alias, max_preset, min_preset, created_at
 		FROM models
 		WHERE id = ?
-	`, id).Scan(&model.ID, &model.Name, &model.Alias, &max, &model.CreatedAt); err != nil {
+	`, id).Scan(&model.ID, &model.Name, &model.Alias, &max, &min, &model.CreatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Model{}, fmt.Errorf("model %d not found", id)
 		}
@@ -176,6 +182,9 @@ func (s *Store) getModelByIDTx(ctx context.Context, tx *sql.Tx, id int64) (Model
 	if max.Valid {
 		model.MaxPreset = &max.String
 	}
+	if min.Valid {
+		model.MinPreset = &min.String
+	}
 
 	presets, err := s.loadPresetsTx(ctx, tx, id)
 	if err != nil {
@@ -270,7 +279,7 @@ func validateModelInput(input ModelInput) error {
 // GetModelPresets returns all presets with power consumption data for a model.
 func (s *Store) GetModelPresets(ctx context.Context, modelAlias string) ([]ModelPreset, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT mp.id, mp.model_id, mp.value, mp.position, mp.expected_power_w, mp.expected_hashrate_th, mp.created_at
+		SELECT mp.id, mp.model_id, mp.value, mp.position, mp.expected_power_w, mp.expected_hashrate_th, mp.hashrate_observed, mp.created_at
 		FROM model_presets mp
 		JOIN models m ON mp.model_id = m.id
 		WHERE m.alias = ?
@@ -287,15 +296,17 @@ func (s *Store) GetModelPresets(ctx context.Context, modelAlias string) ([]Model
 			preset          ModelPreset
 			expectedPower   sql.NullFloat64
 			expectedHashrate sql.NullFloat64
+			observed        int
 		)
 
 		if err := rows.Scan(&preset.ID, &preset.ModelID, &preset.Value, &preset.Position,
-			&expectedPower, &expectedHashrate, &preset.CreatedAt); err != nil {
+			&expectedPower, &expectedHashrate, &observed, &preset.CreatedAt); err != nil {
 			return nil, fmt.Errorf("scan preset: %w", err)
 		}
 
 		preset.ExpectedPowerW = floatPtrFromNull(expectedPower)
 		preset.ExpectedHashrateTH = floatPtrFromNull(expectedHashrate)
+		preset.HashrateObserved = observed != 0
 		presets = append(presets, preset)
 	}
 
@@ -310,7 +321,7 @@ func (s *Store) GetModelPresets(ctx context.Context, modelAlias string) ([]Model
 // mapped by model alias. This is more efficient than calling GetModelPresets repeatedly.
 func (s *Store) GetAllModelPresets(ctx context.Context) (map[string][]ModelPreset, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT m.alias, mp.id, mp.model_id, mp.value, mp.position, mp.expected_power_w, mp.expected_hashrate_th, mp.created_at
+		SELECT m.alias, mp.id, mp.model_id, mp.value, mp.position, mp.expected_power_w, mp.expected_hashrate_th, mp.hashrate_observed, mp.created_at
 		FROM model_presets mp
 		JOIN models m ON mp.model_id = m.id
 		ORDER BY m.alias, mp.position, mp.id
@@ -327,15 +338,17 @@ func (s *Store) GetAllModelPresets(ctx context.Context) (map[string][]ModelPrese
 			preset           ModelPreset
 			expectedPower    sql.NullFloat64
 			expectedHashrate sql.NullFloat64
+			observed         int
 		)
 
 		if err := rows.Scan(&alias, &preset.ID, &preset.ModelID, &preset.Value, &preset.Position,
-			&expectedPower, &expectedHashrate, &preset.CreatedAt); err != nil {
+			&expectedPower, &expectedHashrate, &observed, &preset.CreatedAt); err != nil {
 			return nil, fmt.Errorf("scan preset: %w", err)
 		}
 
 		preset.ExpectedPowerW = floatPtrFromNull(expectedPower)
 		preset.ExpectedHashrateTH = floatPtrFromNull(expectedHashrate)
+		preset.HashrateObserved = observed != 0
 		result[alias] = append(result[alias], preset)
 	}
 
@@ -368,7 +381,7 @@ func (s *Store) UpdatePresetMetrics(ctx context.Context, modelAlias, presetValue
 		args = append(args, *powerW)
 	}
 	if hashrateTH != nil {
-		setClauses = append(setClauses, "expected_hashrate_th = ?")
+		setClauses = append(setClauses, "expected_hashrate_th = ?", "hashrate_observed = 0")
 		args = append(args, *hashrateTH)
 	}
 
@@ -397,3 +410,228 @@ func (s *Store) UpdatePresetMetrics(ctx context.Context, modelAlias, presetValue
 
 	return nil
 }
+
+// replacePresetsTx makes values the model's preset list, in order. Presets
+// no longer listed are removed; the others keep their expected metrics.
+func replacePresetsTx(ctx context.Context, tx *sql.Tx, modelID int64, alias string, values []string) error {
+	trimmed := make([]string, 0, len(values))
+	for idx, preset := range values {
+		value := strings.TrimSpace(preset)
+		if value == "" {
+			return fmt.Errorf("preset at position %d is empty", idx)
+		}
+		trimmed = append(trimmed, value)
+	}
+
+	query := `DELETE FROM model_presets WHERE model_id = ?`
+	args := []any{modelID}
+	if len(trimmed) > 0 {
+		query += ` AND value NOT IN (?` + strings.Repeat(", ?", len(trimmed)-1) + `)`
+		for _, value := range trimmed {
+			args = append(args, value)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("clear presets for model %s: %w", alias, err)
+	}
+
+	for idx, value := range trimmed {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO model_presets (model_id, value, position)
+			VALUES (?, ?, ?)
+			ON CONFLICT(model_id, value) DO UPDATE SET position = excluded.position
+		`, modelID, value, idx); err != nil {
+			return fmt.Errorf("insert preset %s for model %s: %w", value, alias, err)
+		}
+	}
+	return nil
+}
+
+// CreateModel registers a model before any miner of it is discovered, with
+// its presets and their expected metrics. It fails with ErrModelExists when
+// the alias is taken.
+func (s *Store) CreateModel(ctx context.Context, def ModelDefinition) (Model, error) {
+	def.Name = strings.TrimSpace(def.Name)
+	def.Alias = strings.TrimSpace(def.Alias)
+	if def.Name == "" {
+		return Model{}, fmt.Errorf("%w: name is required", ErrInvalidModel)
+	}
+	if def.Alias == "" {
+		return Model{}, fmt.Errorf("%w: alias is required", ErrInvalidModel)
+	}
+	values, err := validateModelPresets(def.Presets)
+	if err != nil {
+		return Model{}, err
+	}
+	maxPreset, err := presetBound("max_preset", def.MaxPreset, values)
+	if err != nil {
+		return Model{}, err
+	}
+	minPreset, err := presetBound("min_preset", def.MinPreset, values)
+	if err != nil {
+		return Model{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Model{}, fmt.Errorf("begin create model tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := getModelIDByAlias(ctx, tx, def.Alias); err == nil {
+		return Model{}, fmt.Errorf("%w: %s", ErrModelExists, def.Alias)
+	} else if !strings.Contains(err.Error(), "not found") {
+		return Model{}, err
+	}
+
+	var modelID int64
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO models (name, alias, max_preset, min_preset)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`, def.Name, def.Alias, nullableTrimmedString(maxPreset), nullableTrimmedString(minPreset)).Scan(&modelID); err != nil {
+		return Model{}, fmt.Errorf("insert model %s: %w", def.Alias, err)
+	}
+	if err := setPresetsTx(ctx, tx, modelID, def.Alias, def.Presets); err != nil {
+		return Model{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Model{}, fmt.Errorf("commit model tx: %w", err)
+	}
+	return s.getModelByID(ctx, modelID)
+}
+
+// SetModelPresets replaces the model's preset list and the expected power
+// and hashrate of each preset; a nil metric clears it. The model's
+// max_preset and min_preset must stay in the list.
+func (s *Store) SetModelPresets(ctx context.Context, alias string, presets []ModelPresetInput) (Model, error) {
+	values, err := validateModelPresets(presets)
+	if err != nil {
+		return Model{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Model{}, fmt.Errorf("begin set presets tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	modelID, err := getModelIDByAlias(ctx, tx, alias)
+	if err != nil {
+		return Model{}, err
+	}
+	model, err := s.getModelByIDTx(ctx, tx, modelID)
+	if err != nil {
+		return Model{}, err
+	}
+	if _, err := presetBound("max_preset", model.MaxPreset, values); err != nil {
+		return Model{}, err
+	}
+	if _, err := presetBound("min_preset", model.MinPreset, values); err != nil {
+		return Model{}, err
+	}
+
+	if err := setPresetsTx(ctx, tx, modelID, alias, presets); err != nil {
+		return Model{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Model{}, fmt.Errorf("commit set presets tx: %w", err)
+	}
+	return s.getModelByID(ctx, modelID)
+}
+
+// DeleteModel removes a model and its presets. Models still assigned to a
+// miner are refused with ErrModelInUse.
+func (s *Store) DeleteModel(ctx context.Context, alias string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete model tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	modelID, err := getModelIDByAlias(ctx, tx, alias)
+	if err != nil {
+		return err
+	}
+
+	var miners int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM miners WHERE model_id = ?`, modelID).Scan(&miners); err != nil {
+		return fmt.Errorf("count miners of model %s: %w", alias, err)
+	}
+	if miners > 0 {
+		return fmt.Errorf("%w: %d miner(s) use %s", ErrModelInUse, miners, alias)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM model_presets WHERE model_id = ?`, modelID); err != nil {
+		return fmt.Errorf("delete presets of model %s: %w", alias, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM models WHERE id = ?`, modelID); err != nil {
+		return fmt.Errorf("delete model %s: %w", alias, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete model tx: %w", err)
+	}
+	return nil
+}
+
+// setPresetsTx replaces the model's presets and writes each one's metrics.
+func setPresetsTx(ctx context.Context, tx *sql.Tx, modelID int64, alias string, presets []ModelPresetInput) error {
+	values := make([]string, 0, len(presets))
+	for _, preset := range presets {
+		values = append(values, preset.Value)
+	}
+	if err := replacePresetsTx(ctx, tx, modelID, alias, values); err != nil {
+		return err
+	}
+
+	for _, preset := range presets {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE model_presets
+			SET expected_power_w = ?, expected_hashrate_th = ?, hashrate_observed = 0
+			WHERE model_id = ? AND value = ?
+		`, nullableFloat64(preset.ExpectedPowerW), nullableFloat64(preset.ExpectedHashrateTH), modelID, strings.TrimSpace(preset.Value)); err != nil {
+			return fmt.Errorf("update metrics of preset %s for model %s: %w", preset.Value, alias, err)
+		}
+	}
+	return nil
+}
+
+// validateModelPresets checks a preset list sent through the API and
+// returns its trimmed values.
+func validateModelPresets(presets []ModelPresetInput) ([]string, error) {
+	values := make([]string, 0, len(presets))
+	seen := make(map[string]bool)
+	for idx, preset := range presets {
+		value := strings.TrimSpace(preset.Value)
+		if value == "" {
+			return nil, fmt.Errorf("%w: preset at position %d is empty", ErrInvalidModel, idx)
+		}
+		key := strings.ToLower(value)
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate preset %s", ErrInvalidModel, value)
+		}
+		seen[key] = true
+		if (preset.ExpectedPowerW != nil && *preset.ExpectedPowerW < 0) || (preset.ExpectedHashrateTH != nil && *preset.ExpectedHashrateTH < 0) {
+			return nil, fmt.Errorf("%w: metrics of preset %s must not be negative", ErrInvalidModel, value)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// presetBound checks that a max_preset or min_preset names one of values
+// and returns it as stored there. An empty bound is nil.
+func presetBound(field string, bound *string, values []string) (*string, error) {
+	if bound == nil || strings.TrimSpace(*bound) == "" {
+		return nil, nil
+	}
+	for _, value := range values {
+		if strings.EqualFold(value, strings.TrimSpace(*bound)) {
+			return &value, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s is not in the preset list", ErrInvalidModel, field, strings.TrimSpace(*bound))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -31,43 +32,49 @@ func (s *Store) RecordPlantReading(ctx context.Context, input PlantReadingInput)
 		}
 	}
 
-	res, err := s.db.ExecContext(ctx, `
-		INSERT INTO plant_readings (plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, raw_data, recorded_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO plant_readings (plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, exported_power, battery_soc, battery_power, grid_frequency, grid_voltage, raw_data, recorded_at, fallback)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
 	`, input.PlantID, input.TotalGeneration, input.TotalContainerConsumption, input.AvailablePower,
 		nullableBytes(generationSourcesJSON), nullableBytes(consumptionSourcesJSON),
-		nullableString(input.RawData), recordedAt)
-	if err != nil {
+		nullableFloat64(input.ExportedPower), nullableFloat64(input.BatterySOC), nullableFloat64(input.BatteryPower),
+		nullableFloat64(input.GridFrequency), nullableFloat64(input.GridVoltage),
+		nullableString(input.RawData), recordedAt, nullableString(input.Fallback)).Scan(&id); err != nil {
 		return PlantReading{}, fmt.Errorf("insert plant reading: %w", err)
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return PlantReading{}, fmt.Errorf("read plant reading id: %w", err)
-	}
-
 	return s.GetPlantReadingByID(ctx, id)
 }
 
 // GetPlantReadingByID retrieves a single plant reading by its ID.
 func (s *Store) GetPlantReadingByID(ctx context.Context, id int64) (PlantReading, error) {
 	var (
-		reading                                        PlantReading
-		rawData                                        sql.NullString
+		reading                                       PlantReading
+		rawData, fallback                             sql.NullString
 		generationSourcesJSON, consumptionSourcesJSON sql.NullString
+		exportedPower, batterySOC, batteryPower       sql.NullFloat64
+		gridFrequency, gridVoltage                    sql.NullFloat64
 	)
 
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, raw_data, recorded_at
+		SELECT id, plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, exported_power, battery_soc, battery_power, grid_frequency, grid_voltage, raw_data, recorded_at, fallback
 		FROM plant_readings
 		WHERE id = ?
 	`, id).Scan(&reading.ID, &reading.PlantID, &reading.TotalGeneration, &reading.TotalContainerConsumption,
-		&reading.AvailablePower, &generationSourcesJSON, &consumptionSourcesJSON, &rawData, &reading.RecordedAt)
+		&reading.AvailablePower, &generationSourcesJSON, &consumptionSourcesJSON, &exportedPower, &batterySOC, &batteryPower, &gridFrequency, &gridVoltage, &rawData, &reading.RecordedAt, &fallback)
 	if err != nil {
 		return PlantReading{}, fmt.Errorf("query plant reading %d: %w", id, err)
 	}
 
+	reading.ExportedPower = floatPtrFromNull(exportedPower)
+	reading.BatterySOC = floatPtrFromNull(batterySOC)
+	reading.BatteryPower = floatPtrFromNull(batteryPower)
+	reading.GridFrequency = floatPtrFromNull(gridFrequency)
+	reading.GridVoltage = floatPtrFromNull(gridVoltage)
 	reading.RawData = stringPtrFromNull(rawData)
+	reading.Fallback = stringPtrFromNull(fallback)
 
 	// Deserialize source JSON fields
 	if generationSourcesJSON.Valid && generationSourcesJSON.String != "" {
@@ -87,18 +94,20 @@ func (s *Store) GetPlantReadingByID(ctx context.Context, id int64) (PlantReading
 // GetLatestPlantReading returns the most recent plant reading.
 func (s *Store) GetLatestPlantReading(ctx context.Context) (*PlantReading, error) {
 	var (
-		reading                                        PlantReading
-		rawData                                        sql.NullString
+		reading                                       PlantReading
+		rawData, fallback                             sql.NullString
 		generationSourcesJSON, consumptionSourcesJSON sql.NullString
+		exportedPower, batterySOC, batteryPower       sql.NullFloat64
+		gridFrequency, gridVoltage                    sql.NullFloat64
 	)
 
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, raw_data, recorded_at
+		SELECT id, plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, exported_power, battery_soc, battery_power, grid_frequency, grid_voltage, raw_data, recorded_at, fallback
 		FROM plant_readings
 		ORDER BY recorded_at DESC, id DESC
 		LIMIT 1
 	`).Scan(&reading.ID, &reading.PlantID, &reading.TotalGeneration, &reading.TotalContainerConsumption,
-		&reading.AvailablePower, &generationSourcesJSON, &consumptionSourcesJSON, &rawData, &reading.RecordedAt)
+		&reading.AvailablePower, &generationSourcesJSON, &consumptionSourcesJSON, &exportedPower, &batterySOC, &batteryPower, &gridFrequency, &gridVoltage, &rawData, &reading.RecordedAt, &fallback)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -106,7 +115,13 @@ func (s *Store) GetLatestPlantReading(ctx context.Context) (*PlantReading, error
 		return nil, fmt.Errorf("query latest plant reading: %w", err)
 	}
 
+	reading.ExportedPower = floatPtrFromNull(exportedPower)
+	reading.BatterySOC = floatPtrFromNull(batterySOC)
+	reading.BatteryPower = floatPtrFromNull(batteryPower)
+	reading.GridFrequency = floatPtrFromNull(gridFrequency)
+	reading.GridVoltage = floatPtrFromNull(gridVoltage)
 	reading.RawData = stringPtrFromNull(rawData)
+	reading.Fallback = stringPtrFromNull(fallback)
 
 	// Deserialize source JSON fields
 	if generationSourcesJSON.Valid && generationSourcesJSON.String != "" {
@@ -130,7 +145,7 @@ func (s *Store) ListPlantReadings(ctx context.Context, limit int) ([]PlantReadin
 	}
 
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, raw_data, recorded_at
+		SELECT id, plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, exported_power, battery_soc, battery_power, grid_frequency, grid_voltage, raw_data, recorded_at, fallback
 		FROM plant_readings
 		ORDER BY recorded_at DESC, id DESC
 		LIMIT ?
@@ -142,48 +157,114 @@ func (s *Store) ListPlantReadings(ctx context.Context, limit int) ([]PlantReadin
 
 	var readings []PlantReading
 	for rows.Next() {
-		var (
-			reading                                        PlantReading
-			rawData                                        sql.NullString
-			generationSourcesJSON, consumptionSourcesJSON sql.NullString
-		)
-
-		if err := rows.Scan(&reading.ID, &reading.PlantID, &reading.TotalGeneration,
-			&reading.TotalContainerConsumption, &reading.AvailablePower, &generationSourcesJSON, &consumptionSourcesJSON, &rawData, &reading.RecordedAt); err != nil {
-			return nil, fmt.Errorf("scan plant reading: %w", err)
+		reading, err := scanPlantReading(rows)
+		if err != nil {
+			return nil, err
 		}
+		readings = append(readings, reading)
+	}
 
-		reading.RawData = stringPtrFromNull(rawData)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate plant readings: %w", err)
+	}
 
-		// Deserialize source JSON fields
-		if generationSourcesJSON.Valid && generationSourcesJSON.String != "" {
-			if err := json.Unmarshal([]byte(generationSourcesJSON.String), &reading.GenerationSources); err != nil {
-				return nil, fmt.Errorf("unmarshal generation sources: %w", err)
+	return readings, nil
+}
+
+// ForEachPlantReading calls fn for every reading recorded in [from, to) in
+// chronological order, reading the table in pages like
+// ForEachPowerBalanceEvent.
+func (s *Store) ForEachPlantReading(ctx context.Context, from, to time.Time, fn func(PlantReading) error) error {
+	lastAt := from.UTC()
+	var lastID int64
+
+	for {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT id, plant_id, total_generation, total_container_consumption, available_power, generation_sources, consumption_sources, exported_power, battery_soc, battery_power, grid_frequency, grid_voltage, raw_data, recorded_at, fallback
+			FROM plant_readings
+			WHERE recorded_at >= ? AND recorded_at < ? AND (recorded_at > ? OR id > ?)
+			ORDER BY recorded_at, id
+			LIMIT ?
+		`, lastAt, to.UTC(), lastAt, lastID, exportPageSize)
+		if err != nil {
+			return fmt.Errorf("query plant readings: %w", err)
+		}
+
+		var page []PlantReading
+		for rows.Next() {
+			reading, err := scanPlantReading(rows)
+			if err != nil {
+				rows.Close()
+				return err
 			}
+			page = append(page, reading)
 		}
-		if consumptionSourcesJSON.Valid && consumptionSourcesJSON.String != "" {
-			if err := json.Unmarshal([]byte(consumptionSourcesJSON.String), &reading.ConsumptionSources); err != nil {
-				return nil, fmt.Errorf("unmarshal consumption sources: %w", err)
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("iterate plant readings: %w", err)
+		}
+
+		for _, reading := range page {
+			if err := fn(reading); err != nil {
+				return err
 			}
 		}
+		if len(page) < exportPageSize {
+			return nil
+		}
+		lastAt = page[len(page)-1].RecordedAt
+		lastID = page[len(page)-1].ID
+	}
+}
 
-		readings = append(readings, reading)
+func scanPlantReading(rows *sql.Rows) (PlantReading, error) {
+	var (
+		reading                                       PlantReading
+		rawData, fallback                             sql.NullString
+		generationSourcesJSON, consumptionSourcesJSON sql.NullString
+		exportedPower, batterySOC, batteryPower       sql.NullFloat64
+		gridFrequency, gridVoltage                    sql.NullFloat64
+	)
+
+	if err := rows.Scan(&reading.ID, &reading.PlantID, &reading.TotalGeneration,
+		&reading.TotalContainerConsumption, &reading.AvailablePower, &generationSourcesJSON, &consumptionSourcesJSON, &exportedPower, &batterySOC, &batteryPower, &gridFrequency, &gridVoltage, &rawData, &reading.RecordedAt, &fallback); err != nil {
+		return PlantReading{}, fmt.Errorf("scan plant reading: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate plant readings: %w", err)
+	reading.ExportedPower = floatPtrFromNull(exportedPower)
+	reading.BatterySOC = floatPtrFromNull(batterySOC)
+	reading.BatteryPower = floatPtrFromNull(batteryPower)
+	reading.GridFrequency = floatPtrFromNull(gridFrequency)
+	reading.GridVoltage = floatPtrFromNull(gridVoltage)
+	reading.RawData = stringPtrFromNull(rawData)
+	reading.Fallback = stringPtrFromNull(fallback)
+
+	// Deserialize source JSON fields
+	if generationSourcesJSON.Valid && generationSourcesJSON.String != "" {
+		if err := json.Unmarshal([]byte(generationSourcesJSON.String), &reading.GenerationSources); err != nil {
+			return PlantReading{}, fmt.Errorf("unmarshal generation sources: %w", err)
+		}
+	}
+	if consumptionSourcesJSON.Valid && consumptionSourcesJSON.String != "" {
+		if err := json.Unmarshal([]byte(consumptionSourcesJSON.String), &reading.ConsumptionSources); err != nil {
+			return PlantReading{}, fmt.Errorf("unmarshal consumption sources: %w", err)
+		}
 	}
 
-	return readings, nil
+	return reading, nil
 }
 
+// ErrSettingNotFound is returned by GetAppSetting for unknown keys.
+var ErrSettingNotFound = errors.New("setting not found")
+
 // GetAppSetting retrieves a setting value by key.
 func (s *Store) GetAppSetting(ctx context.Context, key string) (string, error) {
 	var value string
 	err := s.db.QueryRowContext(ctx, `SELECT value FROM app_settings WHERE key = ?`, key).Scan(&value)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("setting %q not found", key)
+			return "", fmt.Errorf("%w: %q", ErrSettingNotFound, key)
 		}
 		return "", fmt.Errorf("query setting %q: %w", key, err)
 	}
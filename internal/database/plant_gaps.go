@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Plant gap reasons: why the poller had no usable reading.
+const (
+	PlantGapUnreachable   = "unreachable"
+	PlantGapLowConfidence = "low_confidence"
+	PlantGapStale         = "stale"
+)
+
+// PlantGap is a stretch without usable plant readings. EndedAt is nil while
+// it lasts, and Alerted is set once it lasted long enough to alert on.
+type PlantGap struct {
+	ID            int64
+	Reason        string
+	LastReadingAt *time.Time
+	StartedAt     time.Time
+	EndedAt       *time.Time
+	LastError     *string
+	Alerted       bool
+}
+
+// Duration is how long the gap lasted, up to now while it is open.
+func (g PlantGap) Duration(now time.Time) time.Duration {
+	if g.EndedAt != nil {
+		return g.EndedAt.Sub(g.StartedAt)
+	}
+	return now.Sub(g.StartedAt)
+}
+
+const plantGapColumns = `id, reason, last_reading_at, started_at, ended_at, last_error, alerted`
+
+// OpenPlantGap returns the gap still open, recording lastError on it, or
+// starts a new one at startedAt. An open gap survives restarts, so a
+// restart in the middle of an outage does not split it.
+func (s *Store) OpenPlantGap(ctx context.Context, reason string, lastReadingAt *time.Time, startedAt time.Time, lastError *string) (PlantGap, error) {
+	gap, err := s.CurrentPlantGap(ctx)
+	if err != nil {
+		return PlantGap{}, err
+	}
+	if gap != nil {
+		if _, err := s.db.ExecContext(ctx, `UPDATE plant_gaps SET last_error = ? WHERE id = ?`,
+			nullableTrimmedString(lastError), gap.ID); err != nil {
+			return PlantGap{}, fmt.Errorf("update plant gap %d: %w", gap.ID, err)
+		}
+		gap.LastError = lastError
+		return *gap, nil
+	}
+
+	var id int64
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO plant_gaps (reason, last_reading_at, started_at, last_error)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`, reason, nullableTime(lastReadingAt), startedAt.UTC(), nullableTrimmedString(lastError)).Scan(&id)
+	if err != nil {
+		return PlantGap{}, fmt.Errorf("insert plant gap: %w", err)
+	}
+	return PlantGap{
+		ID:            id,
+		Reason:        reason,
+		LastReadingAt: lastReadingAt,
+		StartedAt:     startedAt,
+		LastError:     lastError,
+	}, nil
+}
+
+// CurrentPlantGap returns the gap still open, or nil when readings are
+// arriving.
+func (s *Store) CurrentPlantGap(ctx context.Context) (*PlantGap, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+plantGapColumns+`
+		FROM plant_gaps
+		WHERE ended_at IS NULL
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query open plant gap: %w", err)
+	}
+	gaps, err := scanPlantGaps(rows)
+	if err != nil || len(gaps) == 0 {
+		return nil, err
+	}
+	return &gaps[0], nil
+}
+
+// MarkPlantGapAlerted records that the gap was alerted on.
+func (s *Store) MarkPlantGapAlerted(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE plant_gaps SET alerted = 1 WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("mark plant gap %d alerted: %w", id, err)
+	}
+	return nil
+}
+
+// ClosePlantGaps ends every open gap at endedAt and returns them.
+func (s *Store) ClosePlantGaps(ctx context.Context, endedAt time.Time) ([]PlantGap, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+plantGapColumns+` FROM plant_gaps WHERE ended_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("query open plant gaps: %w", err)
+	}
+	gaps, err := scanPlantGaps(rows)
+	if err != nil || len(gaps) == 0 {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE plant_gaps SET ended_at = ? WHERE ended_at IS NULL`, endedAt.UTC()); err != nil {
+		return nil, fmt.Errorf("close plant gaps: %w", err)
+	}
+	for i := range gaps {
+		ended := endedAt
+		gaps[i].EndedAt = &ended
+	}
+	return gaps, nil
+}
+
+// ListPlantGaps returns up to limit gaps, newest first.
+func (s *Store) ListPlantGaps(ctx context.Context, limit int) ([]PlantGap, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+plantGapColumns+`
+		FROM plant_gaps
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query plant gaps: %w", err)
+	}
+	return scanPlantGaps(rows)
+}
+
+func scanPlantGaps(rows *sql.Rows) ([]PlantGap, error) {
+	defer rows.Close()
+
+	var gaps []PlantGap
+	for rows.Next() {
+		var (
+			gap           PlantGap
+			lastReadingAt sql.NullTime
+			endedAt       sql.NullTime
+			lastError     sql.NullString
+			alerted       int64
+		)
+		if err := rows.Scan(&gap.ID, &gap.Reason, &lastReadingAt, &gap.StartedAt, &endedAt, &lastError, &alerted); err != nil {
+			return nil, fmt.Errorf("scan plant gap: %w", err)
+		}
+		gap.LastReadingAt = timePtrFromNull(lastReadingAt)
+		gap.EndedAt = timePtrFromNull(endedAt)
+		gap.LastError = stringPtrFromNull(lastError)
+		gap.Alerted = alerted != 0
+		gaps = append(gaps, gap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate plant gaps: %w", err)
+	}
+	return gaps, nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PlantDailyMetrics are the plant metrics derived for one calendar day.
+// Energies are in kWh.
+type PlantDailyMetrics struct {
+	// Day is the date in Timezone, as YYYY-MM-DD.
+	Day       string
+	Timezone  string
+	Generated float64
+	Container float64
+	// Surplus is generation the containers did not consume.
+	Surplus float64
+	// CurtailedAtMax is the part of Surplus left while every miner the
+	// balancer could raise was already at its maximum preset.
+	CurtailedAtMax float64
+	// Short is container consumption beyond generation.
+	Short float64
+	// AtMax is how long the fleet spent at its maximum presets.
+	AtMax time.Duration
+	// CapacityKW is the nameplate capacity the capacity factor was based
+	// on; both are nil when no capacity is configured.
+	CapacityKW     *float64
+	CapacityFactor *float64
+	// Covered is the part of the day backed by plant readings and Coverage
+	// its fraction of the day, or of the day so far for today.
+	Covered    time.Duration
+	Coverage   float64
+	ComputedAt time.Time
+}
+
+// SavePlantDailyMetrics stores a day's metrics, replacing an earlier
+// computation of the same day.
+func (s *Store) SavePlantDailyMetrics(ctx context.Context, metrics PlantDailyMetrics) error {
+	computedAt := metrics.ComputedAt
+	if computedAt.IsZero() {
+		computedAt = time.Now()
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO plant_daily_metrics (
+			day, timezone, generated_kwh, container_kwh, surplus_kwh, curtailed_at_max_kwh,
+			short_kwh, at_max_seconds, capacity_kw, capacity_factor, covered_seconds, coverage, computed_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(day, timezone) DO UPDATE SET
+			generated_kwh = excluded.generated_kwh,
+			container_kwh = excluded.container_kwh,
+			surplus_kwh = excluded.surplus_kwh,
+			curtailed_at_max_kwh = excluded.curtailed_at_max_kwh,
+			short_kwh = excluded.short_kwh,
+			at_max_seconds = excluded.at_max_seconds,
+			capacity_kw = excluded.capacity_kw,
+			capacity_factor = excluded.capacity_factor,
+			covered_seconds = excluded.covered_seconds,
+			coverage = excluded.coverage,
+			computed_at = excluded.computed_at
+	`, metrics.Day, metrics.Timezone, metrics.Generated, metrics.Container, metrics.Surplus, metrics.CurtailedAtMax,
+		metrics.Short, metrics.AtMax.Seconds(), nullableFloat64(metrics.CapacityKW), nullableFloat64(metrics.CapacityFactor),
+		metrics.Covered.Seconds(), metrics.Coverage, computedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("save plant metrics for %s: %w", metrics.Day, err)
+	}
+	return nil
+}
+
+// ListPlantDailyMetrics returns the stored metrics of the days from
+// fromDay to toDay inclusive (YYYY-MM-DD) in timezone, oldest first.
+func (s *Store) ListPlantDailyMetrics(ctx context.Context, timezone, fromDay, toDay string) ([]PlantDailyMetrics, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT day, timezone, generated_kwh, container_kwh, surplus_kwh, curtailed_at_max_kwh,
+			short_kwh, at_max_seconds, capacity_kw, capacity_factor, covered_seconds, coverage, computed_at
+		FROM plant_daily_metrics
+		WHERE timezone = ? AND day >= ? AND day <= ?
+		ORDER BY day
+	`, timezone, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("query plant metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PlantDailyMetrics
+	for rows.Next() {
+		var (
+			metrics                      PlantDailyMetrics
+			atMaxSeconds, coveredSeconds float64
+			capacityKW, capacityFactor   sql.NullFloat64
+		)
+		if err := rows.Scan(&metrics.Day, &metrics.Timezone, &metrics.Generated, &metrics.Container, &metrics.Surplus,
+			&metrics.CurtailedAtMax, &metrics.Short, &atMaxSeconds, &capacityKW, &capacityFactor,
+			&coveredSeconds, &metrics.Coverage, &metrics.ComputedAt); err != nil {
+			return nil, fmt.Errorf("scan plant metrics: %w", err)
+		}
+		metrics.AtMax = time.Duration(atMaxSeconds * float64(time.Second))
+		metrics.Covered = time.Duration(coveredSeconds * float64(time.Second))
+		metrics.CapacityKW = floatPtrFromNull(capacityKW)
+		metrics.CapacityFactor = floatPtrFromNull(capacityFactor)
+		out = append(out, metrics)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate plant metrics: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// QuarantinedReading is a plant reading rejected for its confidence score,
+// kept for later analysis. Action is the low-confidence policy applied in
+// its place.
+type QuarantinedReading struct {
+	ID                        int64
+	PlantID                   string
+	Confidence                float64
+	TrustStatus               *string
+	TrustSummary              *string
+	TotalGeneration           float64
+	TotalContainerConsumption float64
+	Action                    string
+	RawData                   *string
+	RecordedAt                time.Time
+	QuarantinedAt             time.Time
+}
+
+// QuarantinedReadingInput is used when quarantining a reading.
+type QuarantinedReadingInput struct {
+	PlantID                   string
+	Confidence                float64
+	TrustStatus               *string
+	TrustSummary              *string
+	TotalGeneration           float64
+	TotalContainerConsumption float64
+	Action                    string
+	RawData                   *string
+	RecordedAt                time.Time
+}
+
+const quarantinedReadingColumns = `id, plant_id, confidence, trust_status, trust_summary, total_generation, total_container_consumption, action, raw_data, recorded_at, quarantined_at`
+
+// QuarantinePlantReading stores a rejected reading.
+func (s *Store) QuarantinePlantReading(ctx context.Context, input QuarantinedReadingInput) error {
+	recordedAt := input.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = time.Now().UTC()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO plant_reading_quarantine (plant_id, confidence, trust_status, trust_summary, total_generation, total_container_consumption, action, raw_data, recorded_at, quarantined_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, input.PlantID, input.Confidence, nullableTrimmedString(input.TrustStatus), nullableTrimmedString(input.TrustSummary),
+		input.TotalGeneration, input.TotalContainerConsumption, input.Action, nullableString(input.RawData),
+		recordedAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("quarantine plant reading: %w", err)
+	}
+	return nil
+}
+
+// ListQuarantinedReadings returns up to limit quarantined readings recorded
+// in [from, to), newest first.
+func (s *Store) ListQuarantinedReadings(ctx context.Context, from, to time.Time, limit int) ([]QuarantinedReading, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+quarantinedReadingColumns+`
+		FROM plant_reading_quarantine
+		WHERE recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT ?
+	`, from.UTC(), to.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query quarantined readings: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []QuarantinedReading
+	for rows.Next() {
+		var (
+			reading                   QuarantinedReading
+			trustStatus, trustSummary sql.NullString
+			rawData                   sql.NullString
+		)
+		if err := rows.Scan(&reading.ID, &reading.PlantID, &reading.Confidence, &trustStatus, &trustSummary,
+			&reading.TotalGeneration, &reading.TotalContainerConsumption, &reading.Action, &rawData,
+			&reading.RecordedAt, &reading.QuarantinedAt); err != nil {
+			return nil, fmt.Errorf("scan quarantined reading: %w", err)
+		}
+		reading.TrustStatus = stringPtrFromNull(trustStatus)
+		reading.TrustSummary = stringPtrFromNull(trustSummary)
+		reading.RawData = stringPtrFromNull(rawData)
+		readings = append(readings, reading)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate quarantined readings: %w", err)
+	}
+	return readings, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Plant source kinds, naming the reading map a source is reported in.
+const (
+	PlantSourceGeneration  = "generation"
+	PlantSourceConsumption = "consumption"
+)
+
+// PlantSourceSummary describes one generation or consumption source over a
+// window of plant readings. Values are in MW, as the aggregator reports
+// them.
+type PlantSourceSummary struct {
+	Name     string
+	Kind     string
+	Samples  int
+	LatestMW float64
+	LatestAt time.Time
+	MinMW    float64
+	MaxMW    float64
+	AvgMW    float64
+}
+
+// PlantSourcePoint is one reading of a single source.
+type PlantSourcePoint struct {
+	RecordedAt time.Time
+	MW         float64
+}
+
+// plantSources returns the source map of a reading for kind.
+func plantSources(reading PlantReading, kind string) map[string]float64 {
+	if kind == PlantSourceConsumption {
+		return reading.ConsumptionSources
+	}
+	return reading.GenerationSources
+}
+
+// SummarizePlantSources lists every source reported by plant readings in
+// [from, to), generation sources first, each sorted by name.
+func (s *Store) SummarizePlantSources(ctx context.Context, from, to time.Time) ([]PlantSourceSummary, error) {
+	type key struct{ kind, name string }
+	summaries := make(map[key]*PlantSourceSummary)
+
+	err := s.ForEachPlantReading(ctx, from, to, func(reading PlantReading) error {
+		for _, kind := range []string{PlantSourceGeneration, PlantSourceConsumption} {
+			for name, mw := range plantSources(reading, kind) {
+				summary, ok := summaries[key{kind, name}]
+				if !ok {
+					summary = &PlantSourceSummary{Name: name, Kind: kind, MinMW: mw, MaxMW: mw}
+					summaries[key{kind, name}] = summary
+				}
+				summary.Samples++
+				summary.AvgMW += mw
+				summary.MinMW = min(summary.MinMW, mw)
+				summary.MaxMW = max(summary.MaxMW, mw)
+				summary.LatestMW = mw
+				summary.LatestAt = reading.RecordedAt
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("summarize plant sources: %w", err)
+	}
+
+	out := make([]PlantSourceSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		summary.AvgMW /= float64(summary.Samples)
+		out = append(out, *summary)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind == PlantSourceGeneration
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// PlantSourceHistory returns the readings of one source in [from, to),
+// oldest first. With a positive step, readings are averaged into buckets of
+// that length, each stamped with its start. Readings that do not report
+// the source are skipped. It returns at most limit points.
+func (s *Store) PlantSourceHistory(ctx context.Context, kind, name string, from, to time.Time, step time.Duration, limit int) ([]PlantSourcePoint, error) {
+	var (
+		out         []PlantSourcePoint
+		bucketStart time.Time
+		bucketSum   float64
+		bucketCount int
+	)
+	flush := func() {
+		if bucketCount > 0 {
+			out = append(out, PlantSourcePoint{RecordedAt: bucketStart, MW: bucketSum / float64(bucketCount)})
+		}
+		bucketSum, bucketCount = 0, 0
+	}
+
+	errLimit := errors.New("limit reached")
+	err := s.ForEachPlantReading(ctx, from, to, func(reading PlantReading) error {
+		mw, ok := plantSources(reading, kind)[name]
+		if !ok {
+			return nil
+		}
+		if step <= 0 {
+			out = append(out, PlantSourcePoint{RecordedAt: reading.RecordedAt, MW: mw})
+		} else {
+			start := from.Add(reading.RecordedAt.Sub(from).Truncate(step))
+			if bucketCount > 0 && !start.Equal(bucketStart) {
+				flush()
+			}
+			bucketStart = start
+			bucketSum += mw
+			bucketCount++
+		}
+		if limit > 0 && len(out) >= limit {
+			return errLimit
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errLimit) {
+		return nil, fmt.Errorf("plant source history: %w", err)
+	}
+	if err == nil {
+		flush()
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PoolWorker ties a pool worker name to the miner that reported it in its
+// latest status.
+type PoolWorker struct {
+	Worker     string
+	MinerID    string
+	MinerName  *string
+	PoolIndex  int
+	URL        *string
+	Status     *string
+	RecordedAt time.Time
+}
+
+// ListPoolWorkers returns the worker of every pool in the latest status of
+// each miner that is not archived, ordered by worker name. A worker shared
+// by several miners is listed once per miner.
+func (s *Store) ListPoolWorkers(ctx context.Context) ([]PoolWorker, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.worker, m.id, m.name, p.pool_index, p.url, p.status, st.recorded_at
+		FROM miners m
+		JOIN statuses st ON st.id = m.latest_status_id
+		JOIN status_pools p ON p.status_id = st.id
+		WHERE m.archived_at IS NULL AND p.worker IS NOT NULL AND p.worker <> ''
+		ORDER BY p.worker, m.id, p.pool_index
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query pool workers: %w", err)
+	}
+	defer rows.Close()
+
+	var workers []PoolWorker
+	for rows.Next() {
+		var (
+			worker           PoolWorker
+			name, url, state sql.NullString
+		)
+		if err := rows.Scan(&worker.Worker, &worker.MinerID, &name, &worker.PoolIndex, &url, &state, &worker.RecordedAt); err != nil {
+			return nil, fmt.Errorf("scan pool worker: %w", err)
+		}
+		worker.MinerName = stringPtrFromNull(name)
+		worker.URL = stringPtrFromNull(url)
+		worker.Status = stringPtrFromNull(state)
+		workers = append(workers, worker)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pool workers: %w", err)
+	}
+	return workers, nil
+}
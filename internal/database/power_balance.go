@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -25,13 +27,15 @@ func (s *Store) RecordPowerBalanceEvent(ctx context.Context, input PowerBalanceE
 		successInt = 1
 	}
 
-	res, err := s.db.ExecContext(ctx, `
+	var id int64
+	err := s.db.QueryRowContext(ctx, `
 		INSERT INTO power_balance_events (
 			miner_id, old_preset, new_preset, old_power, new_power, reason,
 			total_consumption_before, total_consumption_after, available_power, target_power,
 			success, error_message, recorded_at
 		)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
 	`, minerID,
 		nullableString(input.OldPreset),
 		nullableString(input.NewPreset),
@@ -44,45 +48,255 @@ func (s *Store) RecordPowerBalanceEvent(ctx context.Context, input PowerBalanceE
 		nullableFloat64(input.TargetPower),
 		successInt,
 		nullableString(input.ErrorMessage),
-		recordedAt)
+		recordedAt).Scan(&id)
 	if err != nil {
 		return PowerBalanceEvent{}, fmt.Errorf("insert power balance event for miner %s: %w", minerID, err)
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return PowerBalanceEvent{}, fmt.Errorf("read power balance event id: %w", err)
-	}
-
 	return s.GetPowerBalanceEventByID(ctx, id)
 }
 
 // GetPowerBalanceEventByID retrieves a single power balance event by ID.
 func (s *Store) GetPowerBalanceEventByID(ctx context.Context, id int64) (PowerBalanceEvent, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+powerBalanceEventColumns+` FROM power_balance_events WHERE id = ?`, id)
+	event, err := scanPowerBalanceEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PowerBalanceEvent{}, fmt.Errorf("power balance event %d not found", id)
+	}
+	if err != nil {
+		return PowerBalanceEvent{}, fmt.Errorf("query power balance event %d: %w", id, err)
+	}
+	return event, nil
+}
+
+// ListPowerBalanceEvents returns recent power balance events, optionally filtered by miner.
+func (s *Store) ListPowerBalanceEvents(ctx context.Context, minerID *string, limit int) ([]PowerBalanceEvent, error) {
+	filter := PowerBalanceEventFilter{Limit: limit}
+	if minerID != nil {
+		filter.MinerID = *minerID
+	}
+	return s.FilterPowerBalanceEvents(ctx, filter)
+}
+
+// PowerBalanceEventFilter narrows FilterPowerBalanceEvents. Empty fields
+// match everything.
+type PowerBalanceEventFilter struct {
+	MinerID      string
+	Success      *bool
+	Acknowledged *bool
+	Limit        int
+}
+
+// FilterPowerBalanceEvents returns the most recent events matching filter,
+// newest first. Success false with Acknowledged false lists the failures
+// nobody has worked through yet.
+func (s *Store) FilterPowerBalanceEvents(ctx context.Context, filter PowerBalanceEventFilter) ([]PowerBalanceEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
+	query := `SELECT ` + powerBalanceEventColumns + ` FROM power_balance_events`
 	var (
-		event        PowerBalanceEvent
-		oldPreset    sql.NullString
-		newPreset    sql.NullString
-		oldPower     sql.NullFloat64
-		newPower     sql.NullFloat64
-		consumBefore sql.NullFloat64
-		consumAfter  sql.NullFloat64
-		availPower   sql.NullFloat64
-		targetPower  sql.NullFloat64
-		successInt   int
-		errorMsg     sql.NullString
+		where []string
+		args  []any
 	)
+	if filter.MinerID != "" {
+		where = append(where, "miner_id = ?")
+		args = append(args, filter.MinerID)
+	}
+	if filter.Success != nil {
+		if *filter.Success {
+			where = append(where, "success = 1")
+		} else {
+			where = append(where, "success = 0")
+		}
+	}
+	if filter.Acknowledged != nil {
+		if *filter.Acknowledged {
+			where = append(where, "acknowledged_at IS NOT NULL")
+		} else {
+			where = append(where, "acknowledged_at IS NULL")
+		}
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY recorded_at DESC, id DESC LIMIT ?"
+	args = append(args, filter.Limit)
 
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, miner_id, old_preset, new_preset, old_power, new_power, reason,
-			total_consumption_before, total_consumption_after, available_power, target_power,
-			success, error_message, recorded_at
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query power balance events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []PowerBalanceEvent
+	for rows.Next() {
+		event, err := scanPowerBalanceEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan power balance event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate power balance events: %w", err)
+	}
+
+	return events, nil
+}
+
+// PowerBalanceEventAnnotation changes how an event has been followed up.
+// Nil fields are left alone. A non-empty AcknowledgedBy acknowledges the
+// event at the given time and an empty one withdraws the acknowledgment;
+// an empty Note clears the note.
+type PowerBalanceEventAnnotation struct {
+	AcknowledgedBy *string
+	Note           *string
+}
+
+// AnnotatePowerBalanceEvent acknowledges or notes an event and returns it.
+func (s *Store) AnnotatePowerBalanceEvent(ctx context.Context, id int64, annotation PowerBalanceEventAnnotation, now time.Time) (PowerBalanceEvent, error) {
+	now = now.UTC()
+	sets := []string{"annotated_at = ?"}
+	args := []any{now}
+	if annotation.AcknowledgedBy != nil {
+		by := strings.TrimSpace(*annotation.AcknowledgedBy)
+		if by == "" {
+			sets = append(sets, "acknowledged_by = NULL", "acknowledged_at = NULL")
+		} else {
+			sets = append(sets, "acknowledged_by = ?", "acknowledged_at = ?")
+			args = append(args, by, now)
+		}
+	}
+	if annotation.Note != nil {
+		var note any
+		if trimmed := strings.TrimSpace(*annotation.Note); trimmed != "" {
+			note = trimmed
+		}
+		sets = append(sets, "note = ?")
+		args = append(args, note)
+	}
+	args = append(args, id)
+
+	res, err := s.db.ExecContext(ctx, `UPDATE power_balance_events SET `+strings.Join(sets, ", ")+` WHERE id = ?`, args...)
+	if err != nil {
+		return PowerBalanceEvent{}, fmt.Errorf("annotate power balance event %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return PowerBalanceEvent{}, fmt.Errorf("power balance event %d not found", id)
+	}
+	return s.GetPowerBalanceEventByID(ctx, id)
+}
+
+// CountPresetChanges returns how many successful preset changes each miner
+// had since the given time.
+func (s *Store) CountPresetChanges(ctx context.Context, since time.Time) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT miner_id, COUNT(*)
 		FROM power_balance_events
-		WHERE id = ?
-	`, id).Scan(&event.ID, &event.MinerID, &oldPreset, &newPreset, &oldPower, &newPower, &event.Reason,
-		&consumBefore, &consumAfter, &availPower, &targetPower, &successInt, &errorMsg, &event.RecordedAt)
+		WHERE success = 1 AND recorded_at >= ?
+		GROUP BY miner_id
+	`, since.UTC())
 	if err != nil {
-		return PowerBalanceEvent{}, fmt.Errorf("query power balance event %d: %w", id, err)
+		return nil, fmt.Errorf("count preset changes: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			minerID string
+			count   int
+		)
+		if err := rows.Scan(&minerID, &count); err != nil {
+			return nil, fmt.Errorf("scan preset change count: %w", err)
+		}
+		counts[minerID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate preset change counts: %w", err)
+	}
+	return counts, nil
+}
+
+// exportPageSize bounds how many rows an export reads per query, so the
+// connection is released between pages while the caller writes them out.
+const exportPageSize = 500
+
+// ForEachPowerBalanceEvent calls fn for every event recorded in [from, to)
+// in chronological order. Rows are read in pages; an error from fn stops
+// the iteration and is returned.
+func (s *Store) ForEachPowerBalanceEvent(ctx context.Context, from, to time.Time, fn func(PowerBalanceEvent) error) error {
+	lastAt := from.UTC()
+	var lastID int64
+
+	for {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT `+powerBalanceEventColumns+`
+			FROM power_balance_events
+			WHERE recorded_at >= ? AND recorded_at < ? AND (recorded_at > ? OR id > ?)
+			ORDER BY recorded_at, id
+			LIMIT ?
+		`, lastAt, to.UTC(), lastAt, lastID, exportPageSize)
+		if err != nil {
+			return fmt.Errorf("query power balance events: %w", err)
+		}
+
+		var page []PowerBalanceEvent
+		for rows.Next() {
+			event, err := scanPowerBalanceEvent(rows)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("scan power balance event: %w", err)
+			}
+			page = append(page, event)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("iterate power balance events: %w", err)
+		}
+
+		for _, event := range page {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		if len(page) < exportPageSize {
+			return nil
+		}
+		lastAt = page[len(page)-1].RecordedAt
+		lastID = page[len(page)-1].ID
+	}
+}
+
+const powerBalanceEventColumns = `id, miner_id, old_preset, new_preset, old_power, new_power, reason,
+	total_consumption_before, total_consumption_after, available_power, target_power,
+	success, error_message, recorded_at, acknowledged_at, acknowledged_by, note`
+
+func scanPowerBalanceEvent(row interface{ Scan(...any) error }) (PowerBalanceEvent, error) {
+	var (
+		event          PowerBalanceEvent
+		oldPreset      sql.NullString
+		newPreset      sql.NullString
+		oldPower       sql.NullFloat64
+		newPower       sql.NullFloat64
+		consumBefore   sql.NullFloat64
+		consumAfter    sql.NullFloat64
+		availPower     sql.NullFloat64
+		targetPower    sql.NullFloat64
+		successInt     int
+		errorMsg       sql.NullString
+		acknowledgedAt sql.NullTime
+		acknowledgedBy sql.NullString
+		note           sql.NullString
+	)
+
+	if err := row.Scan(&event.ID, &event.MinerID, &oldPreset, &newPreset, &oldPower, &newPower, &event.Reason,
+		&consumBefore, &consumAfter, &availPower, &targetPower, &successInt, &errorMsg, &event.RecordedAt,
+		&acknowledgedAt, &acknowledgedBy, &note); err != nil {
+		return PowerBalanceEvent{}, err
 	}
 
 	event.OldPreset = stringPtrFromNull(oldPreset)
@@ -95,76 +309,129 @@ func (s *Store) GetPowerBalanceEventByID(ctx context.Context, id int64) (PowerBa
 	event.TargetPower = floatPtrFromNull(targetPower)
 	event.Success = successInt == 1
 	event.ErrorMessage = stringPtrFromNull(errorMsg)
+	event.AcknowledgedAt = timePtrFromNull(acknowledgedAt)
+	event.AcknowledgedBy = stringPtrFromNull(acknowledgedBy)
+	event.Note = stringPtrFromNull(note)
 
 	return event, nil
 }
 
-// ListPowerBalanceEvents returns recent power balance events, optionally filtered by miner.
-func (s *Store) ListPowerBalanceEvents(ctx context.Context, minerID *string, limit int) ([]PowerBalanceEvent, error) {
-	if limit <= 0 {
-		limit = 100
+// PresetChangeCooldown is the minimum time between two balancer preset
+// changes on the same miner.
+const PresetChangeCooldown = 30 * time.Second
+
+// RecordPresetChange stores when the balancer changed the miner's preset.
+func (s *Store) RecordPresetChange(ctx context.Context, minerID string, at time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE miners SET last_preset_change_at = ? WHERE id = ?
+	`, at.UTC(), minerID); err != nil {
+		return fmt.Errorf("record preset change for miner %s: %w", minerID, err)
 	}
+	return nil
+}
 
-	query := `
-		SELECT id, miner_id, old_preset, new_preset, old_power, new_power, reason,
-			total_consumption_before, total_consumption_after, available_power, target_power,
-			success, error_message, recorded_at
-		FROM power_balance_events
-	`
-	args := []any{}
+// SetRebootWindow marks the miner as rebooting until until, or ends the
+// window when until is nil.
+func (s *Store) SetRebootWindow(ctx context.Context, minerID string, until *time.Time) error {
+	var value any
+	if until != nil {
+		value = until.UTC()
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE miners SET reboot_until = ? WHERE id = ?
+	`, value, minerID); err != nil {
+		return fmt.Errorf("set reboot window for miner %s: %w", minerID, err)
+	}
+	return nil
+}
 
-	if minerID != nil && *minerID != "" {
-		query += " WHERE miner_id = ?"
-		args = append(args, *minerID)
+// CooldownRemaining returns how long the balancer must still wait before
+// changing the miner's preset again.
+func (m Miner) CooldownRemaining(now time.Time) time.Duration {
+	if m.LastPresetChangeAt == nil {
+		return 0
 	}
+	return max(PresetChangeCooldown-now.Sub(*m.LastPresetChangeAt), 0)
+}
 
-	query += " ORDER BY recorded_at DESC, id DESC LIMIT ?"
-	args = append(args, limit)
+const balanceModeKey = "balance_mode"
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+// BalanceMode selects how the balancer derives its power target.
+type BalanceMode string
+
+const (
+	// BalanceModeFixedHeadroom targets generation minus the safety margin.
+	BalanceModeFixedHeadroom BalanceMode = "fixed_headroom"
+	// BalanceModeZeroExport targets the consumption at which the plant
+	// exports nothing, soaking up all surplus.
+	BalanceModeZeroExport BalanceMode = "zero_export"
+)
+
+// Valid reports whether m is a known mode.
+func (m BalanceMode) Valid() bool {
+	return m == BalanceModeFixedHeadroom || m == BalanceModeZeroExport
+}
+
+// GetBalanceMode returns the stored mode, defaulting to fixed headroom.
+func (s *Store) GetBalanceMode(ctx context.Context) (BalanceMode, error) {
+	raw, err := s.GetAppSetting(ctx, balanceModeKey)
 	if err != nil {
-		return nil, fmt.Errorf("query power balance events: %w", err)
+		if errors.Is(err, ErrSettingNotFound) {
+			return BalanceModeFixedHeadroom, nil
+		}
+		return "", err
 	}
-	defer rows.Close()
 
-	var events []PowerBalanceEvent
-	for rows.Next() {
-		var (
-			event        PowerBalanceEvent
-			oldPreset    sql.NullString
-			newPreset    sql.NullString
-			oldPower     sql.NullFloat64
-			newPower     sql.NullFloat64
-			consumBefore sql.NullFloat64
-			consumAfter  sql.NullFloat64
-			availPower   sql.NullFloat64
-			targetPower  sql.NullFloat64
-			successInt   int
-			errorMsg     sql.NullString
-		)
+	var mode BalanceMode
+	if err := json.Unmarshal([]byte(raw), &mode); err != nil {
+		return "", fmt.Errorf("decode balance mode: %w", err)
+	}
+	return mode, nil
+}
 
-		if err := rows.Scan(&event.ID, &event.MinerID, &oldPreset, &newPreset, &oldPower, &newPower, &event.Reason,
-			&consumBefore, &consumAfter, &availPower, &targetPower, &successInt, &errorMsg, &event.RecordedAt); err != nil {
-			return nil, fmt.Errorf("scan power balance event: %w", err)
-		}
+// SetBalanceMode stores mode.
+func (s *Store) SetBalanceMode(ctx context.Context, mode BalanceMode) error {
+	data, err := json.Marshal(mode)
+	if err != nil {
+		return fmt.Errorf("encode balance mode: %w", err)
+	}
+	return s.SetAppSetting(ctx, balanceModeKey, string(data))
+}
 
-		event.OldPreset = stringPtrFromNull(oldPreset)
-		event.NewPreset = stringPtrFromNull(newPreset)
-		event.OldPower = floatPtrFromNull(oldPower)
-		event.NewPower = floatPtrFromNull(newPower)
-		event.TotalConsumptionBefore = floatPtrFromNull(consumBefore)
-		event.TotalConsumptionAfter = floatPtrFromNull(consumAfter)
-		event.AvailablePower = floatPtrFromNull(availPower)
-		event.TargetPower = floatPtrFromNull(targetPower)
-		event.Success = successInt == 1
-		event.ErrorMessage = stringPtrFromNull(errorMsg)
+const balancerPauseKey = "balancer_pause"
 
-		events = append(events, event)
+// BalancerPause records whether automatic balancing is paused. While it is,
+// the balancer leaves presets alone except to derate overheating miners.
+// By says who paused it, such as "api" or a Telegram chat.
+type BalancerPause struct {
+	Paused bool       `json:"paused"`
+	Since  *time.Time `json:"since,omitempty"`
+	By     string     `json:"by,omitempty"`
+}
+
+// GetBalancerPause returns the stored pause, which is unpaused until one is
+// saved.
+func (s *Store) GetBalancerPause(ctx context.Context) (BalancerPause, error) {
+	raw, err := s.GetAppSetting(ctx, balancerPauseKey)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return BalancerPause{}, nil
+		}
+		return BalancerPause{}, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate power balance events: %w", err)
+	var pause BalancerPause
+	if err := json.Unmarshal([]byte(raw), &pause); err != nil {
+		return BalancerPause{}, fmt.Errorf("decode balancer pause: %w", err)
 	}
+	return pause, nil
+}
 
-	return events, nil
+// SetBalancerPause stores pause.
+func (s *Store) SetBalancerPause(ctx context.Context, pause BalancerPause) error {
+	data, err := json.Marshal(pause)
+	if err != nil {
+		return fmt.Errorf("encode balancer pause: %w", err)
+	}
+	return s.SetAppSetting(ctx, balancerPauseKey, string(data))
 }
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PowerMeasurement is the power a miner drew at its PDU outlets, stored
+// with the power its firmware reported at the time. Outlets names them as
+// pdu/outlet, comma-separated for a miner on several outlets.
+type PowerMeasurement struct {
+	ID             int64
+	MinerID        string
+	Outlets        string
+	PowerW         float64
+	FirmwarePowerW *float64
+	RecordedAt     time.Time
+}
+
+const powerMeasurementColumns = `id, miner_id, outlets, power_w, firmware_power_w, recorded_at`
+
+// RecordPowerMeasurements stores one PDU poll's measurements in a single
+// transaction.
+func (s *Store) RecordPowerMeasurements(ctx context.Context, measurements []PowerMeasurement) error {
+	if len(measurements) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin power measurement tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, m := range measurements {
+		minerID := strings.TrimSpace(m.MinerID)
+		if minerID == "" {
+			return fmt.Errorf("miner id is required")
+		}
+		recordedAt := m.RecordedAt
+		if recordedAt.IsZero() {
+			recordedAt = time.Now()
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO power_measurements (miner_id, outlets, power_w, firmware_power_w, recorded_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, minerID, m.Outlets, m.PowerW, nullableFloat64(m.FirmwarePowerW), recordedAt.UTC()); err != nil {
+			return fmt.Errorf("insert power measurement for %s: %w", minerID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit power measurements: %w", err)
+	}
+	return nil
+}
+
+// LatestPowerMeasurements returns each miner's newest measurement taken
+// since the given time.
+func (s *Store) LatestPowerMeasurements(ctx context.Context, since time.Time) (map[string]PowerMeasurement, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+powerMeasurementColumns+`
+		FROM power_measurements
+		WHERE recorded_at >= ?
+		ORDER BY recorded_at, id
+	`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("query latest power measurements: %w", err)
+	}
+	measurements, err := scanPowerMeasurements(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]PowerMeasurement, len(measurements))
+	for _, m := range measurements {
+		latest[m.MinerID] = m
+	}
+	return latest, nil
+}
+
+// ListPowerMeasurements returns up to limit of a miner's measurements,
+// newest first.
+func (s *Store) ListPowerMeasurements(ctx context.Context, minerID string, limit int) ([]PowerMeasurement, error) {
+	minerID = strings.TrimSpace(minerID)
+	if minerID == "" {
+		return nil, fmt.Errorf("miner id is required")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+powerMeasurementColumns+`
+		FROM power_measurements
+		WHERE miner_id = ?
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT ?
+	`, minerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query power measurements: %w", err)
+	}
+	return scanPowerMeasurements(rows)
+}
+
+func scanPowerMeasurements(rows *sql.Rows) ([]PowerMeasurement, error) {
+	defer rows.Close()
+
+	var measurements []PowerMeasurement
+	for rows.Next() {
+		var (
+			m             PowerMeasurement
+			firmwarePower sql.NullFloat64
+		)
+		if err := rows.Scan(&m.ID, &m.MinerID, &m.Outlets, &m.PowerW, &firmwarePower, &m.RecordedAt); err != nil {
+			return nil, fmt.Errorf("scan power measurement: %w", err)
+		}
+		m.FirmwarePowerW = floatPtrFromNull(firmwarePower)
+		measurements = append(measurements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate power measurements: %w", err)
+	}
+	return measurements, nil
+}
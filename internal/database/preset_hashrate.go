@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// BackfillPresetHashrates sets the expected hashrate of presets that have
+// none, or only an earlier back-filled one, to the average hashrate miners
+// reported on them since since. Only statuses recorded after a miner's
+// latest preset change count, and a preset needs minSamples of them. It
+// returns how many presets were updated. Statuses hold GH/s; presets TH/s.
+func (s *Store) BackfillPresetHashrates(ctx context.Context, since time.Time, minSamples int) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.model_id, st.preset, AVG(st.hashrate), COUNT(*)
+		FROM statuses st
+		JOIN miners m ON m.id = st.miner_id
+		WHERE st.recorded_at >= ?
+			AND st.hashrate > 0
+			AND st.preset IS NOT NULL
+			AND m.model_id IS NOT NULL
+			AND (m.last_preset_change_at IS NULL OR st.recorded_at > m.last_preset_change_at)
+		GROUP BY m.model_id, st.preset
+		HAVING COUNT(*) >= ?
+	`, since.UTC(), minSamples)
+	if err != nil {
+		return 0, fmt.Errorf("query observed preset hashrates: %w", err)
+	}
+
+	type observation struct {
+		modelID    int64
+		preset     string
+		hashrateTH float64
+	}
+	var observed []observation
+	for rows.Next() {
+		var (
+			o       observation
+			avgGHS  float64
+			samples int64
+		)
+		if err := rows.Scan(&o.modelID, &o.preset, &avgGHS, &samples); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan observed preset hashrate: %w", err)
+		}
+		o.hashrateTH = math.Round(avgGHS/1000*10) / 10
+		observed = append(observed, o)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("iterate observed preset hashrates: %w", err)
+	}
+	rows.Close()
+
+	updated := 0
+	for _, o := range observed {
+		result, err := s.db.ExecContext(ctx, `
+			UPDATE model_presets
+			SET expected_hashrate_th = ?, hashrate_observed = 1
+			WHERE model_id = ? AND value = ?
+				AND (expected_hashrate_th IS NULL OR hashrate_observed = 1)
+		`, o.hashrateTH, o.modelID, o.preset)
+		if err != nil {
+			return updated, fmt.Errorf("back-fill hashrate of preset %s: %w", o.preset, err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			updated += int(n)
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrProfileExists is returned when a configuration profile name is taken.
+var ErrProfileExists = errors.New("profile name already in use")
+
+const configProfileColumns = `id, name, tag, cooling, misc, pools, created_at, updated_at`
+
+// Validate checks the profile name and each settings section it carries.
+func (input ConfigProfileInput) Validate() error {
+	if strings.TrimSpace(input.Name) == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if input.Cooling == nil && input.Misc == nil && len(input.Pools) == 0 {
+		return fmt.Errorf("profile must set cooling, misc or pools")
+	}
+	if input.Misc != nil && input.Misc.MinOperationalChains != nil && *input.Misc.MinOperationalChains < 0 {
+		return fmt.Errorf("min_operational_chains must not be negative")
+	}
+
+	settings := SettingsInput{Pools: input.Pools}
+	if input.Cooling != nil {
+		settings.Cooling = *input.Cooling
+	}
+	return settings.Validate()
+}
+
+// CreateConfigProfile stores a new configuration profile.
+func (s *Store) CreateConfigProfile(ctx context.Context, input ConfigProfileInput) (ConfigProfile, error) {
+	if err := input.Validate(); err != nil {
+		return ConfigProfile{}, err
+	}
+	name := strings.TrimSpace(input.Name)
+	cooling, misc, pools, err := encodeProfileSections(input)
+	if err != nil {
+		return ConfigProfile{}, err
+	}
+	if err := s.checkProfileName(ctx, name, 0); err != nil {
+		return ConfigProfile{}, err
+	}
+
+	now := time.Now().UTC()
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO config_profiles (name, tag, cooling, misc, pools, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, name, nullableString(profileTag(input.Tag)), cooling, misc, pools, now, now).Scan(&id); err != nil {
+		return ConfigProfile{}, fmt.Errorf("insert config profile %s: %w", name, err)
+	}
+	return s.GetConfigProfile(ctx, id)
+}
+
+// UpdateConfigProfile replaces every field of an existing profile.
+func (s *Store) UpdateConfigProfile(ctx context.Context, id int64, input ConfigProfileInput) (ConfigProfile, error) {
+	if err := input.Validate(); err != nil {
+		return ConfigProfile{}, err
+	}
+	name := strings.TrimSpace(input.Name)
+	cooling, misc, pools, err := encodeProfileSections(input)
+	if err != nil {
+		return ConfigProfile{}, err
+	}
+	if err := s.checkProfileName(ctx, name, id); err != nil {
+		return ConfigProfile{}, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE config_profiles
+		SET name = ?, tag = ?, cooling = ?, misc = ?, pools = ?, updated_at = ?
+		WHERE id = ?
+	`, name, nullableString(profileTag(input.Tag)), cooling, misc, pools, time.Now().UTC(), id)
+	if err != nil {
+		return ConfigProfile{}, fmt.Errorf("update config profile %d: %w", id, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return ConfigProfile{}, fmt.Errorf("config profile rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ConfigProfile{}, fmt.Errorf("config profile %d not found", id)
+	}
+	return s.GetConfigProfile(ctx, id)
+}
+
+// DeleteConfigProfile removes a profile. Miners keep the settings it applied.
+func (s *Store) DeleteConfigProfile(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM config_profiles WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete config profile %d: %w", id, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("config profile rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("config profile %d not found", id)
+	}
+	return nil
+}
+
+// GetConfigProfile returns a single configuration profile.
+func (s *Store) GetConfigProfile(ctx context.Context, id int64) (ConfigProfile, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+configProfileColumns+` FROM config_profiles WHERE id = ?`, id)
+	profile, err := scanConfigProfile(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ConfigProfile{}, fmt.Errorf("config profile %d not found", id)
+		}
+		return ConfigProfile{}, err
+	}
+	return profile, nil
+}
+
+// ListConfigProfiles returns every profile ordered by name.
+func (s *Store) ListConfigProfiles(ctx context.Context) ([]ConfigProfile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+configProfileColumns+` FROM config_profiles ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("query config profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []ConfigProfile
+	for rows.Next() {
+		profile, err := scanConfigProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate config profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// checkProfileName reports ErrProfileExists when another profile than id
+// already uses name.
+func (s *Store) checkProfileName(ctx context.Context, name string, id int64) error {
+	var existing int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM config_profiles WHERE name = ?`, name).Scan(&existing)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("look up config profile %s: %w", name, err)
+	case existing != id:
+		return fmt.Errorf("%w: %s", ErrProfileExists, name)
+	}
+	return nil
+}
+
+// profileTag normalises the tag a profile is assigned to; blank unassigns it.
+func profileTag(tag *string) *string {
+	if tag == nil {
+		return nil
+	}
+	normalized := NormalizeTags([]string{*tag})
+	if len(normalized) == 0 {
+		return nil
+	}
+	return &normalized[0]
+}
+
+// encodeProfileSections stores each settings section as JSON, or NULL when
+// the profile leaves it unchanged.
+func encodeProfileSections(input ConfigProfileInput) (cooling, misc, pools any, err error) {
+	encode := func(section string, value any) (any, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("encode profile %s: %w", section, err)
+		}
+		return string(data), nil
+	}
+
+	if input.Cooling != nil {
+		c := *input.Cooling
+		c.Mode = strings.ToLower(strings.TrimSpace(c.Mode))
+		if c.Mode == "" {
+			c.Mode = "auto"
+		}
+		if cooling, err = encode("cooling", c); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if input.Misc != nil {
+		if misc, err = encode("misc", input.Misc); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if len(input.Pools) > 0 {
+		if pools, err = encode("pools", input.Pools); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return cooling, misc, pools, nil
+}
+
+func scanConfigProfile(row interface{ Scan(...any) error }) (ConfigProfile, error) {
+	var (
+		profile              ConfigProfile
+		tag                  sql.NullString
+		cooling, misc, pools sql.NullString
+	)
+	if err := row.Scan(&profile.ID, &profile.Name, &tag, &cooling, &misc, &pools,
+		&profile.CreatedAt, &profile.UpdatedAt); err != nil {
+		return ConfigProfile{}, fmt.Errorf("scan config profile: %w", err)
+	}
+	profile.Tag = stringPtrFromNull(tag)
+
+	if cooling.Valid && cooling.String != "" {
+		profile.Cooling = &CoolingSettingsInput{}
+		if err := json.Unmarshal([]byte(cooling.String), profile.Cooling); err != nil {
+			return ConfigProfile{}, fmt.Errorf("decode cooling for profile %d: %w", profile.ID, err)
+		}
+	}
+	if misc.Valid && misc.String != "" {
+		profile.Misc = &MiscSettingsInput{}
+		if err := json.Unmarshal([]byte(misc.String), profile.Misc); err != nil {
+			return ConfigProfile{}, fmt.Errorf("decode misc for profile %d: %w", profile.ID, err)
+		}
+	}
+	if pools.Valid && pools.String != "" {
+		if err := json.Unmarshal([]byte(pools.String), &profile.Pools); err != nil {
+			return ConfigProfile{}, fmt.Errorf("decode pools for profile %d: %w", profile.ID, err)
+		}
+	}
+	return profile, nil
+}
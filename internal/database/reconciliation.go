@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ContainerReconciliation compares a container's meter with the estimated
+// consumption of the miners located in it. DiscrepancyKW is the meter less
+// the estimate: positive for unmanaged load or miners drawing more than
+// estimated.
+type ContainerReconciliation struct {
+	ID            int64
+	Container     string
+	Location      string
+	MeterKW       float64
+	EstimatedKW   float64
+	DiscrepancyKW float64
+	Miners        int
+	// ReadingAt is when the plant reading with the meter was taken.
+	ReadingAt  time.Time
+	RecordedAt time.Time
+}
+
+const reconciliationColumns = `id, container, location, meter_kw, estimated_kw, discrepancy_kw, miners, reading_at, recorded_at`
+
+// RecordContainerReconciliations stores one reconciliation run.
+func (s *Store) RecordContainerReconciliations(ctx context.Context, reconciliations []ContainerReconciliation) error {
+	if len(reconciliations) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin reconciliation tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, r := range reconciliations {
+		recordedAt := r.RecordedAt
+		if recordedAt.IsZero() {
+			recordedAt = time.Now()
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO container_reconciliations (container, location, meter_kw, estimated_kw, discrepancy_kw, miners, reading_at, recorded_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, r.Container, r.Location, r.MeterKW, r.EstimatedKW, r.DiscrepancyKW, r.Miners, r.ReadingAt.UTC(), recordedAt.UTC()); err != nil {
+			return fmt.Errorf("insert reconciliation for %s: %w", r.Container, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit reconciliations: %w", err)
+	}
+	return nil
+}
+
+// ListContainerReconciliations returns the reconciliations recorded in
+// [from, to), oldest first.
+func (s *Store) ListContainerReconciliations(ctx context.Context, from, to time.Time) ([]ContainerReconciliation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+reconciliationColumns+`
+		FROM container_reconciliations
+		WHERE recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at, id
+	`, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("query reconciliations: %w", err)
+	}
+	return scanContainerReconciliations(rows)
+}
+
+// LatestContainerReconciliations returns the containers of the newest
+// reconciliation run.
+func (s *Store) LatestContainerReconciliations(ctx context.Context) ([]ContainerReconciliation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+reconciliationColumns+`
+		FROM container_reconciliations
+		WHERE recorded_at = (SELECT MAX(recorded_at) FROM container_reconciliations)
+		ORDER BY container
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query latest reconciliations: %w", err)
+	}
+	return scanContainerReconciliations(rows)
+}
+
+func scanContainerReconciliations(rows *sql.Rows) ([]ContainerReconciliation, error) {
+	defer rows.Close()
+
+	var out []ContainerReconciliation
+	for rows.Next() {
+		var r ContainerReconciliation
+		if err := rows.Scan(&r.ID, &r.Container, &r.Location, &r.MeterKW, &r.EstimatedKW, &r.DiscrepancyKW,
+			&r.Miners, &r.ReadingAt, &r.RecordedAt); err != nil {
+			return nil, fmt.Errorf("scan reconciliation: %w", err)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate reconciliations: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// pollRatioWeight is the weight of the newest poll in a miner's success
+// ratio, which therefore reflects roughly the last 20 polls. A miner starts
+// at a ratio of 1.
+const pollRatioWeight = 0.05
+
+// maxPollErrorLength bounds the stored error message.
+const maxPollErrorLength = 512
+
+// PollReliability tracks how reliably a miner answers status polls.
+type PollReliability struct {
+	// ConsecutiveFailures counts failed polls since the last success.
+	ConsecutiveFailures int
+	// SuccessRatio is an exponentially weighted success rate between 0 and
+	// 1, nil until the miner has been polled.
+	SuccessRatio *float64
+	LastError    *string
+	LastErrorAt  *time.Time
+}
+
+// Flaky reports whether the miner has failed maxFailures polls in a row or
+// its success ratio has dropped below minRatio.
+func (r PollReliability) Flaky(maxFailures int, minRatio float64) bool {
+	if maxFailures > 0 && r.ConsecutiveFailures >= maxFailures {
+		return true
+	}
+	return r.SuccessRatio != nil && *r.SuccessRatio < minRatio
+}
+
+// RecordPollResult folds one status poll into the miner's reliability. A nil
+// pollErr is a success. The updated record is returned.
+func (s *Store) RecordPollResult(ctx context.Context, minerID string, pollErr error) (PollReliability, error) {
+	var err error
+	if pollErr == nil {
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE miners
+			SET poll_failures = 0,
+				poll_success_ratio = COALESCE(poll_success_ratio, 1) * ? + ?
+			WHERE id = ?
+		`, 1-pollRatioWeight, pollRatioWeight, minerID)
+	} else {
+		message := pollErr.Error()
+		if len(message) > maxPollErrorLength {
+			message = message[:maxPollErrorLength]
+		}
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE miners
+			SET poll_failures = poll_failures + 1,
+				poll_success_ratio = COALESCE(poll_success_ratio, 1) * ?,
+				last_poll_error = ?,
+				last_poll_error_at = ?
+			WHERE id = ?
+		`, 1-pollRatioWeight, message, time.Now().UTC(), minerID)
+	}
+	if err != nil {
+		return PollReliability{}, fmt.Errorf("record poll result for miner %s: %w", minerID, err)
+	}
+	return s.GetPollReliability(ctx, minerID)
+}
+
+// GetPollReliability returns the miner's poll reliability.
+func (s *Store) GetPollReliability(ctx context.Context, minerID string) (PollReliability, error) {
+	var (
+		rel     PollReliability
+		ratio   sql.NullFloat64
+		message sql.NullString
+		at      sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT poll_failures, poll_success_ratio, last_poll_error, last_poll_error_at
+		FROM miners
+		WHERE id = ?
+	`, minerID).Scan(&rel.ConsecutiveFailures, &ratio, &message, &at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PollReliability{}, fmt.Errorf("miner %s not found", minerID)
+		}
+		return PollReliability{}, fmt.Errorf("query poll reliability for miner %s: %w", minerID, err)
+	}
+	rel.SuccessRatio = floatPtrFromNull(ratio)
+	rel.LastError = stringPtrFromNull(message)
+	rel.LastErrorAt = timePtrFromNull(at)
+	return rel, nil
+}
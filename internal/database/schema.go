@@ -68,10 +68,21 @@ var schemaStatements = []string{
 		recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (miner_id) REFERENCES miners(id) ON DELETE CASCADE
 	);`,
-	`CREATE INDEX IF NOT EXISTS idx_statuses_miner ON statuses(miner_id, recorded_at DESC);`,
+	// History indexes end in id so "ORDER BY recorded_at, id" (either
+	// direction) and the keyset pagination in the ForEach* readers are
+	// served straight from the index instead of sorting every row of the
+	// miner (or table) in a temp b-tree. They replace the original
+	// recorded_at-only indexes, which are dropped below.
+	`CREATE INDEX IF NOT EXISTS idx_statuses_miner_recorded ON statuses(miner_id, recorded_at, id);`,
+	`CREATE INDEX IF NOT EXISTS idx_statuses_recorded_id ON statuses(recorded_at, id);`,
+	`DROP INDEX IF EXISTS idx_statuses_miner;`,
+	`DROP INDEX IF EXISTS idx_statuses_recorded;`,
 	`ALTER TABLE statuses ADD COLUMN preset TEXT;`,
 	`ALTER TABLE statuses ADD COLUMN power_usage REAL;`,
 	`ALTER TABLE statuses ADD COLUMN power_consumption REAL;`,
+	// last_confirmed_at is the latest poll that found the snapshot
+	// unchanged; NULL until one does.
+	`ALTER TABLE statuses ADD COLUMN last_confirmed_at DATETIME;`,
 	`CREATE TABLE IF NOT EXISTS status_fans (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		status_id INTEGER NOT NULL,
@@ -81,6 +92,19 @@ var schemaStatements = []string{
 		FOREIGN KEY (status_id) REFERENCES statuses(id) ON DELETE CASCADE
 	);`,
 	`CREATE INDEX IF NOT EXISTS idx_status_fans_status ON status_fans(status_id);`,
+	`CREATE TABLE IF NOT EXISTS status_pools (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		status_id INTEGER NOT NULL,
+		pool_index INTEGER NOT NULL,
+		url TEXT,
+		worker TEXT,
+		status TEXT,
+		accepted INTEGER,
+		rejected INTEGER,
+		stale INTEGER,
+		FOREIGN KEY (status_id) REFERENCES statuses(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_status_pools_status ON status_pools(status_id);`,
 	`CREATE TABLE IF NOT EXISTS chain_snapshots (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		miner_id TEXT NOT NULL,
@@ -100,7 +124,11 @@ var schemaStatements = []string{
 	`ALTER TABLE chain_snapshots ADD COLUMN pcb_temp_max REAL;`,
 	`ALTER TABLE chain_snapshots ADD COLUMN chip_temp_min REAL;`,
 	`ALTER TABLE chain_snapshots ADD COLUMN chip_temp_max REAL;`,
-	`CREATE INDEX IF NOT EXISTS idx_chain_snapshots_miner ON chain_snapshots(miner_id, recorded_at DESC);`,
+	`ALTER TABLE chain_snapshots ADD COLUMN chip_temp_avg REAL;`,
+	`ALTER TABLE chain_snapshots ADD COLUMN chip_count INTEGER;`,
+	`CREATE INDEX IF NOT EXISTS idx_chain_snapshots_miner_recorded ON chain_snapshots(miner_id, recorded_at, id);`,
+	`DROP INDEX IF EXISTS idx_chain_snapshots_miner;`,
+	`CREATE INDEX IF NOT EXISTS idx_chain_snapshots_status ON chain_snapshots(status_id);`,
 	`CREATE TABLE IF NOT EXISTS chain_chips (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		chain_snapshot_id INTEGER NOT NULL,
@@ -112,6 +140,25 @@ var schemaStatements = []string{
 	`CREATE INDEX IF NOT EXISTS idx_chain_chips_snapshot ON chain_chips(chain_snapshot_id);`,
 	`ALTER TABLE model_presets ADD COLUMN expected_power_w REAL;`,
 	`ALTER TABLE model_presets ADD COLUMN expected_hashrate_th REAL;`,
+	`ALTER TABLE models ADD COLUMN min_preset TEXT;`,
+	`ALTER TABLE miners ADD COLUMN fw_name TEXT;`,
+	`ALTER TABLE miners ADD COLUMN fw_version TEXT;`,
+	`ALTER TABLE miners ADD COLUMN driver TEXT NOT NULL DEFAULT 'vnish';`,
+	`ALTER TABLE miners ADD COLUMN name TEXT;`,
+	`ALTER TABLE miners ADD COLUMN location TEXT;`,
+	`ALTER TABLE miners ADD COLUMN tags TEXT;`,
+	`ALTER TABLE miners ADD COLUMN hold INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE miners ADD COLUMN hold_until DATETIME;`,
+	`ALTER TABLE miners ADD COLUMN poll_failures INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE miners ADD COLUMN poll_success_ratio REAL;`,
+	`ALTER TABLE miners ADD COLUMN last_poll_error TEXT;`,
+	`ALTER TABLE miners ADD COLUMN last_poll_error_at DATETIME;`,
+	`ALTER TABLE miners ADD COLUMN api_scheme TEXT;`,
+	`ALTER TABLE miners ADD COLUMN api_port INTEGER;`,
+	`ALTER TABLE settings ADD COLUMN fan_duty INTEGER;`,
+	`ALTER TABLE miners ADD COLUMN archived_at DATETIME;`,
+	`ALTER TABLE miners ADD COLUMN last_preset_change_at DATETIME;`,
+	`ALTER TABLE miners ADD COLUMN available INTEGER;`,
 	`CREATE TABLE IF NOT EXISTS plant_readings (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		plant_id TEXT NOT NULL,
@@ -121,9 +168,13 @@ var schemaStatements = []string{
 		raw_data TEXT,
 		recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
-	`CREATE INDEX IF NOT EXISTS idx_plant_readings_recorded ON plant_readings(recorded_at DESC);`,
+	`CREATE INDEX IF NOT EXISTS idx_plant_readings_recorded_id ON plant_readings(recorded_at, id);`,
+	`DROP INDEX IF EXISTS idx_plant_readings_recorded;`,
 	`ALTER TABLE plant_readings ADD COLUMN generation_sources TEXT;`,
 	`ALTER TABLE plant_readings ADD COLUMN consumption_sources TEXT;`,
+	`ALTER TABLE plant_readings ADD COLUMN exported_power REAL;`,
+	`ALTER TABLE plant_readings ADD COLUMN battery_soc REAL;`,
+	`ALTER TABLE plant_readings ADD COLUMN battery_power REAL;`,
 	`CREATE TABLE IF NOT EXISTS power_balance_events (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		miner_id TEXT NOT NULL,
@@ -141,13 +192,276 @@ var schemaStatements = []string{
 		recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (miner_id) REFERENCES miners(id) ON DELETE CASCADE
 	);`,
-	`CREATE INDEX IF NOT EXISTS idx_power_balance_events_miner ON power_balance_events(miner_id, recorded_at DESC);`,
-	`CREATE INDEX IF NOT EXISTS idx_power_balance_events_recorded ON power_balance_events(recorded_at DESC);`,
+	`CREATE INDEX IF NOT EXISTS idx_power_balance_events_miner_recorded ON power_balance_events(miner_id, recorded_at, id);`,
+	`CREATE INDEX IF NOT EXISTS idx_power_balance_events_recorded_id ON power_balance_events(recorded_at, id);`,
+	`DROP INDEX IF EXISTS idx_power_balance_events_miner;`,
+	`DROP INDEX IF EXISTS idx_power_balance_events_recorded;`,
+	`CREATE TABLE IF NOT EXISTS balance_cycles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		mode TEXT NOT NULL,
+		generation_kw REAL NOT NULL,
+		target_power REAL NOT NULL,
+		consumption_before REAL NOT NULL,
+		consumption_after REAL NOT NULL,
+		miners_eligible INTEGER NOT NULL DEFAULT 0,
+		miners_adjusted INTEGER NOT NULL DEFAULT 0,
+		miners_failed INTEGER NOT NULL DEFAULT 0,
+		skipped TEXT,
+		recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_balance_cycles_recorded ON balance_cycles(recorded_at DESC);`,
+	`CREATE TABLE IF NOT EXISTS balance_cycle_skips (
+		cycle_id INTEGER NOT NULL,
+		miner_id TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		PRIMARY KEY (cycle_id, miner_id),
+		FOREIGN KEY (cycle_id) REFERENCES balance_cycles(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS hashboard_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		miner_id TEXT NOT NULL,
+		chain_identifier TEXT,
+		action TEXT NOT NULL,
+		attempt INTEGER NOT NULL DEFAULT 0,
+		success INTEGER NOT NULL DEFAULT 1,
+		error_message TEXT,
+		recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (miner_id) REFERENCES miners(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_hashboard_events_miner ON hashboard_events(miner_id, recorded_at DESC);`,
+	`CREATE INDEX IF NOT EXISTS idx_hashboard_events_recorded ON hashboard_events(recorded_at DESC);`,
+	`CREATE TABLE IF NOT EXISTS drift_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		miner_id TEXT NOT NULL,
+		field TEXT NOT NULL,
+		desired TEXT,
+		actual TEXT,
+		action TEXT NOT NULL,
+		success INTEGER NOT NULL DEFAULT 1,
+		error_message TEXT,
+		recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (miner_id) REFERENCES miners(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_drift_events_miner ON drift_events(miner_id, recorded_at DESC);`,
+	`CREATE INDEX IF NOT EXISTS idx_drift_events_recorded ON drift_events(recorded_at DESC);`,
+	`CREATE TABLE IF NOT EXISTS dr_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT NOT NULL DEFAULT 'operator',
+		reason TEXT,
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME NOT NULL,
+		max_load_kw REAL NOT NULL,
+		cancelled_at DATETIME,
+		samples INTEGER NOT NULL DEFAULT 0,
+		violations INTEGER NOT NULL DEFAULT 0,
+		peak_load_kw REAL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_dr_events_window ON dr_events(starts_at, ends_at);`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		endpoint TEXT NOT NULL,
+		url TEXT NOT NULL,
+		event TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		state TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_status INTEGER,
+		last_error TEXT,
+		next_attempt_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		delivered_at DATETIME
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(state, next_attempt_at);`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created ON webhook_deliveries(created_at DESC);`,
+	`CREATE TABLE IF NOT EXISTS miner_availability (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		miner_id TEXT NOT NULL,
+		online INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		reason TEXT,
+		changed_at DATETIME NOT NULL,
+		FOREIGN KEY (miner_id) REFERENCES miners(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_miner_availability_miner ON miner_availability(miner_id, changed_at, id);`,
+	`CREATE TABLE IF NOT EXISTS customers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		contact TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS customer_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		token_hash TEXT NOT NULL UNIQUE,
+		prefix TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_used_at DATETIME,
+		FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_customer_tokens_customer ON customer_tokens(customer_id);`,
+	`ALTER TABLE miners ADD COLUMN customer_id INTEGER;`,
+	`CREATE INDEX IF NOT EXISTS idx_miners_customer ON miners(customer_id);`,
+	`CREATE TABLE IF NOT EXISTS leader_leases (
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		acquired_at DATETIME NOT NULL,
+		renewed_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);`,
+	`CREATE TABLE IF NOT EXISTS config_profiles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		tag TEXT,
+		cooling TEXT,
+		misc TEXT,
+		pools TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
 	`CREATE TABLE IF NOT EXISTS app_settings (
 		key TEXT PRIMARY KEY,
 		value TEXT NOT NULL,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
 	`INSERT OR IGNORE INTO app_settings (key, value) VALUES ('safety_margin_percent', '10.0');`,
-	`INSERT OR IGNORE INTO app_settings (key, value) VALUES ('last_preset_change', '{}');`,
+	// Preset change times moved to miners.last_preset_change_at; the old
+	// map only covered a 30 second cooldown, so it is dropped, not migrated.
+	`DELETE FROM app_settings WHERE key = 'last_preset_change';`,
+	`ALTER TABLE miners ADD COLUMN serial TEXT;`,
+	`CREATE INDEX IF NOT EXISTS idx_miners_serial ON miners(serial);`,
+	`CREATE TABLE IF NOT EXISTS api_key_rotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		miner_id TEXT NOT NULL,
+		triggered_by TEXT NOT NULL,
+		success INTEGER NOT NULL DEFAULT 0,
+		error_message TEXT,
+		rotated_at DATETIME NOT NULL,
+		FOREIGN KEY (miner_id) REFERENCES miners(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_api_key_rotations_miner ON api_key_rotations(miner_id, rotated_at DESC);`,
+	`CREATE TABLE IF NOT EXISTS unlock_passwords (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		scope TEXT NOT NULL,
+		target TEXT NOT NULL DEFAULT '',
+		password TEXT NOT NULL,
+		priority INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`ALTER TABLE miners ADD COLUMN reboot_until DATETIME;`,
+	// Set while a preset's expected hashrate was back-filled from polled
+	// statuses rather than reported by the firmware or entered by hand.
+	`ALTER TABLE model_presets ADD COLUMN hashrate_observed INTEGER NOT NULL DEFAULT 0;`,
+	`CREATE TABLE IF NOT EXISTS plant_gaps (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		reason TEXT NOT NULL,
+		last_reading_at DATETIME,
+		started_at DATETIME NOT NULL,
+		ended_at DATETIME,
+		last_error TEXT,
+		alerted INTEGER NOT NULL DEFAULT 0
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_plant_gaps_started ON plant_gaps(started_at DESC);`,
+	// The low-confidence policy that produced a reading in place of the
+	// aggregator's; NULL for trusted readings.
+	`ALTER TABLE plant_readings ADD COLUMN fallback TEXT;`,
+	`CREATE TABLE IF NOT EXISTS plant_reading_quarantine (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		plant_id TEXT NOT NULL,
+		confidence REAL NOT NULL,
+		trust_status TEXT,
+		trust_summary TEXT,
+		total_generation REAL NOT NULL,
+		total_container_consumption REAL NOT NULL,
+		action TEXT NOT NULL,
+		raw_data TEXT,
+		recorded_at DATETIME NOT NULL,
+		quarantined_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_plant_reading_quarantine_recorded ON plant_reading_quarantine(recorded_at DESC);`,
+	`CREATE TABLE IF NOT EXISTS plant_daily_metrics (
+		day TEXT NOT NULL,
+		timezone TEXT NOT NULL,
+		generated_kwh REAL NOT NULL,
+		container_kwh REAL NOT NULL,
+		surplus_kwh REAL NOT NULL,
+		curtailed_at_max_kwh REAL NOT NULL,
+		short_kwh REAL NOT NULL,
+		at_max_seconds REAL NOT NULL,
+		capacity_kw REAL,
+		capacity_factor REAL,
+		covered_seconds REAL NOT NULL,
+		coverage REAL NOT NULL,
+		computed_at DATETIME NOT NULL,
+		PRIMARY KEY (day, timezone)
+	);`,
+	`ALTER TABLE plant_readings ADD COLUMN grid_frequency REAL;`,
+	`ALTER TABLE plant_readings ADD COLUMN grid_voltage REAL;`,
+	`CREATE TABLE IF NOT EXISTS grid_protection_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cause TEXT NOT NULL,
+		frequency_hz REAL,
+		voltage_v REAL,
+		shed_kw REAL NOT NULL,
+		consumption_before_w REAL NOT NULL,
+		consumption_after_w REAL NOT NULL,
+		miners_adjusted INTEGER NOT NULL DEFAULT 0,
+		miners_failed INTEGER NOT NULL DEFAULT 0,
+		started_at DATETIME NOT NULL,
+		cleared_at DATETIME
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_grid_protection_events_started ON grid_protection_events(started_at DESC);`,
+	`CREATE TABLE IF NOT EXISTS power_measurements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		miner_id TEXT NOT NULL,
+		outlets TEXT NOT NULL,
+		power_w REAL NOT NULL,
+		firmware_power_w REAL,
+		recorded_at DATETIME NOT NULL,
+		FOREIGN KEY (miner_id) REFERENCES miners(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_power_measurements_miner_recorded ON power_measurements(miner_id, recorded_at DESC);`,
+	`CREATE INDEX IF NOT EXISTS idx_power_measurements_recorded ON power_measurements(recorded_at);`,
+	`CREATE TABLE IF NOT EXISTS container_reconciliations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		container TEXT NOT NULL,
+		location TEXT NOT NULL,
+		meter_kw REAL NOT NULL,
+		estimated_kw REAL NOT NULL,
+		discrepancy_kw REAL NOT NULL,
+		miners INTEGER NOT NULL DEFAULT 0,
+		reading_at DATETIME NOT NULL,
+		recorded_at DATETIME NOT NULL
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_container_reconciliations_recorded ON container_reconciliations(recorded_at);`,
+	`ALTER TABLE miners ADD COLUMN protected INTEGER NOT NULL DEFAULT 0;`,
+	`CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		role TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_login_at DATETIME
+	);`,
+	`CREATE TABLE IF NOT EXISTS user_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		csrf_token TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id);`,
+	`ALTER TABLE power_balance_events ADD COLUMN acknowledged_at DATETIME;`,
+	`ALTER TABLE power_balance_events ADD COLUMN acknowledged_by TEXT;`,
+	`ALTER TABLE power_balance_events ADD COLUMN note TEXT;`,
+	`ALTER TABLE power_balance_events ADD COLUMN annotated_at DATETIME;`,
+	`CREATE INDEX IF NOT EXISTS idx_power_balance_events_unacknowledged ON power_balance_events(recorded_at) WHERE success = 0 AND acknowledged_at IS NULL;`,
+	`CREATE INDEX IF NOT EXISTS idx_power_balance_events_annotated ON power_balance_events(annotated_at) WHERE annotated_at IS NOT NULL;`,
+	`CREATE INDEX IF NOT EXISTS idx_statuses_miner_confirmed ON statuses(miner_id, last_confirmed_at) WHERE last_confirmed_at IS NOT NULL;`,
 }
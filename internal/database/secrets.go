@@ -0,0 +1,331 @@
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	encryptedPrefix   = "enc:v1:"
+	dataKeySettingKey = "encryption_data_key"
+	dataKeyLength     = 32
+	defaultUnlockPass = "admin"
+)
+
+// The data key is stored wrapped as wrappedKeyPrefix, the base64 scrypt
+// salt, ":" and the sealed key. The version in the prefix names the KDF and
+// its parameters, so they can change without breaking stored keys.
+const (
+	wrappedKeyPrefix = "wrap:v2:"
+	kdfSaltLength    = 16
+	scryptN          = 1 << 15
+	scryptR          = 8
+	scryptP          = 1
+)
+
+// secretCipher seals individual column values with AES-GCM.
+type secretCipher struct {
+	aead cipher.AEAD
+}
+
+func newSecretCipher(key []byte) (*secretCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("init cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("init gcm: %w", err)
+	}
+	return &secretCipher{aead: aead}, nil
+}
+
+func (c *secretCipher) seal(plain []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, plain, nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *secretCipher) open(value string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret: %w", err)
+	}
+	size := c.aead.NonceSize()
+	if len(raw) < size {
+		return nil, fmt.Errorf("secret is truncated")
+	}
+	plain, err := c.aead.Open(nil, raw[:size], raw[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt secret: %w", err)
+	}
+	return plain, nil
+}
+
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// EnableEncryption turns on at-rest encryption for miner API keys and unlock
+// passwords. A key derived from the master secret with scrypt wraps a random
+// data key stored in app_settings (envelope encryption), so rotating the
+// master secret only rewraps that one row. Existing plaintext values are
+// encrypted in place. Call after Init.
+func (s *Store) EnableEncryption(ctx context.Context, masterSecret string) error {
+	masterSecret = strings.TrimSpace(masterSecret)
+	if masterSecret == "" {
+		return fmt.Errorf("encryption secret is empty")
+	}
+
+	dataKey, err := s.loadOrCreateDataKey(ctx, masterSecret)
+	if err != nil {
+		return err
+	}
+
+	c, err := newSecretCipher(dataKey)
+	if err != nil {
+		return err
+	}
+	s.cipher = c
+
+	return s.encryptPlaintextSecrets(ctx)
+}
+
+func (s *Store) loadOrCreateDataKey(ctx context.Context, masterSecret string) ([]byte, error) {
+	var wrapped string
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM app_settings WHERE key = ?`, dataKeySettingKey).Scan(&wrapped)
+	switch {
+	case err == nil:
+		dataKey, err := unwrapDataKey(wrapped, masterSecret)
+		if err != nil {
+			return nil, fmt.Errorf("unwrap data key (wrong encryption secret?): %w", err)
+		}
+		return dataKey, nil
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return nil, fmt.Errorf("load data key: %w", err)
+	}
+
+	dataKey := make([]byte, dataKeyLength)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	if err := s.storeDataKey(ctx, dataKey, masterSecret); err != nil {
+		return nil, err
+	}
+	return dataKey, nil
+}
+
+// storeDataKey wraps dataKey with a key derived from masterSecret and a
+// fresh salt and saves it.
+func (s *Store) storeDataKey(ctx context.Context, dataKey []byte, masterSecret string) error {
+	salt := make([]byte, kdfSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("generate key salt: %w", err)
+	}
+	wrapper, err := keyWrapper(masterSecret, salt)
+	if err != nil {
+		return err
+	}
+	sealed, err := wrapper.seal(dataKey)
+	if err != nil {
+		return fmt.Errorf("wrap data key: %w", err)
+	}
+	wrapped := wrappedKeyPrefix + base64.StdEncoding.EncodeToString(salt) + ":" + sealed
+	return s.SetAppSetting(ctx, dataKeySettingKey, wrapped)
+}
+
+// unwrapDataKey opens a stored data key.
+func unwrapDataKey(wrapped, masterSecret string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(wrapped, wrappedKeyPrefix)
+	if !ok {
+		return nil, fmt.Errorf("unknown data key format")
+	}
+
+	encodedSalt, sealed, ok := strings.Cut(rest, ":")
+	if !ok {
+		return nil, fmt.Errorf("wrapped data key is malformed")
+	}
+	salt, err := base64.StdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, fmt.Errorf("decode key salt: %w", err)
+	}
+	wrapper, err := keyWrapper(masterSecret, salt)
+	if err != nil {
+		return nil, err
+	}
+	return wrapper.open(sealed)
+}
+
+// keyWrapper derives the key-encryption key from the master secret.
+func keyWrapper(masterSecret string, salt []byte) (*secretCipher, error) {
+	kek, err := scrypt.Key([]byte(masterSecret), salt, scryptN, scryptR, scryptP, dataKeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	return newSecretCipher(kek)
+}
+
+// encryptPlaintextSecrets migrates rows written before encryption was enabled.
+func (s *Store) encryptPlaintextSecrets(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin secret migration tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, api_key, unlock_pass FROM miners`)
+	if err != nil {
+		return fmt.Errorf("query miner secrets: %w", err)
+	}
+
+	type pending struct {
+		id         string
+		apiKey     sql.NullString
+		unlockPass string
+	}
+	var updates []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.apiKey, &p.unlockPass); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan miner secrets: %w", err)
+		}
+		if (p.apiKey.Valid && !s.sealedByStore(p.apiKey.String)) || !s.sealedByStore(p.unlockPass) {
+			updates = append(updates, p)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate miner secrets: %w", err)
+	}
+	rows.Close()
+
+	for _, p := range updates {
+		apiKey, err := s.sealStoredSecret(stringPtrFromNull(p.apiKey))
+		if err != nil {
+			return err
+		}
+		unlockPass, err := s.sealStoredSecret(&p.unlockPass)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE miners SET api_key = ?, unlock_pass = ? WHERE id = ?
+		`, apiKey, unlockPass, p.id); err != nil {
+			return fmt.Errorf("encrypt secrets for miner %s: %w", p.id, err)
+		}
+	}
+
+	if err := s.encryptPlaintextUnlockPasswords(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit secret migration tx: %w", err)
+	}
+	return nil
+}
+
+// encryptPlaintextUnlockPasswords seals the vault's plaintext passwords.
+func (s *Store) encryptPlaintextUnlockPasswords(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, password FROM unlock_passwords`)
+	if err != nil {
+		return fmt.Errorf("query unlock passwords: %w", err)
+	}
+
+	plain := make(map[int64]string)
+	for rows.Next() {
+		var (
+			id       int64
+			password string
+		)
+		if err := rows.Scan(&id, &password); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan unlock password: %w", err)
+		}
+		if !s.sealedByStore(password) {
+			plain[id] = password
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate unlock passwords: %w", err)
+	}
+	rows.Close()
+
+	for id, password := range plain {
+		sealed, err := s.sealSecret(&password)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE unlock_passwords SET password = ? WHERE id = ?`, sealed, id); err != nil {
+			return fmt.Errorf("encrypt unlock password %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// sealedByStore reports whether value is a secret this store sealed, as
+// opposed to a plaintext that merely starts with the encrypted prefix.
+func (s *Store) sealedByStore(value string) bool {
+	if s.cipher == nil || !isEncrypted(value) {
+		return false
+	}
+	_, err := s.cipher.open(value)
+	return err == nil
+}
+
+// sealStoredSecret seals a secret column read back from the database unless
+// the store already sealed it.
+func (s *Store) sealStoredSecret(value *string) (any, error) {
+	if value != nil && s.sealedByStore(*value) {
+		return *value, nil
+	}
+	return s.sealSecret(value)
+}
+
+// sealSecret returns the value to persist for a secret column. Without a
+// configured cipher the plaintext is stored unchanged. Anything else is
+// sealed, even text that looks encrypted already.
+func (s *Store) sealSecret(value *string) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if s.cipher == nil {
+		return *value, nil
+	}
+	sealed, err := s.cipher.seal([]byte(*value))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt secret: %w", err)
+	}
+	return sealed, nil
+}
+
+// revealSecret decrypts a stored secret column. Plaintext rows are returned
+// as-is so databases can be migrated lazily.
+func (s *Store) revealSecret(value string) (string, error) {
+	if !isEncrypted(value) {
+		return value, nil
+	}
+	if s.cipher == nil {
+		return "", fmt.Errorf("secret is encrypted but no encryption key is configured")
+	}
+	plain, err := s.cipher.open(value)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -15,7 +15,7 @@ func (s *Store) SaveMinerSettings(ctx context.Context, minerID string, input Set
 		return Settings{}, fmt.Errorf("miner id is required")
 	}
 
-	if err := validateSettingsInput(input); err != nil {
+	if err := input.Validate(); err != nil {
 		return Settings{}, err
 	}
 
@@ -29,6 +29,7 @@ func (s *Store) SaveMinerSettings(ctx context.Context, minerID string, input Set
 		fanMinCount          any = nullableInt(input.Cooling.FanMinCount)
 		fanMinDuty           any = nullableInt(input.Cooling.FanMinDuty)
 		fanMaxDuty           any = nullableInt(input.Cooling.FanMaxDuty)
+		fanDuty              any = nullableInt(input.Cooling.FanDuty)
 		coolingMode              = strings.TrimSpace(input.Cooling.Mode)
 		minOperationalChains any = nullableInt(input.Misc.MinOperationalChains)
 		preset               any = nullableTrimmedString(input.Preset)
@@ -38,26 +39,24 @@ func (s *Store) SaveMinerSettings(ctx context.Context, minerID string, input Set
 		coolingMode = "auto"
 	}
 
-	res, err := tx.ExecContext(ctx, `
+	var settingsID int64
+	err = tx.QueryRowContext(ctx, `
 		INSERT INTO settings (
 			fan_min_count,
 			fan_min_duty,
 			fan_max_duty,
+			fan_duty,
 			cooling_mode,
 			ignore_broken_sensors,
 			min_operational_chains,
 			preset
-		) VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, fanMinCount, fanMinDuty, fanMaxDuty, coolingMode, boolToInt(input.Misc.IgnoreBrokenSensors), minOperationalChains, preset)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, fanMinCount, fanMinDuty, fanMaxDuty, fanDuty, strings.ToLower(coolingMode), boolToInt(input.Misc.IgnoreBrokenSensors), minOperationalChains, preset).Scan(&settingsID)
 	if err != nil {
 		return Settings{}, fmt.Errorf("insert settings: %w", err)
 	}
 
-	settingsID, err := res.LastInsertId()
-	if err != nil {
-		return Settings{}, fmt.Errorf("read settings id: %w", err)
-	}
-
 	for idx, pool := range input.Pools {
 		if strings.TrimSpace(pool.URL) == "" {
 			return Settings{}, fmt.Errorf("pool at position %d requires a url", idx)
@@ -95,6 +94,33 @@ func (s *Store) SaveMinerSettings(ctx context.Context, minerID string, input Set
 	return s.GetSettingsByID(ctx, settingsID)
 }
 
+// Input returns the snapshot as input for SaveMinerSettings, so a change to
+// one section can carry the others forward.
+func (s Settings) Input() SettingsInput {
+	input := SettingsInput{
+		Cooling: CoolingSettingsInput{
+			FanMinCount: s.Cooling.FanMinCount,
+			FanMinDuty:  s.Cooling.FanMinDuty,
+			FanMaxDuty:  s.Cooling.FanMaxDuty,
+			FanDuty:     s.Cooling.FanDuty,
+			Mode:        s.Cooling.Mode,
+		},
+		Misc: MiscSettingsInput{
+			IgnoreBrokenSensors:  s.Misc.IgnoreBrokenSensors,
+			MinOperationalChains: s.Misc.MinOperationalChains,
+		},
+		Preset: s.Preset,
+	}
+	for _, pool := range s.Pools {
+		input.Pools = append(input.Pools, PoolInput{
+			URL:      pool.URL,
+			Username: pool.Username,
+			Password: pool.Password,
+		})
+	}
+	return input
+}
+
 // GetSettingsByID fetches a settings snapshot along with its pools.
 func (s *Store) GetSettingsByID(ctx context.Context, settingsID int64) (Settings, error) {
 	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
@@ -121,12 +147,13 @@ func (s *Store) getSettingsByIDTx(ctx context.Context, tx *sql.Tx, settingsID in
 		fanMinCount          sql.NullInt64
 		fanMinDuty           sql.NullInt64
 		fanMaxDuty           sql.NullInt64
+		fanDuty              sql.NullInt64
 		minOperationalChains sql.NullInt64
 		preset               sql.NullString
 	)
 
 	err := tx.QueryRowContext(ctx, `
-		SELECT id, fan_min_count, fan_min_duty, fan_max_duty, cooling_mode,
+		SELECT id, fan_min_count, fan_min_duty, fan_max_duty, fan_duty, cooling_mode,
 		       ignore_broken_sensors, min_operational_chains, preset, created_at
 		FROM settings
 		WHERE id = ?
@@ -135,6 +162,7 @@ func (s *Store) getSettingsByIDTx(ctx context.Context, tx *sql.Tx, settingsID in
 		&fanMinCount,
 		&fanMinDuty,
 		&fanMaxDuty,
+		&fanDuty,
 		&settings.Cooling.Mode,
 		&settings.Misc.IgnoreBrokenSensors,
 		&minOperationalChains,
@@ -151,6 +179,7 @@ func (s *Store) getSettingsByIDTx(ctx context.Context, tx *sql.Tx, settingsID in
 	settings.Cooling.FanMinCount = intPtrFromNull(fanMinCount)
 	settings.Cooling.FanMinDuty = intPtrFromNull(fanMinDuty)
 	settings.Cooling.FanMaxDuty = intPtrFromNull(fanMaxDuty)
+	settings.Cooling.FanDuty = intPtrFromNull(fanDuty)
 	settings.Misc.MinOperationalChains = intPtrFromNull(minOperationalChains)
 	if preset.Valid {
 		value := strings.TrimSpace(preset.String)
@@ -203,7 +232,8 @@ func loadPools(ctx context.Context, tx *sql.Tx, settingsID int64) ([]Pool, error
 	return pools, nil
 }
 
-func validateSettingsInput(input SettingsInput) error {
+// Validate checks the cooling mode, fan limits and pools.
+func (input SettingsInput) Validate() error {
 	mode := strings.TrimSpace(input.Cooling.Mode)
 	if mode == "" {
 		mode = "auto"
@@ -215,6 +245,25 @@ func validateSettingsInput(input SettingsInput) error {
 		return fmt.Errorf("invalid cooling mode %q", input.Cooling.Mode)
 	}
 
+	for name, duty := range map[string]*int{
+		"fan_min_duty": input.Cooling.FanMinDuty,
+		"fan_max_duty": input.Cooling.FanMaxDuty,
+		"fan_duty":     input.Cooling.FanDuty,
+	} {
+		if duty != nil && (*duty < 0 || *duty > 100) {
+			return fmt.Errorf("%s must be between 0 and 100", name)
+		}
+	}
+	if lo, hi := input.Cooling.FanMinDuty, input.Cooling.FanMaxDuty; lo != nil && hi != nil && *lo > *hi {
+		return fmt.Errorf("fan_min_duty must not exceed fan_max_duty")
+	}
+	if count := input.Cooling.FanMinCount; count != nil && *count < 0 {
+		return fmt.Errorf("fan_min_count must not be negative")
+	}
+	if strings.EqualFold(mode, "manual") && input.Cooling.FanDuty == nil {
+		return fmt.Errorf("fan_duty is required in manual mode")
+	}
+
 	for idx, pool := range input.Pools {
 		if strings.TrimSpace(pool.URL) == "" {
 			return fmt.Errorf("pool at position %d requires a url", idx)
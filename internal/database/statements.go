@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// preparedStmt names a hot-path query that the Store prepares once in Init
+// instead of having it parsed on every poll.
+type preparedStmt int
+
+const (
+	stmtInsertStatus preparedStmt = iota
+	stmtSetLatestStatus
+	stmtConfirmStatus
+	stmtInsertChainSnapshot
+	stmtInsertChip
+	stmtInsertChipChunk
+	stmtInsertFan
+	stmtInsertFanChunk
+	stmtInsertPool
+	stmtSelectStatus
+	stmtSelectStatusFans
+	stmtSelectStatusPools
+	stmtSelectChainSnapshots
+	stmtSelectChips
+	preparedStmtCount
+)
+
+// insertChunkRows is the row count of the multi-row chip and fan INSERTs.
+// Longer lists are written in chunks of this size and the remainder one row
+// at a time, so every statement shape can be prepared up front.
+const insertChunkRows = 16
+
+const (
+	insertChipPrefix = `INSERT INTO chain_chips (chain_snapshot_id, chip_identifier, hashrate, temperature) VALUES `
+	insertFanPrefix  = `INSERT INTO status_fans (status_id, fan_identifier, rpm, status) VALUES `
+)
+
+var preparedQueries = [preparedStmtCount]string{
+	stmtInsertStatus: `
+		INSERT INTO statuses (miner_id, uptime, state, preset, hashrate, power_usage, power_consumption, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+	stmtSetLatestStatus: `
+		UPDATE miners
+		SET latest_status_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`,
+	stmtConfirmStatus: `
+		UPDATE statuses
+		SET last_confirmed_at = ?
+		WHERE id = ? AND miner_id = ?
+	`,
+	stmtInsertChainSnapshot: `
+		INSERT INTO chain_snapshots (
+			miner_id,
+			status_id,
+			chain_identifier,
+			state,
+			hashrate,
+			pcb_temp_min,
+			pcb_temp_max,
+			chip_temp_min,
+			chip_temp_max,
+			chip_temp_avg,
+			chip_count,
+			recorded_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+	stmtInsertChip:      insertChipPrefix + valuesGroups(1, 4),
+	stmtInsertChipChunk: insertChipPrefix + valuesGroups(insertChunkRows, 4),
+	stmtInsertFan:       insertFanPrefix + valuesGroups(1, 4),
+	stmtInsertFanChunk:  insertFanPrefix + valuesGroups(insertChunkRows, 4),
+	stmtInsertPool: `
+		INSERT INTO status_pools (status_id, pool_index, url, worker, status, accepted, rejected, stale)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+	stmtSelectStatus: `
+		SELECT id, miner_id, uptime, state, preset, hashrate, power_usage, power_consumption, recorded_at, last_confirmed_at
+		FROM statuses
+		WHERE id = ?
+	`,
+	stmtSelectStatusFans: `
+		SELECT id, fan_identifier, rpm, status
+		FROM status_fans
+		WHERE status_id = ?
+		ORDER BY id
+	`,
+	stmtSelectStatusPools: `
+		SELECT id, pool_index, url, worker, status, accepted, rejected, stale
+		FROM status_pools
+		WHERE status_id = ?
+		ORDER BY pool_index, id
+	`,
+	stmtSelectChainSnapshots: `
+		SELECT
+			id,
+			miner_id,
+			chain_identifier,
+			state,
+			hashrate,
+			pcb_temp_min,
+			pcb_temp_max,
+			chip_temp_min,
+			chip_temp_max,
+			chip_temp_avg,
+			chip_count,
+			recorded_at
+		FROM chain_snapshots
+		WHERE status_id = ?
+		ORDER BY recorded_at, id
+	`,
+	stmtSelectChips: `
+		SELECT id, chip_identifier, hashrate, temperature
+		FROM chain_chips
+		WHERE chain_snapshot_id = ?
+		ORDER BY id
+	`,
+}
+
+// valuesGroups returns rows comma-separated "(?, ...)" groups of cols
+// placeholders each.
+func valuesGroups(rows, cols int) string {
+	group := "(" + strings.TrimSuffix(strings.Repeat("?, ", cols), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(group+", ", rows), ", ")
+}
+
+// prepareStatements prepares the hot-path queries. It runs before any
+// transaction is open: with SQLite's single connection, preparing on the
+// pool from inside a transaction would wait forever for a free connection.
+func (s *Store) prepareStatements(ctx context.Context) error {
+	for i, query := range preparedQueries {
+		if s.stmts[i] != nil {
+			continue
+		}
+		stmt, err := s.db.PrepareContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("prepare statement %d: %w", i, err)
+		}
+		s.stmts[i] = stmt
+	}
+	return nil
+}
+
+// Close releases the prepared statements. The caller still owns and closes
+// the underlying database handle.
+func (s *Store) Close() error {
+	var firstErr error
+	for i, stmt := range s.stmts {
+		if stmt == nil {
+			continue
+		}
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		s.stmts[i] = nil
+	}
+	return firstErr
+}
+
+// txStmt binds a prepared statement to tx. database/sql reuses the
+// statement already prepared on the transaction's connection, or prepares
+// it there once if the connection is new. It returns nil before Init.
+func (s *Store) txStmt(ctx context.Context, tx *sql.Tx, id preparedStmt) *sql.Stmt {
+	if s.stmts[id] == nil {
+		return nil
+	}
+	return tx.StmtContext(ctx, s.stmts[id])
+}
+
+func (s *Store) execPrepared(ctx context.Context, tx *sql.Tx, id preparedStmt, args ...any) (sql.Result, error) {
+	if stmt := s.txStmt(ctx, tx, id); stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return tx.ExecContext(ctx, preparedQueries[id], args...)
+}
+
+func (s *Store) queryPrepared(ctx context.Context, tx *sql.Tx, id preparedStmt, args ...any) (*sql.Rows, error) {
+	if stmt := s.txStmt(ctx, tx, id); stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return tx.QueryContext(ctx, preparedQueries[id], args...)
+}
+
+func (s *Store) queryRowPrepared(ctx context.Context, tx *sql.Tx, id preparedStmt, args ...any) *sql.Row {
+	if stmt := s.txStmt(ctx, tx, id); stmt != nil {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+	return tx.QueryRowContext(ctx, preparedQueries[id], args...)
+}
+
+// insertChunked writes rows with the chunk statement insertChunkRows at a
+// time and the single-row statement for the remainder.
+func (s *Store) insertChunked(ctx context.Context, tx *sql.Tx, chunk, single preparedStmt, rows [][]any) error {
+	for !s.singleRowInserts && len(rows) >= insertChunkRows {
+		args := make([]any, 0, insertChunkRows*len(rows[0]))
+		for _, row := range rows[:insertChunkRows] {
+			args = append(args, row...)
+		}
+		if _, err := s.execPrepared(ctx, tx, chunk, args...); err != nil {
+			return err
+		}
+		rows = rows[insertChunkRows:]
+	}
+	for _, row := range rows {
+		if _, err := s.execPrepared(ctx, tx, single, row...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 )
@@ -22,56 +24,141 @@ func (s *Store) RecordMinerStatus(ctx context.Context, minerID string, input Min
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	statusID, err := s.recordStatusTx(ctx, tx, minerID, input)
+	if err != nil {
+		return Status{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Status{}, fmt.Errorf("commit record status tx: %w", err)
+	}
+
+	return s.GetStatusByID(ctx, statusID)
+}
+
+// recordStatusTx inserts a status snapshot with its fans, pools and chains
+// and marks it as the miner's latest.
+func (s *Store) recordStatusTx(ctx context.Context, tx *sql.Tx, minerID string, input MinerStatusInput) (int64, error) {
 	recordedAt := input.RecordedAt
 	if recordedAt.IsZero() {
 		recordedAt = time.Now().UTC()
 	}
 
-	res, err := tx.ExecContext(ctx, `
-		INSERT INTO statuses (miner_id, uptime, state, preset, hashrate, power_usage, power_consumption, recorded_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, minerID,
+	var statusID int64
+	err := s.queryRowPrepared(ctx, tx, stmtInsertStatus, minerID,
 		nullableInt64(input.Uptime),
 		nullableTrimmedString(input.State),
 		nullableTrimmedString(input.Preset),
 		nullableFloat64(input.Hashrate),
 		nullableFloat64(input.PowerUsage),
 		nullableFloat64(input.PowerConsumption),
-		recordedAt)
+		recordedAt).Scan(&statusID)
+	if err != nil {
+		return 0, fmt.Errorf("insert status for miner %s: %w", minerID, err)
+	}
+
+	if err := s.insertStatusFansTx(ctx, tx, statusID, input.Fans); err != nil {
+		return 0, err
+	}
+	if err := s.insertStatusPoolsTx(ctx, tx, statusID, input.Pools); err != nil {
+		return 0, err
+	}
+	if err := s.insertChainSnapshotsTx(ctx, tx, minerID, &statusID, recordedAt, input.Chains); err != nil {
+		return 0, err
+	}
+
+	result, err := s.execPrepared(ctx, tx, stmtSetLatestStatus, statusID, minerID)
+	if err != nil {
+		return 0, fmt.Errorf("set latest status for miner %s: %w", minerID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("status attach rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, fmt.Errorf("miner %s not found", minerID)
+	}
+	return statusID, nil
+}
+
+// ConfirmMinerStatus records that a poll at confirmedAt found the miner
+// as its status snapshot statusID describes it, instead of storing a copy.
+func (s *Store) ConfirmMinerStatus(ctx context.Context, minerID string, statusID int64, confirmedAt time.Time) error {
+	minerID = strings.TrimSpace(minerID)
+	if minerID == "" {
+		return fmt.Errorf("miner id is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return Status{}, fmt.Errorf("insert status for miner %s: %w", minerID, err)
+		return fmt.Errorf("begin confirm status tx: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	statusID, err := res.LastInsertId()
+	if err := s.confirmStatusTx(ctx, tx, minerID, statusID, confirmedAt); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit confirm status tx: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) confirmStatusTx(ctx context.Context, tx *sql.Tx, minerID string, statusID int64, confirmedAt time.Time) error {
+	if confirmedAt.IsZero() {
+		confirmedAt = time.Now().UTC()
+	}
+	result, err := s.execPrepared(ctx, tx, stmtConfirmStatus, confirmedAt.UTC(), statusID, minerID)
 	if err != nil {
-		return Status{}, fmt.Errorf("read status id: %w", err)
+		return fmt.Errorf("confirm status %d for miner %s: %w", statusID, minerID, err)
 	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("status confirm rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("status %d of miner %s not found", statusID, minerID)
+	}
+	return nil
+}
 
-	for _, fan := range input.Fans {
-		if _, err := tx.ExecContext(ctx, `
-			INSERT INTO status_fans (status_id, fan_identifier, rpm, status)
-			VALUES (?, ?, ?, ?)
-		`, statusID, nullableTrimmedString(fan.FanIdentifier), nullableInt(fan.RPM), nullableTrimmedString(fan.Status)); err != nil {
-			return Status{}, fmt.Errorf("insert fan status: %w", err)
+func (s *Store) insertStatusFansTx(ctx context.Context, tx *sql.Tx, statusID int64, fans []FanStatusInput) error {
+	if len(fans) == 0 {
+		return nil
+	}
+	rows := make([][]any, 0, len(fans))
+	for _, fan := range fans {
+		rows = append(rows, []any{statusID, nullableTrimmedString(fan.FanIdentifier), nullableInt(fan.RPM), nullableTrimmedString(fan.Status)})
+	}
+	if err := s.insertChunked(ctx, tx, stmtInsertFanChunk, stmtInsertFan, rows); err != nil {
+		return fmt.Errorf("insert fan status: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) insertStatusPoolsTx(ctx context.Context, tx *sql.Tx, statusID int64, pools []PoolStatInput) error {
+	for _, pool := range pools {
+		if _, err := s.execPrepared(ctx, tx, stmtInsertPool, statusID, pool.PoolIndex,
+			nullableTrimmedString(pool.URL),
+			nullableTrimmedString(pool.Worker),
+			nullableTrimmedString(pool.Status),
+			nullableInt64(pool.Accepted),
+			nullableInt64(pool.Rejected),
+			nullableInt64(pool.Stale)); err != nil {
+			return fmt.Errorf("insert pool status: %w", err)
 		}
 	}
+	return nil
+}
 
-	for _, chain := range input.Chains {
-		chainRes, err := tx.ExecContext(ctx, `
-			INSERT INTO chain_snapshots (
-				miner_id,
-				status_id,
-				chain_identifier,
-				state,
-				hashrate,
-				pcb_temp_min,
-				pcb_temp_max,
-				chip_temp_min,
-				chip_temp_max,
-				recorded_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`, minerID,
-			statusID,
+// insertChainSnapshotsTx inserts chain snapshots, attached to statusID when
+// it is set, and their chips.
+func (s *Store) insertChainSnapshotsTx(ctx context.Context, tx *sql.Tx, minerID string, statusID *int64, recordedAt time.Time, chains []ChainSnapshotInput) error {
+	for _, chain := range chains {
+		var chainID int64
+		err := s.queryRowPrepared(ctx, tx, stmtInsertChainSnapshot, minerID,
+			nullableInt64(statusID),
 			nullableTrimmedString(chain.ChainIdentifier),
 			nullableTrimmedString(chain.State),
 			nullableFloat64(chain.Hashrate),
@@ -79,48 +166,25 @@ func (s *Store) RecordMinerStatus(ctx context.Context, minerID string, input Min
 			nullableFloat64(chain.PCBTempMax),
 			nullableFloat64(chain.ChipTempMin),
 			nullableFloat64(chain.ChipTempMax),
-			recordedAt)
+			nullableFloat64(chain.ChipTempAvg),
+			nullableInt(chain.ChipCount),
+			recordedAt).Scan(&chainID)
 		if err != nil {
-			return Status{}, fmt.Errorf("insert chain snapshot: %w", err)
+			return fmt.Errorf("insert chain snapshot: %w", err)
 		}
 
-		chainID, err := chainRes.LastInsertId()
-		if err != nil {
-			return Status{}, fmt.Errorf("read chain snapshot id: %w", err)
+		if len(chain.Chips) == 0 {
+			continue
 		}
-
+		rows := make([][]any, 0, len(chain.Chips))
 		for _, chip := range chain.Chips {
-			if _, err := tx.ExecContext(ctx, `
-				INSERT INTO chain_chips (chain_snapshot_id, chip_identifier, hashrate, temperature)
-				VALUES (?, ?, ?, ?)
-			`, chainID, nullableTrimmedString(chip.ChipIdentifier), nullableFloat64(chip.Hashrate), nullableFloat64(chip.Temperature)); err != nil {
-				return Status{}, fmt.Errorf("insert chip snapshot: %w", err)
-			}
+			rows = append(rows, []any{chainID, nullableTrimmedString(chip.ChipIdentifier), nullableFloat64(chip.Hashrate), nullableFloat64(chip.Temperature)})
+		}
+		if err := s.insertChunked(ctx, tx, stmtInsertChipChunk, stmtInsertChip, rows); err != nil {
+			return fmt.Errorf("insert chip snapshot: %w", err)
 		}
 	}
-
-	result, err := tx.ExecContext(ctx, `
-		UPDATE miners
-		SET latest_status_id = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?
-	`, statusID, minerID)
-	if err != nil {
-		return Status{}, fmt.Errorf("set latest status for miner %s: %w", minerID, err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return Status{}, fmt.Errorf("status attach rows affected: %w", err)
-	}
-	if rowsAffected == 0 {
-		return Status{}, fmt.Errorf("miner %s not found", minerID)
-	}
-
-	if err := tx.Commit(); err != nil {
-		return Status{}, fmt.Errorf("commit record status tx: %w", err)
-	}
-
-	return s.GetStatusByID(ctx, statusID)
+	return nil
 }
 
 // GetStatusByID retrieves a status snapshot with relations.
@@ -201,13 +265,10 @@ func (s *Store) getStatusByIDTx(ctx context.Context, tx *sql.Tx, statusID int64)
 		hashrate         sql.NullFloat64
 		powerUsage       sql.NullFloat64
 		powerConsumption sql.NullFloat64
+		confirmedAt      sql.NullTime
 	)
 
-	err := tx.QueryRowContext(ctx, `
-		SELECT id, miner_id, uptime, state, preset, hashrate, power_usage, power_consumption, recorded_at
-		FROM statuses
-		WHERE id = ?
-	`, statusID).Scan(&status.ID, &status.MinerID, &uptime, &state, &preset, &hashrate, &powerUsage, &powerConsumption, &status.RecordedAt)
+	err := s.queryRowPrepared(ctx, tx, stmtSelectStatus, statusID).Scan(&status.ID, &status.MinerID, &uptime, &state, &preset, &hashrate, &powerUsage, &powerConsumption, &status.RecordedAt, &confirmedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Status{}, fmt.Errorf("status %d not found", statusID)
@@ -221,14 +282,24 @@ func (s *Store) getStatusByIDTx(ctx context.Context, tx *sql.Tx, statusID int64)
 	status.Preset = stringPtrFromNull(preset)
 	status.PowerUsage = floatPtrFromNull(powerUsage)
 	status.PowerConsumption = floatPtrFromNull(powerConsumption)
+	status.LastConfirmedAt = status.RecordedAt
+	if confirmedAt.Valid {
+		status.LastConfirmedAt = confirmedAt.Time
+	}
 
-	fans, err := loadStatusFans(ctx, tx, status.ID)
+	fans, err := s.loadStatusFans(ctx, tx, status.ID)
 	if err != nil {
 		return Status{}, err
 	}
 	status.Fans = fans
 
-	chains, err := loadChainSnapshots(ctx, tx, status.ID)
+	pools, err := s.loadStatusPools(ctx, tx, status.ID)
+	if err != nil {
+		return Status{}, err
+	}
+	status.Pools = pools
+
+	chains, err := s.loadChainSnapshots(ctx, tx, status.ID)
 	if err != nil {
 		return Status{}, err
 	}
@@ -237,13 +308,8 @@ func (s *Store) getStatusByIDTx(ctx context.Context, tx *sql.Tx, statusID int64)
 	return status, nil
 }
 
-func loadStatusFans(ctx context.Context, tx *sql.Tx, statusID int64) ([]FanStatus, error) {
-	rows, err := tx.QueryContext(ctx, `
-		SELECT id, fan_identifier, rpm, status
-		FROM status_fans
-		WHERE status_id = ?
-		ORDER BY id
-	`, statusID)
+func (s *Store) loadStatusFans(ctx context.Context, tx *sql.Tx, statusID int64) ([]FanStatus, error) {
+	rows, err := s.queryPrepared(ctx, tx, stmtSelectStatusFans, statusID)
 	if err != nil {
 		return nil, fmt.Errorf("query status fans: %w", err)
 	}
@@ -275,23 +341,42 @@ func loadStatusFans(ctx context.Context, tx *sql.Tx, statusID int64) ([]FanStatu
 	return fans, nil
 }
 
-func loadChainSnapshots(ctx context.Context, tx *sql.Tx, statusID int64) ([]ChainSnapshot, error) {
-	rows, err := tx.QueryContext(ctx, `
-		SELECT
-			id,
-			miner_id,
-			chain_identifier,
-			state,
-			hashrate,
-			pcb_temp_min,
-			pcb_temp_max,
-			chip_temp_min,
-			chip_temp_max,
-			recorded_at
-		FROM chain_snapshots
-		WHERE status_id = ?
-		ORDER BY recorded_at, id
-	`, statusID)
+func (s *Store) loadStatusPools(ctx context.Context, tx *sql.Tx, statusID int64) ([]PoolStat, error) {
+	rows, err := s.queryPrepared(ctx, tx, stmtSelectStatusPools, statusID)
+	if err != nil {
+		return nil, fmt.Errorf("query status pools: %w", err)
+	}
+	defer rows.Close()
+
+	var pools []PoolStat
+	for rows.Next() {
+		var (
+			pool                      PoolStat
+			url, worker, poolState    sql.NullString
+			accepted, rejected, stale sql.NullInt64
+		)
+		if err := rows.Scan(&pool.ID, &pool.PoolIndex, &url, &worker, &poolState, &accepted, &rejected, &stale); err != nil {
+			return nil, fmt.Errorf("scan pool status: %w", err)
+		}
+		pool.StatusID = statusID
+		pool.URL = stringPtrFromNull(url)
+		pool.Worker = stringPtrFromNull(worker)
+		pool.Status = stringPtrFromNull(poolState)
+		pool.Accepted = int64PtrFromNull(accepted)
+		pool.Rejected = int64PtrFromNull(rejected)
+		pool.Stale = int64PtrFromNull(stale)
+		pools = append(pools, pool)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pool statuses: %w", err)
+	}
+
+	return pools, nil
+}
+
+func (s *Store) loadChainSnapshots(ctx context.Context, tx *sql.Tx, statusID int64) ([]ChainSnapshot, error) {
+	rows, err := s.queryPrepared(ctx, tx, stmtSelectChainSnapshots, statusID)
 	if err != nil {
 		return nil, fmt.Errorf("query chain snapshots: %w", err)
 	}
@@ -308,6 +393,8 @@ func loadChainSnapshots(ctx context.Context, tx *sql.Tx, statusID int64) ([]Chai
 			pcbTempMax      sql.NullFloat64
 			chipTempMin     sql.NullFloat64
 			chipTempMax     sql.NullFloat64
+			chipTempAvg     sql.NullFloat64
+			chipCount       sql.NullInt64
 		)
 
 		if err := rows.Scan(
@@ -320,6 +407,8 @@ func loadChainSnapshots(ctx context.Context, tx *sql.Tx, statusID int64) ([]Chai
 			&pcbTempMax,
 			&chipTempMin,
 			&chipTempMax,
+			&chipTempAvg,
+			&chipCount,
 			&snapshot.RecordedAt,
 		); err != nil {
 			return nil, fmt.Errorf("scan chain snapshot: %w", err)
@@ -332,29 +421,38 @@ func loadChainSnapshots(ctx context.Context, tx *sql.Tx, statusID int64) ([]Chai
 		snapshot.PCBTempMax = floatPtrFromNull(pcbTempMax)
 		snapshot.ChipTempMin = floatPtrFromNull(chipTempMin)
 		snapshot.ChipTempMax = floatPtrFromNull(chipTempMax)
-
-		chips, err := loadChipSnapshots(ctx, tx, snapshot.ID)
-		if err != nil {
-			return nil, err
-		}
-		snapshot.Chips = chips
+		snapshot.ChipTempAvg = floatPtrFromNull(chipTempAvg)
+		snapshot.ChipCount = intPtrFromNull(chipCount)
 		snapshots = append(snapshots, snapshot)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate chain snapshots: %w", err)
 	}
+	rows.Close()
 
+	if err := s.attachChipSnapshots(ctx, tx, snapshots); err != nil {
+		return nil, err
+	}
 	return snapshots, nil
 }
 
-func loadChipSnapshots(ctx context.Context, tx *sql.Tx, chainSnapshotID int64) ([]ChipSnapshot, error) {
-	rows, err := tx.QueryContext(ctx, `
-		SELECT id, chip_identifier, hashrate, temperature
-		FROM chain_chips
-		WHERE chain_snapshot_id = ?
-		ORDER BY id
-	`, chainSnapshotID)
+// attachChipSnapshots loads the chips of each snapshot. Callers close their
+// snapshot rows first, as PostgreSQL cannot query on a transaction with an
+// open cursor.
+func (s *Store) attachChipSnapshots(ctx context.Context, tx *sql.Tx, snapshots []ChainSnapshot) error {
+	for i := range snapshots {
+		chips, err := s.loadChipSnapshots(ctx, tx, snapshots[i].ID)
+		if err != nil {
+			return err
+		}
+		snapshots[i].Chips = chips
+	}
+	return nil
+}
+
+func (s *Store) loadChipSnapshots(ctx context.Context, tx *sql.Tx, chainSnapshotID int64) ([]ChipSnapshot, error) {
+	rows, err := s.queryPrepared(ctx, tx, stmtSelectChips, chainSnapshotID)
 	if err != nil {
 		return nil, fmt.Errorf("query chip snapshots: %w", err)
 	}
@@ -385,3 +483,264 @@ func loadChipSnapshots(ctx context.Context, tx *sql.Tx, chainSnapshotID int64) (
 
 	return chips, nil
 }
+
+// statusPageSize bounds how many status rows ForEachStatusPower reads per
+// query; the table grows by up to one row per miner per poll.
+const statusPageSize = 5000
+
+// ForEachStatusPower calls fn for every status recorded in [from, to) in
+// chronological order across all miners, reading the table in pages. A
+// snapshot that later polls confirmed unchanged is passed again, as a
+// Confirmation, at its last confirmation; this includes the snapshot each
+// miner had at from when it was confirmed past from.
+func (s *Store) ForEachStatusPower(ctx context.Context, from, to time.Time, fn func(StatusPower) error) error {
+	return s.forEachStatusPower(ctx, "", from, to, fn)
+}
+
+// ForEachMinerStatusPower is ForEachStatusPower limited to one miner.
+func (s *Store) ForEachMinerStatusPower(ctx context.Context, minerID string, from, to time.Time, fn func(StatusPower) error) error {
+	return s.forEachStatusPower(ctx, minerID, from, to, fn)
+}
+
+// forEachStatusPower pages through statuses in [from, to), only minerID's
+// when it is not empty.
+func (s *Store) forEachStatusPower(ctx context.Context, minerID string, from, to time.Time, fn func(StatusPower) error) error {
+	lastAt := from.UTC()
+	var lastID int64
+
+	// Confirmations are held back until the samples recorded before them
+	// have been passed on, to keep the order chronological.
+	var pending []StatusPower
+	emit := func(sample StatusPower, confirmedAt sql.NullTime) error {
+		for len(pending) > 0 && !pending[0].RecordedAt.After(sample.RecordedAt) {
+			if err := fn(pending[0]); err != nil {
+				return err
+			}
+			pending = pending[1:]
+		}
+		if err := fn(sample); err != nil {
+			return err
+		}
+		if confirmedAt.Valid && confirmedAt.Time.After(sample.RecordedAt) {
+			confirmation := sample
+			confirmation.RecordedAt = confirmedAt.Time
+			confirmation.Confirmation = true
+			i := sort.Search(len(pending), func(i int) bool { return pending[i].RecordedAt.After(confirmation.RecordedAt) })
+			pending = slices.Insert(pending, i, confirmation)
+		}
+		return nil
+	}
+
+	// A literal filter lets SQLite pick idx_statuses_miner_recorded.
+	filter := ""
+	if minerID != "" {
+		filter = "AND miner_id = ?"
+	}
+
+	// The snapshot each miner had at from matters only when polls
+	// confirmed it past from.
+	openingArgs := []any{lastAt, lastAt}
+	openingFilter := ""
+	if minerID != "" {
+		openingFilter = "AND m.id = ?"
+		openingArgs = append(openingArgs, minerID)
+	}
+	var opening []statusPowerRow
+	err := s.eachRow(ctx, "opening status power", func(rows *sql.Rows) error {
+		row, err := scanStatusPowerRow(rows)
+		if err != nil {
+			return err
+		}
+		opening = append(opening, row)
+		return nil
+	}, `
+		SELECT s.id, s.miner_id, s.power_consumption, s.recorded_at, s.last_confirmed_at
+		FROM miners m
+		JOIN statuses s ON s.id = (
+			SELECT id FROM statuses
+			WHERE miner_id = m.id AND recorded_at < ?
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		)
+		WHERE s.last_confirmed_at >= ? `+openingFilter+`
+		ORDER BY s.recorded_at, s.id
+	`, openingArgs...)
+	if err != nil {
+		return err
+	}
+	for _, row := range opening {
+		if err := emit(row.sample, row.confirmedAt); err != nil {
+			return err
+		}
+	}
+
+	for {
+		args := []any{lastAt, to.UTC(), lastAt, lastID}
+		if minerID != "" {
+			args = append(args, minerID)
+		}
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT id, miner_id, power_consumption, recorded_at, last_confirmed_at
+			FROM statuses
+			WHERE recorded_at >= ? AND recorded_at < ? AND (recorded_at > ? OR id > ?) `+filter+`
+			ORDER BY recorded_at, id
+			LIMIT ?
+		`, append(args, statusPageSize)...)
+		if err != nil {
+			return fmt.Errorf("query status power: %w", err)
+		}
+
+		var page []statusPowerRow
+		for rows.Next() {
+			row, err := scanStatusPowerRow(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, row)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("iterate status power: %w", err)
+		}
+
+		for _, row := range page {
+			if err := emit(row.sample, row.confirmedAt); err != nil {
+				return err
+			}
+		}
+		if len(page) < statusPageSize {
+			break
+		}
+		lastAt = page[len(page)-1].sample.RecordedAt
+		lastID = page[len(page)-1].sample.ID
+	}
+
+	for _, confirmation := range pending {
+		if err := fn(confirmation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type statusPowerRow struct {
+	sample      StatusPower
+	confirmedAt sql.NullTime
+}
+
+func scanStatusPowerRow(rows *sql.Rows) (statusPowerRow, error) {
+	var (
+		row   statusPowerRow
+		power sql.NullFloat64
+	)
+	if err := rows.Scan(&row.sample.ID, &row.sample.MinerID, &power, &row.sample.RecordedAt, &row.confirmedAt); err != nil {
+		return statusPowerRow{}, fmt.Errorf("scan status power: %w", err)
+	}
+	row.sample.PowerConsumption = floatPtrFromNull(power)
+	return row, nil
+}
+
+// Status columns ListStatusSeries can select.
+const (
+	StatusColumnUptime           = "uptime"
+	StatusColumnState            = "state"
+	StatusColumnPreset           = "preset"
+	StatusColumnHashrate         = "hashrate"
+	StatusColumnPowerUsage       = "power_usage"
+	StatusColumnPowerConsumption = "power_consumption"
+)
+
+var statusSeriesColumns = map[string]bool{
+	StatusColumnUptime:           true,
+	StatusColumnState:            true,
+	StatusColumnPreset:           true,
+	StatusColumnHashrate:         true,
+	StatusColumnPowerUsage:       true,
+	StatusColumnPowerConsumption: true,
+}
+
+// ListStatusSeries returns the statuses of several miners recorded in
+// [from, to), ordered by miner and then time, in a single query. Only the
+// given columns are read; the other fields are left nil and fans and chains
+// are not loaded. At most limit rows are returned.
+func (s *Store) ListStatusSeries(ctx context.Context, minerIDs, columns []string, from, to time.Time, limit int) ([]Status, error) {
+	if len(minerIDs) == 0 {
+		return nil, nil
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("at least one status column is required")
+	}
+	for _, column := range columns {
+		if !statusSeriesColumns[column] {
+			return nil, fmt.Errorf("unknown status column %q", column)
+		}
+	}
+	if limit <= 0 {
+		limit = 10000
+	}
+
+	args := make([]any, 0, len(minerIDs)+3)
+	for _, id := range minerIDs {
+		args = append(args, id)
+	}
+	args = append(args, from.UTC(), to.UTC(), limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, miner_id, recorded_at, `+strings.Join(columns, ", ")+`
+		FROM statuses
+		WHERE miner_id IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(minerIDs)), ", ")+`)
+			AND recorded_at >= ? AND recorded_at < ?
+		ORDER BY miner_id, recorded_at, id
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query status series: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []Status
+	for rows.Next() {
+		var (
+			status           Status
+			uptime           sql.NullInt64
+			state            sql.NullString
+			preset           sql.NullString
+			hashrate         sql.NullFloat64
+			powerUsage       sql.NullFloat64
+			powerConsumption sql.NullFloat64
+		)
+		dest := []any{&status.ID, &status.MinerID, &status.RecordedAt}
+		for _, column := range columns {
+			switch column {
+			case StatusColumnUptime:
+				dest = append(dest, &uptime)
+			case StatusColumnState:
+				dest = append(dest, &state)
+			case StatusColumnPreset:
+				dest = append(dest, &preset)
+			case StatusColumnHashrate:
+				dest = append(dest, &hashrate)
+			case StatusColumnPowerUsage:
+				dest = append(dest, &powerUsage)
+			case StatusColumnPowerConsumption:
+				dest = append(dest, &powerConsumption)
+			}
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan status series: %w", err)
+		}
+		status.Uptime = int64PtrFromNull(uptime)
+		status.State = stringPtrFromNull(state)
+		status.Preset = stringPtrFromNull(preset)
+		status.Hashrate = floatPtrFromNull(hashrate)
+		status.PowerUsage = floatPtrFromNull(powerUsage)
+		status.PowerConsumption = floatPtrFromNull(powerConsumption)
+		statuses = append(statuses, status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate status series: %w", err)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// benchmarkStore opens a fresh SQLite store in a temporary directory with
+// one miner to record statuses for. Without prepared it drops the prepared
+// statements, so every query is parsed again as before they existed.
+func benchmarkStore(b *testing.B, prepared, chunked bool) *Store {
+	b.Helper()
+	ctx := context.Background()
+
+	db, err := Open(DialectSQLite, filepath.Join(b.TempDir(), "bench.db"), PoolOptions{MaxOpenConns: 1, MaxIdleConns: 1})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = db.Close() })
+
+	store, err := New(db, DialectSQLite)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := store.Init(ctx); err != nil {
+		b.Fatal(err)
+	}
+	if !prepared {
+		if err := store.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	store.singleRowInserts = !chunked
+
+	ip := "10.0.0.10"
+	if _, err := store.UpsertMiner(ctx, UpsertMinerParams{ID: benchmarkMinerID, IP: &ip}); err != nil {
+		b.Fatal(err)
+	}
+	return store
+}
+
+const benchmarkMinerID = "02:de:00:00:00:01"
+
+// benchmarkSnapshot is a status poll of an S21-class miner: four fans, three
+// pools and three hashboards of 108 chips each.
+func benchmarkSnapshot(recordedAt time.Time) MinerStatusInput {
+	str := func(v string) *string { return &v }
+	num := func(v float64) *float64 { return &v }
+	count := func(v int) *int { return &v }
+	total := func(v int64) *int64 { return &v }
+
+	input := MinerStatusInput{
+		Uptime:           total(86400),
+		State:            str("mining"),
+		Preset:           str("3300"),
+		Hashrate:         num(200000),
+		PowerUsage:       num(3300),
+		PowerConsumption: num(3310),
+		RecordedAt:       recordedAt,
+	}
+	for i := range 4 {
+		input.Fans = append(input.Fans, FanStatusInput{FanIdentifier: str(fmt.Sprint(i)), RPM: count(4800 + i*20), Status: str("ok")})
+	}
+	for i := range 3 {
+		input.Pools = append(input.Pools, PoolStatInput{
+			PoolIndex: i,
+			URL:       str(fmt.Sprintf("stratum+tcp://pool%d.example.com:3333", i)),
+			Worker:    str("farm.worker1"),
+			Status:    str("alive"),
+			Accepted:  total(120000),
+			Rejected:  total(30),
+			Stale:     total(4),
+		})
+	}
+	for c := range 3 {
+		chain := ChainSnapshotInput{
+			ChainIdentifier: str(fmt.Sprint(c)),
+			State:           str("mining"),
+			Hashrate:        num(66000),
+			PCBTempMin:      num(48),
+			PCBTempMax:      num(61),
+			ChipTempMin:     num(55),
+			ChipTempMax:     num(74),
+			ChipTempAvg:     num(66),
+			ChipCount:       count(108),
+		}
+		for i := range 108 {
+			chain.Chips = append(chain.Chips, ChipSnapshotInput{
+				ChipIdentifier: str(fmt.Sprint(i)),
+				Hashrate:       num(611 + float64(i%7)),
+				Temperature:    num(60 + float64(i%15)),
+			})
+		}
+		input.Chains = append(input.Chains, chain)
+	}
+	return input
+}
+
+var statementModes = []struct {
+	name              string
+	prepared, chunked bool
+}{
+	{"unprepared", false, false},
+	{"unprepared_chunked", false, true},
+	{"prepared", true, false},
+	{"prepared_chunked", true, true},
+}
+
+// BenchmarkRecordMinerStatus measures a full status write, including the
+// read-back of the stored snapshot.
+func BenchmarkRecordMinerStatus(b *testing.B) {
+	for _, mode := range statementModes {
+		b.Run(mode.name, func(b *testing.B) {
+			store := benchmarkStore(b, mode.prepared, mode.chunked)
+			ctx := context.Background()
+			at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+			b.ReportAllocs()
+			for b.Loop() {
+				at = at.Add(time.Minute)
+				if _, err := store.RecordMinerStatus(ctx, benchmarkMinerID, benchmarkSnapshot(at)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRecordStatusTx measures only the inserts a poll makes, which is
+// what the write queue batches.
+func BenchmarkRecordStatusTx(b *testing.B) {
+	for _, mode := range statementModes {
+		b.Run(mode.name, func(b *testing.B) {
+			store := benchmarkStore(b, mode.prepared, mode.chunked)
+			ctx := context.Background()
+			at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+			b.ReportAllocs()
+			for b.Loop() {
+				at = at.Add(time.Minute)
+				tx, err := store.db.BeginTx(ctx, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := store.recordStatusTx(ctx, tx, benchmarkMinerID, benchmarkSnapshot(at)); err != nil {
+					_ = tx.Rollback()
+					b.Fatal(err)
+				}
+				if err := tx.Commit(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -7,19 +7,30 @@ import (
 	"strings"
 )
 
-// Store wraps a SQLite connection and exposes helpers to manage PowerHive
-// domain entities.
+// Store wraps a SQLite or PostgreSQL connection and exposes helpers to manage
+// PowerHive domain entities.
 type Store struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
+	cipher  *secretCipher
+	stmts   [preparedStmtCount]*sql.Stmt
+	// singleRowInserts turns off the multi-row chip and fan INSERTs, so the
+	// benchmarks can measure what chunking saves.
+	singleRowInserts bool
 }
 
-// New creates a Store and enables SQLite foreign keys on the supplied
-// connection. Call Init on the returned store to install the schema.
-func New(db *sql.DB) (*Store, error) {
+// New creates a Store for the given dialect. SQLite connections get foreign
+// keys, WAL and a busy timeout enabled. Call Init on the returned store to
+// install the schema.
+func New(db *sql.DB, dialect Dialect) (*Store, error) {
 	if db == nil {
 		return nil, fmt.Errorf("database connection is nil")
 	}
 
+	if dialect == DialectPostgres {
+		return &Store{db: db, dialect: dialect}, nil
+	}
+
 	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
 		return nil, fmt.Errorf("enable foreign keys: %w", err)
 	}
@@ -34,21 +45,22 @@ func New(db *sql.DB) (*Store, error) {
 		return nil, fmt.Errorf("set busy timeout: %w", err)
 	}
 
-	return &Store{db: db}, nil
+	return &Store{db: db, dialect: DialectSQLite}, nil
 }
 
 // Init installs the database schema. It is safe to call multiple times; every
-// statement uses IF NOT EXISTS guards.
+// statement uses IF NOT EXISTS guards. The SQLite statements are translated
+// for PostgreSQL so both backends migrate from the same list.
 func (s *Store) Init(ctx context.Context) error {
 	for i, stmt := range schemaStatements {
-		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+		if _, err := s.db.ExecContext(ctx, translateSchema(s.dialect, stmt)); err != nil {
 			if isIgnorableSchemaError(err) {
 				continue
 			}
 			return fmt.Errorf("apply schema statement %d: %w", i+1, err)
 		}
 	}
-	return nil
+	return s.prepareStatements(ctx)
 }
 
 // DB exposes the underlying database handle for read-only situations. Mutating
@@ -57,6 +69,11 @@ func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
+// Dialect reports which SQL backend the store is connected to.
+func (s *Store) Dialect() Dialect {
+	return s.dialect
+}
+
 func isIgnorableSchemaError(err error) bool {
 	if err == nil {
 		return false
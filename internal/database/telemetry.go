@@ -28,46 +28,8 @@ func (s *Store) RecordChainTelemetry(ctx context.Context, minerID string, record
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	for _, chain := range chains {
-		res, err := tx.ExecContext(ctx, `
-            INSERT INTO chain_snapshots (
-                miner_id,
-                status_id,
-                chain_identifier,
-                state,
-                hashrate,
-                pcb_temp_min,
-                pcb_temp_max,
-                chip_temp_min,
-                chip_temp_max,
-                recorded_at
-            ) VALUES (?, NULL, ?, ?, ?, ?, ?, ?, ?, ?)
-        `, minerID,
-			nullableTrimmedString(chain.ChainIdentifier),
-			nullableTrimmedString(chain.State),
-			nullableFloat64(chain.Hashrate),
-			nullableFloat64(chain.PCBTempMin),
-			nullableFloat64(chain.PCBTempMax),
-			nullableFloat64(chain.ChipTempMin),
-			nullableFloat64(chain.ChipTempMax),
-			recordedAt)
-		if err != nil {
-			return fmt.Errorf("insert chain telemetry: %w", err)
-		}
-
-		chainID, err := res.LastInsertId()
-		if err != nil {
-			return fmt.Errorf("chain telemetry id: %w", err)
-		}
-
-		for _, chip := range chain.Chips {
-			if _, err := tx.ExecContext(ctx, `
-				INSERT INTO chain_chips (chain_snapshot_id, chip_identifier, hashrate, temperature)
-				VALUES (?, ?, ?, ?)
-			`, chainID, nullableTrimmedString(chip.ChipIdentifier), nullableFloat64(chip.Hashrate), nullableFloat64(chip.Temperature)); err != nil {
-				return fmt.Errorf("insert chip telemetry: %w", err)
-			}
-		}
+	if err := s.insertChainSnapshotsTx(ctx, tx, minerID, nil, recordedAt, chains); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -105,6 +67,8 @@ func (s *Store) ListChainTelemetry(ctx context.Context, minerID string, limit in
             pcb_temp_max,
             chip_temp_min,
             chip_temp_max,
+            chip_temp_avg,
+            chip_count,
             recorded_at
         FROM chain_snapshots
         WHERE miner_id = ?
@@ -128,9 +92,11 @@ func (s *Store) ListChainTelemetry(ctx context.Context, minerID string, limit in
 			pcbTempMax      sql.NullFloat64
 			chipTempMin     sql.NullFloat64
 			chipTempMax     sql.NullFloat64
+			chipTempAvg     sql.NullFloat64
+			chipCount       sql.NullInt64
 		)
 
-		if err := rows.Scan(&snapshot.ID, &statusID, &snapshot.MinerID, &chainIdentifier, &state, &hashrate, &pcbTempMin, &pcbTempMax, &chipTempMin, &chipTempMax, &snapshot.RecordedAt); err != nil {
+		if err := rows.Scan(&snapshot.ID, &statusID, &snapshot.MinerID, &chainIdentifier, &state, &hashrate, &pcbTempMin, &pcbTempMax, &chipTempMin, &chipTempMax, &chipTempAvg, &chipCount, &snapshot.RecordedAt); err != nil {
 			return nil, fmt.Errorf("scan telemetry snapshot: %w", err)
 		}
 
@@ -145,12 +111,8 @@ func (s *Store) ListChainTelemetry(ctx context.Context, minerID string, limit in
 		snapshot.PCBTempMax = floatPtrFromNull(pcbTempMax)
 		snapshot.ChipTempMin = floatPtrFromNull(chipTempMin)
 		snapshot.ChipTempMax = floatPtrFromNull(chipTempMax)
-
-		chips, err := loadChipSnapshots(ctx, tx, snapshot.ID)
-		if err != nil {
-			return nil, err
-		}
-		snapshot.Chips = chips
+		snapshot.ChipTempAvg = floatPtrFromNull(chipTempAvg)
+		snapshot.ChipCount = intPtrFromNull(chipCount)
 
 		snapshots = append(snapshots, snapshot)
 	}
@@ -158,6 +120,11 @@ func (s *Store) ListChainTelemetry(ctx context.Context, minerID string, limit in
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate telemetry snapshots: %w", err)
 	}
+	rows.Close()
+
+	if err := s.attachChipSnapshots(ctx, tx, snapshots); err != nil {
+		return nil, err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit telemetry read tx: %w", err)
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const thermalLimitsKey = "thermal_limits"
+
+// ThermalLimits drive the balancer's temperature derating. A miner whose
+// hottest chip reaches ChipTempCeilingC is stepped down; within MarginC
+// below the ceiling its preset is not raised. A zero ceiling disables the
+// rule.
+type ThermalLimits struct {
+	ChipTempCeilingC float64 `json:"chip_temp_ceiling_c"`
+	MarginC          float64 `json:"margin_c"`
+}
+
+// DefaultThermalLimits applies until limits are saved.
+var DefaultThermalLimits = ThermalLimits{ChipTempCeilingC: 85, MarginC: 5}
+
+// GetThermalLimits returns the stored limits or DefaultThermalLimits.
+func (s *Store) GetThermalLimits(ctx context.Context) (ThermalLimits, error) {
+	raw, err := s.GetAppSetting(ctx, thermalLimitsKey)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultThermalLimits, nil
+		}
+		return ThermalLimits{}, err
+	}
+
+	var limits ThermalLimits
+	if err := json.Unmarshal([]byte(raw), &limits); err != nil {
+		return ThermalLimits{}, fmt.Errorf("decode thermal limits: %w", err)
+	}
+	return limits, nil
+}
+
+// SetThermalLimits stores limits.
+func (s *Store) SetThermalLimits(ctx context.Context, limits ThermalLimits) error {
+	data, err := json.Marshal(limits)
+	if err != nil {
+		return fmt.Errorf("encode thermal limits: %w", err)
+	}
+	return s.SetAppSetting(ctx, thermalLimitsKey, string(data))
+}
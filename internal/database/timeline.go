@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StatusChange is a status poll that reported a different state or preset
+// from the poll before it.
+type StatusChange struct {
+	StatusID   int64
+	MinerID    string
+	OldState   *string
+	NewState   *string
+	OldPreset  *string
+	NewPreset  *string
+	RecordedAt time.Time
+}
+
+// MinerTimeline is what was recorded about a miner in a time range, each
+// source oldest first.
+type MinerTimeline struct {
+	Availability    []AvailabilityChange
+	StatusChanges   []StatusChange
+	BalanceEvents   []PowerBalanceEvent
+	HashboardEvents []HashboardEvent
+	DriftEvents     []DriftEvent
+	KeyRotations    []KeyRotation
+}
+
+// GetMinerTimeline returns everything recorded about a miner from from
+// (inclusive) to to (exclusive).
+func (s *Store) GetMinerTimeline(ctx context.Context, minerID string, from, to time.Time) (MinerTimeline, error) {
+	from, to = from.UTC(), to.UTC()
+	var (
+		timeline MinerTimeline
+		err      error
+	)
+
+	timeline.Availability, err = s.queryAvailabilityChanges(ctx, `
+		SELECT id, miner_id, online, source, reason, changed_at
+		FROM miner_availability
+		WHERE miner_id = ? AND changed_at >= ? AND changed_at < ?
+		ORDER BY changed_at, id
+	`, minerID, from, to)
+	if err != nil {
+		return MinerTimeline{}, err
+	}
+
+	if timeline.StatusChanges, err = s.listStatusChanges(ctx, minerID, from, to); err != nil {
+		return MinerTimeline{}, err
+	}
+
+	if err := s.eachRow(ctx, "power balance events", func(rows *sql.Rows) error {
+		item, err := scanPowerBalanceEvent(rows)
+		if err != nil {
+			return err
+		}
+		timeline.BalanceEvents = append(timeline.BalanceEvents, item)
+		return nil
+	}, `
+		SELECT id, miner_id, old_preset, new_preset, old_power, new_power, reason,
+			total_consumption_before, total_consumption_after, available_power, target_power,
+			success, error_message, recorded_at
+		FROM power_balance_events
+		WHERE miner_id = ? AND recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at, id
+	`, minerID, from, to); err != nil {
+		return MinerTimeline{}, err
+	}
+
+	if err := s.eachRow(ctx, "hashboard events", func(rows *sql.Rows) error {
+		item, err := scanHashboardEvent(rows)
+		if err != nil {
+			return err
+		}
+		timeline.HashboardEvents = append(timeline.HashboardEvents, item)
+		return nil
+	}, `
+		SELECT id, miner_id, chain_identifier, action, attempt, success, error_message, recorded_at
+		FROM hashboard_events
+		WHERE miner_id = ? AND recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at, id
+	`, minerID, from, to); err != nil {
+		return MinerTimeline{}, err
+	}
+
+	if err := s.eachRow(ctx, "drift events", func(rows *sql.Rows) error {
+		item, err := scanDriftEvent(rows)
+		if err != nil {
+			return err
+		}
+		timeline.DriftEvents = append(timeline.DriftEvents, item)
+		return nil
+	}, `
+		SELECT id, miner_id, field, desired, actual, action, success, error_message, recorded_at
+		FROM drift_events
+		WHERE miner_id = ? AND recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at, id
+	`, minerID, from, to); err != nil {
+		return MinerTimeline{}, err
+	}
+
+	if err := s.eachRow(ctx, "key rotations", func(rows *sql.Rows) error {
+		item, err := scanKeyRotation(rows)
+		if err != nil {
+			return err
+		}
+		timeline.KeyRotations = append(timeline.KeyRotations, item)
+		return nil
+	}, `
+		SELECT id, miner_id, triggered_by, success, error_message, rotated_at
+		FROM api_key_rotations
+		WHERE miner_id = ? AND rotated_at >= ? AND rotated_at < ?
+		ORDER BY rotated_at, id
+	`, minerID, from, to); err != nil {
+		return MinerTimeline{}, err
+	}
+
+	return timeline, nil
+}
+
+// eachRow runs query and hands every row to fn, stopping at its first
+// error.
+func (s *Store) eachRow(ctx context.Context, what string, fn func(*sql.Rows) error, query string, args ...any) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate %s: %w", what, err)
+	}
+	return nil
+}
+
+// listStatusChanges compares each status poll in the range with the one
+// before it, starting from the last poll before from.
+func (s *Store) listStatusChanges(ctx context.Context, minerID string, from, to time.Time) ([]StatusChange, error) {
+	var (
+		prevState, prevPreset sql.NullString
+		seeded                bool
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT state, preset
+		FROM statuses
+		WHERE miner_id = ? AND recorded_at < ?
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT 1
+	`, minerID, from).Scan(&prevState, &prevPreset)
+	switch {
+	case err == nil:
+		seeded = true
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("query status before %s: %w", from.Format(time.RFC3339), err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, state, preset, recorded_at
+		FROM statuses
+		WHERE miner_id = ? AND recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at, id
+	`, minerID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query statuses: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []StatusChange
+	for rows.Next() {
+		var (
+			id            int64
+			state, preset sql.NullString
+			recordedAt    time.Time
+		)
+		if err := rows.Scan(&id, &state, &preset, &recordedAt); err != nil {
+			return nil, fmt.Errorf("scan status: %w", err)
+		}
+		// The first poll of a miner with no earlier history is where it
+		// starts, not a change.
+		if seeded && (state != prevState || preset != prevPreset) {
+			changes = append(changes, StatusChange{
+				StatusID:   id,
+				MinerID:    minerID,
+				OldState:   stringPtrFromNull(prevState),
+				NewState:   stringPtrFromNull(state),
+				OldPreset:  stringPtrFromNull(prevPreset),
+				NewPreset:  stringPtrFromNull(preset),
+				RecordedAt: recordedAt,
+			})
+		}
+		prevState, prevPreset, seeded = state, preset, true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate statuses: %w", err)
+	}
+	return changes, nil
+}
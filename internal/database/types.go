@@ -9,6 +9,7 @@ type Model struct {
 	Alias     string
 	Presets   []string
 	MaxPreset *string
+	MinPreset *string
 	CreatedAt time.Time
 }
 
@@ -18,6 +19,25 @@ type ModelInput struct {
 	Alias     string
 	Presets   []string
 	MaxPreset *string
+	MinPreset *string
+}
+
+// ModelPresetInput is one preset of a model with its expected power and
+// hashrate.
+type ModelPresetInput struct {
+	Value              string
+	ExpectedPowerW     *float64
+	ExpectedHashrateTH *float64
+}
+
+// ModelDefinition describes a model created through the API, for example
+// ahead of the hardware's arrival.
+type ModelDefinition struct {
+	Name      string
+	Alias     string
+	Presets   []ModelPresetInput
+	MaxPreset *string
+	MinPreset *string
 }
 
 // Miner models the persisted state for a physical miner.
@@ -27,6 +47,40 @@ type Miner struct {
 	APIKey         *string
 	Managed        bool
 	UnlockPass     string
+	FWName         *string
+	FWVersion      *string
+	Driver         string
+	// APIScheme and APIPort locate the firmware API. Both are nil for
+	// miners discovered before they were recorded, which use plain HTTP on
+	// port 80.
+	APIScheme      *string
+	APIPort        *int
+	Name           *string
+	Location       *string
+	Tags           []string
+	// Hold keeps the balancer away from the miner until HoldUntil, or
+	// indefinitely when HoldUntil is nil. See Held.
+	Hold           bool
+	HoldUntil      *time.Time
+	// Protected miners are left alone by the balancer and by automated
+	// preset and pool changes; only a forced admin request touches them.
+	Protected      bool
+	Reliability    PollReliability
+	// ArchivedAt is set for decommissioned miners. They are left out of
+	// ListMiners, so nothing polls or balances them, but keep their history.
+	ArchivedAt     *time.Time
+	// LastPresetChangeAt is when the balancer last changed the miner's
+	// preset. See CooldownRemaining.
+	LastPresetChangeAt *time.Time
+	// CustomerID is the hosting customer the miner belongs to, nil for the
+	// operator's own miners.
+	CustomerID     *int64
+	// Serial is the control board's serial number from /info, an identity
+	// that survives MAC changes. See MergeMiners.
+	Serial         *string
+	// RebootUntil ends the window of a reboot PowerHive started. See
+	// Rebooting.
+	RebootUntil    *time.Time
 	Model          *Model
 	Settings       *Settings
 	LatestStatus   *Status
@@ -43,6 +97,26 @@ type UpsertMinerParams struct {
 	Managed    *bool
 	UnlockPass *string
 	ModelAlias *string
+	FWName     *string
+	FWVersion  *string
+	Driver     *string
+	// APIScheme and APIPort are written together when APIPort is non-nil.
+	APIScheme  *string
+	APIPort    *int
+	Name       *string
+	Location   *string
+	// Tags replaces the miner's tags when non-nil; an empty slice clears them.
+	Tags *[]string
+	// Hold sets or clears the balancer hold. HoldUntil is written alongside
+	// it; nil holds indefinitely.
+	Hold      *bool
+	HoldUntil *time.Time
+	// Protected sets or clears the miner's protection.
+	Protected *bool
+	// CustomerID assigns the miner to a customer; 0 unassigns it.
+	CustomerID *int64
+	// Serial records the serial number; an empty value leaves it unchanged.
+	Serial *string
 }
 
 // Settings represents the persisted miner configuration payload.
@@ -60,7 +134,9 @@ type CoolingSettings struct {
 	FanMinCount *int
 	FanMinDuty  *int
 	FanMaxDuty  *int
-	Mode        string
+	// FanDuty is the fixed duty of manual mode.
+	FanDuty *int
+	Mode    string
 }
 
 // MiscSettings tracks optional settings unrelated to cooling.
@@ -92,6 +168,7 @@ type CoolingSettingsInput struct {
 	FanMinCount *int
 	FanMinDuty  *int
 	FanMaxDuty  *int
+	FanDuty     *int
 	Mode        string
 }
 
@@ -119,8 +196,23 @@ type Status struct {
 	PowerUsage       *float64
 	PowerConsumption *float64
 	RecordedAt       time.Time
-	Fans             []FanStatus
-	Chains           []ChainSnapshot
+	// LastConfirmedAt is the latest poll that found the miner as this
+	// snapshot describes it; RecordedAt until a later poll confirms it.
+	LastConfirmedAt time.Time
+	Fans            []FanStatus
+	Chains          []ChainSnapshot
+	Pools           []PoolStat
+}
+
+// StatusPower is the slice of a status snapshot used for energy accounting.
+type StatusPower struct {
+	ID               int64
+	MinerID          string
+	PowerConsumption *float64
+	RecordedAt       time.Time
+	// Confirmation marks the sample that repeats a snapshot at its last
+	// confirmation; RecordedAt is then that time.
+	Confirmation bool
 }
 
 // MinerStatusInput is used when recording a fresh status snapshot.
@@ -134,6 +226,7 @@ type MinerStatusInput struct {
 	RecordedAt       time.Time
 	Fans             []FanStatusInput
 	Chains           []ChainSnapshotInput
+	Pools            []PoolStatInput
 }
 
 // FanStatus represents the persisted state of a single fan.
@@ -152,6 +245,31 @@ type FanStatusInput struct {
 	Status        *string
 }
 
+// PoolStat is one pool's share counters as the firmware reported them in a
+// status poll. The counters are cumulative since the miner last restarted.
+type PoolStat struct {
+	ID        int64
+	StatusID  int64
+	PoolIndex int
+	URL       *string
+	Worker    *string
+	Status    *string
+	Accepted  *int64
+	Rejected  *int64
+	Stale     *int64
+}
+
+// PoolStatInput is the input representation for a pool entry.
+type PoolStatInput struct {
+	PoolIndex int
+	URL       *string
+	Worker    *string
+	Status    *string
+	Accepted  *int64
+	Rejected  *int64
+	Stale     *int64
+}
+
 // ChainSnapshot stores the state of a hashboard at a particular time.
 type ChainSnapshot struct {
 	ID              int64
@@ -164,6 +282,8 @@ type ChainSnapshot struct {
 	PCBTempMax      *float64
 	ChipTempMin     *float64
 	ChipTempMax     *float64
+	ChipTempAvg     *float64
+	ChipCount       *int
 	RecordedAt      time.Time
 	Chips           []ChipSnapshot
 }
@@ -177,6 +297,8 @@ type ChainSnapshotInput struct {
 	PCBTempMax      *float64
 	ChipTempMin     *float64
 	ChipTempMax     *float64
+	ChipTempAvg     *float64
+	ChipCount       *int
 	Chips           []ChipSnapshotInput
 }
 
@@ -204,7 +326,10 @@ type ModelPreset struct {
 	Position           int
 	ExpectedPowerW     *float64
 	ExpectedHashrateTH *float64
-	CreatedAt          time.Time
+	// HashrateObserved is set when ExpectedHashrateTH was back-filled from
+	// the hashrate miners reported on the preset.
+	HashrateObserved bool
+	CreatedAt        time.Time
 }
 
 // PlantReading stores a snapshot of hydro plant generation and consumption.
@@ -216,6 +341,12 @@ type PlantReading struct {
 	AvailablePower             float64
 	GenerationSources          map[string]float64 // Individual generator sources (e.g., "generoso", "nogueira") in MW
 	ConsumptionSources         map[string]float64 // Individual container sources (e.g., "container_eles", "container_mazp") in MW
+	ExportedPower              *float64           // Power exported to the grid in kW; negative when importing, nil if not reported
+	BatterySOC                 *float64           // Battery state of charge in percent, nil without a battery
+	BatteryPower               *float64           // Battery power in kW; positive when discharging, negative when charging
+	GridFrequency              *float64           // Grid frequency in Hz, nil if not reported
+	GridVoltage                *float64           // Grid voltage in V, nil if not reported
+	Fallback                   *string            // Low-confidence policy that produced the reading, nil for trusted readings
 	RawData                    *string
 	RecordedAt                 time.Time
 }
@@ -228,6 +359,12 @@ type PlantReadingInput struct {
 	AvailablePower            float64
 	GenerationSources         map[string]float64 // Individual generator sources in MW
 	ConsumptionSources        map[string]float64 // Individual container sources in MW
+	ExportedPower             *float64           // Grid export in kW, nil if not reported
+	BatterySOC                *float64           // Battery state of charge in percent
+	BatteryPower              *float64           // Battery power in kW, positive when discharging
+	GridFrequency             *float64           // Grid frequency in Hz
+	GridVoltage               *float64           // Grid voltage in V
+	Fallback                  *string            // Low-confidence policy that produced the reading
 	RawData                   *string
 	RecordedAt                time.Time
 }
@@ -248,6 +385,127 @@ type PowerBalanceEvent struct {
 	Success                 bool
 	ErrorMessage            *string
 	RecordedAt              time.Time
+	AcknowledgedAt          *time.Time // When an operator worked through the event
+	AcknowledgedBy          *string
+	Note                    *string
+}
+
+// Hashboard watchdog actions.
+const (
+	HashboardActionRestart   = "restart"
+	HashboardActionAlert     = "alert"
+	HashboardActionRecovered = "recovered"
+)
+
+// HashboardEvent records a hashboard watchdog action on a miner.
+type HashboardEvent struct {
+	ID              int64
+	MinerID         string
+	ChainIdentifier *string
+	Action          string
+	Attempt         int
+	Success         bool
+	ErrorMessage    *string
+	RecordedAt      time.Time
+}
+
+// Settings drift actions.
+const (
+	DriftActionDetected   = "detected"
+	DriftActionRemediated = "remediated"
+	DriftActionResolved   = "resolved"
+)
+
+// DriftEvent records a difference between a miner's live settings and its
+// desired settings, or what was done about it. Field names the setting,
+// such as "preset", "cooling.mode" or "pools".
+type DriftEvent struct {
+	ID           int64
+	MinerID      string
+	Field        string
+	Desired      *string
+	Actual       *string
+	Action       string
+	Success      bool
+	ErrorMessage *string
+	RecordedAt   time.Time
+}
+
+// KeyRotation records one attempt to replace a miner's firmware API key.
+// Trigger is "scheduled" or "manual".
+type KeyRotation struct {
+	ID           int64
+	MinerID      string
+	Trigger      string
+	Success      bool
+	ErrorMessage *string
+	RotatedAt    time.Time
+}
+
+// Unlock password scopes. A fleet password applies to every miner, the
+// others to the miners of a model alias, at a location or carrying a tag.
+const (
+	UnlockScopeFleet    = "fleet"
+	UnlockScopeModel    = "model"
+	UnlockScopeLocation = "location"
+	UnlockScopeTag      = "tag"
+)
+
+// UnlockPassword is a default unlock password from the credential vault.
+// Target is the model alias, location or tag the scope names, empty for
+// the fleet. Lower priorities are tried first.
+type UnlockPassword struct {
+	ID        int64
+	Scope     string
+	Target    string
+	Password  string
+	Priority  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UnlockPasswordInput creates or updates a vault entry. An empty Password
+// keeps the stored one on update.
+type UnlockPasswordInput struct {
+	Scope    string
+	Target   string
+	Password string
+	Priority int
+}
+
+// UnlockCandidate is a password to try when unlocking a miner. Source is
+// "miner" for the miner's own password, "default" for the factory
+// password, or the vault entry's scope and target, such as "model:s19".
+type UnlockCandidate struct {
+	Password string
+	Source   string
+}
+
+// DREvent is a demand-response curtailment window. While it is active the
+// balancer caps fleet consumption at MaxLoadKW. Samples counts the balance
+// cycles that ran during the event and Violations those whose measured load
+// exceeded the cap.
+type DREvent struct {
+	ID          int64
+	Source      string
+	Reason      *string
+	StartsAt    time.Time
+	EndsAt      time.Time
+	MaxLoadKW   float64
+	CancelledAt *time.Time
+	Samples     int
+	Violations  int
+	PeakLoadKW  *float64
+	CreatedAt   time.Time
+}
+
+// DREventInput schedules a demand-response event.
+type DREventInput struct {
+	Source    string
+	Reason    *string
+	StartsAt  time.Time
+	EndsAt    time.Time
+	MaxLoadKW float64
 }
 
 // PowerBalanceEventInput is used when logging a power balance event.
@@ -266,3 +524,62 @@ type PowerBalanceEventInput struct {
 	ErrorMessage           *string
 	RecordedAt             time.Time
 }
+
+// BalanceCycle summarises one run of the power balancer. Power values are in
+// watts except GenerationKW.
+type BalanceCycle struct {
+	ID                 int64
+	Mode               string
+	GenerationKW       float64
+	TargetPowerW       float64
+	ConsumptionBeforeW float64
+	ConsumptionAfterW  float64
+	MinersEligible     int
+	MinersAdjusted     int
+	MinersFailed       int
+	Skipped            map[string]int     // Miners left alone, counted by reason
+	SkippedMiners      []BalanceCycleSkip // Only filled by GetBalanceCycle
+	RecordedAt         time.Time
+}
+
+// BalanceCycleSkip records why a cycle left a miner alone.
+type BalanceCycleSkip struct {
+	MinerID string
+	Reason  string
+}
+
+// BalanceCycleInput is used when recording a balance cycle.
+type BalanceCycleInput struct {
+	Mode               string
+	GenerationKW       float64
+	TargetPowerW       float64
+	ConsumptionBeforeW float64
+	ConsumptionAfterW  float64
+	MinersEligible     int
+	MinersAdjusted     int
+	MinersFailed       int
+	SkippedMiners      map[string]string // Skip reason by miner ID
+	RecordedAt         time.Time
+}
+
+// ConfigProfile is a named set of settings that a rollout applies to every
+// miner tagged Tag. Nil or empty sections are left unchanged on the miners.
+type ConfigProfile struct {
+	ID        int64
+	Name      string
+	Tag       *string
+	Cooling   *CoolingSettingsInput
+	Misc      *MiscSettingsInput
+	Pools     []PoolInput
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ConfigProfileInput creates or replaces a configuration profile.
+type ConfigProfileInput struct {
+	Name    string
+	Tag     *string
+	Cooling *CoolingSettingsInput
+	Misc    *MiscSettingsInput
+	Pools   []PoolInput
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidUnlockPassword is returned for a vault entry with an unknown
+// scope, a missing target or an empty password.
+var ErrInvalidUnlockPassword = errors.New("invalid unlock password")
+
+// unlockScopeOrder ranks the scopes from most to least specific; candidates
+// are tried in this order.
+var unlockScopeOrder = []string{UnlockScopeTag, UnlockScopeLocation, UnlockScopeModel, UnlockScopeFleet}
+
+// ListUnlockPasswords returns the vault entries in the order they are tried:
+// by scope, from tag to fleet, then target, priority and ID.
+func (s *Store) ListUnlockPasswords(ctx context.Context) ([]UnlockPassword, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, scope, target, password, priority, created_at, updated_at
+		FROM unlock_passwords
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query unlock passwords: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []UnlockPassword
+	for rows.Next() {
+		var entry UnlockPassword
+		if err := rows.Scan(&entry.ID, &entry.Scope, &entry.Target, &entry.Password, &entry.Priority, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan unlock password: %w", err)
+		}
+		if entry.Password, err = s.revealSecret(entry.Password); err != nil {
+			return nil, fmt.Errorf("unlock password %d: %w", entry.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate unlock passwords: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if ra, rb := slices.Index(unlockScopeOrder, a.Scope), slices.Index(unlockScopeOrder, b.Scope); ra != rb {
+			return ra < rb
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.ID < b.ID
+	})
+	return entries, nil
+}
+
+// GetUnlockPassword returns a single vault entry.
+func (s *Store) GetUnlockPassword(ctx context.Context, id int64) (UnlockPassword, error) {
+	var entry UnlockPassword
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, scope, target, password, priority, created_at, updated_at
+		FROM unlock_passwords WHERE id = ?
+	`, id).Scan(&entry.ID, &entry.Scope, &entry.Target, &entry.Password, &entry.Priority, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UnlockPassword{}, fmt.Errorf("unlock password %d not found", id)
+		}
+		return UnlockPassword{}, fmt.Errorf("query unlock password %d: %w", id, err)
+	}
+	if entry.Password, err = s.revealSecret(entry.Password); err != nil {
+		return UnlockPassword{}, fmt.Errorf("unlock password %d: %w", id, err)
+	}
+	return entry, nil
+}
+
+// CreateUnlockPassword adds a default password to the vault.
+func (s *Store) CreateUnlockPassword(ctx context.Context, input UnlockPasswordInput) (UnlockPassword, error) {
+	input, err := normalizeUnlockPassword(input, true)
+	if err != nil {
+		return UnlockPassword{}, err
+	}
+	sealed, err := s.sealSecret(&input.Password)
+	if err != nil {
+		return UnlockPassword{}, err
+	}
+
+	now := time.Now().UTC()
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO unlock_passwords (scope, target, password, priority, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, input.Scope, input.Target, sealed, input.Priority, now, now).Scan(&id); err != nil {
+		return UnlockPassword{}, fmt.Errorf("insert unlock password: %w", err)
+	}
+	return s.GetUnlockPassword(ctx, id)
+}
+
+// UpdateUnlockPassword replaces a vault entry's scope, target and priority,
+// and its password unless input leaves it empty.
+func (s *Store) UpdateUnlockPassword(ctx context.Context, id int64, input UnlockPasswordInput) (UnlockPassword, error) {
+	input, err := normalizeUnlockPassword(input, false)
+	if err != nil {
+		return UnlockPassword{}, err
+	}
+
+	sets := []string{"scope = ?", "target = ?", "priority = ?", "updated_at = ?"}
+	args := []any{input.Scope, input.Target, input.Priority, time.Now().UTC()}
+	if input.Password != "" {
+		sealed, err := s.sealSecret(&input.Password)
+		if err != nil {
+			return UnlockPassword{}, err
+		}
+		sets = append(sets, "password = ?")
+		args = append(args, sealed)
+	}
+	args = append(args, id)
+
+	result, err := s.db.ExecContext(ctx, `UPDATE unlock_passwords SET `+strings.Join(sets, ", ")+` WHERE id = ?`, args...)
+	if err != nil {
+		return UnlockPassword{}, fmt.Errorf("update unlock password %d: %w", id, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return UnlockPassword{}, fmt.Errorf("unlock password rows affected: %w", err)
+	}
+	if rows == 0 {
+		return UnlockPassword{}, fmt.Errorf("unlock password %d not found", id)
+	}
+	return s.GetUnlockPassword(ctx, id)
+}
+
+// DeleteUnlockPassword removes a vault entry. Miners that already unlocked
+// with it keep it as their own password.
+func (s *Store) DeleteUnlockPassword(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM unlock_passwords WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete unlock password %d: %w", id, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unlock password rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("unlock password %d not found", id)
+	}
+	return nil
+}
+
+// UnlockCandidates returns the passwords to try when unlocking the miner:
+// its own password, then the vault entries matching its tags, location,
+// model and finally the fleet, then the factory default. Each password
+// appears once, at its first position.
+func (s *Store) UnlockCandidates(ctx context.Context, miner Miner) ([]UnlockCandidate, error) {
+	entries, err := s.ListUnlockPasswords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var candidates []UnlockCandidate
+	add := func(password, source string) {
+		if password == "" || seen[password] {
+			return
+		}
+		seen[password] = true
+		candidates = append(candidates, UnlockCandidate{Password: password, Source: source})
+	}
+
+	add(strings.TrimSpace(miner.UnlockPass), "miner")
+	for _, entry := range entries {
+		if unlockPasswordMatches(entry, miner) {
+			source := entry.Scope
+			if entry.Target != "" {
+				source += ":" + entry.Target
+			}
+			add(entry.Password, source)
+		}
+	}
+	add(defaultUnlockPass, "default")
+	return candidates, nil
+}
+
+func unlockPasswordMatches(entry UnlockPassword, miner Miner) bool {
+	switch entry.Scope {
+	case UnlockScopeFleet:
+		return true
+	case UnlockScopeModel:
+		return miner.Model != nil && strings.EqualFold(miner.Model.Alias, entry.Target)
+	case UnlockScopeLocation:
+		return miner.Location != nil && strings.EqualFold(strings.TrimSpace(*miner.Location), entry.Target)
+	case UnlockScopeTag:
+		return slices.Contains(miner.Tags, entry.Target)
+	}
+	return false
+}
+
+// normalizeUnlockPassword validates a vault entry. Targets are compared
+// case-insensitively, so tags are stored lowercased like miner tags.
+func normalizeUnlockPassword(input UnlockPasswordInput, requirePassword bool) (UnlockPasswordInput, error) {
+	input.Scope = strings.ToLower(strings.TrimSpace(input.Scope))
+	input.Target = strings.TrimSpace(input.Target)
+	input.Password = strings.TrimSpace(input.Password)
+
+	switch input.Scope {
+	case UnlockScopeFleet:
+		input.Target = ""
+	case UnlockScopeTag:
+		input.Target = strings.ToLower(input.Target)
+		fallthrough
+	case UnlockScopeModel, UnlockScopeLocation:
+		if input.Target == "" {
+			return input, fmt.Errorf("%w: a %s password needs a target", ErrInvalidUnlockPassword, input.Scope)
+		}
+	default:
+		return input, fmt.Errorf("%w: scope must be one of %s", ErrInvalidUnlockPassword, strings.Join(unlockScopeOrder, ", "))
+	}
+	if requirePassword && input.Password == "" {
+		return input, fmt.Errorf("%w: password is required", ErrInvalidUnlockPassword)
+	}
+	return input, nil
+}
@@ -0,0 +1,385 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User roles. Admins manage users and may change protected miners;
+// operators use the rest of the API.
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+)
+
+// Password length limits. bcrypt ignores anything past 72 bytes.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+var (
+	// ErrUserExists is returned when a username is taken.
+	ErrUserExists = errors.New("username already in use")
+	// ErrInvalidCredentials is returned by AuthenticateUser for an unknown
+	// user or a wrong password alike.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidSession is returned by SessionForToken for unknown and
+	// expired sessions.
+	ErrInvalidSession = errors.New("invalid or expired session")
+	// ErrLastAdmin is returned when a change would leave no admin.
+	ErrLastAdmin = errors.New("at least one admin must remain")
+)
+
+// dummyPasswordHash is compared against when a username is unknown, so a
+// failed login takes as long whether or not the user exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("powerhive"), bcrypt.DefaultCost)
+	return hash
+})
+
+// User is a dashboard account. The password is only stored as a bcrypt
+// hash.
+type User struct {
+	ID          int64
+	Username    string
+	Role        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	LastLoginAt *time.Time
+}
+
+// UserUpdate changes the fields that are not nil. A new password ends the
+// user's sessions.
+type UserUpdate struct {
+	Password *string
+	Role     *string
+}
+
+// Session is a signed-in dashboard session. Only a hash of its token is
+// stored; CSRFToken must accompany every write made with it.
+type Session struct {
+	ID        int64
+	UserID    int64
+	CSRFToken string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// ValidUserRole reports whether role is one of the user roles.
+func ValidUserRole(role string) bool {
+	return role == RoleAdmin || role == RoleOperator
+}
+
+// CreateUser stores a new user with a hash of password.
+func (s *Store) CreateUser(ctx context.Context, username, password, role string) (User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return User{}, fmt.Errorf("username is required")
+	}
+	if !ValidUserRole(role) {
+		return User{}, fmt.Errorf("role must be %q or %q", RoleAdmin, RoleOperator)
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+	if err := s.checkUsername(ctx, username); err != nil {
+		return User{}, err
+	}
+
+	now := time.Now().UTC()
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO users (username, password_hash, role, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`, username, hash, role, now, now).Scan(&id); err != nil {
+		return User{}, fmt.Errorf("insert user %s: %w", username, err)
+	}
+	return s.GetUser(ctx, id)
+}
+
+// UpdateUser changes a user's password or role. Demoting the last admin
+// fails with ErrLastAdmin.
+func (s *Store) UpdateUser(ctx context.Context, id int64, update UserUpdate) (User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("begin update user tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var role string
+	err = tx.QueryRowContext(ctx, `SELECT role FROM users WHERE id = ?`, id).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, fmt.Errorf("user %d not found", id)
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("query user %d: %w", id, err)
+	}
+
+	now := time.Now().UTC()
+	if update.Role != nil && *update.Role != role {
+		if !ValidUserRole(*update.Role) {
+			return User{}, fmt.Errorf("role must be %q or %q", RoleAdmin, RoleOperator)
+		}
+		if role == RoleAdmin {
+			if err := lastAdminCheck(ctx, tx, id); err != nil {
+				return User{}, err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET role = ?, updated_at = ? WHERE id = ?`, *update.Role, now, id); err != nil {
+			return User{}, fmt.Errorf("update role of user %d: %w", id, err)
+		}
+	}
+	if update.Password != nil {
+		hash, err := hashPassword(*update.Password)
+		if err != nil {
+			return User{}, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?`, hash, now, id); err != nil {
+			return User{}, fmt.Errorf("update password of user %d: %w", id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_sessions WHERE user_id = ?`, id); err != nil {
+			return User{}, fmt.Errorf("end sessions of user %d: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("commit update user tx: %w", err)
+	}
+	return s.GetUser(ctx, id)
+}
+
+// DeleteUser removes a user and ends its sessions. Deleting the last admin
+// fails with ErrLastAdmin.
+func (s *Store) DeleteUser(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete user tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var role string
+	err = tx.QueryRowContext(ctx, `SELECT role FROM users WHERE id = ?`, id).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user %d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("query user %d: %w", id, err)
+	}
+	if role == RoleAdmin {
+		if err := lastAdminCheck(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_sessions WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("delete sessions of user %d: %w", id, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete user %d: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete user tx: %w", err)
+	}
+	return nil
+}
+
+// GetUser returns a single user.
+func (s *Store) GetUser(ctx context.Context, id int64) (User, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, username, role, created_at, updated_at, last_login_at FROM users WHERE id = ?
+	`, id)
+	user, err := scanUser(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, fmt.Errorf("user %d not found", id)
+		}
+		return User{}, err
+	}
+	return user, nil
+}
+
+// ListUsers returns every user ordered by username.
+func (s *Store) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, username, role, created_at, updated_at, last_login_at FROM users ORDER BY username
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+	return users, nil
+}
+
+// AuthenticateUser checks a username and password and notes the login, or
+// returns ErrInvalidCredentials.
+func (s *Store) AuthenticateUser(ctx context.Context, username, password string, now time.Time) (User, error) {
+	var (
+		id   int64
+		hash string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, password_hash FROM users WHERE username = ?
+	`, strings.TrimSpace(username)).Scan(&id, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("look up user %s: %w", username, err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return User{}, ErrInvalidCredentials
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE users SET last_login_at = ? WHERE id = ?`, now.UTC(), id); err != nil {
+		return User{}, fmt.Errorf("record login of user %d: %w", id, err)
+	}
+	return s.GetUser(ctx, id)
+}
+
+// CreateSession starts a session for the user that lasts ttl, and removes
+// expired sessions. The token itself is only returned here; the store keeps
+// its hash.
+func (s *Store) CreateSession(ctx context.Context, userID int64, ttl time.Duration, now time.Time) (Session, string, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return Session{}, "", fmt.Errorf("generate session token: %w", err)
+	}
+	csrf, err := randomHex(32)
+	if err != nil {
+		return Session{}, "", fmt.Errorf("generate csrf token: %w", err)
+	}
+
+	now = now.UTC()
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE expires_at <= ?`, now); err != nil {
+		return Session{}, "", fmt.Errorf("purge expired sessions: %w", err)
+	}
+	session := Session{
+		UserID:    userID,
+		CSRFToken: csrf,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO user_sessions (user_id, token_hash, csrf_token, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`, userID, hashToken(token), csrf, session.CreatedAt, session.ExpiresAt).Scan(&session.ID); err != nil {
+		return Session{}, "", fmt.Errorf("insert session for user %d: %w", userID, err)
+	}
+	return session, token, nil
+}
+
+// SessionForToken returns an unexpired session and its user, or
+// ErrInvalidSession.
+func (s *Store) SessionForToken(ctx context.Context, token string, now time.Time) (Session, User, error) {
+	var session Session
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, csrf_token, created_at, expires_at FROM user_sessions
+		WHERE token_hash = ? AND expires_at > ?
+	`, hashToken(token), now.UTC()).Scan(&session.ID, &session.UserID, &session.CSRFToken, &session.CreatedAt, &session.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, User{}, ErrInvalidSession
+	}
+	if err != nil {
+		return Session{}, User{}, fmt.Errorf("look up session: %w", err)
+	}
+
+	user, err := s.GetUser(ctx, session.UserID)
+	if err != nil {
+		return Session{}, User{}, err
+	}
+	return session, user, nil
+}
+
+// DeleteSession ends the session with the token. Unknown tokens are
+// ignored.
+func (s *Store) DeleteSession(ctx context.Context, token string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE token_hash = ?`, hashToken(token)); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
+
+func scanUser(row interface{ Scan(...any) error }) (User, error) {
+	var (
+		user      User
+		lastLogin sql.NullTime
+	)
+	if err := row.Scan(&user.ID, &user.Username, &user.Role, &user.CreatedAt, &user.UpdatedAt, &lastLogin); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, err
+		}
+		return User{}, fmt.Errorf("scan user: %w", err)
+	}
+	user.LastLoginAt = timePtrFromNull(lastLogin)
+	return user, nil
+}
+
+// checkUsername reports ErrUserExists when the username is taken.
+func (s *Store) checkUsername(ctx context.Context, username string) error {
+	var existing int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM users WHERE username = ?`, username).Scan(&existing)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("look up user %s: %w", username, err)
+	}
+	return fmt.Errorf("%w: %s", ErrUserExists, username)
+}
+
+// lastAdminCheck returns ErrLastAdmin unless an admin other than id exists.
+func lastAdminCheck(ctx context.Context, tx *sql.Tx, id int64) error {
+	var others int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = ? AND id <> ?`, RoleAdmin, id).Scan(&others); err != nil {
+		return fmt.Errorf("count admins: %w", err)
+	}
+	if others == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return "", fmt.Errorf("password must be %d to %d bytes long", MinPasswordLength, MaxPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Webhook delivery states.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// WebhookDelivery is one event queued for one webhook endpoint, with the
+// outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID            int64
+	Endpoint      string
+	URL           string
+	Event         string
+	Payload       string
+	State         string
+	Attempts      int
+	LastStatus    *int
+	LastError     *string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// WebhookDeliveryInput queues an event for an endpoint.
+type WebhookDeliveryInput struct {
+	Endpoint  string
+	URL       string
+	Event     string
+	Payload   string
+	CreatedAt time.Time
+}
+
+// WebhookAttempt records how a delivery attempt to URL went. State is the
+// delivery's new state; a pending delivery is tried again at
+// NextAttemptAt.
+type WebhookAttempt struct {
+	URL           string
+	At            time.Time
+	State         string
+	StatusCode    *int
+	Error         *string
+	NextAttemptAt time.Time
+}
+
+// WebhookDeliveryFilter narrows ListWebhookDeliveries. Empty fields match
+// everything.
+type WebhookDeliveryFilter struct {
+	State    string
+	Event    string
+	Endpoint string
+	Limit    int
+}
+
+const webhookDeliveryColumns = `id, endpoint, url, event, payload, state, attempts, last_status, last_error, next_attempt_at, created_at, delivered_at`
+
+// EnqueueWebhookDelivery queues an event for delivery, due immediately.
+func (s *Store) EnqueueWebhookDelivery(ctx context.Context, input WebhookDeliveryInput) (WebhookDelivery, error) {
+	input.Endpoint = strings.TrimSpace(input.Endpoint)
+	if input.Endpoint == "" || strings.TrimSpace(input.URL) == "" || strings.TrimSpace(input.Event) == "" {
+		return WebhookDelivery{}, fmt.Errorf("webhook endpoint, url and event are required")
+	}
+	if input.CreatedAt.IsZero() {
+		input.CreatedAt = time.Now()
+	}
+	createdAt := input.CreatedAt.UTC()
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (endpoint, url, event, payload, state, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, input.Endpoint, input.URL, input.Event, input.Payload, WebhookPending, createdAt, createdAt).Scan(&id)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("insert webhook delivery for %s: %w", input.Endpoint, err)
+	}
+	return WebhookDelivery{
+		ID:            id,
+		Endpoint:      input.Endpoint,
+		URL:           input.URL,
+		Event:         input.Event,
+		Payload:       input.Payload,
+		State:         WebhookPending,
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+	}, nil
+}
+
+// DueWebhookDeliveries returns pending deliveries whose next attempt is at
+// or before now, oldest first.
+func (s *Store) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE state = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+	`, WebhookPending, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query due webhook deliveries: %w", err)
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// RecordWebhookAttempt counts an attempt and moves the delivery to
+// attempt.State.
+func (s *Store) RecordWebhookAttempt(ctx context.Context, id int64, attempt WebhookAttempt) error {
+	var deliveredAt *time.Time
+	if attempt.State == WebhookDelivered {
+		deliveredAt = &attempt.At
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1,
+			url = ?,
+			state = ?,
+			last_status = ?,
+			last_error = ?,
+			next_attempt_at = ?,
+			delivered_at = ?
+		WHERE id = ?
+	`, attempt.URL, attempt.State, nullableInt(attempt.StatusCode), nullableString(attempt.Error),
+		attempt.NextAttemptAt.UTC(), nullableTime(deliveredAt), id)
+	if err != nil {
+		return fmt.Errorf("record attempt for webhook delivery %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("webhook delivery %d not found", id)
+	}
+	return nil
+}
+
+// RetryWebhookDelivery puts a delivery back in the queue, due at now, with
+// a fresh set of attempts.
+func (s *Store) RetryWebhookDelivery(ctx context.Context, id int64, now time.Time) (WebhookDelivery, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET state = ?, attempts = 0, next_attempt_at = ?, delivered_at = NULL
+		WHERE id = ?
+	`, WebhookPending, now.UTC(), id)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("retry webhook delivery %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return WebhookDelivery{}, fmt.Errorf("webhook delivery %d not found", id)
+	}
+	return s.GetWebhookDelivery(ctx, id)
+}
+
+// GetWebhookDelivery returns a single delivery.
+func (s *Store) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("query webhook delivery %d: %w", id, err)
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	if len(deliveries) == 0 {
+		return WebhookDelivery{}, fmt.Errorf("webhook delivery %d not found", id)
+	}
+	return deliveries[0], nil
+}
+
+// ListWebhookDeliveries returns deliveries newest first.
+func (s *Store) ListWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries`
+	var (
+		where []string
+		args  []any
+	)
+	if filter.State != "" {
+		where = append(where, "state = ?")
+		args = append(args, filter.State)
+	}
+	if filter.Event != "" {
+		where = append(where, "event = ?")
+		args = append(args, filter.Event)
+	}
+	if filter.Endpoint != "" {
+		where = append(where, "endpoint = ?")
+		args = append(args, filter.Endpoint)
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query webhook deliveries: %w", err)
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var (
+			delivery    WebhookDelivery
+			lastStatus  sql.NullInt64
+			lastError   sql.NullString
+			deliveredAt sql.NullTime
+		)
+		if err := rows.Scan(&delivery.ID, &delivery.Endpoint, &delivery.URL, &delivery.Event, &delivery.Payload,
+			&delivery.State, &delivery.Attempts, &lastStatus, &lastError, &delivery.NextAttemptAt,
+			&delivery.CreatedAt, &deliveredAt); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		delivery.LastStatus = intPtrFromNull(lastStatus)
+		delivery.LastError = stringPtrFromNull(lastError)
+		delivery.DeliveredAt = timePtrFromNull(deliveredAt)
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrWriteQueueClosed is returned for writes submitted after the queue
+// stopped.
+var ErrWriteQueueClosed = errors.New("write queue closed")
+
+// WriteQueue gathers status and telemetry writes from concurrent pollers and
+// commits them in shared transactions, so a poll of hundreds of miners costs
+// a handful of commits rather than one each. Every write runs under its own
+// savepoint: a failing miner is rolled back and reported to its caller
+// without affecting the rest of the batch.
+type WriteQueue struct {
+	store    *Store
+	requests chan *writeRequest
+	done     chan struct{}
+	maxBatch int
+	maxDelay time.Duration
+}
+
+type writeRequest struct {
+	write  func(ctx context.Context, tx *sql.Tx) error
+	result chan error
+}
+
+// NewWriteQueue returns a queue that commits once maxBatch writes are
+// pending or maxDelay after the first one arrived. Run must be started for
+// writes to complete.
+func (s *Store) NewWriteQueue(maxBatch int, maxDelay time.Duration) *WriteQueue {
+	if maxBatch <= 0 {
+		maxBatch = 1
+	}
+	return &WriteQueue{
+		store:    s,
+		requests: make(chan *writeRequest),
+		done:     make(chan struct{}),
+		maxBatch: maxBatch,
+		maxDelay: maxDelay,
+	}
+}
+
+// RecordMinerStatus queues a status snapshot and waits until it is
+// committed. It behaves like Store.RecordMinerStatus without reading the
+// stored status back.
+func (q *WriteQueue) RecordMinerStatus(ctx context.Context, minerID string, input MinerStatusInput) error {
+	minerID = strings.TrimSpace(minerID)
+	if minerID == "" {
+		return fmt.Errorf("miner id is required")
+	}
+	return q.submit(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := q.store.recordStatusTx(ctx, tx, minerID, input)
+		return err
+	})
+}
+
+// ConfirmMinerStatus queues a status confirmation and waits until it is
+// committed. It behaves like Store.ConfirmMinerStatus.
+func (q *WriteQueue) ConfirmMinerStatus(ctx context.Context, minerID string, statusID int64, confirmedAt time.Time) error {
+	minerID = strings.TrimSpace(minerID)
+	if minerID == "" {
+		return fmt.Errorf("miner id is required")
+	}
+	return q.submit(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return q.store.confirmStatusTx(ctx, tx, minerID, statusID, confirmedAt)
+	})
+}
+
+// RecordChainTelemetry queues chain telemetry and waits until it is
+// committed.
+func (q *WriteQueue) RecordChainTelemetry(ctx context.Context, minerID string, recordedAt time.Time, chains []ChainSnapshotInput) error {
+	minerID = strings.TrimSpace(minerID)
+	if minerID == "" {
+		return fmt.Errorf("miner id is required")
+	}
+	if len(chains) == 0 {
+		return nil
+	}
+	if recordedAt.IsZero() {
+		recordedAt = time.Now().UTC()
+	}
+	return q.submit(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return q.store.insertChainSnapshotsTx(ctx, tx, minerID, nil, recordedAt, chains)
+	})
+}
+
+func (q *WriteQueue) submit(ctx context.Context, write func(context.Context, *sql.Tx) error) error {
+	req := &writeRequest{write: write, result: make(chan error, 1)}
+	select {
+	case q.requests <- req:
+	case <-q.done:
+		return ErrWriteQueueClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run commits queued writes until ctx is cancelled. The batch in progress
+// is finished before it returns; later writes fail with
+// ErrWriteQueueClosed.
+func (q *WriteQueue) Run(ctx context.Context) {
+	defer close(q.done)
+
+	for {
+		var first *writeRequest
+		select {
+		case <-ctx.Done():
+			return
+		case first = <-q.requests:
+		}
+
+		batch := []*writeRequest{first}
+		timer := time.NewTimer(q.maxDelay)
+	collect:
+		for len(batch) < q.maxBatch {
+			select {
+			case req := <-q.requests:
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			case <-ctx.Done():
+				break collect
+			}
+		}
+		timer.Stop()
+
+		q.commit(context.WithoutCancel(ctx), batch)
+	}
+}
+
+// commit runs batch in one transaction, each write under a savepoint, and
+// reports every write's outcome to its caller.
+func (q *WriteQueue) commit(ctx context.Context, batch []*writeRequest) {
+	results := make([]error, len(batch))
+	if err := q.commitTx(ctx, batch, results); err != nil {
+		for i := range results {
+			results[i] = err
+		}
+	}
+	for i, req := range batch {
+		req.result <- results[i]
+	}
+}
+
+func (q *WriteQueue) commitTx(ctx context.Context, batch []*writeRequest, results []error) error {
+	tx, err := q.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin write batch tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, req := range batch {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT queued_write`); err != nil {
+			return fmt.Errorf("write batch savepoint: %w", err)
+		}
+		if results[i] = req.write(ctx, tx); results[i] != nil {
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT queued_write`); err != nil {
+				return fmt.Errorf("write batch rollback to savepoint: %w", err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT queued_write`); err != nil {
+			return fmt.Errorf("write batch release savepoint: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit write batch tx: %w", err)
+	}
+	return nil
+}
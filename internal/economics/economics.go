@@ -0,0 +1,201 @@
+// Package economics estimates mining revenue, energy cost and profit from
+// a hashprice, the plant's energy cost and miner hashrate/power figures.
+package economics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// SettingKey is the app_settings key holding the economics settings.
+const SettingKey = "economics"
+
+// Settings sources.
+const (
+	SourceManual = "manual"
+	SourceFeed   = "feed"
+)
+
+// Settings holds the market and cost inputs. Hashprice is the expected
+// revenue of one TH/s over a day.
+type Settings struct {
+	BTCPriceUSD          *float64 `json:"btc_price_usd,omitempty"`
+	HashpriceUSDPerTHDay *float64 `json:"hashprice_usd_per_th_day,omitempty"`
+	EnergyCostUSDPerKWh  *float64 `json:"energy_cost_usd_per_kwh,omitempty"`
+	// TariffPeriods override EnergyCostUSDPerKWh at certain times of day,
+	// read in TariffTimezone (default UTC).
+	TariffPeriods       []TariffPeriod `json:"tariff_periods,omitempty"`
+	TariffTimezone      string         `json:"tariff_timezone,omitempty"`
+	MinProfitablePreset bool           `json:"min_profitable_preset"`
+	Source              string         `json:"source,omitempty"`
+	UpdatedAt           *time.Time     `json:"updated_at,omitempty"`
+}
+
+// TariffPeriod prices energy from Start until End, both "HH:MM". A period
+// whose End is not after its Start runs past midnight.
+type TariffPeriod struct {
+	Name      string  `json:"name,omitempty"`
+	Start     string  `json:"start"`
+	End       string  `json:"end"`
+	USDPerKWh float64 `json:"usd_per_kwh"`
+}
+
+// Tariff resolves the energy price in force at a given time.
+type Tariff struct {
+	base    float64
+	loc     *time.Location
+	periods []tariffWindow
+}
+
+type tariffWindow struct {
+	start, end int // minutes after midnight
+	rate       float64
+}
+
+// Tariff returns the configured tariff, or nil when no energy cost is set.
+// It fails on malformed periods or an unknown time zone.
+func (s Settings) Tariff() (*Tariff, error) {
+	if s.EnergyCostUSDPerKWh == nil {
+		if len(s.TariffPeriods) > 0 {
+			return nil, fmt.Errorf("tariff periods need energy_cost_usd_per_kwh as the base rate")
+		}
+		return nil, nil
+	}
+
+	tariff := &Tariff{base: *s.EnergyCostUSDPerKWh, loc: time.UTC}
+	if s.TariffTimezone != "" {
+		loc, err := time.LoadLocation(s.TariffTimezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown tariff time zone %q", s.TariffTimezone)
+		}
+		tariff.loc = loc
+	}
+	for i, period := range s.TariffPeriods {
+		start, err := parseClock(period.Start)
+		if err != nil {
+			return nil, fmt.Errorf("tariff period %d: start: %w", i+1, err)
+		}
+		end, err := parseClock(period.End)
+		if err != nil {
+			return nil, fmt.Errorf("tariff period %d: end: %w", i+1, err)
+		}
+		if period.USDPerKWh < 0 {
+			return nil, fmt.Errorf("tariff period %d: usd_per_kwh must not be negative", i+1)
+		}
+		tariff.periods = append(tariff.periods, tariffWindow{start: start, end: end, rate: period.USDPerKWh})
+	}
+	return tariff, nil
+}
+
+// Rate returns the price per kWh at t. The first matching period wins;
+// outside every period the base energy cost applies.
+func (t *Tariff) Rate(at time.Time) float64 {
+	local := at.In(t.loc)
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range t.periods {
+		if window.start < window.end {
+			if minute >= window.start && minute < window.end {
+				return window.rate
+			}
+		} else if minute >= window.start || minute < window.end {
+			return window.rate
+		}
+	}
+	return t.base
+}
+
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// Complete reports whether both hashprice and energy cost are known.
+func (s Settings) Complete() bool {
+	return s.HashpriceUSDPerTHDay != nil && s.EnergyCostUSDPerKWh != nil
+}
+
+// Estimate is a per-day projection for a hashrate and power draw.
+type Estimate struct {
+	HashrateTH float64 `json:"hashrate_th"`
+	PowerW     float64 `json:"power_w"`
+	RevenueUSD float64 `json:"revenue_usd"`
+	CostUSD    float64 `json:"cost_usd"`
+	ProfitUSD  float64 `json:"profit_usd"`
+}
+
+// Estimate projects a day of running at hashrateTH and powerW. It returns
+// false when the settings are incomplete.
+func (s Settings) Estimate(hashrateTH, powerW float64) (Estimate, bool) {
+	if !s.Complete() {
+		return Estimate{}, false
+	}
+	revenue := hashrateTH * *s.HashpriceUSDPerTHDay
+	cost := powerW / 1000 * 24 * *s.EnergyCostUSDPerKWh
+	return Estimate{
+		HashrateTH: hashrateTH,
+		PowerW:     powerW,
+		RevenueUSD: revenue,
+		CostUSD:    cost,
+		ProfitUSD:  revenue - cost,
+	}, true
+}
+
+// Load reads the stored settings. Missing settings yield the zero value.
+func Load(ctx context.Context, store database.SettingsStore) (Settings, error) {
+	raw, err := store.GetAppSetting(ctx, SettingKey)
+	if err != nil {
+		if errors.Is(err, database.ErrSettingNotFound) {
+			return Settings{}, nil
+		}
+		return Settings{}, err
+	}
+
+	var settings Settings
+	if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+		return Settings{}, fmt.Errorf("decode economics settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Save stores settings, stamping UpdatedAt.
+func Save(ctx context.Context, store database.SettingsStore, settings Settings) error {
+	now := time.Now().UTC()
+	settings.UpdatedAt = &now
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("encode economics settings: %w", err)
+	}
+	return store.SetAppSetting(ctx, SettingKey, string(data))
+}
+
+// UnprofitablePresets returns, per model alias, the presets whose expected
+// hashrate and power give a negative daily profit. Presets missing either
+// figure are left out.
+func (s Settings) UnprofitablePresets(presets map[string][]database.ModelPreset) map[string]map[string]bool {
+	out := make(map[string]map[string]bool)
+	for alias, list := range presets {
+		for _, preset := range list {
+			if preset.ExpectedHashrateTH == nil || preset.ExpectedPowerW == nil {
+				continue
+			}
+			estimate, ok := s.Estimate(*preset.ExpectedHashrateTH, *preset.ExpectedPowerW)
+			if !ok || estimate.ProfitUSD >= 0 {
+				continue
+			}
+			if out[alias] == nil {
+				out[alias] = make(map[string]bool)
+			}
+			out[alias][preset.Value] = true
+		}
+	}
+	return out
+}
@@ -0,0 +1,283 @@
+package firmware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const maxAntminerChains = 16
+
+// AntminerDriver reads stock Antminer firmware through the cgminer/bmminer
+// API. Stock firmware offers no power control over that API, so the
+// preset, sleep and wake operations return ErrUnsupported.
+type AntminerDriver struct {
+	api *CGMinerClient
+}
+
+// NewAntminerDriver builds a stock-firmware driver for addr. The request
+// timeout is taken from WithHTTPClient when supplied.
+func NewAntminerDriver(addr string, opts ...Option) (*AntminerDriver, error) {
+	base, err := NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	host := strings.TrimSpace(addr)
+	if u, err := url.Parse(base.baseURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+
+	api, err := NewCGMinerClient(host, base.httpClient.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &AntminerDriver{api: api}, nil
+}
+
+// Kind identifies the stock Antminer driver.
+func (a *AntminerDriver) Kind() string {
+	return DriverAntminer
+}
+
+// API exposes the underlying cgminer client.
+func (a *AntminerDriver) API() *CGMinerClient {
+	return a.api
+}
+
+// Info maps the version command onto InfoResponse. The cgminer API does not
+// report a MAC address.
+func (a *AntminerDriver) Info(ctx context.Context) (InfoResponse, error) {
+	version, err := a.api.Version(ctx)
+	if err != nil {
+		return InfoResponse{}, err
+	}
+
+	info := InfoResponse{
+		Miner:     strings.TrimSpace(version.Type),
+		Model:     strings.TrimSpace(version.Type),
+		FWName:    "bmminer",
+		FWVersion: strings.TrimSpace(version.CompileTime),
+	}
+	switch {
+	case version.BMMiner != "":
+		if info.FWVersion == "" {
+			info.FWVersion = version.BMMiner
+		}
+	case version.CGMiner != "":
+		info.FWName = "cgminer"
+		if info.FWVersion == "" {
+			info.FWVersion = version.CGMiner
+		}
+	}
+	return info, nil
+}
+
+// Summary combines the summary, stats and pools commands. A failing pools
+// command does not fail the summary.
+func (a *AntminerDriver) Summary(ctx context.Context) (SummaryResponse, error) {
+	summary, err := a.api.Summary(ctx)
+	if err != nil {
+		return SummaryResponse{}, err
+	}
+	stats, err := a.minerStats(ctx)
+	if err != nil {
+		return SummaryResponse{}, err
+	}
+
+	miner := SummaryMiner{
+		HashrateRealtime: summary.GHS5s,
+		HashrateAverage:  summary.GHSAvg,
+		HashrateNominal:  statFloat(stats, "total_rateideal"),
+	}
+	miner.MinerStatus.MinerStateTime = summary.Elapsed
+	miner.MinerStatus.MinerState = "stopped"
+	if summary.GHS5s != nil && *summary.GHS5s > 0 {
+		miner.MinerStatus.MinerState = "mining"
+	}
+
+	for i := 1; i <= 8; i++ {
+		rpm := statInt(stats, fmt.Sprintf("fan%d", i))
+		if rpm == nil || *rpm <= 0 {
+			continue
+		}
+		miner.Cooling.Fans = append(miner.Cooling.Fans, SummaryFan{ID: i, RPM: rpm, Status: "ok"})
+	}
+	miner.Cooling.FanNum = len(miner.Cooling.Fans)
+
+	if pools, err := a.api.Pools(ctx); err == nil {
+		for _, pool := range pools {
+			miner.Pools = append(miner.Pools, SummaryPool{
+				ID:       pool.ID,
+				URL:      pool.URL,
+				User:     pool.User,
+				Status:   strings.ToLower(pool.Status),
+				Accepted: pool.Accepted,
+				Rejected: pool.Rejected,
+				Stale:    pool.Stale,
+			})
+		}
+	}
+
+	for _, chain := range antminerChains(stats) {
+		miner.Chains = append(miner.Chains, SummaryChain{
+			ID:               chain.id,
+			Frequency:        chain.freq,
+			HashrateRealtime: chain.rate,
+			HashrateIdeal:    chain.ideal,
+			PowerConsumption: chain.power,
+			PCBTemp:          chain.pcb,
+			ChipTemp:         chain.chip,
+			Status:           ChainStatus{State: chain.state},
+		})
+	}
+
+	return SummaryResponse{Miner: miner}, nil
+}
+
+// Chains returns chain-level telemetry; per-chip data is not available.
+func (a *AntminerDriver) Chains(ctx context.Context) ([]ChainTelemetry, error) {
+	stats, err := a.minerStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var chains []ChainTelemetry
+	for _, chain := range antminerChains(stats) {
+		chains = append(chains, ChainTelemetry{
+			ID:               chain.id,
+			Status:           ChainStatus{State: chain.state},
+			HashrateRealtime: chain.rate,
+			HashrateNominal:  chain.ideal,
+			Frequency:        chain.freq,
+		})
+	}
+	return chains, nil
+}
+
+// SetPowerTarget is not available on stock firmware.
+func (a *AntminerDriver) SetPowerTarget(ctx context.Context, preset string) (*SaveConfigResult, error) {
+	return nil, ErrUnsupported
+}
+
+// Sleep is not available on stock firmware.
+func (a *AntminerDriver) Sleep(ctx context.Context) error {
+	return ErrUnsupported
+}
+
+// Wake is not available on stock firmware.
+func (a *AntminerDriver) Wake(ctx context.Context) error {
+	return ErrUnsupported
+}
+
+// Restart issues the cgminer restart command. Stock firmware only accepts it
+// when the API is configured with write access.
+func (a *AntminerDriver) Restart(ctx context.Context) error {
+	return a.api.Command(ctx, "restart", "", nil)
+}
+
+// minerStats returns the stats entry holding the mining counters; the first
+// entry only describes the software.
+func (a *AntminerDriver) minerStats(ctx context.Context) (map[string]any, error) {
+	entries, err := a.api.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if _, ok := entry["GHS 5s"]; ok {
+			return entry, nil
+		}
+		if _, ok := entry["Elapsed"]; ok {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("cgminer stats carry no mining counters")
+}
+
+type antminerChain struct {
+	id    int
+	state string
+	rate  *float64
+	ideal *float64
+	freq  *float64
+	power *float64
+	pcb   TemperatureRange
+	chip  TemperatureRange
+}
+
+// antminerChains extracts the numbered chain_* and temp* stats. Newer
+// firmware reports temperatures as dash-separated sensor lists.
+func antminerChains(stats map[string]any) []antminerChain {
+	var chains []antminerChain
+	for i := 1; i <= maxAntminerChains; i++ {
+		chips := statInt(stats, fmt.Sprintf("chain_acn%d", i))
+		rate := statFloat(stats, fmt.Sprintf("chain_rate%d", i))
+		if (chips == nil || *chips == 0) && rate == nil {
+			continue
+		}
+
+		chain := antminerChain{
+			id:    i,
+			state: "mining",
+			rate:  rate,
+			ideal: statFloat(stats, fmt.Sprintf("chain_rateideal%d", i)),
+			freq:  statFloat(stats, fmt.Sprintf("freq_avg%d", i)),
+			power: statFloat(stats, fmt.Sprintf("chain_consumption%d", i)),
+			pcb:   tempRange(stats, fmt.Sprintf("temp_pcb%d", i), fmt.Sprintf("temp%d", i)),
+			chip:  tempRange(stats, fmt.Sprintf("temp_chip%d", i), fmt.Sprintf("temp2_%d", i)),
+		}
+		if chips == nil || *chips == 0 || rate == nil || *rate <= 0 {
+			chain.state = "failure"
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// tempRange reads the first present key as either a number or a list like
+// "38-41-36-39".
+func tempRange(stats map[string]any, keys ...string) TemperatureRange {
+	for _, key := range keys {
+		value, ok := stats[key]
+		if !ok {
+			continue
+		}
+
+		var readings []float64
+		switch v := value.(type) {
+		case float64:
+			readings = append(readings, v)
+		case string:
+			for _, part := range strings.Split(v, "-") {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err == nil {
+					readings = append(readings, f)
+				}
+			}
+		}
+
+		var out TemperatureRange
+		for _, r := range readings {
+			if r <= 0 {
+				continue
+			}
+			if out.Min == nil || r < *out.Min {
+				out.Min = floatPtr(r)
+			}
+			if out.Max == nil || r > *out.Max {
+				out.Max = floatPtr(r)
+			}
+		}
+		return out
+	}
+	return TemperatureRange{}
+}
+
+func floatPtr(value float64) *float64 {
+	if math.IsNaN(value) {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,446 @@
+package firmware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBraiinsUsername = "root"
+	braiinsTokenSlack      = 30 * time.Second
+	braiinsSoftwareName    = "Braiins OS"
+)
+
+// BraiinsClient drives miners running Braiins OS through the JSON gateway of
+// its public gRPC API. Presets are power targets in watts.
+type BraiinsClient struct {
+	baseURL    string
+	httpClient *http.Client
+	username   string
+	password   string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewBraiinsClient builds a Braiins OS driver. An empty username selects the
+// firmware default.
+func NewBraiinsClient(addr, username, password string, opts ...Option) (*BraiinsClient, error) {
+	base, err := NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(username) == "" {
+		username = defaultBraiinsUsername
+	}
+
+	return &BraiinsClient{
+		baseURL:    base.baseURL,
+		httpClient: base.httpClient,
+		username:   strings.TrimSpace(username),
+		password:   password,
+	}, nil
+}
+
+// Kind identifies the Braiins OS driver.
+func (b *BraiinsClient) Kind() string {
+	return DriverBraiins
+}
+
+// Info maps the miner details onto InfoResponse.
+func (b *BraiinsClient) Info(ctx context.Context) (InfoResponse, error) {
+	var details braiinsDetails
+	if err := b.do(ctx, http.MethodGet, "/miner/details", nil, &details); err != nil {
+		return InfoResponse{}, err
+	}
+
+	info := InfoResponse{
+		Miner:     strings.TrimSpace(details.Identity.Name),
+		Model:     strings.TrimSpace(details.Identity.Model),
+		FWName:    braiinsSoftwareName,
+		FWVersion: strings.TrimSpace(details.Version.Current),
+		Serial:    strings.TrimSpace(details.SerialNumber),
+	}
+	if info.Miner == "" {
+		info.Miner = strings.TrimSpace(strings.Join([]string{details.Identity.Brand, details.Identity.Model}, " "))
+	}
+	info.System.MinerName = strings.TrimSpace(details.Hostname)
+	info.System.NetworkStatus.MAC = strings.ToLower(strings.TrimSpace(details.MACAddress))
+	info.System.NetworkStatus.Hostname = strings.TrimSpace(details.Hostname)
+	return info, nil
+}
+
+// Summary combines miner stats, cooling state and hashboards.
+func (b *BraiinsClient) Summary(ctx context.Context) (SummaryResponse, error) {
+	var stats braiinsStats
+	if err := b.do(ctx, http.MethodGet, "/miner/stats", nil, &stats); err != nil {
+		return SummaryResponse{}, err
+	}
+
+	var details braiinsDetails
+	if err := b.do(ctx, http.MethodGet, "/miner/details", nil, &details); err != nil {
+		return SummaryResponse{}, err
+	}
+
+	var cooling braiinsCooling
+	if err := b.do(ctx, http.MethodGet, "/cooling/state", nil, &cooling); err != nil {
+		return SummaryResponse{}, err
+	}
+
+	boards, err := b.hashboards(ctx)
+	if err != nil {
+		return SummaryResponse{}, err
+	}
+
+	miner := SummaryMiner{
+		MinerType:        strings.TrimSpace(details.Identity.Model),
+		HashrateRealtime: stats.Miner.RealHashrate.Last5s.GHs,
+		HashrateAverage:  stats.Miner.RealHashrate.Last15m.GHs,
+		HashrateNominal:  stats.Miner.NominalHashrate.GHs,
+		PowerConsumption: stats.Power.Consumption.Watt,
+		PowerEfficiency:  stats.Power.Efficiency.JoulePerTH,
+	}
+	miner.MinerStatus.MinerState = braiinsState(details.Status)
+	miner.MinerStatus.MinerStateTime = details.BosminerUptime
+
+	miner.Cooling.FanNum = len(cooling.Fans)
+	for _, fan := range cooling.Fans {
+		miner.Cooling.Fans = append(miner.Cooling.Fans, SummaryFan{
+			ID:     fan.Position,
+			RPM:    fan.RPM,
+			Status: "ok",
+		})
+	}
+
+	for _, board := range boards {
+		id, _ := strconv.Atoi(board.ID)
+		chain := SummaryChain{
+			ID:               id,
+			HashrateRealtime: board.Stats.RealHashrate.Last5s.GHs,
+			HashrateIdeal:    board.Stats.NominalHashrate.GHs,
+			Voltage:          board.Voltage.Volt,
+			PCBTemp:          TemperatureRange{Max: board.BoardTemp.DegreeC},
+			ChipTemp:         TemperatureRange{Max: board.HighestChipTemp.Temperature.DegreeC},
+			Status:           ChainStatus{State: braiinsBoardState(board.Enabled)},
+		}
+		if board.Frequency.Hertz != nil {
+			mhz := *board.Frequency.Hertz / 1e6
+			chain.Frequency = &mhz
+		}
+		miner.Chains = append(miner.Chains, chain)
+	}
+
+	return SummaryResponse{Miner: miner}, nil
+}
+
+// Chains returns per-board telemetry. Braiins OS does not expose per-chip
+// readings, so Chips is left empty.
+func (b *BraiinsClient) Chains(ctx context.Context) ([]ChainTelemetry, error) {
+	boards, err := b.hashboards(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chains := make([]ChainTelemetry, 0, len(boards))
+	for _, board := range boards {
+		id, _ := strconv.Atoi(board.ID)
+		chain := ChainTelemetry{
+			ID:               id,
+			Status:           ChainStatus{State: braiinsBoardState(board.Enabled)},
+			HashrateRealtime: board.Stats.RealHashrate.Last5s.GHs,
+			HashrateNominal:  board.Stats.NominalHashrate.GHs,
+		}
+		if board.Frequency.Hertz != nil {
+			mhz := *board.Frequency.Hertz / 1e6
+			chain.Frequency = &mhz
+		}
+		chains = append(chains, chain)
+	}
+	return chains, nil
+}
+
+// SetPowerTarget sets the tuner power target. The preset must be a wattage
+// such as "3250" or "3250W"; SleepPreset pauses mining instead.
+func (b *BraiinsClient) SetPowerTarget(ctx context.Context, preset string) (*SaveConfigResult, error) {
+	preset = strings.TrimSpace(preset)
+	if strings.EqualFold(preset, SleepPreset) {
+		return nil, b.Sleep(ctx)
+	}
+
+	watts, err := strconv.ParseUint(strings.TrimSuffix(strings.ToUpper(preset), "W"), 10, 64)
+	if err != nil || watts == 0 {
+		return nil, fmt.Errorf("braiins preset %q is not a power target in watts", preset)
+	}
+
+	payload := map[string]any{
+		"save_action": "SAVE_ACTION_SAVE_AND_APPLY",
+		"power_target": map[string]any{
+			"watt": watts,
+		},
+	}
+	if err := b.do(ctx, http.MethodPut, "/performance/power-target", payload, nil); err != nil {
+		return nil, err
+	}
+
+	// A paused miner keeps its new target but stays idle until resumed.
+	if err := b.Wake(ctx); err != nil {
+		return nil, err
+	}
+	return &SaveConfigResult{}, nil
+}
+
+// Sleep pauses mining.
+func (b *BraiinsClient) Sleep(ctx context.Context) error {
+	return b.do(ctx, http.MethodPut, "/actions/pause", map[string]any{}, nil)
+}
+
+// Wake resumes mining after Sleep.
+func (b *BraiinsClient) Wake(ctx context.Context) error {
+	return b.do(ctx, http.MethodPut, "/actions/resume", map[string]any{}, nil)
+}
+
+// Restart restarts the mining process.
+func (b *BraiinsClient) Restart(ctx context.Context) error {
+	return b.do(ctx, http.MethodPut, "/actions/restart", map[string]any{}, nil)
+}
+
+// Reboot reboots the control board.
+func (b *BraiinsClient) Reboot(ctx context.Context) error {
+	return b.do(ctx, http.MethodPut, "/actions/reboot", map[string]any{}, nil)
+}
+
+// Locate turns the locate LED on or off.
+func (b *BraiinsClient) Locate(ctx context.Context, on bool) error {
+	return b.do(ctx, http.MethodPut, "/actions/locate", map[string]any{"enable": on}, nil)
+}
+
+// CurrentPreset reports the active power target, or SleepPreset when paused.
+func (b *BraiinsClient) CurrentPreset(ctx context.Context) (*string, error) {
+	var details braiinsDetails
+	if err := b.do(ctx, http.MethodGet, "/miner/details", nil, &details); err != nil {
+		return nil, err
+	}
+	if braiinsState(details.Status) == "paused" {
+		preset := SleepPreset
+		return &preset, nil
+	}
+
+	var tuner braiinsTunerState
+	if err := b.do(ctx, http.MethodGet, "/performance/tuner-state", nil, &tuner); err != nil {
+		return nil, err
+	}
+	watt := tuner.ModeState.PowerTarget.CurrentTarget.Watt
+	if watt == nil {
+		return nil, nil
+	}
+	preset := strconv.FormatUint(*watt, 10)
+	return &preset, nil
+}
+
+func (b *BraiinsClient) hashboards(ctx context.Context) ([]braiinsHashboard, error) {
+	var resp struct {
+		Hashboards []braiinsHashboard `json:"hashboards"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/miner/hw/hashboards", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Hashboards, nil
+}
+
+// login returns a cached session token, refreshing it shortly before expiry.
+func (b *BraiinsClient) login(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.token != "" && time.Now().Before(b.expires) {
+		return b.token, nil
+	}
+
+	var resp struct {
+		Token    string `json:"token"`
+		TimeoutS int64  `json:"timeout_s"`
+	}
+	payload := map[string]string{"username": b.username, "password": b.password}
+	if err := b.send(ctx, http.MethodPost, "/auth/login", "", payload, &resp); err != nil {
+		return "", fmt.Errorf("braiins login: %w", err)
+	}
+	if strings.TrimSpace(resp.Token) == "" {
+		return "", fmt.Errorf("braiins login succeeded but token is empty")
+	}
+
+	b.token = resp.Token
+	b.expires = time.Now().Add(time.Duration(resp.TimeoutS)*time.Second - braiinsTokenSlack)
+	return b.token, nil
+}
+
+func (b *BraiinsClient) do(ctx context.Context, method, endpoint string, body, out any) error {
+	token, err := b.login(ctx)
+	if err != nil {
+		return err
+	}
+	return b.send(ctx, method, endpoint, token, body, out)
+}
+
+func (b *BraiinsClient) send(ctx context.Context, method, endpoint, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("create request %s %s: %w", method, endpoint, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s %s: %w", method, endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	// Drop an expired session so the next call logs in again. Login requests
+	// carry no token and already hold the lock.
+	if resp.StatusCode == http.StatusUnauthorized && token != "" {
+		b.mu.Lock()
+		b.token = ""
+		b.mu.Unlock()
+	}
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("braiins %s %s: %d %s", method, endpoint, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s response: %w", endpoint, err)
+	}
+	return nil
+}
+
+func braiinsState(status string) string {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch {
+	case strings.HasSuffix(status, "_NORMAL"):
+		return "mining"
+	case strings.HasSuffix(status, "_PAUSED"):
+		return "paused"
+	case strings.HasSuffix(status, "_SUSPENDED"):
+		return "suspended"
+	case strings.HasSuffix(status, "_RESTRICTED"):
+		return "restricted"
+	case status == "":
+		return ""
+	default:
+		return strings.ToLower(status)
+	}
+}
+
+func braiinsBoardState(enabled bool) string {
+	if enabled {
+		return "mining"
+	}
+	return "disabled"
+}
+
+type braiinsDetails struct {
+	Identity struct {
+		Brand string `json:"brand"`
+		Model string `json:"model"`
+		Name  string `json:"name"`
+	} `json:"miner_identity"`
+	Hostname string `json:"hostname"`
+	Version  struct {
+		Current string `json:"current"`
+	} `json:"bos_version"`
+	MACAddress     string `json:"mac_address"`
+	SerialNumber   string `json:"serial_number"`
+	Status         string `json:"status"`
+	BosminerUptime *int64 `json:"bosminer_uptime_s"`
+}
+
+type braiinsHashrate struct {
+	GHs *float64 `json:"gigahash_per_second"`
+}
+
+type braiinsRealHashrate struct {
+	Last5s  braiinsHashrate `json:"last_5s"`
+	Last15m braiinsHashrate `json:"last_15m"`
+}
+
+type braiinsStats struct {
+	Miner struct {
+		RealHashrate    braiinsRealHashrate `json:"real_hashrate"`
+		NominalHashrate braiinsHashrate     `json:"nominal_hashrate"`
+	} `json:"miner_stats"`
+	Power struct {
+		Consumption struct {
+			Watt *float64 `json:"watt"`
+		} `json:"approximated_consumption"`
+		Efficiency struct {
+			JoulePerTH *float64 `json:"joule_per_terahash"`
+		} `json:"efficiency"`
+	} `json:"power_stats"`
+}
+
+type braiinsCooling struct {
+	Fans []struct {
+		Position int  `json:"position"`
+		RPM      *int `json:"rpm"`
+	} `json:"fans"`
+}
+
+type braiinsTemperature struct {
+	DegreeC *float64 `json:"degree_c"`
+}
+
+type braiinsHashboard struct {
+	ID      string `json:"id"`
+	Enabled bool   `json:"enabled"`
+	Voltage struct {
+		Volt *float64 `json:"volt"`
+	} `json:"current_voltage"`
+	Frequency struct {
+		Hertz *float64 `json:"hertz"`
+	} `json:"current_frequency"`
+	BoardTemp       braiinsTemperature `json:"board_temp"`
+	HighestChipTemp struct {
+		Temperature braiinsTemperature `json:"temperature"`
+	} `json:"highest_chip_temp"`
+	Stats struct {
+		RealHashrate    braiinsRealHashrate `json:"real_hashrate"`
+		NominalHashrate braiinsHashrate     `json:"nominal_hashrate"`
+	} `json:"stats"`
+}
+
+type braiinsTunerState struct {
+	ModeState struct {
+		PowerTarget struct {
+			CurrentTarget struct {
+				Watt *uint64 `json:"watt"`
+			} `json:"current_target"`
+		} `json:"power_target_mode_state"`
+	} `json:"mode_state"`
+}
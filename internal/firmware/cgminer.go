@@ -0,0 +1,265 @@
+package firmware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CGMinerPort is the default port of the cgminer/bmminer JSON API.
+	CGMinerPort          = "4028"
+	defaultCGMinerWait   = 5 * time.Second
+	maxCGMinerReplyBytes = 1 << 20
+)
+
+// CGMinerClient speaks the line-less JSON protocol of the cgminer/bmminer
+// API: one request per TCP connection, reply terminated by a NUL byte.
+type CGMinerClient struct {
+	addr    string
+	timeout time.Duration
+}
+
+// NewCGMinerClient builds a client for host, defaulting to port 4028.
+func NewCGMinerClient(host string, timeout time.Duration) (*CGMinerClient, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return nil, fmt.Errorf("miner address is required")
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, CGMinerPort)
+	}
+	if timeout <= 0 {
+		timeout = defaultCGMinerWait
+	}
+	return &CGMinerClient{addr: host, timeout: timeout}, nil
+}
+
+// CGMinerStatus is the STATUS block every reply carries.
+type CGMinerStatus struct {
+	Status string `json:"STATUS"`
+	Code   int    `json:"Code"`
+	Msg    string `json:"Msg"`
+}
+
+// CGMinerSummary is the subset of the summary command used by PowerHive.
+// Hashrates are in GH/s.
+type CGMinerSummary struct {
+	Elapsed     *int64
+	GHS5s       *float64
+	GHSAvg      *float64
+	Accepted    *int
+	Rejected    *int
+	HardwareErr *int
+}
+
+// CGMinerVersion describes the miner software.
+type CGMinerVersion struct {
+	Miner       string `json:"Miner"`
+	BMMiner     string `json:"BMMiner"`
+	CGMiner     string `json:"CGMiner"`
+	API         string `json:"API"`
+	CompileTime string `json:"CompileTime"`
+	Type        string `json:"Type"`
+}
+
+// CGMinerPool is one entry of the pools command.
+type CGMinerPool struct {
+	ID       int    `json:"POOL"`
+	URL      string `json:"URL"`
+	User     string `json:"User"`
+	Status   string `json:"Status"`
+	Priority int    `json:"Priority"`
+	Accepted *int   `json:"Accepted"`
+	Rejected *int   `json:"Rejected"`
+	Stale    *int   `json:"Stale"`
+}
+
+// Summary runs the summary command.
+func (c *CGMinerClient) Summary(ctx context.Context) (CGMinerSummary, error) {
+	var reply struct {
+		Status  []CGMinerStatus  `json:"STATUS"`
+		Summary []map[string]any `json:"SUMMARY"`
+	}
+	if err := c.Command(ctx, "summary", "", &reply); err != nil {
+		return CGMinerSummary{}, err
+	}
+	if err := replyError(reply.Status); err != nil {
+		return CGMinerSummary{}, err
+	}
+	if len(reply.Summary) == 0 {
+		return CGMinerSummary{}, fmt.Errorf("cgminer summary is empty")
+	}
+
+	// Numeric fields arrive as numbers from cgminer and as strings from
+	// some bmminer builds.
+	raw := reply.Summary[0]
+	summary := CGMinerSummary{
+		GHS5s:       statFloat(raw, "GHS 5s"),
+		GHSAvg:      statFloat(raw, "GHS av"),
+		Accepted:    statInt(raw, "Accepted"),
+		Rejected:    statInt(raw, "Rejected"),
+		HardwareErr: statInt(raw, "Hardware Errors"),
+	}
+	if elapsed := statFloat(raw, "Elapsed"); elapsed != nil {
+		value := int64(*elapsed)
+		summary.Elapsed = &value
+	}
+	if summary.GHS5s == nil {
+		if mhs := statFloat(raw, "MHS 5s"); mhs != nil {
+			ghs := *mhs / 1000
+			summary.GHS5s = &ghs
+		}
+	}
+	if summary.GHSAvg == nil {
+		if mhs := statFloat(raw, "MHS av"); mhs != nil {
+			ghs := *mhs / 1000
+			summary.GHSAvg = &ghs
+		}
+	}
+	return summary, nil
+}
+
+// Stats runs the stats command. Entries are returned as raw key/value maps
+// since the fields differ between miner generations.
+func (c *CGMinerClient) Stats(ctx context.Context) ([]map[string]any, error) {
+	var reply struct {
+		Status []CGMinerStatus  `json:"STATUS"`
+		Stats  []map[string]any `json:"STATS"`
+	}
+	if err := c.Command(ctx, "stats", "", &reply); err != nil {
+		return nil, err
+	}
+	if err := replyError(reply.Status); err != nil {
+		return nil, err
+	}
+	return reply.Stats, nil
+}
+
+// Pools runs the pools command.
+func (c *CGMinerClient) Pools(ctx context.Context) ([]CGMinerPool, error) {
+	var reply struct {
+		Status []CGMinerStatus `json:"STATUS"`
+		Pools  []CGMinerPool   `json:"POOLS"`
+	}
+	if err := c.Command(ctx, "pools", "", &reply); err != nil {
+		return nil, err
+	}
+	if err := replyError(reply.Status); err != nil {
+		return nil, err
+	}
+	return reply.Pools, nil
+}
+
+// Version runs the version command.
+func (c *CGMinerClient) Version(ctx context.Context) (CGMinerVersion, error) {
+	var reply struct {
+		Status  []CGMinerStatus  `json:"STATUS"`
+		Version []CGMinerVersion `json:"VERSION"`
+	}
+	if err := c.Command(ctx, "version", "", &reply); err != nil {
+		return CGMinerVersion{}, err
+	}
+	if err := replyError(reply.Status); err != nil {
+		return CGMinerVersion{}, err
+	}
+	if len(reply.Version) == 0 {
+		return CGMinerVersion{}, fmt.Errorf("cgminer version is empty")
+	}
+	return reply.Version[0], nil
+}
+
+// Command sends a single API command and decodes the reply into out. A nil
+// out discards the reply, for commands such as restart that answer with
+// plain text.
+func (c *CGMinerClient) Command(ctx context.Context, command, parameter string, out any) error {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("connect cgminer api %s: %w", c.addr, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	request := map[string]string{"command": command}
+	if parameter != "" {
+		request["parameter"] = parameter
+	}
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return fmt.Errorf("send cgminer %s: %w", command, err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(conn, maxCGMinerReplyBytes))
+	if err != nil && len(data) == 0 {
+		return fmt.Errorf("read cgminer %s: %w", command, err)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	data = sanitizeCGMinerReply(data)
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode cgminer %s: %w", command, err)
+	}
+	return nil
+}
+
+// sanitizeCGMinerReply strips the NUL terminator and repairs the malformed
+// JSON some bmminer builds emit between STATS entries.
+func sanitizeCGMinerReply(data []byte) []byte {
+	data = bytes.TrimRight(data, "\x00\r\n ")
+	data = bytes.ReplaceAll(data, []byte("}{"), []byte("},{"))
+	return data
+}
+
+func replyError(status []CGMinerStatus) error {
+	if len(status) == 0 {
+		return nil
+	}
+	switch strings.ToUpper(status[0].Status) {
+	case "E", "F":
+		return fmt.Errorf("cgminer error %d: %s", status[0].Code, status[0].Msg)
+	}
+	return nil
+}
+
+// statFloat reads a numeric stat that may be encoded as a number or string.
+func statFloat(stats map[string]any, key string) *float64 {
+	value, ok := stats[key]
+	if !ok {
+		return nil
+	}
+	switch v := value.(type) {
+	case float64:
+		return &v
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil
+		}
+		return &f
+	}
+	return nil
+}
+
+// statInt reads an integer stat that may be encoded as a number or string.
+func statInt(stats map[string]any, key string) *int {
+	f := statFloat(stats, key)
+	if f == nil {
+		return nil
+	}
+	value := int(*f)
+	return &value
+}
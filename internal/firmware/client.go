@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -23,6 +26,8 @@ type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	apiKey     string
+	// optErr records an option that could not be applied.
+	optErr error
 }
 
 // Option mutates the client during construction.
@@ -60,6 +65,9 @@ func NewClient(addr string, opts ...Option) (*Client, error) {
 	for _, opt := range opts {
 		opt(client)
 	}
+	if client.optErr != nil {
+		return nil, client.optErr
+	}
 
 	if client.baseURL == "" {
 		baseURL, err := deriveBaseURL(addr)
@@ -184,9 +192,9 @@ func (c *Client) AutotunePresets(ctx context.Context, bearer string) ([]Autotune
 // Uses POST /settings with minimal payload to change only the preset.
 // Returns SaveConfigResult indicating if a restart/reboot is required.
 func (c *Client) SetPreset(ctx context.Context, apiKey, preset string) (*SaveConfigResult, error) {
-	payload := SetPresetRequest{
+	payload := SettingsRequest{
 		Miner: MinerConfig{
-			Overclock: OverclockSettings{
+			Overclock: &OverclockSettings{
 				Preset: preset,
 			},
 		},
@@ -203,6 +211,75 @@ func (c *Client) SetPreset(ctx context.Context, apiKey, preset string) (*SaveCon
 	return &result, nil
 }
 
+// SetCoolingSettings changes the cooling mode and fan limits using an API
+// key. Like SetPreset it posts only the cooling block to /settings.
+func (c *Client) SetCoolingSettings(ctx context.Context, apiKey string, cooling CoolingSettings) (*SaveConfigResult, error) {
+	payload := SettingsRequest{
+		Miner: MinerConfig{
+			Cooling: &cooling,
+		},
+	}
+
+	var result SaveConfigResult
+	if err := c.do(ctx, http.MethodPost, "/settings", requestOptions{
+		apiKey: apiKey,
+		body:   payload,
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetSettings reads the miner's configured settings using an API key.
+func (c *Client) GetSettings(ctx context.Context, apiKey string) (MinerConfig, error) {
+	var settings SettingsRequest
+	err := c.do(ctx, http.MethodGet, "/settings", requestOptions{
+		apiKey: apiKey,
+	}, &settings)
+	return settings.Miner, err
+}
+
+// SetPoolSettings replaces the pool list using an API key. Like SetPreset it
+// posts only the pools to /settings.
+func (c *Client) SetPoolSettings(ctx context.Context, apiKey string, pools []PoolSettings) (*SaveConfigResult, error) {
+	payload := SettingsRequest{
+		Miner: MinerConfig{
+			Pools: pools,
+		},
+	}
+
+	var result SaveConfigResult
+	if err := c.do(ctx, http.MethodPost, "/settings", requestOptions{
+		apiKey: apiKey,
+		body:   payload,
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// SetMiscSettings changes the misc settings using an API key. Like
+// SetPreset it posts only the misc block to /settings.
+func (c *Client) SetMiscSettings(ctx context.Context, apiKey string, misc MiscSettings) (*SaveConfigResult, error) {
+	payload := SettingsRequest{
+		Miner: MinerConfig{
+			Misc: &misc,
+		},
+	}
+
+	var result SaveConfigResult
+	if err := c.do(ctx, http.MethodPost, "/settings", requestOptions{
+		apiKey: apiKey,
+		body:   payload,
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 func (c *Client) RestartMining(ctx context.Context, apiKey string) error {
 	if err := c.do(ctx, http.MethodPost, "/restart", requestOptions{
 		apiKey: apiKey,
@@ -214,6 +291,62 @@ func (c *Client) RestartMining(ctx context.Context, apiKey string) error {
 	return nil
 }
 
+// UpdateFirmware uploads a firmware image using a bearer token. The miner
+// flashes the image and reboots once the upload completes. The client's
+// request timeout does not apply; bound the upload with ctx instead.
+func (c *Client) UpdateFirmware(ctx context.Context, bearer, filename string, image io.Reader, keepSettings bool) error {
+	if c == nil {
+		return fmt.Errorf("nil client")
+	}
+	if image == nil {
+		return fmt.Errorf("firmware image is required")
+	}
+
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+
+	go func() {
+		err := func() error {
+			if err := form.WriteField("keep_settings", strconv.FormatBool(keepSettings)); err != nil {
+				return err
+			}
+			part, err := form.CreateFormFile("file", filepath.Base(filename))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(part, image); err != nil {
+				return err
+			}
+			return form.Close()
+		}()
+		_ = pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/firmware/update", pr)
+	if err != nil {
+		_ = pr.Close()
+		return fmt.Errorf("create firmware update request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if bearer = strings.TrimSpace(bearer); bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	uploader := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := uploader.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload firmware: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("firmware POST /firmware/update: %d %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
 type requestOptions struct {
 	body   any
 	bearer string
@@ -221,8 +354,30 @@ type requestOptions struct {
 }
 
 func (c *Client) do(ctx context.Context, method, endpoint string, opts requestOptions, out any) error {
+	resp, err := c.send(ctx, method, endpoint, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("decode %s response: %w", endpoint, err)
+	}
+
+	return nil
+}
+
+// send issues a request and returns the response of a successful one,
+// whose body the caller must close.
+func (c *Client) send(ctx context.Context, method, endpoint string, opts requestOptions) (*http.Response, error) {
 	if c == nil {
-		return fmt.Errorf("nil client")
+		return nil, fmt.Errorf("nil client")
 	}
 	if ctx == nil {
 		ctx = context.Background()
@@ -235,14 +390,14 @@ func (c *Client) do(ctx context.Context, method, endpoint string, opts requestOp
 	if opts.body != nil {
 		data, err := json.Marshal(opts.body)
 		if err != nil {
-			return fmt.Errorf("marshal request body: %w", err)
+			return nil, fmt.Errorf("marshal request body: %w", err)
 		}
 		body = bytes.NewReader(data)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, body)
 	if err != nil {
-		return fmt.Errorf("create request %s %s: %w", method, endpoint, err)
+		return nil, fmt.Errorf("create request %s %s: %w", method, endpoint, err)
 	}
 
 	if opts.body != nil {
@@ -263,26 +418,19 @@ func (c *Client) do(ctx context.Context, method, endpoint string, opts requestOp
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request %s %s: %w", method, endpoint, err)
+		return nil, fmt.Errorf("request %s %s: %w", method, endpoint, err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		data, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return fmt.Errorf("firmware %s %s: %d %s", method, endpoint, resp.StatusCode, strings.TrimSpace(string(data)))
-	}
-
-	if out == nil {
-		_, _ = io.Copy(io.Discard, resp.Body)
-		return nil
-	}
-
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(out); err != nil {
-		return fmt.Errorf("decode %s response: %w", endpoint, err)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("firmware %s %s: %d %s: %w", method, endpoint, resp.StatusCode, strings.TrimSpace(string(data)), ErrUnauthorized)
+		}
+		return nil, fmt.Errorf("firmware %s %s: %d %s", method, endpoint, resp.StatusCode, strings.TrimSpace(string(data)))
 	}
 
-	return nil
+	return resp, nil
 }
 
 func deriveBaseURL(addr string) (string, error) {
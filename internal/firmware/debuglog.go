@@ -0,0 +1,323 @@
+package firmware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxLoggedBody caps the bytes of each request and response body kept
+	// in a RequestLog entry.
+	maxLoggedBody = 4096
+	redacted      = "[redacted]"
+)
+
+// sensitiveKeys lists the JSON fields whose values never reach the log.
+// Matching is case-insensitive and ignores "_" and "-".
+var sensitiveKeys = map[string]struct{}{
+	"pw":            {},
+	"pass":          {},
+	"password":      {},
+	"passwd":        {},
+	"key":           {},
+	"apikey":        {},
+	"token":         {},
+	"accesstoken":   {},
+	"refreshtoken":  {},
+	"secret":        {},
+	"authorization": {},
+}
+
+// RequestLogEntry describes one firmware HTTP exchange. Bodies are JSON with
+// credentials redacted, or a short placeholder for other content.
+type RequestLogEntry struct {
+	Time         time.Time
+	Method       string
+	URL          string
+	Status       int
+	Latency      time.Duration
+	RequestBody  string
+	ResponseBody string
+	Error        string
+}
+
+// RequestLog keeps the most recent firmware HTTP exchanges in a ring buffer
+// while enabled. Nothing is captured while it is disabled.
+type RequestLog struct {
+	mu      sync.Mutex
+	enabled bool
+	entries []RequestLogEntry
+	next    int
+	full    bool
+}
+
+// NewRequestLog returns a disabled log holding up to capacity entries.
+func NewRequestLog(capacity int) *RequestLog {
+	l := &RequestLog{}
+	l.SetCapacity(capacity)
+	return l
+}
+
+// SetCapacity resizes the buffer, keeping the newest entries.
+func (l *RequestLog) SetCapacity(capacity int) {
+	if capacity < 1 {
+		capacity = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if capacity == len(l.entries) {
+		return
+	}
+	kept := l.ordered()
+	if len(kept) > capacity {
+		kept = kept[len(kept)-capacity:]
+	}
+	l.entries = make([]RequestLogEntry, capacity)
+	copy(l.entries, kept)
+	l.next = len(kept) % capacity
+	l.full = len(kept) == capacity
+}
+
+// SetEnabled turns recording on or off. Entries already recorded are kept.
+func (l *RequestLog) SetEnabled(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enabled = enabled
+}
+
+// Enabled reports whether requests are being recorded.
+func (l *RequestLog) Enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enabled
+}
+
+// Capacity reports how many entries the log retains.
+func (l *RequestLog) Capacity() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.entries)
+}
+
+// Entries returns the recorded exchanges, oldest first.
+func (l *RequestLog) Entries() []RequestLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ordered()
+}
+
+// Clear drops every recorded entry.
+func (l *RequestLog) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.entries)
+	l.next = 0
+	l.full = false
+}
+
+// ordered returns a copy of the entries, oldest first. l.mu must be held.
+func (l *RequestLog) ordered() []RequestLogEntry {
+	if !l.full {
+		return append([]RequestLogEntry(nil), l.entries[:l.next]...)
+	}
+	out := make([]RequestLogEntry, 0, len(l.entries))
+	out = append(out, l.entries[l.next:]...)
+	return append(out, l.entries[:l.next]...)
+}
+
+func (l *RequestLog) add(entry RequestLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) == 0 {
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Transport wraps base so each request is recorded while the log is
+// enabled. Response bodies are captured as they are read and the entry is
+// added when the body is closed.
+func (l *RequestLog) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &loggingTransport{base: base, log: l}
+}
+
+type loggingTransport struct {
+	base http.RoundTripper
+	log  *RequestLog
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.log.Enabled() {
+		return t.base.RoundTrip(req)
+	}
+
+	entry := RequestLogEntry{
+		Time:        time.Now().UTC(),
+		Method:      req.Method,
+		URL:         req.URL.Redacted(),
+		RequestBody: requestBody(req),
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	entry.Latency = time.Since(start)
+	if err != nil {
+		entry.Error = err.Error()
+		t.log.add(entry)
+		return nil, err
+	}
+
+	entry.Status = resp.StatusCode
+	resp.Body = &capturingBody{
+		ReadCloser:  resp.Body,
+		contentType: resp.Header.Get("Content-Type"),
+		entry:       entry,
+		log:         t.log,
+	}
+	return resp, nil
+}
+
+// requestBody returns the logged form of the request body without
+// consuming it. Streamed bodies, such as firmware uploads, are noted by
+// size only.
+func requestBody(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	contentType := req.Header.Get("Content-Type")
+	if req.GetBody == nil {
+		return placeholder(contentType, req.ContentLength)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return placeholder(contentType, req.ContentLength)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(io.LimitReader(body, maxLoggedBody+1))
+	return redactBody(contentType, data)
+}
+
+// capturingBody keeps the first maxLoggedBody bytes read from a response
+// and records the exchange on Close.
+type capturingBody struct {
+	io.ReadCloser
+	contentType string
+	buf         bytes.Buffer
+	entry       RequestLogEntry
+	log         *RequestLog
+	once        sync.Once
+}
+
+func (b *capturingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := maxLoggedBody + 1 - b.buf.Len(); room > 0 && n > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+func (b *capturingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.entry.ResponseBody = redactBody(b.contentType, b.buf.Bytes())
+		b.log.add(b.entry)
+	})
+	return err
+}
+
+// redactBody renders a captured body for the log. JSON has sensitive
+// fields masked and plain text, usually an error message, is kept as is;
+// anything else is reduced to a placeholder.
+func redactBody(contentType string, data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	truncated := len(data) > maxLoggedBody
+	if truncated {
+		data = data[:maxLoggedBody]
+	}
+
+	if isJSON(contentType, data) {
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			// Truncated or malformed JSON cannot be walked safely.
+			return placeholder(contentType, -1)
+		}
+		out, err := json.Marshal(redactValue(doc))
+		if err != nil {
+			return placeholder(contentType, -1)
+		}
+		return string(out)
+	}
+
+	if strings.HasPrefix(contentType, "text/plain") {
+		text := strings.TrimSpace(string(data))
+		if truncated {
+			text += "..."
+		}
+		return text
+	}
+	if truncated {
+		return placeholder(contentType, -1)
+	}
+	return placeholder(contentType, int64(len(data)))
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if isSensitiveKey(k) {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactValue(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	_, ok := sensitiveKeys[key]
+	return ok
+}
+
+func isJSON(contentType string, data []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	}
+	// Some firmware omits the header; sniff for an object or array.
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+func placeholder(contentType string, size int64) string {
+	if contentType == "" {
+		contentType = "unknown content"
+	}
+	if size < 0 {
+		return fmt.Sprintf("<%s>", contentType)
+	}
+	return fmt.Sprintf("<%d bytes %s>", size, contentType)
+}
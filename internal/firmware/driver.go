@@ -0,0 +1,260 @@
+package firmware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Supported firmware drivers.
+const (
+	DriverVnish    = "vnish"
+	DriverBraiins  = "braiins"
+	DriverAntminer = "antminer"
+)
+
+// SleepPreset is the preset name the balancer uses to put a miner to sleep.
+const SleepPreset = "disabled"
+
+// ErrUnsupported is returned when a driver cannot perform an operation, for
+// example power control on stock firmware.
+var ErrUnsupported = errors.New("operation not supported by firmware driver")
+
+// ErrUnauthorized is returned when the firmware rejects a request's
+// credentials, such as a wrong unlock password.
+var ErrUnauthorized = errors.New("credentials rejected")
+
+// MinerDriver abstracts the firmware API of a single miner. Responses are
+// normalised into the Vnish payload types the rest of the system stores.
+type MinerDriver interface {
+	Kind() string
+	Info(ctx context.Context) (InfoResponse, error)
+	Summary(ctx context.Context) (SummaryResponse, error)
+	Chains(ctx context.Context) ([]ChainTelemetry, error)
+	// SetPowerTarget applies a model preset. Drivers without named presets
+	// interpret it as a wattage; SleepPreset puts the miner to sleep.
+	SetPowerTarget(ctx context.Context, preset string) (*SaveConfigResult, error)
+	Sleep(ctx context.Context) error
+	Wake(ctx context.Context) error
+}
+
+// PresetReader is implemented by drivers that can report the active preset.
+type PresetReader interface {
+	CurrentPreset(ctx context.Context) (*string, error)
+}
+
+// Restarter is implemented by drivers that can restart the mining process,
+// which reinitialises every hashboard.
+type Restarter interface {
+	Restart(ctx context.Context) error
+}
+
+// Rebooter is implemented by drivers that can reboot the whole control
+// board, which some settings need before they take effect.
+type Rebooter interface {
+	Reboot(ctx context.Context) error
+}
+
+// Locator is implemented by drivers that can blink the miner's locate LED.
+type Locator interface {
+	Locate(ctx context.Context, on bool) error
+}
+
+// CoolingController is implemented by drivers that can change the cooling
+// mode and fan limits.
+type CoolingController interface {
+	SetCooling(ctx context.Context, cooling CoolingSettings) (*SaveConfigResult, error)
+}
+
+// SettingsReader is implemented by drivers that can read back the
+// configured preset, cooling and pools.
+type SettingsReader interface {
+	ReadSettings(ctx context.Context) (MinerConfig, error)
+}
+
+// PoolController is implemented by drivers that can replace the pool list.
+type PoolController interface {
+	SetPools(ctx context.Context, pools []PoolSettings) (*SaveConfigResult, error)
+}
+
+// MiscController is implemented by drivers that can change the misc
+// settings, such as ignoring broken sensors.
+type MiscController interface {
+	SetMisc(ctx context.Context, misc MiscSettings) (*SaveConfigResult, error)
+}
+
+// LogReader is implemented by drivers that can fetch the miner's logs, one
+// of LogKinds. Unsupported kinds return ErrUnsupported.
+type LogReader interface {
+	Logs(ctx context.Context, kind string, maxBytes int64) (MinerLog, error)
+}
+
+// NetworkController is implemented by drivers that can read and change the
+// miner's network configuration.
+type NetworkController interface {
+	ReadNetwork(ctx context.Context) (NetworkSettings, error)
+	SetNetwork(ctx context.Context, network NetworkSettings) (*SaveConfigResult, error)
+}
+
+// Credentials carries the authentication each driver may need.
+type Credentials struct {
+	// APIKey authenticates Vnish requests.
+	APIKey string
+	// Username and Password authenticate Braiins OS sessions.
+	Username string
+	Password string
+}
+
+// ParseDriver normalises a driver name. An empty name selects Vnish.
+func ParseDriver(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", DriverVnish:
+		return DriverVnish, nil
+	case DriverBraiins, "bos", "braiins-os":
+		return DriverBraiins, nil
+	case DriverAntminer, "stock", "cgminer", "bmminer":
+		return DriverAntminer, nil
+	default:
+		return "", fmt.Errorf("unsupported firmware driver %q", name)
+	}
+}
+
+// NewDriver builds the driver for kind talking to the miner at addr.
+func NewDriver(kind, addr string, creds Credentials, opts ...Option) (MinerDriver, error) {
+	kind, err := ParseDriver(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case DriverBraiins:
+		return NewBraiinsClient(addr, creds.Username, creds.Password, opts...)
+	case DriverAntminer:
+		return NewAntminerDriver(addr, opts...)
+	default:
+		return NewClient(addr, append(opts, WithAPIKey(creds.APIKey))...)
+	}
+}
+
+// Kind identifies the Vnish driver.
+func (c *Client) Kind() string {
+	return DriverVnish
+}
+
+// SetPowerTarget applies a Vnish autotune preset using the client's API key.
+func (c *Client) SetPowerTarget(ctx context.Context, preset string) (*SaveConfigResult, error) {
+	return c.SetPreset(ctx, c.apiKey, preset)
+}
+
+// SetCooling applies cooling settings using the client's API key.
+func (c *Client) SetCooling(ctx context.Context, cooling CoolingSettings) (*SaveConfigResult, error) {
+	return c.SetCoolingSettings(ctx, c.apiKey, cooling)
+}
+
+// ReadSettings reads the configured settings using the client's API key.
+func (c *Client) ReadSettings(ctx context.Context) (MinerConfig, error) {
+	return c.GetSettings(ctx, c.apiKey)
+}
+
+// SetPools replaces the pool list using the client's API key.
+func (c *Client) SetPools(ctx context.Context, pools []PoolSettings) (*SaveConfigResult, error) {
+	return c.SetPoolSettings(ctx, c.apiKey, pools)
+}
+
+// SetMisc applies misc settings using the client's API key.
+func (c *Client) SetMisc(ctx context.Context, misc MiscSettings) (*SaveConfigResult, error) {
+	return c.SetMiscSettings(ctx, c.apiKey, misc)
+}
+
+// Sleep stops mining without changing the configured preset.
+func (c *Client) Sleep(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/mining/stop", requestOptions{}, nil)
+}
+
+// Wake resumes mining after Sleep.
+func (c *Client) Wake(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/mining/start", requestOptions{}, nil)
+}
+
+// Restart restarts mining using the client's API key.
+func (c *Client) Restart(ctx context.Context) error {
+	return c.RestartMining(ctx, c.apiKey)
+}
+
+// Reboot reboots the control board. The miner stops answering until it is
+// back up, typically one to three minutes later.
+func (c *Client) Reboot(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/system/reboot", requestOptions{apiKey: c.apiKey}, nil)
+}
+
+// Locate turns the find-miner LED on or off. Vnish only toggles the LED, so
+// the current state is read from /status first.
+func (c *Client) Locate(ctx context.Context, on bool) error {
+	status, err := c.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.FindMiner == on {
+		return nil
+	}
+	return c.do(ctx, http.MethodPost, "/find-miner", requestOptions{}, nil)
+}
+
+// CurrentPreset reads the active preset from /perf-summary.
+func (c *Client) CurrentPreset(ctx context.Context) (*string, error) {
+	perf, err := c.PerfSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseCurrentPreset(perf.CurrentPreset), nil
+}
+
+// parseCurrentPreset accepts the several shapes /perf-summary uses for the
+// current preset: a bare string or an object with name/preset/pretty.
+func parseCurrentPreset(raw json.RawMessage) *string {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var asString string
+	if err := json.Unmarshal(raw, &asString); err == nil {
+		return trimmedPtr(asString)
+	}
+
+	var preset struct {
+		Name   string `json:"name"`
+		Pretty string `json:"pretty"`
+		Preset string `json:"preset"`
+	}
+	if err := json.Unmarshal(raw, &preset); err == nil {
+		if ptr := trimmedPtr(preset.Name); ptr != nil {
+			return ptr
+		}
+		if ptr := trimmedPtr(preset.Preset); ptr != nil {
+			return ptr
+		}
+		if ptr := trimmedPtr(preset.Pretty); ptr != nil {
+			return ptr
+		}
+	}
+
+	str := strings.TrimSpace(string(raw))
+	if str == "" || strings.EqualFold(str, "null") {
+		return nil
+	}
+	if strings.HasPrefix(str, "\"") && strings.HasSuffix(str, "\"") {
+		str = strings.Trim(str, "\"")
+	}
+	return trimmedPtr(str)
+}
+
+func trimmedPtr(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,123 @@
+package firmware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Limiter caps the number of firmware requests in flight. Waiting requests
+// are admitted in arrival order, so one busy caller cannot starve the
+// others. A limit of zero or less admits everything.
+type Limiter struct {
+	mu      sync.Mutex
+	limit   int
+	inUse   int
+	waiters []chan struct{}
+}
+
+// NewLimiter returns a limiter admitting limit concurrent requests.
+func NewLimiter(limit int) *Limiter {
+	return &Limiter{limit: limit}
+}
+
+// SetLimit changes the limit. Requests already in flight are unaffected.
+func (l *Limiter) SetLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.admit()
+}
+
+// Acquire blocks until a slot is free or ctx is done.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.limit <= 0 || (l.inUse < l.limit && len(l.waiters) == 0) {
+		l.inUse++
+		l.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-ready:
+			// Admitted while giving up; hand the slot on.
+			l.inUse--
+			l.admit()
+		default:
+			for i, w := range l.waiters {
+				if w == ready {
+					l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+					break
+				}
+			}
+		}
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (l *Limiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inUse--
+	l.admit()
+}
+
+// admit wakes waiters while slots are free. l.mu must be held.
+func (l *Limiter) admit() {
+	for len(l.waiters) > 0 && (l.limit <= 0 || l.inUse < l.limit) {
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+		l.inUse++
+	}
+}
+
+// Transport wraps base so each request holds a slot until its response
+// body is closed.
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &limitedTransport{base: base, limiter: l}
+}
+
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Acquire(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.limiter.Release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: t.limiter.Release}
+	return resp, nil
+}
+
+// releasingBody frees the request's slot when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,96 @@
+package firmware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Log kinds a LogReader can fetch.
+const (
+	// LogMiner is the mining software's log.
+	LogMiner = "miner"
+	// LogKernel is the operating system's kernel messages.
+	LogKernel = "kernel"
+)
+
+// LogKinds lists every log kind.
+var LogKinds = []string{LogMiner, LogKernel}
+
+// MinerLog is the end of a log fetched from a miner.
+type MinerLog struct {
+	Kind string
+	Text []byte
+	// Truncated is set when the log was longer than the requested size and
+	// its beginning was dropped.
+	Truncated bool
+}
+
+// vnishLogNames maps log kinds to Vnish log names. Vnish serves kernel
+// messages as its system log.
+var vnishLogNames = map[string]string{
+	LogMiner:  "miner",
+	LogKernel: "system",
+}
+
+// Logs fetches the end of one of the miner's logs, at most maxBytes of it.
+func (c *Client) Logs(ctx context.Context, kind string, maxBytes int64) (MinerLog, error) {
+	name, ok := vnishLogNames[kind]
+	if !ok {
+		return MinerLog{}, fmt.Errorf("%w: %s log", ErrUnsupported, kind)
+	}
+
+	resp, err := c.send(ctx, http.MethodGet, "/logs/"+name, requestOptions{})
+	if err != nil {
+		return MinerLog{}, err
+	}
+	defer resp.Body.Close()
+
+	text, truncated, err := readTail(resp.Body, maxBytes)
+	if err != nil {
+		return MinerLog{}, fmt.Errorf("read %s log: %w", kind, err)
+	}
+	return MinerLog{Kind: kind, Text: text, Truncated: truncated}, nil
+}
+
+// readTail reads r to the end and returns its last maxBytes bytes, starting
+// at a line boundary when anything was dropped. Memory stays bounded by
+// twice maxBytes however long r is.
+func readTail(r io.Reader, maxBytes int64) ([]byte, bool, error) {
+	if maxBytes <= 0 {
+		return nil, false, fmt.Errorf("max bytes must be positive")
+	}
+
+	var (
+		buf       []byte
+		truncated bool
+		chunk     = make([]byte, 32*1024)
+	)
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if int64(len(buf)) > 2*maxBytes {
+			buf = append(buf[:0], buf[int64(len(buf))-maxBytes:]...)
+			truncated = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if int64(len(buf)) > maxBytes {
+		buf = buf[int64(len(buf))-maxBytes:]
+		truncated = true
+	}
+	if truncated {
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 && i+1 < len(buf) {
+			buf = buf[i+1:]
+		}
+	}
+	return buf, truncated, nil
+}
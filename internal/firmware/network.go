@@ -0,0 +1,49 @@
+package firmware
+
+import (
+	"context"
+	"net/http"
+)
+
+// NetworkSettings is the network block of the Vnish settings. Nil fields
+// are left unchanged when posted. The address fields are only used while
+// DHCP is off.
+type NetworkSettings struct {
+	DHCP       *bool    `json:"dhcp,omitempty"`
+	Hostname   *string  `json:"hostname,omitempty"`
+	IPAddress  *string  `json:"ipaddress,omitempty"`
+	Netmask    *string  `json:"netmask,omitempty"`
+	Gateway    *string  `json:"gateway,omitempty"`
+	DNSServers []string `json:"dnsservers,omitempty"`
+}
+
+// networkRequest is the payload of /settings carrying only the network
+// block.
+type networkRequest struct {
+	Network *NetworkSettings `json:"network,omitempty"`
+}
+
+// ReadNetwork reads the network configuration from /settings.
+func (c *Client) ReadNetwork(ctx context.Context) (NetworkSettings, error) {
+	var settings networkRequest
+	if err := c.do(ctx, http.MethodGet, "/settings", requestOptions{}, &settings); err != nil {
+		return NetworkSettings{}, err
+	}
+	if settings.Network == nil {
+		return NetworkSettings{}, nil
+	}
+	return *settings.Network, nil
+}
+
+// SetNetwork posts only the network block to /settings. The miner applies
+// a new address once it restarts its network or reboots, so the reply
+// still comes from the old address.
+func (c *Client) SetNetwork(ctx context.Context, network NetworkSettings) (*SaveConfigResult, error) {
+	var result SaveConfigResult
+	if err := c.do(ctx, http.MethodPost, "/settings", requestOptions{
+		body: networkRequest{Network: &network},
+	}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,86 @@
+package firmware
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TLSOptions controls how certificates are checked for miners served over
+// HTTPS. Miner controllers usually present self-signed certificates, so the
+// system roots rarely apply.
+type TLSOptions struct {
+	// InsecureSkipVerify accepts any certificate.
+	InsecureSkipVerify bool
+	// PinnedSHA256 lists hex SHA-256 fingerprints of accepted leaf
+	// certificates. When set, a certificate is accepted if and only if it
+	// matches a pin.
+	PinnedSHA256 []string
+}
+
+// Config builds the tls.Config described by o.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(o.PinnedSHA256) == 0 {
+		cfg.InsecureSkipVerify = o.InsecureSkipVerify
+		return cfg, nil
+	}
+
+	pins := make(map[string]bool, len(o.PinnedSHA256))
+	for _, pin := range o.PinnedSHA256 {
+		normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(pin), ":", ""))
+		if raw, err := hex.DecodeString(normalized); err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("invalid certificate pin %q", pin)
+		}
+		pins[normalized] = true
+	}
+
+	// Chain verification is replaced by the pin check.
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("miner presented no certificate")
+		}
+		sum := sha256.Sum256(rawCerts[0])
+		if !pins[hex.EncodeToString(sum[:])] {
+			return fmt.Errorf("miner certificate %x does not match any pin", sum)
+		}
+		return nil
+	}
+	return cfg, nil
+}
+
+// NewTransport returns a transport like http.DefaultTransport that checks
+// HTTPS certificates according to opts.
+func NewTransport(opts TLSOptions) (*http.Transport, error) {
+	tlsConfig, err := opts.Config()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// WithTLS gives the client its own transport using opts. The timeout of a
+// client set through WithHTTPClient is kept, but its transport is replaced,
+// so apply WithTLS after WithHTTPClient.
+func WithTLS(opts TLSOptions) Option {
+	return func(c *Client) {
+		transport, err := NewTransport(opts)
+		if err != nil {
+			c.optErr = err
+			return
+		}
+		timeout := defaultRequestTimeout
+		if c.httpClient != nil {
+			timeout = c.httpClient.Timeout
+		}
+		c.httpClient = &http.Client{Timeout: timeout, Transport: transport}
+	}
+}
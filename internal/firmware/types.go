@@ -199,16 +199,35 @@ type PerfSummaryResponse struct {
 	CurrentPreset json.RawMessage `json:"current_preset"`
 }
 
-// SetPresetRequest is the minimal payload structure for POST /settings
-// to change only the preset. All fields in the firmware settings API are
-// optional, so we only send what we want to change.
-type SetPresetRequest struct {
+// SettingsRequest is the minimal payload structure for POST /settings.
+// All fields in the firmware settings API are optional, so we only send
+// what we want to change. GET /settings returns the same structure.
+type SettingsRequest struct {
 	Miner MinerConfig `json:"miner"`
 }
 
-// MinerConfig wraps the overclock settings in the settings payload.
+// MinerConfig wraps the overclock, cooling, misc and pool settings in the
+// settings payload. Nil sections are left unchanged by the firmware.
 type MinerConfig struct {
-	Overclock OverclockSettings `json:"overclock"`
+	Overclock *OverclockSettings `json:"overclock,omitempty"`
+	Cooling   *CoolingSettings   `json:"cooling,omitempty"`
+	Misc      *MiscSettings      `json:"misc,omitempty"`
+	Pools     []PoolSettings     `json:"pools,omitempty"`
+}
+
+// MiscSettings is the misc block of POST /settings. Nil fields are left
+// unchanged.
+type MiscSettings struct {
+	IgnoreBrokenSensors  *bool `json:"ignore_broken_sensors,omitempty"`
+	MinOperationalChains *int  `json:"min_operational_chains,omitempty"`
+}
+
+// PoolSettings is one entry of the pools list, in priority order. The
+// firmware may mask Pass when reading settings back.
+type PoolSettings struct {
+	URL  string `json:"url"`
+	User string `json:"user"`
+	Pass string `json:"pass,omitempty"`
 }
 
 // OverclockSettings contains the preset field and other optional overclock settings.
@@ -216,6 +235,29 @@ type OverclockSettings struct {
 	Preset string `json:"preset"`
 }
 
+// Cooling modes accepted by the firmware.
+const (
+	CoolingAuto      = "auto"
+	CoolingManual    = "manual"
+	CoolingImmersion = "immersion"
+)
+
+// CoolingSettings is the cooling block of POST /settings. Nil fields are
+// left unchanged.
+type CoolingSettings struct {
+	Mode        *CoolingModeSetting `json:"mode,omitempty"`
+	FanMinCount *int                `json:"fan_min_count,omitempty"`
+	FanMinDuty  *int                `json:"fan_min_duty,omitempty"`
+	FanMaxDuty  *int                `json:"fan_max_duty,omitempty"`
+}
+
+// CoolingModeSetting selects the cooling mode. Param is the fixed fan duty
+// in percent for manual mode.
+type CoolingModeSetting struct {
+	Name  string `json:"name"`
+	Param *int   `json:"param,omitempty"`
+}
+
 // SaveConfigResult is returned by POST /settings indicating if restart/reboot is needed.
 type SaveConfigResult struct {
 	RebootRequired  bool `json:"reboot_required"`
@@ -0,0 +1,99 @@
+// Package forecast predicts plant generation a few minutes ahead so the
+// balancer can ramp the fleet down before a dip arrives.
+package forecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// ErrInsufficientData is returned when there are too few recent readings to
+// make a prediction.
+var ErrInsufficientData = errors.New("not enough recent plant readings to forecast")
+
+// minSamples is the fewest readings MovingAverage needs to fit a trend.
+const minSamples = 4
+
+// Forecast is a predicted total generation at a point in time.
+type Forecast struct {
+	GenerationKW float64
+	At           time.Time
+	// Samples is the number of readings the prediction was based on.
+	Samples int
+}
+
+// Provider predicts plant generation. The moving average below is the
+// first implementation; weather or inflow models can satisfy the same
+// interface.
+type Provider interface {
+	// Forecast predicts total generation horizon ahead of now.
+	Forecast(ctx context.Context, horizon time.Duration) (Forecast, error)
+}
+
+// MovingAverage extrapolates the trend of plant readings over a trailing
+// window. It averages the older and newer halves of the window and projects
+// the slope between them forward, so a steady decline shows up in the
+// forecast before generation actually reaches the lower level.
+type MovingAverage struct {
+	store  database.PlantReader
+	window time.Duration
+}
+
+// NewMovingAverage returns a provider over the readings of the last window.
+func NewMovingAverage(store database.PlantReader, window time.Duration) *MovingAverage {
+	return &MovingAverage{store: store, window: window}
+}
+
+// Forecast implements Provider.
+func (m *MovingAverage) Forecast(ctx context.Context, horizon time.Duration) (Forecast, error) {
+	now := time.Now().UTC()
+	from := now.Add(-m.window)
+
+	var readings []database.PlantReading
+	err := m.store.ForEachPlantReading(ctx, from, now.Add(time.Second), func(reading database.PlantReading) error {
+		readings = append(readings, reading)
+		return nil
+	})
+	if err != nil {
+		return Forecast{}, fmt.Errorf("load plant readings: %w", err)
+	}
+	if len(readings) < minSamples {
+		return Forecast{}, ErrInsufficientData
+	}
+
+	half := len(readings) / 2
+	olderKW, olderAt := mean(readings[:half])
+	newerKW, newerAt := mean(readings[half:])
+
+	predicted := newerKW
+	if span := newerAt.Sub(olderAt); span > 0 {
+		slope := (newerKW - olderKW) / span.Seconds()
+		predicted += slope * now.Add(horizon).Sub(newerAt).Seconds()
+	}
+	if predicted < 0 {
+		predicted = 0
+	}
+
+	return Forecast{
+		GenerationKW: predicted,
+		At:           now.Add(horizon),
+		Samples:      len(readings),
+	}, nil
+}
+
+// mean returns the average generation of readings and their average time.
+func mean(readings []database.PlantReading) (float64, time.Time) {
+	var total float64
+	var offset time.Duration
+	base := readings[0].RecordedAt
+	for _, reading := range readings {
+		total += reading.TotalGeneration
+		offset += reading.RecordedAt.Sub(base)
+	}
+	n := len(readings)
+	return total / float64(n), base.Add(offset / time.Duration(n))
+}
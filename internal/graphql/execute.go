@@ -0,0 +1,687 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Request is a GraphQL request as clients send it over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Location is a line and column in the query document, both from 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an error in the errors list of a response. Path is set for
+// errors raised while resolving a field.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Result is a GraphQL response. Data is nil when the request failed before
+// execution: the query did not parse or is not valid for the schema.
+type Result struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Executed reports whether the request got as far as execution; Data may
+// still hold nulls for fields that failed.
+func (r *Result) Executed() bool {
+	return r.Data != nil
+}
+
+// Execute parses and validates the request's query and runs the selected
+// operation against the schema.
+func (s *Schema) Execute(ctx context.Context, req Request) *Result {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return failed(err)
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return failed(err)
+	}
+	if op.kind != "query" {
+		return failed(&Error{Message: fmt.Sprintf("%s operations are not supported; only queries are", op.kind), Locations: []Location{op.loc}})
+	}
+	if err := checkFragmentCycles(doc); err != nil {
+		return failed(err)
+	}
+
+	e := &executor{schema: s, doc: doc}
+	if e.vars, err = s.coerceVariables(op, req.Variables); err != nil {
+		return failed(err)
+	}
+	if err := e.validate(s.Query, op.selections, 1); err != nil {
+		return failed(err)
+	}
+
+	data, ok := e.selectionSet(ctx, s.Query, nil, op.selections, nil)
+	result := &Result{Errors: e.errors}
+	if ok {
+		result.Data = data
+	} else {
+		// A non-null root field failed. Data is still present, as null.
+		result.Data = json.RawMessage("null")
+	}
+	return result
+}
+
+func failed(err error) *Result {
+	if gqlErr, ok := err.(*Error); ok {
+		return &Result{Errors: []*Error{gqlErr}}
+	}
+	return &Result{Errors: []*Error{{Message: err.Error()}}}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, &Error{Message: "operationName is required when the document has more than one operation"}
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+// checkFragmentCycles rejects fragments that spread themselves, directly
+// or through others.
+func checkFragmentCycles(doc *document) error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(doc.fragments))
+	var visit func(name string, loc Location) error
+	var walk func(selections []*selection) error
+	walk = func(selections []*selection) error {
+		for _, sel := range selections {
+			if sel.kind == selectSpread {
+				if err := visit(sel.fragment, sel.loc); err != nil {
+					return err
+				}
+			}
+			if err := walk(sel.selections); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	visit = func(name string, loc Location) error {
+		frag, ok := doc.fragments[name]
+		if !ok {
+			return &Error{Message: fmt.Sprintf("Unknown fragment %q.", name), Locations: []Location{loc}}
+		}
+		switch state[name] {
+		case visiting:
+			return &Error{Message: fmt.Sprintf("Cannot spread fragment %q within itself.", name), Locations: []Location{loc}}
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		if err := walk(frag.selections); err != nil {
+			return err
+		}
+		state[name] = done
+		return nil
+	}
+	for name, frag := range doc.fragments {
+		if err := visit(name, frag.loc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// coerceVariables checks the variables the client sent against the
+// operation's definitions and fills in defaults.
+func (s *Schema) coerceVariables(op *operation, provided map[string]any) (map[string]any, error) {
+	vars := make(map[string]any, len(op.variables))
+	for _, def := range op.variables {
+		typ, err := s.inputType(def.typ)
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Variable \"$%s\": %v", def.name, err), Locations: []Location{def.loc}}
+		}
+		value, ok := provided[def.name]
+		if !ok {
+			if def.hasDefault {
+				value, ok = def.def, true
+			} else if _, nonNull := typ.(*NonNull); nonNull {
+				return nil, &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type %s was not provided.", def.name, def.typ), Locations: []Location{def.loc}}
+			}
+		}
+		if !ok {
+			continue
+		}
+		coerced, err := coerceInput(typ, fromJSON(value))
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Variable \"$%s\" got invalid value: %v", def.name, err), Locations: []Location{def.loc}}
+		}
+		vars[def.name] = coerced
+	}
+	return vars, nil
+}
+
+// inputType resolves a variable's type, which must be built from scalars.
+func (s *Schema) inputType(ref typeRef) (Type, error) {
+	var typ Type
+	if ref.elem != nil {
+		elem, err := s.inputType(*ref.elem)
+		if err != nil {
+			return nil, err
+		}
+		typ = ListOf(elem)
+	} else {
+		named, ok := s.types[ref.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q", ref.name)
+		}
+		if _, ok := named.(*Scalar); !ok {
+			return nil, fmt.Errorf("%s is not an input type", ref.name)
+		}
+		typ = named
+	}
+	if ref.nonNull {
+		typ = NonNullOf(typ)
+	}
+	return typ, nil
+}
+
+// fromJSON converts a decoded JSON variable to the value forms the parser
+// produces, so scalars parse both the same way.
+func fromJSON(value any) any {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = fromJSON(item)
+		}
+		return out
+	case map[string]any:
+		return objectValue(nil)
+	}
+	return value
+}
+
+func coerceInput(typ Type, value any) (any, error) {
+	if nn, ok := typ.(*NonNull); ok {
+		if value == nil {
+			return nil, fmt.Errorf("expected a non-null %s", nn.Of)
+		}
+		return coerceInput(nn.Of, value)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	switch t := typ.(type) {
+	case *List:
+		items, ok := value.([]any)
+		if !ok {
+			// A single value stands for a list of one.
+			items = []any{value}
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			coerced, err := coerceInput(t.Of, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = coerced
+		}
+		return out, nil
+	case *Scalar:
+		if _, ok := value.(objectValue); ok {
+			return nil, fmt.Errorf("%s cannot represent an object", t.Name)
+		}
+		if _, ok := value.([]any); ok {
+			return nil, fmt.Errorf("%s cannot represent a list", t.Name)
+		}
+		return t.Parse(value)
+	}
+	return nil, fmt.Errorf("%s is not an input type", typ)
+}
+
+type executor struct {
+	schema *Schema
+	doc    *document
+	vars   map[string]any
+	errors []*Error
+}
+
+// fieldGroup is the selections that share a response key, merged.
+type fieldGroup struct {
+	key   string
+	nodes []*selection
+}
+
+// collectFields expands fragments and applies @skip and @include, grouping
+// the fields of selections by response key in the order they first appear.
+func (e *executor) collectFields(obj *Object, selections []*selection) ([]*fieldGroup, error) {
+	var (
+		groups []*fieldGroup
+		byKey  = make(map[string]*fieldGroup)
+	)
+	var collect func(selections []*selection, seen map[string]bool) error
+	collect = func(selections []*selection, seen map[string]bool) error {
+		for _, sel := range selections {
+			include, err := e.included(sel.directives)
+			if err != nil {
+				return err
+			}
+			if !include {
+				continue
+			}
+			switch sel.kind {
+			case selectField:
+				key := sel.responseKey()
+				group, ok := byKey[key]
+				if !ok {
+					group = &fieldGroup{key: key}
+					byKey[key] = group
+					groups = append(groups, group)
+				} else if group.nodes[0].name != sel.name {
+					return &Error{
+						Message:   fmt.Sprintf("Fields %q conflict because %s and %s are different fields. Use different aliases on the fields to fetch both if this was intentional.", key, group.nodes[0].name, sel.name),
+						Locations: []Location{group.nodes[0].loc, sel.loc},
+					}
+				}
+				group.nodes = append(group.nodes, sel)
+			case selectSpread:
+				if seen[sel.fragment] {
+					continue
+				}
+				seen[sel.fragment] = true
+				frag, ok := e.doc.fragments[sel.fragment]
+				if !ok {
+					return &Error{Message: fmt.Sprintf("Unknown fragment %q.", sel.fragment), Locations: []Location{sel.loc}}
+				}
+				if err := e.checkCondition(obj, frag.typeCondition, sel.loc); err != nil {
+					return err
+				}
+				if include, err := e.included(frag.directives); err != nil || !include {
+					if err != nil {
+						return err
+					}
+					continue
+				}
+				if err := collect(frag.selections, seen); err != nil {
+					return err
+				}
+			case selectInline:
+				if sel.typeCondition != "" {
+					if err := e.checkCondition(obj, sel.typeCondition, sel.loc); err != nil {
+						return err
+					}
+				}
+				if err := collect(sel.selections, seen); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := collect(selections, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// checkCondition checks a fragment's type condition. Without interfaces
+// or unions a fragment only applies to the type it names.
+func (e *executor) checkCondition(obj *Object, condition string, loc Location) error {
+	if condition == obj.Name {
+		return nil
+	}
+	if _, ok := e.schema.types[condition]; !ok {
+		return &Error{Message: fmt.Sprintf("Unknown type %q.", condition), Locations: []Location{loc}}
+	}
+	return &Error{Message: fmt.Sprintf("Fragment cannot be spread here as objects of type %q can never be of type %q.", obj.Name, condition), Locations: []Location{loc}}
+}
+
+// included evaluates @skip and @include.
+func (e *executor) included(directives []directive) (bool, error) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			return false, &Error{Message: fmt.Sprintf("Unknown directive \"@%s\".", d.name), Locations: []Location{d.loc}}
+		}
+		if len(d.args) != 1 || d.args[0].name != "if" {
+			return false, &Error{Message: fmt.Sprintf("Directive \"@%s\" takes one argument, if: Boolean!.", d.name), Locations: []Location{d.loc}}
+		}
+		value, _, err := e.literal(d.args[0].value)
+		if err != nil {
+			return false, &Error{Message: err.Error(), Locations: []Location{d.loc}}
+		}
+		cond, err := coerceInput(NonNullOf(Boolean), value)
+		if err != nil {
+			return false, &Error{Message: fmt.Sprintf("Directive \"@%s\": %v", d.name, err), Locations: []Location{d.loc}}
+		}
+		if cond.(bool) == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// literal substitutes variables into a value from the document. It
+// reports false for a lone variable the request left unset.
+func (e *executor) literal(value any) (any, bool, error) {
+	switch v := value.(type) {
+	case variableRef:
+		resolved, ok := e.vars[string(v)]
+		if !ok && !e.defined(string(v)) {
+			return nil, false, fmt.Errorf("Variable \"$%s\" is not defined.", v)
+		}
+		return resolved, ok, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			resolved, _, err := e.literal(item)
+			if err != nil {
+				return nil, false, err
+			}
+			out[i] = resolved
+		}
+		return out, true, nil
+	}
+	return value, true, nil
+}
+
+func (e *executor) defined(name string) bool {
+	for _, op := range e.doc.operations {
+		for _, def := range op.variables {
+			if def.name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// arguments coerces the arguments given to field, filling in defaults.
+func (e *executor) arguments(obj *Object, field *Field, node *selection) (map[string]any, error) {
+	for _, arg := range node.args {
+		if field.arg(arg.name) == nil {
+			return nil, &Error{Message: fmt.Sprintf("Unknown argument %q on field \"%s.%s\".", arg.name, obj.Name, field.Name), Locations: []Location{arg.loc}}
+		}
+	}
+	args := make(map[string]any, len(field.Args))
+	for _, def := range field.Args {
+		var (
+			value any
+			set   bool
+			loc   = node.loc
+		)
+		for _, arg := range node.args {
+			if arg.name != def.Name {
+				continue
+			}
+			resolved, ok, err := e.literal(arg.value)
+			if err != nil {
+				return nil, &Error{Message: err.Error(), Locations: []Location{arg.loc}}
+			}
+			value, set, loc = resolved, ok, arg.loc
+		}
+		if !set {
+			if def.Default != nil {
+				args[def.Name] = def.Default
+				continue
+			}
+			if _, nonNull := def.Type.(*NonNull); nonNull {
+				return nil, &Error{Message: fmt.Sprintf("Field \"%s.%s\" argument %q of type %s is required, but it was not provided.", obj.Name, field.Name, def.Name, def.Type), Locations: []Location{loc}}
+			}
+			args[def.Name] = nil
+			continue
+		}
+		coerced, err := coerceInput(def.Type, value)
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Argument %q on field \"%s.%s\": %v", def.Name, obj.Name, field.Name, err), Locations: []Location{loc}}
+		}
+		args[def.Name] = coerced
+	}
+	return args, nil
+}
+
+// validate checks selections against obj before anything is resolved.
+func (e *executor) validate(obj *Object, selections []*selection, depth int) error {
+	if e.schema.MaxDepth > 0 && depth > e.schema.MaxDepth {
+		return &Error{Message: fmt.Sprintf("The query is nested more than %d levels deep.", e.schema.MaxDepth), Locations: []Location{selections[0].loc}}
+	}
+	groups, err := e.collectFields(obj, selections)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		node := group.nodes[0]
+		if node.name == "__typename" {
+			if len(node.selections) > 0 {
+				return &Error{Message: "Field \"__typename\" must not have a selection since type \"String!\" has no subfields.", Locations: []Location{node.loc}}
+			}
+			continue
+		}
+		field := obj.Field(node.name)
+		if field == nil {
+			return &Error{Message: fmt.Sprintf("Cannot query field %q on type %q.", node.name, obj.Name), Locations: []Location{node.loc}}
+		}
+		var sub []*selection
+		for _, n := range group.nodes {
+			if _, err := e.arguments(obj, field, n); err != nil {
+				return err
+			}
+			sub = append(sub, n.selections...)
+		}
+		child, isObject := namedType(field.Type).(*Object)
+		switch {
+		case isObject && len(sub) == 0:
+			return &Error{Message: fmt.Sprintf("Field %q of type %q must have a selection of subfields.", node.name, field.Type), Locations: []Location{node.loc}}
+		case !isObject && len(sub) > 0:
+			return &Error{Message: fmt.Sprintf("Field %q must not have a selection since type %q has no subfields.", node.name, field.Type), Locations: []Location{node.loc}}
+		case isObject:
+			if err := e.validate(child, sub, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// orderedObject is an object result, which keeps the order the query
+// selected its fields in.
+type orderedObject []objectEntry
+
+type objectEntry struct {
+	key   string
+	value any
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(entry.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// selectionSet resolves the selected fields of source, an obj. It reports
+// false when a non-null field came out null, which makes the whole object
+// null.
+func (e *executor) selectionSet(ctx context.Context, obj *Object, source any, selections []*selection, path []any) (orderedObject, bool) {
+	groups, err := e.collectFields(obj, selections)
+	if err != nil {
+		// Validation already collected these fields.
+		e.addError(err, path)
+		return nil, false
+	}
+	out := make(orderedObject, 0, len(groups))
+	for _, group := range groups {
+		node := group.nodes[0]
+		fieldPath := appendPath(path, group.key)
+		if node.name == "__typename" {
+			out = append(out, objectEntry{group.key, obj.Name})
+			continue
+		}
+
+		field := obj.Field(node.name)
+		value, ok := e.resolveField(ctx, obj, field, source, group, fieldPath)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, objectEntry{group.key, value})
+	}
+	return out, true
+}
+
+func (e *executor) resolveField(ctx context.Context, obj *Object, field *Field, source any, group *fieldGroup, path []any) (any, bool) {
+	node := group.nodes[0]
+	_, nonNull := field.Type.(*NonNull)
+	args, err := e.arguments(obj, field, node)
+	if err != nil {
+		e.addError(err, path, node.loc)
+		return nil, !nonNull
+	}
+
+	var value any
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	} else if field.Resolve != nil {
+		value, err = field.Resolve(ctx, source, args)
+	} else if m, ok := source.(map[string]any); ok {
+		value = m[field.Name]
+	}
+	if err != nil {
+		e.addError(err, path, node.loc)
+		return nil, !nonNull
+	}
+
+	var sub []*selection
+	for _, n := range group.nodes {
+		sub = append(sub, n.selections...)
+	}
+	return e.complete(ctx, field.Type, sub, value, path, node.loc)
+}
+
+// complete turns a resolved value into its result for typ. It reports
+// false when the value is null although typ is non-null, leaving the
+// parent to become null in turn.
+func (e *executor) complete(ctx context.Context, typ Type, selections []*selection, value any, path []any, loc Location) (any, bool) {
+	if nn, ok := typ.(*NonNull); ok {
+		out, ok := e.completeNullable(ctx, nn.Of, selections, value, path, loc)
+		if !ok {
+			return nil, false
+		}
+		if out == nil {
+			e.addError(&Error{Message: "Cannot return null for non-nullable field."}, path, loc)
+			return nil, false
+		}
+		return out, true
+	}
+	out, ok := e.completeNullable(ctx, typ, selections, value, path, loc)
+	if !ok {
+		return nil, true
+	}
+	return out, true
+}
+
+func (e *executor) completeNullable(ctx context.Context, typ Type, selections []*selection, value any, path []any, loc Location) (any, bool) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, true
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return nil, true
+	}
+
+	switch t := typ.(type) {
+	case *Scalar:
+		out, ok := t.Serialize(v.Interface())
+		if !ok {
+			e.addError(fmt.Errorf("%s cannot represent %v", t.Name, v.Interface()), path, loc)
+			return nil, false
+		}
+		return out, true
+	case *List:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			e.addError(fmt.Errorf("expected a list, got %s", v.Type()), path, loc)
+			return nil, false
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			item, ok := e.complete(ctx, t.Of, selections, v.Index(i).Interface(), appendPath(path, i), loc)
+			if !ok {
+				return nil, false
+			}
+			out[i] = item
+		}
+		return out, true
+	case *Object:
+		return e.selectionSet(ctx, t, v.Interface(), selections, path)
+	}
+	return nil, false
+}
+
+func (e *executor) addError(err error, path []any, locs ...Location) {
+	gqlErr, ok := err.(*Error)
+	if !ok {
+		gqlErr = &Error{Message: err.Error()}
+	} else {
+		copied := *gqlErr
+		gqlErr = &copied
+	}
+	if len(gqlErr.Locations) == 0 {
+		gqlErr.Locations = locs
+	}
+	gqlErr.Path = path
+	e.errors = append(e.errors, gqlErr)
+}
+
+func appendPath(path []any, elem any) []any {
+	out := make([]any, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, elem)
+}
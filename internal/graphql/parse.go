@@ -0,0 +1,724 @@
+// Package graphql implements the part of GraphQL PowerHive's read API
+// needs: parsing query documents and executing them against a schema of
+// object types built in Go. Mutations, subscriptions, interfaces, unions,
+// input objects and introspection beyond __typename are not supported.
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const byteOrderMark = "\uFEFF"
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	loc   Location
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "<EOF>"
+	case tokString:
+		return strconv.Quote(t.value)
+	}
+	return t.value
+}
+
+// lex splits a document into tokens, dropping whitespace, commas and
+// comments.
+func lex(src string) ([]token, error) {
+	var (
+		tokens []token
+		line   = 1
+		lineAt = 0
+	)
+	for i := 0; i < len(src); {
+		c := src[i]
+		loc := Location{Line: line, Column: i - lineAt + 1}
+		switch {
+		case c == '\n':
+			i++
+			line, lineAt = line+1, i
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+		case strings.HasPrefix(src[i:], byteOrderMark):
+			i += len(byteOrderMark)
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{tokPunct, "...", loc})
+			i += 3
+		case strings.ContainsRune("!$()[]{}:=@|&", rune(c)):
+			tokens = append(tokens, token{tokPunct, string(c), loc})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokName, src[start:i], loc})
+		case c == '-' || isDigit(c):
+			start := i
+			kind := tokInt
+			if c == '-' {
+				i++
+			}
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = tokFloat
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = tokFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && (src[i] == '_' || isLetter(src[i]) || src[i] == '.') {
+				return nil, &Error{Message: fmt.Sprintf("Syntax Error: invalid number %q", src[start:i+1]), Locations: []Location{loc}}
+			}
+			tokens = append(tokens, token{kind, src[start:i], loc})
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return nil, &Error{Message: "Syntax Error: unterminated string", Locations: []Location{loc}}
+			}
+			raw := src[i+3 : i+3+end]
+			tokens = append(tokens, token{tokString, blockString(raw), loc})
+			line += strings.Count(raw, "\n")
+			if n := strings.LastIndex(raw, "\n"); n >= 0 {
+				lineAt = i + 3 + n + 1
+			}
+			i += 3 + end + 3
+		case c == '"':
+			value, n, err := quotedString(src[i:])
+			if err != nil {
+				return nil, &Error{Message: "Syntax Error: " + err.Error(), Locations: []Location{loc}}
+			}
+			tokens = append(tokens, token{tokString, value, loc})
+			i += n
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, &Error{Message: fmt.Sprintf("Syntax Error: unexpected character %q", r), Locations: []Location{loc}}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, loc: Location{Line: line, Column: len(src) - lineAt + 1}}), nil
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// quotedString decodes the string literal at the start of src and returns
+// it with the number of bytes it took.
+func quotedString(src string) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"':
+			return b.String(), i + 1, nil
+		case c == '\n' || c == '\r':
+			return "", 0, fmt.Errorf("unterminated string")
+		case c != '\\':
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if i+1 >= len(src) {
+			break
+		}
+		switch esc := src[i+1]; esc {
+		case '"', '\\', '/':
+			b.WriteByte(esc)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if i+6 > len(src) {
+				return "", 0, fmt.Errorf("invalid unicode escape")
+			}
+			code, err := strconv.ParseUint(src[i+2:i+6], 16, 32)
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid unicode escape \\u%s", src[i+2:i+6])
+			}
+			b.WriteRune(rune(code))
+			i += 4
+		default:
+			return "", 0, fmt.Errorf("invalid escape \\%c", esc)
+		}
+		i += 2
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// blockString strips the common indentation and the blank first and last
+// lines of a """block string""".
+func blockString(raw string) string {
+	raw = strings.ReplaceAll(raw, `\"""`, `"""`)
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = ""
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	variables  []variableDef
+	selections []*selection
+	loc        Location
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []directive
+	selections    []*selection
+	loc           Location
+}
+
+type variableDef struct {
+	name       string
+	typ        typeRef
+	def        any
+	hasDefault bool
+	loc        Location
+}
+
+// typeRef is a type as written in a variable definition.
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+func (t typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type selectionKind int
+
+const (
+	selectField selectionKind = iota
+	selectSpread
+	selectInline
+)
+
+// selection is a field, a ...fragment spread or an inline ... on fragment.
+type selection struct {
+	kind selectionKind
+	// Fields
+	alias, name string
+	args        []argument
+	// Spreads name the fragment; inline fragments may name a type.
+	fragment      string
+	typeCondition string
+	directives    []directive
+	selections    []*selection
+	loc           Location
+}
+
+func (s *selection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+type argument struct {
+	name  string
+	value any
+	loc   Location
+}
+
+type directive struct {
+	name string
+	args []argument
+	loc  Location
+}
+
+// Values in a document are nil, bool, int64, float64, string, enumValue,
+// variableRef, []any or objectValue.
+type (
+	enumValue   string
+	variableRef string
+	objectValue []argument
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse reads a query document.
+func parse(src string) (*document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.peek().kind != tokEOF {
+		if p.peekName("fragment") {
+			frag, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[frag.name]; dup {
+				return nil, &Error{Message: fmt.Sprintf("There can be only one fragment named %q.", frag.name), Locations: []Location{frag.loc}}
+			}
+			doc.fragments[frag.name] = frag
+			continue
+		}
+		op, err := p.operationDefinition()
+		if err != nil {
+			return nil, err
+		}
+		doc.operations = append(doc.operations, op)
+	}
+	if len(doc.operations) == 0 {
+		return nil, &Error{Message: "Syntax Error: the document has no operation"}
+	}
+	return doc, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) peekPunct(value string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.value == value
+}
+
+func (p *parser) peekName(value string) bool {
+	t := p.peek()
+	return t.kind == tokName && t.value == value
+}
+
+func (p *parser) unexpected(want string) error {
+	t := p.peek()
+	return &Error{Message: fmt.Sprintf("Syntax Error: expected %s, found %s", want, t), Locations: []Location{t.loc}}
+}
+
+func (p *parser) expectPunct(value string) error {
+	if !p.peekPunct(value) {
+		return p.unexpected(strconv.Quote(value))
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) expectName() (token, error) {
+	if p.peek().kind != tokName {
+		return token{}, p.unexpected("Name")
+	}
+	return p.next(), nil
+}
+
+func (p *parser) operationDefinition() (*operation, error) {
+	op := &operation{kind: "query", loc: p.peek().loc}
+	if p.peekPunct("{") {
+		selections, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		op.selections = selections
+		return op, nil
+	}
+
+	kind, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	switch kind.value {
+	case "query", "mutation", "subscription":
+		op.kind = kind.value
+	default:
+		return nil, &Error{Message: fmt.Sprintf("Syntax Error: unexpected %s", kind), Locations: []Location{kind.loc}}
+	}
+	if p.peek().kind == tokName {
+		op.name = p.next().value
+	}
+	if p.peekPunct("(") {
+		if op.variables, err = p.variableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(true); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) variableDefinitions() ([]variableDef, error) {
+	p.next()
+	var defs []variableDef
+	for !p.peekPunct(")") {
+		def := variableDef{loc: p.peek().loc}
+		if err := p.expectPunct("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		def.name = name.value
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		if def.typ, err = p.typeReference(); err != nil {
+			return nil, err
+		}
+		if p.peekPunct("=") {
+			p.next()
+			if def.def, err = p.value(true); err != nil {
+				return nil, err
+			}
+			def.hasDefault = true
+		}
+		if _, err := p.directives(true); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	p.next()
+	return defs, nil
+}
+
+func (p *parser) typeReference() (typeRef, error) {
+	var ref typeRef
+	if p.peekPunct("[") {
+		p.next()
+		elem, err := p.typeReference()
+		if err != nil {
+			return typeRef{}, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return typeRef{}, err
+		}
+		ref.elem = &elem
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return typeRef{}, err
+		}
+		ref.name = name.value
+	}
+	if p.peekPunct("!") {
+		p.next()
+		ref.nonNull = true
+	}
+	return ref, nil
+}
+
+func (p *parser) fragmentDefinition() (*fragment, error) {
+	frag := &fragment{loc: p.next().loc}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if name.value == "on" {
+		return nil, &Error{Message: "Syntax Error: a fragment cannot be named \"on\"", Locations: []Location{name.loc}}
+	}
+	frag.name = name.value
+	if !p.peekName("on") {
+		return nil, p.unexpected(`"on"`)
+	}
+	p.next()
+	cond, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	frag.typeCondition = cond.value
+	if frag.directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) selectionSet() ([]*selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var selections []*selection
+	for !p.peekPunct("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.next()
+	if len(selections) == 0 {
+		return nil, p.unexpected("a selection")
+	}
+	return selections, nil
+}
+
+func (p *parser) selection() (*selection, error) {
+	sel := &selection{loc: p.peek().loc}
+	var err error
+	if p.peekPunct("...") {
+		p.next()
+		if p.peek().kind == tokName && !p.peekName("on") {
+			sel.kind = selectSpread
+			sel.fragment = p.next().value
+			sel.directives, err = p.directives(false)
+			return sel, err
+		}
+		sel.kind = selectInline
+		if p.peekName("on") {
+			p.next()
+			cond, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			sel.typeCondition = cond.value
+		}
+		if sel.directives, err = p.directives(false); err != nil {
+			return nil, err
+		}
+		sel.selections, err = p.selectionSet()
+		return sel, err
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	sel.name = name.value
+	if p.peekPunct(":") {
+		p.next()
+		field, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		sel.alias, sel.name = sel.name, field.value
+	}
+	if p.peekPunct("(") {
+		if sel.args, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+	}
+	if sel.directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	if p.peekPunct("{") {
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *parser) arguments(isConst bool) ([]argument, error) {
+	p.next()
+	var args []argument
+	for !p.peekPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		for _, arg := range args {
+			if arg.name == name.value {
+				return nil, &Error{Message: fmt.Sprintf("There can be only one argument named %q.", name.value), Locations: []Location{name.loc}}
+			}
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.value(isConst)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, argument{name: name.value, value: value, loc: name.loc})
+	}
+	p.next()
+	if len(args) == 0 {
+		return nil, p.unexpected("an argument")
+	}
+	return args, nil
+}
+
+func (p *parser) directives(isConst bool) ([]directive, error) {
+	var out []directive
+	for p.peekPunct("@") {
+		loc := p.next().loc
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		d := directive{name: name.value, loc: loc}
+		if p.peekPunct("(") {
+			if d.args, err = p.arguments(isConst); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// value reads a value. Variables are not allowed where isConst is set, in
+// default values.
+func (p *parser) value(isConst bool) (any, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokInt:
+		p.next()
+		n, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Syntax Error: integer %s is out of range", t.value), Locations: []Location{t.loc}}
+		}
+		return n, nil
+	case tokFloat:
+		p.next()
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Syntax Error: invalid float %s", t.value), Locations: []Location{t.loc}}
+		}
+		return f, nil
+	case tokString:
+		p.next()
+		return t.value, nil
+	case tokName:
+		p.next()
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumValue(t.value), nil
+	case tokPunct:
+		switch t.value {
+		case "$":
+			if isConst {
+				return nil, &Error{Message: "Syntax Error: variables are not allowed here", Locations: []Location{t.loc}}
+			}
+			p.next()
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return variableRef(name.value), nil
+		case "[":
+			p.next()
+			list := []any{}
+			for !p.peekPunct("]") {
+				if p.peek().kind == tokEOF {
+					return nil, p.unexpected(`"]"`)
+				}
+				item, err := p.value(isConst)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			p.next()
+			var fields objectValue
+			for !p.peekPunct("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				value, err := p.value(isConst)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, argument{name: name.value, value: value, loc: name.loc})
+			}
+			p.next()
+			return fields, nil
+		}
+	}
+	return nil, p.unexpected("a value")
+}
@@ -0,0 +1,479 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Type is a GraphQL output or argument type: a *Scalar, *Object, *List
+// or *NonNull.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Serialize turns a resolved Go value into its JSON
+// form and reports false when it cannot; Parse coerces an argument or
+// variable value, which is nil, bool, int64, float64, string or an enum
+// name.
+type Scalar struct {
+	Name        string
+	Description string
+	Serialize   func(value any) (any, bool)
+	Parse       func(value any) (any, error)
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// List is a list of Of.
+type List struct{ Of Type }
+
+func (l *List) String() string { return "[" + l.Of.String() + "]" }
+
+// NonNull is an Of that is never null.
+type NonNull struct{ Of Type }
+
+func (n *NonNull) String() string { return n.Of.String() + "!" }
+
+// ListOf returns the list type of of.
+func ListOf(of Type) Type { return &List{Of: of} }
+
+// NonNullOf returns the non-null type of of.
+func NonNullOf(of Type) Type { return &NonNull{Of: of} }
+
+// ResolveFunc returns the value of a field of source, the value resolved
+// for the object the field belongs to (nil for the query root). args holds
+// every declared argument, with its default when the query left it out.
+type ResolveFunc func(ctx context.Context, source any, args map[string]any) (any, error)
+
+// Argument is an argument a field takes. Arguments are scalars or lists
+// of them.
+type Argument struct {
+	Name string
+	Type Type
+	// Default is used when the query leaves the argument out.
+	Default any
+}
+
+// Field is a field of an object type. A field without Resolve reads the
+// key of its name from a map[string]any source.
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Argument
+	Resolve     ResolveFunc
+}
+
+func (f *Field) arg(name string) *Argument {
+	for _, arg := range f.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+// Object is an object type with its fields in declaration order.
+type Object struct {
+	Name        string
+	Description string
+	fields      []*Field
+	byName      map[string]*Field
+}
+
+// NewObject returns an object type with no fields.
+func NewObject(name, description string) *Object {
+	return &Object{Name: name, Description: description, byName: make(map[string]*Field)}
+}
+
+func (o *Object) String() string { return o.Name }
+
+// AddField adds field to o, replacing a field of the same name.
+func (o *Object) AddField(field *Field) {
+	if _, ok := o.byName[field.Name]; ok {
+		for i, existing := range o.fields {
+			if existing.Name == field.Name {
+				o.fields[i] = field
+			}
+		}
+	} else {
+		o.fields = append(o.fields, field)
+	}
+	o.byName[field.Name] = field
+}
+
+// Field returns the field of o named name, or nil.
+func (o *Object) Field(name string) *Field {
+	return o.byName[name]
+}
+
+// Built-in scalars. Int is not limited to 32 bits, since database IDs and
+// counters are int64.
+var (
+	String = &Scalar{
+		Name:      "String",
+		Serialize: serializeString,
+		Parse:     parseString,
+	}
+	ID = &Scalar{
+		Name: "ID",
+		Serialize: func(value any) (any, bool) {
+			if s, ok := serializeString(value); ok {
+				return s, true
+			}
+			if n, ok := serializeInt(value); ok {
+				return fmt.Sprint(n), true
+			}
+			return nil, false
+		},
+		Parse: func(value any) (any, error) {
+			if n, ok := value.(int64); ok {
+				return fmt.Sprint(n), nil
+			}
+			return parseString(value)
+		},
+	}
+	Int = &Scalar{
+		Name:      "Int",
+		Serialize: serializeInt,
+		Parse: func(value any) (any, error) {
+			switch v := value.(type) {
+			case int64:
+				return v, nil
+			case float64:
+				if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+					return int64(v), nil
+				}
+			}
+			return nil, fmt.Errorf("Int cannot represent %s", describe(value))
+		},
+	}
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(value any) (any, bool) {
+			if n, ok := serializeInt(value); ok {
+				return float64(n.(int64)), true
+			}
+			v := reflect.ValueOf(value)
+			if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+				f := v.Float()
+				return f, !math.IsNaN(f) && !math.IsInf(f, 0)
+			}
+			return nil, false
+		},
+		Parse: func(value any) (any, error) {
+			switch v := value.(type) {
+			case int64:
+				return float64(v), nil
+			case float64:
+				return v, nil
+			}
+			return nil, fmt.Errorf("Float cannot represent %s", describe(value))
+		},
+	}
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(value any) (any, bool) {
+			v := reflect.ValueOf(value)
+			if v.Kind() == reflect.Bool {
+				return v.Bool(), true
+			}
+			return nil, false
+		},
+		Parse: func(value any) (any, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %s", describe(value))
+		},
+	}
+	// JSON passes any JSON-encodable value through unchanged, for maps
+	// whose keys are data rather than schema.
+	JSON = &Scalar{
+		Name:        "JSON",
+		Description: "Any JSON value.",
+		Serialize:   func(value any) (any, bool) { return value, true },
+		Parse:       func(value any) (any, error) { return value, nil },
+	}
+)
+
+func serializeString(value any) (any, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.String {
+		return v.String(), true
+	}
+	return nil, false
+}
+
+func serializeInt(value any) (any, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() <= math.MaxInt64 {
+			return int64(v.Uint()), true
+		}
+	}
+	return nil, false
+}
+
+func parseString(value any) (any, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("String cannot represent %s", describe(value))
+}
+
+func describe(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case enumValue:
+		return string(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// StructObject returns an object type with a field for every JSON-encoded
+// field of the struct sample, named by its json tag. Go types map to
+// scalars, slices to lists, maps to JSON and structs to the object types
+// in objects; pointers and slices are nullable, anything else non-null. It
+// panics on a field it cannot map, since the schema is fixed at build
+// time.
+func StructObject(name, description string, sample any, objects map[reflect.Type]*Object) *Object {
+	typ := reflect.TypeOf(sample)
+	obj := NewObject(name, description)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		key, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = sf.Name
+		}
+		fieldType, err := goType(sf.Type, objects)
+		if err != nil {
+			panic(fmt.Sprintf("graphql: %s.%s: %v", name, key, err))
+		}
+		index := sf.Index
+		obj.AddField(&Field{
+			Name: key,
+			Type: fieldType,
+			Resolve: func(_ context.Context, source any, _ map[string]any) (any, error) {
+				v := reflect.ValueOf(source)
+				for v.Kind() == reflect.Pointer {
+					if v.IsNil() {
+						return nil, nil
+					}
+					v = v.Elem()
+				}
+				return v.FieldByIndex(index).Interface(), nil
+			},
+		})
+	}
+	return obj
+}
+
+func goType(typ reflect.Type, objects map[reflect.Type]*Object) (Type, error) {
+	nullable := false
+	if typ.Kind() == reflect.Pointer {
+		typ, nullable = typ.Elem(), true
+	}
+	var out Type
+	switch typ.Kind() {
+	case reflect.String:
+		out = String
+	case reflect.Bool:
+		out = Boolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		out = Int
+	case reflect.Float32, reflect.Float64:
+		out = Float
+	case reflect.Map, reflect.Interface:
+		return JSON, nil
+	case reflect.Slice:
+		elem, err := goType(typ.Elem(), objects)
+		if err != nil {
+			return nil, err
+		}
+		return ListOf(elem), nil
+	case reflect.Struct:
+		obj, ok := objects[typ]
+		if !ok {
+			return nil, fmt.Errorf("no object type for %s", typ)
+		}
+		out = obj
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+	if nullable {
+		return out, nil
+	}
+	return NonNullOf(out), nil
+}
+
+// Schema is a set of types reachable from the query root.
+type Schema struct {
+	Query *Object
+	// MaxDepth bounds how deeply a query may nest selections. Zero means
+	// no limit.
+	MaxDepth int
+	types    map[string]Type
+}
+
+// NewSchema returns the schema rooted at query, checking that its type
+// names are unique.
+func NewSchema(query *Object) (*Schema, error) {
+	s := &Schema{Query: query, types: make(map[string]Type)}
+	for _, scalar := range []*Scalar{String, ID, Int, Float, Boolean} {
+		s.types[scalar.Name] = scalar
+	}
+	if err := s.collect(query); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Schema) collect(typ Type) error {
+	switch t := typ.(type) {
+	case *List:
+		return s.collect(t.Of)
+	case *NonNull:
+		return s.collect(t.Of)
+	}
+	name := typ.String()
+	if existing, ok := s.types[name]; ok {
+		if existing != typ {
+			return fmt.Errorf("two types are named %s", name)
+		}
+		return nil
+	}
+	s.types[name] = typ
+	obj, ok := typ.(*Object)
+	if !ok {
+		return nil
+	}
+	for _, field := range obj.fields {
+		if err := s.collect(field.Type); err != nil {
+			return err
+		}
+		for _, arg := range field.Args {
+			if _, ok := namedType(arg.Type).(*Scalar); !ok {
+				return fmt.Errorf("%s.%s(%s) must be a scalar or a list of them", obj.Name, field.Name, arg.Name)
+			}
+			if err := s.collect(arg.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// namedType strips the list and non-null wrappers from typ.
+func namedType(typ Type) Type {
+	for {
+		switch t := typ.(type) {
+		case *List:
+			typ = t.Of
+		case *NonNull:
+			typ = t.Of
+		default:
+			return typ
+		}
+	}
+}
+
+// SDL returns the schema in the GraphQL schema definition language.
+func (s *Schema) SDL() string {
+	names := make([]string, 0, len(s.types))
+	for name, typ := range s.types {
+		if _, ok := typ.(*Object); ok && typ != s.Query {
+			names = append(names, name)
+		} else if scalar, ok := typ.(*Scalar); ok && !builtinScalar(scalar) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	writeObject(&b, s.Query)
+	for _, name := range names {
+		b.WriteString("\n")
+		switch t := s.types[name].(type) {
+		case *Object:
+			writeObject(&b, t)
+		case *Scalar:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "scalar %s\n", t.Name)
+		}
+	}
+	return b.String()
+}
+
+func builtinScalar(s *Scalar) bool {
+	return s == String || s == ID || s == Int || s == Float || s == Boolean
+}
+
+func writeObject(b *strings.Builder, obj *Object) {
+	writeDescription(b, "", obj.Description)
+	fmt.Fprintf(b, "type %s {\n", obj.Name)
+	for _, field := range obj.fields {
+		writeDescription(b, "  ", field.Description)
+		fmt.Fprintf(b, "  %s", field.Name)
+		if len(field.Args) > 0 {
+			args := make([]string, 0, len(field.Args))
+			for _, arg := range field.Args {
+				text := arg.Name + ": " + arg.Type.String()
+				if arg.Default != nil {
+					text += " = " + literal(arg.Default)
+				}
+				args = append(args, text)
+			}
+			fmt.Fprintf(b, "(%s)", strings.Join(args, ", "))
+		}
+		fmt.Fprintf(b, ": %s\n", field.Type)
+	}
+	b.WriteString("}\n")
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	if !strings.Contains(description, "\n") {
+		fmt.Fprintf(b, "%s%q\n", indent, description)
+		return
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(description, "\n") {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+}
+
+// literal writes a default value as GraphQL source.
+func literal(value any) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, literal(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,138 @@
+// Package linkevents recognises switch ports going down or up in the SNMP
+// traps and syslog messages network gear sends.
+package linkevents
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"powerhive/internal/snmp"
+)
+
+// Event is a switch port changing state.
+type Event struct {
+	// Ports holds every name the switch gave the port: its ifIndex,
+	// ifName and ifDescr from a trap, or the interface named in a syslog
+	// message.
+	Ports []string
+	Up    bool
+}
+
+// IF-MIB columns a link trap carries, indexed by ifIndex.
+const (
+	oidIfIndex       = "1.3.6.1.2.1.2.2.1.1."
+	oidIfDescr       = "1.3.6.1.2.1.2.2.1.2."
+	oidIfAdminStatus = "1.3.6.1.2.1.2.2.1.7."
+	oidIfOperStatus  = "1.3.6.1.2.1.2.2.1.8."
+	oidIfName        = "1.3.6.1.2.1.31.1.1.1.1."
+)
+
+// FromTrap returns the event of a linkDown or linkUp trap. Other traps
+// report false.
+func FromTrap(trap snmp.Trap) (Event, bool) {
+	var event Event
+	switch trap.TrapOID {
+	case snmp.TrapLinkDown:
+	case snmp.TrapLinkUp:
+		event.Up = true
+	default:
+		return Event{}, false
+	}
+
+	for _, binding := range trap.Bindings {
+		switch {
+		case strings.HasPrefix(binding.OID, oidIfIndex):
+			event.add(binding.Value.String())
+		case strings.HasPrefix(binding.OID, oidIfAdminStatus):
+			event.add(strings.TrimPrefix(binding.OID, oidIfAdminStatus))
+		case strings.HasPrefix(binding.OID, oidIfOperStatus):
+			event.add(strings.TrimPrefix(binding.OID, oidIfOperStatus))
+		case strings.HasPrefix(binding.OID, oidIfDescr), strings.HasPrefix(binding.OID, oidIfName):
+			event.add(binding.Value.String())
+		}
+	}
+	return event, len(event.Ports) > 0
+}
+
+func (e *Event) add(port string) {
+	port = strings.TrimSpace(port)
+	if port == "" {
+		return
+	}
+	for _, existing := range e.Ports {
+		if existing == port {
+			return
+		}
+	}
+	e.Ports = append(e.Ports, port)
+}
+
+// defaultPatterns recognise the link messages of common switches.
+var defaultPatterns = []string{
+	// Cisco: %LINK-3-UPDOWN: Interface GigabitEthernet1/0/1, changed state to down
+	`(?i)Interface (?P<port>[^\s,]+), changed state to (?P<state>up|down)`,
+	// Juniper: SNMP_TRAP_LINK_DOWN: ifIndex 520, ifAdminStatus up(1), ifOperStatus down(2), ifName ge-0/0/1
+	`(?i)SNMP_TRAP_LINK_(?P<state>UP|DOWN):.*ifName (?P<port>\S+)`,
+	// HPE Aruba and ProCurve: Port 1/1/1 is Down, port 5 is now off-line
+	`(?i)\bport (?P<port>\S+) is (?:now )?(?P<state>up|down|on-line|off-line)\b`,
+	// MikroTik: ether5 link down
+	`(?i)(?P<port>\S+) link (?P<state>up|down)\b`,
+}
+
+// SyslogParser finds link events in syslog messages.
+type SyslogParser struct {
+	patterns []*regexp.Regexp
+}
+
+// NewSyslogParser returns a parser trying patterns before the built-in
+// ones. Each pattern must capture the port in a group named port and its
+// new state (up, down, on-line or off-line) in a group named state.
+func NewSyslogParser(patterns []string) (*SyslogParser, error) {
+	p := &SyslogParser{}
+	for _, pattern := range append(append([]string(nil), patterns...), defaultPatterns...) {
+		re, err := CompilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	return p, nil
+}
+
+// CompilePattern compiles a syslog pattern and checks it captures port
+// and state.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid syslog pattern %q: %w", pattern, err)
+	}
+	if re.SubexpIndex("port") < 0 || re.SubexpIndex("state") < 0 {
+		return nil, fmt.Errorf("syslog pattern %q must have port and state groups", pattern)
+	}
+	return re, nil
+}
+
+// Parse returns the link event in message, if any.
+func (p *SyslogParser) Parse(message string) (Event, bool) {
+	for _, re := range p.patterns {
+		match := re.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		var event Event
+		switch strings.ToLower(match[re.SubexpIndex("state")]) {
+		case "down", "off-line":
+		case "up", "on-line":
+			event.Up = true
+		default:
+			continue
+		}
+		event.add(match[re.SubexpIndex("port")])
+		if len(event.Ports) == 0 {
+			continue
+		}
+		return event, true
+	}
+	return Event{}, false
+}
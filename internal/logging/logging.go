@@ -0,0 +1,183 @@
+// Package logging builds the application logger: text or JSON output,
+// per-component levels adjustable at runtime and optional rotating file
+// output.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"powerhive/internal/config"
+)
+
+// componentKey is the attribute services use to name themselves.
+const componentKey = "component"
+
+// DefaultComponent addresses the level used by components without an
+// override.
+const DefaultComponent = "default"
+
+// Levels holds the default level and per-component overrides.
+type Levels struct {
+	mu         sync.RWMutex
+	def        slog.Level
+	components map[string]slog.Level
+}
+
+// Level returns the effective level for component.
+func (l *Levels) Level(component string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if level, ok := l.components[component]; ok {
+		return level
+	}
+	return l.def
+}
+
+// Set changes the level of component, or the default level when component
+// is empty or DefaultComponent. An empty level removes a component override.
+func (l *Levels) Set(component, level string) error {
+	component = strings.TrimSpace(component)
+	level = strings.TrimSpace(level)
+
+	if component == "" || component == DefaultComponent {
+		parsed, err := parseLevel(level)
+		if err != nil {
+			return err
+		}
+		l.mu.Lock()
+		l.def = parsed
+		l.mu.Unlock()
+		return nil
+	}
+
+	if level == "" {
+		l.mu.Lock()
+		delete(l.components, component)
+		l.mu.Unlock()
+		return nil
+	}
+
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.components[component] = parsed
+	l.mu.Unlock()
+	return nil
+}
+
+// Apply replaces every level with those in cfg.
+func (l *Levels) Apply(cfg config.LoggingConfig) error {
+	def, err := parseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	components := make(map[string]slog.Level, len(cfg.Components))
+	for component, level := range cfg.Components {
+		parsed, err := parseLevel(level)
+		if err != nil {
+			return fmt.Errorf("component %s: %w", component, err)
+		}
+		components[strings.TrimSpace(component)] = parsed
+	}
+
+	l.mu.Lock()
+	l.def = def
+	l.components = components
+	l.mu.Unlock()
+	return nil
+}
+
+// Snapshot lists the default level under DefaultComponent followed by the
+// component overrides.
+func (l *Levels) Snapshot() map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	out := make(map[string]string, len(l.components)+1)
+	out[DefaultComponent] = strings.ToLower(l.def.String())
+	for component, level := range l.components {
+		out[component] = strings.ToLower(level.String())
+	}
+	return out
+}
+
+func parseLevel(level string) (slog.Level, error) {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", level)
+	}
+	return parsed, nil
+}
+
+// New builds a logger from cfg. The returned closer releases the log file,
+// if any.
+func New(cfg config.LoggingConfig) (*slog.Logger, *Levels, io.Closer, error) {
+	levels := &Levels{components: map[string]slog.Level{}}
+	if err := levels.Apply(cfg); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var out io.Writer = os.Stdout
+	var closer io.Closer = nopCloser{}
+	if cfg.File != "" {
+		file, err := NewRotatingFile(cfg.File, int64(cfg.MaxSizeMB)<<20, cfg.MaxBackups)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		out = io.MultiWriter(os.Stdout, file)
+		closer = file
+	}
+
+	// The inner handler accepts everything; componentHandler filters.
+	opts := &slog.HandlerOptions{Level: slog.Level(-16)}
+	var inner slog.Handler
+	if cfg.Format == config.LogFormatJSON {
+		inner = slog.NewJSONHandler(out, opts)
+	} else {
+		inner = slog.NewTextHandler(out, opts)
+	}
+
+	return slog.New(&componentHandler{inner: inner, levels: levels}), levels, closer, nil
+}
+
+// componentHandler applies the level of the component named by the
+// logger's "component" attribute.
+type componentHandler struct {
+	inner     slog.Handler
+	levels    *Levels
+	component string
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.levels.Level(h.component)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	component := h.component
+	for _, attr := range attrs {
+		if attr.Key == componentKey {
+			component = attr.Value.String()
+		}
+	}
+	return &componentHandler{inner: h.inner.WithAttrs(attrs), levels: h.levels, component: component}
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return &componentHandler{inner: h.inner.WithGroup(name), levels: h.levels, component: h.component}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,90 @@
+package pdu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// httpReader reads outlets from a JSON status page, fetched once per Read.
+// Credentials may be given in the URL.
+type httpReader struct {
+	url    string
+	path   string
+	scale  float64
+	client *http.Client
+}
+
+// Read implements Reader.
+func (r *httpReader) Read(ctx context.Context, outlets []string) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create pdu request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch pdu status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pdu returned status %d", resp.StatusCode)
+	}
+
+	var payload any
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode pdu status: %w", err)
+	}
+
+	powers := make(map[string]float64, len(outlets))
+	for _, outlet := range outlets {
+		value, err := numberAt(payload, outletTemplate(r.path, outlet))
+		if err != nil {
+			continue
+		}
+		powers[outlet] = value * r.scale
+	}
+	return powers, nil
+}
+
+// numberAt follows a dot-separated path of object keys and array indexes
+// and returns the number found there. Numeric strings are accepted.
+func numberAt(payload any, path string) (float64, error) {
+	current := payload
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return 0, fmt.Errorf("%s: missing %q", path, key)
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return 0, fmt.Errorf("%s: no element %q", path, key)
+			}
+			current = node[index]
+		default:
+			return 0, fmt.Errorf("%s: not an object at %q", path, key)
+		}
+	}
+
+	switch v := current.(type) {
+	case float64:
+		return v, nil
+	case string:
+		value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+		return value, nil
+	default:
+		return 0, fmt.Errorf("%s: not a number", path)
+	}
+}
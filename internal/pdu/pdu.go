@@ -0,0 +1,54 @@
+// Package pdu reads the power drawn at each outlet of a smart PDU, over
+// HTTP from a JSON status page or over SNMPv2c.
+package pdu
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"powerhive/internal/config"
+	"powerhive/internal/snmp"
+)
+
+// Reader reads the power of a PDU's outlets.
+type Reader interface {
+	// Read returns the watts drawn at each outlet it could read. Outlets
+	// the PDU had no reading for are missing from the result.
+	Read(ctx context.Context, outlets []string) (map[string]float64, error)
+}
+
+// New returns the reader for device, giving up on a request after
+// timeout.
+func New(device config.PDUDeviceConfig, timeout time.Duration) (Reader, error) {
+	scale := device.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	switch device.Protocol {
+	case config.PDUProtocolHTTP:
+		return &httpReader{
+			url:    device.URL,
+			path:   device.PowerPath,
+			scale:  scale,
+			client: &http.Client{Timeout: timeout},
+		}, nil
+	case config.PDUProtocolSNMP:
+		return &snmpReader{
+			address:   snmp.WithDefaultPort(device.Address, "161"),
+			community: device.Community,
+			oid:       device.PowerOID,
+			scale:     scale,
+			timeout:   timeout,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported pdu protocol %q", device.Protocol)
+	}
+}
+
+// outletTemplate fills the outlet number into a power path or OID.
+func outletTemplate(template, outlet string) string {
+	return strings.ReplaceAll(template, "{outlet}", outlet)
+}
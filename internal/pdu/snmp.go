@@ -0,0 +1,41 @@
+package pdu
+
+import (
+	"context"
+	"time"
+
+	"powerhive/internal/snmp"
+)
+
+// snmpReader reads outlets with SNMPv2c GetRequests.
+type snmpReader struct {
+	address   string
+	community string
+	oid       string
+	scale     float64
+	timeout   time.Duration
+}
+
+// Read implements Reader.
+func (r *snmpReader) Read(ctx context.Context, outlets []string) (map[string]float64, error) {
+	byOID := make(map[string]string, len(outlets))
+	oids := make([]string, 0, len(outlets))
+	for _, outlet := range outlets {
+		// Match the agent's spelling of the OID, without leading zeros.
+		oid := snmp.CanonicalOID(outletTemplate(r.oid, outlet))
+		byOID[oid] = outlet
+		oids = append(oids, oid)
+	}
+
+	values, err := snmp.Get(ctx, r.address, r.community, oids, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+	powers := make(map[string]float64, len(outlets))
+	for oid, value := range values {
+		if outlet, ok := byOID[oid]; ok {
+			powers[outlet] = value * r.scale
+		}
+	}
+	return powers, nil
+}
@@ -0,0 +1,576 @@
+// Package seed fills a database with a made-up fleet and its history, so the
+// dashboard and API can be demonstrated and load-tested without hardware.
+// Miners get locally administered MAC addresses, addresses in the 198.18.0.0/15
+// benchmarking range and the "demo" tag; the history is what the pollers and
+// the balancer would have written while following a daily generation curve.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+// Tag is added to every seeded miner.
+const Tag = "demo"
+
+// statusWorkers bounds the status writes in flight at once; the write queue
+// folds them into shared transactions.
+const statusWorkers = 64
+
+// balanceHeadroom is the share of generation the simulated balancer leaves
+// unused, like the default safety margin.
+const balanceHeadroom = 0.05
+
+// presetHysteresis is how far past a miner's step, as a share of the
+// fleet's range, the level must go before the miner changes preset.
+const presetHysteresis = 0.04
+
+// Options sizes the seeded fleet and its history. Zero values take the
+// defaults noted on each field.
+type Options struct {
+	// Miners is the fleet size (default 50).
+	Miners int
+	// History is how far back the history reaches from End (default 24h).
+	History time.Duration
+	// StatusInterval spaces status polls and balance cycles (default 5m).
+	StatusInterval time.Duration
+	// PlantInterval spaces plant readings (default 1m).
+	PlantInterval time.Duration
+	// PlantID is recorded on the plant readings (default "demo").
+	PlantID string
+	// End is the time of the newest record (default now).
+	End time.Time
+	// Seed makes the fleet and its history repeatable; zero picks one at
+	// random.
+	Seed uint64
+	// Logger reports progress (default slog.Default()).
+	Logger *slog.Logger
+}
+
+// Report counts what Run wrote.
+type Report struct {
+	From, To       time.Time
+	Seed           uint64
+	Models         int
+	Miners         int
+	Statuses       int
+	PlantReadings  int
+	BalanceEvents  int
+	BalanceCycles  int
+	FleetMaxPowerW float64
+}
+
+// modelSpec describes one seeded miner model. Presets run from the lowest
+// to the highest power and are named the way the firmware names them.
+type modelSpec struct {
+	name, alias   string
+	driver        string
+	fwName        string
+	fwVersion     string
+	presets       []string
+	joulesPerTH   float64
+	chains, fans  int
+	chipsPerChain int
+}
+
+var catalogue = []modelSpec{
+	{
+		name: "Antminer S21", alias: "s21",
+		driver: firmware.DriverBraiins, fwName: "Braiins OS", fwVersion: "25.03",
+		presets:     []string{"2400W", "2700W", "3010W", "3300W", "3500W"},
+		joulesPerTH: 17.5, chains: 3, fans: 4, chipsPerChain: 108,
+	},
+	{
+		name: "Antminer S19 XP", alias: "s19xp",
+		driver: firmware.DriverVnish, fwName: "Vnish", fwVersion: "1.2.6",
+		presets:     []string{"2000", "2400", "2800", "3000", "3250"},
+		joulesPerTH: 21.5, chains: 3, fans: 4, chipsPerChain: 110,
+	},
+	{
+		name: "Antminer S19j Pro", alias: "s19jpro",
+		driver: firmware.DriverVnish, fwName: "Vnish", fwVersion: "1.2.6",
+		presets:     []string{"1100", "1300", "1500", "1800", "2200", "2800"},
+		joulesPerTH: 29.5, chains: 3, fans: 4, chipsPerChain: 126,
+	},
+}
+
+// presetWatts parses a catalogue preset; every entry is numeric with an
+// optional W suffix.
+func presetWatts(preset string) float64 {
+	w, _ := strconv.ParseFloat(strings.TrimSuffix(preset, "W"), 64)
+	return w
+}
+
+// fakeMiner is a seeded miner and its simulated state.
+type fakeMiner struct {
+	id        string
+	ip        string
+	model     *modelSpec
+	container string
+	// offset staggers the miner's preset steps against the rest of the
+	// fleet, so the fleet ramps one miner at a time.
+	offset float64
+	// silentFrom, when set, is when the miner stops answering polls.
+	silentFrom time.Time
+	preset     int
+	bootedAt   time.Time
+}
+
+func (m *fakeMiner) watts() float64 {
+	return presetWatts(m.model.presets[m.preset])
+}
+
+// Run writes the fleet and its history to store. It does not check for an
+// existing fleet; seeding twice with the same options upserts the same
+// miners and adds a second copy of the history.
+func Run(ctx context.Context, store *database.Store, opts Options) (Report, error) {
+	opts = withDefaults(opts)
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x5eed))
+	log := opts.Logger.With("component", "seed")
+
+	end := opts.End.UTC().Truncate(time.Second)
+	start := end.Add(-opts.History)
+	report := Report{From: start, To: end, Seed: opts.Seed}
+
+	for i := range catalogue {
+		if err := seedModel(ctx, store, &catalogue[i]); err != nil {
+			return report, err
+		}
+		report.Models++
+	}
+
+	miners := make([]*fakeMiner, opts.Miners)
+	for i := range miners {
+		m := &fakeMiner{
+			id:        fmt.Sprintf("02:de:%02x:%02x:%02x:%02x", i>>24&0xff, i>>16&0xff, i>>8&0xff, i&0xff),
+			model:     &catalogue[i%len(catalogue)],
+			container: fmt.Sprintf("container_%d", 1+i*2/opts.Miners),
+			offset:    rng.Float64(),
+			bootedAt:  start.Add(-time.Duration(rng.Int64N(int64(72 * time.Hour)))),
+		}
+		// A few miners drop off late in the history so the offline views
+		// have something to show.
+		if rng.Float64() < 0.04 {
+			m.silentFrom = end.Add(-time.Duration(rng.Float64() * float64(opts.History) / 4))
+		}
+		if err := seedMiner(ctx, store, i, m); err != nil {
+			return report, err
+		}
+		miners[i] = m
+		report.Miners++
+		report.FleetMaxPowerW += presetWatts(m.model.presets[len(m.model.presets)-1])
+	}
+	log.Info("seeded fleet", "models", report.Models, "miners", report.Miners, "max_power_w", report.FleetMaxPowerW)
+
+	writes := store.NewWriteQueue(200, 50*time.Millisecond)
+	queueCtx, stopQueue := context.WithCancel(ctx)
+	queueDone := make(chan struct{})
+	go func() {
+		defer close(queueDone)
+		writes.Run(queueCtx)
+	}()
+	defer func() {
+		stopQueue()
+		<-queueDone
+	}()
+
+	curve := generationCurve{peakKW: report.FleetMaxPowerW / 1000 * 1.05, seed: opts.Seed}
+	minTotalW := 0.0
+	for _, m := range miners {
+		minTotalW += presetWatts(m.model.presets[0])
+	}
+	// Start settled, so the history does not open with the whole fleet
+	// ramping up.
+	level := fleetLevel(curve.at(start)*1000*(1-balanceHeadroom), minTotalW, report.FleetMaxPowerW)
+	for _, m := range miners {
+		m.preset = m.wantedPreset(level)
+	}
+
+	ticks := int(opts.History / opts.StatusInterval)
+	nextPlant := start
+	for tick := 0; tick <= ticks; tick++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		at := start.Add(time.Duration(tick) * opts.StatusInterval)
+		generationKW := curve.at(at)
+
+		events, cycle, err := balance(ctx, store, miners, at, generationKW, minTotalW, report.FleetMaxPowerW)
+		if err != nil {
+			return report, err
+		}
+		report.BalanceEvents += events
+		report.BalanceCycles += cycle
+
+		n, err := recordStatuses(ctx, writes, rng, miners, at)
+		if err != nil {
+			return report, err
+		}
+		report.Statuses += n
+
+		for ; nextPlant.Before(at.Add(opts.StatusInterval)) && !nextPlant.After(end); nextPlant = nextPlant.Add(opts.PlantInterval) {
+			if err := recordPlantReading(ctx, store, rng, opts.PlantID, miners, nextPlant, curve.at(nextPlant)); err != nil {
+				return report, err
+			}
+			report.PlantReadings++
+		}
+
+		if ticks >= 10 && tick%(ticks/10) == 0 && tick > 0 {
+			log.Info("seeding history", "progress_percent", tick*100/ticks, "at", at.Format(time.RFC3339))
+		}
+	}
+
+	for _, m := range miners {
+		if m.silentFrom.IsZero() {
+			if _, err := store.RecordPollResult(ctx, m.id, nil); err != nil {
+				return report, fmt.Errorf("seed poll result: %w", err)
+			}
+			continue
+		}
+		// Discovery clears the address of miners it no longer finds.
+		if _, err := store.RecordPollResult(ctx, m.id, fmt.Errorf("dial tcp %s:80: i/o timeout", m.ip)); err != nil {
+			return report, fmt.Errorf("seed poll result: %w", err)
+		}
+		offline := ""
+		if _, err := store.UpsertMiner(ctx, database.UpsertMinerParams{ID: m.id, IP: &offline}); err != nil {
+			return report, fmt.Errorf("seed offline miner %s: %w", m.id, err)
+		}
+	}
+
+	return report, nil
+}
+
+func withDefaults(opts Options) Options {
+	if opts.Miners <= 0 {
+		opts.Miners = 50
+	}
+	if opts.History <= 0 {
+		opts.History = 24 * time.Hour
+	}
+	if opts.StatusInterval <= 0 {
+		opts.StatusInterval = 5 * time.Minute
+	}
+	if opts.PlantInterval <= 0 {
+		opts.PlantInterval = time.Minute
+	}
+	if strings.TrimSpace(opts.PlantID) == "" {
+		opts.PlantID = "demo"
+	}
+	if opts.End.IsZero() {
+		opts.End = time.Now()
+	}
+	if opts.Seed == 0 {
+		opts.Seed = rand.Uint64()
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return opts
+}
+
+func seedModel(ctx context.Context, store *database.Store, spec *modelSpec) error {
+	lowest, highest := spec.presets[0], spec.presets[len(spec.presets)-1]
+	if _, err := store.UpsertModel(ctx, database.ModelInput{
+		Name:      spec.name,
+		Alias:     spec.alias,
+		Presets:   spec.presets,
+		MinPreset: &lowest,
+		MaxPreset: &highest,
+	}); err != nil {
+		return fmt.Errorf("seed model %s: %w", spec.alias, err)
+	}
+	for _, preset := range spec.presets {
+		power := presetWatts(preset)
+		hashrate := power / spec.joulesPerTH
+		if err := store.UpdatePresetMetrics(ctx, spec.alias, preset, &power, &hashrate); err != nil {
+			return fmt.Errorf("seed preset %s/%s: %w", spec.alias, preset, err)
+		}
+	}
+	return nil
+}
+
+func seedMiner(ctx context.Context, store *database.Store, index int, m *fakeMiner) error {
+	m.ip = fmt.Sprintf("198.18.%d.%d", index/250, index%250+1)
+	managed := true
+	name := fmt.Sprintf("demo-%04d", index+1)
+	rack := fmt.Sprintf("rack-%c", 'a'+rune(index/20%26))
+	tags := []string{Tag, rack}
+	port := 80
+	scheme := "http"
+	if _, err := store.UpsertMiner(ctx, database.UpsertMinerParams{
+		ID:         m.id,
+		IP:         &m.ip,
+		Managed:    &managed,
+		ModelAlias: &m.model.alias,
+		FWName:     &m.model.fwName,
+		FWVersion:  &m.model.fwVersion,
+		Driver:     &m.model.driver,
+		APIScheme:  &scheme,
+		APIPort:    &port,
+		Name:       &name,
+		Location:   &m.container,
+		Tags:       &tags,
+	}); err != nil {
+		return fmt.Errorf("seed miner %s: %w", m.id, err)
+	}
+
+	preset := m.model.presets[len(m.model.presets)/2]
+	worker := "demo." + name
+	if _, err := store.SaveMinerSettings(ctx, m.id, database.SettingsInput{
+		Cooling: database.CoolingSettingsInput{Mode: "auto"},
+		Preset:  &preset,
+		Pools: []database.PoolInput{
+			{URL: "stratum+tcp://pool.example.com:3333", Username: &worker},
+			{URL: "stratum+tcp://backup.example.com:3333", Username: &worker},
+		},
+	}); err != nil {
+		return fmt.Errorf("seed settings for miner %s: %w", m.id, err)
+	}
+	return nil
+}
+
+// generationCurve is a daily swing between a night low and a midday high
+// with a slow random drift on top, in kW. The drift is a fixed function of
+// the time, so readings taken between status ticks agree with them.
+type generationCurve struct {
+	peakKW float64
+	seed   uint64
+}
+
+// driftStep is how often the drift picks a new direction.
+const driftStep = 10 * time.Minute
+
+func (c generationCurve) at(t time.Time) float64 {
+	hour := float64(t.Hour()) + float64(t.Minute())/60
+	// Lowest around 04:00, highest around 16:00.
+	daily := 0.65 + 0.3*math.Sin(2*math.Pi*(hour-10)/24)
+
+	step := t.Unix() / int64(driftStep/time.Second)
+	frac := float64(t.Unix()%int64(driftStep/time.Second)) / driftStep.Seconds()
+	drift := c.driftAt(step)*(1-frac) + c.driftAt(step+1)*frac
+
+	return math.Max(0, c.peakKW*(daily+0.05*drift))
+}
+
+// driftAt is the drift, between -1 and 1, at the start of a step.
+func (c generationCurve) driftAt(step int64) float64 {
+	return rand.New(rand.NewPCG(c.seed, uint64(step))).Float64()*2 - 1
+}
+
+// fleetLevel is how far between its lowest and highest total the fleet
+// should run to draw targetW, from 0 to 1.
+func fleetLevel(targetW, minTotalW, maxTotalW float64) float64 {
+	if maxTotalW <= minTotalW {
+		return 0
+	}
+	return math.Max(0, math.Min(1, (targetW-minTotalW)/(maxTotalW-minTotalW)))
+}
+
+// wantedPreset is the preset index m settles on at a fleet level.
+func (m *fakeMiner) wantedPreset(level float64) int {
+	steps := len(m.model.presets) - 1
+	return int(math.Min(float64(steps), math.Floor(level*float64(steps)+m.offset)))
+}
+
+// balance moves the fleet toward the generation less the headroom, one
+// preset step at a time per cycle like the balancer, and records the
+// changes and the cycle. It returns the number of events and cycles
+// written.
+func balance(ctx context.Context, store *database.Store, miners []*fakeMiner, at time.Time, generationKW, minTotalW, maxTotalW float64) (int, int, error) {
+	targetW := generationKW * 1000 * (1 - balanceHeadroom)
+	level := fleetLevel(targetW, minTotalW, maxTotalW)
+
+	before := 0.0
+	eligible := 0
+	for _, m := range miners {
+		if m.silentFrom.IsZero() || at.Before(m.silentFrom) {
+			before += m.watts()
+			eligible++
+		}
+	}
+
+	after := before
+	events := 0
+	for _, m := range miners {
+		if !m.silentFrom.IsZero() && !at.Before(m.silentFrom) {
+			continue
+		}
+		// Move only once the level is well past the step, so the drift
+		// does not flap miners back and forth.
+		next := m.preset
+		switch {
+		case m.wantedPreset(level-presetHysteresis) > m.preset:
+			next++
+		case m.wantedPreset(level+presetHysteresis) < m.preset:
+			next--
+		default:
+			continue
+		}
+
+		oldPreset, newPreset := m.model.presets[m.preset], m.model.presets[next]
+		oldPower, newPower := m.watts(), presetWatts(newPreset)
+		consumptionBefore := after
+		after += newPower - oldPower
+		available := generationKW*1000 - consumptionBefore
+		if _, err := store.RecordPowerBalanceEvent(ctx, database.PowerBalanceEventInput{
+			MinerID:                m.id,
+			OldPreset:              &oldPreset,
+			NewPreset:              &newPreset,
+			OldPower:               &oldPower,
+			NewPower:               &newPower,
+			Reason:                 "automatic_balance",
+			TotalConsumptionBefore: &consumptionBefore,
+			TotalConsumptionAfter:  &after,
+			AvailablePower:         &available,
+			TargetPower:            &targetW,
+			Success:                true,
+			RecordedAt:             at,
+		}); err != nil {
+			return events, 0, fmt.Errorf("seed balance event: %w", err)
+		}
+		if err := store.RecordPresetChange(ctx, m.id, at); err != nil {
+			return events, 0, fmt.Errorf("seed preset change: %w", err)
+		}
+		m.preset = next
+		events++
+	}
+
+	if _, err := store.RecordBalanceCycle(ctx, database.BalanceCycleInput{
+		Mode:               string(database.BalanceModeFixedHeadroom),
+		GenerationKW:       generationKW,
+		TargetPowerW:       targetW,
+		ConsumptionBeforeW: before,
+		ConsumptionAfterW:  after,
+		MinersEligible:     eligible,
+		MinersAdjusted:     events,
+		RecordedAt:         at,
+	}); err != nil {
+		return events, 0, fmt.Errorf("seed balance cycle: %w", err)
+	}
+	return events, 1, nil
+}
+
+// recordStatuses writes one status per answering miner at at.
+func recordStatuses(ctx context.Context, writes *database.WriteQueue, rng *rand.Rand, miners []*fakeMiner, at time.Time) (int, error) {
+	inputs := make(map[string]database.MinerStatusInput, len(miners))
+	for _, m := range miners {
+		if !m.silentFrom.IsZero() && !at.Before(m.silentFrom) {
+			continue
+		}
+		inputs[m.id] = statusInput(rng, m, at)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		slots    = make(chan struct{}, statusWorkers)
+	)
+	for id, input := range inputs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := writes.RecordMinerStatus(ctx, id, input); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("seed status for miner %s: %w", id, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return len(inputs), firstErr
+}
+
+func statusInput(rng *rand.Rand, m *fakeMiner, at time.Time) database.MinerStatusInput {
+	preset := m.model.presets[m.preset]
+	power := m.watts() * (0.98 + rng.Float64()*0.04)
+	hashrateGH := m.watts() / m.model.joulesPerTH * 1000 * (0.97 + rng.Float64()*0.05)
+	uptime := int64(at.Sub(m.bootedAt).Seconds())
+	state := "mining"
+
+	// Hotter and louder the closer the miner runs to its top preset.
+	load := float64(m.preset) / float64(max(1, len(m.model.presets)-1))
+	input := database.MinerStatusInput{
+		Uptime:           &uptime,
+		State:            &state,
+		Preset:           &preset,
+		Hashrate:         &hashrateGH,
+		PowerUsage:       &power,
+		PowerConsumption: &power,
+		RecordedAt:       at,
+	}
+	for f := 0; f < m.model.fans; f++ {
+		id := fmt.Sprintf("fan-%d", f)
+		rpm := int(3200 + load*2400 + rng.Float64()*200)
+		status := "ok"
+		input.Fans = append(input.Fans, database.FanStatusInput{FanIdentifier: &id, RPM: &rpm, Status: &status})
+	}
+	for c := 0; c < m.model.chains; c++ {
+		id := fmt.Sprintf("chain-%d", c)
+		chainState := "mining"
+		chainHashrate := hashrateGH / float64(m.model.chains)
+		chipAvg := 58 + load*14 + rng.Float64()*3
+		chipMin, chipMax := chipAvg-4, chipAvg+5
+		pcbMin, pcbMax := chipAvg-14, chipAvg-6
+		chips := m.model.chipsPerChain
+		input.Chains = append(input.Chains, database.ChainSnapshotInput{
+			ChainIdentifier: &id,
+			State:           &chainState,
+			Hashrate:        &chainHashrate,
+			PCBTempMin:      &pcbMin,
+			PCBTempMax:      &pcbMax,
+			ChipTempMin:     &chipMin,
+			ChipTempMax:     &chipMax,
+			ChipTempAvg:     &chipAvg,
+			ChipCount:       &chips,
+		})
+	}
+	return input
+}
+
+// recordPlantReading writes a reading with the fleet's current draw split
+// across its containers and generation split across two generators.
+func recordPlantReading(ctx context.Context, store *database.Store, rng *rand.Rand, plantID string, miners []*fakeMiner, at time.Time, generationKW float64) error {
+	consumption := make(map[string]float64)
+	consumptionKW := 0.0
+	for _, m := range miners {
+		if !m.silentFrom.IsZero() && !at.Before(m.silentFrom) {
+			continue
+		}
+		kw := m.watts() / 1000
+		consumption[m.container] += kw / 1000
+		consumptionKW += kw
+	}
+	share := 0.55 + rng.Float64()*0.05
+	exportedKW := generationKW - consumptionKW
+	if _, err := store.RecordPlantReading(ctx, database.PlantReadingInput{
+		PlantID:                   plantID,
+		TotalGeneration:           generationKW,
+		TotalContainerConsumption: consumptionKW,
+		AvailablePower:            generationKW - consumptionKW,
+		GenerationSources: map[string]float64{
+			"generator_1": generationKW * share / 1000,
+			"generator_2": generationKW * (1 - share) / 1000,
+		},
+		ConsumptionSources: consumption,
+		ExportedPower:      &exportedKW,
+		RecordedAt:         at,
+	}); err != nil {
+		return fmt.Errorf("seed plant reading: %w", err)
+	}
+	return nil
+}
@@ -1,18 +1,42 @@
 package server
 
 import (
+	"bytes"
 	"embed"
+	"html/template"
 	"io/fs"
 	"net/http"
+	"time"
 )
 
 //go:embed web/*
 var embeddedWeb embed.FS
 
-func staticHandler() (http.Handler, error) {
+// staticHandler serves the dashboard. index.html is a template that gets
+// the base path, so its asset links and API calls work behind a reverse
+// proxy that serves PowerHive under a sub-path.
+func staticHandler(basePath string) (http.Handler, error) {
 	fsContent, err := fs.Sub(embeddedWeb, "web")
 	if err != nil {
 		return nil, err
 	}
-	return http.FileServer(http.FS(fsContent)), nil
+	index, err := template.ParseFS(fsContent, "index.html")
+	if err != nil {
+		return nil, err
+	}
+	var page bytes.Buffer
+	if err := index.Execute(&page, struct{ BasePath string }{basePath}); err != nil {
+		return nil, err
+	}
+
+	files := http.FileServer(http.FS(fsContent))
+	started := time.Now()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && r.URL.Path != "/index.html" {
+			files.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		http.ServeContent(w, r, "index.html", started, bytes.NewReader(page.Bytes()))
+	}), nil
 }
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+type availabilityIncidentDTO struct {
+	StartedAt string  `json:"started_at"`
+	EndedAt   *string `json:"ended_at"`
+	// DurationSeconds covers the whole incident, including any part
+	// outside the requested range; ongoing incidents run to the range end.
+	DurationSeconds float64 `json:"duration_seconds"`
+	Source          string  `json:"source"`
+	Reason          *string `json:"reason"`
+}
+
+type availabilityDTO struct {
+	MinerID string `json:"miner_id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	// UptimePercent is the share of the tracked time the miner was online,
+	// nil when nothing was tracked in the range.
+	UptimePercent          *float64                  `json:"uptime_percent"`
+	OnlineSeconds          float64                   `json:"online_seconds"`
+	OfflineSeconds         float64                   `json:"offline_seconds"`
+	UntrackedSeconds       float64                   `json:"untracked_seconds"`
+	IncidentCount          int                       `json:"incident_count"`
+	LongestIncidentSeconds float64                   `json:"longest_incident_seconds"`
+	Incidents              []availabilityIncidentDTO `json:"incidents"`
+}
+
+// handleMinerAvailability reports a miner's uptime and downtime incidents
+// between from and to (default the last 30 days). Time before the first
+// recorded change is untracked and left out of the uptime percentage.
+func (s *Server) handleMinerAvailability(w http.ResponseWriter, r *http.Request, minerID string) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if now := time.Now().UTC(); to.After(now) {
+		to = now
+	}
+	if !from.Before(to) {
+		writeError(w, http.StatusBadRequest, "from must be in the past")
+		return
+	}
+
+	if _, err := s.store.GetMiner(ctx, minerID); err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "miner not found")
+			return
+		}
+		s.log.Error("get miner failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch miner")
+		return
+	}
+
+	changes, err := s.store.ListAvailabilityChanges(ctx, minerID, from, to)
+	if err != nil {
+		s.log.Error("list availability changes failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch availability")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, buildAvailability(minerID, from, to, changes))
+}
+
+// buildAvailability folds the changes from ListAvailabilityChanges into
+// time spent online, offline and untracked, and the offline incidents.
+func buildAvailability(minerID string, from, to time.Time, changes []database.AvailabilityChange) availabilityDTO {
+	out := availabilityDTO{
+		MinerID:   minerID,
+		From:      formatTime(from),
+		To:        formatTime(to),
+		Incidents: []availabilityIncidentDTO{},
+	}
+
+	var (
+		cursor  = from
+		current *database.AvailabilityChange
+	)
+	closeIncident := func(end *time.Time) {
+		incident := availabilityIncidentDTO{
+			StartedAt: formatTime(current.ChangedAt),
+			Source:    current.Source,
+			Reason:    current.Reason,
+		}
+		until := to
+		if end != nil {
+			until = *end
+			incident.EndedAt = formatTimePtr(end)
+		}
+		incident.DurationSeconds = until.Sub(current.ChangedAt).Seconds()
+		if incident.DurationSeconds > out.LongestIncidentSeconds {
+			out.LongestIncidentSeconds = incident.DurationSeconds
+		}
+		out.Incidents = append(out.Incidents, incident)
+	}
+	advance := func(until time.Time) {
+		span := until.Sub(cursor).Seconds()
+		switch {
+		case current == nil:
+			out.UntrackedSeconds += span
+		case current.Online:
+			out.OnlineSeconds += span
+		default:
+			out.OfflineSeconds += span
+		}
+		cursor = until
+	}
+
+	for i := range changes {
+		change := &changes[i]
+		if change.ChangedAt.After(from) {
+			advance(change.ChangedAt)
+			if current != nil && !current.Online && change.Online {
+				closeIncident(&change.ChangedAt)
+			}
+		}
+		current = change
+	}
+	advance(to)
+	if current != nil && !current.Online {
+		closeIncident(nil)
+	}
+
+	out.IncidentCount = len(out.Incidents)
+	if tracked := out.OnlineSeconds + out.OfflineSeconds; tracked > 0 {
+		uptime := out.OnlineSeconds / tracked * 100
+		out.UptimePercent = &uptime
+	}
+	return out
+}
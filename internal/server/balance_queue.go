@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// PresetChangeQueue is the balancer's queue of preset changes not yet
+// finished.
+type PresetChangeQueue struct {
+	Workers         int
+	ContainerPacing time.Duration
+	// PendingDeltaW is the consumption change the queued changes are
+	// expected to make.
+	PendingDeltaW float64
+	Changes       []QueuedPresetChange
+}
+
+// QueuedPresetChange is one waiting or running change. StartedAt is set
+// once it is being applied.
+type QueuedPresetChange struct {
+	MinerID   string
+	Location  *string
+	OldPreset *string
+	NewPreset string
+	OldPowerW *float64
+	NewPowerW *float64
+	Reason    string
+	QueuedAt  time.Time
+	StartedAt *time.Time
+}
+
+// BalanceQueueReporter reports the balancer's preset change queue.
+type BalanceQueueReporter interface {
+	BalanceQueue() PresetChangeQueue
+}
+
+// WithBalanceQueue enables GET /api/balance/queue.
+func WithBalanceQueue(r BalanceQueueReporter) Option {
+	return func(s *Server) {
+		s.queue = r
+	}
+}
+
+type queuedPresetChangeDTO struct {
+	MinerID   string   `json:"miner_id"`
+	Location  *string  `json:"location"`
+	State     string   `json:"state"`
+	OldPreset *string  `json:"old_preset"`
+	NewPreset string   `json:"new_preset"`
+	OldPowerW *float64 `json:"old_power_w"`
+	NewPowerW *float64 `json:"new_power_w"`
+	Reason    string   `json:"reason"`
+	QueuedAt  string   `json:"queued_at"`
+	StartedAt *string  `json:"started_at"`
+}
+
+// handleBalanceQueue lists the preset changes the balancer has queued,
+// those being applied first.
+func (s *Server) handleBalanceQueue(w http.ResponseWriter, r *http.Request) {
+	if s.queue == nil {
+		writeError(w, http.StatusServiceUnavailable, "balance queue is not available")
+		return
+	}
+
+	queue := s.queue.BalanceQueue()
+	changes := make([]queuedPresetChangeDTO, 0, len(queue.Changes))
+	applying := 0
+	for _, change := range queue.Changes {
+		state := "queued"
+		if change.StartedAt != nil {
+			state = "applying"
+			applying++
+		}
+		changes = append(changes, queuedPresetChangeDTO{
+			MinerID:   change.MinerID,
+			Location:  change.Location,
+			State:     state,
+			OldPreset: change.OldPreset,
+			NewPreset: change.NewPreset,
+			OldPowerW: change.OldPowerW,
+			NewPowerW: change.NewPowerW,
+			Reason:    change.Reason,
+			QueuedAt:  formatTime(change.QueuedAt),
+			StartedAt: formatTimePtr(change.StartedAt),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"workers":                  queue.Workers,
+		"container_pacing_seconds": queue.ContainerPacing.Seconds(),
+		"applying":                 applying,
+		"queued":                   len(changes) - applying,
+		"pending_delta_w":          queue.PendingDeltaW,
+		"changes":                  changes,
+	})
+}
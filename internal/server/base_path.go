@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithBasePath serves PowerHive under path, such as "/powerhive", for a
+// reverse proxy that forwards a sub-path. The dashboard's links and API
+// calls carry the path. Requests without it are still served, so a proxy
+// may strip it and local health checks keep working.
+func WithBasePath(path string) Option {
+	return func(s *Server) {
+		s.basePath = strings.TrimRight(path, "/")
+	}
+}
+
+// stripBasePath removes the base path from requests that carry it, and
+// redirects the bare base path to the dashboard under it.
+func (s *Server) stripBasePath(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case s.basePath == "":
+			next.ServeHTTP(w, r)
+		case r.URL.Path == s.basePath:
+			target := s.basePath + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, s.basePath+"/"):
+			next.ServeHTTP(w, withPath(r, strings.TrimPrefix(r.URL.Path, s.basePath)))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package server
+
+import "context"
+
+// ChangeLimits are the balancer's preset change limits, zero when off, and
+// the changes counted against them.
+type ChangeLimits struct {
+	PerCycle           int
+	PerHour            int
+	PerMinerPerDay     int
+	LastCycle          int
+	LastHour           int
+	LastDay            int
+	Queued             int
+	MinersAtDailyLimit int
+}
+
+// ChangeLimitReporter reports the balancer's preset change counters.
+type ChangeLimitReporter interface {
+	ChangeLimits(ctx context.Context) (ChangeLimits, bool)
+}
+
+// WithChangeLimits adds the preset change counters to
+// /api/balance/status.
+func WithChangeLimits(r ChangeLimitReporter) Option {
+	return func(s *Server) {
+		s.changeLimits = r
+	}
+}
+
+type changeLimitsDTO struct {
+	PerCycle           *int `json:"per_cycle"`
+	PerHour            *int `json:"per_hour"`
+	PerMinerPerDay     *int `json:"per_miner_per_day"`
+	LastCycle          int  `json:"changes_last_cycle"`
+	LastHour           int  `json:"changes_last_hour"`
+	LastDay            int  `json:"changes_last_day"`
+	Queued             int  `json:"changes_queued"`
+	MinersAtDailyLimit int  `json:"miners_at_daily_limit"`
+}
+
+func toChangeLimitsDTO(limits ChangeLimits) *changeLimitsDTO {
+	limit := func(v int) *int {
+		if v <= 0 {
+			return nil
+		}
+		return &v
+	}
+	return &changeLimitsDTO{
+		PerCycle:           limit(limits.PerCycle),
+		PerHour:            limit(limits.PerHour),
+		PerMinerPerDay:     limit(limits.PerMinerPerDay),
+		LastCycle:          limits.LastCycle,
+		LastHour:           limits.LastHour,
+		LastDay:            limits.LastDay,
+		Queued:             limits.Queued,
+		MinersAtDailyLimit: limits.MinersAtDailyLimit,
+	}
+}
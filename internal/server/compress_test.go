@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+func newTestServer(t *testing.T) (*Server, *database.Store) {
+	t.Helper()
+	db, err := database.Open(database.DialectSQLite, filepath.Join(t.TempDir(), "test.db"), database.PoolOptions{MaxOpenConns: 1, MaxIdleConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	store, err := database.New(db, database.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	srv, err := New(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, store
+}
+
+// TestStatusesETagFollowsConfirmation checks that confirming a status in
+// place, which moves its last_confirmed_at without adding a row, still
+// invalidates the cached status listing.
+func TestStatusesETagFollowsConfirmation(t *testing.T) {
+	srv, store := newTestServer(t)
+	ctx := context.Background()
+
+	const minerID = "02:de:00:00:00:01"
+	ip, state := "10.0.0.10", "mining"
+	if _, err := store.UpsertMiner(ctx, database.UpsertMinerParams{ID: minerID, IP: &ip}); err != nil {
+		t.Fatal(err)
+	}
+	recordedAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	status, err := store.RecordMinerStatus(ctx, minerID, database.MinerStatusInput{State: &state, RecordedAt: recordedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/miners/"+minerID+"/statuses", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first request: status %d, ETag %q", first.Code, etag)
+	}
+	if rec := get(etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("unchanged listing: status %d, want 304", rec.Code)
+	}
+
+	if err := store.ConfirmMinerStatus(ctx, minerID, status.ID, recordedAt.Add(30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := get(etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("after confirmation: status %d, want 200", rec.Code)
+	}
+	if next := rec.Header().Get("ETag"); next == "" || next == etag {
+		t.Errorf("after confirmation: ETag %q, want one different from %q", next, etag)
+	}
+}
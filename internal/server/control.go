@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+)
+
+// ErrMinerUnreachable is returned by MinerController when the miner has no
+// address or lacks the credentials its driver needs.
+var ErrMinerUnreachable = errors.New("miner is offline or missing credentials")
+
+// ErrInvalidSettings is returned by MinerController when the requested
+// settings fail validation; nothing was sent to the miner.
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// MinerController sends one-off commands to a single miner.
+type MinerController interface {
+	// LocateMiner turns the miner's locate LED on or off.
+	LocateMiner(ctx context.Context, minerID string, on bool) error
+	// SetCooling applies cooling settings and returns the recorded
+	// settings snapshot. Nil fields keep the current values.
+	SetCooling(ctx context.Context, minerID string, cooling database.CoolingSettingsInput) (database.Settings, *firmware.SaveConfigResult, error)
+	// MinerLogs fetches the last maxBytes of one of the miner's logs.
+	MinerLogs(ctx context.Context, minerID, kind string, maxBytes int64) (firmware.MinerLog, error)
+	// MinerNetwork reads the miner's network configuration.
+	MinerNetwork(ctx context.Context, minerID string) (firmware.NetworkSettings, error)
+	// SetMinerNetwork applies network settings over the current ones and
+	// returns the configuration sent to the miner.
+	SetMinerNetwork(ctx context.Context, minerID string, update firmware.NetworkSettings) (firmware.NetworkSettings, *firmware.SaveConfigResult, error)
+	// ReIPMiners applies plan and returns the outcome per miner.
+	ReIPMiners(ctx context.Context, plan ReIPPlan) ([]ReIPResult, error)
+}
+
+// WithMinerController enables the per-miner command endpoints.
+func WithMinerController(c MinerController) Option {
+	return func(s *Server) {
+		s.controller = c
+	}
+}
+
+func (s *Server) handleMinerLocate(w http.ResponseWriter, r *http.Request, minerID string) {
+	if s.controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "miner control is not available")
+		return
+	}
+
+	// An empty body turns the LED on.
+	req := struct {
+		Enabled *bool `json:"enabled"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	on := req.Enabled == nil || *req.Enabled
+
+	if err := s.controller.LocateMiner(r.Context(), minerID, on); err != nil {
+		s.writeControlError(w, minerID, "locate", err)
+		return
+	}
+
+	s.log.Info("miner locate LED changed", "miner", minerID, "enabled", on)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"miner_id": minerID,
+		"enabled":  on,
+	})
+}
+
+type coolingDTO struct {
+	Mode        string `json:"mode"`
+	FanDuty     *int   `json:"fan_duty,omitempty"`
+	FanMinCount *int   `json:"fan_min_count,omitempty"`
+	FanMinDuty  *int   `json:"fan_min_duty,omitempty"`
+	FanMaxDuty  *int   `json:"fan_max_duty,omitempty"`
+}
+
+func toCoolingDTO(cooling database.CoolingSettings) coolingDTO {
+	return coolingDTO{
+		Mode:        cooling.Mode,
+		FanDuty:     cooling.FanDuty,
+		FanMinCount: cooling.FanMinCount,
+		FanMinDuty:  cooling.FanMinDuty,
+		FanMaxDuty:  cooling.FanMaxDuty,
+	}
+}
+
+func (s *Server) handleMinerCooling(w http.ResponseWriter, r *http.Request, minerID string) {
+	if s.controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "miner control is not available")
+		return
+	}
+
+	var req struct {
+		Mode        string `json:"mode"`
+		FanDuty     *int   `json:"fan_duty"`
+		FanMinCount *int   `json:"fan_min_count"`
+		FanMinDuty  *int   `json:"fan_min_duty"`
+		FanMaxDuty  *int   `json:"fan_max_duty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	cooling := database.CoolingSettingsInput{
+		Mode:        req.Mode,
+		FanDuty:     req.FanDuty,
+		FanMinCount: req.FanMinCount,
+		FanMinDuty:  req.FanMinDuty,
+		FanMaxDuty:  req.FanMaxDuty,
+	}
+	settings, result, err := s.controller.SetCooling(r.Context(), minerID, cooling)
+	if err != nil {
+		s.writeControlError(w, minerID, "cooling control", err)
+		return
+	}
+
+	s.log.Info("miner cooling changed", "miner", minerID, "mode", settings.Cooling.Mode)
+	resp := map[string]any{
+		"miner_id": minerID,
+		"cooling":  toCoolingDTO(settings.Cooling),
+	}
+	if result != nil {
+		resp["reboot_required"] = result.RebootRequired
+		resp["restart_required"] = result.RestartRequired
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeControlError maps a MinerController error to a response.
+func (s *Server) writeControlError(w http.ResponseWriter, minerID, action string, err error) {
+	switch {
+	case isNotFound(err):
+		writeError(w, http.StatusNotFound, "miner not found")
+	case errors.Is(err, ErrMinerUnreachable):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrInvalidSettings):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, firmware.ErrUnsupported):
+		writeError(w, http.StatusNotImplemented, "the miner's firmware does not support "+action)
+	default:
+		s.log.Error("miner command failed", "miner", minerID, "action", action, "err", err)
+		writeError(w, http.StatusBadGateway, "miner did not accept the command: "+err.Error())
+	}
+}
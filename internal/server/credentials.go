@@ -0,0 +1,173 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"powerhive/internal/database"
+)
+
+// maskedSecretVisible is how many trailing characters of a long secret
+// stay visible, enough to tell passwords apart.
+const maskedSecretVisible = 2
+
+// maskSecret hides a password for display. Secrets of eight characters or
+// more keep their last two; shorter ones are hidden entirely.
+func maskSecret(secret string) string {
+	const mask = "********"
+	if len(secret) < len(mask) {
+		return mask
+	}
+	return mask + secret[len(secret)-maskedSecretVisible:]
+}
+
+type unlockPasswordRequest struct {
+	Scope    string `json:"scope"`
+	Target   string `json:"target"`
+	Password string `json:"password"`
+	Priority int    `json:"priority"`
+}
+
+type unlockPasswordDTO struct {
+	ID        int64  `json:"id"`
+	Scope     string `json:"scope"`
+	Target    string `json:"target"`
+	Password  string `json:"password"`
+	Priority  int    `json:"priority"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func toUnlockPasswordDTO(entry database.UnlockPassword) unlockPasswordDTO {
+	return unlockPasswordDTO{
+		ID:        entry.ID,
+		Scope:     entry.Scope,
+		Target:    entry.Target,
+		Password:  maskSecret(entry.Password),
+		Priority:  entry.Priority,
+		CreatedAt: formatTime(entry.CreatedAt),
+		UpdatedAt: formatTime(entry.UpdatedAt),
+	}
+}
+
+// listUnlockPasswords returns the credential vault in the order entries
+// are tried, with passwords masked.
+func (s *Server) listUnlockPasswords(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.store.ListUnlockPasswords(r.Context())
+	if err != nil {
+		s.log.Error("list unlock passwords failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to list credentials")
+		return
+	}
+	out := make([]unlockPasswordDTO, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, toUnlockPasswordDTO(entry))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createUnlockPassword(w http.ResponseWriter, r *http.Request) {
+	input, ok := decodeUnlockPasswordRequest(w, r)
+	if !ok {
+		return
+	}
+	entry, err := s.store.CreateUnlockPassword(r.Context(), input)
+	if err != nil {
+		s.writeUnlockPasswordError(w, 0, err)
+		return
+	}
+	s.log.Info("unlock password added", "id", entry.ID, "scope", entry.Scope, "target", entry.Target)
+	writeJSON(w, http.StatusCreated, toUnlockPasswordDTO(entry))
+}
+
+// updateUnlockPassword replaces an entry's scope, target and priority. The
+// password only changes when one is sent, since responses never carry it.
+func (s *Server) updateUnlockPassword(w http.ResponseWriter, r *http.Request, id int64) {
+	input, ok := decodeUnlockPasswordRequest(w, r)
+	if !ok {
+		return
+	}
+	entry, err := s.store.UpdateUnlockPassword(r.Context(), id, input)
+	if err != nil {
+		s.writeUnlockPasswordError(w, id, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUnlockPasswordDTO(entry))
+}
+
+func (s *Server) deleteUnlockPassword(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := s.store.DeleteUnlockPassword(r.Context(), id); err != nil {
+		s.writeUnlockPasswordError(w, id, err)
+		return
+	}
+	s.log.Info("unlock password deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// minerUnlockCandidates lists, masked, the passwords tried in order when
+// unlocking the miner and where each comes from.
+func (s *Server) minerUnlockCandidates(w http.ResponseWriter, r *http.Request, minerID string) {
+	ctx := r.Context()
+	miner, err := s.store.GetMiner(ctx, minerID)
+	if err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "miner not found")
+			return
+		}
+		s.log.Error("get miner failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch miner")
+		return
+	}
+
+	candidates, err := s.store.UnlockCandidates(ctx, miner)
+	if err != nil {
+		s.log.Error("list unlock candidates failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to list credentials")
+		return
+	}
+	type candidateDTO struct {
+		Source   string `json:"source"`
+		Password string `json:"password"`
+	}
+	out := make([]candidateDTO, 0, len(candidates))
+	for _, candidate := range candidates {
+		out = append(out, candidateDTO{Source: candidate.Source, Password: maskSecret(candidate.Password)})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"miner_id":   minerID,
+		"candidates": out,
+	})
+}
+
+func decodeUnlockPasswordRequest(w http.ResponseWriter, r *http.Request) (database.UnlockPasswordInput, bool) {
+	var req unlockPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return database.UnlockPasswordInput{}, false
+	}
+	if len(req.Target) > maxMinerLabelLength {
+		writeError(w, http.StatusBadRequest, "target is too long")
+		return database.UnlockPasswordInput{}, false
+	}
+	return database.UnlockPasswordInput{
+		Scope:    req.Scope,
+		Target:   req.Target,
+		Password: strings.TrimSpace(req.Password),
+		Priority: req.Priority,
+	}, true
+}
+
+// writeUnlockPasswordError maps a vault store error to a response.
+func (s *Server) writeUnlockPasswordError(w http.ResponseWriter, id int64, err error) {
+	switch {
+	case isNotFound(err):
+		writeError(w, http.StatusNotFound, "credential not found")
+	case errors.Is(err, database.ErrInvalidUnlockPassword):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		s.log.Error("credential request failed", "id", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to save credential")
+	}
+}
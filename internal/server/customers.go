@@ -0,0 +1,416 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// portalPrefix is the part of the API open to customer tokens. Everything
+// under it is scoped to the token's customer.
+const portalPrefix = "/api/portal/"
+
+type customerKey struct{}
+
+// portalCustomer returns the customer authenticated by customerScope.
+func portalCustomer(ctx context.Context) database.Customer {
+	customer, _ := ctx.Value(customerKey{}).(database.Customer)
+	return customer
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// customerScope authenticates requests under /api/portal/ with a customer
+// token and keeps customer tokens out of the rest of the API, which belongs
+// to the operator.
+func (s *Server) customerScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if !strings.HasPrefix(r.URL.Path, portalPrefix) {
+			if strings.HasPrefix(r.URL.Path, "/api/") && strings.HasPrefix(token, database.CustomerTokenPrefix) {
+				writeError(w, http.StatusForbidden, "customer tokens only grant access to "+apiVersionPrefix+"/portal/")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="powerhive"`)
+			writeError(w, http.StatusUnauthorized, "a customer token is required")
+			return
+		}
+		customer, err := s.store.CustomerForToken(r.Context(), token, time.Now())
+		if err != nil {
+			if errors.Is(err, database.ErrInvalidCustomerToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="powerhive", error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, "invalid customer token")
+				return
+			}
+			s.log.Error("customer token lookup failed", "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to check customer token")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), customerKey{}, customer)))
+	})
+}
+
+type customerRequest struct {
+	Name    string  `json:"name"`
+	Contact *string `json:"contact"`
+}
+
+type customerDTO struct {
+	ID        int64   `json:"id"`
+	Name      string  `json:"name"`
+	Contact   *string `json:"contact"`
+	Miners    int     `json:"miners"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+func toCustomerDTO(customer database.Customer) customerDTO {
+	return customerDTO{
+		ID:        customer.ID,
+		Name:      customer.Name,
+		Contact:   customer.Contact,
+		Miners:    customer.Miners,
+		CreatedAt: formatTime(customer.CreatedAt),
+		UpdatedAt: formatTime(customer.UpdatedAt),
+	}
+}
+
+type customerTokenDTO struct {
+	ID         int64   `json:"id"`
+	CustomerID int64   `json:"customer_id"`
+	Name       string  `json:"name"`
+	Prefix     string  `json:"prefix"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at"`
+	// Token is only set in the response that creates it.
+	Token string `json:"token,omitempty"`
+}
+
+func toCustomerTokenDTO(token database.CustomerToken) customerTokenDTO {
+	return customerTokenDTO{
+		ID:         token.ID,
+		CustomerID: token.CustomerID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		CreatedAt:  formatTime(token.CreatedAt),
+		LastUsedAt: formatTimePtr(token.LastUsedAt),
+	}
+}
+
+func (s *Server) listCustomers(w http.ResponseWriter, r *http.Request) {
+	customers, err := s.store.ListCustomers(r.Context())
+	if err != nil {
+		s.log.Error("list customers failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to list customers")
+		return
+	}
+	out := make([]customerDTO, 0, len(customers))
+	for _, customer := range customers {
+		out = append(out, toCustomerDTO(customer))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getCustomer(w http.ResponseWriter, r *http.Request, id int64) {
+	customer, err := s.store.GetCustomer(r.Context(), id)
+	if err != nil {
+		s.writeCustomerError(w, id, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toCustomerDTO(customer))
+}
+
+func (s *Server) createCustomer(w http.ResponseWriter, r *http.Request) {
+	input, ok := decodeCustomerRequest(w, r)
+	if !ok {
+		return
+	}
+	customer, err := s.store.CreateCustomer(r.Context(), input)
+	if err != nil {
+		s.writeCustomerError(w, 0, err)
+		return
+	}
+	s.log.Info("customer created", "customer", customer.ID, "name", customer.Name)
+	writeJSON(w, http.StatusCreated, toCustomerDTO(customer))
+}
+
+func (s *Server) updateCustomer(w http.ResponseWriter, r *http.Request, id int64) {
+	input, ok := decodeCustomerRequest(w, r)
+	if !ok {
+		return
+	}
+	customer, err := s.store.UpdateCustomer(r.Context(), id, input)
+	if err != nil {
+		s.writeCustomerError(w, id, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toCustomerDTO(customer))
+}
+
+// deleteCustomer removes the customer and its tokens; its miners become the
+// operator's own again.
+func (s *Server) deleteCustomer(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := s.store.DeleteCustomer(r.Context(), id); err != nil {
+		s.writeCustomerError(w, id, err)
+		return
+	}
+	s.log.Info("customer deleted", "customer", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeCustomerRequest(w http.ResponseWriter, r *http.Request) (database.CustomerInput, bool) {
+	var req customerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return database.CustomerInput{}, false
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return database.CustomerInput{}, false
+	}
+	if len(req.Name) > maxMinerLabelLength {
+		writeError(w, http.StatusBadRequest, "name is too long")
+		return database.CustomerInput{}, false
+	}
+	return database.CustomerInput{Name: req.Name, Contact: req.Contact}, true
+}
+
+// writeCustomerError maps a customer store error to a response.
+func (s *Server) writeCustomerError(w http.ResponseWriter, id int64, err error) {
+	switch {
+	case isNotFound(err):
+		writeError(w, http.StatusNotFound, "customer not found")
+	case errors.Is(err, database.ErrCustomerExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		s.log.Error("customer request failed", "customer", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to save customer")
+	}
+}
+
+func (s *Server) listCustomerTokens(w http.ResponseWriter, r *http.Request, id int64) {
+	ctx := r.Context()
+	if _, err := s.store.GetCustomer(ctx, id); err != nil {
+		s.writeCustomerError(w, id, err)
+		return
+	}
+	tokens, err := s.store.ListCustomerTokens(ctx, id)
+	if err != nil {
+		s.log.Error("list customer tokens failed", "customer", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to list tokens")
+		return
+	}
+	out := make([]customerTokenDTO, 0, len(tokens))
+	for _, token := range tokens {
+		out = append(out, toCustomerTokenDTO(token))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// createCustomerToken issues a token. Its value is in this response only.
+func (s *Server) createCustomerToken(w http.ResponseWriter, r *http.Request, id int64) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON payload")
+			return
+		}
+	}
+	if len(req.Name) > maxMinerLabelLength {
+		writeError(w, http.StatusBadRequest, "name is too long")
+		return
+	}
+
+	record, token, err := s.store.CreateCustomerToken(r.Context(), id, req.Name)
+	if err != nil {
+		s.writeCustomerError(w, id, err)
+		return
+	}
+	s.log.Info("customer token created", "customer", id, "token", record.Prefix)
+	out := toCustomerTokenDTO(record)
+	out.Token = token
+	writeJSON(w, http.StatusCreated, out)
+}
+
+func (s *Server) deleteCustomerToken(w http.ResponseWriter, r *http.Request, id int64) {
+	tokenID, err := strconv.ParseInt(r.PathValue("token_id"), 10, 64)
+	if err != nil || tokenID <= 0 {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.store.DeleteCustomerToken(r.Context(), id, tokenID); err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "token not found")
+			return
+		}
+		s.log.Error("delete customer token failed", "customer", id, "token", tokenID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to delete token")
+		return
+	}
+	s.log.Info("customer token revoked", "customer", id, "token", tokenID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type customerMinerAvailabilityDTO struct {
+	MinerID       string   `json:"miner_id"`
+	Name          *string  `json:"name"`
+	UptimePercent *float64 `json:"uptime_percent"`
+	IncidentCount int      `json:"incident_count"`
+}
+
+// customerAvailabilityDTO sums the availability of a customer's miners.
+type customerAvailabilityDTO struct {
+	From             string                         `json:"from"`
+	To               string                         `json:"to"`
+	UptimePercent    *float64                       `json:"uptime_percent"`
+	OnlineSeconds    float64                        `json:"online_seconds"`
+	OfflineSeconds   float64                        `json:"offline_seconds"`
+	UntrackedSeconds float64                        `json:"untracked_seconds"`
+	IncidentCount    int                            `json:"incident_count"`
+	Miners           []customerMinerAvailabilityDTO `json:"miners"`
+}
+
+type customerSummaryDTO struct {
+	Customer     customerDTO             `json:"customer"`
+	Fleet        fleetSummaryDTO         `json:"fleet"`
+	Availability customerAvailabilityDTO `json:"availability"`
+	GeneratedAt  string                  `json:"generated_at"`
+}
+
+// handleCustomerSummary reports a customer's current hashrate and
+// consumption and the availability of its miners between from and to.
+func (s *Server) handleCustomerSummary(w http.ResponseWriter, r *http.Request, id int64) {
+	customer, err := s.store.GetCustomer(r.Context(), id)
+	if err != nil {
+		s.writeCustomerError(w, id, err)
+		return
+	}
+	s.writeCustomerSummary(w, r, customer)
+}
+
+func (s *Server) writeCustomerSummary(w http.ResponseWriter, r *http.Request, customer database.Customer) {
+	ctx := r.Context()
+	now := time.Now().UTC()
+	query := r.URL.Query()
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if to.After(now) {
+		to = now
+	}
+	if !from.Before(to) {
+		writeError(w, http.StatusBadRequest, "from must be in the past")
+		return
+	}
+
+	fleet, err := s.store.CustomerFleetSummary(ctx, customer.ID, now)
+	if err != nil {
+		s.log.Error("customer fleet summary failed", "customer", customer.ID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to build customer summary")
+		return
+	}
+	miners, err := s.store.ListCustomerMiners(ctx, customer.ID)
+	if err != nil {
+		s.log.Error("list customer miners failed", "customer", customer.ID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to build customer summary")
+		return
+	}
+
+	availability := customerAvailabilityDTO{
+		From:   formatTime(from),
+		To:     formatTime(to),
+		Miners: make([]customerMinerAvailabilityDTO, 0, len(miners)),
+	}
+	for _, miner := range miners {
+		changes, err := s.store.ListAvailabilityChanges(ctx, miner.ID, from, to)
+		if err != nil {
+			s.log.Error("list availability changes failed", "miner", miner.ID, "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to build customer summary")
+			return
+		}
+		report := buildAvailability(miner.ID, from, to, changes)
+		availability.OnlineSeconds += report.OnlineSeconds
+		availability.OfflineSeconds += report.OfflineSeconds
+		availability.UntrackedSeconds += report.UntrackedSeconds
+		availability.IncidentCount += report.IncidentCount
+		availability.Miners = append(availability.Miners, customerMinerAvailabilityDTO{
+			MinerID:       miner.ID,
+			Name:          miner.Name,
+			UptimePercent: report.UptimePercent,
+			IncidentCount: report.IncidentCount,
+		})
+	}
+	if tracked := availability.OnlineSeconds + availability.OfflineSeconds; tracked > 0 {
+		uptime := availability.OnlineSeconds / tracked * 100
+		availability.UptimePercent = &uptime
+	}
+
+	writeJSON(w, http.StatusOK, customerSummaryDTO{
+		Customer:     toCustomerDTO(customer),
+		Fleet:        toFleetSummaryDTO(fleet),
+		Availability: availability,
+		GeneratedAt:  formatTime(now),
+	})
+}
+
+// Portal handlers serve the customer authenticated by customerScope.
+
+func (s *Server) portalSummary(w http.ResponseWriter, r *http.Request) {
+	s.writeCustomerSummary(w, r, portalCustomer(r.Context()))
+}
+
+func (s *Server) portalMiners(w http.ResponseWriter, r *http.Request) {
+	customer := portalCustomer(r.Context())
+	miners, err := s.store.ListCustomerMiners(r.Context(), customer.ID)
+	if err != nil {
+		s.log.Error("list customer miners failed", "customer", customer.ID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to list miners")
+		return
+	}
+	out := make([]minerDTO, 0, len(miners))
+	for _, miner := range miners {
+		out = append(out, s.toMinerDTO(miner))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// portalMiner adapts a per-miner handler to the portal. Miners of other
+// customers are not found.
+func (s *Server) portalMiner(fn func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return withMinerID(func(w http.ResponseWriter, r *http.Request, minerID string) {
+		customer := portalCustomer(r.Context())
+		miner, err := s.store.GetMiner(r.Context(), minerID)
+		if err != nil && !isNotFound(err) {
+			s.log.Error("get miner failed", "miner", minerID, "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to fetch miner")
+			return
+		}
+		if err != nil || miner.CustomerID == nil || *miner.CustomerID != customer.ID || miner.ArchivedAt != nil {
+			writeError(w, http.StatusNotFound, "miner not found")
+			return
+		}
+		fn(w, r, minerID)
+	})
+}
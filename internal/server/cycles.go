@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+type balanceCycleDTO struct {
+	ID                 int64          `json:"id"`
+	Mode               string         `json:"mode"`
+	GenerationKW       float64        `json:"generation_kw"`
+	TargetPowerW       float64        `json:"target_power_w"`
+	ConsumptionBeforeW float64        `json:"consumption_before_w"`
+	ConsumptionAfterW  float64        `json:"consumption_after_w"`
+	MinersEligible     int            `json:"miners_eligible"`
+	MinersAdjusted     int            `json:"miners_adjusted"`
+	MinersFailed       int            `json:"miners_failed"`
+	Skipped            map[string]int `json:"skipped"`
+	RecordedAt         string         `json:"recorded_at"`
+}
+
+// balanceCycleDetailDTO adds the per-miner skip reasons to a cycle.
+type balanceCycleDetailDTO struct {
+	balanceCycleDTO
+	SkippedMiners []balanceCycleSkipDTO `json:"skipped_miners"`
+}
+
+type balanceCycleSkipDTO struct {
+	MinerID string `json:"miner_id"`
+	Reason  string `json:"reason"`
+}
+
+func toBalanceCycleDTO(cycle database.BalanceCycle) balanceCycleDTO {
+	skipped := cycle.Skipped
+	if skipped == nil {
+		skipped = map[string]int{}
+	}
+	return balanceCycleDTO{
+		ID:                 cycle.ID,
+		Mode:               cycle.Mode,
+		GenerationKW:       cycle.GenerationKW,
+		TargetPowerW:       cycle.TargetPowerW,
+		ConsumptionBeforeW: cycle.ConsumptionBeforeW,
+		ConsumptionAfterW:  cycle.ConsumptionAfterW,
+		MinersEligible:     cycle.MinersEligible,
+		MinersAdjusted:     cycle.MinersAdjusted,
+		MinersFailed:       cycle.MinersFailed,
+		Skipped:            skipped,
+		RecordedAt:         formatTime(cycle.RecordedAt),
+	}
+}
+
+// handleBalanceCycles lists balance cycle summaries, newest first. Without
+// from or to the latest cycles are returned; with either, the range is read
+// like the exports.
+func (s *Server) handleBalanceCycles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 100
+	if raw := query.Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	var from, to time.Time
+	if strings.TrimSpace(query.Get("from")) != "" || strings.TrimSpace(query.Get("to")) != "" {
+		var err error
+		from, to, err = parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	cycles, err := s.store.ListBalanceCycles(r.Context(), from, to, limit)
+	if err != nil {
+		s.log.Error("list balance cycles failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch balance cycles")
+		return
+	}
+
+	out := make([]balanceCycleDTO, 0, len(cycles))
+	for _, cycle := range cycles {
+		out = append(out, toBalanceCycleDTO(cycle))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleBalanceCycle returns one cycle with the reason each skipped miner
+// was left alone.
+func (s *Server) handleBalanceCycle(w http.ResponseWriter, r *http.Request, id int64) {
+	cycle, err := s.store.GetBalanceCycle(r.Context(), id)
+	if err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "balance cycle not found")
+			return
+		}
+		s.log.Error("get balance cycle failed", "cycle", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch balance cycle")
+		return
+	}
+
+	out := balanceCycleDetailDTO{
+		balanceCycleDTO: toBalanceCycleDTO(cycle),
+		SkippedMiners:   make([]balanceCycleSkipDTO, 0, len(cycle.SkippedMiners)),
+	}
+	for _, skip := range cycle.SkippedMiners {
+		out.SkippedMiners = append(out.SkippedMiners, balanceCycleSkipDTO{MinerID: skip.MinerID, Reason: skip.Reason})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+const (
+	dashboardAlertWindow  = 24 * time.Hour
+	dashboardAlertLimit   = 10
+	dashboardRankingLimit = 5
+)
+
+// dashboardDTO bundles what the landing page shows so it loads with one
+// request.
+type dashboardDTO struct {
+	Balance              balanceStatusDTO  `json:"balance"`
+	Fleet                fleetSummaryDTO   `json:"fleet"`
+	Plant                *plantReadingDTO  `json:"plant"`
+	Alerts               []alertDTO        `json:"alerts"`
+	HottestMiners        []minerRankingDTO `json:"hottest_miners"`
+	LeastEfficientMiners []minerRankingDTO `json:"least_efficient_miners"`
+	GeneratedAt          string            `json:"generated_at"`
+}
+
+type fleetSummaryDTO struct {
+	Miners        int      `json:"miners"`
+	Online        int      `json:"online"`
+	Offline       int      `json:"offline"`
+	Managed       int      `json:"managed"`
+	Held          int      `json:"held"`
+	PowerW        float64  `json:"power_w"`
+	HashrateTH    float64  `json:"hashrate_th"`
+	EfficiencyJTH *float64 `json:"efficiency_j_th"`
+}
+
+type alertDTO struct {
+	Source     string  `json:"source"`
+	MinerID    string  `json:"miner_id"`
+	Kind       string  `json:"kind"`
+	Message    *string `json:"message"`
+	RecordedAt string  `json:"recorded_at"`
+}
+
+// minerRankingDTO is one miner in a worst-first list. Value is the hottest
+// chip in °C or the efficiency in J/TH.
+type minerRankingDTO struct {
+	MinerID string  `json:"miner_id"`
+	Name    *string `json:"name"`
+	Value   float64 `json:"value"`
+}
+
+// handleDashboard returns the balance status, fleet totals, recent alerts,
+// the last plant reading and the worst miners by temperature and
+// efficiency. Each part is computed with a single aggregate query.
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now()
+	fail := func(what string, err error) {
+		s.log.Error("dashboard query failed", "part", what, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch dashboard")
+	}
+
+	reading, err := s.store.GetLatestPlantReading(ctx)
+	if err != nil {
+		fail("plant", err)
+		return
+	}
+	fleet, err := s.store.FleetSummary(ctx, now)
+	if err != nil {
+		fail("fleet", err)
+		return
+	}
+	alerts, err := s.store.ListAlerts(ctx, now.Add(-dashboardAlertWindow), dashboardAlertLimit)
+	if err != nil {
+		fail("alerts", err)
+		return
+	}
+	hottest, err := s.store.HottestMiners(ctx, dashboardRankingLimit)
+	if err != nil {
+		fail("hottest miners", err)
+		return
+	}
+	inefficient, err := s.store.LeastEfficientMiners(ctx, dashboardRankingLimit)
+	if err != nil {
+		fail("least efficient miners", err)
+		return
+	}
+
+	out := dashboardDTO{
+		Balance:              s.balanceStatus(ctx, reading, fleet),
+		Fleet:                toFleetSummaryDTO(fleet),
+		Alerts:               make([]alertDTO, 0, len(alerts)),
+		HottestMiners:        toMinerRankingDTOs(hottest),
+		LeastEfficientMiners: toMinerRankingDTOs(inefficient),
+		GeneratedAt:          formatTime(now),
+	}
+	if reading != nil {
+		plant := toPlantReadingDTO(*reading)
+		out.Plant = &plant
+	}
+	for _, alert := range alerts {
+		out.Alerts = append(out.Alerts, alertDTO{
+			Source:     alert.Source,
+			MinerID:    alert.MinerID,
+			Kind:       alert.Kind,
+			Message:    alert.Message,
+			RecordedAt: formatTime(alert.RecordedAt),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+func toFleetSummaryDTO(fleet database.FleetSummary) fleetSummaryDTO {
+	dto := fleetSummaryDTO{
+		Miners:     fleet.Miners,
+		Online:     fleet.Online,
+		Offline:    fleet.Miners - fleet.Online,
+		Managed:    fleet.Managed,
+		Held:       fleet.Held,
+		PowerW:     fleet.ManagedPowerW + fleet.UnmanagedPowerW,
+		HashrateTH: fleet.HashrateGH / 1000,
+	}
+	if dto.HashrateTH > 0 && dto.PowerW > 0 {
+		efficiency := dto.PowerW / dto.HashrateTH
+		dto.EfficiencyJTH = &efficiency
+	}
+	return dto
+}
+
+func toMinerRankingDTOs(ranking []database.MinerRanking) []minerRankingDTO {
+	out := make([]minerRankingDTO, 0, len(ranking))
+	for _, entry := range ranking {
+		out = append(out, minerRankingDTO{MinerID: entry.MinerID, Name: entry.Name, Value: entry.Value})
+	}
+	return out
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// maxDREventDuration bounds a single demand-response event.
+const maxDREventDuration = 7 * 24 * time.Hour
+
+// Demand-response event states reported by the API.
+const (
+	drStatusScheduled = "scheduled"
+	drStatusActive    = "active"
+	drStatusCompleted = "completed"
+	drStatusCancelled = "cancelled"
+)
+
+type drEventDTO struct {
+	ID          int64           `json:"id"`
+	Source      string          `json:"source"`
+	Reason      *string         `json:"reason,omitempty"`
+	StartsAt    string          `json:"starts_at"`
+	EndsAt      string          `json:"ends_at"`
+	MaxLoadKW   float64         `json:"max_load_kw"`
+	Status      string          `json:"status"`
+	CancelledAt *string         `json:"cancelled_at,omitempty"`
+	Compliance  drComplianceDTO `json:"compliance"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// drComplianceDTO summarises the balance cycles sampled during an event
+// and the load integrated over the part of it that has elapsed. Compliant
+// requires every sample to be within the cap and, once plant readings cover
+// at least drLoadCoverageThreshold of the event, the time-weighted average
+// load as well; it is nil until there is either.
+type drComplianceDTO struct {
+	Samples           int      `json:"samples"`
+	Violations        int      `json:"violations"`
+	PeakLoadKW        *float64 `json:"peak_load_kw"`
+	CompliancePercent *float64 `json:"compliance_percent"`
+	EnergyKWh         *float64 `json:"energy_kwh"`
+	AverageLoadKW     *float64 `json:"average_load_kw"`
+	LoadCoverage      float64  `json:"load_coverage"`
+	Compliant         *bool    `json:"compliant"`
+}
+
+func toDREventDTO(event database.DREvent, now time.Time) drEventDTO {
+	status := drStatusScheduled
+	switch {
+	case event.CancelledAt != nil:
+		status = drStatusCancelled
+	case !now.Before(event.EndsAt):
+		status = drStatusCompleted
+	case event.Active(now):
+		status = drStatusActive
+	}
+
+	compliance := drComplianceDTO{
+		Samples:    event.Samples,
+		Violations: event.Violations,
+		PeakLoadKW: event.PeakLoadKW,
+	}
+	if event.Samples > 0 {
+		percent := math.Round(float64(event.Samples-event.Violations)/float64(event.Samples)*1000) / 10
+		compliant := event.Violations == 0
+		compliance.CompliancePercent = &percent
+		compliance.Compliant = &compliant
+	}
+
+	return drEventDTO{
+		ID:          event.ID,
+		Source:      event.Source,
+		Reason:      event.Reason,
+		StartsAt:    formatTime(event.StartsAt),
+		EndsAt:      formatTime(event.EndsAt),
+		MaxLoadKW:   event.MaxLoadKW,
+		Status:      status,
+		CancelledAt: formatTimePtr(event.CancelledAt),
+		Compliance:  compliance,
+		CreatedAt:   formatTime(event.CreatedAt),
+	}
+}
+
+// drLoadCoverageThreshold is the fraction of an event plant readings must
+// cover before its average load counts towards compliance.
+const drLoadCoverageThreshold = 0.9
+
+// drEventDTO renders event with its load integrated from the plant's
+// container consumption, the figure the balancer measures against the cap,
+// between its start and now, its end or its cancellation.
+func (s *Server) drEventDTO(ctx context.Context, event database.DREvent, now time.Time) drEventDTO {
+	dto := toDREventDTO(event, now)
+
+	end := now
+	if event.EndsAt.Before(end) {
+		end = event.EndsAt
+	}
+	if event.CancelledAt != nil && event.CancelledAt.Before(end) {
+		end = *event.CancelledAt
+	}
+	if !event.StartsAt.Before(end) {
+		return dto
+	}
+
+	energy, err := s.store.IntegratePlantEnergy(ctx, event.StartsAt, end, reportMaxGap)
+	if err != nil {
+		s.log.Warn("integrate demand response load failed", "event", event.ID, "err", err)
+		return dto
+	}
+	load := energy.Container
+	avg := load.AverageKW()
+	if avg == nil {
+		return dto
+	}
+	kwh := roundKWh(load.KWh)
+	avgKW := math.Round(*avg*1000) / 1000
+	dto.Compliance.EnergyKWh = &kwh
+	dto.Compliance.AverageLoadKW = &avgKW
+	dto.Compliance.LoadCoverage = math.Round(load.Coverage()*1000) / 1000
+	if load.Coverage() >= drLoadCoverageThreshold {
+		compliant := *avg <= event.MaxLoadKW && event.Violations == 0
+		dto.Compliance.Compliant = &compliant
+	}
+	return dto
+}
+
+func (s *Server) getDREvent(w http.ResponseWriter, r *http.Request, id int64) {
+	event, err := s.store.GetDREvent(r.Context(), id)
+	s.writeDREvent(w, r, id, event, err)
+}
+
+func (s *Server) cancelDREvent(w http.ResponseWriter, r *http.Request, id int64) {
+	event, err := s.store.CancelDREvent(r.Context(), id)
+	if err == nil {
+		s.log.Info("demand response event cancelled", "event", id)
+	}
+	s.writeDREvent(w, r, id, event, err)
+}
+
+func (s *Server) writeDREvent(w http.ResponseWriter, r *http.Request, id int64, event database.DREvent, err error) {
+	if err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "event not found")
+			return
+		}
+		s.log.Error("demand response event request failed", "event", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch event")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.drEventDTO(r.Context(), event, time.Now()))
+}
+
+func (s *Server) listDREvents(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	events, err := s.store.ListDREvents(r.Context(), limit)
+	if err != nil {
+		s.log.Error("list demand response events failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch events")
+		return
+	}
+
+	now := time.Now()
+	out := make([]drEventDTO, 0, len(events))
+	for _, event := range events {
+		out = append(out, s.drEventDTO(r.Context(), event, now))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// createDREvent schedules an event from starts_at (default now) to ends_at,
+// or for duration_minutes.
+func (s *Server) createDREvent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		StartsAt        *time.Time `json:"starts_at"`
+		EndsAt          *time.Time `json:"ends_at"`
+		DurationMinutes *int       `json:"duration_minutes"`
+		MaxLoadKW       *float64   `json:"max_load_kw"`
+		Source          string     `json:"source"`
+		Reason          *string    `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	if req.MaxLoadKW == nil || *req.MaxLoadKW < 0 {
+		writeError(w, http.StatusBadRequest, "max_load_kw is required and must not be negative")
+		return
+	}
+	if (req.EndsAt == nil) == (req.DurationMinutes == nil) {
+		writeError(w, http.StatusBadRequest, "provide exactly one of ends_at or duration_minutes")
+		return
+	}
+
+	now := time.Now().UTC()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = req.StartsAt.UTC()
+	}
+	var endsAt time.Time
+	if req.EndsAt != nil {
+		endsAt = req.EndsAt.UTC()
+	} else {
+		if *req.DurationMinutes <= 0 {
+			writeError(w, http.StatusBadRequest, "duration_minutes must be positive")
+			return
+		}
+		endsAt = startsAt.Add(time.Duration(*req.DurationMinutes) * time.Minute)
+	}
+
+	if !endsAt.After(startsAt) {
+		writeError(w, http.StatusBadRequest, "ends_at must be after starts_at")
+		return
+	}
+	if !endsAt.After(now) {
+		writeError(w, http.StatusBadRequest, "event has already ended")
+		return
+	}
+	if endsAt.Sub(startsAt) > maxDREventDuration {
+		writeError(w, http.StatusBadRequest, "events may last at most 7 days")
+		return
+	}
+	if len(req.Source) > 64 {
+		writeError(w, http.StatusBadRequest, "source must be at most 64 characters")
+		return
+	}
+	if req.Reason != nil && len(*req.Reason) > 512 {
+		writeError(w, http.StatusBadRequest, "reason must be at most 512 characters")
+		return
+	}
+
+	event, err := s.store.CreateDREvent(r.Context(), database.DREventInput{
+		Source:    req.Source,
+		Reason:    req.Reason,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		MaxLoadKW: *req.MaxLoadKW,
+	})
+	if err != nil {
+		s.log.Error("create demand response event failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to create event")
+		return
+	}
+
+	s.log.Info("demand response event scheduled",
+		"event", event.ID,
+		"source", event.Source,
+		"starts_at", event.StartsAt,
+		"ends_at", event.EndsAt,
+		"max_load_kw", event.MaxLoadKW,
+	)
+	writeJSON(w, http.StatusCreated, s.drEventDTO(r.Context(), event, now))
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrScanInProgress is returned by DiscoveryService.TriggerScan when a scan is
+// already running or queued.
+var ErrScanInProgress = errors.New("discovery scan already in progress")
+
+// DiscoveryService is implemented by the network discoverer.
+type DiscoveryService interface {
+	// TriggerScan queues an immediate scan. An empty subnet scans every
+	// configured subnet.
+	TriggerScan(subnet string) error
+	ScanStatus() DiscoveryStatus
+}
+
+// DiscoveryStatus reports the discoverer's current and most recent scan.
+type DiscoveryStatus struct {
+	Running  bool
+	Pending  bool
+	LastScan *DiscoveryScan
+}
+
+// DiscoveryScan summarises a completed scan.
+type DiscoveryScan struct {
+	Trigger     string
+	Subnet      string
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Duration    time.Duration
+	HostsProbed int
+	HostsAlive  int
+	MinersFound int
+	MinersLost  int
+	Error       string
+}
+
+// WithDiscovery enables the discovery trigger and status endpoints.
+func WithDiscovery(d DiscoveryService) Option {
+	return func(s *Server) {
+		s.discovery = d
+	}
+}
+
+func (s *Server) handleDiscoveryScan(w http.ResponseWriter, r *http.Request) {
+	if s.discovery == nil {
+		writeError(w, http.StatusServiceUnavailable, "discovery is not available")
+		return
+	}
+
+	var req struct {
+		Subnet string `json:"subnet"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	subnet := strings.TrimSpace(req.Subnet)
+	if subnet == "" {
+		subnet = strings.TrimSpace(r.URL.Query().Get("subnet"))
+	}
+
+	if err := s.discovery.TriggerScan(subnet); err != nil {
+		if errors.Is(err, ErrScanInProgress) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.log.Info("discovery scan triggered", "subnet", subnet)
+	writeJSON(w, http.StatusAccepted, toDiscoveryStatusDTO(s.discovery.ScanStatus()))
+}
+
+func (s *Server) handleDiscoveryStatus(w http.ResponseWriter, r *http.Request) {
+	if s.discovery == nil {
+		writeError(w, http.StatusServiceUnavailable, "discovery is not available")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toDiscoveryStatusDTO(s.discovery.ScanStatus()))
+}
+
+type discoveryStatusDTO struct {
+	Running  bool              `json:"running"`
+	Pending  bool              `json:"pending"`
+	LastScan *discoveryScanDTO `json:"last_scan"`
+}
+
+type discoveryScanDTO struct {
+	Trigger     string  `json:"trigger"`
+	Subnet      *string `json:"subnet"`
+	StartedAt   string  `json:"started_at"`
+	FinishedAt  string  `json:"finished_at"`
+	DurationMs  int64   `json:"duration_ms"`
+	HostsProbed int     `json:"hosts_probed"`
+	HostsAlive  int     `json:"hosts_alive"`
+	MinersFound int     `json:"miners_found"`
+	MinersLost  int     `json:"miners_lost"`
+	Error       *string `json:"error"`
+}
+
+func toDiscoveryStatusDTO(status DiscoveryStatus) discoveryStatusDTO {
+	dto := discoveryStatusDTO{
+		Running: status.Running,
+		Pending: status.Pending,
+	}
+
+	if scan := status.LastScan; scan != nil {
+		last := discoveryScanDTO{
+			Trigger:     scan.Trigger,
+			StartedAt:   formatTime(scan.StartedAt),
+			FinishedAt:  formatTime(scan.FinishedAt),
+			DurationMs:  scan.Duration.Milliseconds(),
+			HostsProbed: scan.HostsProbed,
+			HostsAlive:  scan.HostsAlive,
+			MinersFound: scan.MinersFound,
+			MinersLost:  scan.MinersLost,
+		}
+		if scan.Subnet != "" {
+			subnet := scan.Subnet
+			last.Subnet = &subnet
+		}
+		if scan.Error != "" {
+			msg := scan.Error
+			last.Error = &msg
+		}
+		dto.LastScan = &last
+	}
+
+	return dto
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"powerhive/internal/economics"
+)
+
+func (s *Server) getEconomicsSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := economics.Load(r.Context(), s.store)
+	if err != nil {
+		s.log.Error("load economics settings failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load economics settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) updateEconomicsSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		BTCPriceUSD          *float64                  `json:"btc_price_usd"`
+		HashpriceUSDPerTHDay *float64                  `json:"hashprice_usd_per_th_day"`
+		EnergyCostUSDPerKWh  *float64                  `json:"energy_cost_usd_per_kwh"`
+		TariffPeriods        *[]economics.TariffPeriod `json:"tariff_periods"`
+		TariffTimezone       *string                   `json:"tariff_timezone"`
+		MinProfitablePreset  *bool                     `json:"min_profitable_preset"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	for _, value := range []*float64{req.BTCPriceUSD, req.HashpriceUSDPerTHDay, req.EnergyCostUSDPerKWh} {
+		if value != nil && *value < 0 {
+			writeError(w, http.StatusBadRequest, "prices and costs must not be negative")
+			return
+		}
+	}
+
+	settings, err := economics.Load(ctx, s.store)
+	if err != nil {
+		s.log.Error("load economics settings failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load economics settings")
+		return
+	}
+
+	if req.BTCPriceUSD != nil {
+		settings.BTCPriceUSD = req.BTCPriceUSD
+		settings.Source = economics.SourceManual
+	}
+	if req.HashpriceUSDPerTHDay != nil {
+		settings.HashpriceUSDPerTHDay = req.HashpriceUSDPerTHDay
+		settings.Source = economics.SourceManual
+	}
+	if req.EnergyCostUSDPerKWh != nil {
+		settings.EnergyCostUSDPerKWh = req.EnergyCostUSDPerKWh
+	}
+	if req.TariffPeriods != nil {
+		settings.TariffPeriods = *req.TariffPeriods
+	}
+	if req.TariffTimezone != nil {
+		settings.TariffTimezone = strings.TrimSpace(*req.TariffTimezone)
+	}
+	if req.MinProfitablePreset != nil {
+		settings.MinProfitablePreset = *req.MinProfitablePreset
+	}
+	if _, err := settings.Tariff(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := economics.Save(ctx, s.store, settings); err != nil {
+		s.log.Error("save economics settings failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to save economics settings")
+		return
+	}
+
+	settings, err = economics.Load(ctx, s.store)
+	if err != nil {
+		s.log.Error("reload economics settings failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load economics settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+type minerEstimateDTO struct {
+	MinerID string  `json:"miner_id"`
+	Model   *string `json:"model,omitempty"`
+	Preset  *string `json:"preset,omitempty"`
+	economics.Estimate
+}
+
+type presetEstimateDTO struct {
+	Model      string `json:"model"`
+	Preset     string `json:"preset"`
+	Profitable bool   `json:"profitable"`
+	economics.Estimate
+}
+
+func (s *Server) handleEconomicsEstimates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	settings, err := economics.Load(ctx, s.store)
+	if err != nil {
+		s.log.Error("load economics settings failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load economics settings")
+		return
+	}
+
+	miners, err := s.store.ListMiners(ctx)
+	if err != nil {
+		s.log.Error("list miners failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch miners")
+		return
+	}
+	presets, err := s.store.GetAllModelPresets(ctx)
+	if err != nil {
+		s.log.Error("list model presets failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch model presets")
+		return
+	}
+
+	minerOut := make([]minerEstimateDTO, 0, len(miners))
+	var fleet economics.Estimate
+	for _, miner := range miners {
+		status := miner.LatestStatus
+		if status == nil || status.Hashrate == nil || status.PowerConsumption == nil {
+			continue
+		}
+		// Statuses report hashrate in GH/s.
+		estimate, ok := settings.Estimate(*status.Hashrate/1000, *status.PowerConsumption)
+		if !ok {
+			continue
+		}
+
+		dto := minerEstimateDTO{MinerID: miner.ID, Preset: status.Preset, Estimate: estimate}
+		if miner.Model != nil {
+			dto.Model = &miner.Model.Alias
+		}
+		minerOut = append(minerOut, dto)
+
+		fleet.HashrateTH += estimate.HashrateTH
+		fleet.PowerW += estimate.PowerW
+		fleet.RevenueUSD += estimate.RevenueUSD
+		fleet.CostUSD += estimate.CostUSD
+		fleet.ProfitUSD += estimate.ProfitUSD
+	}
+
+	presetOut := make([]presetEstimateDTO, 0)
+	for alias, list := range presets {
+		for _, preset := range list {
+			if preset.ExpectedHashrateTH == nil || preset.ExpectedPowerW == nil {
+				continue
+			}
+			estimate, ok := settings.Estimate(*preset.ExpectedHashrateTH, *preset.ExpectedPowerW)
+			if !ok {
+				continue
+			}
+			presetOut = append(presetOut, presetEstimateDTO{
+				Model:      alias,
+				Preset:     preset.Value,
+				Profitable: estimate.ProfitUSD >= 0,
+				Estimate:   estimate,
+			})
+		}
+	}
+	sort.Slice(presetOut, func(i, j int) bool {
+		if presetOut[i].Model != presetOut[j].Model {
+			return presetOut[i].Model < presetOut[j].Model
+		}
+		return presetOut[i].PowerW < presetOut[j].PowerW
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings": settings,
+		"complete": settings.Complete(),
+		"fleet":    fleet,
+		"miners":   minerOut,
+		"presets":  presetOut,
+	})
+}
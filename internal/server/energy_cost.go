@@ -0,0 +1,314 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+	"powerhive/internal/economics"
+)
+
+// addMinerCost integrates like addMiner, prices the energy at the tariff
+// rate in force at the start of each part and counts the time as covered.
+func (e *energyReport) addMinerCost(prev, next database.StatusPower, tariff *economics.Tariff) {
+	seg, ok := minerSegment(prev, next)
+	if !ok {
+		return
+	}
+	e.spread(seg, func(day *energyDay, part database.PowerSegment) {
+		rate := 0.0
+		if tariff != nil {
+			rate = tariff.Rate(part.Start)
+		}
+		kwh := part.KWh()
+		day.miners += kwh
+		day.cost += kwh * rate
+		day.covered += part.Duration()
+	})
+}
+
+type minerEnergyDayDTO struct {
+	Date string  `json:"date,omitempty"`
+	KWh  float64 `json:"kwh"`
+	// CostUSD is nil when no energy cost is configured.
+	CostUSD *float64 `json:"cost_usd"`
+	// Coverage is the fraction of the period backed by power readings.
+	Coverage float64 `json:"coverage"`
+}
+
+type minerEnergyDTO struct {
+	MinerID       string              `json:"miner_id"`
+	Name          *string             `json:"name,omitempty"`
+	CustomerID    *int64              `json:"customer_id"`
+	From          string              `json:"from,omitempty"`
+	To            string              `json:"to,omitempty"`
+	Timezone      string              `json:"timezone,omitempty"`
+	MaxGapSeconds int                 `json:"max_gap_seconds,omitempty"`
+	Days          []minerEnergyDayDTO `json:"days"`
+	Totals        minerEnergyDayDTO   `json:"totals"`
+}
+
+// minerEnergyDTO renders the report of a single miner. Only the miner
+// fields of its days are used.
+func (e *energyReport) minerEnergyDTO(miner database.Miner, priced bool) minerEnergyDTO {
+	out := minerEnergyDTO{
+		MinerID:       miner.ID,
+		Name:          miner.Name,
+		CustomerID:    miner.CustomerID,
+		From:          formatTime(e.from),
+		To:            formatTime(e.to),
+		Timezone:      e.loc.String(),
+		MaxGapSeconds: int(reportMaxGap / time.Second),
+		Days:          make([]minerEnergyDayDTO, 0, len(e.days)),
+	}
+
+	var totals energyDay
+	for _, day := range e.days {
+		out.Days = append(out.Days, day.minerDTO(day.date.Format(time.DateOnly), priced))
+		totals.add(day)
+	}
+	out.Totals = totals.minerDTO("", priced)
+	return out
+}
+
+func (d *energyDay) add(other *energyDay) {
+	d.miners += other.miners
+	d.cost += other.cost
+	d.covered += other.covered
+	d.length += other.length
+}
+
+func (d *energyDay) minerDTO(date string, priced bool) minerEnergyDayDTO {
+	coverage := 0.0
+	if d.length > 0 {
+		coverage = math.Min(1, d.covered.Seconds()/d.length.Seconds())
+	}
+	out := minerEnergyDayDTO{
+		Date:     date,
+		KWh:      roundKWh(d.miners),
+		Coverage: math.Round(coverage*1000) / 1000,
+	}
+	if priced {
+		cost := math.Round(d.cost*100) / 100
+		out.CostUSD = &cost
+	}
+	return out
+}
+
+// loadTariff returns the configured tariff, nil when no energy cost is set.
+func (s *Server) loadTariff(r *http.Request) (*economics.Tariff, error) {
+	settings, err := economics.Load(r.Context(), s.store)
+	if err != nil {
+		return nil, err
+	}
+	return settings.Tariff()
+}
+
+// handleMinerEnergy reports the energy a miner consumed per day between
+// from and to, priced at the economics tariff.
+func (s *Server) handleMinerEnergy(w http.ResponseWriter, r *http.Request, minerID string) {
+	params, ok := parseReportQuery(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	miner, err := s.store.GetMiner(ctx, minerID)
+	if err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "miner not found")
+			return
+		}
+		s.log.Error("get miner failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch miner")
+		return
+	}
+	tariff, err := s.loadTariff(r)
+	if err != nil {
+		s.log.Error("load tariff failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load energy tariff")
+		return
+	}
+
+	report := newEnergyReport(params.from, params.to, params.loc)
+	var prev *database.StatusPower
+	err = s.store.ForEachMinerStatusPower(ctx, minerID, params.from.Add(-reportMaxGap), params.to.Add(reportMaxGap), func(sample database.StatusPower) error {
+		if prev != nil {
+			report.addMinerCost(*prev, sample, tariff)
+		}
+		prev = &sample
+		return nil
+	})
+	if err != nil {
+		s.log.Error("miner energy statuses failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to build energy report")
+		return
+	}
+
+	out := report.minerEnergyDTO(miner, tariff != nil)
+	if params.format != "csv" {
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	name := fmt.Sprintf("powerhive-energy-%s-%s-%s.csv", strings.ReplaceAll(minerID, ":", ""),
+		params.from.In(params.loc).Format("20060102"), params.to.In(params.loc).Format("20060102"))
+	csvOut := startCSVExport(w, name)
+	_ = csvOut.write(minerEnergyCSVHeader)
+	writeMinerEnergyCSV(csvOut, out, true)
+	if err := csvOut.finish(); err != nil {
+		s.log.Error("write miner energy failed", "miner", minerID, "err", err)
+	}
+}
+
+type customerEnergyDTO struct {
+	Customer      customerDTO         `json:"customer"`
+	From          string              `json:"from"`
+	To            string              `json:"to"`
+	Timezone      string              `json:"timezone"`
+	MaxGapSeconds int                 `json:"max_gap_seconds"`
+	Miners        []minerEnergyDTO    `json:"miners"`
+	Days          []minerEnergyDayDTO `json:"days"`
+	Totals        minerEnergyDayDTO   `json:"totals"`
+}
+
+// handleCustomerEnergy is the invoicing export for a hosting customer.
+func (s *Server) handleCustomerEnergy(w http.ResponseWriter, r *http.Request, id int64) {
+	customer, err := s.store.GetCustomer(r.Context(), id)
+	if err != nil {
+		s.writeCustomerError(w, id, err)
+		return
+	}
+	s.writeCustomerEnergy(w, r, customer)
+}
+
+// writeCustomerEnergy reports the energy and cost of the miners currently
+// assigned to the customer, per miner and day. Energy is attributed by the
+// current assignment, so move miners between customers after invoicing.
+func (s *Server) writeCustomerEnergy(w http.ResponseWriter, r *http.Request, customer database.Customer) {
+	params, ok := parseReportQuery(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	miners, err := s.store.ListCustomerMiners(ctx, customer.ID)
+	if err != nil {
+		s.log.Error("list customer miners failed", "customer", customer.ID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to build energy report")
+		return
+	}
+	tariff, err := s.loadTariff(r)
+	if err != nil {
+		s.log.Error("load tariff failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load energy tariff")
+		return
+	}
+
+	reports := make(map[string]*energyReport, len(miners))
+	for _, miner := range miners {
+		reports[miner.ID] = newEnergyReport(params.from, params.to, params.loc)
+	}
+	prevStatus := make(map[string]database.StatusPower)
+	err = s.store.ForEachStatusPower(ctx, params.from.Add(-reportMaxGap), params.to.Add(reportMaxGap), func(sample database.StatusPower) error {
+		report, ok := reports[sample.MinerID]
+		if !ok {
+			return nil
+		}
+		if prev, ok := prevStatus[sample.MinerID]; ok {
+			report.addMinerCost(prev, sample, tariff)
+		}
+		prevStatus[sample.MinerID] = sample
+		return nil
+	})
+	if err != nil {
+		s.log.Error("customer energy statuses failed", "customer", customer.ID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to build energy report")
+		return
+	}
+
+	// The combined days sum each miner's length, so coverage comes out as
+	// the average over the customer's miners.
+	combined := newEnergyReport(params.from, params.to, params.loc)
+	for _, day := range combined.days {
+		day.length = 0
+	}
+	out := customerEnergyDTO{
+		Customer:      toCustomerDTO(customer),
+		From:          formatTime(params.from),
+		To:            formatTime(params.to),
+		Timezone:      params.loc.String(),
+		MaxGapSeconds: int(reportMaxGap / time.Second),
+		Miners:        make([]minerEnergyDTO, 0, len(miners)),
+		Days:          make([]minerEnergyDayDTO, 0, len(combined.days)),
+	}
+	for _, miner := range miners {
+		report := reports[miner.ID]
+		for i, day := range report.days {
+			combined.days[i].add(day)
+		}
+		dto := report.minerEnergyDTO(miner, tariff != nil)
+		dto.From, dto.To, dto.Timezone, dto.MaxGapSeconds = "", "", "", 0
+		out.Miners = append(out.Miners, dto)
+	}
+
+	var totals energyDay
+	for _, day := range combined.days {
+		out.Days = append(out.Days, day.minerDTO(day.date.Format(time.DateOnly), tariff != nil))
+		totals.add(day)
+	}
+	out.Totals = totals.minerDTO("", tariff != nil)
+
+	if params.format != "csv" {
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	name := fmt.Sprintf("powerhive-invoice-%d-%s-%s.csv", customer.ID,
+		params.from.In(params.loc).Format("20060102"), params.to.In(params.loc).Format("20060102"))
+	csvOut := startCSVExport(w, name)
+	_ = csvOut.write(minerEnergyCSVHeader)
+	for _, miner := range out.Miners {
+		writeMinerEnergyCSV(csvOut, miner, true)
+	}
+	writeMinerEnergyCSV(csvOut, minerEnergyDTO{Days: out.Days, Totals: out.Totals}, false)
+	if err := csvOut.finish(); err != nil {
+		s.log.Error("write customer energy failed", "customer", customer.ID, "err", err)
+	}
+}
+
+var minerEnergyCSVHeader = []string{"date", "miner_id", "kwh", "cost_usd", "coverage"}
+
+// writeMinerEnergyCSV writes the totals row and, when days is set, a row
+// per day before it.
+func writeMinerEnergyCSV(out *csvExport, report minerEnergyDTO, days bool) {
+	rows := []minerEnergyDayDTO{report.Totals}
+	if days {
+		rows = append(append([]minerEnergyDayDTO{}, report.Days...), report.Totals)
+	}
+	for _, day := range rows {
+		date := day.Date
+		if date == "" {
+			date = "total"
+		}
+		cost := ""
+		if day.CostUSD != nil {
+			cost = strconv.FormatFloat(*day.CostUSD, 'f', 2, 64)
+		}
+		_ = out.write([]string{
+			date,
+			report.MinerID,
+			strconv.FormatFloat(day.KWh, 'f', -1, 64),
+			cost,
+			strconv.FormatFloat(day.Coverage, 'f', -1, 64),
+		})
+	}
+}
+
+func (s *Server) portalEnergy(w http.ResponseWriter, r *http.Request) {
+	s.writeCustomerEnergy(w, r, portalCustomer(r.Context()))
+}
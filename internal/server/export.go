@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// defaultExportRange is the period exported when no from parameter is given.
+const defaultExportRange = 30 * 24 * time.Hour
+
+// Tables ExportCSV can write.
+const (
+	ExportPlantReadings = "plant_readings"
+	ExportBalanceEvents = "power_balance_events"
+)
+
+// ExportTables lists the tables accepted by ExportCSV.
+var ExportTables = []string{ExportPlantReadings, ExportBalanceEvents}
+
+// ExportCSV writes the rows of table recorded in [from, to) to w in the same
+// CSV layout as the HTTP exports.
+func ExportCSV(ctx context.Context, w io.Writer, store *database.Store, table string, from, to time.Time) error {
+	out := &csvExport{csv: csv.NewWriter(w)}
+	var err error
+	switch table {
+	case ExportPlantReadings:
+		err = out.plantReadings(ctx, store, from, to)
+	case ExportBalanceEvents:
+		err = out.balanceEvents(ctx, store, from, to)
+	default:
+		return fmt.Errorf("unknown export table %q; use one of %s", table, strings.Join(ExportTables, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	return out.finish()
+}
+
+// ParseExportRange reads an export period the way the HTTP exports do: from
+// and to are RFC 3339 timestamps or YYYY-MM-DD dates in UTC, to defaults to
+// now and from to 30 days before to.
+func ParseExportRange(rawFrom, rawTo string) (time.Time, time.Time, error) {
+	return parseExportRange(rawFrom, rawTo, time.UTC)
+}
+
+func (s *Server) handleBalanceEventsExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if format := strings.ToLower(query.Get("format")); format != "" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "unsupported export format; use csv")
+		return
+	}
+
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	includePlant := false
+	for _, include := range strings.Split(query.Get("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "":
+		case "plant_readings":
+			includePlant = true
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown include %q", include))
+			return
+		}
+	}
+
+	name := fmt.Sprintf("powerhive-balance-events-%s-%s.csv", from.Format("20060102"), to.Format("20060102"))
+	out := startCSVExport(w, name)
+	ctx := r.Context()
+
+	if err := out.balanceEvents(ctx, s.store, from, to); err != nil {
+		s.log.Error("export balance events failed", "err", err)
+		return
+	}
+	if includePlant {
+		// Plant readings follow as a second table after a blank line.
+		_ = out.csv.Write([]string{""})
+		if err := out.plantReadings(ctx, s.store, from, to); err != nil {
+			s.log.Error("export plant readings failed", "err", err)
+			return
+		}
+	}
+	if err := out.finish(); err != nil {
+		s.log.Error("write export failed", "err", err)
+	}
+}
+
+func (s *Server) handlePlantExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if format := strings.ToLower(query.Get("format")); format != "" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "unsupported export format; use csv")
+		return
+	}
+
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := fmt.Sprintf("powerhive-plant-readings-%s-%s.csv", from.Format("20060102"), to.Format("20060102"))
+	out := startCSVExport(w, name)
+	if err := out.plantReadings(r.Context(), s.store, from, to); err != nil {
+		s.log.Error("export plant readings failed", "err", err)
+		return
+	}
+	if err := out.finish(); err != nil {
+		s.log.Error("write export failed", "err", err)
+	}
+}
+
+// csvExport streams CSV rows to the client, flushing every exportFlushRows
+// rows. Errors after startCSVExport can only be logged since the header has
+// been sent.
+type csvExport struct {
+	csv     *csv.Writer
+	flusher http.Flusher
+	rows    int
+}
+
+const exportFlushRows = 500
+
+func startCSVExport(w http.ResponseWriter, filename string) *csvExport {
+	// Exports can outlive the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	return &csvExport{csv: csv.NewWriter(w), flusher: flusher}
+}
+
+func (e *csvExport) write(record []string) error {
+	if err := e.csv.Write(record); err != nil {
+		return err
+	}
+	e.rows++
+	if e.rows%exportFlushRows != 0 {
+		return nil
+	}
+	e.csv.Flush()
+	if e.flusher != nil {
+		e.flusher.Flush()
+	}
+	return e.csv.Error()
+}
+
+func (e *csvExport) finish() error {
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func (e *csvExport) balanceEvents(ctx context.Context, store *database.Store, from, to time.Time) error {
+	if err := e.write([]string{
+		"recorded_at", "miner_id", "reason", "old_preset", "new_preset",
+		"old_power", "new_power", "total_consumption_before", "total_consumption_after",
+		"available_power", "target_power", "success", "error_message",
+	}); err != nil {
+		return err
+	}
+	return store.ForEachPowerBalanceEvent(ctx, from, to, func(event database.PowerBalanceEvent) error {
+		return e.write([]string{
+			formatTime(event.RecordedAt),
+			event.MinerID,
+			event.Reason,
+			csvString(event.OldPreset),
+			csvString(event.NewPreset),
+			csvFloat(event.OldPower),
+			csvFloat(event.NewPower),
+			csvFloat(event.TotalConsumptionBefore),
+			csvFloat(event.TotalConsumptionAfter),
+			csvFloat(event.AvailablePower),
+			csvFloat(event.TargetPower),
+			strconv.FormatBool(event.Success),
+			csvString(event.ErrorMessage),
+		})
+	})
+}
+
+func (e *csvExport) plantReadings(ctx context.Context, store *database.Store, from, to time.Time) error {
+	if err := e.write([]string{
+		"recorded_at", "plant_id", "total_generation", "total_container_consumption",
+		"available_power", "generation_sources", "consumption_sources", "exported_power",
+		"battery_soc", "battery_power", "grid_frequency", "grid_voltage", "fallback",
+	}); err != nil {
+		return err
+	}
+	return store.ForEachPlantReading(ctx, from, to, func(reading database.PlantReading) error {
+		return e.write([]string{
+			formatTime(reading.RecordedAt),
+			reading.PlantID,
+			strconv.FormatFloat(reading.TotalGeneration, 'f', -1, 64),
+			strconv.FormatFloat(reading.TotalContainerConsumption, 'f', -1, 64),
+			strconv.FormatFloat(reading.AvailablePower, 'f', -1, 64),
+			csvSources(reading.GenerationSources),
+			csvSources(reading.ConsumptionSources),
+			csvFloat(reading.ExportedPower),
+			csvFloat(reading.BatterySOC),
+			csvFloat(reading.BatteryPower),
+			csvFloat(reading.GridFrequency),
+			csvFloat(reading.GridVoltage),
+			csvString(reading.Fallback),
+		})
+	})
+}
+
+// parseExportRange reads from/to as RFC 3339 timestamps or YYYY-MM-DD dates
+// in loc. A date-only to covers that whole day.
+func parseExportRange(rawFrom, rawTo string, loc *time.Location) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if rawTo = strings.TrimSpace(rawTo); rawTo != "" {
+		parsed, dateOnly, err := parseExportTime(rawTo, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultExportRange)
+	if rawFrom = strings.TrimSpace(rawFrom); rawFrom != "" {
+		parsed, _, err := parseExportTime(rawFrom, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+func parseExportTime(raw string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), false, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, raw, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected RFC 3339 timestamp or YYYY-MM-DD date")
+	}
+	return t.UTC(), true, nil
+}
+
+func csvString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func csvFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// csvSources encodes a per-source breakdown as a JSON object in one cell.
+func csvSources(sources map[string]float64) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(sources)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// fieldSelection is a parsed ?fields= parameter: the JSON keys of a
+// response to keep. A key mapped to nil keeps its whole value; otherwise
+// the nested selection applies to the object, or to every element of the
+// array, under it.
+type fieldSelection map[string]fieldSelection
+
+// parseFields reads a comma-separated list of JSON keys of dto, with dots
+// reaching into nested objects (latest_status.hashrate). shorthand maps
+// bare names to the path they stand for. An empty list selects everything
+// and returns nil.
+func parseFields(raw string, dto any, shorthand map[string]string) (fieldSelection, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	root := reflect.TypeOf(dto)
+	selection := make(fieldSelection)
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		path := field
+		if _, ok := jsonFieldType(root, field); !ok {
+			if full, ok := shorthand[field]; ok {
+				path = full
+			}
+		}
+
+		parts := strings.Split(path, ".")
+		typ := root
+		for _, part := range parts {
+			next, ok := jsonFieldType(typ, part)
+			if !ok {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+			typ = next
+		}
+
+		current := selection
+		for _, part := range parts[:len(parts)-1] {
+			sub, seen := current[part]
+			if seen && sub == nil {
+				// Already selected whole.
+				current = nil
+				break
+			}
+			if sub == nil {
+				sub = make(fieldSelection)
+				current[part] = sub
+			}
+			current = sub
+		}
+		if current != nil {
+			// The whole value wins over parts of it.
+			current[parts[len(parts)-1]] = nil
+		}
+	}
+	if len(selection) == 0 {
+		return nil, nil
+	}
+	return selection, nil
+}
+
+// minerFieldShorthand lets ?fields= on the miner endpoints name the fields
+// of the latest status directly: hashrate is latest_status.hashrate.
+var minerFieldShorthand = nestedShorthand(statusDTO{}, "latest_status")
+
+// nestedShorthand maps the JSON keys of dto to the same keys under parent.
+func nestedShorthand(dto any, parent string) map[string]string {
+	typ := reflect.TypeOf(dto)
+	out := make(map[string]string, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		key, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if key != "" && key != "-" {
+			out[key] = parent + "." + key
+		}
+	}
+	return out
+}
+
+// jsonFieldType returns the type of the field of typ, a struct or a
+// pointer to or slice of one, encoded under the JSON key name.
+func jsonFieldType(typ reflect.Type, name string) (reflect.Type, bool) {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+		if key == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// apply returns payload, which is encoded as JSON, with only the selected
+// keys. A nil selection returns payload unchanged.
+func (f fieldSelection) apply(payload any) (any, error) {
+	if f == nil {
+		return payload, nil
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return f.prune(value), nil
+}
+
+func (f fieldSelection) prune(value any) any {
+	switch v := value.(type) {
+	case []any:
+		for i, item := range v {
+			v[i] = f.prune(item)
+		}
+		return v
+	case map[string]any:
+		out := make(map[string]any, len(f))
+		for key, sub := range f {
+			item, ok := v[key]
+			if !ok {
+				continue
+			}
+			if sub != nil {
+				item = sub.prune(item)
+			}
+			out[key] = item
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// writeFields writes payload like writeJSON, keeping only the fields
+// selected by the request's ?fields= parameter.
+func (s *Server) writeFields(w http.ResponseWriter, fields fieldSelection, payload any) {
+	out, err := fields.apply(payload)
+	if err != nil {
+		s.log.Error("select response fields failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,358 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	firmwareFormMemory = 32 << 20
+	firmwareTempPrefix = "powerhive-fw-*"
+)
+
+// ErrUpdateInProgress is returned by FirmwareUpdater.StartUpdate when another
+// update job is queued or running.
+var ErrUpdateInProgress = errors.New("firmware update already in progress")
+
+// Firmware update job and target states.
+const (
+	UpdateQueued     = "queued"
+	UpdateRunning    = "running"
+	UpdateCompleted  = "completed"
+	UpdateFailed     = "failed"
+	UpdateRolledBack = "rolled_back"
+
+	TargetPending        = "pending"
+	TargetUpdating       = "updating"
+	TargetUpdated        = "updated"
+	TargetFailed         = "failed"
+	TargetSkipped        = "skipped"
+	TargetRolledBack     = "rolled_back"
+	TargetRollbackFailed = "rollback_failed"
+)
+
+// FirmwareUpdater is implemented by the firmware update orchestrator.
+type FirmwareUpdater interface {
+	// StartUpdate queues a job. The updater takes ownership of the image files
+	// and removes them once the job finishes.
+	StartUpdate(ctx context.Context, req FirmwareUpdateRequest) (FirmwareUpdateJob, error)
+	UpdateJobs() []FirmwareUpdateJob
+	UpdateJob(id string) (FirmwareUpdateJob, bool)
+}
+
+// FirmwareUpdateRequest describes the miners to flash and the image to use.
+type FirmwareUpdateRequest struct {
+	MinerIDs          []string
+	ImagePath         string
+	ImageName         string
+	RollbackImagePath string
+	RollbackImageName string
+	ExpectedVersion   string
+	BatchSize         int
+	KeepSettings      bool
+}
+
+// FirmwareUpdateJob reports the progress of an update job.
+type FirmwareUpdateJob struct {
+	ID              string
+	State           string
+	ImageName       string
+	HasRollback     bool
+	ExpectedVersion string
+	BatchSize       int
+	Targets         []FirmwareUpdateTarget
+	Error           string
+	CreatedAt       time.Time
+	StartedAt       time.Time
+	FinishedAt      time.Time
+}
+
+// FirmwareUpdateTarget tracks a single miner within a job.
+type FirmwareUpdateTarget struct {
+	MinerID     string
+	Batch       int
+	State       string
+	FromVersion string
+	ToVersion   string
+	Error       string
+}
+
+// WithFirmwareUpdater enables the firmware update endpoints.
+func WithFirmwareUpdater(u FirmwareUpdater) Option {
+	return func(s *Server) {
+		s.firmware = u
+	}
+}
+
+func (s *Server) handleFirmwareInventory(w http.ResponseWriter, r *http.Request) {
+	miners, err := s.store.ListMiners(r.Context())
+	if err != nil {
+		s.log.Error("list miners for firmware inventory", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load miners")
+		return
+	}
+
+	type key struct{ name, version string }
+	groups := make(map[key]*firmwareVersionDTO)
+	for _, miner := range miners {
+		k := key{}
+		if miner.FWName != nil {
+			k.name = *miner.FWName
+		}
+		if miner.FWVersion != nil {
+			k.version = *miner.FWVersion
+		}
+		group, ok := groups[k]
+		if !ok {
+			group = &firmwareVersionDTO{Name: k.name, Version: k.version, Miners: []string{}}
+			groups[k] = group
+		}
+		group.Count++
+		group.Miners = append(group.Miners, miner.ID)
+	}
+
+	inventory := make([]firmwareVersionDTO, 0, len(groups))
+	for _, group := range groups {
+		inventory = append(inventory, *group)
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		if inventory[i].Count != inventory[j].Count {
+			return inventory[i].Count > inventory[j].Count
+		}
+		if inventory[i].Name != inventory[j].Name {
+			return inventory[i].Name < inventory[j].Name
+		}
+		return inventory[i].Version < inventory[j].Version
+	})
+
+	writeJSON(w, http.StatusOK, inventory)
+}
+
+func (s *Server) listFirmwareUpdates(w http.ResponseWriter, r *http.Request) {
+	if s.firmware == nil {
+		writeError(w, http.StatusServiceUnavailable, "firmware updates are not available")
+		return
+	}
+
+	jobs := s.firmware.UpdateJobs()
+	resp := make([]firmwareUpdateJobDTO, 0, len(jobs))
+	for _, job := range jobs {
+		resp = append(resp, toFirmwareUpdateJobDTO(job))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) getFirmwareUpdate(w http.ResponseWriter, r *http.Request) {
+	if s.firmware == nil {
+		writeError(w, http.StatusServiceUnavailable, "firmware updates are not available")
+		return
+	}
+
+	job, ok := s.firmware.UpdateJob(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "update job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toFirmwareUpdateJobDTO(job))
+}
+
+// startFirmwareUpdate accepts a multipart form with an "image" file, an
+// optional "rollback_image" file, "miners" (repeated or comma separated),
+// and optional "batch_size", "expected_version" and "keep_settings" fields.
+func (s *Server) startFirmwareUpdate(w http.ResponseWriter, r *http.Request) {
+	if s.firmware == nil {
+		writeError(w, http.StatusServiceUnavailable, "firmware updates are not available")
+		return
+	}
+
+	if err := r.ParseMultipartForm(firmwareFormMemory); err != nil {
+		writeError(w, http.StatusBadRequest, "expected multipart form with firmware image")
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	var minerIDs []string
+	for _, value := range r.MultipartForm.Value["miners"] {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.ToLower(strings.TrimSpace(id)); id != "" {
+				minerIDs = append(minerIDs, id)
+			}
+		}
+	}
+	if len(minerIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one miner is required")
+		return
+	}
+
+	req := FirmwareUpdateRequest{
+		MinerIDs:        minerIDs,
+		ExpectedVersion: strings.TrimSpace(r.FormValue("expected_version")),
+		KeepSettings:    true,
+	}
+
+	if raw := strings.TrimSpace(r.FormValue("batch_size")); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size <= 0 {
+			writeError(w, http.StatusBadRequest, "batch_size must be a positive integer")
+			return
+		}
+		req.BatchSize = size
+	}
+	if raw := strings.TrimSpace(r.FormValue("keep_settings")); raw != "" {
+		keep, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "keep_settings must be a boolean")
+			return
+		}
+		req.KeepSettings = keep
+	}
+
+	image, imageName, err := saveFormFile(r.MultipartForm, "image")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if image == "" {
+		writeError(w, http.StatusBadRequest, "image file is required")
+		return
+	}
+	req.ImagePath, req.ImageName = image, imageName
+
+	rollback, rollbackName, err := saveFormFile(r.MultipartForm, "rollback_image")
+	if err != nil {
+		_ = os.Remove(image)
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.RollbackImagePath, req.RollbackImageName = rollback, rollbackName
+
+	job, err := s.firmware.StartUpdate(r.Context(), req)
+	if err != nil {
+		_ = os.Remove(image)
+		if rollback != "" {
+			_ = os.Remove(rollback)
+		}
+		if errors.Is(err, ErrUpdateInProgress) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.log.Info("firmware update queued", "job", job.ID, "image", imageName, "miners", len(minerIDs))
+	writeJSON(w, http.StatusAccepted, toFirmwareUpdateJobDTO(job))
+}
+
+// saveFormFile copies an uploaded file to a temp file that outlives the
+// request. An absent field returns an empty path.
+func saveFormFile(form *multipart.Form, field string) (string, string, error) {
+	headers := form.File[field]
+	if len(headers) == 0 {
+		return "", "", nil
+	}
+	header := headers[0]
+
+	src, err := header.Open()
+	if err != nil {
+		return "", "", fmt.Errorf("read %s: %w", field, err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", firmwareTempPrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("store %s: %w", field, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		_ = os.Remove(dst.Name())
+		return "", "", fmt.Errorf("store %s: %w", field, err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(dst.Name())
+		return "", "", fmt.Errorf("store %s: %w", field, err)
+	}
+
+	return dst.Name(), header.Filename, nil
+}
+
+type firmwareVersionDTO struct {
+	Name    string   `json:"fw_name"`
+	Version string   `json:"fw_version"`
+	Count   int      `json:"count"`
+	Miners  []string `json:"miners"`
+}
+
+type firmwareUpdateJobDTO struct {
+	ID              string                    `json:"id"`
+	State           string                    `json:"state"`
+	Image           string                    `json:"image"`
+	HasRollback     bool                      `json:"has_rollback"`
+	ExpectedVersion *string                   `json:"expected_version"`
+	BatchSize       int                       `json:"batch_size"`
+	Targets         []firmwareUpdateTargetDTO `json:"targets"`
+	Error           *string                   `json:"error"`
+	CreatedAt       string                    `json:"created_at"`
+	StartedAt       *string                   `json:"started_at"`
+	FinishedAt      *string                   `json:"finished_at"`
+}
+
+type firmwareUpdateTargetDTO struct {
+	MinerID     string  `json:"miner_id"`
+	Batch       int     `json:"batch"`
+	State       string  `json:"state"`
+	FromVersion *string `json:"from_version"`
+	ToVersion   *string `json:"to_version"`
+	Error       *string `json:"error"`
+}
+
+func toFirmwareUpdateJobDTO(job FirmwareUpdateJob) firmwareUpdateJobDTO {
+	dto := firmwareUpdateJobDTO{
+		ID:              job.ID,
+		State:           job.State,
+		Image:           job.ImageName,
+		HasRollback:     job.HasRollback,
+		ExpectedVersion: optionalString(job.ExpectedVersion),
+		BatchSize:       job.BatchSize,
+		Targets:         make([]firmwareUpdateTargetDTO, 0, len(job.Targets)),
+		Error:           optionalString(job.Error),
+		CreatedAt:       formatTime(job.CreatedAt),
+	}
+	if !job.StartedAt.IsZero() {
+		started := formatTime(job.StartedAt)
+		dto.StartedAt = &started
+	}
+	if !job.FinishedAt.IsZero() {
+		finished := formatTime(job.FinishedAt)
+		dto.FinishedAt = &finished
+	}
+
+	for _, target := range job.Targets {
+		dto.Targets = append(dto.Targets, firmwareUpdateTargetDTO{
+			MinerID:     target.MinerID,
+			Batch:       target.Batch,
+			State:       target.State,
+			FromVersion: optionalString(target.FromVersion),
+			ToVersion:   optionalString(target.ToVersion),
+			Error:       optionalString(target.Error),
+		})
+	}
+
+	return dto
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
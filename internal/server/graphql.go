@@ -0,0 +1,411 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	"powerhive/internal/database"
+	"powerhive/internal/graphql"
+)
+
+const (
+	// maxGraphQLDepth bounds how deeply a query may nest, so one request
+	// cannot fan out into statuses of the miners of every model.
+	maxGraphQLDepth = 8
+	// maxGraphQLLimit caps the limit argument of list fields; larger
+	// limits return this many.
+	maxGraphQLLimit = 1000
+)
+
+// WithGraphQL serves a read-only GraphQL view of miners, models, statuses,
+// telemetry, plant readings and balance events at /api/graphql.
+func WithGraphQL() Option {
+	return func(s *Server) {
+		s.graphql = s.newGraphQLSchema()
+	}
+}
+
+// handleGraphQL runs a query sent as a JSON body or, for GET, in the query
+// and variables parameters. Requests that do not parse or validate answer
+// 400; errors resolving single fields come back next to the data.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	if s.graphql == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if raw := query.Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	result := s.graphql.Execute(r.Context(), req)
+	status := http.StatusOK
+	if !result.Executed() {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, result)
+}
+
+// handleGraphQLSchema returns the schema in GraphQL SDL for client code
+// generators, which cannot introspect it.
+func (s *Server) handleGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	if s.graphql == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(s.graphql.SDL()))
+}
+
+// newGraphQLSchema derives the object types from the REST DTOs, so fields
+// keep the names and formats of the JSON responses, and links them with
+// fields that load related records.
+func (s *Server) newGraphQLSchema() *graphql.Schema {
+	objects := make(map[reflect.Type]*graphql.Object)
+	object := func(name, description string, dto any) *graphql.Object {
+		obj := graphql.StructObject(name, description, dto, objects)
+		objects[reflect.TypeOf(dto)] = obj
+		return obj
+	}
+
+	object("Reliability", "The miner's status poll history.", reliabilityDTO{})
+	object("Cooling", "The miner's fan settings.", coolingDTO{})
+	object("PresetPower", "The expected draw and hashrate of a preset.", presetPowerDTO{})
+	model := object("Model", "A miner model and its presets.", modelDTO{})
+	object("Fan", "", fanDTO{})
+	object("Pool", "", poolDTO{})
+	object("Chip", "", chipDTO{})
+	object("Chain", "A hashboard as one status poll saw it.", chainDTO{})
+	status := object("Status", "A status poll. last_confirmed_at is the last poll that found nothing changed.", statusDTO{})
+	telemetry := object("ChainTelemetry", "A per-chain telemetry snapshot.", chainTelemetryDTO{})
+	plantReading := object("PlantReading", "A plant power reading, in kW.", plantReadingDTO{})
+	balanceEvent := object("BalanceEvent", "A preset change the power balancer made or attempted.", powerBalanceEventDTO{})
+	miner := object("Miner", "A miner as GET /api/v1/miners/{id} returns it.", minerDTO{})
+	balanceEventType := graphql.NonNullOf(balanceEvent)
+
+	limitArg := func(def int64) *graphql.Argument {
+		return &graphql.Argument{Name: "limit", Type: graphql.Int, Default: def}
+	}
+	minerArgs := []*graphql.Argument{
+		{Name: "tag", Type: graphql.ListOf(graphql.NonNullOf(graphql.String))},
+		{Name: "status", Type: graphql.String},
+		{Name: "managed", Type: graphql.Boolean},
+		{Name: "model", Type: graphql.String},
+		{Name: "q", Type: graphql.String},
+		{Name: "sort", Type: graphql.String},
+		{Name: "customer_id", Type: graphql.Int},
+		{Name: "archived", Type: graphql.Boolean, Default: false},
+	}
+
+	miner.AddField(&graphql.Field{
+		Name:        "statuses",
+		Description: "Recent status polls, newest first.",
+		Type:        graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(status))),
+		Args:        []*graphql.Argument{limitArg(10)},
+		Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			limit, err := graphQLLimit(args)
+			if err != nil {
+				return nil, err
+			}
+			statuses, err := s.store.ListMinerStatuses(ctx, source.(minerDTO).ID, limit)
+			if err != nil {
+				return nil, s.graphQLFailure("statuses", err)
+			}
+			out := make([]statusDTO, 0, len(statuses))
+			for _, st := range statuses {
+				out = append(out, toStatusDTO(st))
+			}
+			return out, nil
+		},
+	})
+	miner.AddField(&graphql.Field{
+		Name:        "telemetry",
+		Description: "Recent per-chain telemetry, newest first.",
+		Type:        graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(telemetry))),
+		Args:        []*graphql.Argument{limitArg(30)},
+		Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			limit, err := graphQLLimit(args)
+			if err != nil {
+				return nil, err
+			}
+			snapshots, err := s.store.ListChainTelemetry(ctx, source.(minerDTO).ID, limit)
+			if err != nil {
+				return nil, s.graphQLFailure("telemetry", err)
+			}
+			out := make([]chainTelemetryDTO, 0, len(snapshots))
+			for _, snapshot := range snapshots {
+				out = append(out, toChainTelemetryDTO(snapshot))
+			}
+			return out, nil
+		},
+	})
+	miner.AddField(&graphql.Field{
+		Name:        "balance_events",
+		Description: "Recent preset changes by the balancer, newest first.",
+		Type:        graphql.NonNullOf(graphql.ListOf(balanceEventType)),
+		Args:        []*graphql.Argument{limitArg(100)},
+		Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			minerID := source.(minerDTO).ID
+			return s.graphQLBalanceEvents(ctx, &minerID, args)
+		},
+	})
+
+	model.AddField(&graphql.Field{
+		Name: "presets_power",
+		Type: graphql.ListOf(graphql.NonNullOf(objects[reflect.TypeOf(presetPowerDTO{})])),
+		Resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			presets, err := s.store.GetModelPresets(ctx, source.(modelDTO).Alias)
+			if err != nil {
+				return nil, s.graphQLFailure("preset power", err)
+			}
+			return toPresetPowerDTOs(presets), nil
+		},
+	})
+	model.AddField(&graphql.Field{
+		Name:        "miners",
+		Description: "The miners of this model.",
+		Type:        graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(miner))),
+		Resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			alias := source.(modelDTO).Alias
+			miners, err := s.store.ListMiners(ctx)
+			if err != nil {
+				return nil, s.graphQLFailure("miners", err)
+			}
+			out := make([]minerDTO, 0)
+			for _, m := range miners {
+				if m.Model != nil && m.Model.Alias == alias {
+					out = append(out, s.toMinerDTO(m))
+				}
+			}
+			return out, nil
+		},
+	})
+
+	balanceEvent.AddField(&graphql.Field{
+		Name:        "miner",
+		Description: "Null once the miner is deleted.",
+		Type:        miner,
+		Resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			return s.graphQLMiner(ctx, source.(powerBalanceEventDTO).MinerID)
+		},
+	})
+
+	query := graphql.NewObject("Query", "")
+	query.AddField(&graphql.Field{
+		Name:        "miners",
+		Description: "Miners, with the filters and sort keys of GET /api/v1/miners.",
+		Type:        graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(miner))),
+		Args:        minerArgs,
+		Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			q, err := parseMinerListQuery(minerQueryValues(args))
+			if err != nil {
+				return nil, err
+			}
+			miners, err := q.find(ctx, s.store)
+			if err != nil {
+				return nil, s.graphQLFailure("miners", err)
+			}
+			out := make([]minerDTO, 0, len(miners))
+			for _, m := range miners {
+				out = append(out, s.toMinerDTO(m))
+			}
+			return out, nil
+		},
+	})
+	query.AddField(&graphql.Field{
+		Name: "miner",
+		Type: miner,
+		Args: []*graphql.Argument{{Name: "id", Type: graphql.NonNullOf(graphql.ID)}},
+		Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			return s.graphQLMiner(ctx, args["id"].(string))
+		},
+	})
+	query.AddField(&graphql.Field{
+		Name: "models",
+		Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(model))),
+		Resolve: func(ctx context.Context, _ any, _ map[string]any) (any, error) {
+			models, err := s.store.ListModels(ctx)
+			if err != nil {
+				return nil, s.graphQLFailure("models", err)
+			}
+			out := make([]modelDTO, 0, len(models))
+			for _, m := range models {
+				out = append(out, toModelDTO(m))
+			}
+			return out, nil
+		},
+	})
+	query.AddField(&graphql.Field{
+		Name: "model",
+		Type: model,
+		Args: []*graphql.Argument{{Name: "alias", Type: graphql.NonNullOf(graphql.String)}},
+		Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			m, err := s.store.GetModelByAlias(ctx, args["alias"].(string))
+			if err != nil {
+				if isNotFound(err) {
+					return nil, nil
+				}
+				return nil, s.graphQLFailure("model", err)
+			}
+			return toModelDTO(m), nil
+		},
+	})
+	query.AddField(&graphql.Field{
+		Name:        "plant_latest",
+		Description: "The latest plant reading.",
+		Type:        plantReading,
+		Resolve: func(ctx context.Context, _ any, _ map[string]any) (any, error) {
+			reading, err := s.store.GetLatestPlantReading(ctx)
+			if err != nil {
+				return nil, s.graphQLFailure("plant reading", err)
+			}
+			if reading == nil {
+				return nil, nil
+			}
+			return toPlantReadingDTO(*reading), nil
+		},
+	})
+	query.AddField(&graphql.Field{
+		Name:        "plant_readings",
+		Description: "Recent plant readings, newest first.",
+		Type:        graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(plantReading))),
+		Args:        []*graphql.Argument{limitArg(100)},
+		Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			limit, err := graphQLLimit(args)
+			if err != nil {
+				return nil, err
+			}
+			readings, err := s.store.ListPlantReadings(ctx, limit)
+			if err != nil {
+				return nil, s.graphQLFailure("plant readings", err)
+			}
+			out := make([]plantReadingDTO, 0, len(readings))
+			for _, reading := range readings {
+				out = append(out, toPlantReadingDTO(reading))
+			}
+			return out, nil
+		},
+	})
+	query.AddField(&graphql.Field{
+		Name:        "balance_events",
+		Description: "Recent preset changes by the balancer, newest first.",
+		Type:        graphql.NonNullOf(graphql.ListOf(balanceEventType)),
+		Args:        []*graphql.Argument{limitArg(100), {Name: "miner_id", Type: graphql.ID}},
+		Resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			var minerID *string
+			if id, ok := args["miner_id"].(string); ok && id != "" {
+				minerID = &id
+			}
+			return s.graphQLBalanceEvents(ctx, minerID, args)
+		},
+	})
+
+	schema, err := graphql.NewSchema(query)
+	if err != nil {
+		// The schema is fixed; this is a programming error.
+		panic(err)
+	}
+	schema.MaxDepth = maxGraphQLDepth
+	return schema
+}
+
+func (s *Server) graphQLMiner(ctx context.Context, minerID string) (any, error) {
+	m, err := s.store.GetMiner(ctx, minerID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, s.graphQLFailure("miner", err)
+	}
+	return s.toMinerDTO(m), nil
+}
+
+func (s *Server) graphQLBalanceEvents(ctx context.Context, minerID *string, args map[string]any) (any, error) {
+	limit, err := graphQLLimit(args)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.store.ListPowerBalanceEvents(ctx, minerID, limit)
+	if err != nil {
+		return nil, s.graphQLFailure("balance events", err)
+	}
+	out := make([]powerBalanceEventDTO, 0, len(events))
+	for _, event := range events {
+		out = append(out, toPowerBalanceEventDTO(event))
+	}
+	return out, nil
+}
+
+// graphQLFailure logs a store error and returns the error the client
+// sees for the field, which leaves the database out of it.
+func (s *Server) graphQLFailure(what string, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	s.log.Error("graphql query failed", "what", what, "err", err)
+	return fmt.Errorf("failed to fetch %s", what)
+}
+
+// graphQLLimit reads the limit argument, capping it at maxGraphQLLimit.
+func graphQLLimit(args map[string]any) (int, error) {
+	limit, _ := args["limit"].(int64)
+	if limit <= 0 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return int(min(limit, maxGraphQLLimit)), nil
+}
+
+// minerQueryValues turns the arguments of Query.miners into the query
+// parameters of GET /api/miners.
+func minerQueryValues(args map[string]any) url.Values {
+	values := make(url.Values)
+	for name, value := range args {
+		switch v := value.(type) {
+		case string:
+			values.Set(name, v)
+		case bool:
+			values.Set(name, strconv.FormatBool(v))
+		case int64:
+			values.Set(name, strconv.FormatInt(v, 10))
+		case []any:
+			for _, item := range v {
+				values.Add(name, fmt.Sprint(item))
+			}
+		}
+	}
+	return values
+}
+
+func toPresetPowerDTOs(presets []database.ModelPreset) []presetPowerDTO {
+	out := make([]presetPowerDTO, 0, len(presets))
+	for _, pp := range presets {
+		out = append(out, presetPowerDTO{
+			Preset:     pp.Value,
+			PowerW:     pp.ExpectedPowerW,
+			HashrateTH: pp.ExpectedHashrateTH,
+		})
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"powerhive/internal/database"
+)
+
+type gridProtectionEventDTO struct {
+	ID                 int64    `json:"id"`
+	Cause              string   `json:"cause"`
+	FrequencyHz        *float64 `json:"frequency_hz"`
+	VoltageV           *float64 `json:"voltage_v"`
+	ShedKW             float64  `json:"shed_kw"`
+	ConsumptionBeforeW float64  `json:"consumption_before_w"`
+	ConsumptionAfterW  float64  `json:"consumption_after_w"`
+	MinersAdjusted     int      `json:"miners_adjusted"`
+	MinersFailed       int      `json:"miners_failed"`
+	StartedAt          string   `json:"started_at"`
+	ClearedAt          *string  `json:"cleared_at"`
+	Active             bool     `json:"active"`
+}
+
+func toGridProtectionEventDTO(event database.GridProtectionEvent) gridProtectionEventDTO {
+	return gridProtectionEventDTO{
+		ID:                 event.ID,
+		Cause:              event.Cause,
+		FrequencyHz:        event.FrequencyHz,
+		VoltageV:           event.VoltageV,
+		ShedKW:             math.Round(event.ShedKW*1000) / 1000,
+		ConsumptionBeforeW: event.ConsumptionBeforeW,
+		ConsumptionAfterW:  event.ConsumptionAfterW,
+		MinersAdjusted:     event.MinersAdjusted,
+		MinersFailed:       event.MinersFailed,
+		StartedAt:          formatTime(event.StartedAt),
+		ClearedAt:          formatTimePtr(event.ClearedAt),
+		Active:             event.ClearedAt == nil,
+	}
+}
+
+// listGridProtectionEvents lists the load the balancer shed on grid
+// frequency or voltage disturbances, newest first. An active event has no
+// cleared_at; the balancer raises no miner while one is.
+func (s *Server) listGridProtectionEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	events, err := s.store.ListGridProtectionEvents(r.Context(), limit)
+	if err != nil {
+		s.log.Error("list grid protection events failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch grid protection events")
+		return
+	}
+
+	out := make([]gridProtectionEventDTO, 0, len(events))
+	for _, event := range events {
+		out = append(out, toGridProtectionEventDTO(event))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// healthPingTimeout bounds the database round trip of the health check.
+const healthPingTimeout = 3 * time.Second
+
+// Health states reported by GET /api/health.
+const (
+	healthOK          = "ok"
+	healthDegraded    = "degraded"
+	healthUnavailable = "unavailable"
+)
+
+// MaintenanceReporter exposes the latest database maintenance results.
+type MaintenanceReporter interface {
+	MaintenanceReport() database.MaintenanceReport
+}
+
+// WithMaintenance adds database maintenance results to GET /api/health.
+func WithMaintenance(r MaintenanceReporter) Option {
+	return func(s *Server) {
+		s.maintenance = r
+	}
+}
+
+type healthDTO struct {
+	Status      string          `json:"status"`
+	Database    databaseDTO     `json:"database"`
+	Maintenance *maintenanceDTO `json:"maintenance,omitempty"`
+}
+
+type databaseDTO struct {
+	Dialect   string  `json:"dialect"`
+	Reachable bool    `json:"reachable"`
+	Error     *string `json:"error,omitempty"`
+}
+
+type maintenanceDTO struct {
+	CheckpointAt       *string           `json:"checkpoint_at"`
+	CheckpointBusy     *bool             `json:"checkpoint_busy,omitempty"`
+	CheckpointedFrames *int              `json:"checkpointed_frames,omitempty"`
+	WALBytes           *int64            `json:"wal_bytes"`
+	OptimizeAt         *string           `json:"optimize_at"`
+	IntegrityCheckAt   *string           `json:"integrity_check_at"`
+	IntegrityProblems  []string          `json:"integrity_problems"`
+	Errors             map[string]string `json:"errors,omitempty"`
+}
+
+// handleHealth reports whether the database answers and, when maintenance
+// runs, the outcome of the latest checkpoint, optimize and integrity check.
+// An unreachable database answers 503; failed maintenance or integrity
+// problems mark the service degraded but still answer 200.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	resp := healthDTO{
+		Status: healthOK,
+		Database: databaseDTO{
+			Dialect:   string(s.store.Dialect()),
+			Reachable: true,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthPingTimeout)
+	defer cancel()
+	if err := s.store.DB().PingContext(ctx); err != nil {
+		msg := err.Error()
+		resp.Status = healthUnavailable
+		resp.Database.Reachable = false
+		resp.Database.Error = &msg
+	}
+
+	if s.maintenance != nil {
+		report := s.maintenance.MaintenanceReport()
+		resp.Maintenance = toMaintenanceDTO(report)
+		if resp.Status == healthOK && !report.Healthy() {
+			resp.Status = healthDegraded
+		}
+	}
+
+	status := http.StatusOK
+	if resp.Status == healthUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+func toMaintenanceDTO(report database.MaintenanceReport) *maintenanceDTO {
+	dto := &maintenanceDTO{
+		CheckpointAt:      formatTimePtr(report.CheckpointAt),
+		WALBytes:          report.WALBytes,
+		OptimizeAt:        formatTimePtr(report.OptimizeAt),
+		IntegrityCheckAt:  formatTimePtr(report.IntegrityCheckAt),
+		IntegrityProblems: append([]string{}, report.IntegrityProblems...),
+		Errors:            report.Errors,
+	}
+	if report.Checkpoint != nil {
+		dto.CheckpointBusy = &report.Checkpoint.Busy
+		dto.CheckpointedFrames = &report.Checkpoint.CheckpointedFrames
+	}
+	return dto
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"powerhive/internal/database"
+)
+
+// KeyRotator replaces a miner's firmware API key on demand.
+type KeyRotator interface {
+	RotateKey(ctx context.Context, minerID string) (database.KeyRotation, error)
+}
+
+// WithKeyRotator enables POST /api/miners/{id}/rotate-key.
+func WithKeyRotator(k KeyRotator) Option {
+	return func(s *Server) {
+		s.keys = k
+	}
+}
+
+type keyRotationDTO struct {
+	ID           int64   `json:"id"`
+	MinerID      string  `json:"miner_id"`
+	Trigger      string  `json:"trigger"`
+	Success      bool    `json:"success"`
+	ErrorMessage *string `json:"error_message"`
+	RotatedAt    string  `json:"rotated_at"`
+}
+
+func toKeyRotationDTO(rotation database.KeyRotation) keyRotationDTO {
+	return keyRotationDTO{
+		ID:           rotation.ID,
+		MinerID:      rotation.MinerID,
+		Trigger:      rotation.Trigger,
+		Success:      rotation.Success,
+		ErrorMessage: rotation.ErrorMessage,
+		RotatedAt:    formatTime(rotation.RotatedAt),
+	}
+}
+
+// rotateMinerKey replaces the miner's API key now, for use after a
+// suspected leak. The old key stops working once this returns 200.
+func (s *Server) rotateMinerKey(w http.ResponseWriter, r *http.Request, minerID string) {
+	if s.keys == nil {
+		writeError(w, http.StatusServiceUnavailable, "key rotation is not available")
+		return
+	}
+
+	rotation, err := s.keys.RotateKey(r.Context(), minerID)
+	if err != nil {
+		s.writeControlError(w, minerID, "api key rotation", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toKeyRotationDTO(rotation))
+}
+
+func (s *Server) handleKeyRotations(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	minerID := r.URL.Query().Get("miner_id")
+	var minerIDPtr *string
+	if minerID != "" {
+		minerIDPtr = &minerID
+	}
+
+	rotations, err := s.store.ListKeyRotations(r.Context(), minerIDPtr, limit)
+	if err != nil {
+		s.log.Error("list key rotations failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch key rotations")
+		return
+	}
+
+	out := make([]keyRotationDTO, 0, len(rotations))
+	for _, rotation := range rotations {
+		out = append(out, toKeyRotationDTO(rotation))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LeaderStatus describes this instance's role when several instances share
+// one database.
+type LeaderStatus struct {
+	NodeID string
+	Leader bool
+	// Holder is the node holding the lease as last seen, and ExpiresAt
+	// when that lease runs out unless renewed.
+	Holder    string
+	Since     *time.Time
+	ExpiresAt *time.Time
+}
+
+// LeaderReporter reports whether this instance is the leader.
+type LeaderReporter interface {
+	LeaderStatus() LeaderStatus
+}
+
+// WithLeaderReporter enables GET /api/admin/leader and makes the API
+// read-only while this instance stands by.
+func WithLeaderReporter(r LeaderReporter) Option {
+	return func(s *Server) {
+		s.leader = r
+	}
+}
+
+// standbyWritable lists the non-GET endpoints a standby still serves: they
+// change only this process or do not write at all.
+var standbyWritable = map[string]bool{
+	"POST /api/admin/reload":         true,
+	"PATCH /api/admin/loglevel":      true,
+	"PATCH /api/debug/firmware-log":  true,
+	"DELETE /api/debug/firmware-log": true,
+	"POST /api/balance/plan":         true,
+	"POST /api/graphql":              true,
+	"POST /api/login":                true,
+	"POST /api/logout":               true,
+}
+
+// readOnlyStandby rejects API writes while another instance leads, so
+// presets, settings and queued jobs change only on the leader.
+func (s *Server) readOnlyStandby(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.leader == nil || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if standbyWritable[r.Method+" "+r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status := s.leader.LeaderStatus()
+		if status.Leader {
+			next.ServeHTTP(w, r)
+			return
+		}
+		message := "this instance is a standby; send changes to the leader"
+		if status.Holder != "" {
+			message = "this instance is a standby; send changes to the leader, " + status.Holder
+		}
+		writeError(w, http.StatusServiceUnavailable, message)
+	})
+}
+
+// handleAdminLeader reports this instance's role and the current leader.
+func (s *Server) handleAdminLeader(w http.ResponseWriter, r *http.Request) {
+	if s.leader == nil {
+		writeError(w, http.StatusServiceUnavailable, "high availability is not enabled")
+		return
+	}
+
+	status := s.leader.LeaderStatus()
+	role := "standby"
+	if status.Leader {
+		role = "leader"
+	}
+	var holder *string
+	if status.Holder != "" {
+		holder = &status.Holder
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"node_id":    status.NodeID,
+		"role":       role,
+		"leader":     holder,
+		"since":      formatTimePtr(status.Since),
+		"expires_at": formatTimePtr(status.ExpiresAt),
+	})
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits keeps a misbehaving client from monopolising the API and the
+// database behind it. Zero values disable the corresponding limit.
+type Limits struct {
+	RatePerSecond  float64 // Sustained API requests per client IP
+	Burst          int     // Requests a client may make at once
+	MaxBodyBytes   int64   // Largest JSON request body accepted
+	RequestTimeout time.Duration
+}
+
+// WithLimits enables per-IP rate limiting, request body caps and request
+// timeouts on /api/ routes.
+func WithLimits(l Limits) Option {
+	return func(s *Server) {
+		s.limits = l
+		if l.RatePerSecond > 0 {
+			s.limiter = newRateLimiter(l.RatePerSecond, l.Burst)
+		}
+	}
+}
+
+// limit applies the configured Limits to API requests. Static assets are
+// served without limits.
+func (s *Server) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.limiter != nil {
+			if ok, wait := s.limiter.allow(clientIP(r), time.Now()); !ok {
+				s.log.Debug("request rate limited", "client", clientIP(r), "path", r.URL.Path)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, "too many requests")
+				return
+			}
+		}
+
+		if s.limits.MaxBodyBytes > 0 && r.Body != nil && !isMultipart(r) {
+			if r.ContentLength > s.limits.MaxBodyBytes {
+				writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, s.limits.MaxBodyBytes)
+		}
+
+		if s.limits.RequestTimeout > 0 && !longRunning(r) {
+			ctx, cancel := context.WithTimeout(r.Context(), s.limits.RequestTimeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// longRunning reports requests that legitimately outlive the request
+// timeout: streamed exports, reports, backups, bulk re-IP and firmware
+// uploads.
+func longRunning(r *http.Request) bool {
+	path := r.URL.Path
+	return strings.HasSuffix(path, "/export") ||
+		path == "/api/reports/energy" ||
+		path == "/api/admin/backup" ||
+		path == "/api/network/re-ip" ||
+		isMultipart(r)
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && strings.HasPrefix(mediaType, "multipart/")
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimiter is a token bucket per client. Buckets that have refilled are
+// dropped periodically so scanners cycling through addresses don't grow
+// the map without bound.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	clients   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+const rateLimiterSweepInterval = time.Minute
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		clients: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from key's bucket. When none is left it reports how
+// long until the next one.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimiterSweepInterval {
+		for k, b := range l.clients {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.clients[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.clients[key] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/firmware"
+)
+
+const (
+	// defaultMinerLogBytes and maxMinerLogBytes bound how much of a log
+	// GET /api/miners/{id}/logs returns; the start of longer logs is cut.
+	defaultMinerLogBytes = 256 << 10
+	maxMinerLogBytes     = 2 << 20
+)
+
+type minerLogDTO struct {
+	MinerID   string `json:"miner_id"`
+	Type      string `json:"type"`
+	Truncated bool   `json:"truncated"`
+	Lines     int    `json:"lines"`
+	FetchedAt string `json:"fetched_at"`
+	Log       string `json:"log"`
+}
+
+// handleMinerLogs fetches the end of the miner's miner or kernel log from
+// its firmware. max_bytes limits the size and tail keeps only the last
+// lines. format=text downloads the log as a plain text file.
+func (s *Server) handleMinerLogs(w http.ResponseWriter, r *http.Request, minerID string) {
+	if s.controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "miner control is not available")
+		return
+	}
+
+	query := r.URL.Query()
+	kind := strings.ToLower(strings.TrimSpace(query.Get("type")))
+	if kind == "" {
+		kind = firmware.LogMiner
+	}
+	if !slices.Contains(firmware.LogKinds, kind) {
+		writeError(w, http.StatusBadRequest, "type must be one of "+strings.Join(firmware.LogKinds, ", "))
+		return
+	}
+
+	format := strings.ToLower(query.Get("format"))
+	if format != "" && format != "json" && format != "text" {
+		writeError(w, http.StatusBadRequest, "unsupported format; use json or text")
+		return
+	}
+
+	maxBytes := int64(defaultMinerLogBytes)
+	if raw := query.Get("max_bytes"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 || parsed > maxMinerLogBytes {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("max_bytes must be between 1 and %d", maxMinerLogBytes))
+			return
+		}
+		maxBytes = parsed
+	}
+
+	tail := 0
+	if raw := query.Get("tail"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "tail must be a positive number of lines")
+			return
+		}
+		tail = parsed
+	}
+
+	logs, err := s.controller.MinerLogs(r.Context(), minerID, kind, maxBytes)
+	if err != nil {
+		s.writeControlError(w, minerID, kind+" logs", err)
+		return
+	}
+
+	text := logs.Text
+	if tail > 0 {
+		var cut bool
+		text, cut = tailLines(text, tail)
+		logs.Truncated = logs.Truncated || cut
+	}
+
+	if format == "text" {
+		name := fmt.Sprintf("%s-%s-%s.log", strings.ReplaceAll(minerID, ":", ""), kind, time.Now().UTC().Format("20060102-150405"))
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(text)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, minerLogDTO{
+		MinerID:   minerID,
+		Type:      kind,
+		Truncated: logs.Truncated,
+		Lines:     countLines(text),
+		FetchedAt: formatTime(time.Now().UTC()),
+		Log:       string(text),
+	})
+}
+
+// countLines counts the lines of text, including a final one without a
+// newline.
+func countLines(text []byte) int {
+	lines := bytes.Count(text, []byte("\n"))
+	if len(text) > 0 && text[len(text)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+// tailLines returns the last n lines of text and whether any were dropped.
+// A final line without a newline counts as a line.
+func tailLines(text []byte, n int) ([]byte, bool) {
+	end := len(text)
+	if end > 0 && text[end-1] == '\n' {
+		end--
+	}
+	for i := end - 1; i >= 0; i-- {
+		if text[i] != '\n' {
+			continue
+		}
+		n--
+		if n == 0 {
+			return text[i+1:], true
+		}
+	}
+	return text, false
+}
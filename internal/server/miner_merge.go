@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// mergeMiner folds a duplicate record, source_id, into the miner in the
+// path, keeping the path's ID. Discovery can create duplicates when a miner
+// changes MAC, for example after a control board swap.
+func (s *Server) mergeMiner(w http.ResponseWriter, r *http.Request, minerID string) {
+	var req struct {
+		SourceID string `json:"source_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	sourceID := strings.ToLower(strings.TrimSpace(req.SourceID))
+	if sourceID == "" {
+		writeError(w, http.StatusBadRequest, "source_id is required")
+		return
+	}
+	if sourceID == minerID {
+		writeError(w, http.StatusBadRequest, "source_id must differ from the miner being kept")
+		return
+	}
+
+	merged, err := s.store.MergeMiners(r.Context(), minerID, sourceID)
+	if err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.log.Error("merge miners failed", "miner", minerID, "source", sourceID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to merge miners")
+		return
+	}
+
+	s.log.Info("miners merged", "miner", minerID, "source", sourceID)
+	writeJSON(w, http.StatusOK, s.toMinerDTO(merged))
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"powerhive/internal/database"
+)
+
+// minerSortKeys lists what GET /api/miners can sort by.
+var minerSortKeys = []string{"id", "name", "ip", "location", "model", "state", "preset", "hashrate", "power", "uptime", "created_at"}
+
+// minerListQuery is the search, filter and sort parameters of
+// GET /api/miners.
+type minerListQuery struct {
+	archived bool
+	// Every tag must match; tag=a,b is the same as tag=a&tag=b.
+	tags []string
+	// customerID 0 matches the miners that belong to no customer.
+	customerID *int64
+	// status is "online", "offline" or a firmware state such as "mining".
+	status  string
+	managed *bool
+	model   string
+	search  string
+	sortKey string
+	desc    bool
+}
+
+func parseMinerListQuery(values url.Values) (minerListQuery, error) {
+	q := minerListQuery{
+		status: strings.ToLower(strings.TrimSpace(values.Get("status"))),
+		model:  strings.ToLower(strings.TrimSpace(values.Get("model"))),
+		search: strings.ToLower(strings.TrimSpace(values.Get("q"))),
+	}
+	q.archived, _ = strconv.ParseBool(values.Get("archived"))
+	for _, raw := range values["tag"] {
+		q.tags = append(q.tags, strings.Split(raw, ",")...)
+	}
+	q.tags = database.NormalizeTags(q.tags)
+	if raw := values.Get("customer_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id < 0 {
+			return minerListQuery{}, fmt.Errorf("invalid customer_id")
+		}
+		q.customerID = &id
+	}
+	if raw := strings.TrimSpace(values.Get("managed")); raw != "" {
+		managed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return minerListQuery{}, fmt.Errorf("managed must be true or false")
+		}
+		q.managed = &managed
+	}
+	if raw := strings.ToLower(strings.TrimSpace(values.Get("sort"))); raw != "" {
+		q.sortKey, q.desc = strings.TrimPrefix(raw, "-"), strings.HasPrefix(raw, "-")
+		if !slices.Contains(minerSortKeys, q.sortKey) {
+			return minerListQuery{}, fmt.Errorf("unknown sort %q; use %s, with - for descending", q.sortKey, strings.Join(minerSortKeys, ", "))
+		}
+	}
+	return q, nil
+}
+
+// find lists the miners that match the query in its order.
+func (q minerListQuery) find(ctx context.Context, store *database.Store) ([]database.Miner, error) {
+	list := store.ListMiners
+	if q.archived {
+		list = store.ListArchivedMiners
+	}
+	miners, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]database.Miner, 0, len(miners))
+	for _, miner := range miners {
+		if q.matches(miner) {
+			matched = append(matched, miner)
+		}
+	}
+	q.sort(matched)
+	return matched, nil
+}
+
+// matches reports whether miner passes the filters. The model matches the
+// model's alias or name and q the miner's ID, IP, name, location, serial,
+// tags or model, in part and regardless of case.
+func (q minerListQuery) matches(miner database.Miner) bool {
+	if !hasTags(miner.Tags, q.tags) {
+		return false
+	}
+	if q.customerID != nil && valueOrZeroID(miner.CustomerID) != *q.customerID {
+		return false
+	}
+	online := minerOnline(miner)
+	switch q.status {
+	case "":
+	case "online":
+		if !online {
+			return false
+		}
+	case "offline":
+		if online {
+			return false
+		}
+	default:
+		if !online || miner.LatestStatus == nil || !strings.EqualFold(strings.TrimSpace(derefString(miner.LatestStatus.State)), q.status) {
+			return false
+		}
+	}
+	if q.managed != nil && miner.Managed != *q.managed {
+		return false
+	}
+	if q.model != "" {
+		if miner.Model == nil || !(strings.Contains(strings.ToLower(miner.Model.Alias), q.model) || strings.Contains(strings.ToLower(miner.Model.Name), q.model)) {
+			return false
+		}
+	}
+	if q.search != "" {
+		haystack := []string{miner.ID, derefString(miner.IP), derefString(miner.Name), derefString(miner.Location), derefString(miner.Serial)}
+		haystack = append(haystack, miner.Tags...)
+		if miner.Model != nil {
+			haystack = append(haystack, miner.Model.Alias, miner.Model.Name)
+		}
+		found := false
+		for _, text := range haystack {
+			if strings.Contains(strings.ToLower(text), q.search) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// sort orders miners by the sort key. Miners without a value for it come
+// last in either direction, and ties keep ID order.
+func (q minerListQuery) sort(miners []database.Miner) {
+	if q.sortKey == "" || q.sortKey == "id" {
+		if q.desc {
+			sort.SliceStable(miners, func(i, j int) bool { return miners[i].ID > miners[j].ID })
+		}
+		return
+	}
+	sort.SliceStable(miners, func(i, j int) bool {
+		a, aOK := minerSortValue(miners[i], q.sortKey)
+		b, bOK := minerSortValue(miners[j], q.sortKey)
+		if !aOK || !bOK {
+			return aOK && !bOK
+		}
+		cmp := compareSortValues(a, b)
+		if q.desc {
+			cmp = -cmp
+		}
+		return cmp < 0
+	})
+}
+
+// minerSortValue returns the miner's value for key, a string, float64 or
+// netip.Addr, and false when it has none.
+func minerSortValue(miner database.Miner, key string) (any, bool) {
+	status := miner.LatestStatus
+	if !minerOnline(miner) {
+		// An offline miner's last status is no current reading.
+		status = nil
+	}
+	text := func(value *string) (any, bool) {
+		if value == nil || strings.TrimSpace(*value) == "" {
+			return nil, false
+		}
+		return strings.ToLower(strings.TrimSpace(*value)), true
+	}
+	number := func(value *float64) (any, bool) {
+		if value == nil {
+			return nil, false
+		}
+		return *value, true
+	}
+
+	switch key {
+	case "name":
+		return text(miner.Name)
+	case "ip":
+		if miner.IP == nil {
+			return nil, false
+		}
+		addr, err := netip.ParseAddr(strings.TrimSpace(*miner.IP))
+		if err != nil {
+			return nil, false
+		}
+		return addr, true
+	case "location":
+		return text(miner.Location)
+	case "model":
+		if miner.Model == nil {
+			return nil, false
+		}
+		return text(&miner.Model.Alias)
+	case "created_at":
+		return float64(miner.CreatedAt.UnixNano()), true
+	}
+	if status == nil {
+		return nil, false
+	}
+	switch key {
+	case "state":
+		return text(status.State)
+	case "preset":
+		return text(status.Preset)
+	case "hashrate":
+		return number(status.Hashrate)
+	case "power":
+		return number(status.PowerConsumption)
+	case "uptime":
+		if status.Uptime == nil {
+			return nil, false
+		}
+		return float64(*status.Uptime), true
+	}
+	return nil, false
+}
+
+func compareSortValues(a, b any) int {
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case netip.Addr:
+		return a.Compare(b.(netip.Addr))
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+// minerOnline reports whether the miner has an address, as the online
+// field of the miner DTO does.
+func minerOnline(miner database.Miner) bool {
+	return miner.IP != nil && strings.TrimSpace(*miner.IP) != ""
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+
+	"powerhive/internal/database"
+)
+
+type modelPresetRequest struct {
+	Preset     string   `json:"preset"`
+	PowerW     *float64 `json:"power_w"`
+	HashrateTH *float64 `json:"hashrate_th"`
+}
+
+type createModelRequest struct {
+	Name      string               `json:"name"`
+	Alias     string               `json:"alias"`
+	Presets   []modelPresetRequest `json:"presets"`
+	MaxPreset *string              `json:"max_preset"`
+	MinPreset *string              `json:"min_preset"`
+}
+
+func toModelPresetInputs(presets []modelPresetRequest) []database.ModelPresetInput {
+	out := make([]database.ModelPresetInput, 0, len(presets))
+	for _, preset := range presets {
+		out = append(out, database.ModelPresetInput{
+			Value:              preset.Preset,
+			ExpectedPowerW:     preset.PowerW,
+			ExpectedHashrateTH: preset.HashrateTH,
+		})
+	}
+	return out
+}
+
+// createModel registers a model ahead of discovery, so its presets and
+// expected metrics are known before the first miner of it arrives.
+func (s *Server) createModel(w http.ResponseWriter, r *http.Request) {
+	var req createModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	model, err := s.store.CreateModel(r.Context(), database.ModelDefinition{
+		Name:      req.Name,
+		Alias:     req.Alias,
+		Presets:   toModelPresetInputs(req.Presets),
+		MaxPreset: req.MaxPreset,
+		MinPreset: req.MinPreset,
+	})
+	if err != nil {
+		s.writeModelError(w, req.Alias, err)
+		return
+	}
+	s.log.Info("model created", "alias", model.Alias, "presets", len(model.Presets))
+	writeJSON(w, http.StatusCreated, s.modelWithPresets(r.Context(), model))
+}
+
+// setModelPresets replaces a model's preset list and expected metrics.
+// Discovery only reads presets from the firmware for models that have none,
+// so the list is kept once miners of the model arrive.
+func (s *Server) setModelPresets(w http.ResponseWriter, r *http.Request, alias string) {
+	var req struct {
+		Presets []modelPresetRequest `json:"presets"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if req.Presets == nil {
+		writeError(w, http.StatusBadRequest, "presets is required")
+		return
+	}
+
+	model, err := s.store.SetModelPresets(r.Context(), alias, toModelPresetInputs(req.Presets))
+	if err != nil {
+		s.writeModelError(w, alias, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.modelWithPresets(r.Context(), model))
+}
+
+func (s *Server) deleteModel(w http.ResponseWriter, r *http.Request, alias string) {
+	if err := s.store.DeleteModel(r.Context(), alias); err != nil {
+		s.writeModelError(w, alias, err)
+		return
+	}
+	s.log.Info("model deleted", "alias", alias)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// modelWithPresets builds the model response including each preset's
+// expected metrics.
+func (s *Server) modelWithPresets(ctx context.Context, model database.Model) modelDTO {
+	dto := toModelDTO(model)
+	presets, err := s.store.GetModelPresets(ctx, model.Alias)
+	if err != nil {
+		s.log.Warn("failed to load preset power", "model", model.Alias, "err", err)
+		return dto
+	}
+	dto.PresetsPower = make([]presetPowerDTO, 0, len(presets))
+	for _, preset := range presets {
+		dto.PresetsPower = append(dto.PresetsPower, presetPowerDTO{
+			Preset:     preset.Value,
+			PowerW:     preset.ExpectedPowerW,
+			HashrateTH: preset.ExpectedHashrateTH,
+		})
+	}
+	return dto
+}
+
+// writeModelError maps a model store error to a response.
+func (s *Server) writeModelError(w http.ResponseWriter, alias string, err error) {
+	switch {
+	case errors.Is(err, database.ErrInvalidModel):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, database.ErrModelExists), errors.Is(err, database.ErrModelInUse):
+		writeError(w, http.StatusConflict, err.Error())
+	case isNotFound(err):
+		writeError(w, http.StatusNotFound, "model not found")
+	default:
+		s.log.Error("model request failed", "alias", alias, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to save model")
+	}
+}
+
+type efficiencyPointDTO struct {
+	Preset           string   `json:"preset"`
+	PowerW           *float64 `json:"power_w"`
+	HashrateTH       *float64 `json:"hashrate_th"`
+	WattsPerTH       *float64 `json:"w_per_th"`
+	HashrateObserved bool     `json:"hashrate_observed"`
+}
+
+// modelEfficiency returns the model's efficiency curve: each preset's
+// expected power, hashrate and W/TH, ordered by power. The balancer ranks
+// miners with these figures instead of their live hashrate.
+func (s *Server) modelEfficiency(w http.ResponseWriter, r *http.Request, alias string) {
+	ctx := r.Context()
+	if _, err := s.store.GetModelByAlias(ctx, alias); err != nil {
+		s.writeModelError(w, alias, err)
+		return
+	}
+	presets, err := s.store.GetModelPresets(ctx, alias)
+	if err != nil {
+		s.log.Error("load model presets failed", "alias", alias, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load presets")
+		return
+	}
+
+	points := make([]efficiencyPointDTO, 0, len(presets))
+	for _, preset := range presets {
+		point := efficiencyPointDTO{
+			Preset:           preset.Value,
+			PowerW:           preset.ExpectedPowerW,
+			HashrateTH:       preset.ExpectedHashrateTH,
+			HashrateObserved: preset.HashrateObserved,
+		}
+		if preset.ExpectedPowerW != nil && preset.ExpectedHashrateTH != nil && *preset.ExpectedHashrateTH > 0 {
+			efficiency := math.Round(*preset.ExpectedPowerW / *preset.ExpectedHashrateTH * 100) / 100
+			point.WattsPerTH = &efficiency
+		}
+		points = append(points, point)
+	}
+	// Presets without a power figure go last, in their list order.
+	sort.SliceStable(points, func(i, j int) bool {
+		a, b := points[i].PowerW, points[j].PowerW
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"alias":  alias,
+		"points": points,
+	})
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"powerhive/internal/firmware"
+)
+
+// ReIPPlan assigns sequential static addresses to the miners at a location,
+// starting at StartIP and skipping addresses held by other miners, the
+// gateway and the subnet's network and broadcast addresses. Miners are taken
+// in order of name, then ID.
+type ReIPPlan struct {
+	Location   string
+	StartIP    string
+	Netmask    string
+	Gateway    string
+	DNSServers []string
+	// HostnamePrefix, when set, names each miner after its position in the
+	// plan, such as c1-001.
+	HostnamePrefix string
+	// DryRun returns the planned addresses without changing any miner.
+	DryRun bool
+}
+
+// Re-IP result states.
+const (
+	ReIPPlanned = "planned"
+	ReIPApplied = "applied"
+	ReIPFailed  = "failed"
+)
+
+// ReIPResult is the outcome of a ReIPPlan for one miner.
+type ReIPResult struct {
+	MinerID        string
+	Name           *string
+	OldIP          *string
+	NewIP          string
+	Hostname       *string
+	State          string
+	Error          string
+	RebootRequired bool
+}
+
+type networkDTO struct {
+	DHCP       *bool    `json:"dhcp"`
+	Hostname   *string  `json:"hostname"`
+	IP         *string  `json:"ip"`
+	Netmask    *string  `json:"netmask"`
+	Gateway    *string  `json:"gateway"`
+	DNSServers []string `json:"dns_servers"`
+}
+
+func toNetworkDTO(network firmware.NetworkSettings) networkDTO {
+	dns := network.DNSServers
+	if dns == nil {
+		dns = []string{}
+	}
+	return networkDTO{
+		DHCP:       network.DHCP,
+		Hostname:   network.Hostname,
+		IP:         network.IPAddress,
+		Netmask:    network.Netmask,
+		Gateway:    network.Gateway,
+		DNSServers: dns,
+	}
+}
+
+func (s *Server) getMinerNetwork(w http.ResponseWriter, r *http.Request, minerID string) {
+	if s.controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "miner control is not available")
+		return
+	}
+
+	network, err := s.controller.MinerNetwork(r.Context(), minerID)
+	if err != nil {
+		s.writeControlError(w, minerID, "network settings", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"miner_id": minerID,
+		"network":  toNetworkDTO(network),
+	})
+}
+
+// updateMinerNetwork changes the miner's network configuration. Omitted
+// fields keep the miner's current values.
+func (s *Server) updateMinerNetwork(w http.ResponseWriter, r *http.Request, minerID string) {
+	if s.controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "miner control is not available")
+		return
+	}
+
+	var req networkDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	update := firmware.NetworkSettings{
+		DHCP:       req.DHCP,
+		Hostname:   req.Hostname,
+		IPAddress:  req.IP,
+		Netmask:    req.Netmask,
+		Gateway:    req.Gateway,
+		DNSServers: req.DNSServers,
+	}
+
+	network, result, err := s.controller.SetMinerNetwork(r.Context(), minerID, update)
+	if err != nil {
+		s.writeControlError(w, minerID, "network settings", err)
+		return
+	}
+
+	s.log.Info("miner network changed", "miner", minerID, "dhcp", network.DHCP, "ip", network.IPAddress)
+	resp := map[string]any{
+		"miner_id": minerID,
+		"network":  toNetworkDTO(network),
+	}
+	if result != nil {
+		resp["reboot_required"] = result.RebootRequired
+		resp["restart_required"] = result.RestartRequired
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type reIPResultDTO struct {
+	MinerID        string  `json:"miner_id"`
+	Name           *string `json:"name"`
+	OldIP          *string `json:"old_ip"`
+	NewIP          string  `json:"new_ip"`
+	Hostname       *string `json:"hostname,omitempty"`
+	State          string  `json:"state"`
+	Error          string  `json:"error,omitempty"`
+	RebootRequired bool    `json:"reboot_required"`
+}
+
+// handleReIP assigns sequential static addresses to every miner at a
+// location, such as a container, so their addresses stop changing with
+// DHCP leases.
+func (s *Server) handleReIP(w http.ResponseWriter, r *http.Request) {
+	if s.controller == nil {
+		writeError(w, http.StatusServiceUnavailable, "miner control is not available")
+		return
+	}
+
+	var req struct {
+		Location       string   `json:"location"`
+		StartIP        string   `json:"start_ip"`
+		Netmask        string   `json:"netmask"`
+		Gateway        string   `json:"gateway"`
+		DNSServers     []string `json:"dns_servers"`
+		HostnamePrefix string   `json:"hostname_prefix"`
+		DryRun         bool     `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if strings.TrimSpace(req.Location) == "" {
+		writeError(w, http.StatusBadRequest, "location is required")
+		return
+	}
+
+	results, err := s.controller.ReIPMiners(r.Context(), ReIPPlan{
+		Location:       req.Location,
+		StartIP:        req.StartIP,
+		Netmask:        req.Netmask,
+		Gateway:        req.Gateway,
+		DNSServers:     req.DNSServers,
+		HostnamePrefix: req.HostnamePrefix,
+		DryRun:         req.DryRun,
+	})
+	if err != nil {
+		s.writeControlError(w, "", "network settings", err)
+		return
+	}
+
+	out := make([]reIPResultDTO, 0, len(results))
+	failed := 0
+	for _, result := range results {
+		if result.State == ReIPFailed {
+			failed++
+		}
+		out = append(out, reIPResultDTO{
+			MinerID:        result.MinerID,
+			Name:           result.Name,
+			OldIP:          result.OldIP,
+			NewIP:          result.NewIP,
+			Hostname:       result.Hostname,
+			State:          result.State,
+			Error:          result.Error,
+			RebootRequired: result.RebootRequired,
+		})
+	}
+	if !req.DryRun {
+		s.log.Info("re-ip finished", "location", req.Location, "miners", len(out), "failed", failed)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"location": strings.TrimSpace(req.Location),
+		"dry_run":  req.DryRun,
+		"failed":   failed,
+		"miners":   out,
+	})
+}
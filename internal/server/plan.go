@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// BalancePlanner simulates the power balancer against a hypothetical target
+// without touching any miner.
+type BalancePlanner interface {
+	PlanBalance(ctx context.Context, req BalancePlanRequest) (BalancePlan, error)
+}
+
+// BalancePlanRequest describes a what-if scenario. Exactly one of
+// TargetPowerW or GenerationKW is set; SafetyMarginPercent overrides the
+// stored margin when planning from generation.
+type BalancePlanRequest struct {
+	TargetPowerW        *float64
+	GenerationKW        *float64
+	SafetyMarginPercent *float64
+}
+
+// BalancePlan is the balancer's projected outcome for a scenario.
+type BalancePlan struct {
+	TargetPowerW          float64
+	SafetyMarginPercent   *float64
+	CurrentConsumptionW   float64
+	ProjectedConsumptionW float64
+	CurrentHashrateTH     float64
+	ProjectedHashrateTH   float64
+	Cycles                int
+	Converged             bool
+	Changes               []BalancePlanChange
+}
+
+// BalancePlanChange is one miner's planned preset assignment.
+type BalancePlanChange struct {
+	MinerID           string
+	Model             string
+	Reason            string
+	CurrentPreset     *string
+	PlannedPreset     string
+	CurrentPowerW     *float64
+	PlannedPowerW     *float64
+	CurrentHashrateTH *float64
+	PlannedHashrateTH *float64
+}
+
+// WithBalancePlanner enables POST /api/balance/plan.
+func WithBalancePlanner(p BalancePlanner) Option {
+	return func(s *Server) {
+		s.planner = p
+	}
+}
+
+type balancePlanDTO struct {
+	TargetPowerKW          float64                `json:"target_power_kw"`
+	SafetyMarginPercent    *float64               `json:"safety_margin_percent,omitempty"`
+	CurrentConsumptionKW   float64                `json:"current_consumption_kw"`
+	ProjectedConsumptionKW float64                `json:"projected_consumption_kw"`
+	CurrentHashrateTH      float64                `json:"current_hashrate_th"`
+	ProjectedHashrateTH    float64                `json:"projected_hashrate_th"`
+	Cycles                 int                    `json:"cycles"`
+	Converged              bool                   `json:"converged"`
+	Changes                []balancePlanChangeDTO `json:"changes"`
+}
+
+type balancePlanChangeDTO struct {
+	MinerID           string   `json:"miner_id"`
+	Model             string   `json:"model"`
+	Reason            string   `json:"reason"`
+	CurrentPreset     *string  `json:"current_preset,omitempty"`
+	PlannedPreset     string   `json:"planned_preset"`
+	CurrentPowerW     *float64 `json:"current_power_w,omitempty"`
+	PlannedPowerW     *float64 `json:"planned_power_w,omitempty"`
+	CurrentHashrateTH *float64 `json:"current_hashrate_th,omitempty"`
+	PlannedHashrateTH *float64 `json:"planned_hashrate_th,omitempty"`
+}
+
+func (s *Server) handleBalancePlan(w http.ResponseWriter, r *http.Request) {
+	if s.planner == nil {
+		writeError(w, http.StatusServiceUnavailable, "balance planner is not available")
+		return
+	}
+
+	var req struct {
+		TargetPowerKW       *float64 `json:"target_power_kw"`
+		GenerationKW        *float64 `json:"generation_kw"`
+		SafetyMarginPercent *float64 `json:"safety_margin_percent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if (req.TargetPowerKW == nil) == (req.GenerationKW == nil) {
+		writeError(w, http.StatusBadRequest, "provide exactly one of target_power_kw or generation_kw")
+		return
+	}
+	if (req.TargetPowerKW != nil && *req.TargetPowerKW < 0) || (req.GenerationKW != nil && *req.GenerationKW < 0) {
+		writeError(w, http.StatusBadRequest, "power values must not be negative")
+		return
+	}
+	if req.SafetyMarginPercent != nil {
+		if req.GenerationKW == nil {
+			writeError(w, http.StatusBadRequest, "safety_margin_percent only applies to generation_kw")
+			return
+		}
+		if *req.SafetyMarginPercent < 0 || *req.SafetyMarginPercent > 50 {
+			writeError(w, http.StatusBadRequest, "safety_margin_percent must be between 0 and 50")
+			return
+		}
+	}
+
+	planReq := BalancePlanRequest{
+		GenerationKW:        req.GenerationKW,
+		SafetyMarginPercent: req.SafetyMarginPercent,
+	}
+	if req.TargetPowerKW != nil {
+		targetW := *req.TargetPowerKW * 1000
+		planReq.TargetPowerW = &targetW
+	}
+
+	plan, err := s.planner.PlanBalance(r.Context(), planReq)
+	if err != nil {
+		s.log.Error("balance plan failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to plan balance")
+		return
+	}
+
+	dto := balancePlanDTO{
+		TargetPowerKW:          plan.TargetPowerW / 1000,
+		SafetyMarginPercent:    plan.SafetyMarginPercent,
+		CurrentConsumptionKW:   plan.CurrentConsumptionW / 1000,
+		ProjectedConsumptionKW: plan.ProjectedConsumptionW / 1000,
+		CurrentHashrateTH:      plan.CurrentHashrateTH,
+		ProjectedHashrateTH:    plan.ProjectedHashrateTH,
+		Cycles:                 plan.Cycles,
+		Converged:              plan.Converged,
+		Changes:                make([]balancePlanChangeDTO, 0, len(plan.Changes)),
+	}
+	for _, change := range plan.Changes {
+		dto.Changes = append(dto.Changes, balancePlanChangeDTO(change))
+	}
+
+	writeJSON(w, http.StatusOK, dto)
+}
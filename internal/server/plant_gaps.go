@@ -0,0 +1,59 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+type plantGapDTO struct {
+	ID              int64   `json:"id"`
+	Reason          string  `json:"reason"`
+	LastReadingAt   *string `json:"last_reading_at"`
+	StartedAt       string  `json:"started_at"`
+	EndedAt         *string `json:"ended_at"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	LastError       *string `json:"last_error"`
+	Alerted         bool    `json:"alerted"`
+}
+
+func toPlantGapDTO(gap database.PlantGap, now time.Time) plantGapDTO {
+	return plantGapDTO{
+		ID:              gap.ID,
+		Reason:          gap.Reason,
+		LastReadingAt:   formatTimePtr(gap.LastReadingAt),
+		StartedAt:       formatTime(gap.StartedAt),
+		EndedAt:         formatTimePtr(gap.EndedAt),
+		DurationSeconds: math.Round(gap.Duration(now).Seconds()),
+		LastError:       gap.LastError,
+		Alerted:         gap.Alerted,
+	}
+}
+
+// listPlantGaps lists the stretches the plant poller went without a usable
+// reading, newest first. An open gap has no ended_at.
+func (s *Server) listPlantGaps(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	gaps, err := s.store.ListPlantGaps(r.Context(), limit)
+	if err != nil {
+		s.log.Error("list plant gaps failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch plant gaps")
+		return
+	}
+
+	now := time.Now()
+	out := make([]plantGapDTO, 0, len(gaps))
+	for _, gap := range gaps {
+		out = append(out, toPlantGapDTO(gap, now))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
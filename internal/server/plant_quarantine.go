@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type quarantinedReadingDTO struct {
+	ID                        int64   `json:"id"`
+	PlantID                   string  `json:"plant_id"`
+	Confidence                float64 `json:"confidence"`
+	TrustStatus               *string `json:"trust_status"`
+	TrustSummary              *string `json:"trust_summary"`
+	TotalGeneration           float64 `json:"total_generation"`
+	TotalContainerConsumption float64 `json:"total_container_consumption"`
+	Action                    string  `json:"action"`
+	RawData                   *string `json:"raw_data,omitempty"`
+	RecordedAt                string  `json:"recorded_at"`
+	QuarantinedAt             string  `json:"quarantined_at"`
+}
+
+// listQuarantinedReadings lists the plant readings rejected for their
+// confidence score, newest first. raw=true includes the aggregator's
+// response.
+func (s *Server) listQuarantinedReadings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := 100
+	if raw := query.Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	withRaw := query.Get("raw") == "true"
+
+	readings, err := s.store.ListQuarantinedReadings(r.Context(), from, to, limit)
+	if err != nil {
+		s.log.Error("list quarantined readings failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch quarantined readings")
+		return
+	}
+
+	out := make([]quarantinedReadingDTO, 0, len(readings))
+	for _, reading := range readings {
+		dto := quarantinedReadingDTO{
+			ID:                        reading.ID,
+			PlantID:                   reading.PlantID,
+			Confidence:                reading.Confidence,
+			TrustStatus:               reading.TrustStatus,
+			TrustSummary:              reading.TrustSummary,
+			TotalGeneration:           reading.TotalGeneration,
+			TotalContainerConsumption: reading.TotalContainerConsumption,
+			Action:                    reading.Action,
+			RecordedAt:                formatTime(reading.RecordedAt),
+			QuarantinedAt:             formatTime(reading.QuarantinedAt),
+		}
+		if withRaw {
+			dto.RawData = reading.RawData
+		}
+		out = append(out, dto)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
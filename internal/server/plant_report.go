@@ -0,0 +1,171 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// PlantMetricsReporter tells the plant report which calendar days the
+// stored daily plant metrics cover.
+type PlantMetricsReporter interface {
+	MetricsTimezone() *time.Location
+}
+
+// WithPlantMetrics serves the daily plant metrics computed in the
+// reporter's time zone; without it the report reads UTC days.
+func WithPlantMetrics(m PlantMetricsReporter) Option {
+	return func(s *Server) {
+		s.plantMetrics = m
+	}
+}
+
+type plantReportDTO struct {
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Timezone string              `json:"timezone"`
+	Days     []plantReportDayDTO `json:"days"`
+	Totals   plantReportDayDTO   `json:"totals"`
+}
+
+type plantReportDayDTO struct {
+	Date              string   `json:"date,omitempty"`
+	GeneratedKWh      float64  `json:"generated_kwh"`
+	ContainerKWh      float64  `json:"container_consumption_kwh"`
+	SurplusKWh        float64  `json:"surplus_kwh"`
+	CurtailedAtMaxKWh float64  `json:"curtailed_at_max_kwh"`
+	ShortKWh          float64  `json:"short_kwh"`
+	AtMaxSeconds      float64  `json:"at_max_seconds"`
+	CapacityKW        *float64 `json:"capacity_kw"`
+	CapacityFactor    *float64 `json:"capacity_factor"`
+	PlantCoverage     float64  `json:"plant_coverage"`
+	ComputedAt        string   `json:"computed_at,omitempty"`
+}
+
+// handlePlantReport lists the stored daily plant metrics for the days
+// between from and to, as JSON or CSV. Days without plant readings are
+// absent. The totals weigh each day's capacity factor by the time its
+// readings cover.
+func (s *Server) handlePlantReport(w http.ResponseWriter, r *http.Request) {
+	loc := time.UTC
+	if s.plantMetrics != nil {
+		loc = s.plantMetrics.MetricsTimezone()
+	}
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "unsupported report format; use json or csv")
+		return
+	}
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fromDay := from.In(loc).Format(time.DateOnly)
+	toDay := to.Add(-time.Nanosecond).In(loc).Format(time.DateOnly)
+
+	days, err := s.store.ListPlantDailyMetrics(r.Context(), loc.String(), fromDay, toDay)
+	if err != nil {
+		s.log.Error("list plant metrics failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to build plant report")
+		return
+	}
+
+	out := plantReportDTO{
+		From:     fromDay,
+		To:       toDay,
+		Timezone: loc.String(),
+		Days:     make([]plantReportDayDTO, 0, len(days)),
+	}
+	var (
+		totals           database.PlantDailyMetrics
+		capacityKWh      float64
+		capacityCoverage time.Duration
+		length           time.Duration
+	)
+	for _, day := range days {
+		out.Days = append(out.Days, toPlantReportDayDTO(day))
+		totals.Generated += day.Generated
+		totals.Container += day.Container
+		totals.Surplus += day.Surplus
+		totals.CurtailedAtMax += day.CurtailedAtMax
+		totals.Short += day.Short
+		totals.AtMax += day.AtMax
+		totals.Covered += day.Covered
+		if day.Coverage > 0 {
+			length += time.Duration(float64(day.Covered) / day.Coverage)
+		}
+		if day.CapacityKW != nil {
+			capacityKWh += *day.CapacityKW * day.Covered.Hours()
+			capacityCoverage += day.Covered
+		}
+	}
+	if length > 0 {
+		totals.Coverage = totals.Covered.Seconds() / length.Seconds()
+	}
+	if capacityKWh > 0 && capacityCoverage == totals.Covered {
+		factor := totals.Generated / capacityKWh
+		totals.CapacityFactor = &factor
+	}
+	out.Totals = toPlantReportDayDTO(totals)
+
+	if format != "csv" {
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	name := fmt.Sprintf("powerhive-plant-%s-%s.csv", from.In(loc).Format("20060102"), to.In(loc).Format("20060102"))
+	csvOut := startCSVExport(w, name)
+	_ = csvOut.write([]string{
+		"date", "generated_kwh", "container_consumption_kwh", "surplus_kwh", "curtailed_at_max_kwh",
+		"short_kwh", "at_max_seconds", "capacity_kw", "capacity_factor", "plant_coverage",
+	})
+	for _, day := range append(out.Days, out.Totals) {
+		date := day.Date
+		if date == "" {
+			date = "total"
+		}
+		_ = csvOut.write([]string{
+			date,
+			strconv.FormatFloat(day.GeneratedKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.ContainerKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.SurplusKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.CurtailedAtMaxKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.ShortKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.AtMaxSeconds, 'f', -1, 64),
+			csvFloat(day.CapacityKW),
+			csvFloat(day.CapacityFactor),
+			strconv.FormatFloat(day.PlantCoverage, 'f', -1, 64),
+		})
+	}
+	if err := csvOut.finish(); err != nil {
+		s.log.Error("write plant report failed", "err", err)
+	}
+}
+
+func toPlantReportDayDTO(day database.PlantDailyMetrics) plantReportDayDTO {
+	out := plantReportDayDTO{
+		Date:              day.Day,
+		GeneratedKWh:      roundKWh(day.Generated),
+		ContainerKWh:      roundKWh(day.Container),
+		SurplusKWh:        roundKWh(day.Surplus),
+		CurtailedAtMaxKWh: roundKWh(day.CurtailedAtMax),
+		ShortKWh:          roundKWh(day.Short),
+		AtMaxSeconds:      math.Round(day.AtMax.Seconds()),
+		CapacityKW:        day.CapacityKW,
+		PlantCoverage:     math.Round(min(1, day.Coverage)*1000) / 1000,
+	}
+	if day.CapacityFactor != nil {
+		factor := math.Round(*day.CapacityFactor*10000) / 10000
+		out.CapacityFactor = &factor
+	}
+	if !day.ComputedAt.IsZero() {
+		out.ComputedAt = formatTime(day.ComputedAt)
+	}
+	return out
+}
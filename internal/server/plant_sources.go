@@ -0,0 +1,175 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+const (
+	plantSourcesDefaultRange = 24 * time.Hour
+	plantSourceMaxPoints     = 50000
+)
+
+type plantSourceDTO struct {
+	Name     string  `json:"name"`
+	Kind     string  `json:"kind"`
+	Samples  int     `json:"samples"`
+	LatestMW float64 `json:"latest_mw"`
+	LatestKW float64 `json:"latest_kw"`
+	LatestAt string  `json:"latest_at"`
+	MinMW    float64 `json:"min_mw"`
+	MaxMW    float64 `json:"max_mw"`
+	AvgMW    float64 `json:"avg_mw"`
+}
+
+type plantSourceListDTO struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Sources []plantSourceDTO `json:"sources"`
+}
+
+type plantSourcePointDTO struct {
+	RecordedAt string  `json:"recorded_at"`
+	MW         float64 `json:"mw"`
+	KW         float64 `json:"kw"`
+}
+
+type plantSourceHistoryDTO struct {
+	Name        string                `json:"name"`
+	Kind        string                `json:"kind"`
+	From        string                `json:"from"`
+	To          string                `json:"to"`
+	StepSeconds int                   `json:"step_seconds,omitempty"`
+	Truncated   bool                  `json:"truncated"`
+	Points      []plantSourcePointDTO `json:"points"`
+}
+
+// plantSourceRange parses from and to like the exports, defaulting to the
+// last 24 hours.
+func plantSourceRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if strings.TrimSpace(query.Get("from")) == "" {
+		from = to.Add(-plantSourcesDefaultRange)
+	}
+	return from, to, nil
+}
+
+// listPlantSources lists the generation and consumption sources the plant
+// reported between from and to, with their latest, minimum, maximum and
+// average values.
+func (s *Server) listPlantSources(w http.ResponseWriter, r *http.Request) {
+	from, to, err := plantSourceRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	summaries, err := s.store.SummarizePlantSources(r.Context(), from, to)
+	if err != nil {
+		s.log.Error("summarize plant sources failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch plant sources")
+		return
+	}
+
+	out := plantSourceListDTO{
+		From:    formatTime(from),
+		To:      formatTime(to),
+		Sources: make([]plantSourceDTO, 0, len(summaries)),
+	}
+	for _, summary := range summaries {
+		out.Sources = append(out.Sources, plantSourceDTO{
+			Name:     summary.Name,
+			Kind:     summary.Kind,
+			Samples:  summary.Samples,
+			LatestMW: summary.LatestMW,
+			LatestKW: summary.LatestMW * 1000,
+			LatestAt: formatTime(summary.LatestAt),
+			MinMW:    summary.MinMW,
+			MaxMW:    summary.MaxMW,
+			AvgMW:    summary.AvgMW,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handlePlantSourceHistory returns the time series of one source. kind
+// picks generation or consumption when a name is used by both; without it
+// generation sources are searched first. step (seconds) averages the
+// readings into buckets.
+func (s *Server) handlePlantSourceHistory(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.PathValue("name"))
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "source name is required")
+		return
+	}
+	query := r.URL.Query()
+
+	kinds := []string{database.PlantSourceGeneration, database.PlantSourceConsumption}
+	switch kind := strings.ToLower(strings.TrimSpace(query.Get("kind"))); kind {
+	case "":
+	case database.PlantSourceGeneration, database.PlantSourceConsumption:
+		kinds = []string{kind}
+	default:
+		writeError(w, http.StatusBadRequest, "kind must be generation or consumption")
+		return
+	}
+
+	var step time.Duration
+	if raw := strings.TrimSpace(query.Get("step")); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 {
+			writeError(w, http.StatusBadRequest, "step must be a positive number of seconds")
+			return
+		}
+		step = time.Duration(seconds) * time.Second
+	}
+
+	from, to, err := plantSourceRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, kind := range kinds {
+		points, err := s.store.PlantSourceHistory(r.Context(), kind, name, from, to, step, plantSourceMaxPoints+1)
+		if err != nil {
+			s.log.Error("plant source history failed", "source", name, "kind", kind, "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to fetch plant source history")
+			return
+		}
+		if len(points) == 0 {
+			continue
+		}
+
+		out := plantSourceHistoryDTO{
+			Name:        name,
+			Kind:        kind,
+			From:        formatTime(from),
+			To:          formatTime(to),
+			StepSeconds: int(step / time.Second),
+			Truncated:   len(points) > plantSourceMaxPoints,
+		}
+		if out.Truncated {
+			points = points[:plantSourceMaxPoints]
+		}
+		out.Points = make([]plantSourcePointDTO, 0, len(points))
+		for _, point := range points {
+			out.Points = append(out.Points, plantSourcePointDTO{
+				RecordedAt: formatTime(point.RecordedAt),
+				MW:         point.MW,
+				KW:         point.MW * 1000,
+			})
+		}
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+	writeError(w, http.StatusNotFound, "plant source not found in range")
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"powerhive/internal/database"
+)
+
+type powerMeasurementDTO struct {
+	ID             int64    `json:"id"`
+	Outlets        string   `json:"outlets"`
+	PowerW         float64  `json:"power_w"`
+	FirmwarePowerW *float64 `json:"firmware_power_w"`
+	// DifferenceW is the measured minus the firmware-reported power.
+	DifferenceW *float64 `json:"difference_w"`
+	RecordedAt  string   `json:"recorded_at"`
+}
+
+func toPowerMeasurementDTO(m database.PowerMeasurement) powerMeasurementDTO {
+	out := powerMeasurementDTO{
+		ID:             m.ID,
+		Outlets:        m.Outlets,
+		PowerW:         m.PowerW,
+		FirmwarePowerW: m.FirmwarePowerW,
+		RecordedAt:     formatTime(m.RecordedAt),
+	}
+	if m.FirmwarePowerW != nil {
+		difference := m.PowerW - *m.FirmwarePowerW
+		out.DifferenceW = &difference
+	}
+	return out
+}
+
+// listMinerPowerMeasurements lists the power measured at the miner's PDU
+// outlets next to what its firmware reported, newest first.
+func (s *Server) listMinerPowerMeasurements(w http.ResponseWriter, r *http.Request, minerID string) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	measurements, err := s.store.ListPowerMeasurements(r.Context(), minerID, limit)
+	if err != nil {
+		s.log.Error("list power measurements failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch power measurements")
+		return
+	}
+
+	out := make([]powerMeasurementDTO, 0, len(measurements))
+	for _, m := range measurements {
+		out = append(out, toPowerMeasurementDTO(m))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
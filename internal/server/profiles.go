@@ -0,0 +1,453 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// ErrRolloutInProgress is returned by ProfileRollout.StartRollout when
+// another rollout is queued or running.
+var ErrRolloutInProgress = errors.New("profile rollout already in progress")
+
+// Profile rollout job states. Targets use the firmware target states plus
+// TargetApplying and TargetApplied.
+const (
+	RolloutQueued    = "queued"
+	RolloutRunning   = "running"
+	RolloutCompleted = "completed"
+	RolloutFailed    = "failed"
+
+	TargetApplying = "applying"
+	TargetApplied  = "applied"
+)
+
+// ProfileRollout is implemented by the configuration profile rollout engine.
+type ProfileRollout interface {
+	StartRollout(ctx context.Context, req ProfileRolloutRequest) (ProfileRolloutJob, error)
+	RolloutJobs() []ProfileRolloutJob
+	RolloutJob(id string) (ProfileRolloutJob, bool)
+}
+
+// ProfileRolloutRequest selects the profile to apply. Without MinerIDs it
+// goes to every miner carrying the profile's tag. The job stops after the
+// batch in which more than MaxFailures miners have failed. Protected
+// miners are skipped unless Force is set.
+type ProfileRolloutRequest struct {
+	ProfileID   int64
+	MinerIDs    []string
+	BatchSize   int
+	MaxFailures int
+	Force       bool
+}
+
+// ProfileRolloutJob reports the progress of a rollout.
+type ProfileRolloutJob struct {
+	ID          string
+	ProfileID   int64
+	ProfileName string
+	State       string
+	BatchSize   int
+	MaxFailures int
+	Targets     []ProfileRolloutTarget
+	Error       string
+	CreatedAt   time.Time
+	StartedAt   time.Time
+	FinishedAt  time.Time
+}
+
+// ProfileRolloutTarget tracks a single miner within a rollout.
+type ProfileRolloutTarget struct {
+	MinerID         string
+	Batch           int
+	State           string
+	RestartRequired bool
+	RebootRequired  bool
+	Error           string
+}
+
+// WithProfileRollout enables the profile rollout endpoints.
+func WithProfileRollout(r ProfileRollout) Option {
+	return func(s *Server) {
+		s.rollout = r
+	}
+}
+
+// profileRequest is the body of POST /api/profiles and PUT
+// /api/profiles/{id}. A pool without "pass" keeps the password the profile
+// already stores for the same url and user, since responses never carry it.
+type profileRequest struct {
+	Name    string  `json:"name"`
+	Tag     *string `json:"tag"`
+	Cooling *struct {
+		Mode        string `json:"mode"`
+		FanDuty     *int   `json:"fan_duty"`
+		FanMinCount *int   `json:"fan_min_count"`
+		FanMinDuty  *int   `json:"fan_min_duty"`
+		FanMaxDuty  *int   `json:"fan_max_duty"`
+	} `json:"cooling"`
+	Misc *struct {
+		IgnoreBrokenSensors  bool `json:"ignore_broken_sensors"`
+		MinOperationalChains *int `json:"min_operational_chains"`
+	} `json:"misc"`
+	Pools []struct {
+		URL  string  `json:"url"`
+		User *string `json:"user"`
+		Pass *string `json:"pass"`
+	} `json:"pools"`
+}
+
+func (req profileRequest) input(existing *database.ConfigProfile) database.ConfigProfileInput {
+	input := database.ConfigProfileInput{Name: req.Name, Tag: req.Tag}
+	if req.Cooling != nil {
+		input.Cooling = &database.CoolingSettingsInput{
+			Mode:        req.Cooling.Mode,
+			FanDuty:     req.Cooling.FanDuty,
+			FanMinCount: req.Cooling.FanMinCount,
+			FanMinDuty:  req.Cooling.FanMinDuty,
+			FanMaxDuty:  req.Cooling.FanMaxDuty,
+		}
+	}
+	if req.Misc != nil {
+		input.Misc = &database.MiscSettingsInput{
+			IgnoreBrokenSensors:  req.Misc.IgnoreBrokenSensors,
+			MinOperationalChains: req.Misc.MinOperationalChains,
+		}
+	}
+	for _, pool := range req.Pools {
+		entry := database.PoolInput{URL: pool.URL, Username: pool.User, Password: pool.Pass}
+		if entry.Password == nil && existing != nil {
+			for _, old := range existing.Pools {
+				if strings.TrimSpace(old.URL) == strings.TrimSpace(pool.URL) && derefString(old.Username) == derefString(pool.User) {
+					entry.Password = old.Password
+					break
+				}
+			}
+		}
+		input.Pools = append(input.Pools, entry)
+	}
+	return input
+}
+
+type profileDTO struct {
+	ID        int64            `json:"id"`
+	Name      string           `json:"name"`
+	Tag       *string          `json:"tag"`
+	Cooling   *coolingDTO      `json:"cooling"`
+	Misc      *profileMiscDTO  `json:"misc"`
+	Pools     []profilePoolDTO `json:"pools"`
+	CreatedAt string           `json:"created_at"`
+	UpdatedAt string           `json:"updated_at"`
+}
+
+type profileMiscDTO struct {
+	IgnoreBrokenSensors  bool `json:"ignore_broken_sensors"`
+	MinOperationalChains *int `json:"min_operational_chains,omitempty"`
+}
+
+type profilePoolDTO struct {
+	URL         string  `json:"url"`
+	User        *string `json:"user"`
+	HasPassword bool    `json:"has_password"`
+}
+
+func toProfileDTO(profile database.ConfigProfile) profileDTO {
+	dto := profileDTO{
+		ID:        profile.ID,
+		Name:      profile.Name,
+		Tag:       profile.Tag,
+		Pools:     make([]profilePoolDTO, 0, len(profile.Pools)),
+		CreatedAt: formatTime(profile.CreatedAt),
+		UpdatedAt: formatTime(profile.UpdatedAt),
+	}
+	if c := profile.Cooling; c != nil {
+		dto.Cooling = &coolingDTO{
+			Mode:        c.Mode,
+			FanDuty:     c.FanDuty,
+			FanMinCount: c.FanMinCount,
+			FanMinDuty:  c.FanMinDuty,
+			FanMaxDuty:  c.FanMaxDuty,
+		}
+	}
+	if m := profile.Misc; m != nil {
+		dto.Misc = &profileMiscDTO{
+			IgnoreBrokenSensors:  m.IgnoreBrokenSensors,
+			MinOperationalChains: m.MinOperationalChains,
+		}
+	}
+	for _, pool := range profile.Pools {
+		dto.Pools = append(dto.Pools, profilePoolDTO{
+			URL:         pool.URL,
+			User:        pool.Username,
+			HasPassword: pool.Password != nil && *pool.Password != "",
+		})
+	}
+	return dto
+}
+
+func (s *Server) listProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := s.store.ListConfigProfiles(r.Context())
+	if err != nil {
+		s.log.Error("list config profiles failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch profiles")
+		return
+	}
+	out := make([]profileDTO, 0, len(profiles))
+	for _, profile := range profiles {
+		out = append(out, toProfileDTO(profile))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createProfile(w http.ResponseWriter, r *http.Request) {
+	var req profileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	input := req.input(nil)
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	profile, err := s.store.CreateConfigProfile(r.Context(), input)
+	if err != nil {
+		s.writeProfileError(w, 0, err)
+		return
+	}
+	s.log.Info("config profile created", "profile", profile.ID, "name", profile.Name, "tag", derefString(profile.Tag))
+	writeJSON(w, http.StatusCreated, toProfileDTO(profile))
+}
+
+func (s *Server) getProfile(w http.ResponseWriter, r *http.Request, id int64) {
+	profile, err := s.store.GetConfigProfile(r.Context(), id)
+	if err != nil {
+		s.writeProfileError(w, id, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toProfileDTO(profile))
+}
+
+func (s *Server) updateProfile(w http.ResponseWriter, r *http.Request, id int64) {
+	ctx := r.Context()
+	existing, err := s.store.GetConfigProfile(ctx, id)
+	if err != nil {
+		s.writeProfileError(w, id, err)
+		return
+	}
+	var req profileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	input := req.input(&existing)
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	profile, err := s.store.UpdateConfigProfile(ctx, id, input)
+	if err != nil {
+		s.writeProfileError(w, id, err)
+		return
+	}
+	s.log.Info("config profile updated", "profile", id, "name", profile.Name, "tag", derefString(profile.Tag))
+	writeJSON(w, http.StatusOK, toProfileDTO(profile))
+}
+
+func (s *Server) deleteProfile(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := s.store.DeleteConfigProfile(r.Context(), id); err != nil {
+		s.writeProfileError(w, id, err)
+		return
+	}
+	s.log.Info("config profile deleted", "profile", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// startProfileRollout accepts an optional body with "miners", "batch_size"
+// and "max_failures". An admin may add ?force=true to include protected
+// miners.
+func (s *Server) startProfileRollout(w http.ResponseWriter, r *http.Request, id int64) {
+	if s.rollout == nil {
+		writeError(w, http.StatusServiceUnavailable, "profile rollouts are not available")
+		return
+	}
+	force, ok := s.forceRequested(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Miners      []string `json:"miners"`
+		BatchSize   *int     `json:"batch_size"`
+		MaxFailures *int     `json:"max_failures"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	rolloutReq := ProfileRolloutRequest{ProfileID: id, MinerIDs: req.Miners, Force: force}
+	if req.BatchSize != nil {
+		if *req.BatchSize <= 0 {
+			writeError(w, http.StatusBadRequest, "batch_size must be a positive integer")
+			return
+		}
+		rolloutReq.BatchSize = *req.BatchSize
+	}
+	if req.MaxFailures != nil {
+		if *req.MaxFailures < 0 {
+			writeError(w, http.StatusBadRequest, "max_failures must not be negative")
+			return
+		}
+		rolloutReq.MaxFailures = *req.MaxFailures
+	}
+
+	job, err := s.rollout.StartRollout(r.Context(), rolloutReq)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRolloutInProgress):
+			writeError(w, http.StatusConflict, err.Error())
+		case isNotFound(err):
+			writeError(w, http.StatusNotFound, err.Error())
+		default:
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	s.log.Info("profile rollout queued", "job", job.ID, "profile", id, "miners", len(job.Targets), "force", force)
+	writeJSON(w, http.StatusAccepted, toRolloutJobDTO(job))
+}
+
+func (s *Server) listRollouts(w http.ResponseWriter, r *http.Request) {
+	if s.rollout == nil {
+		writeError(w, http.StatusServiceUnavailable, "profile rollouts are not available")
+		return
+	}
+
+	jobs := s.rollout.RolloutJobs()
+	out := make([]rolloutJobDTO, 0, len(jobs))
+	for _, job := range jobs {
+		out = append(out, toRolloutJobDTO(job))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getRollout(w http.ResponseWriter, r *http.Request) {
+	if s.rollout == nil {
+		writeError(w, http.StatusServiceUnavailable, "profile rollouts are not available")
+		return
+	}
+
+	job, ok := s.rollout.RolloutJob(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "rollout not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, toRolloutJobDTO(job))
+}
+
+// writeProfileError maps a profile store error to a response.
+func (s *Server) writeProfileError(w http.ResponseWriter, id int64, err error) {
+	switch {
+	case isNotFound(err):
+		writeError(w, http.StatusNotFound, "profile not found")
+	case errors.Is(err, database.ErrProfileExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		s.log.Error("config profile request failed", "profile", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to save profile")
+	}
+}
+
+type rolloutJobDTO struct {
+	ID          string             `json:"id"`
+	ProfileID   int64              `json:"profile_id"`
+	ProfileName string             `json:"profile_name"`
+	State       string             `json:"state"`
+	BatchSize   int                `json:"batch_size"`
+	MaxFailures int                `json:"max_failures"`
+	Progress    rolloutProgressDTO `json:"progress"`
+	Targets     []rolloutTargetDTO `json:"targets"`
+	Error       *string            `json:"error"`
+	CreatedAt   string             `json:"created_at"`
+	StartedAt   *string            `json:"started_at"`
+	FinishedAt  *string            `json:"finished_at"`
+}
+
+// rolloutProgressDTO counts the job's targets by state.
+type rolloutProgressDTO struct {
+	Total   int `json:"total"`
+	Pending int `json:"pending"`
+	Applied int `json:"applied"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+type rolloutTargetDTO struct {
+	MinerID         string  `json:"miner_id"`
+	Batch           int     `json:"batch"`
+	State           string  `json:"state"`
+	RestartRequired bool    `json:"restart_required"`
+	RebootRequired  bool    `json:"reboot_required"`
+	Error           *string `json:"error"`
+}
+
+func toRolloutJobDTO(job ProfileRolloutJob) rolloutJobDTO {
+	dto := rolloutJobDTO{
+		ID:          job.ID,
+		ProfileID:   job.ProfileID,
+		ProfileName: job.ProfileName,
+		State:       job.State,
+		BatchSize:   job.BatchSize,
+		MaxFailures: job.MaxFailures,
+		Progress:    rolloutProgressDTO{Total: len(job.Targets)},
+		Targets:     make([]rolloutTargetDTO, 0, len(job.Targets)),
+		Error:       optionalString(job.Error),
+		CreatedAt:   formatTime(job.CreatedAt),
+	}
+	if !job.StartedAt.IsZero() {
+		started := formatTime(job.StartedAt)
+		dto.StartedAt = &started
+	}
+	if !job.FinishedAt.IsZero() {
+		finished := formatTime(job.FinishedAt)
+		dto.FinishedAt = &finished
+	}
+
+	for _, target := range job.Targets {
+		switch target.State {
+		case TargetApplied:
+			dto.Progress.Applied++
+		case TargetFailed:
+			dto.Progress.Failed++
+		case TargetSkipped:
+			dto.Progress.Skipped++
+		default:
+			dto.Progress.Pending++
+		}
+		dto.Targets = append(dto.Targets, rolloutTargetDTO{
+			MinerID:         target.MinerID,
+			Batch:           target.Batch,
+			State:           target.State,
+			RestartRequired: target.RestartRequired,
+			RebootRequired:  target.RebootRequired,
+			Error:           optionalString(target.Error),
+		})
+	}
+
+	return dto
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// ContainerReconciliation is a container's latest reconciliation. Flagged
+// is set when its discrepancy exceeds the alert threshold.
+type ContainerReconciliation struct {
+	database.ContainerReconciliation
+	Flagged bool
+}
+
+// Reconciliation is the latest comparison of the container meters with the
+// miners' estimated consumption. CorrectionKW is what the balancer adds to
+// its consumption estimate, nil when it does not correct.
+type Reconciliation struct {
+	Containers   []ContainerReconciliation
+	AlertPercent float64
+	CorrectionKW *float64
+}
+
+// ReconciliationReporter reports the latest container reconciliation.
+type ReconciliationReporter interface {
+	Reconciliation(ctx context.Context) (Reconciliation, bool)
+}
+
+// WithReconciliation adds the container reconciliation to
+// /api/balance/status.
+func WithReconciliation(r ReconciliationReporter) Option {
+	return func(s *Server) {
+		s.reconciliation = r
+	}
+}
+
+type containerReconciliationDTO struct {
+	Container          string   `json:"container"`
+	Location           string   `json:"location"`
+	MeterKW            float64  `json:"meter_kw"`
+	EstimatedKW        float64  `json:"estimated_kw"`
+	DiscrepancyKW      float64  `json:"discrepancy_kw"`
+	DiscrepancyPercent *float64 `json:"discrepancy_percent"`
+	Miners             int      `json:"miners"`
+	Flagged            bool     `json:"flagged"`
+	ReadingAt          string   `json:"reading_at"`
+	RecordedAt         string   `json:"recorded_at"`
+}
+
+type reconciliationDTO struct {
+	Containers   []containerReconciliationDTO `json:"containers"`
+	AlertPercent float64                      `json:"alert_percent"`
+	CorrectionKW *float64                     `json:"correction_kw"`
+}
+
+func toContainerReconciliationDTO(rec database.ContainerReconciliation, flagged bool) containerReconciliationDTO {
+	out := containerReconciliationDTO{
+		Container:     rec.Container,
+		Location:      rec.Location,
+		MeterKW:       rec.MeterKW,
+		EstimatedKW:   rec.EstimatedKW,
+		DiscrepancyKW: rec.DiscrepancyKW,
+		Miners:        rec.Miners,
+		Flagged:       flagged,
+		ReadingAt:     formatTime(rec.ReadingAt),
+		RecordedAt:    formatTime(rec.RecordedAt),
+	}
+	if rec.MeterKW > 0 {
+		percent := rec.DiscrepancyKW / rec.MeterKW * 100
+		out.DiscrepancyPercent = &percent
+	}
+	return out
+}
+
+func toReconciliationDTO(r Reconciliation) *reconciliationDTO {
+	out := &reconciliationDTO{
+		Containers:   make([]containerReconciliationDTO, 0, len(r.Containers)),
+		AlertPercent: r.AlertPercent,
+		CorrectionKW: r.CorrectionKW,
+	}
+	for _, rec := range r.Containers {
+		out.Containers = append(out.Containers, toContainerReconciliationDTO(rec.ContainerReconciliation, rec.Flagged))
+	}
+	return out
+}
+
+// listReconciliations returns the container reconciliations recorded
+// between from and to, oldest first. Without from, the range starts a day
+// before to.
+func (s *Server) listReconciliations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(query.Get("from")) == "" {
+		from = to.Add(-24 * time.Hour)
+	}
+
+	reconciliations, err := s.store.ListContainerReconciliations(r.Context(), from, to)
+	if err != nil {
+		s.log.Error("list reconciliations failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch reconciliations")
+		return
+	}
+
+	out := make([]containerReconciliationDTO, 0, len(reconciliations))
+	for _, rec := range reconciliations {
+		out = append(out, toContainerReconciliationDTO(rec, false))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,278 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// reportMaxGap is the longest interval between two samples that is
+// integrated into energy. Longer gaps are treated as missing data and show
+// up as reduced coverage.
+const reportMaxGap = 5 * time.Minute
+
+// energyDay accumulates one calendar day of the energy report.
+type energyDay struct {
+	date      time.Time
+	generated float64
+	container float64
+	miners    float64
+	curtailed float64
+	// cost prices miners at the tariff; only per-miner reports fill it.
+	cost    float64
+	covered time.Duration
+	length  time.Duration
+}
+
+// energyReport integrates plant readings and miner statuses over
+// [from, to), split into days in loc.
+type energyReport struct {
+	from, to time.Time
+	loc      *time.Location
+	days     []*energyDay
+}
+
+func newEnergyReport(from, to time.Time, loc *time.Location) *energyReport {
+	report := &energyReport{from: from, to: to, loc: loc}
+
+	local := from.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	for start.Before(to) {
+		end := start.AddDate(0, 0, 1)
+		clippedStart, clippedEnd := start, end
+		if clippedStart.Before(from) {
+			clippedStart = from
+		}
+		if clippedEnd.After(to) {
+			clippedEnd = to
+		}
+		report.days = append(report.days, &energyDay{date: start, length: clippedEnd.Sub(clippedStart)})
+		start = end
+	}
+	return report
+}
+
+// spread calls add with the part of seg within every day it overlaps,
+// clipped to the report range.
+func (e *energyReport) spread(seg database.PowerSegment, add func(day *energyDay, part database.PowerSegment)) {
+	seg, ok := seg.Clip(e.from, e.to)
+	if !ok {
+		return
+	}
+	for _, day := range e.days {
+		if part, ok := seg.Clip(day.date, day.date.AddDate(0, 0, 1)); ok {
+			add(day, part)
+		}
+	}
+}
+
+// addPlant integrates the readings between prev and next with the
+// trapezoidal rule. Plant values are in kW.
+func (e *energyReport) addPlant(prev, next database.PlantReading) {
+	seg, ok := database.NewPowerSegment(prev.RecordedAt, prev.TotalGeneration, next.RecordedAt, next.TotalGeneration, reportMaxGap)
+	if !ok {
+		return
+	}
+	e.spread(seg, func(day *energyDay, part database.PowerSegment) {
+		day.generated += part.KWh()
+		day.covered += part.Duration()
+	})
+	seg.StartKW, seg.EndKW = prev.TotalContainerConsumption, next.TotalContainerConsumption
+	e.spread(seg, func(day *energyDay, part database.PowerSegment) {
+		day.container += part.KWh()
+	})
+	seg.StartKW, seg.EndKW = math.Max(0, prev.AvailablePower), math.Max(0, next.AvailablePower)
+	e.spread(seg, func(day *energyDay, part database.PowerSegment) {
+		day.curtailed += part.KWh()
+	})
+}
+
+// minerSegment joins two of a miner's samples. Miner power is in watts.
+func minerSegment(prev, next database.StatusPower) (database.PowerSegment, bool) {
+	return database.NewStatusSegment(prev, next, reportMaxGap)
+}
+
+// addMiner integrates a miner's power between prev and next with the
+// trapezoidal rule.
+func (e *energyReport) addMiner(prev, next database.StatusPower) {
+	seg, ok := minerSegment(prev, next)
+	if !ok {
+		return
+	}
+	e.spread(seg, func(day *energyDay, part database.PowerSegment) {
+		day.miners += part.KWh()
+	})
+}
+
+type energyReportDTO struct {
+	From          string               `json:"from"`
+	To            string               `json:"to"`
+	Timezone      string               `json:"timezone"`
+	MaxGapSeconds int                  `json:"max_gap_seconds"`
+	Days          []energyReportDayDTO `json:"days"`
+	Totals        energyReportDayDTO   `json:"totals"`
+}
+
+type energyReportDayDTO struct {
+	Date                    string  `json:"date,omitempty"`
+	GeneratedKWh            float64 `json:"generated_kwh"`
+	ContainerConsumptionKWh float64 `json:"container_consumption_kwh"`
+	MinerConsumptionKWh     float64 `json:"miner_consumption_kwh"`
+	CurtailedKWh            float64 `json:"curtailed_kwh"`
+	// PlantCoverage is the fraction of the period backed by plant readings.
+	PlantCoverage float64 `json:"plant_coverage"`
+}
+
+func (e *energyReport) dto() energyReportDTO {
+	out := energyReportDTO{
+		From:          formatTime(e.from),
+		To:            formatTime(e.to),
+		Timezone:      e.loc.String(),
+		MaxGapSeconds: int(reportMaxGap / time.Second),
+		Days:          make([]energyReportDayDTO, 0, len(e.days)),
+	}
+
+	var totals energyDay
+	for _, day := range e.days {
+		out.Days = append(out.Days, day.dto(day.date.Format(time.DateOnly)))
+		totals.generated += day.generated
+		totals.container += day.container
+		totals.miners += day.miners
+		totals.curtailed += day.curtailed
+		totals.covered += day.covered
+		totals.length += day.length
+	}
+	out.Totals = totals.dto("")
+	return out
+}
+
+func (d *energyDay) dto(date string) energyReportDayDTO {
+	coverage := 0.0
+	if d.length > 0 {
+		coverage = math.Min(1, d.covered.Seconds()/d.length.Seconds())
+	}
+	return energyReportDayDTO{
+		Date:                    date,
+		GeneratedKWh:            roundKWh(d.generated),
+		ContainerConsumptionKWh: roundKWh(d.container),
+		MinerConsumptionKWh:     roundKWh(d.miners),
+		CurtailedKWh:            roundKWh(d.curtailed),
+		PlantCoverage:           math.Round(coverage*1000) / 1000,
+	}
+}
+
+func roundKWh(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// reportQuery holds the parameters shared by the energy reports.
+type reportQuery struct {
+	format   string
+	loc      *time.Location
+	from, to time.Time
+}
+
+// parseReportQuery reads format (json or csv), tz and the from/to range,
+// writing a 400 and returning false when one is invalid.
+func parseReportQuery(w http.ResponseWriter, r *http.Request) (reportQuery, bool) {
+	query := r.URL.Query()
+	format := strings.ToLower(query.Get("format"))
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "unsupported report format; use json or csv")
+		return reportQuery{}, false
+	}
+
+	loc := time.UTC
+	if tz := strings.TrimSpace(query.Get("tz")); tz != "" {
+		parsed, err := time.LoadLocation(tz)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown time zone %q", tz))
+			return reportQuery{}, false
+		}
+		loc = parsed
+	}
+
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return reportQuery{}, false
+	}
+
+	// Integrating a month of status history can outlive the write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	return reportQuery{format: format, loc: loc, from: from, to: to}, true
+}
+
+func (s *Server) handleEnergyReport(w http.ResponseWriter, r *http.Request) {
+	params, ok := parseReportQuery(w, r)
+	if !ok {
+		return
+	}
+	format, loc, from, to := params.format, params.loc, params.from, params.to
+
+	ctx := r.Context()
+	report := newEnergyReport(from, to, loc)
+
+	var prevReading *database.PlantReading
+	err := s.store.ForEachPlantReading(ctx, from.Add(-reportMaxGap), to.Add(reportMaxGap), func(reading database.PlantReading) error {
+		if prevReading != nil {
+			report.addPlant(*prevReading, reading)
+		}
+		prevReading = &reading
+		return nil
+	})
+	if err != nil {
+		s.log.Error("energy report plant readings failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to build energy report")
+		return
+	}
+
+	prevStatus := make(map[string]database.StatusPower)
+	err = s.store.ForEachStatusPower(ctx, from.Add(-reportMaxGap), to.Add(reportMaxGap), func(sample database.StatusPower) error {
+		if prev, ok := prevStatus[sample.MinerID]; ok {
+			report.addMiner(prev, sample)
+		}
+		prevStatus[sample.MinerID] = sample
+		return nil
+	})
+	if err != nil {
+		s.log.Error("energy report statuses failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to build energy report")
+		return
+	}
+
+	out := report.dto()
+	if format != "csv" {
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	name := fmt.Sprintf("powerhive-energy-%s-%s.csv", from.In(loc).Format("20060102"), to.In(loc).Format("20060102"))
+	csvOut := startCSVExport(w, name)
+	_ = csvOut.write([]string{
+		"date", "generated_kwh", "container_consumption_kwh", "miner_consumption_kwh",
+		"curtailed_kwh", "plant_coverage",
+	})
+	for _, day := range append(out.Days, out.Totals) {
+		date := day.Date
+		if date == "" {
+			date = "total"
+		}
+		_ = csvOut.write([]string{
+			date,
+			strconv.FormatFloat(day.GeneratedKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.ContainerConsumptionKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.MinerConsumptionKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.CurtailedKWh, 'f', -1, 64),
+			strconv.FormatFloat(day.PlantCoverage, 'f', -1, 64),
+		})
+	}
+	if err := csvOut.finish(); err != nil {
+		s.log.Error("write energy report failed", "err", err)
+	}
+}
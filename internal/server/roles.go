@@ -0,0 +1,70 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"powerhive/internal/database"
+)
+
+// ErrMinerProtected is reported for a protected miner that a change left
+// alone because it was not forced by an admin.
+var ErrMinerProtected = errors.New("miner is protected; an admin must pass force=true")
+
+// WithAdminTokens sets the bearer tokens that carry the admin role. Without
+// any, and unless sign-in is required, every caller without a session is an
+// admin, as the API is otherwise unauthenticated.
+func WithAdminTokens(tokens []string) Option {
+	return func(s *Server) {
+		s.adminTokens = tokens
+	}
+}
+
+// isAdmin reports whether the request carries the admin role, through its
+// session's user or an admin token.
+func (s *Server) isAdmin(r *http.Request) bool {
+	if user, ok := sessionUser(r.Context()); ok {
+		return user.Role == database.RoleAdmin
+	}
+	if s.hasAdminToken(r) {
+		return true
+	}
+	return len(s.adminTokens) == 0 && !s.sessions.Required
+}
+
+// hasAdminToken reports whether the request's bearer token is an admin
+// token.
+func (s *Server) hasAdminToken(r *http.Request) bool {
+	token := bearerToken(r)
+	if token == "" {
+		return false
+	}
+	admin := false
+	for _, candidate := range s.adminTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+			admin = true
+		}
+	}
+	return admin
+}
+
+// forceRequested parses ?force=. Only admins may force a change; for anyone
+// else it writes 403 and returns ok false.
+func (s *Server) forceRequested(w http.ResponseWriter, r *http.Request) (force, ok bool) {
+	raw := r.URL.Query().Get("force")
+	if raw == "" {
+		return false, true
+	}
+	force, err := strconv.ParseBool(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "force must be true or false")
+		return false, false
+	}
+	if force && !s.isAdmin(r) {
+		writeError(w, http.StatusForbidden, "force requires the admin role")
+		return false, false
+	}
+	return force, true
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+const (
+	statusSeriesDefaultRange = 24 * time.Hour
+	statusSeriesMaxMiners    = 200
+	statusSeriesMaxPoints    = 50000
+)
+
+// statusSeriesFields maps the fields a chart may request to status columns.
+// "power" is shorthand for power_consumption.
+var statusSeriesFields = map[string]string{
+	"uptime":            database.StatusColumnUptime,
+	"state":             database.StatusColumnState,
+	"preset":            database.StatusColumnPreset,
+	"hashrate":          database.StatusColumnHashrate,
+	"power":             database.StatusColumnPowerConsumption,
+	"power_usage":       database.StatusColumnPowerUsage,
+	"power_consumption": database.StatusColumnPowerConsumption,
+}
+
+type statusSeriesDTO struct {
+	From      string                 `json:"from"`
+	To        string                 `json:"to"`
+	Fields    []string               `json:"fields"`
+	Truncated bool                   `json:"truncated"`
+	Series    []minerStatusSeriesDTO `json:"series"`
+}
+
+type minerStatusSeriesDTO struct {
+	MinerID string           `json:"miner_id"`
+	Points  []map[string]any `json:"points"`
+}
+
+// handleStatusSeries returns status time series for several miners from a
+// single query. miner_ids lists the miners (comma separated or repeated);
+// fields picks the values per point (default hashrate,power); from and to
+// bound the range like the exports and default to the last 24 hours.
+func (s *Server) handleStatusSeries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var minerIDs []string
+	seen := make(map[string]bool)
+	for _, value := range query["miner_ids"] {
+		for _, id := range strings.Split(value, ",") {
+			id = strings.ToLower(strings.TrimSpace(id))
+			if id != "" && !seen[id] {
+				seen[id] = true
+				minerIDs = append(minerIDs, id)
+			}
+		}
+	}
+	if len(minerIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "miner_ids is required")
+		return
+	}
+	if len(minerIDs) > statusSeriesMaxMiners {
+		writeError(w, http.StatusBadRequest, "too many miner_ids")
+		return
+	}
+
+	var fields, columns []string
+	rawFields := strings.TrimSpace(query.Get("fields"))
+	if rawFields == "" {
+		rawFields = "hashrate,power"
+	}
+	selected := make(map[string]bool)
+	for _, field := range strings.Split(rawFields, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		column, ok := statusSeriesFields[field]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "unknown field "+field)
+			return
+		}
+		fields = append(fields, field)
+		if !selected[column] {
+			selected[column] = true
+			columns = append(columns, column)
+		}
+	}
+
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(query.Get("from")) == "" {
+		from = to.Add(-statusSeriesDefaultRange)
+	}
+
+	statuses, err := s.store.ListStatusSeries(r.Context(), minerIDs, columns, from, to, statusSeriesMaxPoints+1)
+	if err != nil {
+		s.log.Error("list status series failed", "miners", len(minerIDs), "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch statuses")
+		return
+	}
+
+	out := statusSeriesDTO{
+		From:      formatTime(from),
+		To:        formatTime(to),
+		Fields:    fields,
+		Truncated: len(statuses) > statusSeriesMaxPoints,
+		Series:    make([]minerStatusSeriesDTO, 0, len(minerIDs)),
+	}
+	if out.Truncated {
+		statuses = statuses[:statusSeriesMaxPoints]
+	}
+
+	byMiner := make(map[string]int, len(minerIDs))
+	for _, id := range minerIDs {
+		byMiner[id] = len(out.Series)
+		out.Series = append(out.Series, minerStatusSeriesDTO{MinerID: id, Points: []map[string]any{}})
+	}
+	for _, status := range statuses {
+		idx, ok := byMiner[status.MinerID]
+		if !ok {
+			continue
+		}
+		point := make(map[string]any, len(fields)+1)
+		point["recorded_at"] = formatTime(status.RecordedAt)
+		for _, field := range fields {
+			point[field] = statusSeriesValue(status, statusSeriesFields[field])
+		}
+		out.Series[idx].Points = append(out.Series[idx].Points, point)
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+func statusSeriesValue(status database.Status, column string) any {
+	switch column {
+	case database.StatusColumnUptime:
+		return status.Uptime
+	case database.StatusColumnState:
+		return status.State
+	case database.StatusColumnPreset:
+		return status.Preset
+	case database.StatusColumnHashrate:
+		return status.Hashrate
+	case database.StatusColumnPowerUsage:
+		return status.PowerUsage
+	case database.StatusColumnPowerConsumption:
+		return status.PowerConsumption
+	}
+	return nil
+}
@@ -1,149 +1,344 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"powerhive/internal/database"
+	"powerhive/internal/firmware"
+	"powerhive/internal/graphql"
 )
 
 // Server exposes the dashboard API and static assets.
 type Server struct {
-	store  *database.Store
-	log    *slog.Logger
-	mux    *http.ServeMux
-	static http.Handler
+	store       *database.Store
+	log         *slog.Logger
+	mux         *http.ServeMux
+	static      http.Handler
+	discovery   DiscoveryService
+	firmware    FirmwareUpdater
+	reloader    ConfigReloader
+	logLevels   LogLevelController
+	controller  MinerController
+	planner     BalancePlanner
+	reliability ReliabilityChecker
+	firmwareLog FirmwareLog
+	rollout     ProfileRollout
+	maintenance MaintenanceReporter
+	services    ServiceReporter
+	leader      LeaderReporter
+	queue       BalanceQueueReporter
+	keys        KeyRotator
+	handler     http.Handler
+	limits      Limits
+	limiter     *rateLimiter
+
+	// plantMetrics names the time zone of the daily plant metrics.
+	plantMetrics PlantMetricsReporter
+	// smoothing supplies the filtered generation the balancer targets.
+	smoothing GenerationSmoother
+	// changeLimits counts preset changes against the balancer's limits.
+	changeLimits ChangeLimitReporter
+	// reconciliation compares the container meters with the miners'
+	// estimated consumption.
+	reconciliation ReconciliationReporter
+	// graphql serves /api/graphql when the endpoint is enabled.
+	graphql *graphql.Schema
+	// legacySunset is when the unversioned /api/ paths stop answering.
+	legacySunset time.Time
+	// adminTokens are the bearer tokens with the admin role. See isAdmin.
+	adminTokens []string
+	sessions    SessionOptions
+	// writeAccess, when set, limits API writes. See restrictWrites.
+	writeAccess *WriteAccess
+	// basePath is the sub-path a reverse proxy serves PowerHive under,
+	// without a trailing slash; empty at the root.
+	basePath string
+}
+
+// Option wires optional service dependencies into the Server.
+type Option func(*Server)
+
+// ConfigReloader re-reads the configuration file and applies it to the
+// running services.
+type ConfigReloader interface {
+	ReloadConfig() error
+}
+
+// LogLevelController reads and changes log verbosity at runtime.
+type LogLevelController interface {
+	LogLevels() map[string]string
+	// SetLogLevel changes one component's level; the "default" component
+	// sets the fallback level and an empty level clears an override.
+	SetLogLevel(component, level string) error
+}
+
+// ReliabilityChecker applies the configured poll reliability limits.
+type ReliabilityChecker interface {
+	Flaky(rel database.PollReliability) bool
+}
+
+// WithReliabilityChecker reports whether each miner is flaky in the miner
+// responses.
+func WithReliabilityChecker(c ReliabilityChecker) Option {
+	return func(s *Server) {
+		s.reliability = c
+	}
+}
+
+// FirmwareLog exposes the recorded firmware HTTP exchanges.
+type FirmwareLog interface {
+	Enabled() bool
+	SetEnabled(enabled bool)
+	Capacity() int
+	Entries() []firmware.RequestLogEntry
+	Clear()
+}
+
+// WithFirmwareLog enables /api/debug/firmware-log.
+func WithFirmwareLog(l FirmwareLog) Option {
+	return func(s *Server) {
+		s.firmwareLog = l
+	}
+}
+
+// WithLogLevels enables GET and PATCH /api/admin/loglevel.
+func WithLogLevels(c LogLevelController) Option {
+	return func(s *Server) {
+		s.logLevels = c
+	}
+}
+
+// WithConfigReloader enables POST /api/admin/reload.
+func WithConfigReloader(r ConfigReloader) Option {
+	return func(s *Server) {
+		s.reloader = r
+	}
 }
 
 // New constructs a Server with routes configured.
-func New(store *database.Store, logger *slog.Logger) (*Server, error) {
+func New(store *database.Store, logger *slog.Logger, opts ...Option) (*Server, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	static, err := staticHandler()
-	if err != nil {
-		return nil, fmt.Errorf("prepare static assets: %w", err)
-	}
-
 	s := &Server{
 		store:  store,
 		log:    logger.With("component", "http"),
 		mux:    http.NewServeMux(),
-		static: static,
 	}
 
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	static, err := staticHandler(s.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("prepare static assets: %w", err)
+	}
+	s.static = static
+
 	s.routes()
+	s.handler = chain(s.mux, s.stripBasePath, compress, s.logRequests, s.recoverPanics, s.versionAPI, s.restrictWrites, s.limit, s.customerScope, s.authenticate, s.readOnlyStandby)
 	return s, nil
 }
 
-// Handler exposes the configured mux for use with http.Server.
+// Handler exposes the routes wrapped in the middleware chain for use with
+// http.Server.
 func (s *Server) Handler() http.Handler {
-	return s.mux
+	return s.handler
 }
 
 func (s *Server) routes() {
-	s.mux.Handle("/api/miners", http.HandlerFunc(s.handleMiners))
-	s.mux.Handle("/api/miners/", http.HandlerFunc(s.handleMinerRoutes))
-
-	s.mux.Handle("/api/models", http.HandlerFunc(s.handleModels))
-	s.mux.Handle("/api/models/", http.HandlerFunc(s.handleModelRoutes))
-
-	s.mux.Handle("/api/plant/latest", http.HandlerFunc(s.handlePlantLatest))
-	s.mux.Handle("/api/plant/history", http.HandlerFunc(s.handlePlantHistory))
-
-	s.mux.Handle("/api/balance/events", http.HandlerFunc(s.handleBalanceEvents))
-	s.mux.Handle("/api/balance/status", http.HandlerFunc(s.handleBalanceStatus))
-
-	s.mux.Handle("/api/settings", http.HandlerFunc(s.handleSettings))
-	s.mux.Handle("/api/settings/", http.HandlerFunc(s.handleSettingsRoutes))
-
-	// Static assets and dashboard.
-	s.mux.Handle("/", http.HandlerFunc(s.handleStatic))
+	s.mux.HandleFunc("GET /api/miners", s.listMiners)
+	s.mux.HandleFunc("GET /api/miners/{id}", withMinerID(s.getMiner))
+	s.mux.HandleFunc("PATCH /api/miners/{id}", withMinerID(s.updateMiner))
+	s.mux.HandleFunc("DELETE /api/miners/{id}", withMinerID(s.deleteMiner))
+	s.mux.HandleFunc("POST /api/miners/{id}/merge", withMinerID(s.mergeMiner))
+	s.mux.HandleFunc("GET /api/miners/{id}/statuses", withMinerID(s.listMinerStatuses))
+	s.mux.HandleFunc("GET /api/miners/{id}/telemetry", withMinerID(s.listMinerTelemetry))
+	s.mux.HandleFunc("GET /api/miners/{id}/availability", withMinerID(s.handleMinerAvailability))
+	s.mux.HandleFunc("GET /api/miners/{id}/timeline", withMinerID(s.handleMinerTimeline))
+	s.mux.HandleFunc("GET /api/miners/{id}/energy", withMinerID(s.handleMinerEnergy))
+	s.mux.HandleFunc("GET /api/miners/{id}/power-measurements", withMinerID(s.listMinerPowerMeasurements))
+	s.mux.HandleFunc("POST /api/miners/{id}/locate", withMinerID(s.handleMinerLocate))
+	s.mux.HandleFunc("PUT /api/miners/{id}/cooling", withMinerID(s.handleMinerCooling))
+	s.mux.HandleFunc("GET /api/miners/{id}/logs", withMinerID(s.handleMinerLogs))
+	s.mux.HandleFunc("GET /api/miners/{id}/network", withMinerID(s.getMinerNetwork))
+	s.mux.HandleFunc("PUT /api/miners/{id}/network", withMinerID(s.updateMinerNetwork))
+	s.mux.HandleFunc("POST /api/miners/{id}/rotate-key", withMinerID(s.rotateMinerKey))
+	s.mux.HandleFunc("GET /api/miners/{id}/credentials", withMinerID(s.minerUnlockCandidates))
+	s.mux.HandleFunc("POST /api/network/re-ip", s.handleReIP)
+	s.mux.HandleFunc("GET /api/statuses", s.handleStatusSeries)
+	s.mux.HandleFunc("GET /api/graphql", s.handleGraphQL)
+	s.mux.HandleFunc("POST /api/graphql", s.handleGraphQL)
+	s.mux.HandleFunc("GET /api/graphql/schema", s.handleGraphQLSchema)
+	s.mux.HandleFunc("GET /api/workers", s.listPoolWorkers)
+
+	s.mux.HandleFunc("GET /api/dashboard", s.handleDashboard)
+	s.mux.HandleFunc("GET /api/health", s.handleHealth)
+
+	s.mux.HandleFunc("GET /api/models", s.listModels)
+	s.mux.HandleFunc("GET /api/models/{alias}", withModelAlias(s.getModel))
+	s.mux.HandleFunc("POST /api/models", s.createModel)
+	s.mux.HandleFunc("PATCH /api/models/{alias}", withModelAlias(s.updateModel))
+	s.mux.HandleFunc("DELETE /api/models/{alias}", withModelAlias(s.deleteModel))
+	s.mux.HandleFunc("PUT /api/models/{alias}/presets", withModelAlias(s.setModelPresets))
+	s.mux.HandleFunc("GET /api/models/{alias}/efficiency", withModelAlias(s.modelEfficiency))
+
+	s.mux.HandleFunc("GET /api/plant/latest", s.handlePlantLatest)
+	s.mux.HandleFunc("GET /api/plant/history", s.handlePlantHistory)
+	s.mux.HandleFunc("GET /api/plant/export", s.handlePlantExport)
+	s.mux.HandleFunc("GET /api/plant/gaps", s.listPlantGaps)
+	s.mux.HandleFunc("GET /api/plant/quarantine", s.listQuarantinedReadings)
+	s.mux.HandleFunc("GET /api/plant/reconciliation", s.listReconciliations)
+	s.mux.HandleFunc("GET /api/plant/sources", s.listPlantSources)
+	s.mux.HandleFunc("GET /api/plant/sources/{name}/history", s.handlePlantSourceHistory)
+	s.mux.HandleFunc("GET /api/grid/protection", s.listGridProtectionEvents)
+
+	s.mux.HandleFunc("GET /api/reports/energy", s.handleEnergyReport)
+	s.mux.HandleFunc("GET /api/reports/plant", s.handlePlantReport)
+	s.mux.HandleFunc("GET /api/economics/estimates", s.handleEconomicsEstimates)
+
+	s.mux.HandleFunc("GET /api/balance/events", s.handleBalanceEvents)
+	s.mux.HandleFunc("GET /api/balance/events/export", s.handleBalanceEventsExport)
+	s.mux.HandleFunc("PATCH /api/balance/events/{id}", withID(s.annotateBalanceEvent))
+	s.mux.HandleFunc("GET /api/balance/cycles", s.handleBalanceCycles)
+	s.mux.HandleFunc("GET /api/balance/cycles/{id}", withID(s.handleBalanceCycle))
+	s.mux.HandleFunc("GET /api/balance/status", s.handleBalanceStatus)
+	s.mux.HandleFunc("GET /api/balance/queue", s.handleBalanceQueue)
+	s.mux.HandleFunc("POST /api/balance/plan", s.handleBalancePlan)
+	s.mux.HandleFunc("GET /api/hashboards/events", s.handleHashboardEvents)
+	s.mux.HandleFunc("GET /api/drift/events", s.handleDriftEvents)
+	s.mux.HandleFunc("GET /api/key-rotations", s.handleKeyRotations)
+	s.mux.HandleFunc("GET /api/webhooks/deliveries", s.listWebhookDeliveries)
+	s.mux.HandleFunc("POST /api/webhooks/deliveries/{id}/retry", withID(s.retryWebhookDelivery))
+
+	s.mux.HandleFunc("POST /api/login", s.handleLogin)
+	s.mux.HandleFunc("POST /api/logout", s.handleLogout)
+	s.mux.HandleFunc("GET /api/session", s.handleSession)
+	s.mux.HandleFunc("GET /api/users", s.listUsers)
+	s.mux.HandleFunc("POST /api/users", s.createUser)
+	s.mux.HandleFunc("PATCH /api/users/{id}", withID(s.updateUser))
+	s.mux.HandleFunc("DELETE /api/users/{id}", withID(s.deleteUser))
+	s.mux.HandleFunc("GET /api/customers", s.listCustomers)
+	s.mux.HandleFunc("POST /api/customers", s.createCustomer)
+	s.mux.HandleFunc("GET /api/customers/{id}", withID(s.getCustomer))
+	s.mux.HandleFunc("PUT /api/customers/{id}", withID(s.updateCustomer))
+	s.mux.HandleFunc("DELETE /api/customers/{id}", withID(s.deleteCustomer))
+	s.mux.HandleFunc("GET /api/customers/{id}/summary", withID(s.handleCustomerSummary))
+	s.mux.HandleFunc("GET /api/customers/{id}/energy", withID(s.handleCustomerEnergy))
+	s.mux.HandleFunc("GET /api/customers/{id}/tokens", withID(s.listCustomerTokens))
+	s.mux.HandleFunc("POST /api/customers/{id}/tokens", withID(s.createCustomerToken))
+	s.mux.HandleFunc("DELETE /api/customers/{id}/tokens/{token_id}", withID(s.deleteCustomerToken))
+
+	s.mux.HandleFunc("GET /api/portal/summary", s.portalSummary)
+	s.mux.HandleFunc("GET /api/portal/energy", s.portalEnergy)
+	s.mux.HandleFunc("GET /api/portal/miners", s.portalMiners)
+	s.mux.HandleFunc("GET /api/portal/miners/{id}", s.portalMiner(s.getMiner))
+	s.mux.HandleFunc("GET /api/portal/miners/{id}/statuses", s.portalMiner(s.listMinerStatuses))
+	s.mux.HandleFunc("GET /api/portal/miners/{id}/availability", s.portalMiner(s.handleMinerAvailability))
+	s.mux.HandleFunc("GET /api/portal/miners/{id}/energy", s.portalMiner(s.handleMinerEnergy))
+
+	s.mux.HandleFunc("GET /api/dr/events", s.listDREvents)
+	s.mux.HandleFunc("POST /api/dr/events", s.createDREvent)
+	s.mux.HandleFunc("GET /api/dr/events/{id}", withID(s.getDREvent))
+	s.mux.HandleFunc("DELETE /api/dr/events/{id}", withID(s.cancelDREvent))
+
+	s.mux.HandleFunc("GET /api/credentials", s.listUnlockPasswords)
+	s.mux.HandleFunc("POST /api/credentials", s.createUnlockPassword)
+	s.mux.HandleFunc("PUT /api/credentials/{id}", withID(s.updateUnlockPassword))
+	s.mux.HandleFunc("DELETE /api/credentials/{id}", withID(s.deleteUnlockPassword))
+
+	s.mux.HandleFunc("GET /api/settings", s.listSettings)
+	s.mux.HandleFunc("GET /api/settings/economics", s.getEconomicsSettings)
+	s.mux.HandleFunc("PATCH /api/settings/economics", s.updateEconomicsSettings)
+	s.mux.HandleFunc("PATCH /api/settings/thermal-limits", s.updateThermalLimits)
+	s.mux.HandleFunc("PATCH /api/settings/battery", s.updateBatteryPolicy)
+	s.mux.HandleFunc("PATCH /api/settings/balance-mode", s.updateBalanceMode)
+	s.mux.HandleFunc("PATCH /api/settings/safety-margin", s.updateSafetyMargin)
+	s.mux.HandleFunc("PATCH /api/settings/balancer-pause", s.updateBalancerPause)
+
+	s.mux.HandleFunc("GET /api/admin/backup", s.handleAdminBackup)
+	s.mux.HandleFunc("POST /api/admin/reload", s.handleAdminReload)
+	s.mux.HandleFunc("GET /api/admin/loglevel", s.getLogLevels)
+	s.mux.HandleFunc("PATCH /api/admin/loglevel", s.setLogLevel)
+	s.mux.HandleFunc("GET /api/admin/services", s.handleAdminServices)
+	s.mux.HandleFunc("GET /api/admin/leader", s.handleAdminLeader)
+
+	s.mux.HandleFunc("GET /api/debug/firmware-log", s.getFirmwareLog)
+	s.mux.HandleFunc("PATCH /api/debug/firmware-log", s.setFirmwareLogEnabled)
+	s.mux.HandleFunc("DELETE /api/debug/firmware-log", s.clearFirmwareLog)
+
+	s.mux.HandleFunc("POST /api/discovery/scan", s.handleDiscoveryScan)
+	s.mux.HandleFunc("GET /api/discovery/status", s.handleDiscoveryStatus)
+
+	s.mux.HandleFunc("GET /api/firmware", s.handleFirmwareInventory)
+	s.mux.HandleFunc("GET /api/firmware/updates", s.listFirmwareUpdates)
+	s.mux.HandleFunc("POST /api/firmware/updates", s.startFirmwareUpdate)
+	s.mux.HandleFunc("GET /api/firmware/updates/{id}", s.getFirmwareUpdate)
+
+	s.mux.HandleFunc("GET /api/profiles", s.listProfiles)
+	s.mux.HandleFunc("POST /api/profiles", s.createProfile)
+	s.mux.HandleFunc("GET /api/profiles/{id}", withID(s.getProfile))
+	s.mux.HandleFunc("PUT /api/profiles/{id}", withID(s.updateProfile))
+	s.mux.HandleFunc("DELETE /api/profiles/{id}", withID(s.deleteProfile))
+	s.mux.HandleFunc("POST /api/profiles/{id}/rollout", withID(s.startProfileRollout))
+	s.mux.HandleFunc("GET /api/rollouts", s.listRollouts)
+	s.mux.HandleFunc("GET /api/rollouts/{id}", s.getRollout)
+
+	// Static assets and dashboard. Registered for GET only so a request with
+	// the wrong method on an API path gets 405 rather than falling through.
+	s.mux.HandleFunc("GET /", s.handleStatic)
 }
 
-func (s *Server) handleMiners(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		s.listMiners(w, r)
-	default:
-		methodNotAllowed(w, http.MethodGet)
-	}
-}
-
-func (s *Server) handleMinerRoutes(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/miners/")
-	if path == "" {
-		http.NotFound(w, r)
-		return
-	}
-
-	parts := strings.Split(path, "/")
-	minerID := strings.ToLower(strings.TrimSpace(parts[0]))
-	if minerID == "" {
-		http.NotFound(w, r)
-		return
-	}
-
-	if len(parts) == 1 {
-		switch r.Method {
-		case http.MethodGet:
-			s.getMiner(w, r, minerID)
-		case http.MethodPatch:
-			s.updateMiner(w, r, minerID)
-		default:
-			methodNotAllowed(w, http.MethodGet, http.MethodPatch)
-		}
-		return
-	}
-
-	switch parts[1] {
-	case "statuses":
-		if r.Method == http.MethodGet {
-			s.listMinerStatuses(w, r, minerID)
-			return
-		}
-		methodNotAllowed(w, http.MethodGet)
-	case "telemetry":
-		if r.Method == http.MethodGet {
-			s.listMinerTelemetry(w, r, minerID)
+// withMinerID adapts a handler that takes the normalised {id} path
+// parameter as a miner ID.
+func withMinerID(fn func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		minerID := strings.ToLower(strings.TrimSpace(r.PathValue("id")))
+		if minerID == "" {
+			http.NotFound(w, r)
 			return
 		}
-		methodNotAllowed(w, http.MethodGet)
-	default:
-		http.NotFound(w, r)
+		fn(w, r, minerID)
 	}
 }
 
-func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		s.listModels(w, r)
-	default:
-		methodNotAllowed(w, http.MethodGet)
+// withModelAlias adapts a handler that takes the {alias} path parameter.
+func withModelAlias(fn func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		alias := strings.TrimSpace(r.PathValue("alias"))
+		if alias == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fn(w, r, alias)
 	}
 }
 
-func (s *Server) handleModelRoutes(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/models/")
-	alias := strings.TrimSpace(path)
-	if alias == "" {
-		http.NotFound(w, r)
-		return
-	}
-
-	switch r.Method {
-	case http.MethodGet:
-		s.getModel(w, r, alias)
-	case http.MethodPatch:
-		s.updateModel(w, r, alias)
-	default:
-		methodNotAllowed(w, http.MethodGet, http.MethodPatch)
+// withID adapts a handler that takes a positive integer {id} path
+// parameter; anything else is not found.
+func withID(fn func(http.ResponseWriter, *http.Request, int64)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			http.NotFound(w, r)
+			return
+		}
+		fn(w, r, id)
 	}
 }
 
@@ -157,22 +352,53 @@ func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) listMiners(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	miners, err := s.store.ListMiners(ctx)
+	fields, err := parseFields(r.URL.Query().Get("fields"), minerDTO{}, minerFieldShorthand)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query, err := parseMinerListQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	matched, err := query.find(ctx, s.store)
 	if err != nil {
 		s.log.Error("list miners failed", "err", err)
 		writeError(w, http.StatusInternalServerError, "failed to list miners")
 		return
 	}
 
-	out := make([]minerDTO, 0, len(miners))
-	for _, miner := range miners {
-		out = append(out, toMinerDTO(miner))
+	out := make([]minerDTO, 0, len(matched))
+	for _, miner := range matched {
+		out = append(out, s.toMinerDTO(miner))
 	}
-	writeJSON(w, http.StatusOK, out)
+	s.writeFields(w, fields, out)
+}
+
+func valueOrZeroID(id *int64) int64 {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+func hasTags(tags, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
 }
 
 func (s *Server) getMiner(w http.ResponseWriter, r *http.Request, minerID string) {
 	ctx := r.Context()
+	fields, err := parseFields(r.URL.Query().Get("fields"), minerDTO{}, minerFieldShorthand)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	miner, err := s.store.GetMiner(ctx, minerID)
 	if err != nil {
 		if isNotFound(err) {
@@ -184,7 +410,7 @@ func (s *Server) getMiner(w http.ResponseWriter, r *http.Request, minerID string
 		return
 	}
 
-	writeJSON(w, http.StatusOK, toMinerDTO(miner))
+	s.writeFields(w, fields, s.toMinerDTO(miner))
 }
 
 func (s *Server) updateMiner(w http.ResponseWriter, r *http.Request, minerID string) {
@@ -194,7 +420,10 @@ func (s *Server) updateMiner(w http.ResponseWriter, r *http.Request, minerID str
 		writeError(w, http.StatusBadRequest, "invalid JSON payload")
 		return
 	}
-	if req.Managed == nil && req.UnlockPass == nil {
+	onlyArchive := req.Managed == nil && req.UnlockPass == nil && req.Driver == nil &&
+		req.Name == nil && req.Location == nil && req.Tags == nil && req.Hold == nil && req.HoldMinutes == nil &&
+		req.Protected == nil && req.CustomerID == nil
+	if onlyArchive && req.Archived == nil {
 		writeError(w, http.StatusBadRequest, "no fields to update")
 		return
 	}
@@ -216,15 +445,119 @@ func (s *Server) updateMiner(w http.ResponseWriter, r *http.Request, minerID str
 		params.UnlockPass = &pass
 	}
 
-	if _, err := s.store.UpsertMiner(ctx, params); err != nil {
-		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "miner not found")
+	if req.Driver != nil {
+		driver, err := firmware.ParseDriver(*req.Driver)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		params.Driver = &driver
+	}
+
+	if req.Name != nil {
+		if len(*req.Name) > maxMinerLabelLength {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("name must be at most %d characters", maxMinerLabelLength))
+			return
+		}
+		params.Name = req.Name
+	}
+
+	if req.Location != nil {
+		if len(*req.Location) > maxMinerLabelLength {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("location must be at most %d characters", maxMinerLabelLength))
+			return
+		}
+		params.Location = req.Location
+	}
+
+	if req.Tags != nil {
+		tags := database.NormalizeTags(*req.Tags)
+		if len(tags) > maxMinerTags {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d tags are allowed", maxMinerTags))
 			return
 		}
-		s.log.Error("update miner failed", "miner", minerID, "err", err)
-		writeError(w, http.StatusInternalServerError, "failed to update miner")
+		for _, tag := range tags {
+			if len(tag) > maxMinerLabelLength || strings.ContainsAny(tag, ", ") {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid tag %q; tags cannot contain spaces or commas", tag))
+				return
+			}
+		}
+		params.Tags = &tags
+	}
+
+	if req.HoldMinutes != nil && (req.Hold == nil || !*req.Hold) {
+		writeError(w, http.StatusBadRequest, "hold_minutes requires \"hold\": true")
 		return
 	}
+	if req.Hold != nil {
+		params.Hold = req.Hold
+		if req.HoldMinutes != nil {
+			if *req.HoldMinutes <= 0 {
+				writeError(w, http.StatusBadRequest, "hold_minutes must be positive")
+				return
+			}
+			until := time.Now().UTC().Add(time.Duration(*req.HoldMinutes) * time.Minute)
+			params.HoldUntil = &until
+		}
+	}
+
+	if req.Protected != nil {
+		if !s.isAdmin(r) {
+			writeError(w, http.StatusForbidden, "changing protected requires the admin role")
+			return
+		}
+		params.Protected = req.Protected
+	}
+
+	if req.CustomerID != nil {
+		if *req.CustomerID < 0 {
+			writeError(w, http.StatusBadRequest, "customer_id must not be negative")
+			return
+		}
+		if *req.CustomerID != 0 {
+			if _, err := s.store.GetCustomer(ctx, *req.CustomerID); err != nil {
+				if isNotFound(err) {
+					writeError(w, http.StatusBadRequest, "customer not found")
+					return
+				}
+				s.log.Error("get customer failed", "customer", *req.CustomerID, "err", err)
+				writeError(w, http.StatusInternalServerError, "failed to update miner")
+				return
+			}
+		}
+		params.CustomerID = req.CustomerID
+	}
+
+	if !onlyArchive {
+		if _, err := s.store.UpsertMiner(ctx, params); err != nil {
+			if isNotFound(err) {
+				writeError(w, http.StatusNotFound, "miner not found")
+				return
+			}
+			s.log.Error("update miner failed", "miner", minerID, "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to update miner")
+			return
+		}
+	}
+
+	if req.Archived != nil {
+		var err error
+		if *req.Archived {
+			_, err = s.store.ArchiveMiner(ctx, minerID, time.Now())
+		} else {
+			_, err = s.store.RestoreMiner(ctx, minerID)
+		}
+		if err != nil {
+			if isNotFound(err) {
+				writeError(w, http.StatusNotFound, "miner not found")
+				return
+			}
+			s.log.Error("update miner archive failed", "miner", minerID, "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to update miner")
+			return
+		}
+		s.log.Info("miner archive changed", "miner", minerID, "archived", *req.Archived)
+	}
 
 	updated, err := s.store.GetMiner(ctx, minerID)
 	if err != nil {
@@ -233,7 +566,46 @@ func (s *Server) updateMiner(w http.ResponseWriter, r *http.Request, minerID str
 		return
 	}
 
-	writeJSON(w, http.StatusOK, toMinerDTO(updated))
+	writeJSON(w, http.StatusOK, s.toMinerDTO(updated))
+}
+
+// deleteMiner archives the miner, or with ?purge=true deletes it and its
+// history.
+func (s *Server) deleteMiner(w http.ResponseWriter, r *http.Request, minerID string) {
+	ctx := r.Context()
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+
+	if purge {
+		if err := s.store.DeleteMiner(ctx, minerID); err != nil {
+			if isNotFound(err) {
+				writeError(w, http.StatusNotFound, "miner not found")
+				return
+			}
+			s.log.Error("delete miner failed", "miner", minerID, "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to delete miner")
+			return
+		}
+		s.log.Info("miner deleted", "miner", minerID)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"miner_id": minerID,
+			"purged":   true,
+		})
+		return
+	}
+
+	archived, err := s.store.ArchiveMiner(ctx, minerID, time.Now())
+	if err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "miner not found")
+			return
+		}
+		s.log.Error("archive miner failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to archive miner")
+		return
+	}
+	s.log.Info("miner archived", "miner", minerID)
+
+	writeJSON(w, http.StatusOK, s.toMinerDTO(archived))
 }
 
 func (s *Server) listMinerStatuses(w http.ResponseWriter, r *http.Request, minerID string) {
@@ -244,6 +616,15 @@ func (s *Server) listMinerStatuses(w http.ResponseWriter, r *http.Request, miner
 			limit = parsed
 		}
 	}
+	fields, err := parseFields(r.URL.Query().Get("fields"), statusDTO{}, nil)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s.notModified(w, r, database.HistoryStatuses, &minerID) {
+		return
+	}
 
 	statuses, err := s.store.ListMinerStatuses(ctx, minerID, limit)
 	if err != nil {
@@ -260,7 +641,7 @@ func (s *Server) listMinerStatuses(w http.ResponseWriter, r *http.Request, miner
 	for _, status := range statuses {
 		out = append(out, toStatusDTO(status))
 	}
-	writeJSON(w, http.StatusOK, out)
+	s.writeFields(w, fields, out)
 }
 
 func (s *Server) listMinerTelemetry(w http.ResponseWriter, r *http.Request, minerID string) {
@@ -272,6 +653,10 @@ func (s *Server) listMinerTelemetry(w http.ResponseWriter, r *http.Request, mine
 		}
 	}
 
+	if s.notModified(w, r, database.HistoryTelemetry, &minerID) {
+		return
+	}
+
 	snapshots, err := s.store.ListChainTelemetry(ctx, minerID, limit)
 	if err != nil {
 		s.log.Error("list telemetry failed", "miner", minerID, "err", err)
@@ -396,6 +781,38 @@ func (s *Server) updateModel(w http.ResponseWriter, r *http.Request, alias strin
 		maxPreset = model.MaxPreset
 	}
 
+	var minPreset *string
+	if req.MinPreset != nil {
+		value := strings.TrimSpace(*req.MinPreset)
+		if value == "" {
+			minPreset = nil
+		} else {
+			if !containsCaseInsensitive(model.Presets, value) {
+				writeError(w, http.StatusBadRequest, "min_preset must match an available preset")
+				return
+			}
+			minPreset = &value
+		}
+	} else {
+		minPreset = model.MinPreset
+	}
+
+	if maxPreset != nil && minPreset != nil {
+		presets, err := s.store.GetModelPresets(ctx, model.Alias)
+		if err != nil {
+			s.log.Error("load model presets failed", "alias", alias, "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to load model presets")
+			return
+		}
+		powers := presetPowers(presets)
+		maxPower, maxKnown := powers[strings.ToLower(*maxPreset)]
+		minPower, minKnown := powers[strings.ToLower(*minPreset)]
+		if maxKnown && minKnown && minPower > maxPower {
+			writeError(w, http.StatusBadRequest, "min_preset must not draw more power than max_preset")
+			return
+		}
+	}
+
 	// Handle disabled preset power update
 	if req.DisabledPresetPowerW != nil {
 		if *req.DisabledPresetPowerW < 0 {
@@ -421,6 +838,7 @@ func (s *Server) updateModel(w http.ResponseWriter, r *http.Request, alias strin
 		Name:      model.Name,
 		Alias:     model.Alias,
 		MaxPreset: maxPreset,
+		MinPreset: minPreset,
 	})
 	if err != nil {
 		s.log.Error("update model failed", "alias", alias, "err", err)
@@ -449,11 +867,6 @@ func (s *Server) updateModel(w http.ResponseWriter, r *http.Request, alias strin
 	writeJSON(w, http.StatusOK, dto)
 }
 
-func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
-	w.Header().Set("Allow", strings.Join(allowed, ", "))
-	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
-}
-
 func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -489,18 +902,62 @@ func containsCaseInsensitive(values []string, target string) bool {
 	return false
 }
 
+// presetPowers maps each preset to its expected power. Like the balancer, it
+// falls back to the wattage in the preset names ("3300" or "3300W") only when
+// the model has no recorded power at all.
+func presetPowers(presets []database.ModelPreset) map[string]float64 {
+	powers := make(map[string]float64)
+	for _, preset := range presets {
+		if preset.ExpectedPowerW != nil {
+			powers[strings.ToLower(strings.TrimSpace(preset.Value))] = *preset.ExpectedPowerW
+		}
+	}
+	if len(powers) > 0 {
+		return powers
+	}
+	for _, preset := range presets {
+		key := strings.ToLower(strings.TrimSpace(preset.Value))
+		if watts, err := strconv.ParseFloat(strings.TrimSuffix(key, "w"), 64); err == nil && watts > 0 {
+			powers[key] = watts
+		}
+	}
+	return powers
+}
+
+// Limits on the free-form labels operators attach to miners.
+const (
+	maxMinerLabelLength = 128
+	maxMinerTags        = 32
+)
+
 type updateMinerRequest struct {
-	Managed    *bool   `json:"managed"`
-	UnlockPass *string `json:"unlock_pass"`
+	Managed    *bool     `json:"managed"`
+	UnlockPass *string   `json:"unlock_pass"`
+	Driver     *string   `json:"driver"`
+	Name       *string   `json:"name"`
+	Location   *string   `json:"location"`
+	Tags       *[]string `json:"tags"`
+	// Hold keeps the balancer away from the miner. HoldMinutes limits the
+	// hold; without it the hold lasts until cleared.
+	Hold        *bool `json:"hold"`
+	HoldMinutes *int  `json:"hold_minutes"`
+	// Protected keeps preset and pool changes away from the miner unless an
+	// admin forces them. Only admins may change it.
+	Protected *bool `json:"protected"`
+	// Archived archives the miner, or restores it when false.
+	Archived *bool `json:"archived"`
+	// CustomerID assigns the miner to a hosting customer; 0 unassigns it.
+	CustomerID *int64 `json:"customer_id"`
 }
 
 type updateModelRequest struct {
 	MaxPreset          *string  `json:"max_preset"`
+	MinPreset          *string  `json:"min_preset"`
 	DisabledPresetPowerW *float64 `json:"disabled_preset_power_w"`
 }
 
 func (r *updateModelRequest) HasUpdates() bool {
-	return r.MaxPreset != nil || r.DisabledPresetPowerW != nil
+	return r.MaxPreset != nil || r.MinPreset != nil || r.DisabledPresetPowerW != nil
 }
 
 type minerDTO struct {
@@ -508,16 +965,53 @@ type minerDTO struct {
 	IP           *string    `json:"ip"`
 	Online       bool       `json:"online"`
 	Managed      bool       `json:"managed"`
-	Model        *modelDTO  `json:"model,omitempty"`
-	LatestStatus *statusDTO `json:"latest_status,omitempty"`
-	CreatedAt    string     `json:"created_at"`
-	UpdatedAt    string     `json:"updated_at"`
+	FWName       *string    `json:"fw_name"`
+	FWVersion    *string    `json:"fw_version"`
+	Driver       string     `json:"driver"`
+	APIScheme    *string    `json:"api_scheme"`
+	APIPort      *int       `json:"api_port"`
+	Name         *string    `json:"name"`
+	Location     *string    `json:"location"`
+	Tags         []string   `json:"tags"`
+	Hold         bool       `json:"hold"`
+	HoldUntil    *string    `json:"hold_until"`
+	// Held reports whether the hold is currently in effect.
+	Held      bool `json:"held"`
+	Protected bool `json:"protected"`
+	// CooldownSeconds is the time left before the balancer may change the
+	// miner's preset again.
+	CooldownSeconds    int            `json:"cooldown_remaining_seconds"`
+	LastPresetChangeAt *string        `json:"last_preset_change_at"`
+	Reliability        reliabilityDTO `json:"reliability"`
+	Cooling            *coolingDTO    `json:"cooling,omitempty"`
+	// Immersion miners run without fans and raise no fan alerts.
+	Immersion       bool           `json:"immersion"`
+	ArchivedAt      *string        `json:"archived_at"`
+	CustomerID      *int64         `json:"customer_id"`
+	Serial          *string        `json:"serial"`
+	// RebootUntil is set while a reboot the balancer started is under way.
+	RebootUntil     *string        `json:"reboot_until"`
+	Model           *modelDTO      `json:"model,omitempty"`
+	LatestStatus    *statusDTO     `json:"latest_status,omitempty"`
+	CreatedAt       string         `json:"created_at"`
+	UpdatedAt       string         `json:"updated_at"`
+}
+
+// reliabilityDTO summarises the miner's status poll history. Flaky miners
+// are skipped by the balancer.
+type reliabilityDTO struct {
+	ConsecutiveFailures int      `json:"consecutive_failures"`
+	SuccessRatio        *float64 `json:"success_ratio"`
+	LastError           *string  `json:"last_error"`
+	LastErrorAt         *string  `json:"last_error_at"`
+	Flaky               bool     `json:"flaky"`
 }
 
 type modelDTO struct {
 	Name         string        `json:"name"`
 	Alias        string        `json:"alias"`
 	MaxPreset    *string       `json:"max_preset"`
+	MinPreset    *string       `json:"min_preset"`
 	Presets      []string      `json:"presets"`
 	PresetsPower []presetPowerDTO `json:"presets_power"`
 	CreatedAt    string        `json:"created_at"`
@@ -538,7 +1032,9 @@ type statusDTO struct {
 	PowerConsumption *float64   `json:"power_consumption"`
 	Uptime           *int64     `json:"uptime"`
 	RecordedAt       string     `json:"recorded_at"`
+	LastConfirmedAt  string     `json:"last_confirmed_at"`
 	Fans             []fanDTO   `json:"fans,omitempty"`
+	Pools            []poolDTO  `json:"pools,omitempty"`
 	Chains           []chainDTO `json:"chains,omitempty"`
 }
 
@@ -548,6 +1044,16 @@ type fanDTO struct {
 	Status     *string `json:"status"`
 }
 
+type poolDTO struct {
+	Index    int     `json:"index"`
+	URL      *string `json:"url"`
+	Worker   *string `json:"worker"`
+	Status   *string `json:"status"`
+	Accepted *int64  `json:"accepted"`
+	Rejected *int64  `json:"rejected"`
+	Stale    *int64  `json:"stale"`
+}
+
 type chainDTO struct {
 	Identifier  *string   `json:"identifier"`
 	State       *string   `json:"state"`
@@ -556,6 +1062,8 @@ type chainDTO struct {
 	PCBTempMax  *float64  `json:"pcb_temp_max"`
 	ChipTempMin *float64  `json:"chip_temp_min"`
 	ChipTempMax *float64  `json:"chip_temp_max"`
+	ChipTempAvg *float64  `json:"chip_temp_avg,omitempty"`
+	ChipCount   *int      `json:"chip_count,omitempty"`
 	Chips       []chipDTO `json:"chips,omitempty"`
 }
 
@@ -572,13 +1080,15 @@ type chainTelemetryDTO struct {
 	Chain      chainDTO `json:"chain"`
 }
 
-func toMinerDTO(miner database.Miner) minerDTO {
+func (s *Server) toMinerDTO(miner database.Miner) minerDTO {
+	now := time.Now()
 	var model *modelDTO
 	if miner.Model != nil {
 		model = &modelDTO{
 			Name:      miner.Model.Name,
 			Alias:     miner.Model.Alias,
 			MaxPreset: miner.Model.MaxPreset,
+			MinPreset: miner.Model.MinPreset,
 			Presets:   append([]string{}, miner.Model.Presets...),
 			CreatedAt: formatTime(miner.Model.CreatedAt),
 		}
@@ -590,15 +1100,48 @@ func toMinerDTO(miner database.Miner) minerDTO {
 		latest = &dto
 	}
 
+	var cooling *coolingDTO
+	if miner.Settings != nil {
+		dto := toCoolingDTO(miner.Settings.Cooling)
+		cooling = &dto
+	}
+
 	return minerDTO{
-		ID:           miner.ID,
-		IP:           miner.IP,
-		Online:       miner.IP != nil && strings.TrimSpace(*miner.IP) != "",
-		Managed:      miner.Managed,
-		Model:        model,
-		LatestStatus: latest,
-		CreatedAt:    formatTime(miner.CreatedAt),
-		UpdatedAt:    formatTime(miner.UpdatedAt),
+		ID:                 miner.ID,
+		IP:                 miner.IP,
+		Online:             miner.IP != nil && strings.TrimSpace(*miner.IP) != "",
+		Managed:            miner.Managed,
+		FWName:             miner.FWName,
+		FWVersion:          miner.FWVersion,
+		Driver:             miner.Driver,
+		APIScheme:          miner.APIScheme,
+		APIPort:            miner.APIPort,
+		Name:               miner.Name,
+		Location:           miner.Location,
+		Tags:               append([]string{}, miner.Tags...),
+		Hold:               miner.Hold,
+		HoldUntil:          formatTimePtr(miner.HoldUntil),
+		Held:               miner.Held(now),
+		Protected:          miner.Protected,
+		CooldownSeconds:    int(math.Ceil(miner.CooldownRemaining(now).Seconds())),
+		LastPresetChangeAt: formatTimePtr(miner.LastPresetChangeAt),
+		Reliability: reliabilityDTO{
+			ConsecutiveFailures: miner.Reliability.ConsecutiveFailures,
+			SuccessRatio:        miner.Reliability.SuccessRatio,
+			LastError:           miner.Reliability.LastError,
+			LastErrorAt:         formatTimePtr(miner.Reliability.LastErrorAt),
+			Flaky:               s.reliability != nil && s.reliability.Flaky(miner.Reliability),
+		},
+		Cooling:         cooling,
+		Immersion:       miner.Immersion(),
+		ArchivedAt:      formatTimePtr(miner.ArchivedAt),
+		CustomerID:      miner.CustomerID,
+		Serial:          miner.Serial,
+		RebootUntil:     formatTimePtr(miner.RebootUntil),
+		Model:           model,
+		LatestStatus:    latest,
+		CreatedAt:       formatTime(miner.CreatedAt),
+		UpdatedAt:       formatTime(miner.UpdatedAt),
 	}
 }
 
@@ -607,6 +1150,7 @@ func toModelDTO(model database.Model) modelDTO {
 		Name:      model.Name,
 		Alias:     model.Alias,
 		MaxPreset: model.MaxPreset,
+		MinPreset: model.MinPreset,
 		Presets:   append([]string{}, model.Presets...),
 		CreatedAt: formatTime(model.CreatedAt),
 	}
@@ -622,6 +1166,10 @@ func toStatusDTO(status database.Status) statusDTO {
 		PowerConsumption: status.PowerConsumption,
 		Uptime:           status.Uptime,
 		RecordedAt:       formatTime(status.RecordedAt),
+		LastConfirmedAt:  formatTime(status.RecordedAt),
+	}
+	if status.LastConfirmedAt.After(status.RecordedAt) {
+		dto.LastConfirmedAt = formatTime(status.LastConfirmedAt)
 	}
 
 	for _, fan := range status.Fans {
@@ -632,6 +1180,18 @@ func toStatusDTO(status database.Status) statusDTO {
 		})
 	}
 
+	for _, pool := range status.Pools {
+		dto.Pools = append(dto.Pools, poolDTO{
+			Index:    pool.PoolIndex,
+			URL:      pool.URL,
+			Worker:   pool.Worker,
+			Status:   pool.Status,
+			Accepted: pool.Accepted,
+			Rejected: pool.Rejected,
+			Stale:    pool.Stale,
+		})
+	}
+
 	for _, chain := range status.Chains {
 		c := chainDTO{
 			Identifier:  chain.ChainIdentifier,
@@ -641,6 +1201,8 @@ func toStatusDTO(status database.Status) statusDTO {
 			PCBTempMax:  chain.PCBTempMax,
 			ChipTempMin: chain.ChipTempMin,
 			ChipTempMax: chain.ChipTempMax,
+			ChipTempAvg: chain.ChipTempAvg,
+			ChipCount:   chain.ChipCount,
 		}
 		for _, chip := range chain.Chips {
 			c.Chips = append(c.Chips, chipDTO{
@@ -660,9 +1222,15 @@ func toChainTelemetryDTO(snapshot database.ChainSnapshot) chainTelemetryDTO {
 		ID:         snapshot.ID,
 		RecordedAt: formatTime(snapshot.RecordedAt),
 		Chain: chainDTO{
-			Identifier: snapshot.ChainIdentifier,
-			State:      snapshot.State,
-			Hashrate:   snapshot.Hashrate,
+			Identifier:  snapshot.ChainIdentifier,
+			State:       snapshot.State,
+			Hashrate:    snapshot.Hashrate,
+			PCBTempMin:  snapshot.PCBTempMin,
+			PCBTempMax:  snapshot.PCBTempMax,
+			ChipTempMin: snapshot.ChipTempMin,
+			ChipTempMax: snapshot.ChipTempMax,
+			ChipTempAvg: snapshot.ChipTempAvg,
+			ChipCount:   snapshot.ChipCount,
 		},
 	}
 
@@ -688,14 +1256,17 @@ func formatTime(t time.Time) string {
 	return t.UTC().Format(time.RFC3339)
 }
 
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	value := formatTime(*t)
+	return &value
+}
+
 // Plant energy handlers
 
 func (s *Server) handlePlantLatest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w, http.MethodGet)
-		return
-	}
-
 	ctx := r.Context()
 	reading, err := s.store.GetLatestPlantReading(ctx)
 	if err != nil {
@@ -713,11 +1284,6 @@ func (s *Server) handlePlantLatest(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handlePlantHistory(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w, http.MethodGet)
-		return
-	}
-
 	ctx := r.Context()
 	limit := 100
 	if raw := r.URL.Query().Get("limit"); raw != "" {
@@ -726,6 +1292,10 @@ func (s *Server) handlePlantHistory(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if s.notModified(w, r, database.HistoryPlantReadings, nil) {
+		return
+	}
+
 	readings, err := s.store.ListPlantReadings(ctx, limit)
 	if err != nil {
 		s.log.Error("list plant readings failed", "err", err)
@@ -742,27 +1312,44 @@ func (s *Server) handlePlantHistory(w http.ResponseWriter, r *http.Request) {
 
 // Balance event handlers
 
+// handleBalanceEvents lists recent balance events, narrowed by miner_id,
+// success and acknowledged. success=false&acknowledged=false is the list of
+// failures still to be worked through.
 func (s *Server) handleBalanceEvents(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w, http.MethodGet)
-		return
-	}
-
 	ctx := r.Context()
-	limit := 100
-	if raw := r.URL.Query().Get("limit"); raw != "" {
+	query := r.URL.Query()
+	filter := database.PowerBalanceEventFilter{
+		MinerID: query.Get("miner_id"),
+		Limit:   100,
+	}
+	if raw := query.Get("limit"); raw != "" {
 		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
-			limit = parsed
+			filter.Limit = parsed
+		}
+	}
+	for name, target := range map[string]**bool{"success": &filter.Success, "acknowledged": &filter.Acknowledged} {
+		raw := strings.TrimSpace(query.Get(name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, name+" must be true or false")
+			return
 		}
+		*target = &value
 	}
 
-	minerID := r.URL.Query().Get("miner_id")
 	var minerIDPtr *string
-	if minerID != "" {
-		minerIDPtr = &minerID
+	if filter.MinerID != "" {
+		minerIDPtr = &filter.MinerID
 	}
 
-	events, err := s.store.ListPowerBalanceEvents(ctx, minerIDPtr, limit)
+	if s.notModified(w, r, database.HistoryBalanceEvents, minerIDPtr) {
+		return
+	}
+
+	events, err := s.store.FilterPowerBalanceEvents(ctx, filter)
 	if err != nil {
 		s.log.Error("list balance events failed", "err", err)
 		writeError(w, http.StatusInternalServerError, "failed to fetch balance events")
@@ -776,66 +1363,148 @@ func (s *Server) handleBalanceEvents(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, out)
 }
 
-func (s *Server) handleBalanceStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		methodNotAllowed(w, http.MethodGet)
+// maxEventNoteLength bounds the notes operators attach to balance events.
+const maxEventNoteLength = 1000
+
+// annotateBalanceEvent acknowledges a balance event with "acknowledged_by",
+// or withdraws the acknowledgment when it is empty, and sets its "note".
+func (s *Server) annotateBalanceEvent(w http.ResponseWriter, r *http.Request, id int64) {
+	var req struct {
+		AcknowledgedBy *string `json:"acknowledged_by"`
+		Note           *string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
 		return
 	}
-
-	ctx := r.Context()
-
-	// Get latest plant reading
-	plantReading, err := s.store.GetLatestPlantReading(ctx)
-	if err != nil {
-		s.log.Error("get plant reading failed", "err", err)
-		writeError(w, http.StatusInternalServerError, "failed to fetch balance status")
+	if req.AcknowledgedBy == nil && req.Note == nil {
+		writeError(w, http.StatusBadRequest, "no fields to update")
 		return
 	}
-
-	// Get safety margin
-	safetyMarginStr, err := s.store.GetAppSetting(ctx, "safety_margin_percent")
-	if err != nil {
-		s.log.Warn("get safety margin failed, using default", "err", err)
-		safetyMarginStr = "10.0"
+	if req.AcknowledgedBy != nil && len(strings.TrimSpace(*req.AcknowledgedBy)) > maxMinerLabelLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("acknowledged_by must be at most %d characters", maxMinerLabelLength))
+		return
 	}
-
-	var safetyMargin float64
-	if err := json.Unmarshal([]byte(safetyMarginStr), &safetyMargin); err != nil {
-		safetyMargin = 10.0
+	if req.Note != nil && len(strings.TrimSpace(*req.Note)) > maxEventNoteLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("note must be at most %d characters", maxEventNoteLength))
+		return
 	}
 
-	// Get all miners and calculate consumption (managed + unmanaged)
-	miners, err := s.store.ListMiners(ctx)
+	event, err := s.store.AnnotatePowerBalanceEvent(r.Context(), id, database.PowerBalanceEventAnnotation{
+		AcknowledgedBy: req.AcknowledgedBy,
+		Note:           req.Note,
+	}, time.Now())
 	if err != nil {
-		s.log.Error("list miners failed", "err", err)
-		writeError(w, http.StatusInternalServerError, "failed to fetch balance status")
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "balance event not found")
+			return
+		}
+		s.log.Error("annotate balance event failed", "event", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to update balance event")
 		return
 	}
+	s.log.Info("balance event annotated", "event", id, "acknowledged", event.AcknowledgedAt != nil)
+	writeJSON(w, http.StatusOK, toPowerBalanceEventDTO(event))
+}
 
-	var currentConsumption float64
-	var managedConsumption float64
-	var unmanagedConsumption float64
-	managedCount := 0
-	for _, miner := range miners {
-		// Count all online miners with power data
-		if miner.IP == nil || *miner.IP == "" {
-			continue // Skip offline miners
+func (s *Server) handleHashboardEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
 		}
+	}
 
-		var minerPower float64
-		if miner.LatestStatus != nil && miner.LatestStatus.PowerConsumption != nil {
-			minerPower = *miner.LatestStatus.PowerConsumption
-		}
+	minerID := r.URL.Query().Get("miner_id")
+	var minerIDPtr *string
+	if minerID != "" {
+		minerIDPtr = &minerID
+	}
 
-		if miner.Managed {
-			managedCount++
-			managedConsumption += minerPower
-		} else {
-			unmanagedConsumption += minerPower
+	events, err := s.store.ListHashboardEvents(r.Context(), minerIDPtr, limit)
+	if err != nil {
+		s.log.Error("list hashboard events failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch hashboard events")
+		return
+	}
+
+	out := make([]hashboardEventDTO, 0, len(events))
+	for _, event := range events {
+		out = append(out, toHashboardEventDTO(event))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleDriftEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
 		}
-		currentConsumption += minerPower
 	}
 
+	minerID := r.URL.Query().Get("miner_id")
+	var minerIDPtr *string
+	if minerID != "" {
+		minerIDPtr = &minerID
+	}
+
+	events, err := s.store.ListDriftEvents(r.Context(), minerIDPtr, limit)
+	if err != nil {
+		s.log.Error("list drift events failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch drift events")
+		return
+	}
+
+	out := make([]driftEventDTO, 0, len(events))
+	for _, event := range events {
+		out = append(out, toDriftEventDTO(event))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleBalanceStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get latest plant reading
+	plantReading, err := s.store.GetLatestPlantReading(ctx)
+	if err != nil {
+		s.log.Error("get plant reading failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch balance status")
+		return
+	}
+
+	fleet, err := s.store.FleetSummary(ctx, time.Now())
+	if err != nil {
+		s.log.Error("fleet summary failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch balance status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.balanceStatus(ctx, plantReading, fleet))
+}
+
+// balanceStatus compares the fleet's consumption with the balancer's target
+// for the given plant reading, which may be nil.
+func (s *Server) balanceStatus(ctx context.Context, plantReading *database.PlantReading, fleet database.FleetSummary) balanceStatusDTO {
+	// Get safety margin
+	safetyMarginStr, err := s.store.GetAppSetting(ctx, "safety_margin_percent")
+	if err != nil {
+		s.log.Warn("get safety margin failed, using default", "err", err)
+		safetyMarginStr = "10.0"
+	}
+
+	var safetyMargin float64
+	if err := json.Unmarshal([]byte(safetyMarginStr), &safetyMargin); err != nil {
+		safetyMargin = 10.0
+	}
+
+	// Consumption of online miners (managed + unmanaged)
+	managedCount := fleet.Managed
+	managedConsumption := fleet.ManagedPowerW
+	unmanagedConsumption := fleet.UnmanagedPowerW
+	currentConsumption := managedConsumption + unmanagedConsumption
+
 	// Get expected consumption from app settings
 	var expectedConsumption float64
 	expectedConsumptionStr, err := s.store.GetAppSetting(ctx, "expected_consumption_w")
@@ -848,7 +1517,20 @@ func (s *Server) handleBalanceStatus(w http.ResponseWriter, r *http.Request) {
 		expectedConsumption = currentConsumption // Fallback to current if not set
 	}
 
+	mode, err := s.store.GetBalanceMode(ctx)
+	if err != nil {
+		s.log.Warn("get balance mode failed, using default", "err", err)
+		mode = database.BalanceModeFixedHeadroom
+	}
+
+	pause, err := s.store.GetBalancerPause(ctx)
+	if err != nil {
+		s.log.Warn("get balancer pause failed", "err", err)
+	}
+
 	var status balanceStatusDTO
+	status.BalanceMode = string(mode)
+	status.Paused = pause.Paused
 	status.SafetyMarginPercent = safetyMargin
 	status.ManagedMinersCount = managedCount
 	status.CurrentConsumptionW = currentConsumption
@@ -856,18 +1538,63 @@ func (s *Server) handleBalanceStatus(w http.ResponseWriter, r *http.Request) {
 	status.UnmanagedConsumptionW = unmanagedConsumption
 	status.ExpectedConsumptionW = expectedConsumption
 	status.ExpectedDeltaW = expectedConsumption - currentConsumption
+	s.averageConsumption(ctx, &status)
+	if s.changeLimits != nil {
+		if limits, ok := s.changeLimits.ChangeLimits(ctx); ok {
+			status.ChangeLimits = toChangeLimitsDTO(limits)
+		}
+	}
+	if s.reconciliation != nil {
+		if reconciliation, ok := s.reconciliation.Reconciliation(ctx); ok {
+			status.ContainerReconciliation = toReconciliationDTO(reconciliation)
+		}
+	}
+
+	gap, err := s.store.CurrentPlantGap(ctx)
+	if err != nil {
+		s.log.Warn("get plant gap failed", "err", err)
+	} else if gap != nil {
+		dto := toPlantGapDTO(*gap, time.Now())
+		status.PlantGap = &dto
+	}
 
 	if plantReading != nil {
 		status.PlantGenerationKW = plantReading.TotalGeneration
 		status.PlantContainerKW = plantReading.TotalContainerConsumption
 		status.AvailablePowerKW = plantReading.AvailablePower
-		targetPower := plantReading.TotalGeneration * (1.0 - safetyMargin/100.0)
+		status.ExportedKW = plantReading.ExportedPower
+		generation := plantReading.TotalGeneration
+		if s.smoothing != nil {
+			if smoothed, ok := s.smoothing.SmoothedGeneration(ctx); ok {
+				generation = smoothed.SmoothedKW
+				status.RawGenerationKW = &smoothed.RawKW
+				status.SmoothedGenerationKW = &smoothed.SmoothedKW
+				status.SmoothingMethod = smoothed.Method
+				status.SmoothingSamples = smoothed.Samples
+			}
+		}
+		targetPower := generation * (1.0 - safetyMargin/100.0)
+		if mode == database.BalanceModeZeroExport && plantReading.ExportedPower != nil {
+			targetPower = currentConsumption/1000.0 + *plantReading.ExportedPower
+		}
+		if plantReading.BatterySOC != nil {
+			policy, err := s.store.GetBatteryPolicy(ctx)
+			if err != nil {
+				s.log.Warn("get battery policy failed, using defaults", "err", err)
+				policy = database.DefaultBatteryPolicy
+			}
+			targetPower = policy.Adjust(*plantReading.BatterySOC, targetPower)
+		}
+		status.BatterySOCPercent = plantReading.BatterySOC
+		status.BatteryPowerKW = plantReading.BatteryPower
 		status.TargetPowerKW = targetPower
 		status.TargetPowerW = targetPower * 1000.0
 
 		// Include last reading timestamp
 		timestamp := formatTime(plantReading.RecordedAt)
 		status.LastReadingAt = &timestamp
+		age := math.Round(time.Since(plantReading.RecordedAt).Seconds())
+		status.DataAgeSeconds = &age
 
 		// Calculate status
 		delta := (status.TargetPowerW - currentConsumption) / status.TargetPowerW * 100
@@ -882,40 +1609,39 @@ func (s *Server) handleBalanceStatus(w http.ResponseWriter, r *http.Request) {
 		status.Status = "NO_DATA"
 	}
 
-	writeJSON(w, http.StatusOK, status)
+	return status
 }
 
-// Settings handlers
+// balanceAverageWindow is how far back balance status averages consumption.
+const balanceAverageWindow = 15 * time.Minute
 
-func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		s.listSettings(w, r)
-	default:
-		methodNotAllowed(w, http.MethodGet)
-	}
-}
+// averageConsumption fills the time-weighted miner and container
+// consumption over the last balanceAverageWindow, which ride out the noise
+// of the single latest sample. They stay nil without history.
+func (s *Server) averageConsumption(ctx context.Context, status *balanceStatusDTO) {
+	now := time.Now()
+	from := now.Add(-balanceAverageWindow)
+	status.AverageWindowSeconds = int(balanceAverageWindow / time.Second)
 
-func (s *Server) handleSettingsRoutes(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/settings/")
-	if path == "" {
-		http.NotFound(w, r)
-		return
+	miners, err := s.store.IntegrateMinerEnergy(ctx, "", from, now, reportMaxGap)
+	if err != nil {
+		s.log.Warn("integrate miner consumption failed", "err", err)
+	} else if avg := miners.AverageKW(); avg != nil {
+		watts := math.Round(*avg * 1000)
+		status.AverageConsumptionW = &watts
 	}
 
-	if path == "safety-margin" {
-		switch r.Method {
-		case http.MethodPatch:
-			s.updateSafetyMargin(w, r)
-		default:
-			methodNotAllowed(w, http.MethodPatch)
-		}
-		return
+	plant, err := s.store.IntegratePlantEnergy(ctx, from, now, reportMaxGap)
+	if err != nil {
+		s.log.Warn("integrate container consumption failed", "err", err)
+	} else if avg := plant.Container.AverageKW(); avg != nil {
+		kw := roundKWh(*avg)
+		status.AverageContainerKW = &kw
 	}
-
-	http.NotFound(w, r)
 }
 
+// Settings handlers
+
 func (s *Server) listSettings(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -930,8 +1656,182 @@ func (s *Server) listSettings(w http.ResponseWriter, r *http.Request) {
 		safetyMargin = 10.0
 	}
 
+	thermalLimits, err := s.store.GetThermalLimits(ctx)
+	if err != nil {
+		s.log.Warn("get thermal limits failed, using defaults", "err", err)
+		thermalLimits = database.DefaultThermalLimits
+	}
+
+	batteryPolicy, err := s.store.GetBatteryPolicy(ctx)
+	if err != nil {
+		s.log.Warn("get battery policy failed, using defaults", "err", err)
+		batteryPolicy = database.DefaultBatteryPolicy
+	}
+
+	balanceMode, err := s.store.GetBalanceMode(ctx)
+	if err != nil {
+		s.log.Warn("get balance mode failed, using default", "err", err)
+		balanceMode = database.BalanceModeFixedHeadroom
+	}
+
+	pause, err := s.store.GetBalancerPause(ctx)
+	if err != nil {
+		s.log.Warn("get balancer pause failed", "err", err)
+	}
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"safety_margin_percent": safetyMargin,
+		"thermal_limits":        thermalLimits,
+		"balance_mode":          balanceMode,
+		"battery_policy":        batteryPolicy,
+		"balancer_pause":        pause,
+	})
+}
+
+func (s *Server) updateBatteryPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		BoostSOCPercent       *float64 `json:"boost_soc_percent"`
+		MaxDischargeKW        *float64 `json:"max_discharge_kw"`
+		ReserveSOCPercent     *float64 `json:"reserve_soc_percent"`
+		DepletedMarginPercent *float64 `json:"depleted_margin_percent"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	policy, err := s.store.GetBatteryPolicy(ctx)
+	if err != nil {
+		s.log.Error("get battery policy failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to update setting")
+		return
+	}
+	if req.BoostSOCPercent != nil {
+		policy.BoostSOCPercent = *req.BoostSOCPercent
+	}
+	if req.MaxDischargeKW != nil {
+		policy.MaxDischargeKW = *req.MaxDischargeKW
+	}
+	if req.ReserveSOCPercent != nil {
+		policy.ReserveSOCPercent = *req.ReserveSOCPercent
+	}
+	if req.DepletedMarginPercent != nil {
+		policy.DepletedMarginPercent = *req.DepletedMarginPercent
+	}
+
+	for _, soc := range []float64{policy.BoostSOCPercent, policy.ReserveSOCPercent} {
+		if soc < 0 || soc > 100 {
+			writeError(w, http.StatusBadRequest, "state of charge thresholds must be between 0 and 100 percent")
+			return
+		}
+	}
+	if policy.ReserveSOCPercent >= policy.BoostSOCPercent {
+		writeError(w, http.StatusBadRequest, "reserve_soc_percent must be below boost_soc_percent")
+		return
+	}
+	if policy.MaxDischargeKW < 0 {
+		writeError(w, http.StatusBadRequest, "max_discharge_kw must not be negative")
+		return
+	}
+	if policy.DepletedMarginPercent < 0 || policy.DepletedMarginPercent > 50 {
+		writeError(w, http.StatusBadRequest, "depleted_margin_percent must be between 0 and 50 percent")
+		return
+	}
+
+	if err := s.store.SetBatteryPolicy(ctx, policy); err != nil {
+		s.log.Error("set battery policy failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to update setting")
+		return
+	}
+
+	s.log.Info("battery policy updated",
+		"boost_soc_percent", policy.BoostSOCPercent,
+		"max_discharge_kw", policy.MaxDischargeKW,
+		"reserve_soc_percent", policy.ReserveSOCPercent,
+		"depleted_margin_percent", policy.DepletedMarginPercent,
+	)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"battery_policy": policy,
+	})
+}
+
+func (s *Server) updateBalanceMode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		Mode database.BalanceMode `json:"mode"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if !req.Mode.Valid() {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("mode must be %q or %q",
+			database.BalanceModeFixedHeadroom, database.BalanceModeZeroExport))
+		return
+	}
+
+	if err := s.store.SetBalanceMode(ctx, req.Mode); err != nil {
+		s.log.Error("set balance mode failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to update setting")
+		return
+	}
+
+	s.log.Info("balance mode updated", "mode", req.Mode)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"balance_mode": req.Mode,
+	})
+}
+
+func (s *Server) updateThermalLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		ChipTempCeilingC *float64 `json:"chip_temp_ceiling_c"`
+		MarginC          *float64 `json:"margin_c"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	limits, err := s.store.GetThermalLimits(ctx)
+	if err != nil {
+		s.log.Error("get thermal limits failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to update setting")
+		return
+	}
+	if req.ChipTempCeilingC != nil {
+		limits.ChipTempCeilingC = *req.ChipTempCeilingC
+	}
+	if req.MarginC != nil {
+		limits.MarginC = *req.MarginC
+	}
+
+	if limits.ChipTempCeilingC != 0 && (limits.ChipTempCeilingC < 40 || limits.ChipTempCeilingC > 125) {
+		writeError(w, http.StatusBadRequest, "chip temperature ceiling must be 0 (disabled) or between 40 and 125 C")
+		return
+	}
+	if limits.MarginC < 0 || limits.MarginC > 30 {
+		writeError(w, http.StatusBadRequest, "thermal margin must be between 0 and 30 C")
+		return
+	}
+
+	if err := s.store.SetThermalLimits(ctx, limits); err != nil {
+		s.log.Error("set thermal limits failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to update setting")
+		return
+	}
+
+	s.log.Info("thermal limits updated", "chip_temp_ceiling_c", limits.ChipTempCeilingC, "margin_c", limits.MarginC)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"thermal_limits": limits,
 	})
 }
 
@@ -971,6 +1871,226 @@ func (s *Server) updateSafetyMargin(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// updateBalancerPause pauses or resumes automatic balancing. Hot miners
+// are still derated while paused.
+func (s *Server) updateBalancerPause(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		Paused *bool `json:"paused"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Paused == nil {
+		writeError(w, http.StatusBadRequest, "paused is required")
+		return
+	}
+
+	pause := database.BalancerPause{Paused: *req.Paused}
+	if pause.Paused {
+		now := time.Now().UTC()
+		pause.Since = &now
+		pause.By = "api"
+	}
+	if err := s.store.SetBalancerPause(ctx, pause); err != nil {
+		s.log.Error("set balancer pause failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to update setting")
+		return
+	}
+
+	s.log.Info("balancer pause updated", "paused", pause.Paused)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"balancer_pause": pause,
+	})
+}
+
+// Admin handlers
+
+func (s *Server) handleAdminReload(w http.ResponseWriter, r *http.Request) {
+	if s.reloader == nil {
+		writeError(w, http.StatusServiceUnavailable, "configuration reload is not available")
+		return
+	}
+
+	if err := s.reloader.ReloadConfig(); err != nil {
+		s.log.Error("config reload failed", "err", err)
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"status": "reloaded"})
+}
+
+func (s *Server) getLogLevels(w http.ResponseWriter, r *http.Request) {
+	if s.logLevels == nil {
+		writeError(w, http.StatusServiceUnavailable, "log level control is not available")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"levels": s.logLevels.LogLevels()})
+}
+
+func (s *Server) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	if s.logLevels == nil {
+		writeError(w, http.StatusServiceUnavailable, "log level control is not available")
+		return
+	}
+
+	var payload struct {
+		Component string `json:"component"`
+		Level     string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if err := s.logLevels.SetLogLevel(payload.Component, payload.Level); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.log.Info("log level changed", "target", payload.Component, "level", payload.Level)
+	writeJSON(w, http.StatusOK, map[string]any{"levels": s.logLevels.LogLevels()})
+}
+
+type firmwareLogEntryDTO struct {
+	Time         string  `json:"time"`
+	Method       string  `json:"method"`
+	URL          string  `json:"url"`
+	Status       int     `json:"status,omitempty"`
+	LatencyMS    float64 `json:"latency_ms"`
+	RequestBody  string  `json:"request_body,omitempty"`
+	ResponseBody string  `json:"response_body,omitempty"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// getFirmwareLog lists firmware request log entries, newest first,
+// optionally filtered by ?host=.
+func (s *Server) getFirmwareLog(w http.ResponseWriter, r *http.Request) {
+	if s.firmwareLog == nil {
+		writeError(w, http.StatusServiceUnavailable, "firmware request log is not available")
+		return
+	}
+
+	host := strings.TrimSpace(r.URL.Query().Get("host"))
+	entries := s.firmwareLog.Entries()
+	out := make([]firmwareLogEntryDTO, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if host != "" && !firmwareLogHostMatches(entry.URL, host) {
+			continue
+		}
+		out = append(out, firmwareLogEntryDTO{
+			Time:         formatTime(entry.Time),
+			Method:       entry.Method,
+			URL:          entry.URL,
+			Status:       entry.Status,
+			LatencyMS:    float64(entry.Latency.Microseconds()) / 1000,
+			RequestBody:  entry.RequestBody,
+			ResponseBody: entry.ResponseBody,
+			Error:        entry.Error,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"enabled":  s.firmwareLog.Enabled(),
+		"capacity": s.firmwareLog.Capacity(),
+		"entries":  out,
+	})
+}
+
+// setFirmwareLogEnabled toggles recording of firmware requests.
+func (s *Server) setFirmwareLogEnabled(w http.ResponseWriter, r *http.Request) {
+	if s.firmwareLog == nil {
+		writeError(w, http.StatusServiceUnavailable, "firmware request log is not available")
+		return
+	}
+
+	var payload struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if payload.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+	s.firmwareLog.SetEnabled(*payload.Enabled)
+	s.log.Info("firmware request log toggled", "enabled", *payload.Enabled)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"enabled":  s.firmwareLog.Enabled(),
+		"capacity": s.firmwareLog.Capacity(),
+	})
+}
+
+// clearFirmwareLog empties the firmware request log buffer.
+func (s *Server) clearFirmwareLog(w http.ResponseWriter, r *http.Request) {
+	if s.firmwareLog == nil {
+		writeError(w, http.StatusServiceUnavailable, "firmware request log is not available")
+		return
+	}
+	s.firmwareLog.Clear()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// firmwareLogHostMatches reports whether rawURL targets host, given as an
+// IP or hostname with or without a port.
+func firmwareLogHostMatches(rawURL, host string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, host) || strings.EqualFold(u.Hostname(), host)
+}
+
+func (s *Server) handleAdminBackup(w http.ResponseWriter, r *http.Request) {
+	if s.store.Dialect() != database.DialectSQLite {
+		writeError(w, http.StatusNotImplemented, "online backup is only available for sqlite")
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "powerhive-backup-")
+	if err != nil {
+		s.log.Error("create backup temp dir failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to create backup")
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	name := fmt.Sprintf("powerhive-%s.db", time.Now().UTC().Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+
+	if err := s.store.BackupTo(r.Context(), path); err != nil {
+		s.log.Error("backup failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to create backup")
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		s.log.Error("open backup failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to read backup")
+		return
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, file); err != nil {
+		s.log.Warn("stream backup failed", "err", err)
+		return
+	}
+
+	s.log.Info("backup downloaded", "file", name)
+}
+
 // DTOs for new endpoints
 
 type plantReadingDTO struct {
@@ -981,6 +2101,12 @@ type plantReadingDTO struct {
 	AvailablePower            float64            `json:"available_power"`
 	GenerationSources         map[string]float64 `json:"generation_sources,omitempty"`
 	ConsumptionSources        map[string]float64 `json:"consumption_sources,omitempty"`
+	ExportedPower             *float64           `json:"exported_power"`
+	BatterySOC                *float64           `json:"battery_soc,omitempty"`
+	BatteryPower              *float64           `json:"battery_power,omitempty"`
+	GridFrequency             *float64           `json:"grid_frequency,omitempty"`
+	GridVoltage               *float64           `json:"grid_voltage,omitempty"`
+	Fallback                  *string            `json:"fallback"`
 	RecordedAt                string             `json:"recorded_at"`
 }
 
@@ -993,10 +2119,66 @@ func toPlantReadingDTO(reading database.PlantReading) plantReadingDTO {
 		AvailablePower:            reading.AvailablePower,
 		GenerationSources:         reading.GenerationSources,
 		ConsumptionSources:        reading.ConsumptionSources,
+		ExportedPower:             reading.ExportedPower,
+		BatterySOC:                reading.BatterySOC,
+		BatteryPower:              reading.BatteryPower,
+		GridFrequency:             reading.GridFrequency,
+		GridVoltage:               reading.GridVoltage,
+		Fallback:                  reading.Fallback,
 		RecordedAt:                formatTime(reading.RecordedAt),
 	}
 }
 
+type hashboardEventDTO struct {
+	ID              int64   `json:"id"`
+	MinerID         string  `json:"miner_id"`
+	ChainIdentifier *string `json:"chain_identifier"`
+	Action          string  `json:"action"`
+	Attempt         int     `json:"attempt"`
+	Success         bool    `json:"success"`
+	ErrorMessage    *string `json:"error_message"`
+	RecordedAt      string  `json:"recorded_at"`
+}
+
+func toHashboardEventDTO(event database.HashboardEvent) hashboardEventDTO {
+	return hashboardEventDTO{
+		ID:              event.ID,
+		MinerID:         event.MinerID,
+		ChainIdentifier: event.ChainIdentifier,
+		Action:          event.Action,
+		Attempt:         event.Attempt,
+		Success:         event.Success,
+		ErrorMessage:    event.ErrorMessage,
+		RecordedAt:      formatTime(event.RecordedAt),
+	}
+}
+
+type driftEventDTO struct {
+	ID           int64   `json:"id"`
+	MinerID      string  `json:"miner_id"`
+	Field        string  `json:"field"`
+	Desired      *string `json:"desired"`
+	Actual       *string `json:"actual"`
+	Action       string  `json:"action"`
+	Success      bool    `json:"success"`
+	ErrorMessage *string `json:"error_message"`
+	RecordedAt   string  `json:"recorded_at"`
+}
+
+func toDriftEventDTO(event database.DriftEvent) driftEventDTO {
+	return driftEventDTO{
+		ID:           event.ID,
+		MinerID:      event.MinerID,
+		Field:        event.Field,
+		Desired:      event.Desired,
+		Actual:       event.Actual,
+		Action:       event.Action,
+		Success:      event.Success,
+		ErrorMessage: event.ErrorMessage,
+		RecordedAt:   formatTime(event.RecordedAt),
+	}
+}
+
 type powerBalanceEventDTO struct {
 	ID                     int64    `json:"id"`
 	MinerID                string   `json:"miner_id"`
@@ -1012,6 +2194,10 @@ type powerBalanceEventDTO struct {
 	Success                bool     `json:"success"`
 	ErrorMessage           *string  `json:"error_message"`
 	RecordedAt             string   `json:"recorded_at"`
+	Acknowledged           bool     `json:"acknowledged"`
+	AcknowledgedAt         *string  `json:"acknowledged_at"`
+	AcknowledgedBy         *string  `json:"acknowledged_by"`
+	Note                   *string  `json:"note"`
 }
 
 func toPowerBalanceEventDTO(event database.PowerBalanceEvent) powerBalanceEventDTO {
@@ -1030,22 +2216,44 @@ func toPowerBalanceEventDTO(event database.PowerBalanceEvent) powerBalanceEventD
 		Success:                event.Success,
 		ErrorMessage:           event.ErrorMessage,
 		RecordedAt:             formatTime(event.RecordedAt),
+		Acknowledged:           event.AcknowledgedAt != nil,
+		AcknowledgedAt:         formatTimePtr(event.AcknowledgedAt),
+		AcknowledgedBy:         event.AcknowledgedBy,
+		Note:                   event.Note,
 	}
 }
 
 type balanceStatusDTO struct {
-	PlantGenerationKW      float64 `json:"plant_generation_kw"`
-	PlantContainerKW       float64 `json:"plant_container_kw"`
-	AvailablePowerKW       float64 `json:"available_power_kw"`
-	SafetyMarginPercent    float64 `json:"safety_margin_percent"`
-	TargetPowerKW          float64 `json:"target_power_kw"`
-	TargetPowerW           float64 `json:"target_power_w"`
-	CurrentConsumptionW    float64 `json:"current_consumption_w"`
-	ManagedConsumptionW    float64 `json:"managed_consumption_w"`
-	UnmanagedConsumptionW  float64 `json:"unmanaged_consumption_w"`
-	ExpectedConsumptionW   float64 `json:"expected_consumption_w"`
-	ExpectedDeltaW         float64 `json:"expected_delta_w"`
-	ManagedMinersCount     int     `json:"managed_miners_count"`
-	Status                 string  `json:"status"`
-	LastReadingAt          *string `json:"last_reading_at,omitempty"`
+	PlantGenerationKW      float64  `json:"plant_generation_kw"`
+	PlantContainerKW       float64  `json:"plant_container_kw"`
+	AvailablePowerKW       float64  `json:"available_power_kw"`
+	ExportedKW             *float64 `json:"exported_kw"`
+	BatterySOCPercent      *float64 `json:"battery_soc_percent,omitempty"`
+	BatteryPowerKW         *float64 `json:"battery_power_kw,omitempty"`
+	BalanceMode            string   `json:"balance_mode"`
+	Paused                 bool     `json:"paused"`
+	SafetyMarginPercent    float64  `json:"safety_margin_percent"`
+	TargetPowerKW          float64  `json:"target_power_kw"`
+	TargetPowerW           float64  `json:"target_power_w"`
+	CurrentConsumptionW    float64  `json:"current_consumption_w"`
+	ManagedConsumptionW    float64  `json:"managed_consumption_w"`
+	UnmanagedConsumptionW  float64  `json:"unmanaged_consumption_w"`
+	ExpectedConsumptionW   float64  `json:"expected_consumption_w"`
+	ExpectedDeltaW         float64  `json:"expected_delta_w"`
+	ManagedMinersCount     int      `json:"managed_miners_count"`
+	AverageConsumptionW    *float64 `json:"average_consumption_w"`
+	AverageContainerKW     *float64 `json:"average_container_kw"`
+	AverageWindowSeconds   int      `json:"average_window_seconds"`
+	Status                 string   `json:"status"`
+	LastReadingAt          *string  `json:"last_reading_at,omitempty"`
+	DataAgeSeconds         *float64 `json:"data_age_seconds"`
+	PlantGap               *plantGapDTO `json:"plant_gap"`
+	// RawGenerationKW and SmoothedGenerationKW are set while the balancer
+	// smooths generation; the target is then based on the smoothed value.
+	RawGenerationKW        *float64 `json:"raw_generation_kw,omitempty"`
+	SmoothedGenerationKW   *float64 `json:"smoothed_generation_kw,omitempty"`
+	SmoothingMethod        string   `json:"smoothing_method,omitempty"`
+	SmoothingSamples       int      `json:"smoothing_samples,omitempty"`
+	ChangeLimits           *changeLimitsDTO `json:"change_limits,omitempty"`
+	ContainerReconciliation *reconciliationDTO `json:"container_reconciliation,omitempty"`
 }
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"powerhive/internal/database"
+)
+
+// TestUpdateModelRejectsInvertedPresetRange checks that the power floor can
+// never sit above the power cap, whichever end of the range a PATCH moves.
+func TestUpdateModelRejectsInvertedPresetRange(t *testing.T) {
+	srv, store := newTestServer(t)
+	ctx := context.Background()
+
+	if _, err := store.UpsertModel(ctx, database.ModelInput{Name: "Antminer S19", Alias: "s19", Presets: []string{"eco", "normal", "turbo"}}); err != nil {
+		t.Fatal(err)
+	}
+	for preset, watts := range map[string]float64{"eco": 2800, "normal": 3250, "turbo": 3600} {
+		if err := store.UpdatePresetMetrics(ctx, "s19", preset, &watts, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	patch := func(body string) int {
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/models/s19", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := patch(`{"max_preset": "normal", "min_preset": "eco"}`); code != http.StatusOK {
+		t.Fatalf("valid range: status %d, want 200", code)
+	}
+	if code := patch(`{"min_preset": "turbo"}`); code != http.StatusBadRequest {
+		t.Errorf("min_preset above max_preset: status %d, want 400", code)
+	}
+	if code := patch(`{"min_preset": "normal"}`); code != http.StatusOK {
+		t.Fatalf("min_preset equal to max_preset: status %d, want 200", code)
+	}
+	if code := patch(`{"max_preset": "eco"}`); code != http.StatusBadRequest {
+		t.Errorf("max_preset below min_preset: status %d, want 400", code)
+	}
+
+	model, err := store.GetModelByAlias(ctx, "s19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.MaxPreset == nil || *model.MaxPreset != "normal" || model.MinPreset == nil || *model.MinPreset != "normal" {
+		t.Errorf("rejected updates changed the model: max %v, min %v", model.MaxPreset, model.MinPreset)
+	}
+}
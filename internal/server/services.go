@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// Service states reported by GET /api/admin/services.
+const (
+	// ServiceRunning services have started and not yet returned.
+	ServiceRunning = "running"
+	// ServiceStopped services have returned, normally at shutdown.
+	ServiceStopped = "stopped"
+	// ServiceDisabled services are switched off in the services
+	// configuration or by the embedding program.
+	ServiceDisabled = "disabled"
+	// ServiceUnconfigured services are enabled but lack the configuration
+	// they need, such as backup without backup.dir.
+	ServiceUnconfigured = "unconfigured"
+	// ServiceNotStarted services will start when the app runs.
+	ServiceNotStarted = "not_started"
+	// ServiceStandby services wait for this instance to become the leader
+	// in high-availability mode.
+	ServiceStandby = "standby"
+)
+
+// ServiceState describes one service for GET /api/admin/services.
+type ServiceState struct {
+	Name      string
+	State     string
+	StartedAt *time.Time
+	StoppedAt *time.Time
+	// LastCycleAt is when the latest poll, scan, balance run or job
+	// finished, and LastError how it failed, if it did.
+	LastCycleAt *time.Time
+	LastError   string
+	Cycles      int64
+}
+
+// ServiceReporter lists the running process's services.
+type ServiceReporter interface {
+	ServiceStates() []ServiceState
+}
+
+// WithServiceReporter enables GET /api/admin/services.
+func WithServiceReporter(r ServiceReporter) Option {
+	return func(s *Server) {
+		s.services = r
+	}
+}
+
+type serviceStateDTO struct {
+	Name        string  `json:"name"`
+	State       string  `json:"state"`
+	StartedAt   *string `json:"started_at"`
+	StoppedAt   *string `json:"stopped_at"`
+	LastCycleAt *string `json:"last_cycle_at"`
+	LastError   *string `json:"last_error,omitempty"`
+	Cycles      int64   `json:"cycles"`
+}
+
+// handleAdminServices lists every service with its state and latest cycle.
+func (s *Server) handleAdminServices(w http.ResponseWriter, r *http.Request) {
+	if s.services == nil {
+		writeError(w, http.StatusServiceUnavailable, "service status is not available")
+		return
+	}
+
+	states := s.services.ServiceStates()
+	services := make([]serviceStateDTO, 0, len(states))
+	for _, state := range states {
+		dto := serviceStateDTO{
+			Name:        state.Name,
+			State:       state.State,
+			StartedAt:   formatTimePtr(state.StartedAt),
+			StoppedAt:   formatTimePtr(state.StoppedAt),
+			LastCycleAt: formatTimePtr(state.LastCycleAt),
+			Cycles:      state.Cycles,
+		}
+		if state.LastError != "" {
+			lastError := state.LastError
+			dto.LastError = &lastError
+		}
+		services = append(services, dto)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"services": services})
+}
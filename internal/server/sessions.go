@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// The dashboard signs in with a username and password and is then
+// identified by an HttpOnly session cookie. Writes made with the cookie
+// must repeat the session's CSRF token in csrfHeader. Programmatic clients
+// use bearer tokens instead and need no CSRF token.
+const (
+	sessionCookie     = "powerhive_session"
+	csrfHeader        = "X-CSRF-Token"
+	defaultSessionTTL = 12 * time.Hour
+)
+
+// publicAPIPaths stay open when sign-in is required.
+var publicAPIPaths = map[string]bool{
+	"/api/login":   true,
+	"/api/logout":  true,
+	"/api/session": true,
+	"/api/health":  true,
+}
+
+// SessionOptions configures dashboard sign-in. With Required set, API
+// requests other than publicAPIPaths and the customer portal need a session
+// or an admin token.
+type SessionOptions struct {
+	TTL      time.Duration
+	Required bool
+}
+
+// WithSessions configures dashboard sign-in.
+func WithSessions(opts SessionOptions) Option {
+	return func(s *Server) {
+		s.sessions = opts
+	}
+}
+
+type sessionKey struct{}
+
+type signedIn struct {
+	session database.Session
+	user    database.User
+}
+
+// sessionUser returns the user signed in with the request's session cookie.
+func sessionUser(ctx context.Context) (database.User, bool) {
+	current, ok := ctx.Value(sessionKey{}).(signedIn)
+	return current.user, ok
+}
+
+// authenticate resolves the session cookie, checks the CSRF token of
+// writes made with it, and turns away anonymous callers when sign-in is
+// required.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, portalPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if cookie, err := r.Cookie(sessionCookie); err == nil && cookie.Value != "" {
+			session, user, err := s.store.SessionForToken(r.Context(), cookie.Value, time.Now())
+			switch {
+			case err == nil:
+				if !safeMethod(r.Method) && subtle.ConstantTimeCompare([]byte(r.Header.Get(csrfHeader)), []byte(session.CSRFToken)) != 1 {
+					writeError(w, http.StatusForbidden, "missing or invalid "+csrfHeader+" header")
+					return
+				}
+				ctx := context.WithValue(r.Context(), sessionKey{}, signedIn{session: session, user: user})
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			case !errors.Is(err, database.ErrInvalidSession):
+				s.log.Error("session lookup failed", "err", err)
+				writeError(w, http.StatusInternalServerError, "failed to check session")
+				return
+			}
+		}
+
+		if s.sessions.Required && !publicAPIPaths[r.URL.Path] && !s.hasAdminToken(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="powerhive"`)
+			writeError(w, http.StatusUnauthorized, "sign in or send an admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cookiePath scopes the session cookie to the base path.
+func (s *Server) cookiePath() string {
+	return s.basePath + "/"
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+type sessionDTO struct {
+	Authenticated bool     `json:"authenticated"`
+	LoginRequired bool     `json:"login_required"`
+	User          *userDTO `json:"user,omitempty"`
+	CSRFToken     string   `json:"csrf_token,omitempty"`
+	ExpiresAt     *string  `json:"expires_at,omitempty"`
+}
+
+func (s *Server) toSessionDTO(session database.Session, user database.User) sessionDTO {
+	dto := toUserDTO(user)
+	expires := formatTime(session.ExpiresAt)
+	return sessionDTO{
+		Authenticated: true,
+		LoginRequired: s.sessions.Required,
+		User:          &dto,
+		CSRFToken:     session.CSRFToken,
+		ExpiresAt:     &expires,
+	}
+}
+
+// handleLogin checks a username and password and starts a session.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if strings.TrimSpace(req.Username) == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "username and password are required")
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	user, err := s.store.AuthenticateUser(ctx, req.Username, req.Password, now)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidCredentials) {
+			s.log.Warn("sign-in failed", "username", req.Username, "client", clientIP(r))
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		s.log.Error("sign-in failed", "username", req.Username, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to sign in")
+		return
+	}
+
+	ttl := s.sessions.TTL
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	session, token, err := s.store.CreateSession(ctx, user.ID, ttl, now)
+	if err != nil {
+		s.log.Error("create session failed", "user", user.ID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to sign in")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     s.cookiePath(),
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	s.log.Info("user signed in", "user", user.Username, "client", clientIP(r))
+	writeJSON(w, http.StatusOK, s.toSessionDTO(session, user))
+}
+
+// handleLogout ends the request's session, if any, and clears the cookie.
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil && cookie.Value != "" {
+		if err := s.store.DeleteSession(r.Context(), cookie.Value); err != nil {
+			s.log.Error("delete session failed", "err", err)
+			writeError(w, http.StatusInternalServerError, "failed to sign out")
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     s.cookiePath(),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSession reports who is signed in, with the CSRF token the dashboard
+// sends on writes.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	current, ok := r.Context().Value(sessionKey{}).(signedIn)
+	if !ok {
+		writeJSON(w, http.StatusOK, sessionDTO{LoginRequired: s.sessions.Required})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.toSessionDTO(current.session, current.user))
+}
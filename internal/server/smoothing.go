@@ -0,0 +1,26 @@
+package server
+
+import "context"
+
+// SmoothedGeneration is the plant generation before and after the
+// balancer's smoothing filter.
+type SmoothedGeneration struct {
+	Method     string
+	RawKW      float64
+	SmoothedKW float64
+	Samples    int
+}
+
+// GenerationSmoother reports the generation the balancer targets. ok is
+// false while smoothing is off or there are no readings.
+type GenerationSmoother interface {
+	SmoothedGeneration(ctx context.Context) (SmoothedGeneration, bool)
+}
+
+// WithGenerationSmoothing makes /api/balance/status report the smoothed
+// generation and base its target on it, as the balancer does.
+func WithGenerationSmoothing(g GenerationSmoother) Option {
+	return func(s *Server) {
+		s.smoothing = g
+	}
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+// Timeline entry kinds.
+const (
+	timelineAvailability = "availability"
+	timelinePreset       = "preset_change"
+	timelineStatus       = "status"
+	timelineHashboard    = "hashboard"
+	timelineDrift        = "drift"
+	timelineKeyRotation  = "key_rotation"
+)
+
+var timelineKinds = []string{timelineAvailability, timelinePreset, timelineStatus, timelineHashboard, timelineDrift, timelineKeyRotation}
+
+const (
+	defaultTimelineLimit = 1000
+	maxTimelineLimit     = 10000
+)
+
+type timelineEntryDTO struct {
+	Kind    string `json:"kind"`
+	At      string `json:"at"`
+	Summary string `json:"summary"`
+	// Success is set for entries that record an action PowerHive took.
+	Success *bool `json:"success,omitempty"`
+	// Data is the entry as the endpoint for its kind returns it.
+	Data any `json:"data"`
+
+	at time.Time
+}
+
+type timelineDTO struct {
+	MinerID   string             `json:"miner_id"`
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Entries   []timelineEntryDTO `json:"entries"`
+	Truncated bool               `json:"truncated"`
+}
+
+type statusChangeDTO struct {
+	StatusID   int64   `json:"status_id"`
+	OldState   *string `json:"old_state"`
+	NewState   *string `json:"new_state"`
+	OldPreset  *string `json:"old_preset"`
+	NewPreset  *string `json:"new_preset"`
+	RecordedAt string  `json:"recorded_at"`
+}
+
+type availabilityChangeDTO struct {
+	Online    bool    `json:"online"`
+	Source    string  `json:"source"`
+	Reason    *string `json:"reason"`
+	ChangedAt string  `json:"changed_at"`
+}
+
+// handleMinerTimeline merges what was recorded about a miner between from
+// and to (default the last 24 hours) into one feed, oldest first. kinds
+// limits it to a comma-separated list of entry kinds. Past limit (default
+// 1000) the oldest entries are dropped and truncated is set.
+func (s *Server) handleMinerTimeline(w http.ResponseWriter, r *http.Request, minerID string) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	from, to, err := parseExportRange(query.Get("from"), query.Get("to"), time.UTC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(query.Get("from")) == "" {
+		from = to.Add(-24 * time.Hour)
+	}
+
+	limit := defaultTimelineLimit
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(parsed, maxTimelineLimit)
+	}
+
+	kinds := make(map[string]bool, len(timelineKinds))
+	if raw := strings.TrimSpace(query.Get("kinds")); raw != "" {
+		for _, kind := range strings.Split(raw, ",") {
+			kind = strings.TrimSpace(kind)
+			if !slices.Contains(timelineKinds, kind) {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown kind %q; use %s", kind, strings.Join(timelineKinds, ", ")))
+				return
+			}
+			kinds[kind] = true
+		}
+	} else {
+		for _, kind := range timelineKinds {
+			kinds[kind] = true
+		}
+	}
+
+	if _, err := s.store.GetMiner(ctx, minerID); err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "miner not found")
+			return
+		}
+		s.log.Error("get miner failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch miner")
+		return
+	}
+
+	timeline, err := s.store.GetMinerTimeline(ctx, minerID, from, to)
+	if err != nil {
+		s.log.Error("get miner timeline failed", "miner", minerID, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch timeline")
+		return
+	}
+
+	entries := buildTimeline(timeline, kinds)
+	out := timelineDTO{
+		MinerID: minerID,
+		From:    formatTime(from),
+		To:      formatTime(to),
+		Entries: entries,
+	}
+	if len(entries) > limit {
+		out.Entries = entries[len(entries)-limit:]
+		out.Truncated = true
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// buildTimeline turns the sources of timeline selected by kinds into
+// entries, oldest first.
+func buildTimeline(timeline database.MinerTimeline, kinds map[string]bool) []timelineEntryDTO {
+	entries := make([]timelineEntryDTO, 0)
+	add := func(kind string, at time.Time, summary string, success *bool, data any) {
+		if kinds[kind] {
+			entries = append(entries, timelineEntryDTO{Kind: kind, At: formatTime(at), Summary: summary, Success: success, Data: data, at: at})
+		}
+	}
+
+	for _, change := range timeline.Availability {
+		state := "offline"
+		if change.Online {
+			state = "online"
+		}
+		summary := fmt.Sprintf("%s (%s)", state, change.Source)
+		if change.Reason != nil && *change.Reason != "" {
+			summary += ": " + *change.Reason
+		}
+		add(timelineAvailability, change.ChangedAt, summary, nil, availabilityChangeDTO{
+			Online:    change.Online,
+			Source:    change.Source,
+			Reason:    change.Reason,
+			ChangedAt: formatTime(change.ChangedAt),
+		})
+	}
+	for _, event := range timeline.BalanceEvents {
+		summary := fmt.Sprintf("preset %s → %s (%s)", orDash(event.OldPreset), orDash(event.NewPreset), event.Reason)
+		add(timelinePreset, event.RecordedAt, withFailure(summary, event.Success, event.ErrorMessage), &event.Success, toPowerBalanceEventDTO(event))
+	}
+	for _, change := range timeline.StatusChanges {
+		var parts []string
+		if !sameString(change.OldState, change.NewState) {
+			parts = append(parts, fmt.Sprintf("state %s → %s", orDash(change.OldState), orDash(change.NewState)))
+		}
+		if !sameString(change.OldPreset, change.NewPreset) {
+			parts = append(parts, fmt.Sprintf("preset %s → %s", orDash(change.OldPreset), orDash(change.NewPreset)))
+		}
+		add(timelineStatus, change.RecordedAt, strings.Join(parts, ", "), nil, statusChangeDTO{
+			StatusID:   change.StatusID,
+			OldState:   change.OldState,
+			NewState:   change.NewState,
+			OldPreset:  change.OldPreset,
+			NewPreset:  change.NewPreset,
+			RecordedAt: formatTime(change.RecordedAt),
+		})
+	}
+	for _, event := range timeline.HashboardEvents {
+		summary := "hashboard " + event.Action
+		if event.ChainIdentifier != nil {
+			summary += " on chain " + *event.ChainIdentifier
+		}
+		if event.Attempt > 0 {
+			summary += fmt.Sprintf(" (attempt %d)", event.Attempt)
+		}
+		add(timelineHashboard, event.RecordedAt, withFailure(summary, event.Success, event.ErrorMessage), &event.Success, toHashboardEventDTO(event))
+	}
+	for _, event := range timeline.DriftEvents {
+		summary := fmt.Sprintf("%s drifted to %s (desired %s): %s", event.Field, orDash(event.Actual), orDash(event.Desired), event.Action)
+		add(timelineDrift, event.RecordedAt, withFailure(summary, event.Success, event.ErrorMessage), &event.Success, toDriftEventDTO(event))
+	}
+	for _, rotation := range timeline.KeyRotations {
+		summary := fmt.Sprintf("API key rotated (%s)", rotation.Trigger)
+		add(timelineKeyRotation, rotation.RotatedAt, withFailure(summary, rotation.Success, rotation.ErrorMessage), &rotation.Success, toKeyRotationDTO(rotation))
+	}
+
+	// Entries at the same instant keep the order of timelineKinds, so a
+	// preset change comes before the poll that saw it.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].at.Before(entries[j].at)
+	})
+	return entries
+}
+
+func withFailure(summary string, success bool, errorMessage *string) string {
+	if success {
+		return summary
+	}
+	if errorMessage != nil && *errorMessage != "" {
+		return summary + " failed: " + *errorMessage
+	}
+	return summary + " failed"
+}
+
+func sameString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func orDash(value *string) string {
+	if value == nil || *value == "" {
+		return "—"
+	}
+	return *value
+}
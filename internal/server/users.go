@@ -0,0 +1,168 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"powerhive/internal/database"
+)
+
+// maxUsernameLength bounds dashboard usernames.
+const maxUsernameLength = 64
+
+type userDTO struct {
+	ID          int64   `json:"id"`
+	Username    string  `json:"username"`
+	Role        string  `json:"role"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+	LastLoginAt *string `json:"last_login_at"`
+}
+
+func toUserDTO(user database.User) userDTO {
+	return userDTO{
+		ID:          user.ID,
+		Username:    user.Username,
+		Role:        user.Role,
+		CreatedAt:   formatTime(user.CreatedAt),
+		UpdatedAt:   formatTime(user.UpdatedAt),
+		LastLoginAt: formatTimePtr(user.LastLoginAt),
+	}
+}
+
+// requireAdmin writes 403 and returns false unless the request carries the
+// admin role.
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !s.isAdmin(r) {
+		writeError(w, http.StatusForbidden, "this requires the admin role")
+		return false
+	}
+	return true
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	users, err := s.store.ListUsers(r.Context())
+	if err != nil {
+		s.log.Error("list users failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+	out := make([]userDTO, 0, len(users))
+	for _, user := range users {
+		out = append(out, toUserDTO(user))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// createUser accepts "username", "password" and an optional "role", which
+// defaults to operator.
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	username := strings.TrimSpace(req.Username)
+	if username == "" || len(username) > maxUsernameLength || strings.ContainsAny(username, " \t") {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("username must be 1 to %d characters without spaces", maxUsernameLength))
+		return
+	}
+	if req.Role == "" {
+		req.Role = database.RoleOperator
+	}
+	if !validUserChange(w, &req.Password, &req.Role) {
+		return
+	}
+
+	user, err := s.store.CreateUser(r.Context(), username, req.Password, req.Role)
+	if err != nil {
+		s.writeUserError(w, 0, err)
+		return
+	}
+	s.log.Info("user created", "user", user.Username, "role", user.Role)
+	writeJSON(w, http.StatusCreated, toUserDTO(user))
+}
+
+// updateUser changes a user's "password" or "role". Users may change their
+// own password; everything else needs the admin role.
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request, id int64) {
+	var req struct {
+		Password *string `json:"password"`
+		Role     *string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if req.Password == nil && req.Role == nil {
+		writeError(w, http.StatusBadRequest, "no fields to update")
+		return
+	}
+	self, ok := sessionUser(r.Context())
+	if !(ok && self.ID == id && req.Role == nil) && !s.requireAdmin(w, r) {
+		return
+	}
+	if !validUserChange(w, req.Password, req.Role) {
+		return
+	}
+
+	user, err := s.store.UpdateUser(r.Context(), id, database.UserUpdate{Password: req.Password, Role: req.Role})
+	if err != nil {
+		s.writeUserError(w, id, err)
+		return
+	}
+	s.log.Info("user updated", "user", user.Username, "role", user.Role, "password_changed", req.Password != nil)
+	writeJSON(w, http.StatusOK, toUserDTO(user))
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request, id int64) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if err := s.store.DeleteUser(r.Context(), id); err != nil {
+		s.writeUserError(w, id, err)
+		return
+	}
+	s.log.Info("user deleted", "user", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validUserChange checks a new password and role, either of which may be
+// nil, and writes 400 when one is invalid.
+func validUserChange(w http.ResponseWriter, password, role *string) bool {
+	if password != nil && (len(*password) < database.MinPasswordLength || len(*password) > database.MaxPasswordLength) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("password must be %d to %d bytes long", database.MinPasswordLength, database.MaxPasswordLength))
+		return false
+	}
+	if role != nil && !database.ValidUserRole(*role) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("role must be %q or %q", database.RoleAdmin, database.RoleOperator))
+		return false
+	}
+	return true
+}
+
+// writeUserError maps a user store error to a response.
+func (s *Server) writeUserError(w http.ResponseWriter, id int64, err error) {
+	switch {
+	case isNotFound(err):
+		writeError(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, database.ErrUserExists), errors.Is(err, database.ErrLastAdmin):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		s.log.Error("user request failed", "user", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to save user")
+	}
+}
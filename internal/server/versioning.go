@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The REST API is versioned by path. Routes are served under /api/v1/;
+// the unversioned /api/ paths are aliases kept for existing clients until
+// the configured sunset.
+const (
+	apiVersion       = "1"
+	apiVersionPrefix = "/api/v" + apiVersion
+	// apiVersionHeader names the version a response was produced by. A
+	// client may send it to assert the version it was written against.
+	apiVersionHeader = "API-Version"
+)
+
+// WithLegacyAPISunset announces when the unversioned /api/ aliases go
+// away. From sunset on they answer 410 Gone.
+func WithLegacyAPISunset(sunset time.Time) Option {
+	return func(s *Server) {
+		s.legacySunset = sunset
+	}
+}
+
+// versionAPI maps /api/v1/ requests onto the routes, which are registered
+// without the version, and marks requests to the unversioned aliases as
+// deprecated with the Deprecation, Sunset and Link headers of RFC 9745 and
+// RFC 8594. An unknown version is not found.
+func (s *Server) versionAPI(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set(apiVersionHeader, apiVersion)
+
+		version, rest, versioned := splitAPIVersion(r.URL.Path)
+		if want := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(apiVersionHeader)), "v"); want != "" && want != apiVersion {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported API version %q; this server speaks version %s", want, apiVersion))
+			return
+		}
+		if versioned {
+			if version != apiVersion {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("unknown API version v%s; use %s/", version, apiVersionPrefix))
+				return
+			}
+			next.ServeHTTP(w, withPath(r, "/api"+rest))
+			return
+		}
+
+		successor := s.basePath + apiVersionPrefix + strings.TrimPrefix(r.URL.Path, "/api")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		if !s.legacySunset.IsZero() {
+			w.Header().Set("Sunset", s.legacySunset.UTC().Format(http.TimeFormat))
+			if !time.Now().Before(s.legacySunset) {
+				writeError(w, http.StatusGone, "unversioned API paths were retired; use "+successor)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// splitAPIVersion splits /api/v<n>/rest into n and /rest. versioned is
+// false for paths without a version segment.
+func splitAPIVersion(path string) (version, rest string, versioned bool) {
+	segment, rest, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+	if len(segment) < 2 || segment[0] != 'v' {
+		return "", "", false
+	}
+	for _, c := range segment[1:] {
+		if c < '0' || c > '9' {
+			return "", "", false
+		}
+	}
+	return segment[1:], "/" + rest, true
+}
+
+// withPath returns a shallow copy of r for path, as http.StripPrefix does.
+func withPath(r *http.Request, path string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = path
+	r2.URL.RawPath = ""
+	return r2
+}
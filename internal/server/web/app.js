@@ -16,11 +16,30 @@ document.addEventListener("DOMContentLoaded", () => {
     modelsContainer: document.querySelector("#models-container"),
     notifications: document.querySelector("#notifications"),
     refreshMiners: document.querySelector("#refresh-miners"),
+    minerSearch: document.querySelector("#miner-search"),
+    minerStatus: document.querySelector("#miner-status"),
+    minerManaged: document.querySelector("#miner-managed"),
+    minerSort: document.querySelector("#miner-sort"),
     minerModal: document.querySelector("#miner-modal"),
     minerModalContent: document.querySelector("#miner-modal-content"),
     minerModalClose: document.querySelector("#miner-modal-close"),
+    loginModal: document.querySelector("#login-modal"),
+    loginForm: document.querySelector("#login-form"),
+    loginError: document.querySelector("#login-error"),
+    sessionInfo: document.querySelector("#session-info"),
+    sessionUser: document.querySelector("#session-user"),
+    logoutButton: document.querySelector("#logout-button"),
   };
 
+  // The server injects the path it is served under behind a reverse proxy.
+  const BASE_PATH = document.querySelector('meta[name="powerhive-base-path"]')?.content || "";
+  const API = `${BASE_PATH}/api/v1`;
+
+  // The session's CSRF token, sent on every write once signed in.
+  let csrfToken = null;
+  // Who is signed in, offered as the name on acknowledgments.
+  let signedInAs = null;
+
   const AUTO_REFRESH_MS = 10_000;
 
   const showToast = (message, type = "success", timeout = 5000) => {
@@ -106,6 +125,23 @@ document.addEventListener("DOMContentLoaded", () => {
     return parts.slice(0, 3).join(" ");
   };
 
+  const escapeHTML = (value) =>
+    String(value ?? "").replace(/[&<>"']/g, (ch) => ({ "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;" })[ch]);
+
+  const minerLabel = (miner) => {
+    const parts = [miner.name, miner.location].filter(Boolean).map(escapeHTML);
+    let tags = (miner.tags || []).map((tag) => `<span class="tag">${escapeHTML(tag)}</span>`).join(" ");
+    if (miner.held) {
+      const until = miner.hold_until ? ` until ${new Date(miner.hold_until).toLocaleString()}` : "";
+      tags = `<span class="tag" title="The balancer will not change this miner${until}">hold</span> ${tags}`;
+    }
+    if (miner.protected) {
+      tags = `<span class="tag" title="Presets and pools change only when an admin forces it">protected</span> ${tags}`;
+    }
+    if (!parts.length && !tags) return "";
+    return `<div class="small">${parts.join(" · ")} ${tags}</div>`;
+  };
+
   const prettifyChainState = (state) => {
     const value = (state || "").trim();
     if (!value) return "unknown";
@@ -194,6 +230,10 @@ document.addEventListener("DOMContentLoaded", () => {
     });
   };
 
+  // An unchanged miner keeps its snapshot; the latest poll is when it was
+  // last confirmed.
+  const lastSeen = (status) => status?.last_confirmed_at || status?.recorded_at || null;
+
   const deriveMinerStatus = (miner) => {
     if (!miner) {
       return { label: "Offline", className: "status-offline" };
@@ -221,7 +261,12 @@ document.addEventListener("DOMContentLoaded", () => {
   };
 
   const fetchJSON = async (url, options = {}) => {
+    const method = (options.method || "GET").toUpperCase();
+    if (csrfToken && method !== "GET" && method !== "HEAD") {
+      options = { ...options, headers: { ...options.headers, "X-CSRF-Token": csrfToken } };
+    }
     const res = await fetch(url, options);
+    if (res.status === 401) showLogin();
     if (!res.ok) {
       const data = await res.json().catch(() => ({}));
       const error = data.error || res.statusText || "Request failed";
@@ -230,9 +275,68 @@ document.addEventListener("DOMContentLoaded", () => {
     return res.json();
   };
 
+  const showSession = (session) => {
+    csrfToken = session.csrf_token || null;
+    signedInAs = session.authenticated ? session.user.username : null;
+    if (!refs.sessionInfo) return;
+    refs.sessionInfo.classList.toggle("hidden", !session.authenticated);
+    if (session.authenticated) {
+      refs.sessionUser.textContent = `Signed in as ${session.user.username} (${session.user.role})`;
+    }
+  };
+
+  const loginVisible = () => refs.loginModal && !refs.loginModal.classList.contains("hidden");
+
+  const showLogin = () => {
+    if (!refs.loginModal || loginVisible()) return;
+    refs.loginModal.classList.remove("hidden");
+    refs.loginModal.setAttribute("aria-hidden", "false");
+    document.body.classList.add("modal-open");
+    refs.loginForm?.elements.username.focus();
+  };
+
+  const hideLogin = () => {
+    if (!refs.loginModal) return;
+    refs.loginModal.classList.add("hidden");
+    refs.loginModal.setAttribute("aria-hidden", "true");
+    document.body.classList.remove("modal-open");
+  };
+
+  // loadSession shows who is signed in and asks for a sign-in when the
+  // server requires one. It resolves to whether data can be loaded.
+  const loadSession = async () => {
+    try {
+      const session = await fetchJSON(`${API}/session`);
+      showSession(session);
+      if (session.login_required && !session.authenticated) {
+        showLogin();
+        return false;
+      }
+    } catch (error) {
+      console.error("Failed to load session", error);
+    }
+    return true;
+  };
+
+  // minersURL passes the table's search, filters and sort to the server, so
+  // only the matching miners are downloaded.
+  const minersURL = () => {
+    const params = new URLSearchParams();
+    const add = (name, input) => {
+      const value = input?.value.trim();
+      if (value) params.set(name, value);
+    };
+    add("q", refs.minerSearch);
+    add("status", refs.minerStatus);
+    add("managed", refs.minerManaged);
+    add("sort", refs.minerSort);
+    const query = params.toString();
+    return query ? `${API}/miners?${query}` : `${API}/miners`;
+  };
+
   const fetchMiners = async (silent = false) => {
     try {
-      const data = await fetchJSON("/api/miners");
+      const data = await fetchJSON(minersURL());
       state.miners = Array.isArray(data) ? data : [];
       renderMiners();
       if (state.selectedMiner && refs.minerModal && !refs.minerModal.classList.contains("hidden")) {
@@ -255,7 +359,7 @@ document.addEventListener("DOMContentLoaded", () => {
 
   const fetchModels = async () => {
     try {
-      const data = await fetchJSON("/api/models");
+      const data = await fetchJSON(`${API}/models`);
       state.models = Array.isArray(data) ? data : [];
       renderModels();
     } catch (err) {
@@ -265,7 +369,7 @@ document.addEventListener("DOMContentLoaded", () => {
 
   const fetchBalanceStatus = async () => {
     try {
-      const data = await fetchJSON("/api/balance/status");
+      const data = await fetchJSON(`${API}/balance/status`);
       state.balanceStatus = data;
       renderBalanceStatus();
     } catch (err) {
@@ -275,7 +379,7 @@ document.addEventListener("DOMContentLoaded", () => {
 
   const fetchPlantHistory = async () => {
     try {
-      const data = await fetchJSON("/api/plant/history?limit=50");
+      const data = await fetchJSON(`${API}/plant/history?limit=50`);
       state.plantData = Array.isArray(data) ? data : [];
       updateEnergyChart();
     } catch (err) {
@@ -285,7 +389,7 @@ document.addEventListener("DOMContentLoaded", () => {
 
   const fetchBalanceEvents = async () => {
     try {
-      const data = await fetchJSON("/api/balance/events?limit=20");
+      const data = await fetchJSON(`${API}/balance/events?limit=20`);
       state.balanceEvents = Array.isArray(data) ? data : [];
       renderBalanceEvents();
     } catch (err) {
@@ -293,6 +397,25 @@ document.addEventListener("DOMContentLoaded", () => {
     }
   };
 
+  const acknowledgeBalanceEvent = async (eventId) => {
+    const by = window.prompt("Acknowledged by", signedInAs || "");
+    if (by === null || by.trim() === "") return;
+    const note = window.prompt("Note (optional)", "");
+    if (note === null) return;
+
+    try {
+      await fetchJSON(`${API}/balance/events/${eventId}`, {
+        method: "PATCH",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ acknowledged_by: by, note }),
+      });
+      showToast("Balance event acknowledged", "success");
+      await fetchBalanceEvents();
+    } catch (err) {
+      showToast(err.message, "error");
+    }
+  };
+
   const updateSafetyMargin = async () => {
     const input = document.getElementById("safety-margin-input");
     const value = parseFloat(input.value);
@@ -303,7 +426,7 @@ document.addEventListener("DOMContentLoaded", () => {
     }
 
     try {
-      await fetchJSON("/api/settings/safety-margin", {
+      await fetchJSON(`${API}/settings/safety-margin`, {
         method: "PATCH",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ safety_margin_percent: value }),
@@ -315,10 +438,25 @@ document.addEventListener("DOMContentLoaded", () => {
     }
   };
 
+  const updateBalanceMode = async (event) => {
+    const mode = event.target.value;
+    try {
+      await fetchJSON(`${API}/settings/balance-mode`, {
+        method: "PATCH",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ mode }),
+      });
+      showToast("Balance mode updated", "success");
+      await fetchBalanceStatus();
+    } catch (err) {
+      showToast(err.message, "error");
+    }
+  };
+
   const updateManaged = async (minerId, value, checkbox) => {
     checkbox.disabled = true;
     try {
-      await fetchJSON(`/api/miners/${encodeURIComponent(minerId)}`, {
+      await fetchJSON(`${API}/miners/${encodeURIComponent(minerId)}`, {
         method: "PATCH",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({ managed: value }),
@@ -333,16 +471,17 @@ document.addEventListener("DOMContentLoaded", () => {
     }
   };
 
-  const updateModelMaxPreset = async (alias, value, select) => {
+  const updateModelPresetBound = async (alias, field, value, select) => {
     select.disabled = true;
     try {
-      const payload = { max_preset: value || null };
-      await fetchJSON(`/api/models/${encodeURIComponent(alias)}`, {
+      const payload = { [field]: value || null };
+      await fetchJSON(`${API}/models/${encodeURIComponent(alias)}`, {
         method: "PATCH",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify(payload),
       });
-      showToast(`Model ${alias} max preset saved.`, "success");
+      const label = field === "min_preset" ? "min" : "max";
+      showToast(`Model ${alias} ${label} preset saved.`, "success");
       await fetchModels();
       await fetchMiners(true);
     } catch (err) {
@@ -354,7 +493,7 @@ document.addEventListener("DOMContentLoaded", () => {
 
   const fetchMinerStatuses = async (minerId, silent = false) => {
     try {
-      const data = await fetchJSON(`/api/miners/${encodeURIComponent(minerId)}/statuses?limit=5`);
+      const data = await fetchJSON(`${API}/miners/${encodeURIComponent(minerId)}/statuses?limit=5`);
       return Array.isArray(data) ? data : [];
     } catch (err) {
       if (!silent) {
@@ -366,7 +505,7 @@ document.addEventListener("DOMContentLoaded", () => {
 
   const fetchMinerTelemetry = async (minerId, silent = false) => {
     try {
-      const data = await fetchJSON(`/api/miners/${encodeURIComponent(minerId)}/telemetry?limit=60`);
+      const data = await fetchJSON(`${API}/miners/${encodeURIComponent(minerId)}/telemetry?limit=60`);
       return Array.isArray(data) ? data : [];
     } catch (err) {
       if (!silent) {
@@ -579,7 +718,7 @@ document.addEventListener("DOMContentLoaded", () => {
       },
       {
         label: "Last Update",
-        value: latestStatus.recorded_at ? formatExactTime(latestStatus.recorded_at) : "—",
+        value: lastSeen(latestStatus) ? formatExactTime(lastSeen(latestStatus)) : "—",
       },
     ];
 
@@ -607,10 +746,13 @@ document.addEventListener("DOMContentLoaded", () => {
     refs.minerModalContent.innerHTML = `
       <header class="modal-header">
         <div>
-          <h2>Miner ${miner.id}</h2>
-          <p class="muted">Detailed overview</p>
+          <h2>Miner ${escapeHTML(miner.name || miner.id)}</h2>
+          <p class="muted">${miner.location ? `${escapeHTML(miner.location)} · ` : ""}${miner.name ? `${miner.id} · ` : ""}Detailed overview</p>
+        </div>
+        <div class="modal-actions">
+          <button id="miner-modal-locate" type="button" title="Blink the locate LED">Locate</button>
+          <button id="miner-modal-close" class="modal-close" aria-label="Close" type="button">&times;</button>
         </div>
-        <button id="miner-modal-close" class="modal-close" aria-label="Close" type="button">&times;</button>
       </header>
       <section class="modal-summary">${summaryMarkup}</section>
       ${fanSection}
@@ -628,6 +770,26 @@ document.addEventListener("DOMContentLoaded", () => {
     if (refs.minerModalClose) {
       refs.minerModalClose.addEventListener("click", closeMinerModal, { once: true });
     }
+    const locateButton = document.querySelector("#miner-modal-locate");
+    if (locateButton) {
+      locateButton.addEventListener("click", () => locateMiner(miner.id, locateButton));
+    }
+  };
+
+  const locateMiner = async (minerId, button) => {
+    button.disabled = true;
+    try {
+      await fetchJSON(`${API}/miners/${encodeURIComponent(minerId)}/locate`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ enabled: true }),
+      });
+      showToast(`Locate LED blinking on ${minerId}.`, "success");
+    } catch (err) {
+      showToast(err.message, "error");
+    } finally {
+      button.disabled = false;
+    }
   };
 
   const openMinerModal = async (miner, silent = false) => {
@@ -673,7 +835,8 @@ document.addEventListener("DOMContentLoaded", () => {
     state.miners.forEach((miner) => {
       const latest = miner.latest_status || {};
       const { label: statusLabel, className: statusClass } = deriveMinerStatus(miner);
-      const updated = latest.recorded_at ? formatRelativeTime(latest.recorded_at) : "—";
+      const seen = lastSeen(latest);
+      const updated = seen ? formatRelativeTime(seen) : "—";
       const powerValue =
         latest.power_usage !== null && latest.power_usage !== undefined
           ? latest.power_usage
@@ -697,11 +860,12 @@ document.addEventListener("DOMContentLoaded", () => {
               <strong>${miner.model?.name || "Unknown model"}</strong>
               <span class="badge ${statusClass}">${statusLabel}</span>
             </div>
+            ${minerLabel(miner)}
             <div class="muted">${miner.ip || "IP unavailable"}</div>
             <div class="muted small">${miner.id}</div>
             ${
-              latest.recorded_at
-                ? `<div class="muted small" title="${latest.recorded_at}">Updated ${updated}</div>`
+              seen
+                ? `<div class="muted small" title="${seen}">Updated ${updated}</div>`
                 : ""
             }
           </div>
@@ -734,6 +898,7 @@ document.addEventListener("DOMContentLoaded", () => {
       const card = document.createElement("div");
       card.className = "card";
       const selectId = `preset-${model.alias}`;
+      const minSelectId = `min-preset-${model.alias}`;
 
       // Create a map of preset -> hashrate for easy lookup
       const presetHashrateMap = {};
@@ -756,7 +921,7 @@ document.addEventListener("DOMContentLoaded", () => {
         <h3>${model.name}</h3>
         <p class="muted">${model.alias}</p>
         <label for="${selectId}">Max Preset</label>
-        <select id="${selectId}" data-alias="${model.alias}">
+        <select id="${selectId}" data-alias="${model.alias}" data-field="max_preset">
           <option value="">-- not set --</option>
           ${model.presets
             .map((preset) => {
@@ -770,6 +935,18 @@ document.addEventListener("DOMContentLoaded", () => {
             })
             .join("")}
         </select>
+        <label for="${minSelectId}">Min Preset</label>
+        <select id="${minSelectId}" data-alias="${model.alias}" data-field="min_preset">
+          <option value="">-- not set --</option>
+          ${model.presets
+            .filter((preset) => preset.toLowerCase() !== "disabled")
+            .map((preset) => {
+              return `<option value="${preset}" ${
+                model.min_preset && model.min_preset === preset ? "selected" : ""
+              }>${preset}</option>`;
+            })
+            .join("")}
+        </select>
         ${disabledPresetExists ? `
           <div class="input-group" style="margin-top: 1rem;">
             <label for="${disabledInputId}">Disabled Preset Power (W):</label>
@@ -804,7 +981,7 @@ document.addEventListener("DOMContentLoaded", () => {
         }
 
         try {
-          await fetchJSON(`/api/models/${alias}`, {
+          await fetchJSON(`${API}/models/${alias}`, {
             method: "PATCH",
             body: JSON.stringify({ disabled_preset_power_w: value }),
           });
@@ -830,11 +1007,14 @@ document.addEventListener("DOMContentLoaded", () => {
     document.getElementById("plant-generation").textContent = status.plant_generation_kw.toFixed(2);
     document.getElementById("container-consumption").textContent = status.plant_container_kw.toFixed(2);
     document.getElementById("available-power").textContent = status.available_power_kw.toFixed(2);
+    document.getElementById("exported-power").textContent =
+      status.exported_kw == null ? "--" : status.exported_kw.toFixed(2);
     document.getElementById("target-power").textContent = status.target_power_kw.toFixed(2);
     document.getElementById("current-consumption").textContent = status.current_consumption_w.toFixed(0);
     document.getElementById("expected-consumption").textContent = status.expected_consumption_w.toFixed(0);
     document.getElementById("managed-count").textContent = status.managed_miners_count;
     document.getElementById("safety-margin-input").value = status.safety_margin_percent;
+    document.getElementById("balance-mode-select").value = status.balance_mode;
 
     // Update last update timestamp
     const lastUpdateEl = document.getElementById("balance-last-update");
@@ -842,6 +1022,39 @@ document.addEventListener("DOMContentLoaded", () => {
       const relativeTime = formatRelativeTime(status.last_reading_at);
       lastUpdateEl.textContent = `Last update: ${relativeTime}`;
     }
+
+    renderContainerReconciliation(status.container_reconciliation);
+  };
+
+  const renderContainerReconciliation = (reconciliation) => {
+    const section = document.getElementById("container-reconciliation");
+    const tbody = document.querySelector("#container-reconciliation-table tbody");
+    if (!section || !tbody) return;
+
+    section.hidden = !reconciliation;
+    if (!reconciliation) return;
+
+    const correction = document.getElementById("reconciliation-correction");
+    correction.textContent =
+      reconciliation.correction_kw == null
+        ? `Alert above ${reconciliation.alert_percent}%`
+        : `Balancer correction: ${reconciliation.correction_kw.toFixed(2)} kW`;
+
+    tbody.innerHTML = "";
+    reconciliation.containers.forEach((rec) => {
+      const row = document.createElement("tr");
+      if (rec.flagged) row.classList.add("flagged");
+      const percent = rec.discrepancy_percent == null ? "—" : rec.discrepancy_percent.toFixed(1);
+      row.innerHTML = `
+        <td>${escapeHTML(rec.container)}</td>
+        <td>${rec.miners}</td>
+        <td>${rec.meter_kw.toFixed(2)}</td>
+        <td>${rec.estimated_kw.toFixed(2)}</td>
+        <td>${rec.discrepancy_kw.toFixed(2)}</td>
+        <td>${percent}</td>
+      `;
+      tbody.appendChild(row);
+    });
   };
 
   const updateEnergyChart = () => {
@@ -948,7 +1161,12 @@ document.addEventListener("DOMContentLoaded", () => {
       const powerChange = `${oldPower}W → ${newPower}W`;
 
       const statusClass = event.success ? "success" : "failure";
-      const statusText = event.success ? "✓" : "✗";
+      let statusText = event.success ? "✓" : "✗";
+      if (!event.success && event.acknowledged) {
+        statusText += ` <span class="tag" title="${escapeHTML(event.note || "")}">ack ${escapeHTML(event.acknowledged_by || "")}</span>`;
+      } else if (!event.success) {
+        statusText += ` <button type="button" class="ack-button" data-event-id="${event.id}">Acknowledge</button>`;
+      }
 
       row.innerHTML = `
         <td>${time}</td>
@@ -1006,8 +1224,9 @@ document.addEventListener("DOMContentLoaded", () => {
   refs.modelsContainer.addEventListener("change", (event) => {
     if (event.target.tagName === "SELECT") {
       const alias = event.target.dataset.alias;
+      const field = event.target.dataset.field || "max_preset";
       const value = event.target.value.trim();
-      updateModelMaxPreset(alias, value, event.target);
+      updateModelPresetBound(alias, field, value, event.target);
     }
   });
 
@@ -1015,23 +1234,82 @@ document.addEventListener("DOMContentLoaded", () => {
     refs.refreshMiners.addEventListener("click", () => fetchMiners());
   }
 
+  let minerSearchTimer = null;
+  if (refs.minerSearch) {
+    refs.minerSearch.addEventListener("input", () => {
+      clearTimeout(minerSearchTimer);
+      minerSearchTimer = setTimeout(() => fetchMiners(true), 300);
+    });
+  }
+  [refs.minerStatus, refs.minerManaged, refs.minerSort].forEach((select) => {
+    if (select) select.addEventListener("change", () => fetchMiners(true));
+  });
+
   const safetyMarginButton = document.getElementById("update-safety-margin");
   if (safetyMarginButton) {
     safetyMarginButton.addEventListener("click", updateSafetyMargin);
   }
 
+  const balanceEventsBody = document.querySelector("#balance-events-table tbody");
+  if (balanceEventsBody) {
+    balanceEventsBody.addEventListener("click", (event) => {
+      const button = event.target.closest(".ack-button");
+      if (button) acknowledgeBalanceEvent(button.dataset.eventId);
+    });
+  }
+
+  const balanceModeSelect = document.getElementById("balance-mode-select");
+  if (balanceModeSelect) {
+    balanceModeSelect.addEventListener("change", updateBalanceMode);
+  }
+
+  const loadAll = () => {
+    fetchMiners();
+    fetchModels();
+    fetchBalanceStatus();
+    fetchPlantHistory();
+    fetchBalanceEvents();
+  };
+
+  if (refs.loginForm) {
+    refs.loginForm.addEventListener("submit", async (event) => {
+      event.preventDefault();
+      const fields = refs.loginForm.elements;
+      refs.loginError.textContent = "";
+      try {
+        const session = await fetchJSON(`${API}/login`, {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify({ username: fields.username.value, password: fields.password.value }),
+        });
+        fields.password.value = "";
+        showSession(session);
+        hideLogin();
+        loadAll();
+      } catch (error) {
+        refs.loginError.textContent = error.message;
+      }
+    });
+  }
+
+  if (refs.logoutButton) {
+    refs.logoutButton.addEventListener("click", async () => {
+      await fetch(`${API}/logout`, { method: "POST", headers: { "X-CSRF-Token": csrfToken || "" } }).catch(() => {});
+      window.location.reload();
+    });
+  }
+
   // Initialize energy chart
   initEnergyChart();
 
   // Initial data fetch
-  fetchMiners();
-  fetchModels();
-  fetchBalanceStatus();
-  fetchPlantHistory();
-  fetchBalanceEvents();
+  loadSession().then((ready) => {
+    if (ready) loadAll();
+  });
 
   // Auto-refresh all data
   setInterval(() => {
+    if (loginVisible()) return;
     fetchMiners(true).catch(() => {});
     fetchBalanceStatus().catch(() => {});
     fetchPlantHistory().catch(() => {});
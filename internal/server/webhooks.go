@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"powerhive/internal/database"
+)
+
+type webhookDeliveryDTO struct {
+	ID            int64           `json:"id"`
+	Endpoint      string          `json:"endpoint"`
+	URL           string          `json:"url"`
+	Event         string          `json:"event"`
+	State         string          `json:"state"`
+	Attempts      int             `json:"attempts"`
+	LastStatus    *int            `json:"last_status"`
+	LastError     *string         `json:"last_error"`
+	NextAttemptAt *string         `json:"next_attempt_at"`
+	CreatedAt     string          `json:"created_at"`
+	DeliveredAt   *string         `json:"delivered_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func toWebhookDeliveryDTO(delivery database.WebhookDelivery) webhookDeliveryDTO {
+	dto := webhookDeliveryDTO{
+		ID:          delivery.ID,
+		Endpoint:    delivery.Endpoint,
+		URL:         delivery.URL,
+		Event:       delivery.Event,
+		State:       delivery.State,
+		Attempts:    delivery.Attempts,
+		LastStatus:  delivery.LastStatus,
+		LastError:   delivery.LastError,
+		CreatedAt:   formatTime(delivery.CreatedAt),
+		DeliveredAt: formatTimePtr(delivery.DeliveredAt),
+		Payload:     json.RawMessage(delivery.Payload),
+	}
+	if delivery.State == database.WebhookPending {
+		dto.NextAttemptAt = formatTimePtr(&delivery.NextAttemptAt)
+	}
+	return dto
+}
+
+// listWebhookDeliveries returns the delivery log, newest first, optionally
+// filtered by state, event and endpoint.
+func (s *Server) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.WebhookDeliveryFilter{
+		State:    query.Get("state"),
+		Event:    query.Get("event"),
+		Endpoint: query.Get("endpoint"),
+		Limit:    100,
+	}
+	switch filter.State {
+	case "", database.WebhookPending, database.WebhookDelivered, database.WebhookFailed:
+	default:
+		writeError(w, http.StatusBadRequest, "state must be pending, delivered or failed")
+		return
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			filter.Limit = parsed
+		}
+	}
+
+	deliveries, err := s.store.ListWebhookDeliveries(r.Context(), filter)
+	if err != nil {
+		s.log.Error("list webhook deliveries failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to fetch webhook deliveries")
+		return
+	}
+
+	out := make([]webhookDeliveryDTO, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		out = append(out, toWebhookDeliveryDTO(delivery))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// retryWebhookDelivery queues a delivery again with a fresh set of
+// attempts, typically a failed one after the receiver is fixed.
+func (s *Server) retryWebhookDelivery(w http.ResponseWriter, r *http.Request, id int64) {
+	delivery, err := s.store.RetryWebhookDelivery(r.Context(), id, time.Now())
+	if err != nil {
+		if isNotFound(err) {
+			writeError(w, http.StatusNotFound, "webhook delivery not found")
+			return
+		}
+		s.log.Error("retry webhook delivery failed", "delivery", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to retry webhook delivery")
+		return
+	}
+	s.log.Info("webhook delivery queued for retry", "delivery", id, "endpoint", delivery.Endpoint)
+	writeJSON(w, http.StatusOK, toWebhookDeliveryDTO(delivery))
+}
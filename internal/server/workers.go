@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"powerhive/internal/database"
+)
+
+type poolWorkerDTO struct {
+	Worker     string  `json:"worker"`
+	MinerID    string  `json:"miner_id"`
+	MinerName  *string `json:"miner_name"`
+	PoolIndex  int     `json:"pool_index"`
+	URL        *string `json:"url"`
+	Status     *string `json:"status"`
+	RecordedAt string  `json:"recorded_at"`
+}
+
+// listPoolWorkers maps the worker names miners report to their pools back
+// to the miners. name filters on the full worker name or the part after
+// the account, so "rack1x05" finds "acme.rack1x05".
+func (s *Server) listPoolWorkers(w http.ResponseWriter, r *http.Request) {
+	workers, err := s.store.ListPoolWorkers(r.Context())
+	if err != nil {
+		s.log.Error("list pool workers failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to list workers")
+		return
+	}
+
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	out := make([]poolWorkerDTO, 0, len(workers))
+	for _, worker := range workers {
+		if name != "" && !workerMatches(worker, name) {
+			continue
+		}
+		out = append(out, poolWorkerDTO{
+			Worker:     worker.Worker,
+			MinerID:    worker.MinerID,
+			MinerName:  worker.MinerName,
+			PoolIndex:  worker.PoolIndex,
+			URL:        worker.URL,
+			Status:     worker.Status,
+			RecordedAt: formatTime(worker.RecordedAt),
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func workerMatches(worker database.PoolWorker, name string) bool {
+	if strings.EqualFold(worker.Worker, name) {
+		return true
+	}
+	_, suffix, ok := strings.Cut(worker.Worker, ".")
+	return ok && strings.EqualFold(suffix, name)
+}
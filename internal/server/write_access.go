@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// nonMutating lists the API writes that change nothing in the plant, so
+// restricted callers may still sign in, plan and query.
+var nonMutating = map[string]bool{
+	"POST /api/login":        true,
+	"POST /api/logout":       true,
+	"POST /api/graphql":      true,
+	"POST /api/balance/plan": true,
+}
+
+// WriteAccess limits API writes to callers from Allow or, with ClientCerts,
+// callers that presented a verified client certificate. Reads stay open.
+type WriteAccess struct {
+	Allow       []netip.Prefix
+	ClientCerts bool
+}
+
+// WithWriteAccess restricts API writes.
+func WithWriteAccess(access WriteAccess) Option {
+	return func(s *Server) {
+		s.writeAccess = &access
+	}
+}
+
+// restrictWrites answers 403 to API writes from callers the write access
+// does not allow.
+func (s *Server) restrictWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.writeAccess == nil || !strings.HasPrefix(r.URL.Path, "/api/") || safeMethod(r.Method) ||
+			nonMutating[r.Method+" "+r.URL.Path] || s.writeAllowed(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		s.log.Warn("api write refused", "method", r.Method, "path", r.URL.Path, "client", clientIP(r))
+		writeError(w, http.StatusForbidden, "changes are only accepted from allowed addresses or with a client certificate")
+	})
+}
+
+func (s *Server) writeAllowed(r *http.Request) bool {
+	if s.writeAccess.ClientCerts && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(clientIP(r))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range s.writeAccess.Allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+// Package snmp implements the parts of SNMP PowerHive uses: v2c GETs for
+// reading PDU outlets and v1/v2c traps sent by switches.
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BER tags used by SNMP.
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+	tagIPAddress   = 0x40
+	tagCounter32   = 0x41
+	tagGauge32     = 0x42
+	tagTimeTicks   = 0x43
+	tagCounter64   = 0x46
+	tagGetRequest  = 0xa0
+	tagGetResponse = 0xa2
+	tagTrapV1      = 0xa4
+	tagTrapV2      = 0xa7
+	versionV1      = 0
+	versionV2c     = 1
+	maxPacketSize  = 65535
+)
+
+// Value is a variable binding's value. Numeric types and numeric strings
+// convert to a number; strings keep their text.
+type Value struct {
+	tag     byte
+	content []byte
+}
+
+// Number returns the value as a number. Strings such as "1234.5" are
+// accepted.
+func (v Value) Number() (float64, bool) {
+	switch v.tag {
+	case tagInteger:
+		return float64(decodeInteger(v.content)), true
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		var n uint64
+		for _, b := range v.content {
+			n = n<<8 | uint64(b)
+		}
+		return float64(n), true
+	case tagOctetString:
+		n, err := strconv.ParseFloat(strings.TrimSpace(string(v.content)), 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// String returns the value as text: strings as they are, OIDs and
+// addresses dotted, numbers in decimal.
+func (v Value) String() string {
+	switch v.tag {
+	case tagOctetString:
+		return string(v.content)
+	case tagOID:
+		return decodeOID(v.content)
+	case tagIPAddress:
+		parts := make([]string, len(v.content))
+		for i, b := range v.content {
+			parts[i] = strconv.Itoa(int(b))
+		}
+		return strings.Join(parts, ".")
+	}
+	if n, ok := v.Number(); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return ""
+}
+
+// readAll reads the first n TLVs of data.
+func readAll(data []byte, n int) ([]Value, error) {
+	values := make([]Value, 0, n)
+	for i := 0; i < n; i++ {
+		tag, content, rest, err := readTLV(data)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, Value{tag: tag, content: content})
+		data = rest
+	}
+	return values, nil
+}
+
+// readBindings decodes a variable binding list into OIDs and values, in
+// order.
+func readBindings(list []byte) ([]Binding, error) {
+	var bindings []Binding
+	for len(list) > 0 {
+		tag, binding, rest, err := readTLV(list)
+		if err != nil || tag != tagSequence {
+			return nil, fmt.Errorf("malformed snmp variable binding")
+		}
+		parts, err := readAll(binding, 2)
+		if err != nil || parts[0].tag != tagOID {
+			return nil, fmt.Errorf("malformed snmp variable binding")
+		}
+		bindings = append(bindings, Binding{OID: decodeOID(parts[0].content), Value: parts[1]})
+		list = rest
+	}
+	return bindings, nil
+}
+
+var errTruncated = errors.New("truncated ber value")
+
+// readTLV splits the first tag-length-value off data.
+func readTLV(data []byte) (tag byte, content, rest []byte, err error) {
+	if len(data) < 2 {
+		return 0, nil, nil, errTruncated
+	}
+	tag = data[0]
+	length := int(data[1])
+	offset := 2
+	if length&0x80 != 0 {
+		size := length & 0x7f
+		if size == 0 || size > 4 || len(data) < 2+size {
+			return 0, nil, nil, errTruncated
+		}
+		length = 0
+		for _, b := range data[2 : 2+size] {
+			length = length<<8 | int(b)
+		}
+		offset += size
+	}
+	if length < 0 || len(data) < offset+length {
+		return 0, nil, nil, errTruncated
+	}
+	return tag, data[offset : offset+length], data[offset+length:], nil
+}
+
+func tlv(tag byte, content []byte) []byte {
+	out := []byte{tag}
+	switch n := len(content); {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n <= 0xff:
+		out = append(out, 0x81, byte(n))
+	default:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	}
+	return append(out, content...)
+}
+
+// encodeInteger encodes v in the fewest two's complement bytes.
+func encodeInteger(v int64) []byte {
+	out := []byte{byte(v)}
+	for {
+		next := v >> 8
+		// Stop once the remaining bytes only repeat the sign bit.
+		if (next == 0 && out[0]&0x80 == 0) || (next == -1 && out[0]&0x80 != 0) {
+			return out
+		}
+		v = next
+		out = append([]byte{byte(v)}, out...)
+	}
+}
+
+func decodeInteger(content []byte) int64 {
+	var v int64
+	for i, b := range content {
+		if i == 0 && b&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+// CanonicalOID returns oid the way agents spell it, without a leading dot
+// or leading zeros in its arcs. An invalid OID is returned unchanged.
+func CanonicalOID(oid string) string {
+	oid = strings.TrimPrefix(strings.TrimSpace(oid), ".")
+	encoded, err := encodeOID(oid)
+	if err != nil {
+		return oid
+	}
+	return decodeOID(encoded)
+}
+
+// encodeOID encodes a dotted OID such as 1.3.6.1.2.1.1.3.0.
+func encodeOID(oid string) ([]byte, error) {
+	parts := strings.Split(oid, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid oid %q", oid)
+	}
+	arcs := make([]uint64, len(parts))
+	for i, part := range parts {
+		arc, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid oid %q", oid)
+		}
+		arcs[i] = arc
+	}
+	if arcs[0] > 2 || (arcs[0] < 2 && arcs[1] >= 40) {
+		return nil, fmt.Errorf("invalid oid %q", oid)
+	}
+
+	out := encodeArc(nil, arcs[0]*40+arcs[1])
+	for _, arc := range arcs[2:] {
+		out = encodeArc(out, arc)
+	}
+	return out, nil
+}
+
+// encodeArc appends arc in base 128, high groups first.
+func encodeArc(out []byte, arc uint64) []byte {
+	var groups []byte
+	groups = append(groups, byte(arc&0x7f))
+	for arc >>= 7; arc > 0; arc >>= 7 {
+		groups = append(groups, byte(arc&0x7f)|0x80)
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		out = append(out, groups[i])
+	}
+	return out
+}
+
+func decodeOID(content []byte) string {
+	var (
+		parts []string
+		arc   uint64
+	)
+	for _, b := range content {
+		arc = arc<<7 | uint64(b&0x7f)
+		if b&0x80 != 0 {
+			continue
+		}
+		if parts == nil {
+			first := min(arc/40, 2)
+			parts = append(parts, strconv.FormatUint(first, 10), strconv.FormatUint(arc-first*40, 10))
+		} else {
+			parts = append(parts, strconv.FormatUint(arc, 10))
+		}
+		arc = 0
+	}
+	return strings.Join(parts, ".")
+}
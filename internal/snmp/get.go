@@ -0,0 +1,168 @@
+package snmp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// maxOIDsPerRequest keeps a GetRequest small enough for the agents of
+// common PDUs.
+const maxOIDsPerRequest = 16
+
+// Binding is a variable binding: an OID and its value.
+type Binding struct {
+	OID   string
+	Value Value
+}
+
+// Get reads oids from the agent at address with SNMPv2c GetRequests and
+// returns their numeric values by canonical OID. OIDs the agent has no
+// value for, such as noSuchInstance, are left out. Each request gives up
+// after timeout.
+func Get(ctx context.Context, address, community string, oids []string, timeout time.Duration) (map[string]float64, error) {
+	values := make(map[string]float64, len(oids))
+	for start := 0; start < len(oids); start += maxOIDsPerRequest {
+		bindings, err := get(ctx, address, community, oids[start:min(start+maxOIDsPerRequest, len(oids))], timeout)
+		if err != nil {
+			return nil, err
+		}
+		for _, binding := range bindings {
+			if value, ok := binding.Value.Number(); ok {
+				values[binding.OID] = value
+			}
+		}
+	}
+	return values, nil
+}
+
+// get sends one GetRequest and returns the bindings of its response.
+func get(ctx context.Context, address, community string, oids []string, timeout time.Duration) ([]Binding, error) {
+	requestID, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+	packet, err := encodeGetRequest(community, requestID, oids)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("dial snmp agent: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("set snmp deadline: %w", err)
+	}
+	if _, err := conn.Write(packet); err != nil {
+		return nil, fmt.Errorf("send snmp request: %w", err)
+	}
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("read snmp response: %w", err)
+		}
+		id, bindings, err := decodeGetResponse(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		// A late answer to an earlier request; keep waiting for ours.
+		if id != requestID {
+			continue
+		}
+		return bindings, nil
+	}
+}
+
+func newRequestID() (int32, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, fmt.Errorf("generate snmp request id: %w", err)
+	}
+	return int32(binary.BigEndian.Uint32(b[:]) & 0x7fffffff), nil
+}
+
+// encodeGetRequest builds an SNMPv2c GetRequest message for oids.
+func encodeGetRequest(community string, requestID int32, oids []string) ([]byte, error) {
+	var bindings []byte
+	for _, oid := range oids {
+		encoded, err := encodeOID(oid)
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, tlv(tagSequence, append(tlv(tagOID, encoded), tagNull, 0))...)
+	}
+
+	var pdu []byte
+	pdu = append(pdu, tlv(tagInteger, encodeInteger(int64(requestID)))...)
+	pdu = append(pdu, tlv(tagInteger, encodeInteger(0))...)
+	pdu = append(pdu, tlv(tagInteger, encodeInteger(0))...)
+	pdu = append(pdu, tlv(tagSequence, bindings)...)
+
+	var message []byte
+	message = append(message, tlv(tagInteger, encodeInteger(versionV2c))...)
+	message = append(message, tlv(tagOctetString, []byte(community))...)
+	message = append(message, tlv(tagGetRequest, pdu)...)
+	return tlv(tagSequence, message), nil
+}
+
+// decodeGetResponse returns the request ID of a GetResponse and its
+// bindings.
+func decodeGetResponse(packet []byte) (int32, []Binding, error) {
+	_, _, pduValue, err := decodeMessage(packet)
+	if err != nil {
+		return 0, nil, err
+	}
+	if pduValue.tag != tagGetResponse {
+		return 0, nil, fmt.Errorf("unexpected snmp pdu type 0x%02x", pduValue.tag)
+	}
+
+	pdu, err := readAll(pduValue.content, 4)
+	if err != nil {
+		return 0, nil, fmt.Errorf("malformed snmp response: %w", err)
+	}
+	requestID := int32(decodeInteger(pdu[0].content))
+	if status := decodeInteger(pdu[1].content); status != 0 {
+		return requestID, nil, fmt.Errorf("snmp agent returned error status %d at binding %d", status, decodeInteger(pdu[2].content))
+	}
+	bindings, err := readBindings(pdu[3].content)
+	if err != nil {
+		return requestID, nil, err
+	}
+	return requestID, bindings, nil
+}
+
+// decodeMessage splits an SNMP message into its version, community and
+// PDU.
+func decodeMessage(packet []byte) (int64, string, Value, error) {
+	tag, message, _, err := readTLV(packet)
+	if err != nil || tag != tagSequence {
+		return 0, "", Value{}, fmt.Errorf("malformed snmp message")
+	}
+	fields, err := readAll(message, 3)
+	if err != nil {
+		return 0, "", Value{}, fmt.Errorf("malformed snmp message: %w", err)
+	}
+	return decodeInteger(fields[0].content), string(fields[1].content), fields[2], nil
+}
+
+// WithDefaultPort adds port to address unless it already names one.
+func WithDefaultPort(address, port string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(strings.Trim(address, "[]"), port)
+}
@@ -0,0 +1,76 @@
+package snmp
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Standard trap OIDs (RFC 3418, RFC 2863).
+const (
+	oidSnmpTrapOID            = "1.3.6.1.6.3.1.1.4.1.0"
+	oidGenericTraps           = "1.3.6.1.6.3.1.1.5"
+	TrapLinkDown              = oidGenericTraps + ".3"
+	TrapLinkUp                = oidGenericTraps + ".4"
+	genericEnterpriseSpecific = 6
+)
+
+// Trap is a received v1 or v2c trap. v1 traps are translated to the v2
+// form: TrapOID is set from the generic or enterprise-specific trap number
+// (RFC 3584).
+type Trap struct {
+	Version   int
+	Community string
+	// AgentAddr is the address the agent reported in a v1 trap, empty for
+	// v2c.
+	AgentAddr string
+	TrapOID   string
+	Bindings  []Binding
+}
+
+// ParseTrap decodes a v1 Trap-PDU or a v2c SNMPv2-Trap-PDU.
+func ParseTrap(packet []byte) (Trap, error) {
+	version, community, pduValue, err := decodeMessage(packet)
+	if err != nil {
+		return Trap{}, err
+	}
+	trap := Trap{Version: int(version) + 1, Community: community}
+
+	switch {
+	case version == versionV1 && pduValue.tag == tagTrapV1:
+		fields, err := readAll(pduValue.content, 6)
+		if err != nil {
+			return Trap{}, fmt.Errorf("malformed snmp trap: %w", err)
+		}
+		enterprise := decodeOID(fields[0].content)
+		trap.AgentAddr = fields[1].String()
+		generic := decodeInteger(fields[2].content)
+		if generic == genericEnterpriseSpecific {
+			trap.TrapOID = enterprise + ".0." + strconv.FormatInt(decodeInteger(fields[3].content), 10)
+		} else {
+			trap.TrapOID = oidGenericTraps + "." + strconv.FormatInt(generic+1, 10)
+		}
+		if trap.Bindings, err = readBindings(fields[5].content); err != nil {
+			return Trap{}, err
+		}
+	case version == versionV2c && pduValue.tag == tagTrapV2:
+		fields, err := readAll(pduValue.content, 4)
+		if err != nil {
+			return Trap{}, fmt.Errorf("malformed snmp trap: %w", err)
+		}
+		if trap.Bindings, err = readBindings(fields[3].content); err != nil {
+			return Trap{}, err
+		}
+		for _, binding := range trap.Bindings {
+			if binding.OID == oidSnmpTrapOID {
+				trap.TrapOID = binding.Value.String()
+				break
+			}
+		}
+		if trap.TrapOID == "" {
+			return Trap{}, fmt.Errorf("snmp trap has no snmpTrapOID")
+		}
+	default:
+		return Trap{}, fmt.Errorf("unsupported snmp version %d pdu type 0x%02x", version, pduValue.tag)
+	}
+	return trap, nil
+}
@@ -0,0 +1,164 @@
+// Package telegram is a minimal client for the Telegram Bot API: long
+// polling for messages and sending replies.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the public Bot API.
+const DefaultAPIURL = "https://api.telegram.org"
+
+// maxMessageLength is the longest text sendMessage accepts.
+const maxMessageLength = 4096
+
+// Client calls the Bot API for one bot.
+type Client struct {
+	token   string
+	baseURL string
+	http    *http.Client
+}
+
+// New returns a client for the bot with token. An empty baseURL uses
+// DefaultAPIURL.
+func New(token, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		token:   token,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		// Long polls hold the request open; the context bounds each call.
+		http: &http.Client{},
+	}
+}
+
+// Update is an incoming update. Only messages are requested.
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// Message is a chat message.
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	From      *User  `json:"from"`
+	Text      string `json:"text"`
+}
+
+// Chat identifies the conversation a message belongs to.
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+// User is the sender of a message.
+type User struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+}
+
+// Name returns the user's @username, or first name without one.
+func (u *User) Name() string {
+	if u == nil {
+		return ""
+	}
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	return u.FirstName
+}
+
+type response struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+// GetUpdates waits up to timeout for messages after offset, the last seen
+// update ID plus one.
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout+10*time.Second)
+	defer cancel()
+
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+// SendMessage posts plain text to a chat, cut to the API's length limit.
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	if len(text) > maxMessageLength {
+		text = text[:maxMessageLength-3] + "..."
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	return c.call(ctx, "sendMessage", map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	}, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, params any, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/bot"+c.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// The URL carries the token; keep it out of errors and logs.
+		return fmt.Errorf("%s: %w", method, redact(err, c.token))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return fmt.Errorf("read %s response: %w", method, err)
+	}
+	var out response
+	if err := json.Unmarshal(data, &out); err != nil {
+		return fmt.Errorf("decode %s response (HTTP %d): %w", method, resp.StatusCode, err)
+	}
+	if !out.OK {
+		return fmt.Errorf("%s: %s", method, out.Description)
+	}
+	if result != nil {
+		if err := json.Unmarshal(out.Result, result); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e redactedError) Error() string { return e.msg }
+func (e redactedError) Unwrap() error { return e.err }
+
+func redact(err error, token string) error {
+	if token == "" || !strings.Contains(err.Error(), token) {
+		return err
+	}
+	return redactedError{msg: strings.ReplaceAll(err.Error(), token, "<token>"), err: err}
+}
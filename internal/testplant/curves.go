@@ -0,0 +1,139 @@
+package testplant
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Curve gives the plant's generation in MW at a time since the server
+// started. The server calls it with non-decreasing times.
+type Curve interface {
+	GenerationMW(elapsed time.Duration) float64
+}
+
+// Walk drifts generation by up to Step MW every Interval, staying within
+// [Min, Max], the way the Python test server does. It starts at a random
+// point in the range.
+type Walk struct {
+	Min, Max float64
+	Step     float64
+	Interval time.Duration
+
+	started bool
+	steps   int64
+	value   float64
+}
+
+// GenerationMW advances the walk by every interval that has passed.
+func (w *Walk) GenerationMW(elapsed time.Duration) float64 {
+	if !w.started {
+		w.started = true
+		w.value = w.Min + rand.Float64()*(w.Max-w.Min)
+	}
+	if w.Interval <= 0 {
+		return w.value
+	}
+	for due := int64(elapsed / w.Interval); w.steps < due; w.steps++ {
+		w.value += (rand.Float64()*2 - 1) * w.Step
+		w.value = math.Max(w.Min, math.Min(w.Max, w.value))
+	}
+	return w.value
+}
+
+// Sine swings generation between Min and Max once per Period, starting at
+// the midpoint and rising.
+type Sine struct {
+	Min, Max float64
+	Period   time.Duration
+}
+
+// GenerationMW returns the point on the wave at elapsed.
+func (s Sine) GenerationMW(elapsed time.Duration) float64 {
+	if s.Period <= 0 {
+		return (s.Min + s.Max) / 2
+	}
+	phase := 2 * math.Pi * float64(elapsed%s.Period) / float64(s.Period)
+	return s.Min + (s.Max-s.Min)*(1+math.Sin(phase))/2
+}
+
+// ScriptPoint is the generation a script reaches at At.
+type ScriptPoint struct {
+	At time.Duration
+	MW float64
+}
+
+// Script interpolates linearly between its points and starts over after
+// the last one, so a ramp, a step or a whole day can be replayed on repeat.
+// Hold a level by repeating it at two times; a step is two points a
+// moment apart.
+type Script struct {
+	Points []ScriptPoint
+}
+
+// ParseScript reads a script written as comma-separated time=MW pairs, for
+// example "0s=0.10,10m=0.30,15m=0.30,16m=0.05". Times are Go durations from
+// the start and are sorted.
+func ParseScript(raw string) (Script, error) {
+	var script Script
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		at, mw, ok := strings.Cut(pair, "=")
+		if !ok {
+			return Script{}, fmt.Errorf("script point %q: want time=MW", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(at))
+		if err != nil {
+			return Script{}, fmt.Errorf("script point %q: %w", pair, err)
+		}
+		if d < 0 {
+			return Script{}, fmt.Errorf("script point %q: time must not be negative", pair)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(mw), 64)
+		if err != nil {
+			return Script{}, fmt.Errorf("script point %q: %w", pair, err)
+		}
+		if v < 0 {
+			return Script{}, fmt.Errorf("script point %q: generation must not be negative", pair)
+		}
+		script.Points = append(script.Points, ScriptPoint{At: d, MW: v})
+	}
+	if len(script.Points) == 0 {
+		return Script{}, fmt.Errorf("script has no points")
+	}
+	sort.SliceStable(script.Points, func(i, j int) bool { return script.Points[i].At < script.Points[j].At })
+	return script, nil
+}
+
+// GenerationMW returns the interpolated generation at elapsed.
+func (s Script) GenerationMW(elapsed time.Duration) float64 {
+	points := s.Points
+	if len(points) == 0 {
+		return 0
+	}
+	if period := points[len(points)-1].At; period > 0 {
+		elapsed %= period
+	}
+	if elapsed <= points[0].At {
+		return points[0].MW
+	}
+	for i := 1; i < len(points); i++ {
+		prev, next := points[i-1], points[i]
+		if elapsed > next.At {
+			continue
+		}
+		if next.At == prev.At {
+			return next.MW
+		}
+		frac := float64(elapsed-prev.At) / float64(next.At-prev.At)
+		return prev.MW + (next.MW-prev.MW)*frac
+	}
+	return points[len(points)-1].MW
+}
@@ -0,0 +1,191 @@
+// Package testplant serves a stand-in for the plant energy API, so test mode
+// and end-to-end runs need no external server. It answers GET /data/latest
+// in the aggregator's format with generation from a Curve, and takes the
+// balancer's expected consumption on POST /data/consumption.
+package testplant
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"powerhive/internal/app"
+	"powerhive/internal/clock"
+)
+
+// Source names used to split the totals, two of each like the real plant.
+var (
+	generatorNames = [2]string{"generator_1", "generator_2"}
+	containerNames = [2]string{"container_1", "container_2"}
+)
+
+// Options configures a Server. Token, when set, must be sent as a bearer
+// token; PlantID, when set, must match the plant_id query parameter.
+type Options struct {
+	PlantID string
+	Token   string
+	Curve   Curve
+	// Clock defaults to clock.Real.
+	Clock  clock.Clock
+	Logger *slog.Logger
+}
+
+// Server is the test plant's HTTP API.
+type Server struct {
+	opts  Options
+	log   *slog.Logger
+	clock clock.Clock
+	start time.Time
+
+	mu            sync.Mutex
+	consumptionMW float64
+	readings      int
+}
+
+// New returns a test plant whose curve starts now.
+func New(opts Options) *Server {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Curve == nil {
+		opts.Curve = &Walk{Min: 0.05, Max: 0.15, Step: 0.002, Interval: 30 * time.Second}
+	}
+	return &Server{
+		opts:  opts,
+		log:   opts.Logger.With("component", "testplant"),
+		clock: opts.Clock,
+		start: opts.Clock.Now(),
+	}
+}
+
+// Handler routes the test plant's endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /data/latest", s.handleLatest)
+	mux.HandleFunc("POST /data/consumption", s.handleConsumption)
+	mux.HandleFunc("GET /health", s.handleHealth)
+	return mux
+}
+
+// state returns the generation now and the last posted consumption.
+func (s *Server) state() (time.Time, float64, float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	return now, s.opts.Curve.GenerationMW(now.Sub(s.start)), s.consumptionMW
+}
+
+func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "missing or invalid authorization header")
+			return
+		}
+		if token != s.opts.Token {
+			writeError(w, http.StatusUnauthorized, "invalid API token")
+			return
+		}
+	}
+	plantID := r.URL.Query().Get("plant_id")
+	if s.opts.PlantID != "" && plantID != s.opts.PlantID {
+		writeError(w, http.StatusBadRequest, "invalid plant_id")
+		return
+	}
+
+	now, generationMW, consumptionMW := s.state()
+	s.mu.Lock()
+	s.readings++
+	id := s.readings
+	s.mu.Unlock()
+
+	exportedMW := generationMW - consumptionMW
+	now = now.UTC()
+	writeJSON(w, http.StatusOK, app.PlantAPIResponse{
+		Reading: app.PlantDataReading{
+			ID:                  id,
+			PlantID:             plantID,
+			CollectionTimestamp: now,
+			Generation:          splitSources(generatorNames, generationMW, now),
+			Consumption:         splitSources(containerNames, consumptionMW, now),
+			Totals: app.PlantTotals{
+				GenerationMW:  generationMW,
+				ConsumptionMW: consumptionMW,
+				ExportedMW:    &exportedMW,
+			},
+			Trust: app.TrustInfo{
+				ConfidenceScore: 1,
+				Status:          "trusted",
+				Summary:         "test plant",
+			},
+		},
+	})
+}
+
+// splitSources divides total between two sources, roughly evenly with a
+// little jitter like a real plant.
+func splitSources(names [2]string, total float64, at time.Time) map[string]app.SourceReading {
+	share := 0.45 + rand.Float64()*0.1
+	return map[string]app.SourceReading{
+		names[0]: {SourceTimestamp: at, Status: "success", ValueMW: total * share},
+		names[1]: {SourceTimestamp: at, Status: "success", ValueMW: total * (1 - share)},
+	}
+}
+
+type consumptionRequest struct {
+	ExpectedConsumptionMW *float64 `json:"expected_consumption_mw"`
+}
+
+func (s *Server) handleConsumption(w http.ResponseWriter, r *http.Request) {
+	var req consumptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+		return
+	}
+	if req.ExpectedConsumptionMW == nil {
+		writeError(w, http.StatusBadRequest, "missing expected_consumption_mw field")
+		return
+	}
+	if *req.ExpectedConsumptionMW < 0 {
+		writeError(w, http.StatusBadRequest, "consumption must be non-negative")
+		return
+	}
+
+	s.mu.Lock()
+	s.consumptionMW = *req.ExpectedConsumptionMW
+	s.mu.Unlock()
+
+	s.log.Info("consumption set", "consumption_mw", *req.ExpectedConsumptionMW)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":         "ok",
+		"consumption_mw": *req.ExpectedConsumptionMW,
+	})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	_, generationMW, consumptionMW := s.state()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":              "ok",
+		"current_generation":  generationMW,
+		"current_consumption": consumptionMW,
+		"consumption_source":  "external",
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
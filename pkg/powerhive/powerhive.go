@@ -0,0 +1,310 @@
+// Package powerhive embeds the PowerHive engine — discovery, polling, power
+// balancing and the dashboard — in another Go program.
+//
+//	engine, err := powerhive.New(
+//		powerhive.WithConfigFile("/etc/powerhive/config.json"),
+//		powerhive.WithLogger(logger),
+//		powerhive.WithoutServices(powerhive.ServiceFirmwareUpdater),
+//		powerhive.WithoutHTTP(),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer engine.Close()
+//	mux.Handle("/powerhive/", engine.Handler())
+//	return engine.Run(ctx)
+//
+// With http.base_path set to the mount point, here /powerhive, the
+// dashboard's links and API calls carry it.
+//
+// The configuration and store types are those of the powerhive daemon, so a
+// config.json written for it works unchanged.
+package powerhive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"powerhive/internal/app"
+	"powerhive/internal/config"
+	"powerhive/internal/database"
+	"powerhive/internal/logging"
+)
+
+// Config is the engine configuration, as read from config.json.
+type Config = config.AppConfig
+
+// Store is the database the engine reads and writes.
+type Store = database.Store
+
+// LogLevels adjusts log levels at runtime; see NewLogger.
+type LogLevels = logging.Levels
+
+// Background services, for WithServices and WithoutServices.
+const (
+	ServiceDiscovery       = app.ServiceDiscovery
+	ServiceStatus          = app.ServiceStatus
+	ServiceTelemetry       = app.ServiceTelemetry
+	ServicePlantPoller     = app.ServicePlantPoller
+	ServicePowerBalancer   = app.ServicePowerBalancer
+	ServiceFirmwareUpdater = app.ServiceFirmwareUpdater
+	ServiceProfileRollout  = app.ServiceProfileRollout
+	ServiceBackup          = app.ServiceBackup
+	ServiceEconomics       = app.ServiceEconomics
+	ServiceWatchdog        = app.ServiceWatchdog
+	ServiceDrift           = app.ServiceDrift
+	ServiceMaintenance     = app.ServiceMaintenance
+	ServiceWebhooks        = app.ServiceWebhooks
+	ServiceTelegram        = app.ServiceTelegram
+)
+
+// LoadConfig reads a configuration file, applying defaults and
+// environment overrides.
+func LoadConfig(path string) (Config, error) {
+	return config.Load(path)
+}
+
+// NewLogger builds the logger the daemon uses from the logging section of
+// cfg. Pass the levels to WithLogLevels so the log level API and reloads
+// work, and close the closer on exit.
+func NewLogger(cfg Config) (*slog.Logger, *LogLevels, io.Closer, error) {
+	return logging.New(cfg.Logging)
+}
+
+// OpenStore connects to the configured database and checks that it
+// answers. The returned function closes the store and its connection pool.
+// Run Migrate before the store is used.
+func OpenStore(ctx context.Context, cfg Config) (*Store, func(), error) {
+	dialect, err := database.ParseDialect(cfg.Database.Driver)
+	if err != nil {
+		return nil, nil, fmt.Errorf("select database driver: %w", err)
+	}
+
+	db, err := database.Open(dialect, cfg.Database.Source(), database.PoolOptions{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(cfg.Database.ConnMaxLifetimeSeconds) * time.Second,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("ping database: %w", err)
+	}
+
+	store, err := database.New(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("configure database: %w", err)
+	}
+	return store, func() {
+		store.Close()
+		db.Close()
+	}, nil
+}
+
+// Migrate installs or upgrades the schema and, when an encryption key is
+// configured, enables credential encryption.
+func Migrate(ctx context.Context, store *Store, cfg Config, logger *slog.Logger) error {
+	if err := store.Init(ctx); err != nil {
+		return fmt.Errorf("initialise schema: %w", err)
+	}
+	if cfg.Database.EncryptionKey != "" {
+		if err := store.EnableEncryption(ctx, cfg.Database.EncryptionKey); err != nil {
+			return fmt.Errorf("enable credential encryption: %w", err)
+		}
+		if logger != nil {
+			logger.Info("credential encryption enabled")
+		}
+	}
+	return nil
+}
+
+// Option configures an Engine built by New.
+type Option func(*options)
+
+type options struct {
+	cfg        *Config
+	configPath string
+	loadConfig func() (Config, error)
+	store      *Store
+	logger     *slog.Logger
+	logLevels  *LogLevels
+	app        []app.Option
+}
+
+// WithConfig runs the engine with cfg. ReloadConfig needs WithConfigFile or
+// WithConfigLoader as well.
+func WithConfig(cfg Config) Option {
+	return func(o *options) {
+		o.cfg = &cfg
+	}
+}
+
+// WithConfigFile reads the configuration from path, and again on each
+// ReloadConfig.
+func WithConfigFile(path string) Option {
+	return func(o *options) {
+		o.configPath = path
+	}
+}
+
+// WithConfigLoader sets how ReloadConfig reads the configuration, for
+// callers that apply their own overrides. Without WithConfig or
+// WithConfigFile it also supplies the initial configuration.
+func WithConfigLoader(load func() (Config, error)) Option {
+	return func(o *options) {
+		o.loadConfig = load
+	}
+}
+
+// WithStore runs the engine on an open store instead of opening the
+// configured database. The caller migrates and closes it.
+func WithStore(store *Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithLogger sets the logger; the default is slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithLogLevels connects the log level API and configuration reloads to
+// levels from NewLogger.
+func WithLogLevels(levels *LogLevels) Option {
+	return func(o *options) {
+		o.logLevels = levels
+	}
+}
+
+// WithServices starts only the named background services.
+func WithServices(names ...string) Option {
+	return func(o *options) {
+		o.app = append(o.app, app.WithServices(names...))
+	}
+}
+
+// WithoutServices keeps the named background services from starting.
+func WithoutServices(names ...string) Option {
+	return func(o *options) {
+		o.app = append(o.app, app.WithoutServices(names...))
+	}
+}
+
+// WithoutHTTP keeps the engine from listening on http.addr; mount Handler
+// on your own server instead.
+func WithoutHTTP() Option {
+	return func(o *options) {
+		o.app = append(o.app, app.WithoutHTTP())
+	}
+}
+
+// Engine is a configured PowerHive instance.
+type Engine struct {
+	app        *app.App
+	store      *Store
+	closeStore func()
+}
+
+// New builds an engine. It needs a configuration from WithConfig,
+// WithConfigFile or WithConfigLoader. Without WithStore it opens and
+// migrates the configured database, which Close closes again.
+func New(opts ...Option) (*Engine, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.loadConfig == nil && o.configPath != "" {
+		path := o.configPath
+		o.loadConfig = func() (Config, error) { return config.Load(path) }
+	}
+	var cfg Config
+	switch {
+	case o.cfg != nil:
+		cfg = *o.cfg
+	case o.loadConfig != nil:
+		loaded, err := o.loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	default:
+		return nil, errors.New("powerhive: no configuration; use WithConfig, WithConfigFile or WithConfigLoader")
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	e := &Engine{store: o.store}
+	if e.store == nil {
+		ctx := context.Background()
+		store, closeStore, err := OpenStore(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := Migrate(ctx, store, cfg, logger); err != nil {
+			closeStore()
+			return nil, err
+		}
+		e.store, e.closeStore = store, closeStore
+	}
+
+	a, err := app.New(cfg, e.store, logger, o.app...)
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+	if o.loadConfig != nil {
+		a.SetConfigLoader(o.loadConfig)
+	}
+	if o.logLevels != nil {
+		a.SetLogLevels(o.logLevels)
+	}
+	e.app = a
+	return e, nil
+}
+
+// Run starts the services, and the HTTP server unless WithoutHTTP was
+// given, and blocks until ctx is cancelled or the server fails.
+func (e *Engine) Run(ctx context.Context) error {
+	return e.app.Run(ctx)
+}
+
+// Handler returns the dashboard and API.
+func (e *Engine) Handler() http.Handler {
+	return e.app.Handler()
+}
+
+// Store returns the store the engine runs on.
+func (e *Engine) Store() *Store {
+	return e.store
+}
+
+// ReloadConfig re-reads the configuration and hands it to the running
+// services, as SIGHUP does for the daemon.
+func (e *Engine) ReloadConfig() error {
+	return e.app.ReloadConfig()
+}
+
+// Close closes the store if New opened it. Call it after Run returns.
+func (e *Engine) Close() error {
+	if e.closeStore != nil {
+		e.closeStore()
+		e.closeStore = nil
+	}
+	return nil
+}